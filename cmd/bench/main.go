@@ -0,0 +1,439 @@
+// Command bench generates load against a running serverless Redis proxy
+// and reports the latency percentiles and throughput of each operation.
+//
+// The workload is a weighted mix of operations (-mix) on -keys keys, with
+// -size byte values, run by -concurrency workers for -duration or until
+// -requests have been sent, optionally capped at -rate requests a second:
+//
+//	bench -target http://localhost:8080 -mix GET:80,SET:20 -concurrency 32 -duration 30s
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// preloadBatch is how many keys are written per pipeline when preloading.
+const preloadBatch = 100
+
+// operations are the operations a workload can mix, and the requests they
+// send for the n-th key.
+var operations = map[string]func(w *workload, n int, rng *rand.Rand) (string, interface{}){
+	"GET": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("GET", w.key(n))
+	},
+	"SET": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("SET", w.key(n), w.value)
+	},
+	"DEL": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("DEL", w.key(n))
+	},
+	"EXISTS": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("EXISTS", w.key(n))
+	},
+	"INCR": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("INCR", w.prefix+"counter:"+strconv.Itoa(n))
+	},
+	"HGET": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("HGET", w.hashKey(n), w.field(n))
+	},
+	"HSET": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		return "/v1/command", w.command("HSET", w.hashKey(n), w.field(n), w.value)
+	},
+	"MGET": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		args := make([]interface{}, w.batch)
+		for i := range args {
+			args[i] = w.key(rng.Intn(w.keys))
+		}
+		return "/v1/command", w.command("MGET", args...)
+	},
+	"PIPELINE": func(w *workload, n int, rng *rand.Rand) (string, interface{}) {
+		req := types.PipelineRequest{DB: w.db, Commands: make([]types.CommandRequest, w.batch)}
+		for i := range req.Commands {
+			key := w.key(rng.Intn(w.keys))
+			if i%2 == 0 {
+				req.Commands[i] = types.CommandRequest{Command: "GET", Args: []interface{}{key}}
+			} else {
+				req.Commands[i] = types.CommandRequest{Command: "SET", Args: []interface{}{key, w.value}}
+			}
+		}
+		return "/v1/pipeline", req
+	},
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:8080", "base URL of the proxy")
+	token := flag.String("token", os.Getenv("SR_TOKEN"), "API key, or JWT sent as a bearer token (defaults to $SR_TOKEN)")
+	mix := flag.String("mix", "GET:80,SET:20", "weighted operations, from "+strings.Join(operationNames(), ", "))
+	keys := flag.Int("keys", 10000, "number of distinct keys")
+	prefix := flag.String("prefix", "bench:", "prefix of the keys written")
+	size := flag.Int("size", 100, "value size in bytes")
+	batch := flag.Int("batch", 10, "keys per MGET and commands per PIPELINE")
+	db := flag.Int("db", 0, "Redis database")
+	concurrency := flag.Int("concurrency", 16, "requests in flight at once")
+	duration := flag.Duration("duration", 10*time.Second, "how long to run")
+	requests := flag.Int64("requests", 0, "stop after this many requests, 0 to run for -duration")
+	rate := flag.Float64("rate", 0, "requests a second across all workers, 0 for as many as possible")
+	preload := flag.Bool("preload", true, "write every key and hash field before measuring")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	ops, err := parseMix(*mix)
+	if err != nil {
+		fail(err.Error())
+	}
+	if *keys < 1 || *size < 0 || *batch < 1 || *concurrency < 1 || *duration <= 0 || *requests < 0 || *rate < 0 {
+		fail("-keys, -batch, -concurrency and -duration must be positive, and -size, -requests and -rate not negative")
+	}
+
+	w := &workload{
+		client: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{MaxIdleConns: *concurrency, MaxIdleConnsPerHost: *concurrency},
+		},
+		target: strings.TrimSuffix(*target, "/"),
+		token:  *token,
+		ops:    ops,
+		keys:   *keys,
+		prefix: *prefix,
+		value:  strings.Repeat("x", *size),
+		batch:  *batch,
+		db:     *db,
+	}
+	for _, op := range ops {
+		w.totalWeight += op.weight
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *preload {
+		if err := w.preload(ctx); err != nil {
+			fail("preloading: " + err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	report := w.run(ctx, *concurrency, *requests, *rate)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+		return
+	}
+	fmt.Printf("%s: %d workers, mix %s, %d-byte values over %d keys\n\n", w.target, *concurrency, *mix, *size, *keys)
+	report.print(os.Stdout)
+}
+
+// operation is one entry of a workload mix.
+type operation struct {
+	name   string
+	weight int
+}
+
+// parseMix parses a mix such as "GET:80,SET:20"; an operation without a
+// weight has weight 1.
+func parseMix(mix string) ([]operation, error) {
+	var ops []operation
+	for _, entry := range strings.Split(mix, ",") {
+		name, weight, hasWeight := strings.Cut(strings.TrimSpace(entry), ":")
+		name = strings.ToUpper(name)
+		if _, ok := operations[name]; !ok {
+			return nil, fmt.Errorf("unknown operation %q in -mix, expected one of %s", name, strings.Join(operationNames(), ", "))
+		}
+		op := operation{name: name, weight: 1}
+		if hasWeight {
+			n, err := strconv.Atoi(weight)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s in -mix", weight, name)
+			}
+			op.weight = n
+		}
+		if op.weight > 0 {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("-mix has no operations")
+	}
+	return ops, nil
+}
+
+func operationNames() []string {
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// workload sends the requests of a benchmark.
+type workload struct {
+	client      *http.Client
+	target      string
+	token       string
+	ops         []operation
+	totalWeight int
+	keys        int
+	prefix      string
+	value       string
+	batch       int
+	db          int
+}
+
+func (w *workload) key(n int) string {
+	return w.prefix + "key:" + strconv.Itoa(n)
+}
+
+// hashKey and field spread hash fields over a tenth as many hashes as keys.
+func (w *workload) hashKey(n int) string {
+	return w.prefix + "hash:" + strconv.Itoa(n/10)
+}
+
+func (w *workload) field(n int) string {
+	return "f" + strconv.Itoa(n%10)
+}
+
+func (w *workload) command(name string, args ...interface{}) types.CommandRequest {
+	return types.CommandRequest{Command: name, Args: args, DB: w.db}
+}
+
+// pick chooses an operation by weight.
+func (w *workload) pick(rng *rand.Rand) string {
+	n := rng.Intn(w.totalWeight)
+	for _, op := range w.ops {
+		if n < op.weight {
+			return op.name
+		}
+		n -= op.weight
+	}
+	return w.ops[len(w.ops)-1].name
+}
+
+// send posts body to path, returning an error for failed requests and
+// responses with a status of 400 or more.
+func (w *workload) send(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", w.target+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		auth.SetAuthorization(req, w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 400 {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	var failure types.CommandResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err == nil && failure.Error != "" {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, failure.Error)
+	}
+	return fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// preload writes every key, and every field of every hash, so reads hit.
+func (w *workload) preload(ctx context.Context) error {
+	for start := 0; start < w.keys; start += preloadBatch {
+		req := types.PipelineRequest{DB: w.db}
+		for n := start; n < start+preloadBatch && n < w.keys; n++ {
+			req.Commands = append(req.Commands,
+				types.CommandRequest{Command: "SET", Args: []interface{}{w.key(n), w.value}},
+				types.CommandRequest{Command: "HSET", Args: []interface{}{w.hashKey(n), w.field(n), w.value}})
+		}
+		if err := w.send(ctx, "/v1/pipeline", req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run sends requests from concurrency workers until ctx is done or limit
+// requests have been sent, paced at rate requests a second if positive.
+func (w *workload) run(ctx context.Context, concurrency int, limit int64, rate float64) *report {
+	var tokens <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var sent atomic.Int64
+	stats := make([]map[string]*opStats, concurrency)
+	var workers sync.WaitGroup
+	start := time.Now()
+	for i := range stats {
+		stats[i] = make(map[string]*opStats)
+		workers.Add(1)
+		go func(stats map[string]*opStats, seed int64) {
+			defer workers.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for limit == 0 || sent.Add(1) <= limit {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				name := w.pick(rng)
+				path, body := operations[name](w, rng.Intn(w.keys), rng)
+				requestStart := time.Now()
+				err := w.send(ctx, path, body)
+				if ctx.Err() != nil {
+					return // Cut short by the end of the run
+				}
+
+				s := stats[name]
+				if s == nil {
+					s = &opStats{errors: make(map[string]int)}
+					stats[name] = s
+				}
+				s.latencies = append(s.latencies, time.Since(requestStart))
+				if err != nil {
+					s.errors[err.Error()]++
+				}
+			}
+		}(stats[i], time.Now().UnixNano()+int64(i))
+	}
+	workers.Wait()
+
+	return newReport(stats, time.Since(start))
+}
+
+// opStats holds the latencies and errors of one operation.
+type opStats struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+// report summarizes a benchmark run.
+type report struct {
+	Elapsed    float64           `json:"elapsed_seconds"`
+	Operations []operationReport `json:"operations"`
+	Total      operationReport   `json:"total"`
+	Errors     map[string]int    `json:"errors,omitempty"`
+}
+
+// operationReport summarizes the requests of one operation, with latencies
+// in milliseconds.
+type operationReport struct {
+	Name       string  `json:"name"`
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`
+	Throughput float64 `json:"requests_per_second"`
+	P50        float64 `json:"p50_ms"`
+	P90        float64 `json:"p90_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+}
+
+// newReport merges the stats of every worker.
+func newReport(workerStats []map[string]*opStats, elapsed time.Duration) *report {
+	merged := make(map[string]*opStats)
+	for _, stats := range workerStats {
+		for name, s := range stats {
+			m := merged[name]
+			if m == nil {
+				m = &opStats{errors: make(map[string]int)}
+				merged[name] = m
+			}
+			m.latencies = append(m.latencies, s.latencies...)
+			for message, count := range s.errors {
+				m.errors[message] += count
+			}
+		}
+	}
+
+	r := &report{Elapsed: elapsed.Seconds(), Errors: make(map[string]int)}
+	total := &opStats{errors: r.Errors}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := merged[name]
+		r.Operations = append(r.Operations, summarize(name, s, elapsed))
+		total.latencies = append(total.latencies, s.latencies...)
+		for message, count := range s.errors {
+			r.Errors[message] += count
+		}
+	}
+	r.Total = summarize("total", total, elapsed)
+	return r
+}
+
+func summarize(name string, s *opStats, elapsed time.Duration) operationReport {
+	summary := operationReport{Name: name, Requests: len(s.latencies)}
+	for _, count := range s.errors {
+		summary.Errors += count
+	}
+	if len(s.latencies) == 0 {
+		return summary
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	ms := func(p float64) float64 {
+		return float64(s.latencies[int(p*float64(len(s.latencies)-1))]) / float64(time.Millisecond)
+	}
+	summary.Throughput = float64(len(s.latencies)) / elapsed.Seconds()
+	summary.P50, summary.P90, summary.P99, summary.Max = ms(0.5), ms(0.9), ms(0.99), ms(1)
+	return summary
+}
+
+func (r *report) print(out io.Writer) {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "OPERATION\tREQUESTS\tERRORS\tREQ/S\tP50 MS\tP90 MS\tP99 MS\tMAX MS\t")
+	for _, op := range append(r.Operations, r.Total) {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.0f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			op.Name, op.Requests, op.Errors, op.Throughput, op.P50, op.P90, op.P99, op.Max)
+	}
+	table.Flush()
+
+	fmt.Fprintf(out, "\nRan for %.1fs\n", r.Elapsed)
+	for message, count := range r.Errors {
+		fmt.Fprintf(out, "✗ %d× %s\n", count, message)
+	}
+}
+
+func fail(message string) {
+	fmt.Fprintf(os.Stderr, "bench: %s\n", message)
+	os.Exit(2)
+}
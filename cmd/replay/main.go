@@ -0,0 +1,377 @@
+// Command replay re-issues traffic recorded by the serverless Redis proxy
+// against another proxy, for regression and capacity testing.
+//
+// Exchanges are read from a JSON lines file (-file) or a Redis stream
+// (-redis and -stream) and sent to -target with the -token credentials,
+// which replace the recorded ones. With -compare, each response is checked
+// against the recorded one, ignoring timings; with -speed, exchanges are
+// sent at the recorded pace, sped up by that factor.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// streamPageSize is how many entries are read from a stream at a time.
+const streamPageSize = 1000
+
+func main() {
+	file := flag.String("file", "", "JSON lines file of recorded exchanges")
+	redisAddr := flag.String("redis", "", "Redis address to read a recording stream from")
+	password := flag.String("password", os.Getenv("REDIS_PASSWORD"), "Redis password (defaults to $REDIS_PASSWORD)")
+	db := flag.Int("db", 0, "Redis database of the recording stream")
+	stream := flag.String("stream", "", "recording stream key")
+	target := flag.String("target", "", "base URL of the proxy to replay against, e.g. http://localhost:8080 (required)")
+	token := flag.String("token", os.Getenv("SR_TOKEN"), "API key, or JWT sent as a bearer token (defaults to $SR_TOKEN)")
+	prefix := flag.String("path", "", "only replay requests to paths with this prefix")
+	limit := flag.Int("limit", 0, "replay at most this many exchanges, 0 for all")
+	concurrency := flag.Int("concurrency", 1, "requests in flight at once")
+	speed := flag.Float64("speed", 0, "replay at the recorded pace times this factor, 0 for as fast as possible")
+	compare := flag.Bool("compare", false, "compare statuses and responses with the recorded ones")
+	flag.Parse()
+
+	if *target == "" {
+		fail("-target is required")
+	}
+	if (*file == "") == (*stream == "") {
+		fail("exactly one of -file or -stream is required")
+	}
+	if *stream != "" && *redisAddr == "" {
+		fail("-redis is required with -stream")
+	}
+	if *concurrency < 1 || *speed < 0 || *limit < 0 {
+		fail("-concurrency must be positive, and -speed and -limit not negative")
+	}
+
+	var exchanges []types.RecordedExchange
+	var err error
+	if *file != "" {
+		exchanges, err = readFile(*file)
+	} else {
+		exchanges, err = readStream(*redisAddr, *password, *db, *stream)
+	}
+	if err != nil {
+		fail(err.Error())
+	}
+
+	exchanges = filter(exchanges, *prefix, *limit)
+	replayer := &replayer{
+		client:  &http.Client{Timeout: time.Minute},
+		target:  strings.TrimSuffix(*target, "/"),
+		token:   *token,
+		compare: *compare,
+	}
+	report := replayer.run(exchanges, *concurrency, *speed)
+	report.print(os.Stdout, *compare)
+	if report.failed > 0 || report.mismatched > 0 {
+		os.Exit(1)
+	}
+}
+
+// readFile reads the exchanges of a JSON lines recording.
+func readFile(path string) ([]types.RecordedExchange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var exchanges []types.RecordedExchange
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var exchange types.RecordedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges, scanner.Err()
+}
+
+// readStream reads the exchanges of a recording stream, oldest first.
+func readStream(addr, password string, db int, key string) ([]types.RecordedExchange, error) {
+	client := goredis.NewClient(&goredis.Options{Addr: addr, Password: password, DB: db})
+	defer client.Close()
+
+	ctx := context.Background()
+	var exchanges []types.RecordedExchange
+	start := "-"
+	for {
+		entries, err := client.XRangeN(ctx, key, start, "+", streamPageSize).Result()
+		if err != nil {
+			return nil, fmt.Errorf("reading stream %s: %w", key, err)
+		}
+		for _, entry := range entries {
+			data, _ := entry.Values["exchange"].(string)
+			var exchange types.RecordedExchange
+			if err := json.Unmarshal([]byte(data), &exchange); err != nil {
+				return nil, fmt.Errorf("stream %s entry %s: %w", key, entry.ID, err)
+			}
+			exchanges = append(exchanges, exchange)
+		}
+		if len(entries) < streamPageSize {
+			return exchanges, nil
+		}
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// filter keeps the exchanges to paths with prefix, up to limit.
+func filter(exchanges []types.RecordedExchange, prefix string, limit int) []types.RecordedExchange {
+	kept := exchanges[:0]
+	for _, exchange := range exchanges {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+		if strings.HasPrefix(exchange.Path, prefix) {
+			kept = append(kept, exchange)
+		}
+	}
+	return kept
+}
+
+type replayer struct {
+	client  *http.Client
+	target  string
+	token   string
+	compare bool
+}
+
+// result is the outcome of replaying one exchange.
+type result struct {
+	exchange types.RecordedExchange
+	skipped  bool
+	err      error
+	status   int
+	mismatch string
+	latency  time.Duration
+}
+
+// run replays exchanges with concurrency requests in flight, paced at
+// speed times the recorded pace when speed is positive.
+func (rp *replayer) run(exchanges []types.RecordedExchange, concurrency int, speed float64) *report {
+	jobs := make(chan types.RecordedExchange)
+	results := make(chan result)
+
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for exchange := range jobs {
+				results <- rp.replay(exchange)
+			}
+		}()
+	}
+
+	go func() {
+		start := time.Now()
+		for _, exchange := range exchanges {
+			if speed > 0 {
+				offset := time.Duration(float64(exchange.Time.Sub(exchanges[0].Time)) / speed)
+				time.Sleep(time.Until(start.Add(offset)))
+			}
+			jobs <- exchange
+		}
+		close(jobs)
+		workers.Wait()
+		close(results)
+	}()
+
+	report := &report{start: time.Now()}
+	for result := range results {
+		report.add(result)
+	}
+	report.elapsed = time.Since(report.start)
+	return report
+}
+
+// replay sends exchange to the target and compares the response.
+func (rp *replayer) replay(exchange types.RecordedExchange) result {
+	outcome := result{exchange: exchange}
+	if exchange.BodyTruncated {
+		outcome.skipped = true
+		return outcome
+	}
+
+	url := rp.target + exchange.Path
+	if exchange.Query != "" {
+		url += "?" + exchange.Query
+	}
+	req, err := http.NewRequest(exchange.Method, url, strings.NewReader(exchange.Body))
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+	for name, value := range exchange.Header {
+		req.Header.Set(name, value)
+	}
+	if rp.token != "" {
+		auth.SetAuthorization(req, rp.token)
+	}
+
+	start := time.Now()
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	outcome.latency = time.Since(start)
+	outcome.status = resp.StatusCode
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+
+	if rp.compare {
+		outcome.mismatch = compareResponse(exchange, resp.StatusCode, body)
+	}
+	return outcome
+}
+
+// compareResponse describes how a response differs from the recorded one,
+// or returns "" if it doesn't. Timings, request IDs and other response
+// metadata are ignored, as are the bodies of truncated recordings.
+func compareResponse(exchange types.RecordedExchange, status int, body []byte) string {
+	if status != exchange.Status {
+		return fmt.Sprintf("status %d, recorded %d", status, exchange.Status)
+	}
+	if exchange.ResponseTruncated {
+		return ""
+	}
+
+	replayed, recorded := normalizeResponse(exchange.Path, body), normalizeResponse(exchange.Path, []byte(exchange.Response))
+	if !reflect.DeepEqual(replayed, recorded) {
+		return fmt.Sprintf("response %s, recorded %s", abbreviate(body), abbreviate([]byte(exchange.Response)))
+	}
+	return ""
+}
+
+// normalizeResponse decodes a JSON response without its time fields, and
+// the /v1 response inside a /v2 envelope, which is what was recorded.
+// Responses that aren't JSON are compared as they are.
+func normalizeResponse(path string, body []byte) interface{} {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+	if envelope, ok := value.(map[string]interface{}); ok && strings.HasPrefix(path, "/v2/") {
+		if _, ok := envelope["meta"]; ok {
+			value = envelope["data"]
+			if value == nil {
+				value = envelope["error"]
+			}
+		}
+	}
+	return withoutTimes(value)
+}
+
+func withoutTimes(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		delete(v, "time")
+		for name, field := range v {
+			v[name] = withoutTimes(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = withoutTimes(item)
+		}
+	}
+	return value
+}
+
+func abbreviate(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) > 120 {
+		return text[:120] + "..."
+	}
+	return text
+}
+
+// report summarizes a replay.
+type report struct {
+	start      time.Time
+	elapsed    time.Duration
+	sent       int
+	skipped    int
+	failed     int
+	mismatched int
+	latencies  []time.Duration
+	recorded   []float64
+	mismatches []string
+}
+
+func (r *report) add(outcome result) {
+	switch {
+	case outcome.skipped:
+		r.skipped++
+		return
+	case outcome.err != nil:
+		r.failed++
+		r.mismatches = append(r.mismatches, fmt.Sprintf("%s %s: %v", outcome.exchange.Method, outcome.exchange.Path, outcome.err))
+		return
+	}
+
+	r.sent++
+	r.latencies = append(r.latencies, outcome.latency)
+	r.recorded = append(r.recorded, outcome.exchange.Duration)
+	if outcome.mismatch != "" {
+		r.mismatched++
+		r.mismatches = append(r.mismatches, fmt.Sprintf("%s %s: %s", outcome.exchange.Method, outcome.exchange.Path, outcome.mismatch))
+	}
+}
+
+func (r *report) print(out io.Writer, compared bool) {
+	for _, mismatch := range r.mismatches {
+		fmt.Fprintln(out, "✗", mismatch)
+	}
+
+	fmt.Fprintf(out, "Replayed %d exchanges in %v (%d skipped with truncated bodies, %d failed)\n",
+		r.sent, r.elapsed.Round(time.Millisecond), r.skipped, r.failed)
+	if compared {
+		fmt.Fprintf(out, "Responses: %d matched, %d differed\n", r.sent-r.mismatched, r.mismatched)
+	}
+	if len(r.latencies) > 0 {
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		sort.Float64s(r.recorded)
+		ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+		fmt.Fprintf(out, "Latency ms: p50 %.2f, p99 %.2f, max %.2f (recorded p50 %.2f, p99 %.2f, max %.2f)\n",
+			ms(percentile(r.latencies, 0.5)), ms(percentile(r.latencies, 0.99)), ms(r.latencies[len(r.latencies)-1]),
+			percentile(r.recorded, 0.5), percentile(r.recorded, 0.99), r.recorded[len(r.recorded)-1])
+	}
+}
+
+// percentile returns the p-th percentile of sorted values.
+func percentile[T any](sorted []T, p float64) T {
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+func fail(message string) {
+	fmt.Fprintf(os.Stderr, "replay: %s\n", message)
+	os.Exit(2)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+)
+
+// redisUserMiddleware runs the commands of tenants configured with a Redis
+// ACL user as that user, so Redis enforces their permissions as well.
+func (s *Server) redisUserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ := auth.GetTenantFromContext(r.Context())
+		if tenant == nil || tenant.RedisUsername == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := redis.WithCredentials(r.Context(), redis.Credentials{
+			Username: tenant.RedisUsername,
+			Password: tenant.RedisPassword,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	// defaultTokenTTL is used when a token request doesn't specify a TTL.
+	defaultTokenTTL = 24 * time.Hour
+
+	defaultHistoryLimit = 60
+	maxHistoryLimit     = 1440
+)
+
+// handleMintToken serves POST /admin/tokens, minting a JWT scoped to the
+// requested tenant, permissions, databases and rate limit.
+func (s *Server) handleMintToken(w http.ResponseWriter, r *http.Request) {
+	var req types.TokenRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.TenantID == "" {
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeMissingField, "tenant_id", "tenant_id is required"))
+		return
+	}
+
+	if len(req.Permissions) == 0 {
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeMissingField, "permissions", "permissions must not be empty"))
+		return
+	}
+
+	ttl := defaultTokenTTL
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, "ttl", "ttl must be a positive duration"))
+			return
+		}
+		ttl = parsed
+	}
+
+	allowedDBs := req.AllowedDBs
+	if len(allowedDBs) == 0 {
+		allowedDBs = []int{0}
+	}
+
+	token, claims, err := s.authManager.IssueJWT(req.TenantID, req.RateLimit, allowedDBs, req.Permissions, ttl)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to generate token", http.StatusInternalServerError, err)
+		return
+	}
+
+	s.writeJSONResponse(w, types.TokenResponse{
+		Token:     token,
+		ID:        claims.ID,
+		TenantID:  req.TenantID,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	})
+}
+
+// handleRevoke serves POST /admin/revocations, revoking a single JWT by
+// its jti or every JWT issued to a tenant so far.
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	var req types.RevokeRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if (req.JTI == "") == (req.TenantID == "") {
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeConflictingField, "",
+			"exactly one of jti or tenant_id is required"))
+		return
+	}
+
+	revocations := s.authManager.Revocations()
+	now := time.Now()
+	response := types.RevokeResponse{JTI: req.JTI, TenantID: req.TenantID, RevokedAt: now.Unix()}
+
+	var err error
+	if req.JTI != "" {
+		var expiresAt time.Time
+		if req.ExpiresAt > 0 {
+			expiresAt = time.Unix(req.ExpiresAt, 0)
+		}
+		err = revocations.RevokeToken(r.Context(), req.JTI, expiresAt)
+	} else {
+		err = revocations.RevokeTenant(r.Context(), req.TenantID, now)
+	}
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to revoke", http.StatusInternalServerError, err)
+		return
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleHistory serves GET /admin/v1/history, returning stats samples
+// oldest first. Results are paged with ?limit and a signed ?cursor, and can
+// be narrowed with ?since (RFC 3339).
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+
+	limit := defaultHistoryLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxHistoryLimit {
+			s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, "limit",
+				"limit must be between 1 and %d", maxHistoryLimit))
+			return
+		}
+		limit = n
+	}
+
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, "since",
+				"since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = parsed
+	}
+
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := s.authManager.VerifyCursor(token, tenantID(tenant), "history")
+		if err != nil {
+			s.writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest, err)
+			return
+		}
+		nanos, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			s.writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest, fmt.Errorf("malformed history cursor"))
+			return
+		}
+		since = time.Unix(0, nanos)
+	}
+
+	// Fetch one extra sample to learn whether another page exists.
+	samples := s.metrics.History().Since(since, limit+1)
+
+	response := types.HistoryResponse{Samples: samples}
+	if len(samples) > limit {
+		response.Samples = samples[:limit]
+		last := response.Samples[limit-1].Timestamp.UnixNano()
+		response.Cursor = s.authManager.SignCursor(tenantID(tenant), "history", strconv.FormatInt(last, 10), auth.DefaultCursorTTL)
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleListMaintenance serves GET /admin/maintenance, listing scheduled
+// and active maintenance windows.
+func (s *Server) handleListMaintenance(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	response := types.MaintenanceListResponse{Windows: s.maintenance.List(now)}
+	if window, active := s.maintenance.Active(now); active {
+		response.Active = &window
+	}
+	if mode, on := s.maintenance.Mode(now); on {
+		response.Mode = &mode
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleAddMaintenance serves POST /admin/maintenance, scheduling a window.
+func (s *Server) handleAddMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req types.MaintenanceWindow
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	window, err := s.maintenance.Add(req)
+	if err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	log.Printf("Maintenance window %q scheduled from %s to %s", window.Name,
+		window.Start.Format(time.RFC3339), window.End.Format(time.RFC3339))
+	s.writeJSONResponse(w, window)
+}
+
+// handleSetMaintenanceMode serves PUT /admin/maintenance/mode, switching
+// the proxy into read-only or full maintenance, or back off. With
+// ?drain=<duration> it waits up to that long for requests admitted before
+// the switch to finish, so e.g. no write is still in flight when a Redis
+// migration starts.
+func (s *Server) handleSetMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	var req types.MaintenanceMode
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	var drain time.Duration
+	if value := r.URL.Query().Get("drain"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, "drain", "drain must be a positive duration"))
+			return
+		}
+		drain = parsed
+	}
+
+	mode := s.maintenance.SetMode(req)
+	if mode.Mode == types.MaintenanceOff {
+		s.readCache.Clear()
+	}
+	log.Printf("Maintenance mode %s (reason: %q, cache reads: %v)", mode.Mode, mode.Reason, mode.CacheReads)
+
+	response := types.MaintenanceModeResponse{Mode: mode}
+	if drain > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), drain)
+		defer cancel()
+		drained := s.drainer.Drain(ctx) == nil
+		response.Drained = &drained
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleRecordingStatus serves GET /admin/recording, whether traffic is
+// being recorded and how much has been.
+func (s *Server) handleRecordingStatus(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONResponse(w, s.recorder.Status())
+}
+
+// handleSetRecording serves PUT /admin/recording, starting or stopping
+// traffic recording.
+func (s *Server) handleSetRecording(w http.ResponseWriter, r *http.Request) {
+	var req types.RecordingRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	s.recorder.SetEnabled(*req.Enabled)
+	status := s.recorder.Status()
+	log.Printf("Traffic recording to %s enabled: %v", status.Sink, status.Enabled)
+	s.writeJSONResponse(w, status)
+}
+
+// handleCachePurge serves POST /admin/cache/purge, removing cached
+// responses by key, by tenant or all of them, from the response, command
+// and maintenance read caches.
+func (s *Server) handleCachePurge(w http.ResponseWriter, r *http.Request) {
+	var req types.CachePurgeRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	response := types.CachePurgeResponse{}
+	for _, cache := range []*server.InMemoryCache{s.cache, s.readCache, s.commandCache} {
+		switch {
+		case req.Key != "":
+			if cache.Delete(req.Key) {
+				response.Purged++
+			}
+		case req.TenantID != "":
+			response.Purged += cache.DeleteTenant(req.TenantID)
+		default:
+			response.Purged += cache.Clear()
+		}
+	}
+
+	log.Printf("Purged %d cached responses (key: %q, tenant: %q, all: %v)", response.Purged, req.Key, req.TenantID, req.All)
+	s.writeJSONResponse(w, response)
+}
+
+// handleDeleteMaintenance serves DELETE /admin/maintenance/{id}, cancelling
+// a window. Cancelling an active window ends it immediately.
+func (s *Server) handleDeleteMaintenance(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !s.maintenance.Remove(id) {
+		s.writeErrorResponse(w, "Maintenance window not found", http.StatusNotFound, fmt.Errorf("no window with id %q", id))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/observability"
+	"github.com/scaler/serverless-redis/internal/server"
+)
+
+// annotateTenantMiddleware names the authenticated tenant in the access
+// log and error reports, which sit outside the auth middleware and can't
+// see it.
+func (s *Server) annotateTenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant, _ := auth.GetTenantFromContext(r.Context()); tenant != nil {
+			server.SetAccessLogTenant(r.Context(), tenant.ID)
+			observability.SetErrorTenant(r.Context(), tenant.ID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reportPanic logs a panic recovered from a handler and reports it if
+// error reporting is enabled.
+func (s *Server) reportPanic(r *http.Request, value interface{}, stack []byte) {
+	log.Printf("Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, value, stack)
+	if s.errorReporter != nil {
+		s.errorReporter.ReportPanic(r, value, stack)
+	}
+}
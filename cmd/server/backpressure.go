@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// checkPoolExhausted answers 503 with Retry-After when err means no Redis
+// connection became free within the pool timeout, so clients back off
+// instead of piling more requests onto a saturated pool. It reports
+// whether it wrote a response.
+func (s *Server) checkPoolExhausted(w http.ResponseWriter, err error) bool {
+	if !redis.IsPoolTimeout(err) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(server.RetryAfterSeconds(s.config.Pool.PoolTimeout)))
+	s.writeCodedError(w, "Connection pool exhausted", http.StatusServiceUnavailable, types.ErrCodePoolExhausted, err)
+	return true
+}
+
+// checkPoolExhaustedResults is checkPoolExhausted for pipeline results;
+// a pipeline that couldn't get a connection fails every command with the
+// same error.
+func (s *Server) checkPoolExhaustedResults(w http.ResponseWriter, results []types.CommandResponse) bool {
+	for _, result := range results {
+		if result.Error != "" && s.checkPoolExhausted(w, errors.New(result.Error)) {
+			return true
+		}
+	}
+	return false
+}
+
+// concurrencyMiddleware sheds requests with 503 once the adaptive
+// concurrency limit is reached and the tenant's tier queue is full, rather
+// than letting them queue up in front of a Redis that is already slowing
+// down.
+func (s *Server) concurrencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tier := ""
+		if tenant, ok := auth.GetTenantFromContext(r.Context()); ok {
+			tier = tenant.Tier
+		}
+		if tier == "" {
+			tier = s.config.Server.Concurrency.DefaultTier
+		}
+
+		queued := time.Now()
+		acquired := s.concurrency.Acquire(r.Context(), tier)
+		server.AddQueueTime(r.Context(), time.Since(queued))
+		if !acquired {
+			s.metrics.RecordConcurrencyRejected(tier)
+			w.Header().Set("Retry-After", "1")
+			s.writeCodedError(w, "Server overloaded", http.StatusServiceUnavailable, types.ErrCodeOverloaded, server.ErrOverloaded)
+			return
+		}
+		defer s.concurrency.Release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// observeLatency feeds a Redis round-trip time to the concurrency limiter.
+func (s *Server) observeLatency(latency time.Duration) {
+	if s.concurrency != nil {
+		s.concurrency.Observe(latency)
+	}
+}
+
+// updatePoolMetrics publishes the connection counts and saturation of each
+// connection pool, and of the concurrency limit.
+func (s *Server) updatePoolMetrics() {
+	for _, usage := range s.redisClient.PoolUsage() {
+		s.metrics.UpdatePoolUsage(usage.Name, usage.InUse, usage.Max)
+		s.metrics.UpdatePoolStats(usage.Name, usage.Stats())
+	}
+	if s.concurrency != nil {
+		s.metrics.UpdateConcurrency(s.concurrency.Snapshot())
+	}
+}
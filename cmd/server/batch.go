@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// defaultBatchChunk is how many commands of a batch are sent to Redis as
+// one pipeline unless ?chunk= says otherwise.
+const defaultBatchChunk = 100
+
+// handleBatch serves POST /v1/batch, which reads one CommandRequest per
+// line of the body and streams back one CommandResponse per line, in the
+// same order. Commands run as pipelines of ?chunk= commands while the rest
+// of the body is still arriving, so neither side holds the whole batch in
+// memory. Commands that fail validation or permission checks get an error
+// line and the batch goes on; a malformed line ends it.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	chunk, err := intParam(r.URL.Query().Get("chunk"), defaultBatchChunk, s.config.Server.MaxPipelineCommands)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid chunk", http.StatusBadRequest, fmt.Errorf("chunk %w", err))
+		return
+	}
+	if !s.checkBudget(w, r) {
+		return
+	}
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	server.SetAccessLogCommand(r.Context(), "BATCH")
+
+	// HTTP/1 responses normally end reading the request body
+	_ = http.NewResponseController(w).EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	// pending holds a response for every command read and not yet written;
+	// those with no result yet are in pipeline, to run in one go
+	var pending []types.CommandResponse
+	var pipeline types.PipelineRequest
+	var slots []int
+	flush := func() bool {
+		if len(pipeline.Commands) > 0 {
+			results, _ := s.executePipeline(r.Context(), tenant, pipeline)
+			for i, slot := range slots {
+				pending[slot] = results[i]
+			}
+			pipeline.Commands, slots = nil, nil
+		}
+		for _, response := range pending {
+			if encoder.Encode(response) != nil {
+				return false
+			}
+		}
+		pending = pending[:0]
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64<<10), maxRequestBodySize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var cmd types.CommandRequest
+		if err := json.Unmarshal(line, &cmd); err != nil {
+			pending = append(pending, types.CommandResponse{Error: err.Error(), Code: types.ErrCodeInvalidJSON})
+			flush()
+			return
+		}
+
+		// Commands on another database start a new pipeline
+		if len(pipeline.Commands) > 0 && (cmd.DB != pipeline.DB || cmd.Database != pipeline.Database) {
+			if !flush() {
+				return
+			}
+		}
+
+		if rejected := s.checkBatchCommand(r, tenant, cmd); rejected != nil {
+			pending = append(pending, *rejected)
+		} else {
+			pipeline.DB, pipeline.Database = cmd.DB, cmd.Database
+			pipeline.Commands = append(pipeline.Commands, cmd)
+			slots = append(slots, len(pending))
+			pending = append(pending, types.CommandResponse{})
+		}
+
+		if len(pending) >= chunk && !flush() {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		code := types.ErrCodeInvalidJSON
+		if errors.Is(err, bufio.ErrTooLong) {
+			code = types.ErrCodeValueTooLarge
+		}
+		pending = append(pending, types.CommandResponse{Error: err.Error(), Code: code})
+	}
+	flush()
+}
+
+// checkBatchCommand runs the checks /v1/command makes before sending cmd
+// to Redis, returning the error response for a rejected command.
+func (s *Server) checkBatchCommand(r *http.Request, tenant *types.Tenant, cmd types.CommandRequest) *types.CommandResponse {
+	rejected := func(code string, err error) *types.CommandResponse {
+		return &types.CommandResponse{Error: err.Error(), Code: code}
+	}
+
+	err := cmd.Validate(s.validationLimits())
+	if err == nil {
+		err = s.redisClient.ValidateCommandShard(cmd)
+	}
+	if err != nil {
+		code := types.ErrCodeInvalidArgument
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			code = validationErr.Code
+		}
+		return rejected(code, err)
+	}
+
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, cmd.Command); err != nil {
+			return rejected("", err)
+		}
+		if err := s.validateDatabase(tenant, cmd.Database, cmd.DB); err != nil {
+			return rejected("", err)
+		}
+	}
+
+	if code, _, err := s.writesBlocked(r, cmd); err != nil {
+		return rejected(code, err)
+	}
+	if err := keyPolicyViolation(tenant, cmd); err != nil {
+		return rejected(types.ErrCodeKeyPolicy, err)
+	}
+	if err := s.oversizedKey(tenant, cmd); err != nil {
+		return rejected(types.ErrCodeKeyTooLarge, err)
+	}
+	if err := s.oversizedValue(tenant, cmd); err != nil {
+		return rejected(types.ErrCodeValueTooLarge, err)
+	}
+	if _, err := s.rateLimited(tenant, cmd); err != nil {
+		return rejected(types.ErrCodeRateLimited, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// maxValueBytes is the largest value tenant may write, 0 for no limit.
+func (s *Server) maxValueBytes(tenant *types.Tenant) int {
+	if tenant != nil && tenant.MaxValueBytes > 0 {
+		return tenant.MaxValueBytes
+	}
+	return s.config.BigValues.MaxValueBytes
+}
+
+// maxKeyBytes is the longest key tenant may write to, 0 for no limit.
+func (s *Server) maxKeyBytes(tenant *types.Tenant) int {
+	if tenant != nil && tenant.MaxKeyBytes > 0 {
+		return tenant.MaxKeyBytes
+	}
+	return s.config.BigValues.MaxKeyBytes
+}
+
+// checkValueSize rejects requests writing to a key above the tenant's
+// length limit, or a value above its size limit, with 413 before anything
+// is sent to Redis.
+func (s *Server) checkValueSize(w http.ResponseWriter, tenant *types.Tenant, commands ...types.CommandRequest) bool {
+	if err := s.oversizedKey(tenant, commands...); err != nil {
+		s.writeCodedError(w, "Key too large", http.StatusRequestEntityTooLarge, types.ErrCodeKeyTooLarge, err)
+		return false
+	}
+	if err := s.oversizedValue(tenant, commands...); err != nil {
+		s.writeCodedError(w, "Value too large", http.StatusRequestEntityTooLarge, types.ErrCodeValueTooLarge, err)
+		return false
+	}
+	return true
+}
+
+// oversizedKey returns the error for the first of commands writing to a
+// key above the tenant's length limit, if any does. Reads are let through:
+// they store nothing.
+func (s *Server) oversizedKey(tenant *types.Tenant, commands ...types.CommandRequest) error {
+	limit := s.maxKeyBytes(tenant)
+	if limit <= 0 {
+		return nil
+	}
+
+	for i, cmd := range commands {
+		if redis.IsReadOnly(cmd.Command) {
+			continue
+		}
+		for _, key := range redis.CommandKeys(cmd) {
+			if len(key) <= limit {
+				continue
+			}
+			s.metrics.RecordKeyTooLarge(tenant)
+
+			field := "args"
+			if len(commands) > 1 {
+				field = fmt.Sprintf("commands[%d].args", i)
+			}
+			return &types.ValidationError{Field: field, Message: fmt.Sprintf("%s: %d bytes exceeds the limit of %d", server.ErrKeyTooLarge, len(key), limit)}
+		}
+	}
+	return nil
+}
+
+// oversizedValue returns the error for the first of commands writing a
+// value above the tenant's size limit, if any does.
+func (s *Server) oversizedValue(tenant *types.Tenant, commands ...types.CommandRequest) error {
+	limit := s.maxValueBytes(tenant)
+	if limit <= 0 {
+		return nil
+	}
+
+	for i, cmd := range commands {
+		access, ok := server.ValueKey(cmd)
+		if !ok || !access.Write {
+			continue
+		}
+		if size := server.WriteSize(cmd); size > limit {
+			s.metrics.RecordValueTooLarge(tenant)
+
+			field := "args"
+			if len(commands) > 1 {
+				field = fmt.Sprintf("commands[%d].args", i)
+			}
+			return &types.ValidationError{Field: field, Message: fmt.Sprintf("%s: %d bytes exceeds the limit of %d", server.ErrValueTooLarge, size, limit)}
+		}
+	}
+	return nil
+}
+
+// trackValue reports values read or written by cmd above the big value
+// threshold in metrics and the admin report, logging each new key.
+func (s *Server) trackValue(ctx context.Context, tenant *types.Tenant, cmd types.CommandRequest, db int, result interface{}) {
+	access, ok := server.ValueKey(cmd)
+	if !ok {
+		return
+	}
+
+	size := server.ValueSize(result)
+	if access.Write {
+		size = server.WriteSize(cmd)
+	}
+	s.trackValueSize(ctx, tenant, cmd, db, access, size)
+}
+
+// trackValueSize is trackValue for a value of a known size, such as one
+// streamed rather than held in memory.
+func (s *Server) trackValueSize(ctx context.Context, tenant *types.Tenant, cmd types.CommandRequest, db int, access server.ValueAccess, size int) {
+	if size <= s.bigValues.Threshold() {
+		return
+	}
+
+	s.metrics.RecordBigValue(access.Write, tenant)
+	server.AddWarning(ctx, types.WarningLargeValue, "%s on key %q is %d bytes, above the big value threshold of %d", cmd.Command, access.Key, size, s.bigValues.Threshold())
+	if s.bigValues.Record(tenantID(tenant), db, cmd.Command, access, size) {
+		log.Printf("Big value: %s on key %q (db %d, tenant %q) is %d bytes", cmd.Command, access.Key, db, tenantID(tenant), size)
+	}
+}
+
+// handleBigValues serves GET /admin/big-values, the largest values seen
+// above the threshold.
+func (s *Server) handleBigValues(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONResponse(w, s.bigValues.Report())
+}
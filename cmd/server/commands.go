@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleListCommands serves GET /v1/commands: the commands the calling
+// tenant may run, so clients can tell what would be rejected before
+// sending it.
+func (s *Server) handleListCommands(w http.ResponseWriter, r *http.Request) {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+
+	permitted := func(command string) bool {
+		return tenant == nil || s.authManager.ValidateCommand(tenant, command) == nil
+	}
+
+	response := types.CommandsResponse{
+		Permissions: []string{"*"},
+		Allowed:     []string{},
+		Denied:      []string{},
+		Unlisted:    true,
+		Macros:      []string{},
+	}
+	if tenant != nil {
+		response.TenantID = tenant.ID
+		response.Permissions = tenant.Permissions
+		response.Unlisted = permitsUnlisted(tenant.Permissions)
+	}
+
+	for _, command := range redis.KnownCommands() {
+		if permitted(command) {
+			response.Allowed = append(response.Allowed, command)
+		} else {
+			response.Denied = append(response.Denied, command)
+		}
+	}
+	for _, macro := range s.config.Macros {
+		if permitted("MACRO:" + macro.Name) {
+			response.Macros = append(response.Macros, macro.Name)
+		}
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// permitsUnlisted reports whether permissions match commands outside the
+// command table: through a wildcard, or by naming one.
+func permitsUnlisted(permissions []string) bool {
+	known := make(map[string]bool)
+	for _, command := range redis.KnownCommands() {
+		known[command] = true
+	}
+
+	for _, perm := range permissions {
+		if strings.HasSuffix(perm, "*") {
+			return true
+		}
+		if !strings.HasPrefix(strings.ToUpper(perm), "MACRO:") && !known[strings.ToUpper(perm)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// acceptsCSV reports whether the client asked for text/csv.
+func acceptsCSV(r *http.Request) bool {
+	return accepts(r, "text/csv")
+}
+
+// accepts reports whether the Accept header of r lists mediaType.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		accepted, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && accepted == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReadOnlyOutput rejects CSV or raw output for commands that may
+// modify data, so a spreadsheet refresh or an image link can never be
+// used to write.
+func (s *Server) checkReadOnlyOutput(w http.ResponseWriter, format, command string) bool {
+	if redis.IsReadOnly(command) {
+		return true
+	}
+
+	s.writeErrorResponse(w, format+" output is only available for reads", http.StatusNotAcceptable,
+		fmt.Errorf("command %s may modify data", strings.ToUpper(command)))
+	return false
+}
+
+// writeCSVResponse writes a command reply as CSV. Redis errors have no
+// tabular form and are answered as a 502 error response instead.
+func (s *Server) writeCSVResponse(w http.ResponseWriter, req types.CommandRequest, result interface{}, err error) {
+	if err != nil && !redis.IsNil(err) {
+		s.writeErrorResponse(w, "Redis command failed", http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := redis.Tabulate(req, result).WriteCSV(w); err != nil {
+		log.Printf("Failed to write CSV response: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// delayCommands are the commands a delayed task is delivered with, which
+// the tenant must be permitted to run. Webhooks need the WEBHOOK
+// permission instead.
+var delayCommands = map[string]string{
+	types.DelayTargetList:    "RPUSH",
+	types.DelayTargetStream:  "XADD",
+	types.DelayTargetWebhook: "WEBHOOK",
+}
+
+// handleDelay serves POST /v1/delay, enqueueing a payload for delivery to
+// a list, stream or webhook once its delay is up.
+func (s *Server) handleDelay(w http.ResponseWriter, r *http.Request) {
+	var req types.DelayRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	now := time.Now()
+	deliverAt, field := now.Add(time.Duration(req.DelayMs)*time.Millisecond), "delay_ms"
+	if req.DeliverAt != nil {
+		deliverAt, field = *req.DeliverAt, "deliver_at"
+	}
+	if deliverAt.Sub(now) > s.config.Delay.MaxDelay {
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, field,
+			"delay exceeds the maximum of %s", s.config.Delay.MaxDelay))
+		return
+	}
+
+	tenant, ok := s.authorizeDelay(w, r, req)
+	if !ok || !s.checkDelayUser(w, tenant) {
+		return
+	}
+
+	task := &types.DelayedTask{
+		Target:    req.Target,
+		Key:       req.Key,
+		URL:       req.URL,
+		DB:        req.DB,
+		Payload:   req.Payload,
+		DeliverAt: deliverAt,
+	}
+	if tenant != nil {
+		task.TenantID = tenant.ID
+	}
+	if err := s.delays.Enqueue(r.Context(), task); err != nil {
+		s.writeErrorResponse(w, "Failed to enqueue task", http.StatusBadGateway, err)
+		return
+	}
+	s.metrics.RecordDelayedTask(task.Target, "enqueued")
+
+	s.writeJSONResponse(w, task)
+}
+
+// authorizeDelay checks that the tenant may deliver req to its target,
+// with the checks of the command it is delivered with.
+func (s *Server) authorizeDelay(w http.ResponseWriter, r *http.Request, req types.DelayRequest) (*types.Tenant, bool) {
+	command := delayCommands[req.Target]
+	if req.Target != types.DelayTargetWebhook {
+		deliver := types.CommandRequest{Command: command, Args: []interface{}{req.Key, string(req.Payload)}, DB: req.DB}
+		return s.authorizeMultiKey(w, r, command, "", req.DB, []types.CommandRequest{deliver}, deliver)
+	}
+
+	server.SetAccessLogCommand(r.Context(), command)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, command); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+	}
+	if err := s.delays.CheckWebhook(req.URL); err != nil {
+		s.writeErrorResponse(w, "Webhook not permitted", http.StatusForbidden, err)
+		return tenant, false
+	}
+	return tenant, s.checkRateLimit(w, tenant, types.CommandRequest{Command: command}) && s.checkBudget(w, r)
+}
+
+// checkDelayUser writes 403 for tenants running as a Redis ACL user. Tasks
+// are delivered as the proxy's own user, so queueing them would let the
+// tenant write what its ACL user can't.
+func (s *Server) checkDelayUser(w http.ResponseWriter, tenant *types.Tenant) bool {
+	if tenant != nil && tenant.RedisUsername != "" {
+		s.writeErrorResponse(w, "Delayed tasks not available", http.StatusForbidden,
+			fmt.Errorf("tenant %s runs as Redis ACL user %s, which delivery can't act as", tenant.ID, tenant.RedisUsername))
+		return false
+	}
+	return true
+}
+
+// handleGetDelayed serves GET /v1/delay/{id}, returning a task still
+// waiting for delivery.
+func (s *Server) handleGetDelayed(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.delayedTask(w, r)
+	if !ok {
+		return
+	}
+	s.writeJSONResponse(w, task)
+}
+
+// handleCancelDelayed serves DELETE /v1/delay/{id}, cancelling a task
+// before it is delivered.
+func (s *Server) handleCancelDelayed(w http.ResponseWriter, r *http.Request) {
+	task, ok := s.delayedTask(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.delays.Cancel(r.Context(), task.ID)
+	switch {
+	case errors.Is(err, server.ErrTaskNotFound):
+		s.writeErrorResponse(w, "Task not found", http.StatusNotFound, fmt.Errorf("task %s was delivered already", task.ID))
+	case err != nil:
+		s.writeErrorResponse(w, "Failed to cancel task", http.StatusBadGateway, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// delayedTask loads the task named in the path, writing 404 unless it
+// waits for delivery and belongs to the calling tenant.
+func (s *Server) delayedTask(w http.ResponseWriter, r *http.Request) (*types.DelayedTask, bool) {
+	id := mux.Vars(r)["id"]
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if !s.checkDelayUser(w, tenant) {
+		return nil, false
+	}
+
+	task, err := s.delays.Get(r.Context(), id)
+	if err == nil && tenant != nil && task.TenantID != tenant.ID {
+		err = server.ErrTaskNotFound
+	}
+	switch {
+	case errors.Is(err, server.ErrTaskNotFound):
+		s.writeErrorResponse(w, "Task not found", http.StatusNotFound, fmt.Errorf("no task with id %q", id))
+		return nil, false
+	case err != nil:
+		s.writeErrorResponse(w, "Failed to load task", http.StatusBadGateway, err)
+		return nil, false
+	}
+	return task, true
+}
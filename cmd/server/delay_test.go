@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDelayRefusesRedisUsers(t *testing.T) {
+	_, handler := newTestServer(t, `
+delay:
+  enabled: true
+  db: 1
+auth:
+  enabled: true
+  jwt_secret: test-secret
+  api_keys:
+    - key: acl-key
+      tenant_id: acl
+      allowed_dbs: [0]
+      permissions: ["*"]
+      redis_username: acl
+      redis_password: secret
+`)
+
+	// Delivery runs as the proxy's own user, not the tenant's ACL user
+	body := `{"target":"list","key":"jobs","payload":"x","delay_ms":1000}`
+	if w := doRequest(handler, http.MethodPost, "/v1/delay", "acl-key", body); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a tenant with a Redis ACL user, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(handler, http.MethodGet, "/v1/delay/abc", "acl-key", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a tenant with a Redis ACL user, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// defaultRedisSlowLogCount and maxRedisSlowLogCount bound ?count= on
+// /admin/redis/slowlog; Redis itself returns 10 entries by default.
+const (
+	defaultRedisSlowLogCount = 10
+	maxRedisSlowLogCount     = 1000
+)
+
+// handleRedisInfo serves GET /admin/redis/info, INFO from every backend
+// parsed into sections, optionally for one ?section= or ?backend=.
+func (s *Server) handleRedisInfo(w http.ResponseWriter, r *http.Request) {
+	backend := r.URL.Query().Get("backend")
+	if !s.checkBackend(w, backend) {
+		return
+	}
+
+	infos := s.redisClient.BackendInfo(r.Context(), backend, r.URL.Query().Get("section"))
+	s.writeJSONResponse(w, types.RedisInfoResponse{Backends: infos})
+}
+
+// handleRedisSlowLog serves GET /admin/redis/slowlog, the newest ?count=
+// entries of SLOWLOG on every backend, or on one ?backend=.
+func (s *Server) handleRedisSlowLog(w http.ResponseWriter, r *http.Request) {
+	backend := r.URL.Query().Get("backend")
+	if !s.checkBackend(w, backend) {
+		return
+	}
+
+	count, err := intParam(r.URL.Query().Get("count"), defaultRedisSlowLogCount, maxRedisSlowLogCount)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid count", http.StatusBadRequest, fmt.Errorf("count %w", err))
+		return
+	}
+
+	logs := s.redisClient.BackendSlowLog(r.Context(), backend, int64(count))
+	s.writeJSONResponse(w, types.RedisSlowLogResponse{Backends: logs})
+}
+
+// checkBackend answers 404 if backend is set but isn't one of the proxy's
+// backends.
+func (s *Server) checkBackend(w http.ResponseWriter, backend string) bool {
+	if backend == "" {
+		return true
+	}
+	names := s.redisClient.BackendNames()
+	for _, name := range names {
+		if name == backend {
+			return true
+		}
+	}
+	s.writeErrorResponse(w, "Backend not found", http.StatusNotFound,
+		fmt.Errorf("no backend named %q; backends are %s", backend, strings.Join(names, ", ")))
+	return false
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	// defaultExportLimit and maxExportLimit bound the keys per export page
+	defaultExportLimit = 1000
+	maxExportLimit     = 10000
+)
+
+// handleExport serves GET /admin/export, which streams the keys matching a
+// pattern as NDJSON: one types.ExportRecord per key, then a
+// types.ExportPage with the cursor for the next page. Values are exported
+// as JSON by type, or as DUMP payloads with format=dump.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	db := 0
+	if value := query.Get("db"); value != "" {
+		var err error
+		if db, err = strconv.Atoi(value); err != nil || db < 0 || (s.config.Redis.Databases > 0 && db >= s.config.Redis.Databases) {
+			s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidDB, fmt.Errorf("invalid db %q", value))
+			return
+		}
+	}
+
+	match := query.Get("match")
+	if match == "" {
+		match = "*"
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = types.ExportJSON
+	case types.ExportJSON, types.ExportDump:
+	default:
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument,
+			fmt.Errorf("format must be %s or %s", types.ExportJSON, types.ExportDump))
+		return
+	}
+
+	limit := defaultExportLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxExportLimit {
+			s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument,
+				fmt.Errorf("limit must be between 1 and %d", maxExportLimit))
+			return
+		}
+		limit = n
+	}
+	if !s.checkUnsharded(w) {
+		return
+	}
+
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	kind := fmt.Sprintf("export:%d:%s:%s", db, match, format)
+
+	cursor := "0"
+	if token := query.Get("cursor"); token != "" {
+		var err error
+		if cursor, err = s.authManager.VerifyCursor(token, tenantID(tenant), kind); err != nil {
+			s.writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	// SCAN pages can't be split, so a page may run over the limit by up to
+	// one SCAN page
+	page := types.ExportPage{}
+	for {
+		result, _, err := s.executeCommand(r.Context(), tenant, types.CommandRequest{
+			Command: "SCAN",
+			Args:    []interface{}{cursor, "MATCH", match, "COUNT", min(limit, maxScanCount)},
+			DB:      db,
+		})
+		var keys []string
+		if err == nil {
+			cursor, keys, err = parseScanReply(result)
+			keys = allowedKeys(tenant, keys)
+		}
+		if err != nil {
+			page.Error = err.Error()
+			break
+		}
+
+		for _, record := range s.exportKeys(r, tenant, db, format, keys) {
+			if err := encoder.Encode(record); err != nil {
+				// The client has gone away
+				return
+			}
+			page.Count++
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if cursor == "0" || page.Count >= limit {
+			break
+		}
+	}
+
+	// After an error the same page can be retried from the last cursor
+	if cursor == "0" && page.Error == "" {
+		page.Done = true
+	} else {
+		page.Cursor = s.authManager.SignCursor(tenantID(tenant), kind, cursor, auth.DefaultCursorTTL)
+	}
+	encoder.Encode(page)
+}
+
+// exportKeys reads keys in two pipelines: their TTLs and types or DUMP
+// payloads, then, for JSON exports, their values. Keys deleted since they
+// were scanned are left out.
+func (s *Server) exportKeys(r *http.Request, tenant *types.Tenant, db int, format string, keys []string) []types.ExportRecord {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	first := "TYPE"
+	if format == types.ExportDump {
+		first = "DUMP"
+	}
+	meta := types.PipelineRequest{DB: db, Commands: make([]types.CommandRequest, 0, 2*len(keys))}
+	for _, key := range keys {
+		meta.Commands = append(meta.Commands,
+			types.CommandRequest{Command: "PTTL", Args: []interface{}{key}, DB: db},
+			types.CommandRequest{Command: first, Args: []interface{}{key}, DB: db})
+	}
+	metaResults, _ := s.executePipeline(r.Context(), tenant, meta)
+
+	records := make([]types.ExportRecord, 0, len(keys))
+	values := types.PipelineRequest{DB: db}
+	var valueRecords []int
+	for i, key := range keys {
+		ttl, value := metaResults[2*i], metaResults[2*i+1]
+		if ttl.Error == "" && ttl.Result == int64(-2) {
+			continue
+		}
+
+		records = append(records, types.ExportRecord{Key: key})
+		record := &records[len(records)-1]
+		if ttl.Error != "" || value.Error != "" {
+			record.Error = ttl.Error + value.Error
+			continue
+		}
+		record.TTLMs, _ = ttl.Result.(int64)
+
+		if format == types.ExportDump {
+			payload, _ := value.Result.(string)
+			record.Dump = base64.StdEncoding.EncodeToString([]byte(payload))
+			continue
+		}
+
+		record.Type, _ = value.Result.(string)
+		if cmd, ok := server.ExportCommand(key, record.Type, db); ok {
+			values.Commands = append(values.Commands, cmd)
+			valueRecords = append(valueRecords, len(records)-1)
+		} else {
+			record.Error = fmt.Sprintf("type %s can only be exported with format=dump", record.Type)
+		}
+	}
+
+	if len(values.Commands) > 0 {
+		valueResults, _ := s.executePipeline(r.Context(), tenant, values)
+		for j, i := range valueRecords {
+			records[i].Value = valueResults[j].Result
+			records[i].Error = valueResults[j].Error
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Permissions for /v1/flags, which tenants need besides Redis commands:
+// FLAGS to read, evaluate and stream flags, FLAGS.WRITE to change them.
+const (
+	flagsReadPermission  = "FLAGS"
+	flagsWritePermission = "FLAGS.WRITE"
+)
+
+// handleListFlags serves GET /v1/flags, listing the tenant's flags.
+func (s *Server) handleListFlags(w http.ResponseWriter, r *http.Request) {
+	namespace, ok := s.authorizeFlags(w, r, flagsReadPermission)
+	if !ok {
+		return
+	}
+
+	flags, err := s.flags.List(r.Context(), namespace)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to list flags", http.StatusBadGateway, err)
+		return
+	}
+	s.writeJSONResponse(w, types.FlagsResponse{Flags: flags})
+}
+
+// handleGetFlag serves GET /v1/flags/{name}.
+func (s *Server) handleGetFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if err := types.ValidateFlagName(name, "name"); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	namespace, ok := s.authorizeFlags(w, r, flagsReadPermission)
+	if !ok {
+		return
+	}
+
+	flag, err := s.flags.Get(r.Context(), namespace, name)
+	if !s.checkFlagError(w, "Failed to get flag", err) {
+		return
+	}
+	s.writeJSONResponse(w, flag)
+}
+
+// handlePutFlag serves PUT /v1/flags/{name}, creating or replacing a flag.
+func (s *Server) handlePutFlag(w http.ResponseWriter, r *http.Request) {
+	var req types.FlagRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := types.ValidateFlagName(name, "name"); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	namespace, ok := s.authorizeFlags(w, r, flagsWritePermission)
+	if !ok {
+		return
+	}
+
+	flag := &types.Flag{
+		Name:        name,
+		Enabled:     req.Enabled,
+		Rollout:     100,
+		Subjects:    req.Subjects,
+		Description: req.Description,
+	}
+	if req.Rollout != nil {
+		flag.Rollout = *req.Rollout
+	}
+	if !s.checkFlagError(w, "Failed to save flag", s.flags.Put(r.Context(), namespace, flag)) {
+		return
+	}
+	s.writeJSONResponse(w, flag)
+}
+
+// handleDeleteFlag serves DELETE /v1/flags/{name}.
+func (s *Server) handleDeleteFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if err := types.ValidateFlagName(name, "name"); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	namespace, ok := s.authorizeFlags(w, r, flagsWritePermission)
+	if !ok {
+		return
+	}
+
+	if !s.checkFlagError(w, "Failed to delete flag", s.flags.Delete(r.Context(), namespace, name)) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleEvaluateFlags serves POST /v1/flags/evaluate, which tells whether
+// the named flags, or all of them, are on for a subject. Flags that don't
+// exist are off.
+func (s *Server) handleEvaluateFlags(w http.ResponseWriter, r *http.Request) {
+	var req types.FlagEvaluationRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	namespace, ok := s.authorizeFlags(w, r, flagsReadPermission)
+	if !ok {
+		return
+	}
+
+	flags, err := s.flags.List(r.Context(), namespace)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to evaluate flags", http.StatusBadGateway, err)
+		return
+	}
+	s.writeJSONResponse(w, evaluateFlags(flags, req.Subject, req.Flags))
+}
+
+// handleFlagStream serves GET /v1/flags/stream as server-sent events: a
+// snapshot event with every flag, then a flag event whenever one is
+// created or changed and a delete event when one is deleted. With
+// ?subject=, events carry the flags' evaluations for the subject instead,
+// and only changes to an evaluation are sent. Every flags.heartbeat a
+// comment keeps idle connections open and the flags are reloaded, in case
+// a change notification was missed.
+func (s *Server) handleFlagStream(w http.ResponseWriter, r *http.Request) {
+	namespace, ok := s.authorizeFlags(w, r, flagsReadPermission)
+	if !ok {
+		return
+	}
+	subject := r.URL.Query().Get("subject")
+
+	// Watch before loading, so no change falls in between
+	changes, stop := s.flags.Watch(namespace)
+	defer stop()
+	flags, err := s.flags.List(r.Context(), namespace)
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to list flags", http.StatusBadGateway, err)
+		return
+	}
+
+	// Streams outlive server.write_timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	var snapshot interface{} = types.FlagsResponse{Flags: flags}
+	if subject != "" {
+		snapshot = evaluateFlags(flags, subject, nil)
+	}
+	if writeEvent(w, "snapshot", snapshot) != nil {
+		return
+	}
+	sent := flagEvents(flags, subject)
+
+	heartbeat := time.NewTicker(s.config.Flags.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		if flusher != nil {
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.lifecycle.DrainingStarted():
+			// Clients reconnect, to an instance that isn't shutting down
+			return
+		case <-changes:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		flags, err := s.flags.List(r.Context(), namespace)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			// The next change or heartbeat tries again
+			if writeEvent(w, "error", types.ErrorResponse{Error: "Failed to list flags", Details: err.Error()}) != nil {
+				return
+			}
+			continue
+		}
+
+		current := flagEvents(flags, subject)
+		for _, flag := range flags {
+			if data := current[flag.Name]; !bytes.Equal(data, sent[flag.Name]) {
+				if _, err := fmt.Fprintf(w, "event: flag\ndata: %s\n\n", data); err != nil {
+					return
+				}
+			}
+		}
+		for name := range sent {
+			if _, ok := current[name]; !ok {
+				if writeEvent(w, "delete", map[string]string{"name": name}) != nil {
+					return
+				}
+			}
+		}
+		sent = current
+	}
+}
+
+// authorizeFlags checks that the tenant has permission, returning the
+// namespace its flags are in.
+func (s *Server) authorizeFlags(w http.ResponseWriter, r *http.Request, permission string) (string, bool) {
+	server.SetAccessLogCommand(r.Context(), permission)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, permission); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return "", false
+		}
+	}
+
+	// Changing a flag writes its hash
+	if permission == flagsWritePermission && !s.checkWrites(w, r, types.CommandRequest{Command: "HSET"}) {
+		return "", false
+	}
+	ok := s.checkRateLimit(w, tenant, types.CommandRequest{Command: permission}) && s.checkBudget(w, r)
+	return tenantID(tenant), ok
+}
+
+// checkFlagError writes the response for a failed flag operation,
+// reporting whether err is nil.
+func (s *Server) checkFlagError(w http.ResponseWriter, message string, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, server.ErrFlagNotFound):
+		s.writeErrorResponse(w, "Flag not found", http.StatusNotFound, err)
+	case errors.Is(err, server.ErrTooManyFlags):
+		s.writeErrorResponse(w, "Too many flags", http.StatusConflict, err)
+	default:
+		s.writeErrorResponse(w, message, http.StatusBadGateway, err)
+	}
+	return false
+}
+
+// evaluateFlags evaluates the flags named, or every flag if names is
+// empty, for subject.
+func evaluateFlags(flags []types.Flag, subject string, names []string) types.FlagEvaluationResponse {
+	response := types.FlagEvaluationResponse{Subject: subject, Flags: []types.FlagEvaluation{}}
+	if len(names) == 0 {
+		for _, flag := range flags {
+			response.Flags = append(response.Flags, server.EvaluateFlag(flag, subject))
+		}
+		return response
+	}
+
+	byName := make(map[string]types.Flag, len(flags))
+	for _, flag := range flags {
+		byName[flag.Name] = flag
+	}
+	for _, name := range names {
+		if flag, ok := byName[name]; ok {
+			response.Flags = append(response.Flags, server.EvaluateFlag(flag, subject))
+		} else {
+			response.Flags = append(response.Flags, types.FlagEvaluation{Name: name, Reason: types.FlagReasonNotFound})
+		}
+	}
+	return response
+}
+
+// flagEvents encodes the data of the flag event of every flag: the flag,
+// or its evaluation for subject.
+func flagEvents(flags []types.Flag, subject string) map[string][]byte {
+	events := make(map[string][]byte, len(flags))
+	for _, flag := range flags {
+		var data []byte
+		if subject != "" {
+			data, _ = json.Marshal(server.EvaluateFlag(flag, subject))
+		} else {
+			data, _ = json.Marshal(flag)
+		}
+		events[flag.Name] = data
+	}
+	return events
+}
+
+// writeEvent writes a server-sent event with data as JSON.
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
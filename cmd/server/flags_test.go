@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlagStreamOutsideConcurrencyLimit(t *testing.T) {
+	s, handler := newTestServer(t, `
+server:
+  concurrency:
+    enabled: true
+    initial_limit: 1
+    min_limit: 1
+    max_limit: 1
+flags:
+  enabled: true
+`)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	response, err := http.Get(ts.URL + "/v1/flags/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Stream status = %d", response.StatusCode)
+	}
+	events := bufio.NewReader(response.Body)
+	if line, err := events.ReadString('\n'); err != nil || !strings.HasPrefix(line, "event: snapshot") {
+		t.Fatalf("Expected a snapshot event, got %q, %v", line, err)
+	}
+
+	// The only concurrency slot is still free while the stream is open
+	if w := doRequest(handler, http.MethodPost, "/v1/command", "", `{"command":"PING"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected a command next to the stream to pass, got %d: %s", w.Code, w.Body.String())
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, events)
+		closed <- err
+	}()
+	s.lifecycle.StartDraining()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the stream to end once draining started")
+	}
+}
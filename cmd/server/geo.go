@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleGeoAdd serves POST /v1/geo/{key}, adding members to a geo set with
+// GEOADD.
+func (s *Server) handleGeoAdd(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	var req types.GeoAddRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	args := []interface{}{key}
+	switch {
+	case req.NX:
+		args = append(args, "NX")
+	case req.XX:
+		args = append(args, "XX")
+	}
+	for _, member := range req.Members {
+		args = append(args, member.Lon, member.Lat, member.Name)
+	}
+	cmd := types.CommandRequest{Command: "GEOADD", Args: args, DB: req.DB}
+
+	tenant, ok := s.authorizeCommand(w, r, cmd.Command, "", req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, cmd) || !s.checkValueSize(w, tenant, cmd) {
+		return
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return
+	}
+
+	added, _ := result.(int64)
+	s.writeJSONResponse(w, types.GeoAddResponse{
+		Key:   key,
+		Added: added,
+		Time:  duration.Seconds() * 1000,
+	})
+}
+
+// handleGeoSearch serves POST /v1/geo/{key}/search, finding the members
+// of a geo set within a radius or box with GEOSEARCH, with their positions
+// and distances.
+func (s *Server) handleGeoSearch(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	var req types.GeoSearchRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	cmd := types.CommandRequest{Command: "GEOSEARCH", Args: geoSearchArgs(key, req), DB: req.DB}
+	tenant, ok := s.authorizeCommand(w, r, cmd.Command, "", req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, cmd) {
+		return
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return
+	}
+
+	results, err := redis.ParseGeoSearch(result)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid GEOSEARCH reply", http.StatusBadGateway, err)
+		return
+	}
+	s.writeJSONResponse(w, types.GeoSearchResponse{
+		Key:     key,
+		Unit:    geoUnit(req),
+		Results: results,
+		Time:    duration.Seconds() * 1000,
+	})
+}
+
+// geoSearchArgs builds the GEOSEARCH arguments for req, always asking for
+// distances and coordinates.
+func geoSearchArgs(key string, req types.GeoSearchRequest) []interface{} {
+	args := []interface{}{key}
+	if req.Member != "" {
+		args = append(args, "FROMMEMBER", req.Member)
+	} else {
+		args = append(args, "FROMLONLAT", req.Center.Lon, req.Center.Lat)
+	}
+
+	unit := geoUnit(req)
+	if req.Box != nil {
+		args = append(args, "BYBOX", req.Box.Width, req.Box.Height, unit)
+	} else {
+		args = append(args, "BYRADIUS", req.Radius, unit)
+	}
+
+	order := req.Order
+	if order == "" && req.Count > 0 {
+		order = "asc"
+	}
+	if order != "" {
+		args = append(args, strings.ToUpper(order))
+	}
+	if req.Count > 0 {
+		args = append(args, "COUNT", req.Count)
+	}
+	return append(args, "WITHDIST", "WITHCOORD")
+}
+
+func geoUnit(req types.GeoSearchRequest) string {
+	if req.Unit == "" {
+		return "m"
+	}
+	return req.Unit
+}
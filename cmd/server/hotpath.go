@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Requests of /v1/command are pooled along with the buffers of its bodies,
+// and its JSON is decoded and encoded by hand, so a typical command
+// allocates little beyond its args and result. Requests with more args
+// than maxPooledArgs are left to the garbage collector.
+const maxPooledArgs = 1024
+
+var requestPool = sync.Pool{New: func() interface{} {
+	return &types.CommandRequest{Args: make([]interface{}, 0, 8)}
+}}
+
+// getCommandRequest returns a reset request to decode a command into.
+// Its args must not be kept after releaseCommandRequest: anything using
+// them once the handler returns, such as a background refresh, must clone
+// them first.
+func getCommandRequest() *types.CommandRequest {
+	return requestPool.Get().(*types.CommandRequest)
+}
+
+func releaseCommandRequest(req *types.CommandRequest) {
+	if cap(req.Args) > maxPooledArgs {
+		return
+	}
+	clear(req.Args[:cap(req.Args)])
+	*req = types.CommandRequest{Args: req.Args[:0]}
+	requestPool.Put(req)
+}
+
+// decodeCommand reads a /v1/command body into a pooled buffer and decodes
+// it into req, writing the same errors as decodeJSON.
+func (s *Server) decodeCommand(w http.ResponseWriter, r *http.Request, req *types.CommandRequest) bool {
+	body := server.GetBuffer()
+	defer server.PutBuffer(body)
+
+	_, err := body.ReadFrom(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err == nil {
+		err = types.DecodeCommandRequest(body.Bytes(), req)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.writeErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge, err)
+			return false
+		}
+		s.writeCodedError(w, "Invalid JSON", http.StatusBadRequest, types.ErrCodeInvalidJSON, err)
+		return false
+	}
+	return true
+}
+
+// writeCommandResponse writes response like writeJSONResponse, encoding it
+// into a pooled buffer. With server.redis_error_status, a failed command
+// gets the status its error maps to.
+func (s *Server) writeCommandResponse(w http.ResponseWriter, response types.CommandResponse) {
+	status := http.StatusOK
+	if response.Error != "" && s.config.Server.RedisErrorStatus {
+		status = server.RedisErrorStatus(response.RedisCode, response.Code)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	buffer := server.GetBuffer()
+	defer server.PutBuffer(buffer)
+	encoded, err := response.AppendJSON(buffer.AvailableBuffer())
+	if err == nil {
+		buffer.Write(append(encoded, '\n'))
+		_, _ = w.Write(buffer.Bytes())
+	}
+}
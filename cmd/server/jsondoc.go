@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// jsonRootPath is the path JSON.SET and JSON.MERGE write to by default.
+const jsonRootPath = "$"
+
+// handleGetJSON serves GET /v1/json/{key}, a RedisJSON document, or with
+// ?path= the JSON.GET reply for that path, embedded as JSON.
+func (s *Server) handleGetJSON(w http.ResponseWriter, r *http.Request) {
+	if !s.checkModule(w, redis.ModuleJSON, "RedisJSON") {
+		return
+	}
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+
+	// Without a path JSON.GET returns the whole document; a JSONPath
+	// returns an array of its matches
+	path := r.URL.Query().Get("path")
+	cmd := types.CommandRequest{Command: "JSON.GET", Args: []interface{}{kv.key}, DB: kv.db}
+	if path != "" {
+		cmd.Args = append(cmd.Args, path)
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, cmd.Command, "", kv.db)
+	if !ok || !s.checkKeyPolicy(w, tenant, cmd) {
+		return
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if redis.IsNil(err) {
+		s.writeErrorResponse(w, "Key not found", http.StatusNotFound, fmt.Errorf("key %q does not exist", kv.key))
+		return
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return
+	}
+
+	value, _ := result.(string)
+	if !json.Valid([]byte(value)) {
+		s.writeErrorResponse(w, "Invalid JSON reply", http.StatusBadGateway, fmt.Errorf("JSON.GET replied %q", value))
+		return
+	}
+	s.writeJSONResponse(w, types.JSONDocumentResponse{
+		Key:   kv.key,
+		Path:  path,
+		Value: json.RawMessage(value),
+		Time:  duration.Seconds() * 1000,
+	})
+}
+
+// handleSetJSON serves PUT /v1/json/{key}, storing the request body at
+// ?path= (the root by default) with JSON.SET. ?nx=true or ?xx=true only
+// set a path that doesn't, or does, exist.
+func (s *Server) handleSetJSON(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	nx, xx := query.Get("nx") == "true", query.Get("xx") == "true"
+	if nx && xx {
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeConflictingField,
+			errors.New("nx and xx are mutually exclusive"))
+		return
+	}
+
+	var condition []interface{}
+	switch {
+	case nx:
+		condition = []interface{}{"NX"}
+	case xx:
+		condition = []interface{}{"XX"}
+	}
+	s.writeJSONDocument(w, r, "JSON.SET", condition...)
+}
+
+// handleMergeJSON serves PATCH /v1/json/{key}, merging the request body
+// into ?path= (the root by default) with JSON.MERGE, as in RFC 7396: null
+// members delete, objects merge and anything else replaces.
+func (s *Server) handleMergeJSON(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONDocument(w, r, "JSON.MERGE")
+}
+
+// writeJSONDocument runs command with the key, path and the request body,
+// followed by options.
+func (s *Server) writeJSONDocument(w http.ResponseWriter, r *http.Request, command string, options ...interface{}) {
+	if !s.checkModule(w, redis.ModuleJSON, "RedisJSON") {
+		return
+	}
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		path = jsonRootPath
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, command, "", kv.db)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		s.writeBodyError(w, tenant, err, false)
+		return
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err != nil {
+		s.writeCodedError(w, "Invalid JSON", http.StatusBadRequest, types.ErrCodeInvalidJSON, err)
+		return
+	}
+
+	cmd := types.CommandRequest{
+		Command: command,
+		Args:    append([]interface{}{kv.key, path, compacted.String()}, options...),
+		DB:      kv.db,
+	}
+	if !s.checkKeyPolicy(w, tenant, cmd) || !s.checkValueSize(w, tenant, cmd) {
+		return
+	}
+
+	// A nil reply means the NX or XX condition wasn't met
+	_, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	updated := !redis.IsNil(err)
+	if updated && err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return
+	}
+
+	s.writeJSONResponse(w, types.JSONWriteResponse{
+		Key:     kv.key,
+		Path:    path,
+		Updated: updated,
+		Time:    duration.Seconds() * 1000,
+	})
+}
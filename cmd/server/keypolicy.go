@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// checkKeyPolicy rejects requests naming a key that doesn't match the
+// tenant's key_pattern with 403 before anything is sent to Redis.
+func (s *Server) checkKeyPolicy(w http.ResponseWriter, tenant *types.Tenant, commands ...types.CommandRequest) bool {
+	if err := keyPolicyViolation(tenant, commands...); err != nil {
+		s.writeCodedError(w, "Key not allowed", http.StatusForbidden, types.ErrCodeKeyPolicy, err)
+		return false
+	}
+	return true
+}
+
+// keyPolicyViolation returns the error for the first key of commands not
+// matching the tenant's key_pattern, if any doesn't. Reads are checked as
+// well as writes, so tenants keep to their own keys.
+func keyPolicyViolation(tenant *types.Tenant, commands ...types.CommandRequest) error {
+	if tenant == nil || tenant.KeyPattern == nil {
+		return nil
+	}
+
+	for i, cmd := range commands {
+		for _, key := range redis.CommandKeys(cmd) {
+			if keyAllowed(tenant, key) {
+				continue
+			}
+
+			field := "args"
+			if len(commands) > 1 {
+				field = fmt.Sprintf("commands[%d].args", i)
+			}
+			return &types.ValidationError{Field: field, Message: fmt.Sprintf("key %q does not match the key pattern %s", key, tenant.KeyPattern)}
+		}
+	}
+	return nil
+}
+
+// keyAllowed reports whether key matches the tenant's key_pattern.
+func keyAllowed(tenant *types.Tenant, key string) bool {
+	return tenant == nil || tenant.KeyPattern == nil || tenant.KeyPattern.MatchString(key)
+}
+
+// allowedKeys drops the keys not matching the tenant's key_pattern from
+// keys found by SCAN, which can't be limited to the pattern up front.
+func allowedKeys(tenant *types.Tenant, keys []string) []string {
+	if tenant == nil || tenant.KeyPattern == nil {
+		return keys
+	}
+	allowed := keys[:0]
+	for _, key := range keys {
+		if keyAllowed(tenant, key) {
+			allowed = append(allowed, key)
+		}
+	}
+	return allowed
+}
+
+// checkAnonymousCommands rejects anonymous commands that could write, or
+// read more than the keys they name: anonymous requests are confined by a
+// key prefix, which keyspace and search commands would get around.
+func checkAnonymousCommands(commands []string) error {
+	for _, command := range commands {
+		keys := redis.CommandKeys(types.CommandRequest{Command: command, Args: []interface{}{"key"}})
+		if !redis.IsReadOnly(command) || len(keys) == 0 || strings.HasPrefix(strings.ToUpper(command), "FT.") {
+			return fmt.Errorf("auth anonymous command %s: only read-only commands on named keys are allowed", command)
+		}
+	}
+	return nil
+}
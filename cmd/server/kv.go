@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// maxRequestBodySize bounds request bodies, including the value accepted
+// by PUT /v1/keys/{key}.
+const maxRequestBodySize = 16 << 20
+
+// kvRequest holds the parts of a /v1/keys request shared by all methods.
+type kvRequest struct {
+	key    string
+	db     int
+	asJSON bool
+}
+
+func parseKVRequest(r *http.Request) (kvRequest, error) {
+	req := kvRequest{key: mux.Vars(r)["key"]}
+
+	query := r.URL.Query()
+	if db := query.Get("db"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil {
+			return req, fmt.Errorf("invalid db %q", db)
+		}
+		req.db = n
+	}
+
+	switch as := query.Get("as"); as {
+	case "", "string":
+	case "json":
+		req.asJSON = true
+	default:
+		return req, fmt.Errorf("unsupported value for as: %q", as)
+	}
+
+	return req, nil
+}
+
+// handleGetKey serves GET /v1/keys/{key}. With ?as=json the stored string
+// is parsed and returned as a JSON value instead of an encoded string, and
+// with ?raw=1 or Accept: application/octet-stream it is returned bare, so
+// e.g. an image can be linked to directly. The two can't be combined.
+func (s *Server) handleGetKey(w http.ResponseWriter, r *http.Request) {
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+	raw, rawType, err := rawContentType(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+	if raw && kv.asJSON {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, errors.New("as=json can't be combined with a raw reply"))
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "GET", "", kv.db)
+	if !ok || !s.checkKeyPolicy(w, tenant, types.CommandRequest{Command: "GET", Args: []interface{}{kv.key}}) {
+		return
+	}
+
+	result, duration, err := s.readKey(r.Context(), tenant, kv)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if redis.IsNil(err) {
+		s.writeErrorResponse(w, "Key not found", http.StatusNotFound, fmt.Errorf("key %q does not exist", kv.key))
+		return
+	}
+	if large, ok := result.(*redis.LargeValue); ok {
+		if !kv.asJSON {
+			s.writeLargeValue(w, r, tenant, kv, large, raw, rawType, duration)
+			return
+		}
+		// JSON has to be checked whole before any of it is sent
+		var value strings.Builder
+		if _, err = large.WriteTo(r.Context(), &value); err == nil {
+			result = value.String()
+		} else {
+			result = nil
+		}
+	}
+	if raw {
+		s.writeRawResponse(w, rawType, result, err)
+		return
+	}
+
+	response := newCommandResponse(result, duration, err)
+	if err != nil {
+		s.writeCommandResponse(w, response)
+		return
+	}
+
+	if kv.asJSON {
+		str, _ := result.(string)
+		if !json.Valid([]byte(str)) {
+			s.writeErrorResponse(w, "Stored value is not valid JSON", http.StatusUnprocessableEntity,
+				fmt.Errorf("value of key %q cannot be decoded as JSON", kv.key))
+			return
+		}
+		response.Result = json.RawMessage(str)
+		response.Type = string(types.ResponseTypeJSON)
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleSetKey serves PUT /v1/keys/{key}, storing the raw request body as
+// the value. With ?as=json the body must be valid JSON and is stored in
+// compact form so clients don't have to encode it into a string first.
+// Other bodies above server.large_values.threshold are streamed to Redis.
+func (s *Server) handleSetKey(w http.ResponseWriter, r *http.Request) {
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "SET", "", kv.db)
+	if !ok {
+		return
+	}
+
+	// The key is checked before a streamed body reaches Redis, the value
+	// once it's read
+	keyOnly := types.CommandRequest{Command: "SET", Args: []interface{}{kv.key}}
+	if !s.checkKeyPolicy(w, tenant, keyOnly) || !s.checkValueSize(w, tenant, keyOnly) {
+		return
+	}
+
+	var body []byte
+	if s.streamsValue(r, kv) {
+		if body, ok = s.setLargeValue(w, r, tenant, kv); !ok {
+			return
+		}
+	} else if body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize)); err != nil {
+		s.writeErrorResponse(w, "Failed to read body", http.StatusBadRequest, err)
+		return
+	}
+
+	value := string(body)
+	if kv.asJSON {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, body); err != nil {
+			s.writeCodedError(w, "Invalid JSON", http.StatusBadRequest, types.ErrCodeInvalidJSON, err)
+			return
+		}
+		value = compacted.String()
+	}
+
+	cmd := types.CommandRequest{
+		Command: "SET",
+		Args:    []interface{}{kv.key, value},
+		DB:      kv.db,
+	}
+	if !s.checkValueSize(w, tenant, cmd) {
+		return
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+
+	s.writeCommandResponse(w, newCommandResponse(result, duration, err))
+}
+
+// handleGetOrSet serves POST /v1/keys/{key}/get-or-set, which returns the
+// key's value or, if it doesn't exist, sets it to the request body and
+// returns that. SET NX GET does both in one step, so concurrent callers
+// all see the value that won. ?ttl= expires a value that gets set.
+func (s *Server) handleGetOrSet(w http.ResponseWriter, r *http.Request) {
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+
+	var ttl time.Duration
+	if value := r.URL.Query().Get("ttl"); value != "" {
+		if ttl, err = time.ParseDuration(value); err != nil || ttl < time.Millisecond {
+			s.writeCodedError(w, "Invalid ttl", http.StatusBadRequest, types.ErrCodeInvalidArgument,
+				errors.New("ttl must be a duration of at least 1ms, e.g. 10m"))
+			return
+		}
+	}
+
+	// SET with GET reads the key too, but is one command to charge for
+	if !s.permitCommand(w, r, "GET") {
+		return
+	}
+	tenant, ok := s.authorizeCommand(w, r, "SET", "", kv.db)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to read body", http.StatusBadRequest, err)
+		return
+	}
+
+	value := string(body)
+	if kv.asJSON {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, body); err != nil {
+			s.writeCodedError(w, "Invalid JSON", http.StatusBadRequest, types.ErrCodeInvalidJSON, err)
+			return
+		}
+		value = compacted.String()
+	}
+
+	cmd := types.CommandRequest{
+		Command: "SET",
+		Args:    []interface{}{kv.key, value, "NX", "GET"},
+		DB:      kv.db,
+	}
+	if ttl > 0 {
+		cmd.Args = append(cmd.Args, "PX", ttl.Milliseconds())
+	}
+	if !s.checkKeyPolicy(w, tenant, cmd) || !s.checkValueSize(w, tenant, cmd) {
+		return
+	}
+
+	// A nil reply means there was no value, so the default was set
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	created := redis.IsNil(err)
+	if created {
+		result, err = value, nil
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(result, duration, err))
+		return
+	}
+
+	response := types.GetOrSetResponse{
+		Result:  result,
+		Type:    string(redis.InferResponseType(result)),
+		Created: created,
+		Time:    duration.Seconds() * 1000,
+	}
+	if kv.asJSON {
+		str, _ := result.(string)
+		if !json.Valid([]byte(str)) {
+			s.writeErrorResponse(w, "Stored value is not valid JSON", http.StatusUnprocessableEntity,
+				fmt.Errorf("value of key %q cannot be decoded as JSON", kv.key))
+			return
+		}
+		response.Result = json.RawMessage(str)
+		response.Type = string(types.ResponseTypeJSON)
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// handleDeleteKey serves DELETE /v1/keys/{key}.
+func (s *Server) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
+	kv, err := parseKVRequest(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+	if kv.asJSON {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, errors.New("as=json is not supported for DELETE"))
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "DEL", "", kv.db)
+	if !ok || !s.checkKeyPolicy(w, tenant, types.CommandRequest{Command: "DEL", Args: []interface{}{kv.key}}) {
+		return
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, types.CommandRequest{
+		Command: "DEL",
+		Args:    []interface{}{kv.key},
+		DB:      kv.db,
+	})
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+
+	s.writeCommandResponse(w, newCommandResponse(result, duration, err))
+}
+
+// handleSet serves POST /v1/set, a SET whose NX/XX/EX/PX/KEEPTTL/GET options
+// are typed fields validated before anything is sent to Redis.
+func (s *Server) handleSet(w http.ResponseWriter, r *http.Request) {
+	var req types.SetRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "SET", "", req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, req.Command()) || !s.checkValueSize(w, tenant, req.Command()) {
+		return
+	}
+
+	// A nil reply means the NX/XX condition wasn't met or, with GET, that
+	// there was no previous value; neither is an error.
+	result, duration, err := s.executeCommand(r.Context(), tenant, req.Command())
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if redis.IsNil(err) {
+		result, err = nil, nil
+	}
+
+	s.writeCommandResponse(w, newCommandResponse(result, duration, err))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestGetOrSetChargedOnce(t *testing.T) {
+	_, handler := newTestServer(t, `
+auth:
+  enabled: true
+  jwt_secret: test-secret
+  api_keys:
+    - key: config-key
+      tenant_id: config
+      allowed_dbs: [0]
+      rate_limit: 1
+      permissions: [GET, SET]
+rate_limit:
+  enabled: true
+  default_cost: 1
+`)
+
+	w := doRequest(handler, http.MethodPost, "/v1/keys/flags/get-or-set", "config-key", "on")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a request within the rate limit to pass, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(handler, http.MethodPost, "/v1/keys/flags/get-or-set", "config-key", "on"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the next request to be limited, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestKeysAsJSON(t *testing.T) {
+	_, handler := newTestServer(t, "")
+
+	// Bodies are stored compacted
+	if w := doRequest(handler, http.MethodPut, "/v1/keys/doc?as=json", "", "{\n  \"a\": [1, 2]\n}"); w.Code != http.StatusOK {
+		t.Fatalf("Expected the JSON body to be stored, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(handler, http.MethodGet, "/v1/keys/doc", "", ""); !strings.Contains(w.Body.String(), `"result":"{\"a\":[1,2]}"`) {
+		t.Errorf("Expected the compacted value, got %d: %s", w.Code, w.Body.String())
+	}
+	w := doRequest(handler, http.MethodGet, "/v1/keys/doc?as=json", "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"result":{"a":[1,2]}`) {
+		t.Errorf("Expected the value as JSON, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(handler, http.MethodPut, "/v1/keys/doc?as=json", "", `{"a":`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), types.ErrCodeInvalidJSON) {
+		t.Errorf("Expected 400 %s, got %d: %s", types.ErrCodeInvalidJSON, w.Code, w.Body.String())
+	}
+
+	doRequest(handler, http.MethodPut, "/v1/keys/text", "", "not json")
+	if w := doRequest(handler, http.MethodGet, "/v1/keys/text?as=json", "", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a value that isn't JSON, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(handler, http.MethodDelete, "/v1/keys/doc?as=json", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for DELETE with as=json, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A raw reply can't be JSON as well
+	if w := doRequest(handler, http.MethodGet, "/v1/keys/doc?as=json&raw=1", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for raw=1 with as=json, got %d: %s", w.Code, w.Body.String())
+	}
+	r := httptest.NewRequest(http.MethodGet, "/v1/keys/doc?as=json", nil)
+	r.Header.Set("Accept", "application/octet-stream")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an octet-stream Accept with as=json, got %d: %s", w.Code, w.Body.String())
+	}
+}
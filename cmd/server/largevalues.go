@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// readKey reads the value of a /v1/keys key like GET, except that values
+// above server.large_values.threshold come back as a *redis.LargeValue to
+// be streamed.
+func (s *Server) readKey(ctx context.Context, tenant *types.Tenant, kv kvRequest) (interface{}, time.Duration, error) {
+	large := s.config.Server.LargeValues
+	req := types.CommandRequest{Command: "GET", Args: []interface{}{kv.key}, DB: kv.db}
+	result, duration, err := s.executeWith(ctx, tenant, req, func(ctx context.Context) (interface{}, error) {
+		return s.redisClient.ReadValue(ctx, kv.db, kv.key, large.Threshold, large.ChunkSize)
+	})
+	if _, streamed := result.(*redis.LargeValue); err == nil && !streamed {
+		s.trackValue(ctx, tenant, req, kv.db, result)
+	}
+	return result, duration, err
+}
+
+// writeLargeValue streams a large value read for GET /v1/keys/{key}, bare
+// or as the string result of a JSON response. Its length isn't known up
+// front, so the response is chunked; a Redis error halfway through aborts
+// it, which clients see as a truncated response.
+func (s *Server) writeLargeValue(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, kv kvRequest, value *redis.LargeValue, raw bool, rawType string, duration time.Duration) {
+	start := time.Now()
+	var written int64
+	var err error
+	if raw {
+		if rawType == "" {
+			rawType = http.DetectContentType([]byte(value.Head()))
+		}
+		setRawHeaders(w, rawType)
+		w.WriteHeader(http.StatusOK)
+		written, err = value.WriteTo(r.Context(), w)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `{"result":"`)
+		escaper := &jsonStringWriter{w: w}
+		written, err = value.WriteTo(r.Context(), escaper)
+		if err == nil {
+			err = escaper.Close()
+		}
+		if err == nil {
+			// The time includes streaming, which is most of the work
+			elapsed, _ := json.Marshal((duration + time.Since(start)).Seconds() * 1000)
+			_, err = fmt.Fprintf(w, `","type":"%s","time":%s}`+"\n", types.ResponseTypeString, elapsed)
+		}
+	}
+
+	req := types.CommandRequest{Command: "GET", Args: []interface{}{kv.key}, DB: kv.db}
+	s.trackValueSize(r.Context(), tenant, req, kv.db, server.ValueAccess{Key: kv.key}, int(written))
+	if err != nil {
+		log.Printf("Streaming value of key %q failed after %d bytes: %v", kv.key, written, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// jsonStringWriter writes what is written to it to w as the inside of a
+// JSON string, escaped as encoding/json would. A UTF-8 sequence split
+// between two writes is held back until it is complete.
+type jsonStringWriter struct {
+	w       io.Writer
+	pending []byte
+	buffer  bytes.Buffer
+}
+
+func (j *jsonStringWriter) Write(p []byte) (int, error) {
+	data := append(j.pending, p...)
+
+	// Hold back a trailing sequence that isn't complete yet
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	j.pending = append([]byte(nil), data[cut:]...)
+
+	if err := j.flush(data[:cut]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes what is held back, replacing it if it never completed.
+func (j *jsonStringWriter) Close() error {
+	pending := j.pending
+	j.pending = nil
+	return j.flush(pending)
+}
+
+func (j *jsonStringWriter) flush(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	j.buffer.Reset()
+	if err := json.NewEncoder(&j.buffer).Encode(strings.ToValidUTF8(string(data), "\uFFFD")); err != nil {
+		return err
+	}
+	// Drop the quotes and the encoder's newline
+	encoded := j.buffer.Bytes()
+	_, err := j.w.Write(encoded[1 : len(encoded)-2])
+	return err
+}
+
+// streamsValue reports whether the body of a PUT /v1/keys request should
+// be streamed to Redis: it isn't JSON to check, and it is longer than the
+// threshold or of unknown length.
+func (s *Server) streamsValue(r *http.Request, kv kvRequest) bool {
+	return !kv.asJSON && (r.ContentLength < 0 || r.ContentLength > int64(s.config.Server.LargeValues.Threshold))
+}
+
+// setLargeValue handles a PUT /v1/keys/{key} whose body may be large. Up
+// to the threshold it is read whole and returned for the caller to set as
+// usual; past it, it streams to Redis in chunks and false is returned
+// once the response is written.
+func (s *Server) setLargeValue(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, kv kvRequest) ([]byte, bool) {
+	large := s.config.Server.LargeValues
+	limit := large.MaxSize
+	tenantLimit := int64(s.maxValueBytes(tenant))
+	if tenantLimit > 0 && tenantLimit < limit {
+		limit = tenantLimit
+	}
+	body := http.MaxBytesReader(w, r.Body, limit)
+
+	head, err := io.ReadAll(io.LimitReader(body, int64(large.Threshold)+1))
+	if err != nil {
+		s.writeBodyError(w, tenant, err, limit == tenantLimit)
+		return nil, false
+	}
+	if len(head) <= large.Threshold {
+		return head, true
+	}
+
+	// Errors reading the rest of the body come back from WriteValue
+	var bodyErr error
+	rest := readerFunc(func(p []byte) (int, error) {
+		n, err := body.Read(p)
+		if err != nil && err != io.EOF {
+			bodyErr = err
+		}
+		return n, err
+	})
+
+	req := types.CommandRequest{Command: "SET", Args: []interface{}{kv.key}, DB: kv.db}
+	var written int64
+	_, duration, err := s.executeWith(r.Context(), tenant, req, func(ctx context.Context) (interface{}, error) {
+		var uploadErr error
+		written, uploadErr = s.redisClient.WriteValue(ctx, kv.db, kv.key, io.MultiReader(bytes.NewReader(head), rest), large.ChunkSize)
+		return nil, uploadErr
+	})
+	switch {
+	case bodyErr != nil:
+		s.writeBodyError(w, tenant, bodyErr, limit == tenantLimit)
+	case s.checkPoolExhausted(w, err):
+	case err != nil:
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+	default:
+		s.trackValueSize(r.Context(), tenant, req, kv.db, server.ValueAccess{Key: kv.key, Write: true}, int(written))
+		s.writeCommandResponse(w, newCommandResponse("OK", duration, nil))
+	}
+	return nil, false
+}
+
+// readerFunc adapts a function to io.Reader.
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+// writeBodyError answers a PUT whose body couldn't be read, or was longer
+// than allowed: by the tenant's value size limit when tenantLimit is set,
+// else by server.large_values.max_size.
+func (s *Server) writeBodyError(w http.ResponseWriter, tenant *types.Tenant, err error, tenantLimit bool) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge) && tenantLimit:
+		s.metrics.RecordValueTooLarge(tenant)
+		s.writeCodedError(w, "Value too large", http.StatusRequestEntityTooLarge, types.ErrCodeValueTooLarge,
+			&types.ValidationError{Field: "body", Message: fmt.Sprintf("%s: more than the limit of %d bytes", server.ErrValueTooLarge, tooLarge.Limit)})
+	case errors.As(err, &tooLarge):
+		s.writeErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge, err)
+	default:
+		s.writeErrorResponse(w, "Failed to read body", http.StatusBadRequest, err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// listenerConfigs returns server.listeners, or without any the listener
+// on host:port with server.tls. With server.admin, that listener comes
+// last and the others serving everything are limited to the API.
+func listenerConfigs(cfg types.ServerConfig) []types.ListenerConfig {
+	listeners := []types.ListenerConfig{{
+		Name:    "default",
+		Network: types.ListenerTCP,
+		Address: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		TLS:     cfg.TLS,
+	}}
+	if len(cfg.Listeners) > 0 {
+		listeners = append([]types.ListenerConfig(nil), cfg.Listeners...)
+	}
+
+	if cfg.Admin.Address != "" {
+		for i := range listeners {
+			if listeners[i].Routes == "" {
+				listeners[i].Routes = types.ListenerRoutesAPI
+			}
+		}
+		admin := cfg.Admin
+		admin.Name = "admin"
+		admin.Routes = types.ListenerRoutesAdmin
+		listeners = append(listeners, admin)
+	}
+	return listeners
+}
+
+// listen opens the listener's address. A Unix socket left behind by a
+// previous run is removed first.
+func listen(config types.ListenerConfig) (net.Listener, error) {
+	if config.Network != types.ListenerUnix {
+		return net.Listen("tcp", config.Address)
+	}
+
+	if info, err := os.Stat(config.Address); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(config.Address); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	if config.SocketMode != "" {
+		mode, _ := strconv.ParseUint(config.SocketMode, 8, 32)
+		if err := os.Chmod(config.Address, os.FileMode(mode)); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// listenerURL describes where a listener serves, for the startup banner.
+func listenerURL(config types.ListenerConfig) string {
+	if config.Network == types.ListenerUnix {
+		return "unix:" + config.Address
+	}
+	if config.TLS.Enabled {
+		return "https://" + config.Address
+	}
+	return "http://" + config.Address
+}
+
+// restrictRoutes limits handler to the routes of a listener: api serves
+// the tenant-facing routes, admin serves /admin, /debug and metrics, and
+// both serve health and readiness. Listeners serving everything still
+// leave out /debug. Other paths are not found.
+func restrictRoutes(routes, metricsPath string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		debug := path == "/debug" || strings.HasPrefix(path, "/debug/")
+		admin := debug || path == metricsPath || path == "/admin" || strings.HasPrefix(path, "/admin/")
+		shared := path == "/health" || path == "/ready"
+
+		var allowed bool
+		switch routes {
+		case types.ListenerRoutesAPI:
+			allowed = !admin
+		case types.ListenerRoutesAdmin:
+			allowed = admin || shared
+		default:
+			allowed = !debug
+		}
+		if !allowed {
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serve runs httpServer on listener until it is shut down.
+func serve(httpServer *http.Server, listener net.Listener, tls types.TLSConfig) error {
+	var err error
+	if tls.Enabled {
+		err = httpServer.ServeTLS(listener, tls.CertFile, tls.KeyFile)
+	} else {
+		err = httpServer.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// shutdownAll shuts down every server at once, so none keeps accepting
+// requests while another drains.
+func shutdownAll(ctx context.Context, httpServers []*http.Server) error {
+	errs := make([]error, len(httpServers))
+	var wg sync.WaitGroup
+	for i, httpServer := range httpServers {
+		httpServer.SetKeepAlivesEnabled(false)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = httpServer.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleMacro serves POST /v1/macros/{name}, running a macro from the
+// config as a pipeline or transaction. The tenant needs the MACRO:<name>
+// permission; its commands aren't checked one by one, so operators can
+// grant a multi-step operation without granting each command.
+func (s *Server) handleMacro(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	macro, ok := s.macro(name)
+	if !ok {
+		s.writeErrorResponse(w, "Macro not found", http.StatusNotFound, fmt.Errorf("no macro named %q", name))
+		return
+	}
+
+	server.SetAccessLogCommand(r.Context(), "MACRO:"+name)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, "MACRO:"+name); err != nil {
+			s.writeErrorResponse(w, "Macro not permitted", http.StatusForbidden, err)
+			return
+		}
+	}
+
+	var req types.MacroRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	commands, err := server.ExpandMacro(macro, req.Params)
+	if err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	if tenant != nil {
+		if err := s.validateDatabase(tenant, req.Database, req.DB); err != nil {
+			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
+			return
+		}
+	}
+
+	pipeline := types.PipelineRequest{Commands: commands, DB: req.DB, Database: req.Database}
+	txn := types.TransactionRequest{Commands: commands, DB: req.DB, Database: req.Database}
+	if err := pipeline.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	// Transactions must stay on one shard; pipelines may span several
+	shardErr := s.redisClient.ValidatePipelineShards(pipeline)
+	if macro.Transaction {
+		shardErr = s.redisClient.ValidateTransactionShard(txn)
+	}
+	if shardErr != nil {
+		s.writeValidationError(w, shardErr)
+		return
+	}
+
+	if !s.checkWrites(w, r, commands...) || !s.checkKeyPolicy(w, tenant, commands...) ||
+		!s.checkValueSize(w, tenant, commands...) || !s.checkRateLimit(w, tenant, commands...) ||
+		!s.checkBudget(w, r) {
+		return
+	}
+
+	if macro.Transaction {
+		s.runTransaction(w, r, tenant, txn)
+	} else {
+		s.runPipeline(w, r, tenant, pipeline)
+	}
+}
+
+// macro returns the configured macro called name.
+func (s *Server) macro(name string) (types.MacroConfig, bool) {
+	for _, macro := range s.config.Macros {
+		if macro.Name == name {
+			return macro, true
+		}
+	}
+	return types.MacroConfig{}, false
+}
@@ -3,40 +3,120 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	
 	"github.com/scaler/serverless-redis/internal/auth"
 	"github.com/scaler/serverless-redis/internal/config"
 	"github.com/scaler/serverless-redis/internal/metrics"
+	"github.com/scaler/serverless-redis/internal/observability"
 	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/secrets"
 	"github.com/scaler/serverless-redis/internal/server"
 	"github.com/scaler/serverless-redis/internal/types"
 )
 
 const Version = "1.0.0-optimized"
 
+// responseCacheBytes bounds the cache of /health, /metrics and other GET
+// responses, which aren't tenant-specific
+const responseCacheBytes = 16 << 20
+
 type Server struct {
 	config      *types.Config
 	redisClient *redis.Client
+	embedded    *redis.Embedded // Set in redis.mode embedded
+	faults      *server.FaultInjector
+	recorder    *server.Recorder
 	authManager *auth.Manager
 	metrics     *metrics.Collector
 	cache       *server.InMemoryCache
+	otlp        *observability.Pipeline
+	memoryGuard *server.MemoryGuard
+	concurrency *server.ConcurrencyLimiter
+	rateLimiter *server.RateLimiter
+	bigValues   *server.BigValueTracker
+	slowLog     *server.SlowLog
+	drainer     *server.Drainer
+	readCache   *server.InMemoryCache
+	maintenance *server.MaintenanceScheduler
+	lifecycle   *server.Lifecycle
+	scripts     *redis.ScriptRegistry
+	accessLog   *server.AccessLog
 	startTime   time.Time
+
+	// Delayed tasks of /v1/delay; nil unless delay is enabled
+	delays *server.DelayQueue
+
+	// Feature flags of /v1/flags; nil unless flags are enabled
+	flags *server.FlagStore
+
+	// Transaction sessions of /v1/session; nil unless tx_sessions is
+	// enabled
+	txSessions *server.TxSessions
+
+	// Writes queued while Redis is unreachable; nil unless oplog is
+	// enabled
+	opLog *server.OpLog
+
+	// Guards metrics, /debug and /admin with metrics.access
+	operatorAccess func(http.Handler) http.Handler
+
+	// Redis modules loaded on every backend, detected at startup
+	modules map[string]bool
+
+	// Sends panics and 5xx responses to Sentry or a webhook
+	errorReporter *observability.ErrorReporter
+
+	// Replies to commands with a cache rule, by tenant
+	commandCache *server.InMemoryCache
+	commandRules map[string]types.CacheRule
+
+	// Parent of every request context, cancelled when draining times out
+	requestCtx     context.Context
+	cancelRequests context.CancelFunc
+
+	// The configuration as last reloaded; config stays as started
+	liveMutex sync.Mutex
+	live      *types.Config
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 func main() {
+	configPath := flag.String("config", "", "config file (default $CONFIG_PATH or config.yaml)")
+	validateOnly := flag.Bool("validate-config", false, "validate the configuration and exit")
+	checkRedis := flag.Bool("check-redis", false, "with -validate-config, also connect to every Redis backend")
+	flag.Parse()
+	
+	if *validateOnly {
+		os.Exit(validateConfigFile(os.Stdout, *configPath, *checkRedis))
+	}
+	
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	if *configPath == "" {
+		*configPath = config.Path()
+	}
+	loader := config.NewLoader(*configPath)
+	cfg, err := loader.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
@@ -51,25 +131,56 @@ func main() {
 	// Setup routes
 	router := server.setupRoutes()
 
-	// Create HTTP server
-	httpServer := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	// Create an HTTP server per listener, opening them all before anything
+	// starts so that a taken address fails startup
+	listenerConfigs := listenerConfigs(cfg.Server)
+	listeners := make([]net.Listener, len(listenerConfigs))
+	httpServers := make([]*http.Server, len(listenerConfigs))
+	for i, listenerConfig := range listenerConfigs {
+		if listeners[i], err = listen(listenerConfig); err != nil {
+			log.Fatalf("Failed to listen on %s: %v", listenerURL(listenerConfig), err)
+		}
+		
+		httpServers[i] = &http.Server{
+			Handler:      restrictRoutes(listenerConfig.Routes, cfg.Metrics.Path, router),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+			IdleTimeout:  cfg.Server.IdleTimeout,
+			
+			// Cancelled if requests are still running when the shutdown
+			// deadline passes, so their Redis commands stop cleanly
+			BaseContext: func(net.Listener) context.Context { return server.requestCtx },
+		}
 	}
 
-	// Start background services
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Start background services; they stop before Redis clients close
+	lifecycle := server.lifecycle
 	
 	if cfg.Metrics.Enabled {
-		go server.metrics.StartPeriodicUpdates(ctx, 30*time.Second)
+		lifecycle.Go(func(ctx context.Context) {
+			server.metrics.StartPeriodicUpdates(ctx, 30*time.Second)
+		})
+	}
+	
+	if server.otlp != nil {
+		log.SetOutput(io.MultiWriter(os.Stderr, server.otlp.LogWriter()))
+		server.otlp.Start()
+		lifecycle.OnShutdown("otlp", func(ctx context.Context) error {
+			server.otlp.Shutdown(ctx)
+			return nil
+		})
+	}
+	
+	if server.errorReporter != nil {
+		server.errorReporter.Start()
+		lifecycle.OnShutdown("error reporting", func(ctx context.Context) error {
+			server.errorReporter.Shutdown(ctx)
+			return nil
+		})
 	}
 	
 	// Start cache cleanup (simple background cleanup)
-	go func() {
+	lifecycle.Go(func(ctx context.Context) {
 		ticker := time.NewTicker(5 * time.Minute)
 		defer ticker.Stop()
 		for {
@@ -78,16 +189,144 @@ func main() {
 				return
 			case <-ticker.C:
 				server.cache.ClearExpired()
+				server.commandCache.ClearExpired()
 			}
 		}
-	}()
+	})
+
+	// Track maintenance starting and ending
+	lifecycle.Go(func(ctx context.Context) {
+		server.maintenance.Watch(ctx, 5*time.Second, server.maintenanceChanged)
+	})
+
+	// Apply changes to the configuration document in etcd or Consul
+	if cfg.Remote.Provider != "" && cfg.Remote.Watch {
+		lifecycle.Go(func(ctx context.Context) {
+			server.watchConfig(ctx, loader)
+		})
+	}
+
+	// Pick up API keys and Redis passwords rotated in the secret store
+	if cfg.Secrets.Provider != "" {
+		store, err := secrets.NewStore(cfg.Secrets)
+		if err != nil {
+			log.Fatalf("Failed to create secret store: %v", err)
+		}
+		lifecycle.Go(func(ctx context.Context) {
+			server.watchSecrets(ctx, store)
+		})
+	}
+
+	// Reload scripts lost to a failover or SCRIPT FLUSH
+	if len(cfg.Scripts.Preload) > 0 {
+		lifecycle.Go(func(ctx context.Context) {
+			ticker := time.NewTicker(cfg.Scripts.CheckInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					logScriptStatus(server.scripts.Preload(ctx))
+				}
+			}
+		})
+	}
+
+	// Share tenants' rate limit usage with the other proxy instances
+	if server.rateLimiter != nil {
+		lifecycle.Go(server.rateLimiter.Run)
+	}
+
+	// Write recorded traffic out as it comes in
+	if server.recorder != nil {
+		lifecycle.Go(server.recorder.Run)
+	}
+
+	// Deliver delayed tasks as they come due
+	if server.delays != nil {
+		lifecycle.Go(func(ctx context.Context) {
+			ticker := time.NewTicker(cfg.Delay.PollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := server.delays.Poll(ctx); err != nil && ctx.Err() == nil {
+						log.Printf("Failed to poll delayed tasks: %v", err)
+					}
+				}
+			}
+		})
+	}
+
+	// Tell flag streams of changes made through other instances
+	if server.flags != nil {
+		lifecycle.Go(func(ctx context.Context) {
+			server.redisClient.WatchKeyspace(ctx, cfg.Flags.DB, cfg.Flags.KeyPrefix, server.flags.Changed)
+		})
+	}
+
+	// Release the connections of abandoned transaction sessions
+	if server.txSessions != nil {
+		lifecycle.Go(server.txSessions.Run)
+	}
+
+	// Replay writes queued while Redis was unreachable once it is back
+	if server.opLog != nil {
+		lifecycle.Go(server.opLog.Run)
+	}
+
+	// Publish pool saturation and the concurrency limit often enough for
+	// autoscalers to react
+	if cfg.Metrics.Enabled {
+		lifecycle.Go(func(ctx context.Context) {
+			ticker := time.NewTicker(5 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					server.updatePoolMetrics()
+				}
+			}
+		})
+	}
+
+	// Sample pool and traffic stats into the rolling history
+	lifecycle.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.Metrics.HistoryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				server.metrics.RecordHistorySample(server.redisClient.GetConnectionStats())
+			}
+		}
+	})
 
 	// Start server
 	go func() {
-		fmt.Printf("🚀 Optimized Serverless Redis Proxy v%s starting on %s\n", Version, httpServer.Addr)
-		fmt.Printf("📊 Metrics endpoint: http://%s%s\n", httpServer.Addr, cfg.Metrics.Path)
+		fmt.Printf("🚀 Optimized Serverless Redis Proxy v%s starting on %s\n", Version, listenerURL(listenerConfigs[0]))
+		for _, listenerConfig := range listenerConfigs[1:] {
+			fmt.Printf("👂 Also listening on %s\n", listenerURL(listenerConfig))
+		}
+		// With server.admin, metrics are only on the admin listener, which is last
+		metricsListener := listenerConfigs[0]
+		if cfg.Server.Admin.Address != "" {
+			metricsListener = listenerConfigs[len(listenerConfigs)-1]
+		}
+		fmt.Printf("📊 Metrics endpoint: %s%s\n", listenerURL(metricsListener), cfg.Metrics.Path)
 		fmt.Printf("🔒 Authentication: %v\n", cfg.Auth.Enabled)
-		fmt.Printf("🗄️  Redis: %s\n", cfg.Redis.Primary.Addr)
+		if cfg.Redis.Mode == types.RedisModeEmbedded {
+			fmt.Printf("⚠️  Redis: embedded in-memory at %s (development only; data is lost on exit)\n", cfg.Redis.Primary.Addr)
+		} else {
+			fmt.Printf("🗄️  Redis: %s\n", cfg.Redis.Primary.Addr)
+		}
 		fmt.Printf("🚀 HTTP/2: %v\n", cfg.Server.HTTP2.Enabled)
 		fmt.Printf("🗜️  Compression: enabled\n")
 		fmt.Printf("💾 Caching: enabled\n")
@@ -95,9 +334,19 @@ func main() {
 		if cfg.Redis.Dragonfly.Enabled {
 			fmt.Printf("🐲 DragonflyDB: %s\n", cfg.Redis.Dragonfly.Addr)
 		}
+		if cfg.Faults.Enabled {
+			fmt.Printf("💥 Fault injection: %d rule(s)\n", len(cfg.Faults.Rules))
+		}
+		if cfg.Observability.OTLP.Enabled {
+			fmt.Printf("🔭 OTLP: %s\n", cfg.Observability.OTLP.Endpoint)
+		}
 		
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+		for i := range httpServers {
+			go func() {
+				if err := serve(httpServers[i], listeners[i], listenerConfigs[i].TLS); err != nil {
+					log.Fatalf("Server failed on %s: %v", listenerURL(listenerConfigs[i]), err)
+				}
+			}()
 		}
 	}()
 
@@ -108,18 +357,28 @@ func main() {
 
 	fmt.Println("\n🛑 Shutting down server...")
 
-	// Graceful shutdown
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := httpServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+	if err := server.Shutdown(ctx, httpServers); err != nil {
+		log.Printf("Shutdown incomplete: %v", err)
 	}
 
 	fmt.Println("✅ Server gracefully stopped")
 }
 
 func NewServer(cfg *types.Config) (*Server, error) {
+	// Serve Redis from memory for local development
+	var embedded *redis.Embedded
+	if cfg.Redis.Mode == types.RedisModeEmbedded {
+		var err error
+		if embedded, err = redis.StartEmbedded(); err != nil {
+			return nil, fmt.Errorf("failed to start embedded Redis: %w", err)
+		}
+		cfg.Redis.Primary.Addr = embedded.Addr()
+		log.Printf("WARNING: redis.mode is embedded: data is kept in this process's memory and lost when it stops, and some commands are unsupported. Use it for development and CI only.")
+	}
+
 	// Initialize Redis client
 	redisClient, err := redis.NewClient(cfg)
 	if err != nil {
@@ -127,21 +386,196 @@ func NewServer(cfg *types.Config) (*Server, error) {
 	}
 
 	// Initialize auth manager
+	if cfg.Auth.Anonymous.Enabled {
+		if err := checkAnonymousCommands(cfg.Auth.Anonymous.Commands); err != nil {
+			return nil, err
+		}
+	}
 	authManager := auth.NewManager(&cfg.Auth)
+	if cfg.Auth.Revocation.Enabled {
+		authManager.SetRevocationList(auth.NewRevocationList(redisClient, cfg.Auth.Revocation))
+	}
+	operatorAccess, err := authManager.OperatorMiddleware(cfg.Metrics.Access, cfg.Metrics.Path)
+	if err != nil {
+		return nil, fmt.Errorf("metrics access: %w", err)
+	}
 
 	// Initialize metrics collector
-	metricsCollector := metrics.NewCollector()
+	metricsCollector := metrics.NewCollector(cfg.Metrics)
+	metricsCollector.SetHistoryCapacity(int(cfg.Metrics.HistoryRetention / cfg.Metrics.HistoryInterval))
+	metricsCollector.SetTenantLabels(cfg.Metrics.Tenants)
+	redisClient.SetRetryObserver(metricsCollector.RecordRedisRetries)
+	redisClient.SetRoutingObserver(metricsCollector)
 
 	// Initialize cache
-	cache := server.NewInMemoryCache(1000) // Cache up to 1000 entries
+	cache := server.NewInMemoryCache(responseCacheBytes, 0)
+	commandRules, err := commandCacheRules(cfg.Cache.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preload named scripts so the first EVALSHA/FCALL doesn't hit NOSCRIPT;
+	// missing required scripts fail readiness rather than startup
+	scripts, err := redis.NewScriptRegistry(redisClient, cfg.Scripts.Preload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+	preloadCtx, cancelPreload := context.WithTimeout(context.Background(), 10*time.Second)
+	logScriptStatus(scripts.Preload(preloadCtx))
+	cancelPreload()
+
+	// Detect modules such as RedisBloom, whose endpoints answer 501 without
+	// them. The embedded Redis has none.
+	var modules map[string]bool
+	if embedded == nil {
+		modulesCtx, cancelModules := context.WithTimeout(context.Background(), 5*time.Second)
+		modules, err = redisClient.Modules(modulesCtx)
+		cancelModules()
+		if err != nil {
+			log.Printf("Failed to detect Redis modules: %v", err)
+		}
+	}
+
+	// Initialize maintenance windows from config
+	maintenance, err := server.NewMaintenanceScheduler(cfg.Maintenance.Windows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+
+	// Initialize OTLP export; traces, metrics and logs share one toggle
+	var otlp *observability.Pipeline
+	if cfg.Observability.OTLP.Enabled {
+		otlp = observability.NewPipeline(cfg.Observability.OTLP, Version, prometheus.DefaultGatherer)
+	}
+
+	var concurrency *server.ConcurrencyLimiter
+	if cfg.Server.Concurrency.Enabled {
+		concurrency = server.NewConcurrencyLimiter(cfg.Server.Concurrency)
+	}
+
+	var rateLimiter *server.RateLimiter
+	if cfg.RateLimit.Enabled {
+		rateLimiter = server.NewRateLimiter(cfg.RateLimit, redisClient)
+	}
+
+	var errorReporter *observability.ErrorReporter
+	if cfg.Observability.Errors.Enabled {
+		errorReporter, err = observability.NewErrorReporter(cfg.Observability.Errors, Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lockout := cfg.Auth.Lockout
+	authManager.SetLockoutObserver(func(event, ip string) {
+		metricsCollector.RecordAuthEvent(event)
+		if event != auth.AuthEventLockout {
+			return
+		}
+		message := fmt.Sprintf("locked out %s for %v after %d failed authentication attempts", ip, lockout.Duration, lockout.MaxFailures)
+		log.Printf("WARNING: %s", message)
+		if errorReporter != nil {
+			errorReporter.Report(observability.ErrorEvent{Kind: "auth_lockout", Level: "warning", Message: message, Status: http.StatusTooManyRequests})
+		}
+	})
+
+	var delays *server.DelayQueue
+	if cfg.Delay.Enabled {
+		delays = server.NewDelayQueue(redisClient, cfg.Delay)
+		delays.SetObserver(metricsCollector.RecordDelayedTask)
+	}
+
+	var flags *server.FlagStore
+	if cfg.Flags.Enabled {
+		flags = server.NewFlagStore(redisClient, cfg.Flags)
+		if cfg.Flags.ConfigureNotifications {
+			if err := redisClient.ConfigureKeyspaceEvents(context.Background()); err != nil {
+				log.Printf("WARNING: failed to enable keyspace notifications, flag streams only hear of changes made through other instances every %v: %v", cfg.Flags.Heartbeat, err)
+			}
+		}
+	}
+
+	var txSessions *server.TxSessions
+	if cfg.TxSessions.Enabled {
+		txSessions = server.NewTxSessions(cfg.TxSessions)
+	}
+
+	var opLog *server.OpLog
+	if cfg.OpLog.Enabled {
+		opLog, err = server.NewOpLog(cfg.OpLog, redisClient, redis.IsTransient, redis.IsNil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open op log: %w", err)
+		}
+		opLog.SetObserver(metricsCollector.RecordOpLog)
+		if pending := opLog.Pending(); pending > 0 {
+			log.Printf("Op log %s has %d write(s) waiting to be replayed", cfg.OpLog.File, pending)
+		}
+	}
+
+	var faults *server.FaultInjector
+	if cfg.Faults.Enabled {
+		faults = server.NewFaultInjector(cfg.Faults.Rules)
+		faults.SetObserver(metricsCollector.RecordInjectedFault)
+		log.Printf("WARNING: fault injection is enabled: %d rule(s) add latency, errors and dropped connections to commands. Never enable it in production.", len(cfg.Faults.Rules))
+	}
+
+	var recorder *server.Recorder
+	if recording := cfg.Recording; recording.File != "" {
+		sink, err := server.NewFileSink(recording.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open recording file: %w", err)
+		}
+		recorder = server.NewRecorder(recording, sink, "file:"+recording.File, requestTenantID)
+	} else if recording.Stream != "" {
+		sink := server.NewStreamSink(redisClient, recording.Stream, recording.StreamDB, recording.MaxLen)
+		recorder = server.NewRecorder(recording, sink, "stream:"+recording.Stream, requestTenantID)
+	}
+
+	var accessLog *server.AccessLog
+	if cfg.Logging.Access.Enabled {
+		accessLog = server.NewAccessLog(cfg.Logging, nil, observability.TraceIDFromContext)
+	}
+
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
 
 	return &Server{
 		config:      cfg,
 		redisClient: redisClient,
+		embedded:    embedded,
+		faults:      faults,
+		recorder:    recorder,
 		authManager: authManager,
 		metrics:     metricsCollector,
 		cache:       cache,
+		otlp:        otlp,
+		memoryGuard: server.NewMemoryGuard(cfg.Redis.OOMCooldown),
+		concurrency: concurrency,
+		rateLimiter: rateLimiter,
+		bigValues:   server.NewBigValueTracker(cfg.BigValues.WarnBytes, cfg.BigValues.ReportSize),
+		slowLog:     server.NewSlowLog(cfg.SlowLog),
+		drainer:     server.NewDrainer(),
+		readCache:   server.NewInMemoryCache(cfg.Cache.MaxBytes, cfg.Cache.TenantMaxBytes),
+		maintenance: maintenance,
+		lifecycle:   server.NewLifecycle(),
+		scripts:     scripts,
+		accessLog:   accessLog,
 		startTime:   time.Now(),
+		delays:      delays,
+		flags:       flags,
+		txSessions:  txSessions,
+		opLog:       opLog,
+		modules:     modules,
+
+		errorReporter:  errorReporter,
+		operatorAccess: operatorAccess,
+
+		commandCache: server.NewInMemoryCache(cfg.Cache.MaxBytes, cfg.Cache.TenantMaxBytes),
+		commandRules: commandRules,
+
+		requestCtx:     requestCtx,
+		cancelRequests: cancelRequests,
+
+		live: cfg,
 	}, nil
 }
 
@@ -149,13 +583,26 @@ func (s *Server) setupRoutes() *mux.Router {
 	router := mux.NewRouter()
 
 	// Apply performance middleware stack (order matters!)
+	router.Use(server.BudgetMiddleware) // Outermost so all proxy time is charged
+	if s.otlp != nil {
+		router.Use(s.otlp.TracingMiddleware)
+	}
+	if s.accessLog != nil {
+		router.Use(s.accessLog.Middleware) // Inside tracing for the trace ID
+	}
+	if s.errorReporter != nil {
+		router.Use(s.errorReporter.Middleware) // Outside recovery, which reports panics itself
+	}
+	router.Use(server.RecoveryMiddleware(s.reportPanic))
+	router.Use(s.operatorAccess) // Before caching, which would serve metrics around it
+	router.Use(s.maintenance.Middleware)
 	router.Use(server.KeepAliveMiddleware)
 	router.Use(server.HTTP2OptimizationMiddleware)
 	router.Use(server.ServerPushMiddleware)
 	router.Use(server.ContentEncodingMiddleware) // Compression
 	
 	// Add caching middleware
-	router.Use(server.CachingMiddleware(s.cache))
+	router.Use(server.CachingMiddleware(s.cache, s.config.Cache.Debug, s.config.Cache.Rules...))
 
 	// Add metrics middleware if enabled
 	if s.config.Metrics.Enabled {
@@ -164,90 +611,296 @@ func (s *Server) setupRoutes() *mux.Router {
 		})
 	}
 
-	// API routes with authentication
-	api := router.PathPrefix("/v1").Subrouter()
-	if s.config.Auth.Enabled {
-		api.Use(s.authManager.AuthMiddleware)
-	}
+	// API routes with authentication. /v2 serves the same routes with
+	// responses wrapped in an envelope carrying request metadata.
+	s.apiRoutes(router.PathPrefix("/v1").Subrouter())
+	v2 := router.PathPrefix("/v2").Subrouter()
+	v2.Use(server.EnvelopeMiddleware)
+	s.apiRoutes(v2)
 
-	api.HandleFunc("/command", s.handleCommand).Methods("POST")
-	api.HandleFunc("/pipeline", s.handlePipeline).Methods("POST")
-	api.HandleFunc("/transaction", s.handleTransaction).Methods("POST")
-	
-	// Optimized streaming endpoint (disabled for now)
-	// api.HandleFunc("/stream/pipeline", s.handleStreamingPipeline).Methods("POST")
+	// Admin API, restricted to admin API keys
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(s.authManager.AdminMiddleware)
+
+	admin.HandleFunc("/tokens", s.handleMintToken).Methods("POST")
+	if s.authManager.Revocations() != nil {
+		admin.HandleFunc("/revocations", s.handleRevoke).Methods("POST")
+	}
+	admin.HandleFunc("/v1/history", s.handleHistory).Methods("GET")
+	admin.HandleFunc("/maintenance", s.handleListMaintenance).Methods("GET")
+	admin.HandleFunc("/maintenance", s.handleAddMaintenance).Methods("POST")
+	admin.HandleFunc("/maintenance/mode", s.handleSetMaintenanceMode).Methods("PUT")
+	admin.HandleFunc("/maintenance/{id}", s.handleDeleteMaintenance).Methods("DELETE")
+	admin.HandleFunc("/big-values", s.handleBigValues).Methods("GET")
+	admin.HandleFunc("/slowlog", s.handleSlowLog).Methods("GET")
+	admin.HandleFunc("/slowlog", s.handleResetSlowLog).Methods("DELETE")
+	admin.HandleFunc("/redis/info", s.handleRedisInfo).Methods("GET")
+	admin.HandleFunc("/redis/slowlog", s.handleRedisSlowLog).Methods("GET")
+	admin.HandleFunc("/export", s.handleExport).Methods("GET")
+	admin.HandleFunc("/cache/purge", s.handleCachePurge).Methods("POST")
+	if s.recorder != nil {
+		admin.HandleFunc("/recording", s.handleRecordingStatus).Methods("GET")
+		admin.HandleFunc("/recording", s.handleSetRecording).Methods("PUT")
+	}
+	if s.opLog != nil {
+		admin.HandleFunc("/oplog", s.handleOpLogStatus).Methods("GET")
+	}
 
 	// Health and metrics endpoints (no auth required)
 	router.HandleFunc("/health", s.handleHealth).Methods("GET")
+	router.HandleFunc("/ready", s.handleReady).Methods("GET")
+
+	// API documentation (no auth required)
+	if spec, err := buildOpenAPISpec(); err != nil {
+		log.Printf("Failed to build OpenAPI spec: %v", err)
+	} else {
+		router.HandleFunc("/openapi.json", s.handleOpenAPISpec(spec)).Methods("GET")
+	}
+	if s.config.OpenAPI.SwaggerUI {
+		router.HandleFunc(s.config.OpenAPI.UIPath, s.handleSwaggerUI).Methods("GET")
+	}
 	if s.config.Metrics.Enabled {
 		router.Handle(s.config.Metrics.Path, promhttp.Handler()).Methods("GET")
 	}
 
+	// Go profiles; only admin listeners let requests through to them
+	if s.config.Server.Pprof {
+		router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+		router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
 	// CORS middleware for browser requests
 	router.Use(corsMiddleware)
 
 	return router
 }
 
+// apiRoutes adds the API's middleware and routes to api, a versioned
+// subrouter.
+func (s *Server) apiRoutes(api *mux.Router) {
+	api.Use(s.drainer.Middleware)
+	api.Use(s.maintenanceModeMiddleware)
+	if s.config.Auth.Enabled {
+		api.Use(s.authManager.AuthMiddleware)
+	}
+	if s.accessLog != nil || s.errorReporter != nil {
+		api.Use(s.annotateTenantMiddleware)
+	}
+	if s.recorder != nil {
+		api.Use(s.recorder.Middleware) // After auth, to record the tenant
+	}
+	if s.faults != nil {
+		api.Use(server.FaultMiddleware)
+	}
+	if rules := s.config.Deprecation.Rules; len(rules) > 0 {
+		api.Use(server.DeprecationMiddleware(rules, s.recordDeprecated)) // After auth, to count by tenant
+	}
+	api.Use(s.slowRequestMiddleware)
+	api.Use(s.redisUserMiddleware)
+	api.Use(s.ttlPolicyMiddleware)
+	api.Use(s.sessionMiddleware)
+
+	// Flag streams stay open as long as clients listen, so they hold no
+	// concurrency slot and take no request timeout
+	if s.flags != nil {
+		api.HandleFunc("/flags/stream", s.handleFlagStream).Methods("GET")
+	}
+
+	api = api.NewRoute().Subrouter()
+	if s.concurrency != nil {
+		api.Use(s.concurrencyMiddleware) // After auth, which sets the tier
+	}
+	api.Use(server.TimeoutMiddleware(s.config.Server.MaxRequestTimeout))
+
+	api.HandleFunc("/command", s.handleCommand).Methods("POST")
+	api.HandleFunc("/pipeline", s.handlePipeline).Methods("POST")
+	api.HandleFunc("/transaction", s.handleTransaction).Methods("POST")
+
+	api.HandleFunc("/set", s.handleSet).Methods("POST")
+	api.HandleFunc("/mget", s.handleMGet).Methods("POST")
+	api.HandleFunc("/mset", s.handleMSet).Methods("POST")
+
+	api.HandleFunc("/negotiate", s.handleNegotiate).Methods("POST")
+	api.HandleFunc("/whoami", s.handleWhoAmI).Methods("GET")
+	api.HandleFunc("/commands", s.handleListCommands).Methods("GET")
+
+	api.HandleFunc("/scripts", s.handleListScripts).Methods("GET")
+
+	api.HandleFunc("/scan", s.handleScan).Methods("GET")
+	api.HandleFunc("/namespace/{prefix}/flush", s.handleFlushNamespace).Methods("POST")
+	api.HandleFunc("/namespace/{prefix}/ttl", s.handleTTLAudit).Methods("GET")
+	api.HandleFunc("/namespace/{prefix}/expire", s.handleExpireNamespace).Methods("POST")
+
+	// Unique-visitor counting on daily HyperLogLogs
+	api.HandleFunc("/unique/{metric}", s.handleUniqueAdd).Methods("POST")
+	api.HandleFunc("/unique/{metric}", s.handleUniqueCount).Methods("GET")
+	api.HandleFunc("/hll/{key}", s.handleHLLAdd).Methods("POST")
+	api.HandleFunc("/hll/{key}", s.handleHLLCount).Methods("GET")
+	api.HandleFunc("/bloom/{key}", s.handleBloomAdd).Methods("POST")
+	api.HandleFunc("/bloom/{key}/exists", s.handleBloomExists).Methods("POST")
+	api.HandleFunc("/json/{key}", s.handleGetJSON).Methods("GET")
+	api.HandleFunc("/json/{key}", s.handleSetJSON).Methods("PUT")
+	api.HandleFunc("/json/{key}", s.handleMergeJSON).Methods("PATCH")
+	api.HandleFunc("/geo/{key}", s.handleGeoAdd).Methods("POST")
+	api.HandleFunc("/geo/{key}/search", s.handleGeoSearch).Methods("POST")
+	api.HandleFunc("/vectors/{key}", s.handleVectorUpsert).Methods("POST")
+	api.HandleFunc("/vectors/{key}/search", s.handleVectorSearch).Methods("POST")
+
+	// REST-style key/value endpoints
+	api.HandleFunc("/keys/{key}", s.handleGetKey).Methods("GET")
+	api.HandleFunc("/keys/{key}", s.handleSetKey).Methods("PUT")
+	api.HandleFunc("/keys/{key}", s.handleDeleteKey).Methods("DELETE")
+	api.HandleFunc("/keys/{key}/get-or-set", s.handleGetOrSet).Methods("POST")
+	api.HandleFunc("/macros/{name}", s.handleMacro).Methods("POST")
+	api.HandleFunc("/batch", s.handleBatch).Methods("POST")
+	if s.delays != nil {
+		api.HandleFunc("/delay", s.handleDelay).Methods("POST")
+		api.HandleFunc("/delay/{id}", s.handleGetDelayed).Methods("GET")
+		api.HandleFunc("/delay/{id}", s.handleCancelDelayed).Methods("DELETE")
+	}
+	if s.flags != nil {
+		api.HandleFunc("/flags", s.handleListFlags).Methods("GET")
+		api.HandleFunc("/flags/evaluate", s.handleEvaluateFlags).Methods("POST")
+		api.HandleFunc("/flags/{name}", s.handleGetFlag).Methods("GET")
+		api.HandleFunc("/flags/{name}", s.handlePutFlag).Methods("PUT")
+		api.HandleFunc("/flags/{name}", s.handleDeleteFlag).Methods("DELETE")
+	}
+	if s.txSessions != nil {
+		api.HandleFunc("/session", s.handleOpenTxSession).Methods("POST")
+		api.HandleFunc("/session/{id}/command", s.handleTxSessionCommand).Methods("POST")
+		api.HandleFunc("/session/{id}/exec", s.handleExecTxSession).Methods("POST")
+		api.HandleFunc("/session/{id}/discard", s.handleDiscardTxSession).Methods("POST")
+		api.HandleFunc("/session/{id}", s.handleCloseTxSession).Methods("DELETE")
+	}
+
+	// Optimized streaming endpoint (disabled for now)
+	// api.HandleFunc("/stream/pipeline", s.handleStreamingPipeline).Methods("POST")
+}
+
 func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
-	var req types.CommandRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest, err)
+	pooled := getCommandRequest()
+	defer releaseCommandRequest(pooled)
+	if !s.decodeCommand(w, r, pooled) {
 		return
 	}
+	req := *pooled
 
-	// Get tenant from context
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	if err := s.redisClient.ValidateCommandShard(req); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	raw, rawType, err := rawContentType(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+	asCSV := !raw && acceptsCSV(r)
+	if raw && !s.checkReadOnlyOutput(w, "Raw", req.Command) || asCSV && !s.checkReadOnlyOutput(w, "CSV", req.Command) {
+		return
+	}
+
+	// Writes queued while Redis was unreachable are applied first, so a
+	// write that can be queued goes behind them rather than being refused
 	tenant, _ := auth.GetTenantFromContext(r.Context())
+	queueable := s.canQueue(tenant, req)
+	if queueable {
+		r = queueingBehind(r)
+	}
 
-	// Validate permissions
-	if tenant != nil {
-		if err := s.authManager.ValidateCommand(tenant, req.Command); err != nil {
-			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+	tenant, ok := s.authorizeCommand(w, r, req.Command, req.Database, req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, req) || !s.checkValueSize(w, tenant, req) {
+		return
+	}
+
+	handled, cacheKey := s.serveMaintenanceRead(w, r, tenant, req, asCSV || raw)
+	if handled {
+		return
+	}
+	readKey := ""
+	if !asCSV && !raw {
+		if s.serveCachedMGet(w, r, tenant, req, cacheKey) {
 			return
 		}
-
-		if err := s.authManager.ValidateDatabase(tenant, req.DB); err != nil {
-			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
+		if handled, readKey = s.serveCachedRead(w, r, tenant, req); handled {
 			return
 		}
+	} else {
+		s.explainCache(w, r, server.CacheReasonFormat, "raw and CSV replies are not cached")
 	}
 
-	// Execute command
-	start := time.Now()
-	result, err := s.redisClient.ExecuteCommand(r.Context(), req)
-	duration := time.Since(start)
-
-	// Record metrics
-	status := "success"
-	if err != nil {
-		status = "error"
-		s.metrics.RecordRedisError(req.Command, getRedisErrorType(err), tenant)
+	if queueable && s.queueBehindPending(w, r, tenant, req) {
+		return
 	}
-	s.metrics.RecordRedisCommand(req.Command, status, tenant, duration)
 
-	// Build response
-	response := types.CommandResponse{
-		Result: result,
-		Time:   duration.Seconds() * 1000, // Convert to milliseconds
-		Type:   string(inferResponseType(result)),
+	ctx, cancel := server.WithTimeout(r.Context(), time.Duration(req.TimeoutMs)*time.Millisecond, s.config.Server.MaxRequestTimeout)
+	defer cancel()
+
+	result, duration, err := s.executeCommand(ctx, tenant, req)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if queueable && redis.IsUnavailable(err) && s.queueUnreachable(w, r, tenant, req) {
+		return
 	}
 
-	if err != nil {
-		response.Error = err.Error()
+	if asCSV {
+		s.writeCSVResponse(w, req, result, err)
+		return
+	}
+	if raw {
+		s.writeRawResponse(w, rawType, result, err)
+		return
 	}
 
-	s.writeJSONResponse(w, response)
+	response := newCommandResponse(result, duration, err)
+	if err == nil || redis.IsNil(err) {
+		if cacheKey != "" {
+			s.cacheMaintenanceRead(cacheKey, tenant, response)
+		}
+		if readKey != "" {
+			rule := s.commandRules[strings.ToUpper(req.Command)]
+			if !s.cacheRead(readKey, rule, tenant, response) {
+				s.explainCache(w, r, server.CacheReasonSize, "the reply is more than a tenant may cache")
+			} else if server.NoCache(r) {
+				s.explainCache(w, r, server.CacheReasonNoCache, fmt.Sprintf("refreshed at the client's request; ttl %v", rule.TTL))
+			} else {
+				s.explainCache(w, r, server.CacheReasonCached, fmt.Sprintf("ttl %v for %s", rule.TTL, strings.ToUpper(req.Command)))
+			}
+		}
+	} else if readKey != "" {
+		s.explainCache(w, r, server.CacheReasonStatus, "error replies are not cached")
+	}
+	s.writeCommandResponse(w, response)
 }
 
 func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
 	var req types.PipelineRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest, err)
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	if err := s.redisClient.ValidatePipelineShards(req); err != nil {
+		s.writeValidationError(w, err)
 		return
 	}
 
 	// Get tenant from context
 	tenant, _ := auth.GetTenantFromContext(r.Context())
+	server.SetAccessLogCommand(r.Context(), "PIPELINE")
 
 	// Validate all commands
 	if tenant != nil {
@@ -258,25 +911,26 @@ func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		if err := s.authManager.ValidateDatabase(tenant, req.DB); err != nil {
+		if err := s.validateDatabase(tenant, req.Database, req.DB); err != nil {
 			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
 			return
 		}
 	}
 
-	// Execute pipeline
-	start := time.Now()
-	results := s.redisClient.ExecutePipeline(r.Context(), req)
-	duration := time.Since(start)
+	if !s.checkWrites(w, r, req.Commands...) || !s.checkKeyPolicy(w, tenant, req.Commands...) ||
+		!s.checkValueSize(w, tenant, req.Commands...) || !s.checkRateLimit(w, tenant, req.Commands...) ||
+		!s.checkBudget(w, r) {
+		return
+	}
 
-	// Record metrics for each command
-	for i, cmdReq := range req.Commands {
-		status := "success"
-		if results[i].Error != "" {
-			status = "error"
-			s.metrics.RecordRedisError(cmdReq.Command, getRedisErrorType(fmt.Errorf("%s", results[i].Error)), tenant)
-		}
-		s.metrics.RecordRedisCommand(cmdReq.Command, status, tenant, duration/time.Duration(len(req.Commands)))
+	s.runPipeline(w, r, tenant, req)
+}
+
+// runPipeline executes a checked pipeline and writes its results.
+func (s *Server) runPipeline(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.PipelineRequest) {
+	results, duration := s.executeCachedPipeline(w, r, tenant, req)
+	if s.checkPoolExhaustedResults(w, results) {
+		return
 	}
 
 	response := types.PipelineResponse{
@@ -290,34 +944,80 @@ func (s *Server) handlePipeline(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
 	var req types.TransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest, err)
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	if err := s.redisClient.ValidateTransactionShard(req); err != nil {
+		s.writeValidationError(w, err)
 		return
 	}
 
 	// Get tenant from context
 	tenant, _ := auth.GetTenantFromContext(r.Context())
+	server.SetAccessLogCommand(r.Context(), "MULTI")
+
+	// The precondition is checked like the commands, as the EVAL it is
+	commands := req.Commands
+	if req.Precondition != nil {
+		commands = append(commands[:len(commands):len(commands)], req.Precondition.Command())
+	}
 
 	// Validate all commands
 	if tenant != nil {
-		for _, cmdReq := range req.Commands {
+		for _, cmdReq := range commands {
 			if err := s.authManager.ValidateCommand(tenant, cmdReq.Command); err != nil {
 				s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
 				return
 			}
 		}
 
-		if err := s.authManager.ValidateDatabase(tenant, req.DB); err != nil {
+		if err := s.validateDatabase(tenant, req.Database, req.DB); err != nil {
 			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
 			return
 		}
 	}
 
+	if !s.checkWrites(w, r, commands...) || !s.checkKeyPolicy(w, tenant, commands...) ||
+		!s.checkValueSize(w, tenant, commands...) || !s.checkRateLimit(w, tenant, commands...) ||
+		!s.checkBudget(w, r) {
+		return
+	}
+
+	s.runTransaction(w, r, tenant, req)
+}
+
+// runTransaction executes a checked transaction and writes its results.
+func (s *Server) runTransaction(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.TransactionRequest) {
 	// Execute transaction
 	start := time.Now()
-	response, err := s.redisClient.ExecuteTransaction(r.Context(), req)
+	var response *types.TransactionResponse
+	err := s.injectFaults(r.Context(), tenant, req.Commands...)
+	if err == nil {
+		response, err = s.redisClient.ExecuteTransaction(r.Context(), req)
+	}
 	duration := time.Since(start)
+	s.observeLatency(duration)
+	s.recordSlowCommand(r.Context(), tenant, "MULTI", commandNames(req.Commands), req.DB, duration)
+	server.AddRedisTime(r.Context(), duration)
+	warnDeprecated(r.Context(), req.Commands...)
 
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if errors.Is(err, redis.ErrPrecondition) {
+		s.writeErrorResponse(w, "Transaction failed", http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, redis.ErrTTLPolicy) {
+		s.writeCodedError(w, "Transaction failed", http.StatusBadRequest, types.ErrCodeTTLPolicy, err)
+		return
+	}
 	if err != nil {
 		s.writeErrorResponse(w, "Transaction failed", http.StatusInternalServerError, err)
 		return
@@ -331,11 +1031,23 @@ func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
 		}
 		s.metrics.RecordRedisCommand(cmdReq.Command, status, tenant, duration/time.Duration(len(req.Commands)))
 	}
+	for i, result := range response.Results {
+		if i < len(req.Commands) && result.Error == "" {
+			s.trackValue(r.Context(), tenant, req.Commands[i], req.DB, result.Result)
+		}
+	}
+	s.recordOOMResults(response.Results)
 
 	s.writeJSONResponse(w, response)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	// Tell load balancers to stop routing here while requests drain
+	if s.lifecycle.Draining() {
+		s.writeErrorResponse(w, "Shutting down", http.StatusServiceUnavailable, errors.New("server is draining"))
+		return
+	}
+
 	connectionStats := s.redisClient.GetConnectionStats()
 	
 	response := types.HealthResponse{
@@ -349,6 +1061,19 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	// Add cache statistics
 	response.Connections["cache_entries"] = s.cache.Size()
 
+	// Surface memory pressure so callers and autoscalers can react
+	response.MemoryPressure = s.memoryPressure(r.Context())
+	if response.MemoryPressure.WritesPaused || response.MemoryPressure.UsedRatio >= memoryPressureRatio {
+		response.Status = "degraded"
+	}
+
+	// Planned maintenance takes precedence, so health-based alerting can
+	// tell it apart from an incident
+	if window, active := s.maintenance.Active(time.Now()); active {
+		response.Status = "maintenance"
+		response.Maintenance = &window
+	}
+
 	s.writeJSONResponse(w, response)
 }
 
@@ -371,47 +1096,397 @@ func (s *Server) writeJSONResponse(w http.ResponseWriter, data interface{}) {
 }
 
 func (s *Server) writeErrorResponse(w http.ResponseWriter, message string, status int, err error) {
+	s.writeCodedError(w, message, status, http.StatusText(status), err)
+}
+
+// writeCodedError writes an error response carrying a machine-readable code.
+func (s *Server) writeCodedError(w http.ResponseWriter, message string, status int, code string, err error) {
 	response := types.ErrorResponse{
 		Error:   message,
-		Code:    http.StatusText(status),
+		Code:    code,
 		Details: err.Error(),
 		Time:    time.Now().Unix(),
 	}
 
+	var validationErr *types.ValidationError
+	if errors.As(err, &validationErr) {
+		response.Field = validationErr.Field
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(response)
 }
 
-func (s *Server) Close() error {
-	if s.redisClient != nil {
-		return s.redisClient.Close()
+// writeValidationError writes a 400 response for a failed request
+// validation, using the validation error's code when it has one.
+func (s *Server) writeValidationError(w http.ResponseWriter, err error) {
+	code := types.ErrCodeInvalidArgument
+	var validationErr *types.ValidationError
+	if errors.As(err, &validationErr) {
+		code = validationErr.Code
 	}
-	return nil
+	s.writeCodedError(w, "Invalid request", http.StatusBadRequest, code, err)
+}
+
+// decodeJSON decodes the request body into v, writing an ERR_INVALID_JSON
+// response and returning false if it is malformed, or a 413 if it is
+// larger than maxRequestBodySize.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.writeErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge, err)
+			return false
+		}
+		s.writeCodedError(w, "Invalid JSON", http.StatusBadRequest, types.ErrCodeInvalidJSON, err)
+		return false
+	}
+	return true
+}
+
+// validationLimits returns the configured bounds for request validation.
+func (s *Server) validationLimits() types.ValidationLimits {
+	names := make(map[string]bool, len(s.config.Redis.NamedDatabases))
+	for name := range s.config.Redis.NamedDatabases {
+		names[name] = true
+	}
+	
+	return types.ValidationLimits{
+		MaxCommands:    s.config.Server.MaxPipelineCommands,
+		Databases:      s.config.Redis.Databases,
+		NamedDatabases: names,
+
+		AllowUnknownCommands: s.config.Server.AllowUnknownCommands,
+	}
+}
+
+// authorizeCommand checks that the tenant attached to the request may run
+// command against the named database, or db if database is empty, writing
+// a 403 response and returning false if not.
+// It is the last step before dispatch, so it also enforces the rate limit
+// and the latency budget.
+func (s *Server) authorizeCommand(w http.ResponseWriter, r *http.Request, command, database string, db int) (*types.Tenant, bool) {
+	server.SetAccessLogCommand(r.Context(), command)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, command); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+
+		if err := s.validateDatabase(tenant, database, db); err != nil {
+			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+	}
+
+	if !s.checkWrites(w, r, types.CommandRequest{Command: command}) ||
+		!s.checkRateLimit(w, tenant, types.CommandRequest{Command: command}) {
+		return tenant, false
+	}
+
+	return tenant, s.checkBudget(w, r)
+}
+
+// permitCommand checks only that the tenant attached to the request may
+// run command, writing a 403 response and returning false if not. It is
+// for the further commands of a request charged by authorizeCommand once.
+func (s *Server) permitCommand(w http.ResponseWriter, r *http.Request, command string) bool {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant == nil {
+		return true
+	}
+	if err := s.authManager.ValidateCommand(tenant, command); err != nil {
+		s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+		return false
+	}
+	return true
 }
 
-// Helper functions
-func inferResponseType(val interface{}) types.ResponseType {
-	if val == nil {
-		return types.ResponseTypeNil
-	}
-
-	switch val.(type) {
-	case string:
-		return types.ResponseTypeString
-	case int, int64, uint64:
-		return types.ResponseTypeInteger
-	case float64:
-		return types.ResponseTypeFloat
-	case bool:
-		return types.ResponseTypeBool
-	case []interface{}:
-		return types.ResponseTypeArray
-	case map[string]interface{}:
-		return types.ResponseTypeHash
+// validateDatabase checks that tenant may use the named database, or db if
+// no name is given.
+func (s *Server) validateDatabase(tenant *types.Tenant, database string, db int) error {
+	if database != "" {
+		return s.authManager.ValidateNamedDatabase(tenant, database)
+	}
+	return s.authManager.ValidateDatabase(tenant, db)
+}
+
+// checkWrites rejects requests containing writes with 503 while a
+// read-only maintenance window is active or the tenant's queued writes
+// wait in the op log, and low-priority ones while an out-of-memory
+// cool-down is active so that Redis has room for the remaining traffic to
+// succeed.
+func (s *Server) checkWrites(w http.ResponseWriter, r *http.Request, commands ...types.CommandRequest) bool {
+	code, retryAfter, err := s.writesBlocked(r, commands...)
+	switch code {
+	case "":
+		return true
+	case types.ErrCodeMaintenance:
+		// Retry-After is filled in by the maintenance middleware
+		s.writeCodedError(w, "Read-only maintenance", http.StatusServiceUnavailable, code, err)
+	case types.ErrCodeOpLogPending:
+		w.Header().Set("Retry-After", strconv.Itoa(server.RetryAfterSeconds(retryAfter)))
+		s.writeCodedError(w, "Queued writes are being replayed", http.StatusServiceUnavailable, code, err)
 	default:
-		return types.ResponseTypeString
+		w.Header().Set("Retry-After", strconv.Itoa(server.RetryAfterSeconds(retryAfter)))
+		s.writeCodedError(w, "Writes paused", http.StatusServiceUnavailable, code, err)
+	}
+	return false
+}
+
+// writesBlocked returns the error code and error checkWrites rejects
+// commands with, and how long until writes resume if they are paused, or
+// an empty code if the commands may run.
+func (s *Server) writesBlocked(r *http.Request, commands ...types.CommandRequest) (string, time.Duration, error) {
+	writes := false
+	for _, cmd := range commands {
+		if !redis.IsReadOnly(cmd.Command) {
+			writes = true
+			break
+		}
+	}
+	if !writes {
+		return "", 0, nil
+	}
+
+	if window, active := s.maintenance.Current(time.Now()); active && window.ReadOnly {
+		return types.ErrCodeMaintenance, 0, server.ErrMaintenance
+	}
+
+	if s.opLogBlocks(r) {
+		return types.ErrCodeOpLogPending, s.config.OpLog.RetryInterval, server.ErrOpLogPending
+	}
+
+	if !server.IsLowPriority(r) {
+		return "", 0, nil
+	}
+
+	if paused, remaining := s.memoryGuard.WritesPaused(); paused {
+		return types.ErrCodeWritesPaused, remaining, server.ErrWritesPaused
+	}
+	return "", 0, nil
+}
+
+// checkBudget aborts the request with 504 if its X-SR-Budget-Ms budget was
+// already spent inside the proxy, so clients can tell proxy queueing apart
+// from a slow backend.
+func (s *Server) checkBudget(w http.ResponseWriter, r *http.Request) bool {
+	if err := server.CheckBudget(r.Context(), "dispatch"); err != nil {
+		s.writeCodedError(w, "Latency budget exceeded", http.StatusGatewayTimeout, types.ErrCodeBudgetExceeded, err)
+		return false
+	}
+	return true
+}
+
+// executeCommand runs a single command against Redis and records metrics
+// for it, the same way handleCommand does.
+func (s *Server) executeCommand(ctx context.Context, tenant *types.Tenant, req types.CommandRequest) (interface{}, time.Duration, error) {
+	result, duration, err := s.executeWith(ctx, tenant, req, func(ctx context.Context) (interface{}, error) {
+		return s.redisClient.ExecuteCommand(ctx, req)
+	})
+	if err == nil {
+		s.trackValue(ctx, tenant, req, req.DB, result)
+	}
+	return result, duration, err
+}
+
+// executeWith runs req with execute, recording its latency, metrics and
+// slow log entry like any other command.
+func (s *Server) executeWith(ctx context.Context, tenant *types.Tenant, req types.CommandRequest, execute func(context.Context) (interface{}, error)) (interface{}, time.Duration, error) {
+	start := time.Now()
+	var result interface{}
+	err := s.injectFaults(ctx, tenant, req)
+	if err == nil {
+		result, err = execute(ctx)
+	}
+	duration := time.Since(start)
+	s.observeLatency(duration)
+	s.recordSlowCommand(ctx, tenant, req.Command, req.Args, req.DB, duration)
+	server.AddRedisTime(ctx, duration)
+	warnDeprecated(ctx, req)
+
+	status := "success"
+	if err != nil && !redis.IsNil(err) {
+		status = "error"
+		s.metrics.RecordRedisError(req.Command, getRedisErrorType(err), tenant)
+	}
+	s.metrics.RecordRedisCommand(req.Command, status, tenant, duration)
+
+	if server.IsOOM(err) {
+		s.memoryGuard.RecordOOM()
+	}
+
+	return result, duration, err
+}
+
+// recordDeprecated counts a request matching a deprecation rule.
+func (s *Server) recordDeprecated(r *http.Request, rule types.DeprecationRule) {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	s.metrics.RecordDeprecated(rule.Path, rule.Option, tenant)
+}
+
+// injectFaults injects the configured faults into cmds, returning the
+// first injected error.
+func (s *Server) injectFaults(ctx context.Context, tenant *types.Tenant, cmds ...types.CommandRequest) error {
+	if s.faults == nil {
+		return nil
+	}
+	for _, cmd := range cmds {
+		if err := s.faults.Inject(ctx, cmd.Command, tenantID(tenant)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warnDeprecated warns /v2 clients about each deprecated command in cmds.
+func warnDeprecated(ctx context.Context, cmds ...types.CommandRequest) {
+	for _, cmd := range cmds {
+		if replacement, ok := redis.Deprecated(cmd.Command); ok {
+			server.AddWarning(ctx, types.WarningDeprecatedCommand, "%s is deprecated; use %s instead", strings.ToUpper(cmd.Command), replacement)
+		}
+	}
+}
+
+// executePipeline runs req as a single pipeline and records per-command
+// metrics, the same way handlePipeline does.
+func (s *Server) executePipeline(ctx context.Context, tenant *types.Tenant, req types.PipelineRequest) ([]types.CommandResponse, time.Duration) {
+	start := time.Now()
+	var results []types.CommandResponse
+	if err := s.injectFaults(ctx, tenant, req.Commands...); err != nil {
+		results = make([]types.CommandResponse, len(req.Commands))
+		for i := range results {
+			results[i] = newCommandResponse(nil, 0, err)
+		}
+	} else {
+		results = s.redisClient.ExecutePipeline(ctx, req)
+	}
+	duration := time.Since(start)
+	s.observeLatency(duration)
+	s.recordSlowCommand(ctx, tenant, "PIPELINE", commandNames(req.Commands), req.DB, duration)
+	server.AddRedisTime(ctx, duration)
+	warnDeprecated(ctx, req.Commands...)
+
+	for i, cmdReq := range req.Commands {
+		status := "success"
+		if results[i].Error != "" {
+			status = "error"
+			s.metrics.RecordRedisError(cmdReq.Command, getRedisErrorType(fmt.Errorf("%s", results[i].Error)), tenant)
+		}
+		s.metrics.RecordRedisCommand(cmdReq.Command, status, tenant, duration/time.Duration(len(req.Commands)))
+		if results[i].Error == "" {
+			s.trackValue(ctx, tenant, cmdReq, req.DB, results[i].Result)
+		}
+	}
+	s.recordOOMResults(results)
+
+	return results, duration
+}
+
+// recordOOMResults tags errors in pipeline or transaction results with
+// their error code and starts the write cool-down on out-of-memory errors.
+func (s *Server) recordOOMResults(results []types.CommandResponse) {
+	oom := false
+	for i := range results {
+		if results[i].Error == "" {
+			continue
+		}
+		err := errors.New(results[i].Error)
+		results[i].Code = commandErrorCode(err)
+		results[i].RedisCode = server.RedisErrorCode(err)
+		if server.IsOOM(err) {
+			oom = true
+		}
+	}
+	if oom {
+		s.memoryGuard.RecordOOM()
+	}
+}
+
+// newCommandResponse builds the response envelope for a single command.
+func newCommandResponse(result interface{}, duration time.Duration, err error) types.CommandResponse {
+	response := types.CommandResponse{
+		Result: result,
+		Time:   duration.Seconds() * 1000, // Convert to milliseconds
+		Type:   string(redis.InferResponseType(result)),
+	}
+
+	if err != nil {
+		response.Error = err.Error()
+		response.Code = commandErrorCode(err)
+		response.RedisCode = server.RedisErrorCode(err)
+	}
+
+	return response
+}
+
+// commandErrorCode returns the error code for a failed command, or "" for
+// plain Redis errors. Pipeline errors arrive as strings, so deadlines are
+// also matched by message.
+func commandErrorCode(err error) string {
+	switch {
+	case server.IsOOM(err):
+		return types.ErrCodeOutOfMemory
+	case errors.Is(err, redis.ErrCrossShard):
+		return types.ErrCodeCrossShard
+	case errors.Is(err, redis.ErrTTLPolicy):
+		return types.ErrCodeTTLPolicy
+	case errors.Is(err, context.DeadlineExceeded),
+		strings.Contains(err.Error(), context.DeadlineExceeded.Error()):
+		return types.ErrCodeTimeout
+	}
+	return ""
+}
+
+// Close closes the Redis clients, and stops an embedded Redis. Only the
+// first call does anything, so the deferred Close in main is harmless
+// after Shutdown.
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() {
+		if s.redisClient != nil {
+			s.closeErr = s.redisClient.Close()
+		}
+		if s.embedded != nil {
+			s.embedded.Close()
+		}
+	})
+	return s.closeErr
+}
+
+// Shutdown stops the proxy in order: fail health checks and stop accepting
+// connections, drain in-flight requests, stop background goroutines, run
+// shutdown hooks (flushing buffers and closing streams), and finally close
+// the Redis clients. Requests still running at the deadline are cancelled.
+func (s *Server) Shutdown(ctx context.Context, httpServers []*http.Server) error {
+	s.lifecycle.StartDraining()
+
+	var errs []error
+	if err := shutdownAll(ctx, httpServers); err != nil {
+		errs = append(errs, fmt.Errorf("draining requests: %w", err))
+		s.cancelRequests()
+	}
+
+	if err := s.lifecycle.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Hooks and Close get their own short deadline if draining used it up
+	cleanupCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		cleanupCtx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+	}
+	if err := s.lifecycle.RunShutdownHooks(cleanupCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := s.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("closing Redis clients: %w", err))
 	}
+	return errors.Join(errs...)
 }
 
 func getRedisErrorType(err error) string {
@@ -420,7 +1495,7 @@ func getRedisErrorType(err error) string {
 	switch {
 	case strings.Contains(errStr, "WRONGTYPE"):
 		return "wrong_type"
-	case strings.Contains(errStr, "NOAUTH"):
+	case strings.Contains(errStr, "NOAUTH"), strings.Contains(errStr, "WRONGPASS"):
 		return "no_auth"
 	case strings.Contains(errStr, "NOPERM"):
 		return "no_permission"
@@ -430,7 +1505,9 @@ func getRedisErrorType(err error) string {
 		return "out_of_memory"
 	case strings.Contains(errStr, "EXECABORT"):
 		return "exec_abort"
-	case strings.Contains(errStr, "TIMEOUT"):
+	case strings.Contains(errStr, "CONNECTION POOL TIMEOUT"):
+		return "pool_exhausted"
+	case strings.Contains(errStr, "TIMEOUT"), strings.Contains(errStr, "DEADLINE EXCEEDED"):
 		return "timeout"
 	default:
 		return "other"
@@ -441,7 +1518,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-Request-Timeout, X-SR-Budget-Ms, X-SR-Date, X-SR-Nonce, X-SR-Priority, X-SR-Session, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-SR-Session, X-Request-ID, Server-Timing, Deprecation, Sunset, Link")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	// maintenanceCacheTTL is how long a cached read may be served. The
+	// cache is also cleared when the mode is switched off.
+	maintenanceCacheTTL = time.Hour
+)
+
+// maintenanceChanged is called when a maintenance window or mode starts or
+// ends.
+func (s *Server) maintenanceChanged(window types.MaintenanceWindow, active bool) {
+	s.metrics.SetMaintenanceActive(active)
+	if window.ID == server.ManualMaintenanceID {
+		return // Logged when the mode is switched
+	}
+
+	if active {
+		log.Printf("Maintenance window %q started (read-only: %v, until %s)", window.Name, window.ReadOnly, window.End.Format(time.RFC3339))
+	} else {
+		log.Printf("Maintenance window %s ended", window.ID)
+	}
+}
+
+// maintenanceModeMiddleware rejects every API request with 503 while the
+// proxy is in full maintenance mode. Single commands are let through when
+// the mode serves cached reads; handleCommand answers those from the
+// cache.
+func (s *Server) maintenanceModeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode, on := s.maintenance.Mode(time.Now())
+		if on && mode.Mode == types.MaintenanceFull && !(mode.CacheReads && (r.URL.Path == "/v1/command" || r.URL.Path == "/v2/command")) {
+			s.writeCodedError(w, "Full maintenance", http.StatusServiceUnavailable, types.ErrCodeMaintenance, server.ErrFullMaintenance)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveMaintenanceRead answers reads from the maintenance read cache while
+// a maintenance mode with cache_reads is on, and rejects anything else in
+// full maintenance. It returns whether it wrote a response and the key to
+// cache the reply under, which is empty when it shouldn't be cached, as
+// for replies not sent as JSON. A
+// request with Cache-Control: no-cache skips the cached reply but still
+// refreshes it.
+func (s *Server) serveMaintenanceRead(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.CommandRequest, notJSON bool) (bool, string) {
+	mode, on := s.maintenance.Mode(time.Now())
+	if !on || !mode.CacheReads {
+		return false, ""
+	}
+
+	key := ""
+	if !notJSON && redis.IsReadOnly(req.Command) {
+		key = readCacheKey(tenant, req)
+
+		if entry, found := s.readCache.Get(key); found && !server.NoCache(r) {
+			w.Header().Set("X-Cache", "HIT")
+			server.ServeCacheEntry(w, r, entry)
+			return true, ""
+		}
+	}
+
+	if mode.Mode == types.MaintenanceFull {
+		s.writeCodedError(w, "Full maintenance", http.StatusServiceUnavailable, types.ErrCodeMaintenance, server.ErrFullMaintenance)
+		return true, ""
+	}
+	return false, key
+}
+
+// readCacheKey identifies a read by tenant and everything in the request
+// that changes its reply.
+func readCacheKey(tenant *types.Tenant, req types.CommandRequest) string {
+	args, _ := json.Marshal(req.Args)
+	fields, _ := json.Marshal(req.Fields)
+	return fmt.Sprintf("%s|%s|%d|%s|%s|%s|%t|%s|%d", tenantID(tenant), req.Database, req.DB, strings.ToUpper(req.Command), args,
+		req.HashFormat, req.RESP3, fields, req.MaxItems)
+}
+
+// cacheMaintenanceRead stores a successful read for serveMaintenanceRead.
+func (s *Server) cacheMaintenanceRead(key string, tenant *types.Tenant, response types.CommandResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	s.readCache.Set(key, &server.CacheEntry{
+		Data:       append(data, '\n'),
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		StatusCode: http.StatusOK,
+		Timestamp:  time.Now(),
+		TTL:        maintenanceCacheTTL,
+		Tenant:     tenantID(tenant),
+	})
+}
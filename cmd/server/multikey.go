@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// multiKeyChunk is the most keys /v1/mget and /v1/mset send in one MGET or
+// MSET, so no single command holds Redis up for long.
+const multiKeyChunk = 100
+
+// handleMGet serves POST /v1/mget, reading any number of keys with MGETs
+// of at most multiKeyChunk keys sent in one pipeline.
+func (s *Server) handleMGet(w http.ResponseWriter, r *http.Request) {
+	var req types.MGetRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	chunks := s.chunkKeys(req.Keys, req.Database)
+	commands := make([]types.CommandRequest, len(chunks))
+	for i, chunk := range chunks {
+		args := make([]interface{}, len(chunk))
+		for j, key := range chunk {
+			args[j] = key
+		}
+		commands[i] = types.CommandRequest{Command: "MGET", Args: args}
+	}
+
+	tenant, ok := s.authorizeMultiKey(w, r, "MGET", req.Database, req.DB, commands)
+	if !ok {
+		return
+	}
+
+	results, duration, ok := s.executeMultiKey(w, r, tenant, req.Database, req.DB, commands)
+	if !ok {
+		return
+	}
+
+	response := types.MGetResponse{
+		Values: make(map[string]interface{}, len(req.Keys)),
+		Chunks: len(chunks),
+		Time:   duration.Seconds() * 1000,
+	}
+	for i, chunk := range chunks {
+		values, _ := results[i].Result.([]interface{})
+		for j, key := range chunk {
+			var value interface{}
+			if j < len(values) {
+				value = values[j]
+			}
+			response.Values[key] = value
+		}
+	}
+	for _, value := range response.Values {
+		if value != nil {
+			response.Found++
+		}
+	}
+	s.writeJSONResponse(w, response)
+}
+
+// handleMSet serves POST /v1/mset, writing any number of keys with MSETs
+// of at most multiKeyChunk keys sent in one pipeline. Each MSET is atomic
+// but the request as a whole is not.
+func (s *Server) handleMSet(w http.ResponseWriter, r *http.Request) {
+	var req types.MSetRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	keys := make([]string, 0, len(req.Values))
+	for key := range req.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	chunks := s.chunkKeys(keys, req.Database)
+	commands := make([]types.CommandRequest, len(chunks))
+	for i, chunk := range chunks {
+		args := make([]interface{}, 0, 2*len(chunk))
+		for _, key := range chunk {
+			args = append(args, key, req.Values[key])
+		}
+		commands[i] = types.CommandRequest{Command: "MSET", Args: args}
+	}
+
+	// Size limits apply to each key and value, as they would to a SET
+	sets := make([]types.CommandRequest, len(keys))
+	for i, key := range keys {
+		sets[i] = types.CommandRequest{Command: "SET", Args: []interface{}{key, req.Values[key]}}
+	}
+
+	// MSET can't give keys an expiry, so under a TTL policy each key is SET
+	if tenantTTLPolicy(r) != (redis.TTLPolicy{}) {
+		commands = sets
+	}
+
+	tenant, ok := s.authorizeMultiKey(w, r, "MSET", req.Database, req.DB, commands, sets...)
+	if !ok {
+		return
+	}
+
+	_, duration, ok := s.executeMultiKey(w, r, tenant, req.Database, req.DB, commands)
+	if !ok {
+		return
+	}
+
+	s.writeJSONResponse(w, types.MSetResponse{
+		Keys:   len(keys),
+		Chunks: len(chunks),
+		Time:   duration.Seconds() * 1000,
+	})
+}
+
+// chunkKeys splits keys into chunks of at most multiKeyChunk keys that
+// each live on one shard, keeping their order within a shard.
+func (s *Server) chunkKeys(keys []string, database string) [][]string {
+	var shards []int
+	byShard := make(map[int][]string)
+	for _, key := range keys {
+		shard := s.redisClient.KeyShard(key, database)
+		if _, ok := byShard[shard]; !ok {
+			shards = append(shards, shard)
+		}
+		byShard[shard] = append(byShard[shard], key)
+	}
+
+	var chunks [][]string
+	for _, shard := range shards {
+		shardKeys := byShard[shard]
+		for len(shardKeys) > multiKeyChunk {
+			chunks = append(chunks, shardKeys[:multiKeyChunk])
+			shardKeys = shardKeys[multiKeyChunk:]
+		}
+		chunks = append(chunks, shardKeys)
+	}
+	return chunks
+}
+
+// authorizeMultiKey runs the checks /v1/pipeline makes on the chunked
+// commands of /v1/mget or /v1/mset, checking the size limits on sized one
+// by one.
+func (s *Server) authorizeMultiKey(w http.ResponseWriter, r *http.Request, command, database string, db int, commands []types.CommandRequest, sized ...types.CommandRequest) (*types.Tenant, bool) {
+	server.SetAccessLogCommand(r.Context(), command)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, command); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+
+		if err := s.validateDatabase(tenant, database, db); err != nil {
+			s.writeErrorResponse(w, "Database not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+	}
+
+	if !s.checkWrites(w, r, commands...) || !s.checkKeyPolicy(w, tenant, commands...) {
+		return tenant, false
+	}
+	for _, cmd := range sized {
+		if !s.checkValueSize(w, tenant, cmd) {
+			return tenant, false
+		}
+	}
+	return tenant, s.checkRateLimit(w, tenant, commands...) && s.checkBudget(w, r)
+}
+
+// executeMultiKey runs the chunked commands in one pipeline, writing an
+// error response if any of them failed.
+func (s *Server) executeMultiKey(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, database string, db int, commands []types.CommandRequest) ([]types.CommandResponse, time.Duration, bool) {
+	results, duration := s.executePipeline(r.Context(), tenant, types.PipelineRequest{DB: db, Database: database, Commands: commands})
+	if s.checkPoolExhaustedResults(w, results) {
+		return nil, 0, false
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			s.writeErrorResponse(w, "Redis command failed", http.StatusBadGateway, errors.New(result.Error))
+			return nil, 0, false
+		}
+	}
+	return results, duration, true
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	// defaultNamespaceLimit bounds the keys scanned per flush or expire
+	// request; clients continue from the returned cursor
+	defaultNamespaceLimit = 10000
+	maxNamespaceLimit     = 100000
+
+	// defaultNamespaceBatch is the SCAN COUNT, and so the size of each
+	// UNLINK or EXPIRE batch
+	defaultNamespaceBatch = 500
+
+	// defaultAuditSample and maxAuditSample bound the keys a TTL audit
+	// looks at
+	defaultAuditSample = 1000
+	maxAuditSample     = 100000
+
+	// maxAuditExamples is how many keys without a TTL an audit lists
+	maxAuditExamples = 20
+)
+
+// namespaceRequest holds the parameters shared by the /v1/namespace
+// endpoints.
+type namespaceRequest struct {
+	prefix string
+	db     int
+	limit  int
+	batch  int
+}
+
+func parseNamespaceRequest(r *http.Request, limitParam string, defaultLimit, maxLimit int) (namespaceRequest, error) {
+	req := namespaceRequest{prefix: mux.Vars(r)["prefix"]}
+	query := r.URL.Query()
+
+	if value := query.Get("db"); value != "" {
+		var err error
+		if req.db, err = strconv.Atoi(value); err != nil {
+			return req, fmt.Errorf("invalid db %q", value)
+		}
+	}
+
+	var err error
+	if req.limit, err = intParam(query.Get(limitParam), defaultLimit, maxLimit); err != nil {
+		return req, fmt.Errorf("%s: %w", limitParam, err)
+	}
+	if req.batch, err = intParam(query.Get("batch"), defaultNamespaceBatch, maxScanCount); err != nil {
+		return req, fmt.Errorf("batch: %w", err)
+	}
+
+	return req, nil
+}
+
+// handleFlushNamespace serves POST /v1/namespace/{prefix}/flush, which
+// deletes every key starting with prefix in SCAN + UNLINK batches, for
+// tenants that may not run FLUSHDB. Progress is streamed as NDJSON
+// types.NamespaceProgress lines; with dry_run=true keys are only counted.
+func (s *Server) handleFlushNamespace(w http.ResponseWriter, r *http.Request) {
+	req, err := parseNamespaceRequest(r, "limit", defaultNamespaceLimit, maxNamespaceLimit)
+	if err != nil {
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument, err)
+		return
+	}
+	if !s.checkUnsharded(w) {
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	tenant, ok := s.authorizeCommand(w, r, "SCAN", "", req.db)
+	if !ok {
+		return
+	}
+	if !dryRun {
+		if _, ok := s.authorizeCommand(w, r, "UNLINK", "", req.db); !ok {
+			return
+		}
+	}
+
+	progress := &types.NamespaceProgress{DryRun: dryRun}
+	s.walkNamespace(w, r, tenant, req, "flush", progress, func(keys []interface{}) error {
+		if dryRun || len(keys) == 0 {
+			return nil
+		}
+
+		// Keys deleted since they were scanned aren't counted
+		result, _, err := s.executeCommand(r.Context(), tenant, types.CommandRequest{Command: "UNLINK", Args: keys, DB: req.db})
+		if err != nil {
+			return err
+		}
+		deleted, _ := result.(int64)
+		progress.Deleted += int(deleted)
+		return nil
+	})
+}
+
+// handleExpireNamespace serves POST /v1/namespace/{prefix}/expire?ttl=,
+// which gives every key under prefix without an expiry the ttl, streaming
+// progress like a flush. Keys that already expire are left alone.
+func (s *Server) handleExpireNamespace(w http.ResponseWriter, r *http.Request) {
+	req, err := parseNamespaceRequest(r, "limit", defaultNamespaceLimit, maxNamespaceLimit)
+	if err != nil {
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument, err)
+		return
+	}
+	if !s.checkUnsharded(w) {
+		return
+	}
+	query := r.URL.Query()
+	ttl, err := time.ParseDuration(query.Get("ttl"))
+	if err != nil || ttl < time.Second {
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument,
+			errors.New("ttl must be a duration of at least 1s, e.g. 24h"))
+		return
+	}
+	dryRun := query.Get("dry_run") == "true"
+
+	tenant, ok := s.authorizeCommand(w, r, "SCAN", "", req.db)
+	if !ok {
+		return
+	}
+	if !dryRun {
+		if _, ok := s.authorizeCommand(w, r, "EXPIRE", "", req.db); !ok {
+			return
+		}
+	}
+
+	progress := &types.NamespaceProgress{DryRun: dryRun}
+	s.walkNamespace(w, r, tenant, req, "expire", progress, func(keys []interface{}) error {
+		persistent, err := s.keysWithoutTTL(r, tenant, req.db, keys)
+		if err != nil || dryRun || len(persistent) == 0 {
+			progress.Expired += len(persistent)
+			return err
+		}
+
+		// A key given a TTL since the check keeps it
+		expire := types.PipelineRequest{DB: req.db, Commands: make([]types.CommandRequest, len(persistent))}
+		for i, key := range persistent {
+			expire.Commands[i] = types.CommandRequest{Command: "EXPIRE", Args: []interface{}{key, int64(ttl.Seconds()), "NX"}, DB: req.db}
+		}
+		results, _ := s.executePipeline(r.Context(), tenant, expire)
+		for _, result := range results {
+			if result.Error != "" {
+				return errors.New(result.Error)
+			}
+			if set, _ := result.Result.(int64); set == 1 {
+				progress.Expired++
+			}
+		}
+		return nil
+	})
+}
+
+// handleTTLAudit serves GET /v1/namespace/{prefix}/ttl, which reports the
+// keys without an expiry among the first sample keys under prefix.
+func (s *Server) handleTTLAudit(w http.ResponseWriter, r *http.Request) {
+	req, err := parseNamespaceRequest(r, "sample", defaultAuditSample, maxAuditSample)
+	if err != nil {
+		s.writeCodedError(w, "Invalid request", http.StatusBadRequest, types.ErrCodeInvalidArgument, err)
+		return
+	}
+	if !s.checkUnsharded(w) {
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "SCAN", "", req.db)
+	if !ok {
+		return
+	}
+	if _, ok := s.authorizeCommand(w, r, "TTL", "", req.db); !ok {
+		return
+	}
+
+	start := time.Now()
+	report := types.TTLAuditReport{Prefix: req.prefix}
+	cursor := "0"
+	for {
+		keys, next, err := s.scanNamespace(r, tenant, req, cursor)
+		if err == nil {
+			var persistent []interface{}
+			if persistent, err = s.keysWithoutTTL(r, tenant, req.db, keys); err == nil {
+				report.Sampled += len(keys)
+				report.WithoutTTL += len(persistent)
+				for _, key := range persistent[:min(len(persistent), maxAuditExamples-len(report.Examples))] {
+					report.Examples = append(report.Examples, key.(string))
+				}
+			}
+		}
+		if err != nil {
+			s.writeErrorResponse(w, "TTL audit failed", http.StatusInternalServerError, err)
+			return
+		}
+
+		cursor = next
+		if cursor == "0" || report.Sampled >= req.limit {
+			break
+		}
+	}
+
+	report.Complete = cursor == "0"
+	if report.Sampled > 0 {
+		report.WithoutTTLRatio = float64(report.WithoutTTL) / float64(report.Sampled)
+	}
+	report.Time = time.Since(start).Seconds() * 1000
+
+	s.writeJSONResponse(w, report)
+}
+
+// checkUnsharded writes 501 if keys are spread over shards. SCAN only
+// reaches the first shard, so a walk would miss the keys on the others.
+func (s *Server) checkUnsharded(w http.ResponseWriter) bool {
+	if s.redisClient.Sharded() {
+		s.writeCodedError(w, "Not available with shards", http.StatusNotImplemented, types.ErrCodeCrossShard,
+			errors.New("SCAN only reaches the first shard"))
+		return false
+	}
+	return true
+}
+
+// walkNamespace scans the keys under req.prefix from the request's cursor
+// and hands each batch to visit, streaming progress after every batch. It
+// stops at the end of the keyspace, after req.limit keys, or when the
+// request deadline leaves no room for another batch, ending with a cursor
+// to continue from.
+func (s *Server) walkNamespace(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req namespaceRequest, operation string, progress *types.NamespaceProgress, visit func(keys []interface{}) error) {
+	kind := fmt.Sprintf("%s:%d:%s", operation, req.db, req.prefix)
+	cursor := "0"
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		var err error
+		if cursor, err = s.authManager.VerifyCursor(token, tenantID(tenant), kind); err != nil {
+			s.writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	start := time.Now()
+	for {
+		batchStart := time.Now()
+		keys, next, err := s.scanNamespace(r, tenant, req, cursor)
+		if err == nil {
+			err = visit(keys)
+		}
+		if err != nil {
+			progress.Error = err.Error()
+			break
+		}
+		cursor = next
+		progress.Scanned += len(keys)
+		progress.Time = time.Since(start).Seconds() * 1000
+
+		deadline, hasDeadline := r.Context().Deadline()
+		if cursor == "0" || progress.Scanned >= req.limit ||
+			(hasDeadline && time.Until(deadline) < 2*time.Since(batchStart)) {
+			break
+		}
+		if encoder.Encode(progress) != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if cursor == "0" && progress.Error == "" {
+		progress.Done = true
+	} else {
+		progress.Cursor = s.authManager.SignCursor(tenantID(tenant), kind, cursor, auth.DefaultCursorTTL)
+	}
+	progress.Time = time.Since(start).Seconds() * 1000
+	encoder.Encode(progress)
+}
+
+// scanNamespace runs one SCAN for the keys under req.prefix, returning
+// them and the next cursor.
+func (s *Server) scanNamespace(r *http.Request, tenant *types.Tenant, req namespaceRequest, cursor string) ([]interface{}, string, error) {
+	result, _, err := s.executeCommand(r.Context(), tenant, types.CommandRequest{
+		Command: "SCAN",
+		Args:    []interface{}{cursor, "MATCH", server.EscapeGlob(req.prefix) + "*", "COUNT", req.batch},
+		DB:      req.db,
+	})
+	if err != nil {
+		return nil, cursor, err
+	}
+	next, scanned, err := parseScanReply(result)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	// MATCH already filters by prefix; checking again guards against a
+	// pattern escaping mistake touching other keys
+	keys := make([]interface{}, 0, len(scanned))
+	for _, key := range scanned {
+		if strings.HasPrefix(key, req.prefix) && keyAllowed(tenant, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, next, nil
+}
+
+// keysWithoutTTL returns the keys that exist and have no expiry.
+func (s *Server) keysWithoutTTL(r *http.Request, tenant *types.Tenant, db int, keys []interface{}) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ttls := types.PipelineRequest{DB: db, Commands: make([]types.CommandRequest, len(keys))}
+	for i, key := range keys {
+		ttls.Commands[i] = types.CommandRequest{Command: "TTL", Args: []interface{}{key}, DB: db}
+	}
+	results, _ := s.executePipeline(r.Context(), tenant, ttls)
+
+	var persistent []interface{}
+	for i, result := range results {
+		if result.Error != "" {
+			return nil, errors.New(result.Error)
+		}
+		if ttl, _ := result.Result.(int64); ttl == -1 {
+			persistent = append(persistent, keys[i])
+		}
+	}
+	return persistent, nil
+}
+
+// intParam parses an optional positive integer query parameter.
+func intParam(value string, fallback, max int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || n > max {
+		return 0, errors.New("must be between 1 and " + strconv.Itoa(max))
+	}
+	return n, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// supportedCompression and supportedFormats are what the proxy can serve,
+// in its own order of preference.
+var (
+	supportedCompression = []string{"gzip", "identity"}
+	supportedFormats     = []string{"json", "csv"}
+)
+
+// handleNegotiate serves POST /v1/negotiate. SDKs declare what they
+// support and get back the parameters to use with this server and the
+// features available to their tenant, instead of keying off versions.
+func (s *Server) handleNegotiate(w http.ResponseWriter, r *http.Request) {
+	var req types.NegotiateRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	formats := intersect(req.Formats, supportedFormats)
+	if len(req.Formats) == 0 {
+		formats = supportedFormats
+	}
+	if len(formats) == 0 {
+		s.writeErrorResponse(w, "No common format", http.StatusNotAcceptable,
+			fmt.Errorf("server supports %s", strings.Join(supportedFormats, ", ")))
+		return
+	}
+
+	// Identity is always acceptable, so compression never fails
+	compression := "identity"
+	if common := intersect(req.Compression, supportedCompression); len(common) > 0 {
+		compression = common[0]
+	}
+
+	maxPayload := int64(maxRequestBodySize)
+	if req.MaxPayloadBytes > 0 && req.MaxPayloadBytes < maxPayload {
+		maxPayload = req.MaxPayloadBytes
+	}
+
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+
+	s.writeJSONResponse(w, types.NegotiateResponse{
+		ServerVersion:       Version,
+		Compression:         compression,
+		Format:              formats[0],
+		Formats:             formats,
+		Streaming:           false, // no streaming endpoints yet
+		MaxPayloadBytes:     maxPayload,
+		MaxPipelineCommands: s.config.Server.MaxPipelineCommands,
+		Databases:           s.tenantDatabases(tenant),
+		HashFormats:         []types.HashFormat{types.HashFormatObject, types.HashFormatPairs, types.HashFormatSorted},
+		Features:            s.tenantFeatures(tenant),
+	})
+}
+
+// tenantFeatures reports which optional features tenant can use right now.
+func (s *Server) tenantFeatures(tenant *types.Tenant) map[string]bool {
+	permitted := func(command string) bool {
+		return tenant == nil || s.authManager.ValidateCommand(tenant, command) == nil
+	}
+
+	writes := permitted("SET")
+	if window, active := s.maintenance.Active(time.Now()); active && window.ReadOnly {
+		writes = false
+	}
+
+	return map[string]bool{
+		"writes":           writes,
+		"pipeline":         true,
+		"transaction":      true,
+		"scan":             permitted("SCAN"),
+		"unique":           permitted("PFADD") && permitted("PFCOUNT"),
+		"bloom":            s.modules[redis.ModuleBloom] && permitted("BF.MADD") && permitted("BF.MEXISTS"),
+		"json":             s.modules[redis.ModuleJSON] && permitted("JSON.GET"),
+		"vectors":          s.modules[redis.ModuleVectorSet] && permitted("VADD") && permitted("VSIM"),
+		"csv":              true,
+		"resp3":            true,
+		"latency_budget":   true,
+		"priority":         true,
+		"read_your_writes": s.redisClient.HasReplicas(),
+		"redis_acl":        tenant != nil && tenant.RedisUsername != "",
+		"admin":            tenant == nil || tenant.Admin,
+	}
+}
+
+// tenantDatabases lists the databases tenant may use.
+func (s *Server) tenantDatabases(tenant *types.Tenant) []int {
+	if tenant != nil {
+		return tenant.AllowedDBs
+	}
+
+	databases := make([]int, s.config.Redis.Databases)
+	for i := range databases {
+		databases[i] = i
+	}
+	return databases
+}
+
+// intersect returns the entries of preferred that are in supported,
+// keeping the order of preferred. Matching ignores case.
+func intersect(preferred, supported []string) []string {
+	var common []string
+	for _, p := range preferred {
+		for _, s := range supported {
+			if strings.EqualFold(p, s) {
+				common = append(common, s)
+				break
+			}
+		}
+	}
+	return common
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/openapi"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func pathParam(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &openapi.Schema{Type: "string"}}
+}
+
+func queryParam(name, typ, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+// apiEndpoints lists every documented endpoint. New routes should be added
+// here alongside their registration in setupRoutes.
+func apiEndpoints() []openapi.Endpoint {
+	dbParam := queryParam("db", "integer", "Logical database number")
+	rawParams := []openapi.Parameter{
+		queryParam("raw", "boolean", "Return the bare value instead of the JSON envelope"),
+		queryParam("content_type", "string", "Content-Type of a raw value, sniffed from it by default"),
+	}
+
+	return []openapi.Endpoint{
+		{Method: "POST", Path: "/v1/command", Tag: "commands", Summary: "Execute a single Redis command",
+			Parameters: rawParams,
+			Request:    types.CommandRequest{}, Response: types.CommandResponse{}},
+		{Method: "POST", Path: "/v1/pipeline", Tag: "commands", Summary: "Execute commands as a pipeline",
+			Request: types.PipelineRequest{}, Response: types.PipelineResponse{}},
+		{Method: "POST", Path: "/v1/transaction", Tag: "commands", Summary: "Execute commands in a MULTI/EXEC transaction",
+			Request: types.TransactionRequest{}, Response: types.TransactionResponse{}},
+		{Method: "POST", Path: "/v1/batch", Tag: "commands", Summary: "Execute NDJSON commands streamed in the body, streaming back one NDJSON response per command",
+			Parameters: []openapi.Parameter{queryParam("chunk", "integer", "Commands sent to Redis per pipeline (default 100)")},
+			Request:    types.CommandRequest{}, ContentType: "application/x-ndjson", Response: types.CommandResponse{}},
+		{Method: "POST", Path: "/v1/macros/{name}", Tag: "commands", Summary: "Run a configured macro as a pipeline, or a transaction if it is one",
+			Parameters: []openapi.Parameter{pathParam("name", "Macro name")},
+			Request:    types.MacroRequest{}, Response: types.PipelineResponse{}},
+		{Method: "POST", Path: "/v1/set", Tag: "keys", Summary: "SET with typed NX/XX/EX/PX/KEEPTTL/GET options",
+			Request: types.SetRequest{}, Response: types.CommandResponse{}},
+		{Method: "POST", Path: "/v1/mget", Tag: "keys", Summary: "Read any number of keys, chunked into bounded MGETs",
+			Request: types.MGetRequest{}, Response: types.MGetResponse{}},
+		{Method: "POST", Path: "/v1/mset", Tag: "keys", Summary: "Write any number of keys, chunked into bounded MSETs",
+			Request: types.MSetRequest{}, Response: types.MSetResponse{}},
+		{Method: "GET", Path: "/v1/json/{key}", Tag: "keys", Summary: "Get a RedisJSON document or path as JSON",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("path", "string", "JSONPath to read; the reply is then an array of matches")},
+			Response: types.JSONDocumentResponse{}},
+		{Method: "PUT", Path: "/v1/json/{key}", Tag: "keys", Summary: "Set a RedisJSON document or path to the request body",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("path", "string", "JSONPath to set (default $)"),
+				queryParam("nx", "boolean", "Only set a path that doesn't exist"),
+				queryParam("xx", "boolean", "Only set a path that exists")},
+			Request: &openapi.Schema{}, Response: types.JSONWriteResponse{}},
+		{Method: "PATCH", Path: "/v1/json/{key}", Tag: "keys", Summary: "Merge the request body into a RedisJSON document or path",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("path", "string", "JSONPath to merge into (default $)")},
+			Request: &openapi.Schema{}, Response: types.JSONWriteResponse{}},
+		{Method: "GET", Path: "/v1/keys/{key}", Tag: "keys", Summary: "Get a key's value",
+			Parameters: append([]openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("as", "string", "Set to json to decode the stored value as JSON")}, rawParams...),
+			Response: types.CommandResponse{}},
+		{Method: "PUT", Path: "/v1/keys/{key}", Tag: "keys", Summary: "Set a key to the request body",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("as", "string", "Set to json to validate and compact a JSON body")},
+			Request: &openapi.Schema{Type: "string"}, ContentType: "*/*", Response: types.CommandResponse{}},
+		{Method: "DELETE", Path: "/v1/keys/{key}", Tag: "keys", Summary: "Delete a key",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam},
+			Response:   types.CommandResponse{}},
+		{Method: "POST", Path: "/v1/keys/{key}/get-or-set", Tag: "keys", Summary: "Get a key's value, setting it to the request body if it doesn't exist",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam,
+				queryParam("ttl", "string", "Expiry of a value that gets set, as a duration such as 10m"),
+				queryParam("as", "string", "Set to json to validate a JSON body and decode the value as JSON")},
+			Request: &openapi.Schema{Type: "string"}, ContentType: "*/*", Response: types.GetOrSetResponse{}},
+		{Method: "GET", Path: "/v1/scan", Tag: "keys", Summary: "Iterate keys one page at a time",
+			Parameters: []openapi.Parameter{dbParam,
+				queryParam("match", "string", "Glob-style key pattern"),
+				queryParam("type", "string", "Only return keys of this type"),
+				queryParam("count", "integer", "COUNT hint per page (max 1000)"),
+				queryParam("cursor", "string", "Signed continuation token from the previous page")},
+			Response: types.ScanResponse{}},
+		{Method: "POST", Path: "/v1/namespace/{prefix}/flush", Tag: "keys", Summary: "Delete all keys under a prefix with SCAN + UNLINK, streaming NDJSON progress",
+			Parameters: []openapi.Parameter{pathParam("prefix", "Key prefix"), dbParam,
+				queryParam("batch", "integer", "Keys scanned and unlinked per batch, 500 by default"),
+				queryParam("limit", "integer", "Keys per request, 10000 by default"),
+				queryParam("cursor", "string", "Cursor from the previous request"),
+				queryParam("dry_run", "boolean", "Count matching keys without deleting them")},
+			Response: types.NamespaceProgress{}},
+		{Method: "GET", Path: "/v1/namespace/{prefix}/ttl", Tag: "keys", Summary: "Report sampled keys under a prefix that have no TTL",
+			Parameters: []openapi.Parameter{pathParam("prefix", "Key prefix"), dbParam,
+				queryParam("sample", "integer", "Keys to sample, 1000 by default")},
+			Response: types.TTLAuditReport{}},
+		{Method: "POST", Path: "/v1/namespace/{prefix}/expire", Tag: "keys", Summary: "Set a TTL on keys under a prefix that have none, streaming NDJSON progress",
+			Parameters: []openapi.Parameter{pathParam("prefix", "Key prefix"), dbParam,
+				queryParam("ttl", "string", "TTL to apply, as a duration such as 24h"),
+				queryParam("batch", "integer", "Keys scanned and expired per batch, 500 by default"),
+				queryParam("limit", "integer", "Keys per request, 10000 by default"),
+				queryParam("cursor", "string", "Cursor from the previous request"),
+				queryParam("dry_run", "boolean", "Count keys without a TTL without changing them")},
+			Response: types.NamespaceProgress{}},
+		{Method: "POST", Path: "/v1/hll/{key}", Tag: "analytics", Summary: "Add elements to a HyperLogLog",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.HLLAddRequest{}, Response: types.HLLAddResponse{}},
+		{Method: "GET", Path: "/v1/hll/{key}", Tag: "analytics", Summary: "Count the distinct elements of a HyperLogLog",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name"), dbParam},
+			Response:   types.HLLCountResponse{}},
+		{Method: "POST", Path: "/v1/bloom/{key}", Tag: "analytics", Summary: "Add items to a Bloom filter (RedisBloom)",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.BloomRequest{}, Response: types.BloomAddResponse{}},
+		{Method: "POST", Path: "/v1/bloom/{key}/exists", Tag: "analytics", Summary: "Check items against a Bloom filter (RedisBloom)",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.BloomRequest{}, Response: types.BloomExistsResponse{}},
+		{Method: "POST", Path: "/v1/geo/{key}", Tag: "keys", Summary: "Add members to a geo set by latitude and longitude",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.GeoAddRequest{}, Response: types.GeoAddResponse{}},
+		{Method: "POST", Path: "/v1/geo/{key}/search", Tag: "keys", Summary: "Find geo set members within a radius or box",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.GeoSearchRequest{}, Response: types.GeoSearchResponse{}},
+		{Method: "POST", Path: "/v1/vectors/{key}", Tag: "keys", Summary: "Add or replace embeddings in a vector set",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.VectorUpsertRequest{}, Response: types.VectorUpsertResponse{}},
+		{Method: "POST", Path: "/v1/vectors/{key}/search", Tag: "keys", Summary: "Find the elements of a vector set most similar to a vector",
+			Parameters: []openapi.Parameter{pathParam("key", "Key name")},
+			Request:    types.VectorSearchRequest{}, Response: types.VectorSearchResponse{}},
+		{Method: "POST", Path: "/v1/delay", Tag: "delay", Summary: "Deliver a payload to a list, stream or webhook after a delay",
+			Request: types.DelayRequest{}, Response: types.DelayedTask{}},
+		{Method: "GET", Path: "/v1/delay/{id}", Tag: "delay", Summary: "Get a delayed task waiting for delivery",
+			Parameters: []openapi.Parameter{pathParam("id", "Task ID")},
+			Response:   types.DelayedTask{}},
+		{Method: "DELETE", Path: "/v1/delay/{id}", Tag: "delay", Summary: "Cancel a delayed task before it is delivered",
+			Parameters: []openapi.Parameter{pathParam("id", "Task ID")}},
+		{Method: "GET", Path: "/v1/flags", Tag: "flags", Summary: "List the tenant's feature flags",
+			Response: types.FlagsResponse{}},
+		{Method: "POST", Path: "/v1/flags/evaluate", Tag: "flags", Summary: "Evaluate feature flags for a subject",
+			Request: types.FlagEvaluationRequest{}, Response: types.FlagEvaluationResponse{}},
+		{Method: "GET", Path: "/v1/flags/stream", Tag: "flags", Summary: "Stream feature flag changes as server-sent events, starting with a snapshot",
+			Parameters: []openapi.Parameter{queryParam("subject", "string", "Send evaluations for this subject instead of flags")},
+			Response:   types.FlagsResponse{}},
+		{Method: "GET", Path: "/v1/flags/{name}", Tag: "flags", Summary: "Get a feature flag",
+			Parameters: []openapi.Parameter{pathParam("name", "Flag name")},
+			Response:   types.Flag{}},
+		{Method: "PUT", Path: "/v1/flags/{name}", Tag: "flags", Summary: "Create or replace a feature flag",
+			Parameters: []openapi.Parameter{pathParam("name", "Flag name")},
+			Request:    types.FlagRequest{}, Response: types.Flag{}},
+		{Method: "DELETE", Path: "/v1/flags/{name}", Tag: "flags", Summary: "Delete a feature flag",
+			Parameters: []openapi.Parameter{pathParam("name", "Flag name")}},
+		{Method: "POST", Path: "/v1/session", Tag: "commands", Summary: "Open a transaction session, WATCHing keys on a connection held across requests",
+			Request: types.TxSessionRequest{}, Response: types.TxSessionResponse{}},
+		{Method: "POST", Path: "/v1/session/{id}/command", Tag: "commands", Summary: "Run or, after MULTI, queue a command in a transaction session",
+			Parameters: []openapi.Parameter{pathParam("id", "Session token")},
+			Request:    types.CommandRequest{}, Response: types.CommandResponse{}},
+		{Method: "POST", Path: "/v1/session/{id}/exec", Tag: "commands", Summary: "Run a transaction session's queued commands, unless a watched key changed",
+			Parameters: []openapi.Parameter{pathParam("id", "Session token")},
+			Response:   types.TransactionResponse{}},
+		{Method: "POST", Path: "/v1/session/{id}/discard", Tag: "commands", Summary: "Drop a transaction session's queued commands and watched keys",
+			Parameters: []openapi.Parameter{pathParam("id", "Session token")}},
+		{Method: "DELETE", Path: "/v1/session/{id}", Tag: "commands", Summary: "Close a transaction session",
+			Parameters: []openapi.Parameter{pathParam("id", "Session token")}},
+		{Method: "POST", Path: "/v1/unique/{metric}", Tag: "analytics", Summary: "Record identifiers in a metric's daily HyperLogLog",
+			Parameters: []openapi.Parameter{pathParam("metric", "Metric name")},
+			Request:    types.UniqueAddRequest{}, Response: types.UniqueAddResponse{}},
+		{Method: "GET", Path: "/v1/unique/{metric}", Tag: "analytics", Summary: "Count unique identifiers over a day range",
+			Parameters: []openapi.Parameter{pathParam("metric", "Metric name"), dbParam,
+				queryParam("date", "string", "Single day (YYYY-MM-DD)"),
+				queryParam("from", "string", "First day of the range (YYYY-MM-DD)"),
+				queryParam("to", "string", "Last day of the range (YYYY-MM-DD)"),
+				queryParam("days", "integer", "Rolling window of N days ending today"),
+				queryParam("daily", "boolean", "Include per-day counts")},
+			Response: types.UniqueCountResponse{}},
+		{Method: "POST", Path: "/v1/negotiate", Tag: "system", Summary: "Negotiate SDK parameters and discover tenant features",
+			Request: types.NegotiateRequest{}, Response: types.NegotiateResponse{}},
+		{Method: "GET", Path: "/v1/whoami", Tag: "system", Summary: "Describe the calling tenant and its remaining rate limit",
+			Response: types.WhoAmIResponse{}},
+		{Method: "GET", Path: "/v1/commands", Tag: "system", Summary: "List the commands and macros the calling tenant may run",
+			Response: types.CommandsResponse{}},
+		{Method: "POST", Path: "/admin/tokens", Tag: "admin", Summary: "Mint a scoped JWT (admin key required)",
+			Request: types.TokenRequest{}, Response: types.TokenResponse{}},
+		{Method: "POST", Path: "/admin/revocations", Tag: "admin", Summary: "Revoke a JWT by jti, or all of a tenant's JWTs",
+			Request: types.RevokeRequest{}, Response: types.RevokeResponse{}},
+		{Method: "GET", Path: "/admin/v1/history", Tag: "admin", Summary: "Rolling history of pool and traffic stats",
+			Parameters: []openapi.Parameter{
+				queryParam("since", "string", "Only samples after this RFC 3339 timestamp"),
+				queryParam("limit", "integer", "Samples per page (max 1440)"),
+				queryParam("cursor", "string", "Signed continuation token from the previous page")},
+			Response: types.HistoryResponse{}},
+		{Method: "GET", Path: "/admin/maintenance", Tag: "admin", Summary: "List scheduled and active maintenance windows",
+			Response: types.MaintenanceListResponse{}},
+		{Method: "POST", Path: "/admin/maintenance", Tag: "admin", Summary: "Schedule a maintenance window",
+			Request: types.MaintenanceWindow{}, Response: types.MaintenanceWindow{}},
+		{Method: "PUT", Path: "/admin/maintenance/mode", Tag: "admin", Summary: "Switch read-only or full maintenance mode on or off",
+			Parameters: []openapi.Parameter{queryParam("drain", "string", "Wait up to this duration for requests admitted before the switch")},
+			Request:    types.MaintenanceMode{}, Response: types.MaintenanceModeResponse{}},
+		{Method: "DELETE", Path: "/admin/maintenance/{id}", Tag: "admin", Summary: "Cancel a maintenance window",
+			Parameters: []openapi.Parameter{pathParam("id", "Window ID")}},
+		{Method: "GET", Path: "/admin/big-values", Tag: "admin", Summary: "Largest values seen above the big value threshold",
+			Response: types.BigValueReport{}},
+		{Method: "GET", Path: "/admin/slowlog", Tag: "admin", Summary: "Most recent requests and Redis commands above the slow log thresholds",
+			Parameters: []openapi.Parameter{
+				queryParam("kind", "string", "Only request or command entries"),
+				queryParam("limit", "integer", "Entries to return (max 1000), all by default")},
+			Response: types.SlowLogReport{}},
+		{Method: "DELETE", Path: "/admin/slowlog", Tag: "admin", Summary: "Clear the slow log"},
+		{Method: "GET", Path: "/admin/redis/info", Tag: "admin", Summary: "INFO from every Redis backend, parsed into sections",
+			Parameters: []openapi.Parameter{
+				queryParam("section", "string", "Only this INFO section, e.g. memory"),
+				queryParam("backend", "string", "Only this backend, e.g. primary or replica0")},
+			Response: types.RedisInfoResponse{}},
+		{Method: "GET", Path: "/admin/redis/slowlog", Tag: "admin", Summary: "SLOWLOG GET from every Redis backend",
+			Parameters: []openapi.Parameter{
+				queryParam("count", "integer", "Entries per backend (max 1000), 10 by default"),
+				queryParam("backend", "string", "Only this backend, e.g. primary or replica0")},
+			Response: types.RedisSlowLogResponse{}},
+		{Method: "GET", Path: "/admin/export", Tag: "admin", Summary: "Stream matching keys as NDJSON export records, ending with the next page's cursor",
+			Parameters: []openapi.Parameter{dbParam,
+				queryParam("match", "string", "SCAN MATCH pattern, * by default"),
+				queryParam("format", "string", "json (values by type) or dump (base64 DUMP payloads)"),
+				queryParam("limit", "integer", "Keys per page, 1000 by default"),
+				queryParam("cursor", "string", "Cursor from the previous page")},
+			Response: types.ExportRecord{}},
+		{Method: "POST", Path: "/admin/cache/purge", Tag: "admin", Summary: "Purge cached responses by key, by tenant or all",
+			Request: types.CachePurgeRequest{}, Response: types.CachePurgeResponse{}},
+		{Method: "GET", Path: "/admin/recording", Tag: "admin", Summary: "Whether traffic is being recorded, where to, and how much has been",
+			Response: types.RecordingStatus{}},
+		{Method: "PUT", Path: "/admin/recording", Tag: "admin", Summary: "Start or stop recording traffic for replay",
+			Request: types.RecordingRequest{}, Response: types.RecordingStatus{}},
+		{Method: "GET", Path: "/admin/oplog", Tag: "admin", Summary: "Writes waiting in the op log for Redis to be reachable again",
+			Response: types.OpLogStatus{}},
+		{Method: "GET", Path: "/v1/scripts", Tag: "scripts", Summary: "Preloaded scripts with their SHAs and load status",
+			Response: types.ScriptListResponse{}},
+		{Method: "GET", Path: "/health", Tag: "system", Summary: "Health check", Public: true,
+			Response: types.HealthResponse{}},
+		{Method: "GET", Path: "/ready", Tag: "system", Summary: "Readiness check; fails while required scripts are missing", Public: true,
+			Response: types.ReadyResponse{}},
+	}
+}
+
+// buildOpenAPISpec renders the OpenAPI document once at startup.
+func buildOpenAPISpec() ([]byte, error) {
+	builder := openapi.NewBuilder("Serverless Redis Proxy", Version)
+	for _, endpoint := range apiEndpoints() {
+		builder.Add(endpoint)
+	}
+	return json.Marshal(builder.Document())
+}
+
+func (s *Server) handleOpenAPISpec(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(spec)
+	}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Serverless Redis Proxy API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
+
+func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, swaggerUIPage, "/openapi.json")
+}
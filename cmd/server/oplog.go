@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// canQueue reports whether req may go to the op log: it is on, the tenant
+// is one of oplog.tenants and req is a write wanted for its effect. Writes
+// of tenants running as a Redis ACL user aren't queued, since the replay
+// runs without their credentials.
+func (s *Server) canQueue(tenant *types.Tenant, req types.CommandRequest) bool {
+	return s.queuesWrites(tenant) && redis.IsQueueable(req.Command)
+}
+
+// queuesWrites reports whether the op log is on and writes of tenant may
+// go to it.
+func (s *Server) queuesWrites(tenant *types.Tenant) bool {
+	return s.opLog != nil && tenant != nil && tenant.RedisUsername == "" && s.opLog.Covers(tenant.ID)
+}
+
+// queuedBehindKey marks requests whose write handleCommand queues behind
+// the writes waiting in the op log.
+type queuedBehindKey struct{}
+
+// queueingBehind marks r as queued behind waiting writes, so opLogBlocks
+// lets it through.
+func queueingBehind(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), queuedBehindKey{}, true))
+}
+
+// opLogBlocks reports whether writes of the request must wait for the op
+// log to be replayed: its tenant has writes waiting and the request doesn't
+// queue behind them, so it would be applied first and then overwritten.
+func (s *Server) opLogBlocks(r *http.Request) bool {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if !s.queuesWrites(tenant) || r.Context().Value(queuedBehindKey{}) != nil {
+		return false
+	}
+	return s.opLog.Pending() > 0
+}
+
+// queueBehindPending queues req if writes are waiting in the op log, so it
+// is applied after them rather than before. It reports whether it wrote a
+// response.
+func (s *Server) queueBehindPending(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.CommandRequest) bool {
+	if s.opLog.Pending() == 0 {
+		return false
+	}
+
+	// Applied now, as the policy would be when the write ran
+	req, _, err := tenantTTLPolicy(r).Apply(req, time.Now())
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, 0, err))
+		return true
+	}
+
+	entry, pending, queued, err := s.opLog.AppendIfPending(tenant.ID, req)
+	if !queued {
+		return false
+	}
+	if errors.Is(err, server.ErrOpLogFull) {
+		s.writeCodedError(w, "Redis is unreachable and the op log is full", http.StatusServiceUnavailable, types.ErrCodeOpLogFull, err)
+		return true
+	}
+	if err != nil {
+		s.writeErrorResponse(w, "Failed to queue write", http.StatusInternalServerError, err)
+		return true
+	}
+	s.writeQueued(w, entry, pending)
+	return true
+}
+
+// queueUnreachable queues req, which failed because Redis couldn't be
+// reached. It reports whether it wrote a response; if req couldn't be
+// queued, its failure is answered as it would be without the op log.
+func (s *Server) queueUnreachable(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.CommandRequest) bool {
+	req, _, err := tenantTTLPolicy(r).Apply(req, time.Now())
+	if err != nil {
+		return false
+	}
+
+	entry, pending, err := s.opLog.Append(tenant.ID, req)
+	if err != nil {
+		if !errors.Is(err, server.ErrOpLogFull) {
+			log.Printf("Failed to queue %s of tenant %s in the op log: %v", req.Command, tenant.ID, err)
+		}
+		return false
+	}
+	s.writeQueued(w, entry, pending)
+	return true
+}
+
+// writeQueued answers a write queued in the op log with 202 Accepted.
+func (s *Server) writeQueued(w http.ResponseWriter, entry types.OpLogEntry, pending int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(types.QueuedCommandResponse{Queued: true, Seq: entry.Seq, Pending: pending})
+}
+
+// handleOpLogStatus serves GET /admin/oplog, the writes waiting in the op
+// log and what it did since startup.
+func (s *Server) handleOpLogStatus(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONResponse(w, s.opLog.Status())
+}
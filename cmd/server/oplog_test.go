@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestOpLogKeepsWriteOrder(t *testing.T) {
+	s, handler := newTestServer(t, fmt.Sprintf(`
+oplog:
+  enabled: true
+  file: %s
+  tenants: [ingest]
+auth:
+  enabled: true
+  jwt_secret: test-secret
+  api_keys:
+    - key: ingest-key
+      tenant_id: ingest
+      allowed_dbs: [0]
+      permissions: ["*"]
+`, filepath.Join(t.TempDir(), "oplog.jsonl")))
+
+	// A write queued while Redis was unreachable waits for replay
+	if _, _, err := s.opLog.Append("ingest", types.CommandRequest{Command: "SET", Args: []interface{}{"k", "queued"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay would overwrite a write that skipped the queue
+	w := doRequest(handler, http.MethodPut, "/v1/keys/k", "ingest-key", "direct")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), types.ErrCodeOpLogPending) {
+		t.Fatalf("Expected 503 %s, got %d: %s", types.ErrCodeOpLogPending, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	if w := doRequest(handler, http.MethodGet, "/v1/keys/k", "ingest-key", ""); w.Code == http.StatusServiceUnavailable {
+		t.Errorf("Expected reads to run, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Writes to /v1/command queue behind the waiting one
+	if w := doRequest(handler, http.MethodPost, "/v1/command", "ingest-key", `{"command":"SET","args":["k","later"]}`); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected the write to be queued, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := s.opLog.Replay(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if w := doRequest(handler, http.MethodGet, "/v1/keys/k", "ingest-key", ""); !strings.Contains(w.Body.String(), `"result":"later"`) {
+		t.Errorf("Expected the writes applied in order, got %d: %s", w.Code, w.Body.String())
+	}
+}
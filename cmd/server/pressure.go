@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// memoryPressureRatio is the used/maxmemory ratio above which /health
+// reports the proxy as degraded.
+const memoryPressureRatio = 0.9
+
+// memoryPressure combines Redis memory usage with the proxy's record of
+// out-of-memory errors, and suggests how to relieve the pressure.
+func (s *Server) memoryPressure(ctx context.Context) *types.MemoryPressure {
+	lastOOM, oomCount, pausedUntil := s.memoryGuard.Snapshot()
+
+	pressure := &types.MemoryPressure{
+		OOMErrors:    oomCount,
+		WritesPaused: !pausedUntil.IsZero(),
+	}
+	if !lastOOM.IsZero() {
+		pressure.LastOOM = &lastOOM
+	}
+	if !pausedUntil.IsZero() {
+		pressure.PausedUntil = &pausedUntil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	if mem, err := s.redisClient.MemoryInfo(ctx); err == nil {
+		pressure.UsedMemory = mem.UsedMemory
+		pressure.MaxMemory = mem.MaxMemory
+		pressure.MaxMemoryPolicy = mem.MaxMemoryPolicy
+		if mem.MaxMemory > 0 {
+			pressure.UsedRatio = float64(mem.UsedMemory) / float64(mem.MaxMemory)
+		}
+	}
+
+	pressure.Advice = evictionAdvice(pressure)
+	return pressure
+}
+
+// evictionAdvice suggests configuration changes when Redis is near or at
+// its memory limit.
+func evictionAdvice(p *types.MemoryPressure) []string {
+	if p.OOMErrors == 0 && p.UsedRatio < memoryPressureRatio {
+		return nil
+	}
+
+	var advice []string
+	switch p.MaxMemoryPolicy {
+	case "noeviction":
+		advice = append(advice, "maxmemory-policy is noeviction: switch to allkeys-lru (caches) or volatile-lru (mixed data) so Redis evicts instead of rejecting writes")
+	case "volatile-lru", "volatile-lfu", "volatile-random", "volatile-ttl":
+		advice = append(advice, "maxmemory-policy only evicts keys with a TTL: set expirations on cache keys or use an allkeys-* policy")
+	}
+	if p.MaxMemory > 0 {
+		advice = append(advice, "raise maxmemory or scale the Redis instance")
+	}
+	if p.WritesPaused {
+		advice = append(advice, "low-priority writes (X-SR-Priority: low) are paused until the cool-down ends")
+	}
+	return advice
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleHLLAdd serves POST /v1/hll/{key}, adding elements to a HyperLogLog
+// with PFADD.
+func (s *Server) handleHLLAdd(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	var req types.HLLAddRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	result, duration, ok := s.runSketchCommand(w, r, "PFADD", key, req.DB, req.Elements)
+	if !ok {
+		return
+	}
+
+	updated, _ := result.(int64)
+	s.writeJSONResponse(w, types.HLLAddResponse{
+		Key:     key,
+		Updated: updated == 1,
+		Time:    duration.Seconds() * 1000,
+	})
+}
+
+// handleHLLCount serves GET /v1/hll/{key}, the approximate number of
+// distinct elements added to a HyperLogLog.
+func (s *Server) handleHLLCount(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	db := 0
+	if value := r.URL.Query().Get("db"); value != "" {
+		var err error
+		if db, err = strconv.Atoi(value); err != nil {
+			s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, fmt.Errorf("invalid db %q", value))
+			return
+		}
+	}
+
+	result, duration, ok := s.runSketchCommand(w, r, "PFCOUNT", key, db, nil)
+	if !ok {
+		return
+	}
+
+	count, _ := result.(int64)
+	s.writeJSONResponse(w, types.HLLCountResponse{
+		Key:   key,
+		Count: count,
+		Time:  duration.Seconds() * 1000,
+	})
+}
+
+// handleBloomAdd serves POST /v1/bloom/{key}, adding items to a RedisBloom
+// filter with BF.MADD. A filter that doesn't exist is created with the
+// module's default capacity and error rate.
+func (s *Server) handleBloomAdd(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	req, ok := s.decodeBloomRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, duration, ok := s.runSketchCommand(w, r, "BF.MADD", key, req.DB, req.Items)
+	if !ok {
+		return
+	}
+
+	s.writeJSONResponse(w, types.BloomAddResponse{
+		Key:   key,
+		Added: bloomFlags(req.Items, result),
+		Time:  duration.Seconds() * 1000,
+	})
+}
+
+// handleBloomExists serves POST /v1/bloom/{key}/exists, checking items
+// against a RedisBloom filter with BF.MEXISTS.
+func (s *Server) handleBloomExists(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	req, ok := s.decodeBloomRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, duration, ok := s.runSketchCommand(w, r, "BF.MEXISTS", key, req.DB, req.Items)
+	if !ok {
+		return
+	}
+
+	s.writeJSONResponse(w, types.BloomExistsResponse{
+		Key:    key,
+		Exists: bloomFlags(req.Items, result),
+		Time:   duration.Seconds() * 1000,
+	})
+}
+
+// decodeBloomRequest decodes and validates a Bloom filter request, writing
+// 501 if the backends don't have RedisBloom loaded.
+func (s *Server) decodeBloomRequest(w http.ResponseWriter, r *http.Request) (types.BloomRequest, bool) {
+	var req types.BloomRequest
+	if !s.checkModule(w, redis.ModuleBloom, "RedisBloom") {
+		return req, false
+	}
+
+	if !s.decodeJSON(w, r, &req) {
+		return req, false
+	}
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return req, false
+	}
+	return req, true
+}
+
+// runSketchCommand authorizes and runs command on key with values as its
+// further arguments. A failed command is answered as /v1/command would,
+// so WRONGTYPE maps to 409 under server.redis_error_status.
+func (s *Server) runSketchCommand(w http.ResponseWriter, r *http.Request, command, key string, db int, values []string) (interface{}, time.Duration, bool) {
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, key)
+	for _, value := range values {
+		args = append(args, value)
+	}
+	cmd := types.CommandRequest{Command: command, Args: args, DB: db}
+
+	tenant, ok := s.authorizeCommand(w, r, command, "", db)
+	if !ok || !s.checkKeyPolicy(w, tenant, cmd) || !s.checkValueSize(w, tenant, cmd) {
+		return nil, 0, false
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return nil, 0, false
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return nil, 0, false
+	}
+	return result, duration, true
+}
+
+// bloomFlags maps each item to its flag in a BF.MADD or BF.MEXISTS reply.
+func bloomFlags(items []string, result interface{}) map[string]bool {
+	replies, _ := result.([]interface{})
+
+	flags := make(map[string]bool, len(items))
+	for i, item := range items {
+		var flag int64
+		if i < len(replies) {
+			flag, _ = replies[i].(int64)
+		}
+		// An item listed twice counts as added if either add did
+		flags[item] = flags[item] || flag == 1
+	}
+	return flags
+}
+
+// checkModule writes 501 unless module, called name in the error, was
+// loaded on every backend at startup.
+func (s *Server) checkModule(w http.ResponseWriter, module, name string) bool {
+	if !s.modules[module] {
+		s.writeCodedError(w, name+" not available", http.StatusNotImplemented, types.ErrCodeModuleMissing,
+			fmt.Errorf("%s was not loaded on every backend at startup", name))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// checkRateLimit charges commands against the tenant's rate limit,
+// rejecting them with 429 and Retry-After once it is spent.
+func (s *Server) checkRateLimit(w http.ResponseWriter, tenant *types.Tenant, commands ...types.CommandRequest) bool {
+	retryAfter, err := s.rateLimited(tenant, commands...)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(server.RetryAfterSeconds(retryAfter)))
+		s.writeCodedError(w, "Rate limit exceeded", http.StatusTooManyRequests, types.ErrCodeRateLimited, err)
+		return false
+	}
+	return true
+}
+
+// rateLimited charges commands against the tenant's rate limit, returning
+// an error and how long until they could run if it is spent.
+func (s *Server) rateLimited(tenant *types.Tenant, commands ...types.CommandRequest) (time.Duration, error) {
+	if s.rateLimiter == nil || tenant == nil {
+		return 0, nil
+	}
+
+	cost := s.rateLimiter.Cost(commands...)
+	allowed, retryAfter := s.rateLimiter.Take(tenant.ID, tenant.RateLimit, cost)
+	s.metrics.RecordRateLimit(tenant, cost, allowed)
+	if !allowed {
+		return retryAfter, fmt.Errorf("%w: cost %d at %d per second", server.ErrRateLimited, cost, tenant.RateLimit)
+	}
+	return 0, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+)
+
+// rawContentType reports whether the client asked for a bare value instead
+// of the JSON envelope, with ?raw=1 or Accept: application/octet-stream,
+// and the Content-Type to send it with. ?content_type= sets that type;
+// otherwise ?raw=1 sniffs it from the value ("" here) and the Accept
+// header gets application/octet-stream.
+func rawContentType(r *http.Request) (bool, string, error) {
+	query := r.URL.Query()
+	raw, _ := strconv.ParseBool(query.Get("raw"))
+	contentType := query.Get("content_type")
+	if contentType != "" {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return false, "", fmt.Errorf("invalid content_type %q: %w", contentType, err)
+		}
+	}
+
+	if !raw && accepts(r, "application/octet-stream") {
+		raw = true
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	return raw, contentType, nil
+}
+
+// writeRawResponse writes a command reply as its bare bytes. Only single
+// string replies have a raw form; a missing value is a 404 and Redis
+// errors a 502 error response.
+func (s *Server) writeRawResponse(w http.ResponseWriter, contentType string, result interface{}, err error) {
+	if redis.IsNil(err) || err == nil && result == nil {
+		s.writeErrorResponse(w, "Value not found", http.StatusNotFound, fmt.Errorf("reply is nil"))
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, "Redis command failed", http.StatusBadGateway, err)
+		return
+	}
+
+	value, ok := result.(string)
+	if !ok {
+		s.writeErrorResponse(w, "Raw output needs a single value", http.StatusNotAcceptable,
+			fmt.Errorf("reply is of type %s", redis.InferResponseType(result)))
+		return
+	}
+
+	if contentType == "" {
+		contentType = http.DetectContentType([]byte(value))
+	}
+	setRawHeaders(w, contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(value))
+}
+
+// setRawHeaders sets the headers of a bare value. Values are tenant data,
+// so browsers must neither guess another type nor run them as a page of
+// the proxy's origin.
+func setRawHeaders(w http.ResponseWriter, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// commandCacheRules indexes the cache rules for commands by command name.
+func commandCacheRules(rules []types.CacheRule) (map[string]types.CacheRule, error) {
+	commands := make(map[string]types.CacheRule)
+	for _, rule := range rules {
+		if rule.Command == "" {
+			continue
+		}
+		if !redis.IsReadOnly(rule.Command) {
+			return nil, fmt.Errorf("cache rule for %s: only read-only commands can be cached", rule.Command)
+		}
+		commands[strings.ToUpper(rule.Command)] = rule
+	}
+	return commands, nil
+}
+
+// serveCachedRead answers a command with a cache rule from the command
+// cache. An expired reply within the rule's stale window is still served,
+// and refreshed in the background. It returns whether it wrote a response
+// and the key to cache the reply under, which is empty when there is no
+// rule for the command.
+func (s *Server) serveCachedRead(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.CommandRequest) (bool, string) {
+	rule, ok := s.commandRules[strings.ToUpper(req.Command)]
+	if !ok {
+		if !redis.IsReadOnly(req.Command) {
+			s.explainCache(w, r, server.CacheReasonWrite, strings.ToUpper(req.Command)+" may write")
+		} else {
+			s.explainCache(w, r, server.CacheReasonNoRule, "no cache rule for "+strings.ToUpper(req.Command))
+		}
+		return false, ""
+	}
+
+	key := readCacheKey(tenant, req)
+	entry, found := s.commandCache.Get(key)
+	if !found || server.NoCache(r) {
+		w.Header().Set("X-Cache", "MISS")
+		return false, key
+	}
+
+	if entry.IsExpired() {
+		w.Header().Set("X-Cache", "STALE")
+		// The refresh outlives the pooled request its args belong to, and
+		// runs with the request's context values, such as the tenant's
+		// Redis user, but not its cancellation
+		req.Args = slices.Clone(req.Args)
+		refreshCtx := context.WithoutCancel(r.Context())
+		s.commandCache.Revalidate(key, func() {
+			ctx, cancel := context.WithTimeout(refreshCtx, s.config.Server.MaxRequestTimeout)
+			defer cancel()
+
+			result, duration, err := s.executeCommand(ctx, tenant, req)
+			if err == nil || redis.IsNil(err) {
+				s.cacheRead(key, rule, tenant, newCommandResponse(result, duration, err))
+			}
+		})
+	} else {
+		w.Header().Set("X-Cache", "HIT")
+	}
+	s.explainCache(w, r, server.CacheReasonCached, fmt.Sprintf("ttl %v for %s", rule.TTL, strings.ToUpper(req.Command)))
+	server.ServeCacheEntry(w, r, entry)
+	return true, ""
+}
+
+// explainCache explains a command cache decision, with cache.debug.
+func (s *Server) explainCache(w http.ResponseWriter, r *http.Request, reason, detail string) {
+	server.ExplainCache(w, r, s.config.Cache.Debug, reason, detail)
+}
+
+// cacheRead stores a successful reply for serveCachedRead, reporting
+// whether it fit in the cache.
+func (s *Server) cacheRead(key string, rule types.CacheRule, tenant *types.Tenant, response types.CommandResponse) bool {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return false
+	}
+	return s.commandCache.Set(key, &server.CacheEntry{
+		Data:                 append(data, '\n'),
+		Headers:              http.Header{"Content-Type": {"application/json"}},
+		StatusCode:           http.StatusOK,
+		Timestamp:            time.Now(),
+		TTL:                  rule.TTL,
+		StaleWhileRevalidate: rule.StaleWhileRevalidate,
+		Tenant:               tenantID(tenant),
+	})
+}
+
+// serveCachedMGet answers an MGET with a cache rule key by key: keys with
+// a fresh cached value are served from the cache, and only the others are
+// fetched, with one MGET, and cached for the next request. Expired values
+// are fetched again rather than served stale. It reports whether it wrote
+// a response; MGETs with max_items or fields are cached whole by
+// serveCachedRead instead. maintenanceKey, if set, is where the maintenance
+// read cache keeps the reply.
+func (s *Server) serveCachedMGet(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.CommandRequest, maintenanceKey string) bool {
+	rule, ok := s.commandRules["MGET"]
+	if !ok || !strings.EqualFold(req.Command, "MGET") || req.MaxItems > 0 || len(req.Fields) > 0 {
+		return false
+	}
+
+	values, missing := s.cachedMGet(r, tenant, req)
+	w.Header().Set("X-Cache", cacheStatus(len(req.Args), len(missing)))
+
+	var duration time.Duration
+	if len(missing) > 0 {
+		fetch := req
+		fetch.Args = make([]interface{}, len(missing))
+		for j, i := range missing {
+			fetch.Args[j] = req.Args[i]
+		}
+
+		ctx, cancel := server.WithTimeout(r.Context(), time.Duration(req.TimeoutMs)*time.Millisecond, s.config.Server.MaxRequestTimeout)
+		defer cancel()
+
+		var result interface{}
+		var err error
+		result, duration, err = s.executeCommand(ctx, tenant, fetch)
+		if s.checkPoolExhausted(w, err) {
+			return true
+		}
+		fetched, ok := result.([]interface{})
+		if err != nil || !ok || len(fetched) != len(missing) {
+			s.explainCache(w, r, server.CacheReasonStatus, "error replies are not cached")
+			s.writeCommandResponse(w, newCommandResponse(result, duration, err))
+			return true
+		}
+		for j, i := range missing {
+			values[i] = fetched[j]
+		}
+		s.cacheMGet(rule, tenant, fetch, fetched)
+	}
+
+	response := newCommandResponse(values, duration, nil)
+	if maintenanceKey != "" {
+		s.cacheMaintenanceRead(maintenanceKey, tenant, response)
+	}
+	s.explainCache(w, r, server.CacheReasonCached, fmt.Sprintf("ttl %v per key for MGET; %d of %d keys fetched", rule.TTL, len(missing), len(req.Args)))
+	s.writeCommandResponse(w, response)
+	return true
+}
+
+// cachedMGet returns the fresh cached values of the keys of an MGET, and
+// the indexes of the keys without one. With Cache-Control: no-cache, every
+// key is missing.
+func (s *Server) cachedMGet(r *http.Request, tenant *types.Tenant, req types.CommandRequest) ([]interface{}, []int) {
+	values := make([]interface{}, len(req.Args))
+	var missing []int
+	for i, key := range req.Args {
+		value, ok := s.cachedMGetValue(r, mgetCacheKey(tenant, req, key))
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		values[i] = value
+	}
+	return values, missing
+}
+
+func (s *Server) cachedMGetValue(r *http.Request, key string) (interface{}, bool) {
+	if server.NoCache(r) {
+		return nil, false
+	}
+	entry, found := s.commandCache.Get(key)
+	if !found || entry.IsExpired() {
+		return nil, false
+	}
+	var cached struct {
+		Result []interface{} `json:"result"`
+	}
+	if err := json.Unmarshal(entry.Data, &cached); err != nil || len(cached.Result) != 1 {
+		return nil, false
+	}
+	return cached.Result[0], true
+}
+
+// cacheMGet caches each value an MGET like req fetched under its own key.
+func (s *Server) cacheMGet(rule types.CacheRule, tenant *types.Tenant, req types.CommandRequest, values []interface{}) {
+	for i, key := range req.Args {
+		s.cacheRead(mgetCacheKey(tenant, req, key), rule, tenant, newCommandResponse(values[i:i+1], 0, nil))
+	}
+}
+
+// mgetCacheKey identifies the cached value of key as read by an MGET like
+// req: the reply of an MGET of that key alone.
+func mgetCacheKey(tenant *types.Tenant, req types.CommandRequest, key interface{}) string {
+	req.Args = []interface{}{key}
+	return readCacheKey(tenant, req)
+}
+
+// cacheStatus is the X-Cache header of a reply of which missing parts out
+// of total weren't cached.
+func cacheStatus(total, missing int) string {
+	switch missing {
+	case 0:
+		return "HIT"
+	case total:
+		return "MISS"
+	}
+	return "PARTIAL"
+}
+
+// executeCachedPipeline runs a pipeline of reads, serving the commands
+// with a cache rule that have a fresh cached reply from the cache and
+// sending only the others to Redis. MGETs are served from the cache only
+// if all their keys are. Pipelines that may write are run as they are, so
+// their reads see their writes.
+func (s *Server) executeCachedPipeline(w http.ResponseWriter, r *http.Request, tenant *types.Tenant, req types.PipelineRequest) ([]types.CommandResponse, time.Duration) {
+	cacheable := false
+	for _, cmd := range req.Commands {
+		if !redis.IsReadOnly(cmd.Command) {
+			s.explainCache(w, r, server.CacheReasonWrite, strings.ToUpper(cmd.Command)+" may write, so the pipeline isn't cached")
+			return s.executePipeline(r.Context(), tenant, req)
+		}
+		_, ruled := s.commandRules[strings.ToUpper(cmd.Command)]
+		cacheable = cacheable || ruled
+	}
+	if !cacheable {
+		return s.executePipeline(r.Context(), tenant, req)
+	}
+
+	results := make([]types.CommandResponse, len(req.Commands))
+	var missing []int
+	for i, cmd := range req.Commands {
+		if response, ok := s.cachedPipelineRead(r, tenant, pipelineCommand(req, cmd)); ok {
+			results[i] = response
+			continue
+		}
+		missing = append(missing, i)
+	}
+	w.Header().Set("X-Cache", cacheStatus(len(req.Commands), len(missing)))
+	s.explainCache(w, r, server.CacheReasonCached, fmt.Sprintf("commands with a cache rule; %d of %d commands fetched", len(missing), len(req.Commands)))
+	if len(missing) == 0 {
+		return results, 0
+	}
+
+	fetch := req
+	fetch.Commands = make([]types.CommandRequest, len(missing))
+	for j, i := range missing {
+		fetch.Commands[j] = req.Commands[i]
+	}
+	fetched, duration := s.executePipeline(r.Context(), tenant, fetch)
+	for j, i := range missing {
+		results[i] = fetched[j]
+		if fetched[j].Error == "" {
+			s.cachePipelineRead(tenant, pipelineCommand(req, req.Commands[i]), fetched[j])
+		}
+	}
+	return results, duration
+}
+
+// pipelineCommand returns cmd as run by the pipeline req, which sets its
+// database and protocol.
+func pipelineCommand(req types.PipelineRequest, cmd types.CommandRequest) types.CommandRequest {
+	cmd.DB, cmd.Database, cmd.RESP3 = req.DB, req.Database, req.RESP3
+	return cmd
+}
+
+// cachedPipelineRead returns the fresh cached reply of cmd, if it has a
+// cache rule.
+func (s *Server) cachedPipelineRead(r *http.Request, tenant *types.Tenant, cmd types.CommandRequest) (types.CommandResponse, bool) {
+	command := strings.ToUpper(cmd.Command)
+	if _, ok := s.commandRules[command]; !ok || server.NoCache(r) {
+		return types.CommandResponse{}, false
+	}
+
+	if command == "MGET" && cmd.MaxItems == 0 && len(cmd.Fields) == 0 {
+		values, missing := s.cachedMGet(r, tenant, cmd)
+		if len(missing) > 0 {
+			return types.CommandResponse{}, false
+		}
+		return newCommandResponse(values, 0, nil), true
+	}
+
+	entry, found := s.commandCache.Get(readCacheKey(tenant, cmd))
+	if !found || entry.IsExpired() {
+		return types.CommandResponse{}, false
+	}
+	var response types.CommandResponse
+	if err := json.Unmarshal(entry.Data, &response); err != nil {
+		return types.CommandResponse{}, false
+	}
+	return response, true
+}
+
+// cachePipelineRead caches the reply of cmd, run in a pipeline, if it has
+// a cache rule.
+func (s *Server) cachePipelineRead(tenant *types.Tenant, cmd types.CommandRequest, response types.CommandResponse) {
+	command := strings.ToUpper(cmd.Command)
+	rule, ok := s.commandRules[command]
+	if !ok {
+		return
+	}
+
+	values, isArray := response.Result.([]interface{})
+	if command == "MGET" && cmd.MaxItems == 0 && len(cmd.Fields) == 0 && isArray && len(values) == len(cmd.Args) {
+		s.cacheMGet(rule, tenant, cmd, values)
+		return
+	}
+	s.cacheRead(readCacheKey(tenant, cmd), rule, tenant, response)
+}
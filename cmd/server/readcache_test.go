@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRevalidateAfterRelease(t *testing.T) {
+	// GETs are slowed down so the refresh of a stale reply runs after its
+	// request is released
+	s, handler := newTestServer(t, `
+cache:
+  rules:
+    - command: GET
+      ttl: 50ms
+      stale_while_revalidate: 1m
+faults:
+  enabled: true
+  rules:
+    - commands: [GET]
+      latency: 20ms
+      latency_rate: 1
+`)
+	setKey(t, s, "greeting", "hello")
+	if response, _ := runCommand(t, handler, "", `{"command":"GET","args":["greeting"]}`); response.Result != "hello" {
+		t.Fatalf("Expected hello, got %v", response.Result)
+	}
+
+	setKey(t, s, "greeting", "hi")
+	time.Sleep(60 * time.Millisecond)
+	if response, w := runCommand(t, handler, "", `{"command":"GET","args":["greeting"]}`); response.Result != "hello" || w.Header().Get("X-Cache") != "STALE" {
+		t.Fatalf("Expected the stale hello, got %v (%s)", response.Result, w.Header().Get("X-Cache"))
+	}
+	// Another request reuses the released one meanwhile
+	if w := doRequest(handler, http.MethodPost, "/v1/command", "", `{"command":"SET","args":["other","value"]}`); w.Code != http.StatusOK {
+		t.Fatalf("SET status = %d", w.Code)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		response, w := runCommand(t, handler, "", `{"command":"GET","args":["greeting"]}`)
+		if w.Header().Get("X-Cache") == "HIT" {
+			if response.Result != "hi" {
+				t.Fatalf("Expected the refreshed reply to be hi, got %v", response.Result)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The stale reply was never refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/config"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// remoteRetryDelay is how long watching the remote config waits after an
+// error before trying again.
+const remoteRetryDelay = 5 * time.Second
+
+// watchConfig reloads the configuration whenever its remote document
+// changes, until ctx is done, and applies what can change while running.
+// A document that fails to load or validate is logged and ignored.
+func (s *Server) watchConfig(ctx context.Context, loader *config.Loader) {
+	for {
+		if err := loader.Watch(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to watch remote config: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(remoteRetryDelay):
+			}
+			continue
+		}
+
+		updated, err := loader.Load()
+		if err != nil {
+			log.Printf("Ignoring remote config change: %v", err)
+			continue
+		}
+		log.Printf("Remote config changed")
+		s.applyConfig(updated)
+	}
+}
+
+// liveConfig returns the configuration as last reloaded.
+func (s *Server) liveConfig() *types.Config {
+	s.liveMutex.Lock()
+	defer s.liveMutex.Unlock()
+	return s.live
+}
+
+// applyConfig puts the settings of updated that can change while the
+// proxy runs into effect: API keys, the primary and DragonflyDB passwords
+// and DragonflyDB routing. Other changes are logged as needing a restart.
+func (s *Server) applyConfig(updated *types.Config) {
+	s.liveMutex.Lock()
+	defer s.liveMutex.Unlock()
+	current := s.live
+
+	if !reflect.DeepEqual(updated.Auth.APIKeys, current.Auth.APIKeys) {
+		s.authManager.SetAPIKeys(updated.Auth.APIKeys)
+		log.Printf("API keys updated (%d keys)", len(updated.Auth.APIKeys))
+	}
+	if updated.Redis.Primary.Password != current.Redis.Primary.Password ||
+		updated.Redis.Dragonfly.Password != current.Redis.Dragonfly.Password {
+		s.redisClient.SetPasswords(updated.Redis.Primary.Password, updated.Redis.Dragonfly.Password)
+		log.Printf("Redis passwords updated")
+	}
+	if updated.Redis.Dragonfly.Routing != current.Redis.Dragonfly.Routing {
+		s.redisClient.SetRouting(updated.Redis.Dragonfly.Routing)
+		log.Printf("DragonflyDB routing updated (mode %s)", updated.Redis.Dragonfly.Routing.Mode)
+	}
+
+	if sections := restartSections(current, updated); len(sections) > 0 {
+		log.Printf("Config changes to %s take effect after a restart", strings.Join(sections, ", "))
+	}
+	s.live = updated
+}
+
+// restartSections lists the top-level config sections that differ between
+// current and updated other than in settings applyConfig applies.
+func restartSections(current, updated *types.Config) []string {
+	live := func(config *types.Config) types.Config {
+		copied := *config
+		copied.Auth.APIKeys = nil
+		copied.Redis.Primary.Password = ""
+		copied.Redis.Dragonfly.Password = ""
+		copied.Redis.Dragonfly.Routing = types.RoutingConfig{}
+		return copied
+	}
+	before, after := reflect.ValueOf(live(current)), reflect.ValueOf(live(updated))
+
+	var sections []string
+	for i := 0; i < before.NumField(); i++ {
+		if !reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			name, _, _ := strings.Cut(before.Type().Field(i).Tag.Get("yaml"), ",")
+			sections = append(sections, name)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// maxScanCount caps the COUNT hint a client may request per page.
+const maxScanCount = 1000
+
+// tenantID returns the ID used to bind continuation tokens, which is empty
+// when authentication is disabled.
+func tenantID(tenant *types.Tenant) string {
+	if tenant == nil {
+		return ""
+	}
+	return tenant.ID
+}
+
+// requestTenantID returns the ID of the tenant that made r.
+func requestTenantID(r *http.Request) string {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	return tenantID(tenant)
+}
+
+// handleScan serves GET /v1/scan, one SCAN page per call. The Redis cursor
+// is never exposed: clients pass back the signed token from the previous
+// page, which can't be forged or reused by another tenant.
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	db := 0
+	if value := query.Get("db"); value != "" {
+		var err error
+		if db, err = strconv.Atoi(value); err != nil {
+			s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, fmt.Errorf("invalid db %q", value))
+			return
+		}
+	}
+
+	count := 100
+	if value := query.Get("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxScanCount {
+			s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest,
+				fmt.Errorf("count must be between 1 and %d", maxScanCount))
+			return
+		}
+		count = n
+	}
+
+	if !s.checkUnsharded(w) {
+		return
+	}
+
+	tenant, ok := s.authorizeCommand(w, r, "SCAN", "", db)
+	if !ok {
+		return
+	}
+
+	kind := scanCursorKind(db, query.Get("match"), query.Get("type"))
+
+	cursor := "0"
+	if token := query.Get("cursor"); token != "" {
+		var err error
+		if cursor, err = s.authManager.VerifyCursor(token, tenantID(tenant), kind); err != nil {
+			s.writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	args := []interface{}{cursor, "COUNT", count}
+	if match := query.Get("match"); match != "" {
+		args = append(args, "MATCH", match)
+	}
+	if keyType := query.Get("type"); keyType != "" {
+		args = append(args, "TYPE", keyType)
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, types.CommandRequest{Command: "SCAN", Args: args, DB: db})
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if err != nil {
+		s.writeErrorResponse(w, "Scan failed", http.StatusInternalServerError, err)
+		return
+	}
+
+	next, keys, err := parseScanReply(result)
+	if err != nil {
+		s.writeErrorResponse(w, "Scan failed", http.StatusInternalServerError, err)
+		return
+	}
+	keys = allowedKeys(tenant, keys)
+
+	response := types.ScanResponse{
+		Keys: keys,
+		Time: duration.Seconds() * 1000,
+	}
+	if next != "0" {
+		response.Cursor = s.authManager.SignCursor(tenantID(tenant), kind, next, auth.DefaultCursorTTL)
+		server.SetCursor(r.Context(), response.Cursor)
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// scanCursorKind binds a token to the parameters it was issued for, since
+// resuming a SCAN cursor with a different pattern or database is undefined.
+func scanCursorKind(db int, match, keyType string) string {
+	return fmt.Sprintf("scan:%d:%s:%s", db, match, keyType)
+}
+
+// parseScanReply splits a raw SCAN reply into the next cursor and keys.
+func parseScanReply(result interface{}) (string, []string, error) {
+	reply, ok := result.([]interface{})
+	if !ok || len(reply) != 2 {
+		return "", nil, errors.New("unexpected SCAN reply")
+	}
+
+	next, ok := reply[0].(string)
+	if !ok {
+		return "", nil, errors.New("unexpected SCAN cursor")
+	}
+
+	rawKeys, _ := reply[1].([]interface{})
+	keys := make([]string, 0, len(rawKeys))
+	for _, key := range rawKeys {
+		if str, ok := key.(string); ok {
+			keys = append(keys, str)
+		}
+	}
+
+	return next, keys, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleReady serves GET /ready. Unlike /health it fails while Redis has
+// not been reached yet (lazy connect), required scripts are missing from a
+// backend or the server is draining, so load balancers hold traffic until
+// the proxy can serve it without errors.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	var notReady []string
+	if s.lifecycle.Draining() {
+		notReady = append(notReady, "shutting down")
+	}
+	if err := s.redisClient.Connected(); err != nil {
+		notReady = append(notReady, "redis not connected: "+err.Error())
+	}
+	for _, name := range s.scripts.MissingRequired() {
+		notReady = append(notReady, "script "+name+" not loaded")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(notReady) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = json.NewEncoder(w).Encode(types.ReadyResponse{Ready: len(notReady) == 0, NotReady: notReady})
+}
+
+// handleListScripts serves GET /v1/scripts, listing preloaded scripts with
+// the SHAs to call them by.
+func (s *Server) handleListScripts(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONResponse(w, types.ScriptListResponse{Scripts: s.scripts.Status()})
+}
+
+func logScriptStatus(statuses []types.ScriptStatus) {
+	for _, status := range statuses {
+		if !status.Loaded {
+			log.Printf("Script %s missing on %s: %s", status.Name, strings.Join(status.Missing, ", "), status.Error)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/config"
+	"github.com/scaler/serverless-redis/internal/secrets"
+)
+
+// watchSecrets fetches the secret store's values every
+// secrets.refresh_interval until ctx is done and applies those that
+// changed: API keys to authentication, passwords to new Redis connections.
+// A failed fetch or invalid secrets keep the current ones.
+func (s *Server) watchSecrets(ctx context.Context, store secrets.Store) {
+	ticker := time.NewTicker(s.config.Secrets.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, s.config.Secrets.Timeout)
+		fetched, err := store.Fetch(fetchCtx)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to refresh secrets: %v", err)
+			continue
+		}
+		updated, err := config.ApplySecrets(s.liveConfig(), fetched)
+		if err != nil {
+			log.Printf("Ignoring fetched secrets: %v", err)
+			continue
+		}
+		s.applyConfig(updated)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scaler/serverless-redis/internal/config"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// newTestServer returns a server on an embedded Redis, configured by
+// settings on top of the defaults, and its routes.
+func newTestServer(t *testing.T, settings string) (*Server, http.Handler) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("redis:\n  mode: embedded\n"+settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile() error = %v", err)
+	}
+	// Each server registers its metrics anew
+	registry := prometheus.NewRegistry()
+	prometheus.DefaultRegisterer, prometheus.DefaultGatherer = registry, registry
+
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, s.setupRoutes()
+}
+
+// doRequest sends a request with body to handler, authenticated with apiKey
+// if it is set.
+func doRequest(handler http.Handler, method, path, apiKey, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		r.Header.Set("Authorization", apiKey)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// runCommand sends cmd to /v1/command and decodes the response.
+func runCommand(t *testing.T, handler http.Handler, apiKey, cmd string) (types.CommandResponse, *httptest.ResponseRecorder) {
+	t.Helper()
+	w := doRequest(handler, http.MethodPost, "/v1/command", apiKey, cmd)
+	var response types.CommandResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode %q: %v", w.Body.String(), err)
+	}
+	return response, w
+}
+
+// setKey sets key to value straight in Redis.
+func setKey(t *testing.T, s *Server, key, value string) {
+	t.Helper()
+	if _, err := s.redisClient.ExecuteCommand(context.Background(), types.CommandRequest{Command: "SET", Args: []interface{}{key, value}}); err != nil {
+		t.Fatalf("SET %s error = %v", key, err)
+	}
+}
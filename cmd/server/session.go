@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+)
+
+const (
+	// sessionHeader carries read-your-writes session tokens in both
+	// directions: issued on responses to writes, presented on later reads.
+	sessionHeader = "X-SR-Session"
+
+	sessionCursorKind = "session"
+	sessionTTL        = 15 * time.Minute
+)
+
+// sessionMiddleware gives each request read-your-writes consistency when
+// reads are routed to replicas. A presented session token pins reads to
+// replicas at or past the offset it encodes; a request that writes gets a
+// fresh token carrying the primary's offset after the write.
+func (s *Server) sessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.redisClient.HasReplicas() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant, _ := auth.GetTenantFromContext(r.Context())
+		session := &redis.Session{}
+
+		if token := r.Header.Get(sessionHeader); token != "" {
+			value, err := s.authManager.VerifyCursor(token, tenantID(tenant), sessionCursorKind)
+			switch {
+			case errors.Is(err, auth.ErrCursorExpired):
+				// Replicas have long since caught up with an expired session
+			case err != nil:
+				s.writeErrorResponse(w, "Invalid session token", http.StatusBadRequest, err)
+				return
+			default:
+				offset, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					s.writeErrorResponse(w, "Invalid session token", http.StatusBadRequest, err)
+					return
+				}
+				session.MinOffset = offset
+			}
+		}
+
+		sw := &sessionWriter{ResponseWriter: w, issue: func() {
+			if !session.Wrote() {
+				return
+			}
+			offset, err := s.redisClient.PrimaryOffset(r.Context())
+			if err != nil {
+				return
+			}
+			token := s.authManager.SignCursor(tenantID(tenant), sessionCursorKind, strconv.FormatInt(offset, 10), sessionTTL)
+			w.Header().Set(sessionHeader, token)
+		}}
+
+		next.ServeHTTP(sw, r.WithContext(redis.WithSession(r.Context(), session)))
+	})
+}
+
+// sessionWriter issues the session token just before the response headers
+// are sent, once the handler's writes have been executed.
+type sessionWriter struct {
+	http.ResponseWriter
+	issue func()
+	once  sync.Once
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	sw.once.Do(sw.issue)
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(data []byte) (int, error) {
+	sw.once.Do(sw.issue)
+	return sw.ResponseWriter.Write(data)
+}
+
+func (sw *sessionWriter) Flush() {
+	sw.once.Do(sw.issue)
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
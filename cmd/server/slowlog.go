@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/observability"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// maxSlowLogLimit bounds ?limit= on /admin/slowlog.
+const maxSlowLogLimit = 1000
+
+// slowRequestMiddleware records API requests slower than the request
+// threshold in the slow log.
+func (s *Server) slowRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Event streams last as long as the client listens
+		duration := time.Since(start)
+		if !s.slowLog.IsSlow(types.SlowRequest, duration) || strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			return
+		}
+
+		tenant, _ := auth.GetTenantFromContext(r.Context())
+		entry := types.SlowEntry{
+			Kind:       types.SlowRequest,
+			Time:       start,
+			DurationMs: float64(duration) / float64(time.Millisecond),
+			Tenant:     tenantID(tenant),
+			TraceID:    observability.TraceIDFromContext(r.Context()),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     recorder.status,
+		}
+		s.slowLog.Record(entry)
+		s.metrics.RecordSlow(types.SlowRequest, tenant)
+		log.Printf("Slow request: %s %s took %dms (status %d, tenant %q, trace %q)",
+			r.Method, r.URL.Path, duration.Milliseconds(), recorder.status, entry.Tenant, entry.TraceID)
+	})
+}
+
+// recordSlowCommand records a Redis round trip slower than the command
+// threshold in the slow log. Pipelines and transactions are recorded as
+// one entry named after them, with their commands as arguments.
+func (s *Server) recordSlowCommand(ctx context.Context, tenant *types.Tenant, command string, args []interface{}, db int, duration time.Duration) {
+	if !s.slowLog.IsSlow(types.SlowCommand, duration) {
+		return
+	}
+
+	entry := types.SlowEntry{
+		Kind:       types.SlowCommand,
+		Time:       time.Now().Add(-duration),
+		DurationMs: float64(duration) / float64(time.Millisecond),
+		Tenant:     tenantID(tenant),
+		TraceID:    observability.TraceIDFromContext(ctx),
+		Command:    command,
+		Args:       args,
+		DB:         db,
+	}
+	s.slowLog.Record(entry)
+	s.metrics.RecordSlow(types.SlowCommand, tenant)
+	log.Printf("Slow command: %s took %dms (db %d, tenant %q, trace %q)",
+		command, duration.Milliseconds(), db, entry.Tenant, entry.TraceID)
+}
+
+// commandNames lists the commands of a pipeline or transaction for its
+// slow log entry.
+func commandNames(commands []types.CommandRequest) []interface{} {
+	names := make([]interface{}, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.Command
+	}
+	return names
+}
+
+// handleSlowLog serves GET /admin/slowlog, the most recent slow requests
+// and commands, newest first.
+func (s *Server) handleSlowLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	kind := query.Get("kind")
+	switch kind {
+	case "", types.SlowRequest, types.SlowCommand:
+	default:
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeInvalidArgument, "kind",
+			"kind must be request or command, got %q", kind))
+		return
+	}
+
+	limit, err := intParam(query.Get("limit"), 0, maxSlowLogLimit)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid limit", http.StatusBadRequest, fmt.Errorf("limit %w", err))
+		return
+	}
+
+	s.writeJSONResponse(w, s.slowLog.Report(kind, limit))
+}
+
+// handleResetSlowLog serves DELETE /admin/slowlog.
+func (s *Server) handleResetSlowLog(w http.ResponseWriter, r *http.Request) {
+	s.slowLog.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statusWriter records the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(data []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(data)
+}
+
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
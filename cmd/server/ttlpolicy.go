@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+)
+
+// ttlPolicyMiddleware applies the tenant's default_ttl and max_ttl to the
+// SET-family commands of the request, so keys in shared clusters don't
+// live forever.
+func (s *Server) ttlPolicyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := tenantTTLPolicy(r)
+		if policy == (redis.TTLPolicy{}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(redis.WithTTLPolicy(r.Context(), policy)))
+	})
+}
+
+// tenantTTLPolicy returns the TTL policy of the request's tenant.
+func tenantTTLPolicy(r *http.Request) redis.TTLPolicy {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant == nil {
+		return redis.TTLPolicy{}
+	}
+	return redis.TTLPolicy{Default: tenant.DefaultTTL, Max: tenant.MaxTTL}
+}
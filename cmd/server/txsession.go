@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleOpenTxSession serves POST /v1/session, which opens a transaction
+// session: a Redis connection held for the tenant across requests, with
+// the requested keys WATCHed on it. Opening one needs the WATCH
+// permission.
+func (s *Server) handleOpenTxSession(w http.ResponseWriter, r *http.Request) {
+	var req types.TxSessionRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+	watch := make([]interface{}, len(req.Watch))
+	for i, key := range req.Watch {
+		watch[i] = key
+	}
+	watchReq := types.CommandRequest{Command: "WATCH", Args: watch}
+
+	tenant, ok := s.authorizeCommand(w, r, "WATCH", req.Database, req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, watchReq) {
+		return
+	}
+
+	session, err := s.txSessions.Open(tenantID(tenant), func() (server.TxConn, error) {
+		return s.redisClient.OpenTxConn(r.Context(), req.Database, req.DB, req.Watch)
+	})
+	if s.checkPoolExhausted(w, err) || !s.checkTxSessionError(w, "Failed to open session", err) {
+		return
+	}
+	s.writeJSONResponse(w, types.TxSessionResponse{
+		Session:       session.ID,
+		IdleTimeoutMs: s.config.TxSessions.IdleTimeout.Milliseconds(),
+		ExpiresAt:     session.Expires,
+	})
+}
+
+// handleTxSessionCommand serves POST /v1/session/{id}/command, running a
+// command on the session's connection. After MULTI, commands are queued
+// and reply QUEUED until the session's exec or discard.
+func (s *Server) handleTxSessionCommand(w http.ResponseWriter, r *http.Request) {
+	var req types.CommandRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := types.ValidateTxSessionCommand(&req, s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	// The session's database was checked when it was opened
+	server.SetAccessLogCommand(r.Context(), req.Command)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, req.Command); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return
+		}
+	}
+	if !s.checkWrites(w, r, req) || !s.checkKeyPolicy(w, tenant, req) ||
+		!s.checkValueSize(w, tenant, req) || !s.checkRateLimit(w, tenant, req) ||
+		!s.checkBudget(w, r) {
+		return
+	}
+
+	var response types.CommandResponse
+	id := mux.Vars(r)["id"]
+	err := s.txSessions.Use(id, tenantID(tenant), func(conn server.TxConn) error {
+		result, duration, err := s.executeWith(r.Context(), tenant, req, func(ctx context.Context) (interface{}, error) {
+			return conn.Do(ctx, req)
+		})
+		if errors.Is(err, redis.ErrTxConnLost) {
+			return err
+		}
+		response = newCommandResponse(result, duration, err)
+		return nil
+	})
+	if !s.checkTxSessionError(w, "Session command failed", s.closeLostTxSession(id, tenant, err)) {
+		return
+	}
+	s.writeCommandResponse(w, response)
+}
+
+// handleExecTxSession serves POST /v1/session/{id}/exec, running the
+// commands queued since MULTI. If a watched key changed, none of them run
+// and exec is false; the session stays open for the client to retry.
+func (s *Server) handleExecTxSession(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := s.authorizeTxSession(w, r, "EXEC")
+	if !ok {
+		return
+	}
+
+	var response *types.TransactionResponse
+	id := mux.Vars(r)["id"]
+	start := time.Now()
+	err := s.txSessions.Use(id, tenantID(tenant), func(conn server.TxConn) error {
+		var err error
+		response, err = conn.Exec(r.Context())
+		return err
+	})
+	duration := time.Since(start)
+	s.observeLatency(duration)
+	server.AddRedisTime(r.Context(), duration)
+
+	if err != nil && server.RedisErrorCode(err) != "" {
+		// EXECABORT, or EXEC without MULTI: the client's mistake
+		s.metrics.RecordRedisCommand("EXEC", "error", tenant, duration)
+		s.writeErrorResponse(w, "Transaction failed", http.StatusBadRequest, err)
+		return
+	}
+	if !s.checkTxSessionError(w, "Transaction failed", s.closeLostTxSession(id, tenant, err)) {
+		return
+	}
+
+	status := "success"
+	if !response.Exec {
+		status = "discarded"
+	}
+	s.metrics.RecordRedisCommand("EXEC", status, tenant, duration)
+	s.recordOOMResults(response.Results)
+	s.writeJSONResponse(w, response)
+}
+
+// handleDiscardTxSession serves POST /v1/session/{id}/discard, dropping
+// the queued commands and unwatching every key. The session stays open.
+func (s *Server) handleDiscardTxSession(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := s.authorizeTxSession(w, r, "DISCARD")
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := s.txSessions.Use(id, tenantID(tenant), func(conn server.TxConn) error {
+		return conn.Discard(r.Context())
+	})
+	if err != nil && server.RedisErrorCode(err) != "" {
+		s.writeErrorResponse(w, "Discard failed", http.StatusBadRequest, err)
+		return
+	}
+	if !s.checkTxSessionError(w, "Discard failed", s.closeLostTxSession(id, tenant, err)) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCloseTxSession serves DELETE /v1/session/{id}, discarding anything
+// queued and releasing the session's connection.
+func (s *Server) handleCloseTxSession(w http.ResponseWriter, r *http.Request) {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if !s.checkTxSessionError(w, "Failed to close session", s.txSessions.Close(mux.Vars(r)["id"], tenantID(tenant))) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeTxSession checks that the request's tenant may send command,
+// on the database its session was opened on.
+func (s *Server) authorizeTxSession(w http.ResponseWriter, r *http.Request, command string) (*types.Tenant, bool) {
+	server.SetAccessLogCommand(r.Context(), command)
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+	if tenant != nil {
+		if err := s.authManager.ValidateCommand(tenant, command); err != nil {
+			s.writeErrorResponse(w, "Command not permitted", http.StatusForbidden, err)
+			return tenant, false
+		}
+	}
+	return tenant, s.checkBudget(w, r)
+}
+
+// closeLostTxSession closes the session id if its connection was lost, as
+// it can't be used again, and returns err.
+func (s *Server) closeLostTxSession(id string, tenant *types.Tenant, err error) error {
+	if errors.Is(err, redis.ErrTxConnLost) {
+		_ = s.txSessions.Close(id, tenantID(tenant))
+	}
+	return err
+}
+
+// checkTxSessionError writes the response for a failed transaction session
+// operation, returning false if there was one.
+func (s *Server) checkTxSessionError(w http.ResponseWriter, message string, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, server.ErrTxSessionNotFound):
+		s.writeErrorResponse(w, "Session not found", http.StatusNotFound, err)
+	case errors.Is(err, server.ErrTooManyTxSessions):
+		w.Header().Set("Retry-After", "1")
+		s.writeErrorResponse(w, "Too many sessions", http.StatusTooManyRequests, err)
+	case errors.Is(err, redis.ErrCrossShard):
+		s.writeCodedError(w, message, http.StatusBadRequest, types.ErrCodeCrossShard, err)
+	case errors.Is(err, redis.ErrTxConnLost):
+		s.writeErrorResponse(w, "Session connection lost", http.StatusBadGateway, err)
+	default:
+		s.writeErrorResponse(w, message, http.StatusBadGateway, err)
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	uniqueDateLayout           = "2006-01-02"
+	defaultUniqueRetentionDays = 90
+	maxUniqueRangeDays         = 366
+)
+
+// uniqueKey returns the HyperLogLog key holding a metric's identifiers for
+// one UTC day. Keys rotate daily and expire after the retention period.
+func uniqueKey(metric string, day time.Time) string {
+	return fmt.Sprintf("unique:%s:%s", metric, day.Format(uniqueDateLayout))
+}
+
+func parseUniqueDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), nil
+	}
+
+	day, err := time.Parse(uniqueDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return day, nil
+}
+
+// parseUniqueRange resolves the day range of a count query from either
+// ?date=, ?from=&to= or a rolling ?days=N window ending today.
+func parseUniqueRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	if days := query.Get("days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid days %q", days)
+		}
+		to, _ := parseUniqueDate("")
+		return to.AddDate(0, 0, -(n - 1)), to, nil
+	}
+
+	if query.Get("from") != "" || query.Get("to") != "" {
+		from, err := parseUniqueDate(query.Get("from"))
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to, err := parseUniqueDate(query.Get("to"))
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return from, to, nil
+	}
+
+	day, err := parseUniqueDate(query.Get("date"))
+	return day, day, err
+}
+
+// handleUniqueAdd serves POST /v1/unique/{metric}, adding identifiers to the
+// HyperLogLog for the given (or current) day.
+func (s *Server) handleUniqueAdd(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+
+	var req types.UniqueAddRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		s.writeValidationError(w, types.NewValidationError(types.ErrCodeMissingField, "ids", "ids must not be empty"))
+		return
+	}
+
+	day, err := parseUniqueDate(req.Date)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+
+	retention := req.RetentionDays
+	if retention <= 0 {
+		retention = defaultUniqueRetentionDays
+	}
+
+	if !s.permitCommand(w, r, "EXPIREAT") {
+		return
+	}
+	tenant, ok := s.authorizeCommand(w, r, "PFADD", "", req.DB)
+	if !ok {
+		return
+	}
+
+	key := uniqueKey(metric, day)
+	args := make([]interface{}, 0, len(req.IDs)+1)
+	args = append(args, key)
+	for _, id := range req.IDs {
+		args = append(args, id)
+	}
+
+	// Expiry is measured from the end of the day being recorded so that
+	// backfilled days don't outlive the retention window.
+	expireAt := day.AddDate(0, 0, retention+1).Unix()
+
+	commands := []types.CommandRequest{
+		{Command: "PFADD", Args: args},
+		{Command: "EXPIREAT", Args: []interface{}{key, expireAt}},
+	}
+	if !s.checkKeyPolicy(w, tenant, commands...) {
+		return
+	}
+
+	results, duration := s.executePipeline(r.Context(), tenant, types.PipelineRequest{DB: req.DB, Commands: commands})
+	if s.checkPoolExhaustedResults(w, results) {
+		return
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			s.writeErrorResponse(w, "Failed to record identifiers", http.StatusInternalServerError, errors.New(result.Error))
+			return
+		}
+	}
+
+	updated, _ := results[0].Result.(int64)
+	s.writeJSONResponse(w, types.UniqueAddResponse{
+		Metric:  metric,
+		Date:    day.Format(uniqueDateLayout),
+		Updated: updated == 1,
+		Time:    duration.Seconds() * 1000,
+	})
+}
+
+// handleUniqueCount serves GET /v1/unique/{metric}. The unique count over a
+// range is the PFCOUNT of the union of its daily keys; ?daily=true adds the
+// per-day counts.
+func (s *Server) handleUniqueCount(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+
+	from, to, err := parseUniqueRange(r)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, err)
+		return
+	}
+
+	if to.Before(from) {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, errors.New("from must not be after to"))
+		return
+	}
+
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days > maxUniqueRangeDays {
+		s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest,
+			fmt.Errorf("range of %d days exceeds the maximum of %d", days, maxUniqueRangeDays))
+		return
+	}
+
+	db := 0
+	if value := r.URL.Query().Get("db"); value != "" {
+		if db, err = strconv.Atoi(value); err != nil {
+			s.writeErrorResponse(w, "Invalid request", http.StatusBadRequest, fmt.Errorf("invalid db %q", value))
+			return
+		}
+	}
+	daily := r.URL.Query().Get("daily") == "true"
+
+	tenant, ok := s.authorizeCommand(w, r, "PFCOUNT", "", db)
+	if !ok {
+		return
+	}
+
+	keys := make([]interface{}, 0, days)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		keys = append(keys, uniqueKey(metric, day))
+	}
+
+	commands := []types.CommandRequest{{Command: "PFCOUNT", Args: keys}}
+	if daily {
+		for _, key := range keys {
+			commands = append(commands, types.CommandRequest{Command: "PFCOUNT", Args: []interface{}{key}})
+		}
+	}
+	if !s.checkKeyPolicy(w, tenant, commands...) {
+		return
+	}
+
+	results, duration := s.executePipeline(r.Context(), tenant, types.PipelineRequest{DB: db, Commands: commands})
+	if s.checkPoolExhaustedResults(w, results) {
+		return
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			s.writeErrorResponse(w, "Failed to count identifiers", http.StatusInternalServerError, errors.New(result.Error))
+			return
+		}
+	}
+
+	response := types.UniqueCountResponse{
+		Metric: metric,
+		From:   from.Format(uniqueDateLayout),
+		To:     to.Format(uniqueDateLayout),
+		Time:   duration.Seconds() * 1000,
+	}
+	response.Unique, _ = results[0].Result.(int64)
+
+	if daily {
+		response.Daily = make(map[string]int64, days)
+		for i, day := 0, from; i < days; i, day = i+1, day.AddDate(0, 0, 1) {
+			count, _ := results[i+1].Result.(int64)
+			response.Daily[day.Format(uniqueDateLayout)] = count
+		}
+	}
+
+	s.writeJSONResponse(w, response)
+}
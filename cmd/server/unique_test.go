@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const uniqueAuthSettings = `
+auth:
+  enabled: true
+  jwt_secret: test-secret
+  api_keys:
+    - key: counter-key
+      tenant_id: counter
+      allowed_dbs: [0]
+      permissions: [PFADD, EXPIREAT, PFCOUNT]
+    - key: no-expiry-key
+      tenant_id: no-expiry
+      allowed_dbs: [0]
+      permissions: [PFADD, EXPIRE, PFCOUNT]
+`
+
+func TestUniqueAddPermissions(t *testing.T) {
+	_, handler := newTestServer(t, uniqueAuthSettings)
+
+	body := `{"ids":["a","b"],"date":"2024-03-01"}`
+	if w := doRequest(handler, http.MethodPost, "/v1/unique/visits", "counter-key", body); w.Code != http.StatusOK {
+		t.Errorf("Expected PFADD and EXPIREAT to be enough, got %d: %s", w.Code, w.Body.String())
+	}
+	// The key is given its expiry with EXPIREAT, which EXPIRE doesn't cover
+	if w := doRequest(handler, http.MethodPost, "/v1/unique/visits", "no-expiry-key", body); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without EXPIREAT, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUniqueAddExpiry(t *testing.T) {
+	s, handler := newTestServer(t, "")
+
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	body := `{"ids":["a","b","a"],"date":"` + day.Format(uniqueDateLayout) + `","retention_days":7}`
+	if w := doRequest(handler, http.MethodPost, "/v1/unique/visits", "", body); w.Code != http.StatusOK {
+		t.Fatalf("Expected the identifiers to be recorded, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The key expires retention days after the end of the day recorded
+	result, err := s.redisClient.ExecuteCommand(context.Background(), types.CommandRequest{
+		Command: "TTL", Args: []interface{}{uniqueKey("visits", day)},
+	})
+	if err != nil {
+		t.Fatalf("TTL error = %v", err)
+	}
+	want := time.Until(day.AddDate(0, 0, 8))
+	if ttl, _ := result.(int64); ttl < int64(want.Seconds())-5 || ttl > int64(want.Seconds()) {
+		t.Errorf("Expected a TTL of about %v, got %v", want, result)
+	}
+
+	w := doRequest(handler, http.MethodGet, "/v1/unique/visits?date="+day.Format(uniqueDateLayout), "", "")
+	var response types.UniqueCountResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Unique != 2 {
+		t.Errorf("Expected 2 unique identifiers, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/config"
+	"github.com/scaler/serverless-redis/internal/observability"
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/server"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// validateTimeout bounds the Redis checks of --validate-config.
+const validateTimeout = 10 * time.Second
+
+// validateConfigFile runs the checks the server makes on startup against
+// the config file at path ("" for the default) without serving anything,
+// printing each result to out, and returns the process exit code. Settings
+// that match no config field are reported as errors too. With checkRedis it
+// also connects to every Redis backend.
+func validateConfigFile(out io.Writer, path string, checkRedis bool) int {
+	if path == "" {
+		path = config.Path()
+	}
+	if path == "" {
+		fmt.Fprintln(out, "Validating configuration from environment variables")
+	} else {
+		fmt.Fprintf(out, "Validating %s\n", path)
+	}
+
+	failed := 0
+	check := func(name string, err error) {
+		if err != nil {
+			failed++
+			// yaml errors list one problem per line
+			fmt.Fprintf(out, "  ✗ %s: %s\n", name, strings.ReplaceAll(err.Error(), "\n", "\n      "))
+			return
+		}
+		fmt.Fprintf(out, "  ✓ %s\n", name)
+	}
+
+	if path != "" {
+		check("known settings", config.CheckUnknownFields(path))
+	}
+	cfg, err := config.LoadConfigFile(path)
+	check("configuration", err)
+	if err != nil {
+		return 1
+	}
+
+	_, err = commandCacheRules(cfg.Cache.Rules)
+	check("cache rules", err)
+	_, err = redis.NewScriptRegistry(nil, cfg.Scripts.Preload)
+	check("scripts", err)
+	_, err = server.NewMaintenanceScheduler(cfg.Maintenance.Windows)
+	check("maintenance windows", err)
+	if cfg.Observability.Errors.Enabled {
+		_, err = observability.NewErrorReporter(cfg.Observability.Errors, Version)
+		check("error reporting", err)
+	}
+
+	switch {
+	case !checkRedis || failed > 0:
+	case cfg.Redis.Mode == types.RedisModeEmbedded:
+		fmt.Fprintln(out, "  ✓ redis: embedded, started with the server")
+	default:
+		failed += checkRedisBackends(out, cfg)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(out, "%d check(s) failed\n", failed)
+		return 1
+	}
+	fmt.Fprintln(out, "Configuration is valid")
+	return 0
+}
+
+// checkRedisBackends connects to every configured Redis backend, printing
+// its version or the error, and returns how many could not be reached.
+func checkRedisBackends(out io.Writer, cfg *types.Config) int {
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+
+	// Report unreachable backends instead of waiting for them
+	strict := *cfg
+	strict.Redis.Connect.Lazy = false
+	client, err := redis.NewClient(&strict)
+	if err != nil {
+		fmt.Fprintf(out, "  ✗ redis: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	failed := 0
+	for _, info := range client.BackendInfo(ctx, "", "server") {
+		if info.Error != "" {
+			failed++
+			fmt.Fprintf(out, "  ✗ redis %s: %s\n", info.Backend, info.Error)
+			continue
+		}
+		fmt.Fprintf(out, "  ✓ redis %s: version %s\n", info.Backend, info.Sections["server"]["redis_version"])
+	}
+	return failed
+}
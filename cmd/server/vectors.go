@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/scaler/serverless-redis/internal/redis"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// defaultVectorK is how many matches a vector search returns without k.
+const defaultVectorK = 10
+
+// handleVectorUpsert serves POST /v1/vectors/{key}, adding or replacing
+// elements of a vector set with one VADD each, sent in one pipeline.
+func (s *Server) handleVectorUpsert(w http.ResponseWriter, r *http.Request) {
+	if !s.checkModule(w, redis.ModuleVectorSet, "Vector sets") {
+		return
+	}
+	key := mux.Vars(r)["key"]
+
+	var req types.VectorUpsertRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	commands := make([]types.CommandRequest, len(req.Items))
+	for i, item := range req.Items {
+		blob, _ := item.FP32() // checked by Validate
+		args := []interface{}{key, "FP32", string(blob), item.ID}
+		if len(item.Attributes) > 0 {
+			args = append(args, "SETATTR", string(item.Attributes))
+		}
+		commands[i] = types.CommandRequest{Command: "VADD", Args: args}
+	}
+
+	tenant, ok := s.authorizeMultiKey(w, r, "VADD", "", req.DB, commands, commands...)
+	if !ok {
+		return
+	}
+
+	results, duration, ok := s.executeMultiKey(w, r, tenant, "", req.DB, commands)
+	if !ok {
+		return
+	}
+
+	response := types.VectorUpsertResponse{Key: key, Time: duration.Seconds() * 1000}
+	for _, result := range results {
+		if added, _ := result.Result.(int64); added == 1 {
+			response.Added++
+		} else {
+			response.Updated++
+		}
+	}
+	s.writeJSONResponse(w, response)
+}
+
+// handleVectorSearch serves POST /v1/vectors/{key}/search, the k elements
+// of a vector set most similar to a vector or element, found with VSIM.
+// with_attributes adds each match's attributes, read with VGETATTR.
+func (s *Server) handleVectorSearch(w http.ResponseWriter, r *http.Request) {
+	if !s.checkModule(w, redis.ModuleVectorSet, "Vector sets") {
+		return
+	}
+	key := mux.Vars(r)["key"]
+
+	var req types.VectorSearchRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(s.validationLimits()); err != nil {
+		s.writeValidationError(w, err)
+		return
+	}
+
+	args := []interface{}{key}
+	if req.ID != "" {
+		args = append(args, "ELE", req.ID)
+	} else {
+		blob, _ := req.FP32() // checked by Validate
+		args = append(args, "FP32", string(blob))
+	}
+	k := req.K
+	if k == 0 {
+		k = defaultVectorK
+	}
+	args = append(args, "WITHSCORES", "COUNT", k)
+	if req.Filter != "" {
+		args = append(args, "FILTER", req.Filter)
+	}
+	cmd := types.CommandRequest{Command: "VSIM", Args: args, DB: req.DB}
+
+	tenant, ok := s.authorizeCommand(w, r, cmd.Command, "", req.DB)
+	if !ok || !s.checkKeyPolicy(w, tenant, cmd) {
+		return
+	}
+	if req.WithAttributes {
+		if _, ok := s.authorizeCommand(w, r, "VGETATTR", "", req.DB); !ok {
+			return
+		}
+	}
+
+	result, duration, err := s.executeCommand(r.Context(), tenant, cmd)
+	if s.checkPoolExhausted(w, err) {
+		return
+	}
+	if err != nil {
+		s.writeCommandResponse(w, newCommandResponse(nil, duration, err))
+		return
+	}
+
+	matches, err := redis.ParseVectorScores(result)
+	if err != nil {
+		s.writeErrorResponse(w, "Invalid VSIM reply", http.StatusBadGateway, err)
+		return
+	}
+
+	if req.WithAttributes && len(matches) > 0 {
+		commands := make([]types.CommandRequest, len(matches))
+		for i, match := range matches {
+			commands[i] = types.CommandRequest{Command: "VGETATTR", Args: []interface{}{key, match.ID}}
+		}
+		results, attrDuration := s.executePipeline(r.Context(), tenant, types.PipelineRequest{DB: req.DB, Commands: commands})
+		if s.checkPoolExhaustedResults(w, results) {
+			return
+		}
+		duration += attrDuration
+
+		// Elements without attributes, or removed since the search, reply
+		// nil and are left without
+		for i, result := range results {
+			if attributes, _ := result.Result.(string); result.Error == "" && json.Valid([]byte(attributes)) {
+				matches[i].Attributes = json.RawMessage(attributes)
+			}
+		}
+	}
+
+	s.writeJSONResponse(w, types.VectorSearchResponse{
+		Key:     key,
+		Matches: matches,
+		Time:    duration.Seconds() * 1000,
+	})
+}
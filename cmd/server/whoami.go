@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// handleWhoAmI serves GET /v1/whoami: the calling tenant's identity,
+// permissions and limits, with how much of its rate limit is left.
+func (s *Server) handleWhoAmI(w http.ResponseWriter, r *http.Request) {
+	tenant, _ := auth.GetTenantFromContext(r.Context())
+
+	response := types.WhoAmIResponse{
+		Permissions:   []string{"*"},
+		AllowedDBs:    s.tenantDatabases(tenant),
+		Admin:         tenant == nil,
+		MaxValueBytes: s.maxValueBytes(tenant),
+		MaxKeyBytes:   s.maxKeyBytes(tenant),
+	}
+	if tenant != nil {
+		response.Authenticated = true
+		response.TenantID = tenant.ID
+		response.Permissions = tenant.Permissions
+		response.AllowedDatabases = tenant.AllowedDatabases
+		response.Admin = tenant.Admin
+		response.Tier = tenant.Tier
+		if tenant.KeyPattern != nil {
+			response.KeyPattern = tenant.KeyPattern.String()
+		}
+		response.RateLimit = s.rateLimitStatus(tenant)
+	}
+
+	s.writeJSONResponse(w, response)
+}
+
+// rateLimitStatus reports tenant's rate limit and its usage over about the
+// last second.
+func (s *Server) rateLimitStatus(tenant *types.Tenant) types.RateLimitStatus {
+	status := types.RateLimitStatus{Limit: tenant.RateLimit}
+	if s.rateLimiter == nil || tenant.RateLimit <= 0 {
+		return status
+	}
+
+	remaining := s.rateLimiter.Remaining(tenant.ID, tenant.RateLimit)
+	status.Enforced = true
+	status.Used = int(math.Round(float64(tenant.RateLimit) - remaining))
+	status.Remaining = int(math.Max(0, math.Round(remaining)))
+	return status
+}
@@ -0,0 +1,84 @@
+// Command token mints scoped JWTs for the serverless Redis proxy.
+//
+// The signing secret is taken from -secret, the JWT_SECRET environment
+// variable, or the auth.jwt_secret of the loaded config, in that order.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/config"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("JWT_SECRET"), "JWT signing secret (defaults to $JWT_SECRET or the config file)")
+	tenant := flag.String("tenant", "", "tenant ID the token is issued for (required)")
+	permissions := flag.String("permissions", "*", "comma-separated command permissions, e.g. GET,SET,HGET*")
+	dbs := flag.String("dbs", "0", "comma-separated list of allowed databases")
+	rateLimit := flag.Int("rate-limit", 1000, "requests per second allowed for the tenant")
+	ttl := flag.Duration("ttl", 24*time.Hour, "token lifetime")
+	flag.Parse()
+
+	if *tenant == "" {
+		fail("-tenant is required")
+	}
+
+	if *ttl <= 0 {
+		fail("-ttl must be positive")
+	}
+
+	allowedDBs, err := parseDBs(*dbs)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	if *secret == "" {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fail(fmt.Sprintf("no -secret given and failed to load config: %v", err))
+		}
+		*secret = cfg.Auth.JWTSecret
+	}
+
+	manager := auth.NewManager(&types.AuthConfig{Enabled: true, JWTSecret: *secret})
+	token, err := manager.GenerateJWT(*tenant, *rateLimit, allowedDBs, splitList(*permissions), *ttl)
+	if err != nil {
+		fail(fmt.Sprintf("failed to generate token: %v", err))
+	}
+
+	fmt.Println(token)
+}
+
+func parseDBs(value string) ([]int, error) {
+	var dbs []int
+	for _, part := range splitList(value) {
+		db, err := strconv.Atoi(part)
+		if err != nil || db < 0 {
+			return nil, fmt.Errorf("invalid database %q", part)
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+func fail(message string) {
+	fmt.Fprintf(os.Stderr, "token: %s\n", message)
+	os.Exit(1)
+}
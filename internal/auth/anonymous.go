@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"regexp"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// AnonymousTenantID is the tenant of requests without credentials, when
+// anonymous access is enabled.
+const AnonymousTenantID = "anonymous"
+
+// newAnonymousTenant returns the tenant anonymous requests run as: limited
+// to the configured commands, database and key prefix.
+func newAnonymousTenant(config types.AnonymousConfig) *types.Tenant {
+	return &types.Tenant{
+		ID:             AnonymousTenantID,
+		RateLimit:      config.RateLimit,
+		AllowedDBs:     []int{config.DB},
+		Permissions:    config.Commands,
+		AllowedOrigins: config.AllowedOrigins,
+		KeyPattern:     regexp.MustCompile("^" + regexp.QuoteMeta(config.KeyPrefix)),
+	}
+}
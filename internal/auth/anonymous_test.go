@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestAnonymousAccess(t *testing.T) {
+	config := &types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		Anonymous: types.AnonymousConfig{
+			Enabled:        true,
+			Commands:       []string{"GET", "MGET"},
+			KeyPrefix:      "public:",
+			DB:             2,
+			RateLimit:      50,
+			AllowedOrigins: []string{"https://*.example.com"},
+		},
+	}
+	manager := NewManager(config)
+
+	req := httptest.NewRequest("GET", "/v1/command", nil)
+	if _, err := manager.ValidateRequest(req); !errors.Is(err, ErrRestricted) {
+		t.Errorf("Expected anonymous requests without an allowed origin to be restricted, got %v", err)
+	}
+
+	req.Header.Set("Origin", "https://www.example.com")
+	tenant, err := manager.ValidateRequest(req)
+	if err != nil {
+		t.Fatalf("Expected anonymous access, got %v", err)
+	}
+	if tenant.ID != AnonymousTenantID || tenant.Admin || tenant.RateLimit != 50 {
+		t.Errorf("Unexpected anonymous tenant %+v", tenant)
+	}
+	if err := manager.ValidateCommand(tenant, "get"); err != nil {
+		t.Errorf("Expected GET to be allowed, got %v", err)
+	}
+	if err := manager.ValidateCommand(tenant, "SET"); err == nil {
+		t.Error("Expected SET to be refused")
+	}
+	if err := manager.ValidateDatabase(tenant, 0); err == nil {
+		t.Error("Expected databases other than 2 to be refused")
+	}
+	if !tenant.KeyPattern.MatchString("public:flags") || tenant.KeyPattern.MatchString("private:public:flags") {
+		t.Errorf("Expected the key pattern to require the prefix, got %s", tenant.KeyPattern)
+	}
+
+	// Credentials are still checked when given
+	req.Header.Set("Authorization", "invalid-key")
+	if _, err := manager.ValidateRequest(req); err == nil {
+		t.Error("Expected invalid credentials to be refused")
+	}
+
+	config.Anonymous.Enabled = false
+	req.Header.Del("Authorization")
+	if _, err := NewManager(config).ValidateRequest(req); err == nil {
+		t.Error("Expected requests without credentials to be refused with anonymous access disabled")
+	}
+}
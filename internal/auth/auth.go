@@ -2,12 +2,19 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,9 +22,42 @@ import (
 )
 
 type Manager struct {
-	config  *types.AuthConfig
-	apiKeys map[string]*types.Tenant
-	jwtKey  []byte
+	config      *types.AuthConfig
+	keys        atomic.Pointer[keySet]
+	nonces      *nonceCache
+	revocations *RevocationList
+	jwtKey      []byte
+	keyPatterns *keyPatterns
+	tokens      *tokenCache
+	lockouts    *failureLimiter
+	observer    LockoutObserver
+	anonymous   *types.Tenant
+}
+
+// keySet is everything derived from the configured API keys, replaced as
+// a whole when they change.
+type keySet struct {
+	// API keys by their SHA-256 hash. A lookup takes time depending only on
+	// the hash of the presented key, which reveals nothing about how close
+	// it is to a real key, and the keys themselves are never compared
+	apiKeys     map[[sha256.Size]byte]*types.Tenant
+	signingKeys map[string]signingKey
+	
+	// Settings by tenant ID, so JWTs run as the same Redis user, in the
+	// same tier and with the same limits as the tenant's API keys
+	tenants map[string]tenantSettings
+}
+
+type tenantSettings struct {
+	redisUsername string
+	redisPassword string
+	tier          string
+	maxValueBytes int
+	maxKeyBytes   int
+	keyPattern    *regexp.Regexp
+	databases     []string
+	defaultTTL    time.Duration
+	maxTTL        time.Duration
 }
 
 type JWTClaims struct {
@@ -29,25 +69,109 @@ type JWTClaims struct {
 }
 
 func NewManager(config *types.AuthConfig) *Manager {
-	apiKeys := make(map[string]*types.Tenant)
+	m := &Manager{
+		config:      config,
+		nonces:      newNonceCache(),
+		jwtKey:      []byte(config.JWTSecret),
+		keyPatterns: &keyPatterns{pattern: config.KeyPattern},
+	}
+	if config.TokenCacheSize > 0 {
+		m.tokens = newTokenCache(config.TokenCacheSize)
+	}
+	if lockout := config.Lockout; lockout.MaxFailures > 0 {
+		m.lockouts = newFailureLimiter(lockout.MaxFailures, lockout.Window, lockout.Duration)
+	}
+	if config.Anonymous.Enabled {
+		m.anonymous = newAnonymousTenant(config.Anonymous)
+	}
+	m.keys.Store(newKeySet(config.APIKeys, m.keyPatterns))
+	return m
+}
+
+// SetAPIKeys replaces the API keys, e.g. after a secret store rotated
+// them. Requests already authenticated keep their tenant.
+func (m *Manager) SetAPIKeys(keys []types.APIKey) {
+	m.keys.Store(newKeySet(keys, m.keyPatterns))
+}
+
+func newKeySet(keys []types.APIKey, defaultPatterns *keyPatterns) *keySet {
+	apiKeys := make(map[[sha256.Size]byte]*types.Tenant)
+	signingKeys := make(map[string]signingKey)
+	tenants := make(map[string]tenantSettings)
 	
 	// Build API key lookup map
-	for _, key := range config.APIKeys {
-		apiKeys[key.Key] = &types.Tenant{
-			ID:          key.TenantID,
-			RateLimit:   key.RateLimit,
-			AllowedDBs:  key.AllowedDBs,
-			Permissions: key.Permissions,
+	for _, key := range keys {
+		// CIDRs are checked by config validation; an unparsable entry here
+		// leaves the key unusable rather than unrestricted
+		allowedNets, err := ParseCIDRs(key.AllowedCIDRs)
+		if err != nil {
+			continue
+		}
+		keyPattern := defaultPatterns.get(key.TenantID)
+		if key.KeyPattern != "" {
+			if keyPattern, err = CompileKeyPattern(key.KeyPattern, key.TenantID); err != nil {
+				continue
+			}
+		}
+		
+		tenant := &types.Tenant{
+			ID:             key.TenantID,
+			RateLimit:      key.RateLimit,
+			AllowedDBs:     key.AllowedDBs,
+			Permissions:    key.Permissions,
+			Admin:          key.Admin,
+			AllowedOrigins: key.AllowedOrigins,
+			AllowedNets:    allowedNets,
+			RedisUsername:  key.RedisUsername,
+			RedisPassword:  key.RedisPassword,
+			Tier:           key.Tier,
+			MaxValueBytes:  key.MaxValueBytes,
+			MaxKeyBytes:    key.MaxKeyBytes,
+			KeyPattern:     keyPattern,
+			
+			AllowedDatabases: key.AllowedDatabases,
+			DefaultTTL:       key.DefaultTTL,
+			MaxTTL:           key.MaxTTL,
+		}
+		
+		tenants[key.TenantID] = tenantSettings{
+			redisUsername: key.RedisUsername,
+			redisPassword: key.RedisPassword,
+			tier:          key.Tier,
+			maxValueBytes: key.MaxValueBytes,
+			maxKeyBytes:   key.MaxKeyBytes,
+			keyPattern:    keyPattern,
+			databases:     key.AllowedDatabases,
+			defaultTTL:    key.DefaultTTL,
+			maxTTL:        key.MaxTTL,
+		}
+		
+		// A key may be usable as a bearer key, for request signing, or both
+		if key.Key != "" {
+			apiKeys[sha256.Sum256([]byte(key.Key))] = tenant
+		}
+		if key.KeyID != "" && key.SigningSecret != "" {
+			signingKeys[key.KeyID] = signingKey{secret: []byte(key.SigningSecret), tenant: tenant}
 		}
 	}
 	
-	return &Manager{
-		config:  config,
-		apiKeys: apiKeys,
-		jwtKey:  []byte(config.JWTSecret),
+	return &keySet{
+		apiKeys:     apiKeys,
+		signingKeys: signingKeys,
+		tenants:     tenants,
 	}
 }
 
+// apiKey returns the tenant of an API key.
+func (k *keySet) apiKey(key string) (*types.Tenant, bool) {
+	tenant, exists := k.apiKeys[sha256.Sum256([]byte(key))]
+	return tenant, exists
+}
+
+// ValidateRequest authenticates r and returns its tenant, or the anonymous
+// tenant for requests without credentials if enabled. With lockouts
+// enabled, a client failing to authenticate too often is refused with a
+// LockedOutError for a while, so keys can't be guessed.
 func (m *Manager) ValidateRequest(r *http.Request) (*types.Tenant, error) {
 	if !m.config.Enabled {
 		// Return default tenant when auth is disabled
@@ -56,31 +180,165 @@ func (m *Manager) ValidateRequest(r *http.Request) (*types.Tenant, error) {
 			RateLimit:   1000,
 			AllowedDBs:  []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
 			Permissions: []string{"*"},
+			Admin:       true,
+			
+			AllowedDatabases: []string{"*"},
 		}, nil
 	}
 	
 	auth := r.Header.Get("Authorization")
 	if auth == "" {
+		if m.anonymous != nil {
+			if err := m.checkRestrictions(r, m.anonymous); err != nil {
+				return nil, err
+			}
+			return m.anonymous, nil
+		}
 		return nil, errors.New("missing authorization header")
 	}
 	
-	if strings.HasPrefix(auth, "Bearer ") {
-		return m.validateJWT(auth[7:])
+	if m.lockouts == nil {
+		return m.validateCredentials(r, auth)
 	}
 	
-	if strings.HasPrefix(auth, "Basic ") {
-		return m.validateBasicAuth(auth[6:])
+	client := m.lockoutKey(r)
+	now := time.Now()
+	if until := m.lockouts.lockedUntil(client, now); !until.IsZero() {
+		m.observe(AuthEventBlocked, client)
+		return nil, &LockedOutError{Until: until}
 	}
 	
-	// Try direct API key
-	if tenant, exists := m.apiKeys[auth]; exists {
+	tenant, err := m.validateCredentials(r, auth)
+	
+	// Valid credentials used from the wrong place, and revocation checks
+	// Redis couldn't answer, aren't guesses
+	if err != nil && !errors.Is(err, ErrRestricted) && !errors.Is(err, errRevocationUnavailable) {
+		m.observe(AuthEventFailed, client)
+		if m.lockouts.fail(client, now) {
+			m.observe(AuthEventLockout, client)
+		}
+	}
+	return tenant, err
+}
+
+// SetLockoutObserver registers fn to be called for failed authentications
+// and lockouts.
+func (m *Manager) SetLockoutObserver(fn LockoutObserver) {
+	m.observer = fn
+}
+
+func (m *Manager) observe(event, client string) {
+	if m.observer != nil {
+		m.observer(event, client)
+	}
+}
+
+func (m *Manager) validateCredentials(r *http.Request, auth string) (*types.Tenant, error) {
+	// Direct API keys are the most common, and cheapest, credentials
+	if tenant, exists := m.keys.Load().apiKey(auth); exists {
+		if err := m.checkRestrictions(r, tenant); err != nil {
+			return nil, err
+		}
 		return tenant, nil
 	}
 	
-	return nil, errors.New("invalid authentication method")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return m.validateJWT(r.Context(), auth[7:])
+	}
+	
+	var tenant *types.Tenant
+	if strings.HasPrefix(auth, SigningScheme+" ") {
+		var err error
+		if tenant, err = m.validateSignature(r, auth[len(SigningScheme)+1:]); err != nil {
+			return nil, err
+		}
+	} else if strings.HasPrefix(auth, "Basic ") {
+		var err error
+		if tenant, err = m.validateBasicAuth(auth[6:]); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("invalid authentication method")
+	}
+	
+	if err := m.checkRestrictions(r, tenant); err != nil {
+		return nil, err
+	}
+	
+	return tenant, nil
+}
+
+// SetRevocationList enables revocation checks on every JWT.
+func (m *Manager) SetRevocationList(revocations *RevocationList) {
+	m.revocations = revocations
+}
+
+// Revocations returns the revocation list, or nil if revocation is disabled.
+func (m *Manager) Revocations() *RevocationList {
+	return m.revocations
+}
+
+func (m *Manager) validateJWT(ctx context.Context, tokenString string) (*types.Tenant, error) {
+	claims, err := m.parseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Check token expiration
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
+		return nil, errors.New("JWT token expired")
+	}
+	
+	if m.revocations != nil {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		
+		revoked, err := m.revocations.IsRevoked(ctx, claims.ID, claims.TenantID, issuedAt)
+		if err != nil {
+			// Fail closed: a revoked token must not slip through while the
+			// revocation list is unreachable
+			return nil, fmt.Errorf("%w: %w", errRevocationUnavailable, err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	
+	settings, ok := m.keys.Load().tenants[claims.TenantID]
+	if !ok {
+		settings.keyPattern = m.keyPatterns.get(claims.TenantID)
+	}
+	return &types.Tenant{
+		ID:            claims.TenantID,
+		RateLimit:     claims.RateLimit,
+		AllowedDBs:    claims.AllowedDBs,
+		Permissions:   claims.Permissions,
+		RedisUsername: settings.redisUsername,
+		RedisPassword: settings.redisPassword,
+		Tier:          settings.tier,
+		MaxValueBytes: settings.maxValueBytes,
+		MaxKeyBytes:   settings.maxKeyBytes,
+		KeyPattern:    settings.keyPattern,
+		
+		AllowedDatabases: settings.databases,
+		DefaultTTL:       settings.defaultTTL,
+		MaxTTL:           settings.maxTTL,
+	}, nil
 }
 
-func (m *Manager) validateJWT(tokenString string) (*types.Tenant, error) {
+// parseJWT verifies a token's signature and returns its claims, from the
+// token cache when the token was verified before.
+func (m *Manager) parseJWT(tokenString string) (*JWTClaims, error) {
+	var hash [sha256.Size]byte
+	if m.tokens != nil {
+		hash = sha256.Sum256([]byte(tokenString))
+		if claims, ok := m.tokens.get(hash, time.Now()); ok {
+			return claims, nil
+		}
+	}
+	
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -97,17 +355,10 @@ func (m *Manager) validateJWT(tokenString string) (*types.Tenant, error) {
 		return nil, errors.New("invalid JWT claims")
 	}
 	
-	// Check token expiration
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, errors.New("JWT token expired")
+	if m.tokens != nil {
+		m.tokens.add(hash, claims, time.Now())
 	}
-	
-	return &types.Tenant{
-		ID:          claims.TenantID,
-		RateLimit:   claims.RateLimit,
-		AllowedDBs:  claims.AllowedDBs,
-		Permissions: claims.Permissions,
-	}, nil
+	return claims, nil
 }
 
 func (m *Manager) validateBasicAuth(encoded string) (*types.Tenant, error) {
@@ -124,7 +375,7 @@ func (m *Manager) validateBasicAuth(encoded string) (*types.Tenant, error) {
 	username, password := parts[0], parts[1]
 	
 	// For basic auth, we use the username as tenant ID and password as API key
-	if tenant, exists := m.apiKeys[password]; exists {
+	if tenant, exists := m.keys.Load().apiKey(password); exists {
 		// Verify the username matches tenant ID for additional security
 		if subtle.ConstantTimeCompare([]byte(username), []byte(tenant.ID)) == 1 {
 			return tenant, nil
@@ -167,7 +418,25 @@ func (m *Manager) ValidateDatabase(tenant *types.Tenant, db int) error {
 	return fmt.Errorf("database %d not allowed for tenant '%s'", db, tenant.ID)
 }
 
+// ValidateNamedDatabase checks that tenant may use the named database.
+func (m *Manager) ValidateNamedDatabase(tenant *types.Tenant, name string) error {
+	for _, allowed := range tenant.AllowedDatabases {
+		if allowed == "*" || allowed == name {
+			return nil
+		}
+	}
+	
+	return fmt.Errorf("database %q not allowed for tenant '%s'", name, tenant.ID)
+}
+
 func (m *Manager) GenerateJWT(tenantID string, rateLimit int, allowedDBs []int, permissions []string, duration time.Duration) (string, error) {
+	token, _, err := m.IssueJWT(tenantID, rateLimit, allowedDBs, permissions, duration)
+	return token, err
+}
+
+// IssueJWT is GenerateJWT that also returns the claims, including the
+// token ID (jti) needed to revoke it.
+func (m *Manager) IssueJWT(tenantID string, rateLimit int, allowedDBs []int, permissions []string, duration time.Duration) (string, *JWTClaims, error) {
 	claims := &JWTClaims{
 		TenantID:    tenantID,
 		RateLimit:   rateLimit,
@@ -178,17 +447,37 @@ func (m *Manager) GenerateJWT(tenantID string, rateLimit int, allowedDBs []int,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "serverless-redis",
+			ID:        newTokenID(),
 		},
 	}
 	
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.jwtKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.jwtKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 // Middleware for HTTP authentication
 func (m *Manager) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tenant, err := m.ValidateRequest(r)
+		if errors.Is(err, ErrRestricted) {
+			http.Error(w, fmt.Sprintf(`{"error": "Forbidden", "details": "%s"}`, err.Error()), http.StatusForbidden)
+			return
+		}
+		var locked *LockedOutError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(time.Until(locked.Until).Seconds())))))
+			http.Error(w, fmt.Sprintf(`{"error": "Too many requests", "details": "%s"}`, err.Error()), http.StatusTooManyRequests)
+			return
+		}
 		if err != nil {
 			http.Error(w, fmt.Sprintf(`{"error": "Authentication failed", "details": "%s"}`, err.Error()), http.StatusUnauthorized)
 			return
@@ -203,6 +492,20 @@ func (m *Manager) AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminMiddleware authenticates the request like AuthMiddleware and then
+// requires the tenant to be using an admin API key.
+func (m *Manager) AdminMiddleware(next http.Handler) http.Handler {
+	return m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := GetTenantFromContext(r.Context())
+		if !ok || !tenant.Admin {
+			http.Error(w, `{"error": "Forbidden", "details": "admin API key required"}`, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
 // Context helpers
 type contextKey string
 
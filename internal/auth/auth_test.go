@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -32,11 +33,11 @@ func TestNewManager(t *testing.T) {
 		t.Error("Expected config to be set")
 	}
 
-	if len(manager.apiKeys) != 1 {
-		t.Errorf("Expected 1 API key, got %d", len(manager.apiKeys))
+	if len(manager.keys.Load().apiKeys) != 1 {
+		t.Errorf("Expected 1 API key, got %d", len(manager.keys.Load().apiKeys))
 	}
 
-	tenant, exists := manager.apiKeys["test-key"]
+	tenant, exists := manager.keys.Load().apiKey("test-key")
 	if !exists {
 		t.Error("Expected API key to exist")
 	}
@@ -46,6 +47,31 @@ func TestNewManager(t *testing.T) {
 	}
 }
 
+func TestSetAPIKeys(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		APIKeys:   []types.APIKey{{Key: "old-key", TenantID: "tenant1", Permissions: []string{"*"}}},
+	})
+
+	manager.SetAPIKeys([]types.APIKey{{Key: "new-key", TenantID: "tenant1", Permissions: []string{"*"}}})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "old-key")
+	if _, err := manager.ValidateRequest(req); err == nil {
+		t.Error("Expected the replaced key to be rejected")
+	}
+
+	req.Header.Set("Authorization", "new-key")
+	tenant, err := manager.ValidateRequest(req)
+	if err != nil {
+		t.Fatalf("Expected the new key to be accepted, got %v", err)
+	}
+	if tenant.ID != "tenant1" {
+		t.Errorf("Expected tenant1, got %s", tenant.ID)
+	}
+}
+
 func TestValidateRequestWithAPIKey(t *testing.T) {
 	config := &types.AuthConfig{
 		Enabled:   true,
@@ -178,6 +204,37 @@ func TestValidateRequestWithJWT(t *testing.T) {
 	}
 }
 
+func TestRedisUserForJWT(t *testing.T) {
+	config := &types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		APIKeys: []types.APIKey{
+			{Key: "key1", TenantID: "tenant1", RedisUsername: "tenant1", RedisPassword: "pw", Tier: "pro"},
+		},
+	}
+
+	manager := NewManager(config)
+
+	token, err := manager.GenerateJWT("tenant1", 1000, []int{0}, []string{"GET"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	tenant, err := manager.ValidateRequest(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tenant.RedisUsername != "tenant1" || tenant.RedisPassword != "pw" {
+		t.Errorf("Expected JWT tenant to use the API key's Redis user, got %q", tenant.RedisUsername)
+	}
+	if tenant.Tier != "pro" {
+		t.Errorf("Expected JWT tenant to use the API key's tier, got %q", tenant.Tier)
+	}
+}
+
 func TestValidateCommand(t *testing.T) {
 	tenant := &types.Tenant{
 		ID:          "tenant1",
@@ -302,6 +359,47 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAdminMiddleware(t *testing.T) {
+	config := &types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		APIKeys: []types.APIKey{
+			{Key: "tenant-key", TenantID: "tenant1", Permissions: []string{"*"}},
+			{Key: "admin-key", TenantID: "ops", Permissions: []string{"*"}, Admin: true},
+		},
+	}
+
+	manager := NewManager(config)
+
+	handler := manager.AdminMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		auth     string
+		expected int
+	}{
+		{"Admin key", "admin-key", http.StatusOK},
+		{"Tenant key", "tenant-key", http.StatusForbidden},
+		{"Invalid key", "invalid-key", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/tokens", nil)
+			req.Header.Set("Authorization", tt.auth)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
 func TestAuthDisabled(t *testing.T) {
 	config := &types.AuthConfig{
 		Enabled: false,
@@ -349,4 +447,42 @@ func TestTenantContext(t *testing.T) {
 	if ok {
 		t.Error("Expected no tenant in empty context")
 	}
-}
\ No newline at end of file
+}
+func BenchmarkValidateRequest(b *testing.B) {
+	keys := make([]types.APIKey, 1000)
+	for i := range keys {
+		keys[i] = types.APIKey{Key: fmt.Sprintf("key-%d", i), TenantID: fmt.Sprintf("tenant%d", i), Permissions: []string{"*"}}
+	}
+
+	cases := []struct {
+		name      string
+		cacheSize int
+		jwt       bool
+	}{
+		{"api_key", 0, false},
+		{"jwt", 0, true},
+		{"jwt_cached", 10000, true},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			manager := NewManager(&types.AuthConfig{Enabled: true, JWTSecret: "test-secret", APIKeys: keys, TokenCacheSize: tc.cacheSize})
+			req := httptest.NewRequest("POST", "/v1/command", nil)
+			req.Header.Set("Authorization", "key-500")
+			if tc.jwt {
+				token, err := manager.GenerateJWT("tenant1", 1000, []int{0}, []string{"*"}, time.Hour)
+				if err != nil {
+					b.Fatalf("Failed to generate JWT: %v", err)
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := manager.ValidateRequest(req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
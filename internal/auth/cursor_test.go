@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{JWTSecret: "test-secret"})
+
+	token := manager.SignCursor("tenant1", "scan", "1234", time.Minute)
+
+	cursor, err := manager.VerifyCursor(token, "tenant1", "scan")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cursor != "1234" {
+		t.Errorf("Expected cursor 1234, got %s", cursor)
+	}
+}
+
+func TestCursorRejections(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{JWTSecret: "test-secret"})
+	other := NewManager(&types.AuthConfig{JWTSecret: "other-secret"})
+
+	token := manager.SignCursor("tenant1", "scan", "1234", time.Minute)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name     string
+		verify   func() error
+		expected error
+	}{
+		{"Other tenant", func() error {
+			_, err := manager.VerifyCursor(token, "tenant2", "scan")
+			return err
+		}, ErrInvalidCursor},
+		{"Other kind", func() error {
+			_, err := manager.VerifyCursor(token, "tenant1", "export")
+			return err
+		}, ErrInvalidCursor},
+		{"Other secret", func() error {
+			_, err := other.VerifyCursor(token, "tenant1", "scan")
+			return err
+		}, ErrInvalidCursor},
+		{"Tampered payload", func() error {
+			forged := manager.SignCursor("tenant1", "scan", "9999", time.Minute)
+			forgedPayload, _, _ := strings.Cut(forged, ".")
+			_, err := manager.VerifyCursor(forgedPayload+"."+signature, "tenant1", "scan")
+			return err
+		}, ErrInvalidCursor},
+		{"Missing signature", func() error {
+			_, err := manager.VerifyCursor(payload, "tenant1", "scan")
+			return err
+		}, ErrInvalidCursor},
+		{"Expired", func() error {
+			expired := manager.SignCursor("tenant1", "scan", "1234", -time.Minute)
+			_, err := manager.VerifyCursor(expired, "tenant1", "scan")
+			return err
+		}, ErrCursorExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verify(); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CompileKeyPattern compiles a key naming policy for a tenant. "{tenant}"
+// in pattern stands for the tenant's ID, so one pattern such as
+// "^{tenant}:" can serve every tenant. An empty pattern allows any key.
+func CompileKeyPattern(pattern, tenantID string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(strings.ReplaceAll(pattern, "{tenant}", regexp.QuoteMeta(tenantID)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key_pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// keyPatterns caches auth.key_pattern compiled per tenant, for tenants
+// whose API keys don't set their own.
+type keyPatterns struct {
+	pattern  string
+	byTenant sync.Map // tenant ID -> *regexp.Regexp
+}
+
+func (k *keyPatterns) get(tenantID string) *regexp.Regexp {
+	if k.pattern == "" {
+		return nil
+	}
+	if re, ok := k.byTenant.Load(tenantID); ok {
+		return re.(*regexp.Regexp)
+	}
+	// Config validation compiled the pattern already; with the tenant's
+	// ID quoted it still compiles
+	re, _ := CompileKeyPattern(k.pattern, tenantID)
+	k.byTenant.Store(tenantID, re)
+	return re
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestCompileKeyPattern(t *testing.T) {
+	re, err := CompileKeyPattern("^{tenant}:", "acme.io")
+	if err != nil {
+		t.Fatalf("Failed to compile pattern: %v", err)
+	}
+
+	// The tenant ID is matched literally, dots included
+	for key, want := range map[string]bool{
+		"acme.io:users:1": true,
+		"acmexio:users:1": false,
+		"other:acme.io:1": false,
+	} {
+		if got := re.MatchString(key); got != want {
+			t.Errorf("MatchString(%q) = %v, want %v", key, got, want)
+		}
+	}
+
+	if re, err := CompileKeyPattern("", "acme"); re != nil || err != nil {
+		t.Errorf("Expected no pattern for an empty one, got %v, %v", re, err)
+	}
+	if _, err := CompileKeyPattern("^{tenant}:(", "acme"); err == nil {
+		t.Error("Expected an invalid pattern to fail")
+	}
+}
+
+func TestTenantKeyPatterns(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{
+		Enabled:    true,
+		JWTSecret:  "test-secret",
+		KeyPattern: "^{tenant}:",
+		APIKeys: []types.APIKey{
+			{Key: "default-key", TenantID: "acme", Permissions: []string{"*"}},
+			{Key: "own-key", TenantID: "shared", Permissions: []string{"*"}, KeyPattern: "^(shared|public):"},
+		},
+	})
+
+	token, err := manager.GenerateJWT("jwt-only", 1000, []int{0}, []string{"*"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		allowed       string
+		denied        string
+	}{
+		{"API key using the default", "default-key", "acme:1", "shared:1"},
+		{"API key with its own pattern", "own-key", "public:1", "acme:1"},
+		{"JWT of a tenant without API keys", "Bearer " + token, "jwt-only:1", "acme:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			tenant, err := manager.ValidateRequest(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tenant.KeyPattern == nil {
+				t.Fatal("Expected a key pattern")
+			}
+			if !tenant.KeyPattern.MatchString(tt.allowed) {
+				t.Errorf("Expected %q to be allowed by %s", tt.allowed, tenant.KeyPattern)
+			}
+			if tenant.KeyPattern.MatchString(tt.denied) {
+				t.Errorf("Expected %q to be denied by %s", tt.denied, tenant.KeyPattern)
+			}
+		})
+	}
+}
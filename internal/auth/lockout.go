@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxTrackedClients bounds the clients failed attempts are remembered for;
+// past it, arbitrary clients that aren't locked out are forgotten.
+const maxTrackedClients = 100000
+
+// ErrLockedOut is wrapped by the error for requests from a client locked
+// out after too many failed authentication attempts.
+var ErrLockedOut = errors.New("too many failed authentication attempts")
+
+// Lockout events, as passed to a LockoutObserver
+const (
+	AuthEventFailed  = "failed"
+	AuthEventLockout = "lockout"
+	AuthEventBlocked = "blocked"
+)
+
+// LockoutObserver is called for every failed authentication from ip, when
+// ip is locked out, and for every request refused while it is.
+type LockoutObserver func(event, ip string)
+
+// LockedOutError is returned for requests from a locked out client.
+type LockedOutError struct {
+	Until time.Time
+}
+
+func (e *LockedOutError) Error() string {
+	return ErrLockedOut.Error()
+}
+
+func (e *LockedOutError) Unwrap() error {
+	return ErrLockedOut
+}
+
+// lockoutKey returns the client failed attempts are counted against: its
+// address, or the peer's when that isn't known, as for a forwarded address
+// that doesn't parse or a Unix socket peer, so those don't all share one
+// record.
+func (m *Manager) lockoutKey(r *http.Request) string {
+	if ip := m.clientIP(r); ip != nil {
+		return ip.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// failureLimiter locks clients out for a while once they fail to
+// authenticate maxFailures times within a window. Like the nonce cache it
+// is per instance.
+type failureLimiter struct {
+	maxFailures int
+	window      time.Duration
+	duration    time.Duration
+
+	mutex     sync.Mutex
+	clients   map[string]*failureRecord
+	lastSweep time.Time
+}
+
+type failureRecord struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+func newFailureLimiter(maxFailures int, window, duration time.Duration) *failureLimiter {
+	return &failureLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		duration:    duration,
+		clients:     make(map[string]*failureRecord),
+		lastSweep:   time.Now(),
+	}
+}
+
+// lockedUntil returns when the lockout of client ends, or the zero time if
+// it isn't locked out.
+func (l *failureLimiter) lockedUntil(client string, now time.Time) time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if record, ok := l.clients[client]; ok && now.Before(record.lockedUntil) {
+		return record.lockedUntil
+	}
+	return time.Time{}
+}
+
+// fail counts a failed attempt by client and reports whether it locked the
+// client out.
+func (l *failureLimiter) fail(client string, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now)
+	record, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= maxTrackedClients && !l.evict(now) {
+			// Every client tracked is locked out; forgetting one would
+			// let a flood of new clients lift its lockout
+			return false
+		}
+		record = &failureRecord{}
+		l.clients[client] = record
+	}
+	if now.Sub(record.windowStart) > l.window {
+		record.failures = 0
+		record.windowStart = now
+	}
+
+	record.failures++
+	if record.failures < l.maxFailures || now.Before(record.lockedUntil) {
+		return false
+	}
+	record.failures = 0
+	record.lockedUntil = now.Add(l.duration)
+	return true
+}
+
+// evict forgets an arbitrary client that isn't locked out, reporting
+// whether there was one.
+func (l *failureLimiter) evict(now time.Time) bool {
+	for client, record := range l.clients {
+		if !now.Before(record.lockedUntil) {
+			delete(l.clients, client)
+			return true
+		}
+	}
+	return false
+}
+
+// sweep forgets clients with neither recent failures nor a lockout, at
+// most once a minute.
+func (l *failureLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	for client, record := range l.clients {
+		if now.Sub(record.windowStart) > l.window && !now.Before(record.lockedUntil) {
+			delete(l.clients, client)
+		}
+	}
+	l.lastSweep = now
+}
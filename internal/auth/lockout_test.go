@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestLockout(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		APIKeys: []types.APIKey{
+			{Key: "valid-key", TenantID: "tenant1", Permissions: []string{"*"}},
+			{Key: "office-key", TenantID: "tenant2", Permissions: []string{"*"}, AllowedCIDRs: []string{"10.0.0.0/8"}},
+		},
+		Lockout: types.LockoutConfig{MaxFailures: 3, Window: time.Minute, Duration: time.Minute},
+	})
+	var events []string
+	manager.SetLockoutObserver(func(event, ip string) {
+		events = append(events, event+" "+ip)
+	})
+	validate := func(addr, auth string) error {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.RemoteAddr = addr + ":1234"
+		req.Header.Set("Authorization", auth)
+		_, err := manager.ValidateRequest(req)
+		return err
+	}
+
+	// Valid keys used from the wrong network aren't guesses
+	for i := 0; i < 5; i++ {
+		if err := validate("192.0.2.1", "office-key"); !errors.Is(err, ErrRestricted) {
+			t.Fatalf("Expected ErrRestricted, got %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := validate("192.0.2.1", "guess"); err == nil || errors.Is(err, ErrLockedOut) {
+			t.Fatalf("Expected an invalid key error before the lockout, got %v", err)
+		}
+	}
+	var locked *LockedOutError
+	if err := validate("192.0.2.1", "valid-key"); !errors.As(err, &locked) || time.Until(locked.Until) <= 0 {
+		t.Errorf("Expected the client to be locked out even with a valid key, got %v", err)
+	}
+	if err := validate("192.0.2.2", "valid-key"); err != nil {
+		t.Errorf("Expected other clients not to be locked out, got %v", err)
+	}
+
+	expected := []string{"failed 192.0.2.1", "failed 192.0.2.1", "failed 192.0.2.1", "lockout 192.0.2.1", "blocked 192.0.2.1"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected events %v, got %v", expected, events)
+			break
+		}
+	}
+}
+
+func TestFailureLimiter(t *testing.T) {
+	limiter := newFailureLimiter(2, time.Minute, 5*time.Minute)
+	now := time.Now()
+
+	// Failures in separate windows don't add up
+	if limiter.fail("a", now) || limiter.fail("a", now.Add(2*time.Minute)) {
+		t.Fatal("Expected no lockout for failures in separate windows")
+	}
+	if !limiter.fail("a", now.Add(2*time.Minute+time.Second)) {
+		t.Fatal("Expected a lockout after 2 failures in a window")
+	}
+
+	lockedAt := now.Add(2*time.Minute + time.Second)
+	if until := limiter.lockedUntil("a", lockedAt.Add(time.Minute)); !until.Equal(lockedAt.Add(5 * time.Minute)) {
+		t.Errorf("Expected a lockout until %v, got %v", lockedAt.Add(5*time.Minute), until)
+	}
+	if until := limiter.lockedUntil("a", lockedAt.Add(5*time.Minute)); !until.IsZero() {
+		t.Errorf("Expected the lockout to end, got %v", until)
+	}
+
+	// Clients with nothing left to remember are swept
+	limiter.fail("b", lockedAt.Add(10*time.Minute))
+	if _, ok := limiter.clients["a"]; ok {
+		t.Error("Expected the expired client to be forgotten")
+	}
+}
+
+func TestFailureLimiterKeepsLockouts(t *testing.T) {
+	limiter := newFailureLimiter(1, time.Minute, 5*time.Minute)
+	now := time.Now()
+	limiter.fail("locked", now)
+	for i := 0; i < maxTrackedClients; i++ {
+		limiter.fail(strconv.Itoa(i), now)
+	}
+
+	// A flood of new clients doesn't make room by lifting a lockout
+	if until := limiter.lockedUntil("locked", now); until.IsZero() {
+		t.Error("Expected the lockout to survive the flood")
+	}
+	if len(limiter.clients) > maxTrackedClients {
+		t.Errorf("Expected at most %d clients tracked, got %d", maxTrackedClients, len(limiter.clients))
+	}
+}
+
+func TestLockoutKey(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{
+		Enabled:           true,
+		JWTSecret:         "test-secret",
+		TrustForwardedFor: true,
+		Lockout:           types.LockoutConfig{MaxFailures: 1, Window: time.Minute, Duration: time.Minute},
+	})
+	validate := func(addr, forwarded string) error {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.RemoteAddr = addr + ":1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		req.Header.Set("Authorization", "guess")
+		_, err := manager.ValidateRequest(req)
+		return err
+	}
+
+	// Forwarded values that aren't addresses are counted against the peer
+	// rather than one record shared by all of them
+	if err := validate("192.0.2.1", "unknown"); errors.Is(err, ErrLockedOut) {
+		t.Fatalf("Expected an invalid key error, got %v", err)
+	}
+	if err := validate("192.0.2.2", "unknown"); errors.Is(err, ErrLockedOut) {
+		t.Errorf("Expected other peers not to be locked out, got %v", err)
+	}
+	if err := validate("192.0.2.1", "unknown"); !errors.Is(err, ErrLockedOut) {
+		t.Errorf("Expected the peer to be locked out, got %v", err)
+	}
+}
+
+func TestAuthMiddlewareLockout(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		Lockout:   types.LockoutConfig{MaxFailures: 1, Window: time.Minute, Duration: time.Minute},
+	})
+	handler := manager.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 2)
+	for i := range codes {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.Header.Set("Authorization", "guess")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes[i] = w.Code
+		if i == 1 && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("Expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+	if codes[0] != http.StatusUnauthorized || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected 401 and then 429, got %v", codes)
+	}
+}
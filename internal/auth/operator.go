@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// OperatorMiddleware guards the metrics path, /debug and /admin with
+// config: the first two need its credentials, if any, and all three a
+// client address within its networks, if any. Other paths pass through.
+// It goes before any caching, so cached metrics aren't served around it.
+func (m *Manager) OperatorMiddleware(config types.OperatorAccessConfig, metricsPath string) (func(http.Handler) http.Handler, error) {
+	nets, err := ParseCIDRs(config.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	credentials := config.BearerToken != "" || config.Username != ""
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			admin := path == "/admin" || strings.HasPrefix(path, "/admin/")
+			guarded := path == metricsPath || path == "/debug" || strings.HasPrefix(path, "/debug/")
+			if !admin && !guarded {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(nets) > 0 {
+				if ip := m.clientIP(r); ip == nil || !ipAllowed(nets, ip) {
+					http.Error(w, fmt.Sprintf(`{"error": "Forbidden", "details": "client address %v not allowed"}`, ip), http.StatusForbidden)
+					return
+				}
+			}
+			if guarded && credentials && !operatorCredentials(config, r) {
+				if config.Username != "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="serverless-redis"`)
+				} else {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				http.Error(w, `{"error": "Authentication failed", "details": "operator credentials required"}`, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// operatorCredentials reports whether r carries the bearer token or the
+// username and password of config.
+func operatorCredentials(config types.OperatorAccessConfig, r *http.Request) bool {
+	if config.BearerToken != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(config.BearerToken)) == 1 {
+			return true
+		}
+	}
+	if config.Username != "" {
+		username, password, ok := r.BasicAuth()
+		if ok && subtle.ConstantTimeCompare([]byte(username), []byte(config.Username))&
+			subtle.ConstantTimeCompare([]byte(password), []byte(config.Password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestOperatorMiddleware(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{Enabled: true, JWTSecret: "test-secret"})
+	middleware, err := manager.OperatorMiddleware(types.OperatorAccessConfig{
+		BearerToken:  "scrape-secret",
+		Username:     "prometheus",
+		Password:     "hunter2",
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+	}, "/metrics")
+	if err != nil {
+		t.Fatalf("OperatorMiddleware() error = %v", err)
+	}
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		setup      func(r *http.Request)
+		want       int
+	}{
+		{"Metrics with the bearer token", "/metrics", "10.1.2.3:5000", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer scrape-secret")
+		}, http.StatusOK},
+		{"Metrics with basic auth", "/metrics", "10.1.2.3:5000", func(r *http.Request) {
+			r.SetBasicAuth("prometheus", "hunter2")
+		}, http.StatusOK},
+		{"Metrics with a wrong password", "/metrics", "10.1.2.3:5000", func(r *http.Request) {
+			r.SetBasicAuth("prometheus", "wrong")
+		}, http.StatusUnauthorized},
+		{"Profiles without credentials", "/debug/pprof/heap", "10.1.2.3:5000", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"Metrics from outside the allowlist", "/metrics", "192.0.2.1:5000", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer scrape-secret")
+		}, http.StatusForbidden},
+		{"Admin from outside the allowlist", "/admin/slowlog", "192.0.2.1:5000", func(r *http.Request) {}, http.StatusForbidden},
+		{"Admin keeps its own credentials", "/admin/slowlog", "10.1.2.3:5000", func(r *http.Request) {
+			r.Header.Set("Authorization", "admin-api-key")
+		}, http.StatusOK},
+		{"API routes pass through", "/v1/command", "192.0.2.1:5000", func(r *http.Request) {}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			tt.setup(req)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
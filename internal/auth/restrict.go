@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrRestricted is wrapped by errors for requests whose credentials are
+// valid but used from an origin or address the key is not allowed from.
+var ErrRestricted = errors.New("request not allowed for this key")
+
+// ParseCIDRs parses allowed_cidrs entries. A bare IP address is treated as
+// a single-host network.
+func ParseCIDRs(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// checkRestrictions enforces the tenant's allowed origins and networks.
+// When origins are configured the Origin header is required, so a leaked
+// browser key can't simply be replayed from a server.
+func (m *Manager) checkRestrictions(r *http.Request, tenant *types.Tenant) error {
+	if len(tenant.AllowedOrigins) > 0 {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return fmt.Errorf("%w: missing Origin header", ErrRestricted)
+		}
+		if !originAllowed(tenant.AllowedOrigins, origin) {
+			return fmt.Errorf("%w: origin %s", ErrRestricted, origin)
+		}
+	}
+
+	if len(tenant.AllowedNets) > 0 {
+		ip := m.clientIP(r)
+		if ip == nil {
+			return fmt.Errorf("%w: unknown client address", ErrRestricted)
+		}
+		if !ipAllowed(tenant.AllowedNets, ip) {
+			return fmt.Errorf("%w: client address %s", ErrRestricted, ip)
+		}
+	}
+
+	return nil
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+
+		// "https://*.example.com" matches any subdomain but not the apex
+		if scheme, host, ok := strings.Cut(pattern, "://*."); ok {
+			prefix := scheme + "://"
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ipAllowed(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address checked against allowed_cidrs: the last
+// X-Forwarded-For entry when configured to trust it, else the peer address.
+// The load balancer appends the peer it saw to whatever the client sent, so
+// only the last entry can't be forged.
+func (m *Manager) clientIP(r *http.Request) net.IP {
+	if m.config.TrustForwardedFor {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			last := forwarded[strings.LastIndex(forwarded, ",")+1:]
+			return net.ParseIP(strings.TrimSpace(last))
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
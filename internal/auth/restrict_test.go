@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func restrictedManager(trustForwardedFor bool) *Manager {
+	return NewManager(&types.AuthConfig{
+		Enabled:           true,
+		JWTSecret:         "test-secret",
+		TrustForwardedFor: trustForwardedFor,
+		APIKeys: []types.APIKey{
+			{
+				Key:            "browser-key",
+				TenantID:       "tenant1",
+				AllowedDBs:     []int{0},
+				Permissions:    []string{"*"},
+				AllowedOrigins: []string{"https://app.example.com", "https://*.preview.example.com"},
+			},
+			{
+				Key:          "server-key",
+				TenantID:     "tenant2",
+				AllowedDBs:   []int{0},
+				Permissions:  []string{"*"},
+				AllowedCIDRs: []string{"10.0.0.0/8", "192.0.2.7"},
+			},
+		},
+	})
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	manager := restrictedManager(false)
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"exact match", "https://app.example.com", true},
+		{"case insensitive", "HTTPS://APP.EXAMPLE.COM", true},
+		{"wildcard subdomain", "https://pr-42.preview.example.com", true},
+		{"wildcard does not match apex", "https://preview.example.com", false},
+		{"wrong scheme", "http://app.example.com", false},
+		{"other origin", "https://evil.example.net", false},
+		{"missing origin", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/command", nil)
+			req.Header.Set("Authorization", "browser-key")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			_, err := manager.ValidateRequest(req)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected origin %q to be allowed, got %v", tt.origin, err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrRestricted) {
+				t.Errorf("Expected ErrRestricted for origin %q, got %v", tt.origin, err)
+			}
+		})
+	}
+}
+
+func TestAllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		name              string
+		remoteAddr        string
+		forwardedFor      string
+		trustForwardedFor bool
+		allowed           bool
+	}{
+		{"inside range", "10.1.2.3:5000", "", false, true},
+		{"single host", "192.0.2.7:5000", "", false, true},
+		{"outside range", "203.0.113.9:5000", "", false, false},
+		{"forwarded ignored by default", "203.0.113.9:5000", "10.1.2.3", false, false},
+		{"forwarded trusted", "203.0.113.9:5000", "198.51.100.4, 10.1.2.3", true, true},
+		{"forwarded outside range", "10.1.2.3:5000", "203.0.113.9", true, false},
+		{"spoofed leading entry", "10.9.9.9:5000", "10.1.2.3, 203.0.113.9", true, false},
+		{"forwarded not an address", "10.1.2.3:5000", "10.1.2.3, unknown", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := restrictedManager(tt.trustForwardedFor)
+
+			req := httptest.NewRequest("POST", "/v1/command", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "server-key")
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			_, err := manager.ValidateRequest(req)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected request to be allowed, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrRestricted) {
+				t.Errorf("Expected ErrRestricted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRestrictedKey(t *testing.T) {
+	manager := restrictedManager(false)
+	handler := manager.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	req.Header.Set("Authorization", "browser-key")
+	req.Header.Set("Origin", "https://evil.example.net")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for disallowed origin, got %d", w.Code)
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1", "::1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(nets) != 4 {
+		t.Errorf("Expected 4 networks, got %d", len(nets))
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseCIDRs([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
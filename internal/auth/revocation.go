@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrTokenRevoked is returned for JWTs revoked before their expiry.
+var ErrTokenRevoked = errors.New("JWT token revoked")
+
+// errRevocationUnavailable is wrapped by the error for JWTs whose
+// revocation couldn't be checked.
+var errRevocationUnavailable = errors.New("unable to check token revocation")
+
+// maxRevocationCacheEntries bounds the local lookup cache; it is simply
+// reset when full.
+const maxRevocationCacheEntries = 10000
+
+// CommandExecutor runs a single Redis command. The proxy's Redis client
+// satisfies it.
+type CommandExecutor interface {
+	ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error)
+}
+
+// RevocationList stores revoked token IDs and per-tenant revocation times
+// in Redis, so that revocations reach every proxy instance. Lookups are
+// cached locally for CacheTTL, which bounds how long a revoked token may
+// still be accepted by other instances.
+type RevocationList struct {
+	executor CommandExecutor
+	config   types.RevocationConfig
+
+	mutex sync.Mutex
+	cache map[string]cachedRevocation
+}
+
+type cachedRevocation struct {
+	value   int64 // 0 when the key does not exist
+	expires time.Time
+}
+
+// NewRevocationList creates a revocation list backed by executor.
+func NewRevocationList(executor CommandExecutor, config types.RevocationConfig) *RevocationList {
+	return &RevocationList{
+		executor: executor,
+		config:   config,
+		cache:    make(map[string]cachedRevocation),
+	}
+}
+
+// RevokeToken revokes the token with ID jti. The entry is kept until
+// expiresAt, or for the configured retention if the expiry is unknown.
+func (l *RevocationList) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := []interface{}{l.tokenKey(jti), time.Now().Unix()}
+	if expiresAt.IsZero() {
+		args = append(args, "EX", int64(l.config.Retention.Seconds()))
+	} else {
+		args = append(args, "EXAT", expiresAt.Unix())
+	}
+
+	if _, err := l.executor.ExecuteCommand(ctx, types.CommandRequest{Command: "SET", Args: args, DB: l.config.DB}); err != nil {
+		return err
+	}
+
+	l.forget(l.tokenKey(jti))
+	return nil
+}
+
+// RevokeTenant revokes every token issued to tenantID up to now. Tokens
+// issued afterwards are unaffected.
+func (l *RevocationList) RevokeTenant(ctx context.Context, tenantID string, now time.Time) error {
+	args := []interface{}{l.tenantKey(tenantID), now.Unix()}
+	if _, err := l.executor.ExecuteCommand(ctx, types.CommandRequest{Command: "SET", Args: args, DB: l.config.DB}); err != nil {
+		return err
+	}
+
+	l.forget(l.tenantKey(tenantID))
+	return nil
+}
+
+// IsRevoked reports whether a token, identified by its ID, tenant and
+// issue time, has been revoked.
+func (l *RevocationList) IsRevoked(ctx context.Context, jti, tenantID string, issuedAt time.Time) (bool, error) {
+	keys := []string{l.tenantKey(tenantID)}
+	if jti != "" {
+		keys = append(keys, l.tokenKey(jti))
+	}
+
+	values, err := l.lookup(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+
+	// Issue times have second precision, so a token issued in the same
+	// second as a tenant revocation is treated as revoked
+	if revokedAt := values[0]; revokedAt != 0 && !issuedAt.After(time.Unix(revokedAt, 0)) {
+		return true, nil
+	}
+	if jti != "" && values[1] != 0 {
+		return true, nil
+	}
+	return false, nil
+}
+
+// lookup returns the value of each key, from the cache where possible and
+// with a single MGET for the rest.
+func (l *RevocationList) lookup(ctx context.Context, keys []string) ([]int64, error) {
+	values := make([]int64, len(keys))
+	var missing []int
+
+	now := time.Now()
+	l.mutex.Lock()
+	for i, key := range keys {
+		if cached, ok := l.cache[key]; ok && now.Before(cached.expires) {
+			values[i] = cached.value
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	l.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	args := make([]interface{}, len(missing))
+	for i, idx := range missing {
+		args[i] = keys[idx]
+	}
+
+	result, err := l.executor.ExecuteCommand(ctx, types.CommandRequest{Command: "MGET", Args: args, DB: l.config.DB})
+	if err != nil {
+		return nil, err
+	}
+	replies, ok := result.([]interface{})
+	if !ok || len(replies) != len(missing) {
+		return nil, fmt.Errorf("unexpected MGET reply %T", result)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.cache) >= maxRevocationCacheEntries {
+		l.cache = make(map[string]cachedRevocation)
+	}
+	for i, idx := range missing {
+		if str, ok := replies[i].(string); ok {
+			values[idx], _ = strconv.ParseInt(str, 10, 64)
+		}
+		l.cache[keys[idx]] = cachedRevocation{value: values[idx], expires: now.Add(l.config.CacheTTL)}
+	}
+
+	return values, nil
+}
+
+func (l *RevocationList) forget(key string) {
+	l.mutex.Lock()
+	delete(l.cache, key)
+	l.mutex.Unlock()
+}
+
+func (l *RevocationList) tokenKey(jti string) string {
+	return l.config.KeyPrefix + "jti:" + jti
+}
+
+func (l *RevocationList) tenantKey(tenantID string) string {
+	return l.config.KeyPrefix + "tenant:" + tenantID
+}
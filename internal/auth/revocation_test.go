@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeRedis implements just enough of SET and MGET for the revocation list.
+type fakeRedis struct {
+	mutex sync.Mutex
+	data  map[string]string
+	calls int
+	err   error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string]string)}
+}
+
+func (f *fakeRedis) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	switch req.Command {
+	case "SET":
+		f.data[req.Args[0].(string)] = fmt.Sprint(req.Args[1])
+		return "OK", nil
+	case "MGET":
+		values := make([]interface{}, len(req.Args))
+		for i, key := range req.Args {
+			if value, ok := f.data[key.(string)]; ok {
+				values[i] = value
+			}
+		}
+		return values, nil
+	}
+	return nil, errors.New("unsupported command")
+}
+
+func revocationManager(store *fakeRedis) *Manager {
+	manager := NewManager(&types.AuthConfig{Enabled: true, JWTSecret: "test-secret"})
+	manager.SetRevocationList(NewRevocationList(store, types.RevocationConfig{
+		Enabled:   true,
+		DB:        15,
+		KeyPrefix: "sr:revoked:",
+		CacheTTL:  time.Minute,
+		Retention: time.Hour,
+	}))
+	return manager
+}
+
+func TestRevokeTokenByID(t *testing.T) {
+	manager := revocationManager(newFakeRedis())
+
+	token, claims, err := manager.IssueJWT("tenant1", 100, []int{0}, []string{"*"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatal("Expected issued token to have a jti")
+	}
+
+	other, _, _ := manager.IssueJWT("tenant1", 100, []int{0}, []string{"*"}, time.Hour)
+
+	validate := func(token string) error {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, err := manager.ValidateRequest(req)
+		return err
+	}
+
+	if err := validate(token); err != nil {
+		t.Fatalf("Expected token to be valid before revocation, got %v", err)
+	}
+
+	if err := manager.Revocations().RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+
+	// The local cache entry is dropped on revoke, so this instance sees it
+	// immediately
+	if err := validate(token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected ErrTokenRevoked, got %v", err)
+	}
+	if err := validate(other); err != nil {
+		t.Errorf("Expected other token to remain valid, got %v", err)
+	}
+}
+
+func TestRevokeTenant(t *testing.T) {
+	store := newFakeRedis()
+	list := NewRevocationList(store, types.RevocationConfig{KeyPrefix: "sr:revoked:", CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := list.RevokeTenant(ctx, "tenant1", now); err != nil {
+		t.Fatalf("Failed to revoke tenant: %v", err)
+	}
+
+	revoked, err := list.IsRevoked(ctx, "a", "tenant1", now.Add(-time.Hour))
+	if err != nil || !revoked {
+		t.Errorf("Expected token issued before revocation to be revoked, got %v, %v", revoked, err)
+	}
+
+	revoked, _ = list.IsRevoked(ctx, "b", "tenant1", now.Add(2*time.Second))
+	if revoked {
+		t.Error("Expected token issued after revocation to be valid")
+	}
+
+	revoked, _ = list.IsRevoked(ctx, "c", "tenant2", now.Add(-time.Hour))
+	if revoked {
+		t.Error("Expected other tenants to be unaffected")
+	}
+}
+
+func TestRevocationCache(t *testing.T) {
+	store := newFakeRedis()
+	list := NewRevocationList(store, types.RevocationConfig{KeyPrefix: "sr:revoked:", CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := list.IsRevoked(ctx, "jti", "tenant1", time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if store.calls != 1 {
+		t.Errorf("Expected one Redis lookup with caching, got %d", store.calls)
+	}
+}
+
+func TestRevocationFailsClosed(t *testing.T) {
+	store := newFakeRedis()
+	manager := revocationManager(store)
+	token, _ := manager.GenerateJWT("tenant1", 100, []int{0}, []string{"*"}, time.Hour)
+
+	store.err = errors.New("connection refused")
+
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if _, err := manager.ValidateRequest(req); err == nil {
+		t.Error("Expected validation to fail while the revocation list is unreachable")
+	}
+}
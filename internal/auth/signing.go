@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Request signing headers. The Authorization header carries the key ID and
+// signature; the timestamp and nonce are part of the signed string.
+const (
+	SigningScheme      = "SR-HMAC-SHA256"
+	SigningDateHeader  = "X-SR-Date"
+	SigningNonceHeader = "X-SR-Nonce"
+
+	// DefaultMaxClockSkew is how far X-SR-Date may drift from server time.
+	DefaultMaxClockSkew = 5 * time.Minute
+
+	maxSignedBodySize = 16 << 20
+	minNonceLength    = 16
+	maxNonceLength    = 128
+)
+
+var (
+	ErrSignatureMismatch = errors.New("request signature does not match")
+	ErrClockSkew         = errors.New("request timestamp outside allowed clock skew")
+	ErrNonceReused       = errors.New("request nonce already used")
+)
+
+type signingKey struct {
+	secret []byte
+	tenant *types.Tenant
+}
+
+// StringToSign builds the canonical string covered by a request signature:
+// the scheme, timestamp, nonce, method, path, sorted query string and the
+// hex SHA-256 of the body, separated by newlines.
+func StringToSign(method, path, rawQuery string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		SigningScheme,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(rawQuery),
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// Sign returns the hex HMAC-SHA256 of stringToSign under secret.
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest signs req in place, setting the date, nonce and Authorization
+// headers. The body is read and replaced so the request can still be sent.
+func SignRequest(req *http.Request, keyID, secret, nonce string, now time.Time) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	timestamp := now.Unix()
+	signature := Sign(secret, StringToSign(req.Method, req.URL.Path, req.URL.RawQuery, timestamp, nonce, body))
+
+	req.Header.Set(SigningDateHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SigningNonceHeader, nonce)
+	req.Header.Set("Authorization", fmt.Sprintf("%s KeyId=%s, Signature=%s", SigningScheme, keyID, signature))
+	return nil
+}
+
+// SetAuthorization sets req's Authorization header to token, sending a JWT
+// as a bearer token and an API key as it is.
+func SetAuthorization(req *http.Request, token string) {
+	if strings.Count(token, ".") == 2 {
+		token = "Bearer " + token
+	}
+	req.Header.Set("Authorization", token)
+}
+
+// validateSignature authenticates a signed request. params is the part of
+// the Authorization header after the scheme.
+func (m *Manager) validateSignature(r *http.Request, params string) (*types.Tenant, error) {
+	keyID, signature, err := parseSigningParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	key, exists := m.keys.Load().signingKeys[keyID]
+	if !exists {
+		return nil, errors.New("unknown signing key")
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(SigningDateHeader), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("missing or invalid %s header", SigningDateHeader)
+	}
+
+	skew := m.maxClockSkew()
+	if drift := time.Since(time.Unix(timestamp, 0)); drift > skew || drift < -skew {
+		return nil, ErrClockSkew
+	}
+
+	nonce := r.Header.Get(SigningNonceHeader)
+	if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return nil, fmt.Errorf("%s header must be %d-%d characters", SigningNonceHeader, minNonceLength, maxNonceLength)
+	}
+
+	// Read the body for hashing and put it back for the handler
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+		if len(body) > maxSignedBodySize {
+			return nil, errors.New("signed request body too large")
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := Sign(string(key.secret), StringToSign(r.Method, r.URL.Path, r.URL.RawQuery, timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, ErrSignatureMismatch
+	}
+
+	// Only remember nonces of correctly signed requests, so unsigned
+	// garbage can't fill the cache or burn a client's nonce
+	if !m.nonces.add(keyID+":"+nonce, 2*skew) {
+		return nil, ErrNonceReused
+	}
+
+	return key.tenant, nil
+}
+
+func (m *Manager) maxClockSkew() time.Duration {
+	if m.config.MaxClockSkew > 0 {
+		return m.config.MaxClockSkew
+	}
+	return DefaultMaxClockSkew
+}
+
+// parseSigningParams parses "KeyId=<id>, Signature=<hex>".
+func parseSigningParams(params string) (string, string, error) {
+	var keyID, signature string
+	for _, part := range strings.Split(params, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "KeyId":
+			keyID = value
+		case "Signature":
+			signature = value
+		}
+	}
+
+	if keyID == "" || signature == "" {
+		return "", "", errors.New("signed Authorization header requires KeyId and Signature")
+	}
+	return keyID, signature, nil
+}
+
+// canonicalQuery sorts query parameters so clients and server agree on the
+// signed form regardless of parameter order.
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	pairs := strings.Split(rawQuery, "&")
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// nonceCache remembers recently used nonces until they could no longer pass
+// the clock skew check. It is per instance; replicas behind a load
+// balancer each keep their own cache.
+type nonceCache struct {
+	mutex     sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// add records nonce and reports whether it was unused.
+func (c *nonceCache) add(nonce string, ttl time.Duration) bool {
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if now.Sub(c.lastSweep) > time.Minute {
+		for key, expires := range c.seen {
+			if now.After(expires) {
+				delete(c.seen, key)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expires, exists := c.seen[nonce]; exists && now.Before(expires) {
+		return false
+	}
+	c.seen[nonce] = now.Add(ttl)
+	return true
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func signingManager() *Manager {
+	return NewManager(&types.AuthConfig{
+		Enabled:   true,
+		JWTSecret: "test-secret",
+		APIKeys: []types.APIKey{
+			{
+				TenantID:      "tenant1",
+				AllowedDBs:    []int{0},
+				Permissions:   []string{"*"},
+				KeyID:         "ak_test",
+				SigningSecret: "signing-secret",
+			},
+		},
+	})
+}
+
+func TestSignedRequest(t *testing.T) {
+	manager := signingManager()
+
+	body := `{"command":"GET","args":["k"]}`
+	req := httptest.NewRequest("POST", "/v1/command?b=2&a=1", strings.NewReader(body))
+	if err := SignRequest(req, "ak_test", "signing-secret", "nonce-0123456789abcdef", time.Now()); err != nil {
+		t.Fatalf("Failed to sign request: %v", err)
+	}
+
+	tenant, err := manager.ValidateRequest(req)
+	if err != nil {
+		t.Fatalf("Expected signed request to validate, got %v", err)
+	}
+	if tenant.ID != "tenant1" {
+		t.Errorf("Expected tenant1, got %s", tenant.ID)
+	}
+
+	// The handler must still be able to read the body
+	read, _ := io.ReadAll(req.Body)
+	if string(read) != body {
+		t.Errorf("Expected body to be preserved, got %q", read)
+	}
+}
+
+func TestSignedRequestRejections(t *testing.T) {
+	manager := signingManager()
+
+	newSigned := func(t *testing.T, body, nonce string, now time.Time) *http.Request {
+		req := httptest.NewRequest("POST", "/v1/command", strings.NewReader(body))
+		if err := SignRequest(req, "ak_test", "signing-secret", nonce, now); err != nil {
+			t.Fatalf("Failed to sign request: %v", err)
+		}
+		return req
+	}
+
+	t.Run("tampered body", func(t *testing.T) {
+		req := newSigned(t, `{"command":"GET"}`, "nonce-tampered-body-1", time.Now())
+		req.Body = io.NopCloser(strings.NewReader(`{"command":"FLUSHALL"}`))
+		if _, err := manager.ValidateRequest(req); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("Expected ErrSignatureMismatch, got %v", err)
+		}
+	})
+
+	t.Run("tampered path", func(t *testing.T) {
+		req := newSigned(t, `{}`, "nonce-tampered-path-1", time.Now())
+		req.URL.Path = "/v1/pipeline"
+		if _, err := manager.ValidateRequest(req); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("Expected ErrSignatureMismatch, got %v", err)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/command", strings.NewReader(`{}`))
+		SignRequest(req, "ak_test", "other-secret", "nonce-wrong-secret-1", time.Now())
+		if _, err := manager.ValidateRequest(req); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("Expected ErrSignatureMismatch, got %v", err)
+		}
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		req := newSigned(t, `{}`, "nonce-clock-skew-01", time.Now().Add(-10*time.Minute))
+		if _, err := manager.ValidateRequest(req); !errors.Is(err, ErrClockSkew) {
+			t.Errorf("Expected ErrClockSkew, got %v", err)
+		}
+	})
+
+	t.Run("replayed nonce", func(t *testing.T) {
+		if _, err := manager.ValidateRequest(newSigned(t, `{}`, "nonce-replayed-0001", time.Now())); err != nil {
+			t.Fatalf("Expected first request to validate, got %v", err)
+		}
+
+		if _, err := manager.ValidateRequest(newSigned(t, `{}`, "nonce-replayed-0001", time.Now())); !errors.Is(err, ErrNonceReused) {
+			t.Errorf("Expected ErrNonceReused, got %v", err)
+		}
+	})
+
+	t.Run("short nonce", func(t *testing.T) {
+		if _, err := manager.ValidateRequest(newSigned(t, `{}`, "short", time.Now())); err == nil {
+			t.Error("Expected error for short nonce")
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		SignRequest(req, "ak_unknown", "signing-secret", "nonce-unknown-key-1", time.Now())
+		if _, err := manager.ValidateRequest(req); err == nil {
+			t.Error("Expected error for unknown key")
+		}
+	})
+}
+
+func TestStringToSignCanonicalQuery(t *testing.T) {
+	a := StringToSign("post", "/v1/scan", "b=2&a=1", 1700000000, "nonce", nil)
+	b := StringToSign("POST", "/v1/scan", "a=1&b=2", 1700000000, "nonce", nil)
+	if a != b {
+		t.Errorf("Expected query order and method case not to affect the signed string:\n%s\n%s", a, b)
+	}
+
+	if !strings.HasPrefix(a, SigningScheme+"\n"+strconv.Itoa(1700000000)+"\nnonce\nPOST\n/v1/scan\na=1&b=2\n") {
+		t.Errorf("Unexpected string to sign: %q", a)
+	}
+}
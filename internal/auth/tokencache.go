@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// tokenCache remembers the claims of JWTs whose signature has been
+// verified, by token hash, so a token isn't parsed and verified again on
+// every request. Entries last until the token expires; when the cache is
+// full, expired entries are swept at most once a minute, and otherwise an
+// arbitrary entry is evicted. Revocation is still checked on every request.
+type tokenCache struct {
+	mutex     sync.RWMutex
+	entries   map[[sha256.Size]byte]*JWTClaims
+	size      int
+	lastSweep time.Time
+}
+
+func newTokenCache(size int) *tokenCache {
+	return &tokenCache{entries: make(map[[sha256.Size]byte]*JWTClaims), size: size, lastSweep: time.Now()}
+}
+
+// get returns the claims of a verified token that hasn't expired by now.
+func (c *tokenCache) get(hash [sha256.Size]byte, now time.Time) (*JWTClaims, bool) {
+	c.mutex.RLock()
+	claims, ok := c.entries[hash]
+	c.mutex.RUnlock()
+	if !ok || expired(claims, now) {
+		return nil, false
+	}
+	return claims, true
+}
+
+// add remembers the claims of a verified token.
+func (c *tokenCache) add(hash [sha256.Size]byte, claims *JWTClaims, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.entries) >= c.size && now.Sub(c.lastSweep) > time.Minute {
+		for key, cached := range c.entries {
+			if expired(cached, now) {
+				delete(c.entries, key)
+			}
+		}
+		c.lastSweep = now
+	}
+	if len(c.entries) >= c.size {
+		for key := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, key)
+		}
+	}
+	c.entries[hash] = claims
+}
+
+func expired(claims *JWTClaims, now time.Time) bool {
+	return claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Time)
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTokenCache(t *testing.T) {
+	manager := NewManager(&types.AuthConfig{Enabled: true, JWTSecret: "test-secret", TokenCacheSize: 2})
+	validate := func(token string) error {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, err := manager.ValidateRequest(req)
+		return err
+	}
+
+	token, err := manager.GenerateJWT("tenant1", 100, []int{0}, []string{"*"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to generate JWT: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := validate(token); err != nil {
+			t.Fatalf("Expected token to be valid, got %v", err)
+		}
+	}
+	claims, ok := manager.tokens.get(sha256.Sum256([]byte(token)), time.Now())
+	if !ok || claims.TenantID != "tenant1" {
+		t.Fatalf("Expected the verified token to be cached, got %v", claims)
+	}
+	if _, ok := manager.tokens.get(sha256.Sum256([]byte(token)), time.Now().Add(2*time.Hour)); ok {
+		t.Error("Expected the cached token to expire with the token")
+	}
+
+	// A token with a forged signature must not hit the cache
+	if err := validate(token[:len(token)-2] + "xx"); err == nil {
+		t.Error("Expected a forged token to be rejected")
+	}
+
+	for i := 0; i < 3; i++ {
+		other, _ := manager.GenerateJWT("tenant2", 100, []int{0}, []string{"*"}, time.Duration(i+1)*time.Hour)
+		if err := validate(other); err != nil {
+			t.Fatalf("Expected token to be valid, got %v", err)
+		}
+	}
+	if len(manager.tokens.entries) != 2 {
+		t.Errorf("Expected the cache bounded to 2 tokens, got %d", len(manager.tokens.entries))
+	}
+}
+
+func TestTokenCacheExpiredToken(t *testing.T) {
+	cache := newTokenCache(10)
+	now := time.Now()
+	claims := &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))}}
+	cache.add([sha256.Size]byte{1}, claims, now)
+
+	if _, ok := cache.get([sha256.Size]byte{1}, now); !ok {
+		t.Error("Expected the token to be cached")
+	}
+	if _, ok := cache.get([sha256.Size]byte{1}, now.Add(time.Minute)); ok {
+		t.Error("Expected no token once it expired")
+	}
+}
+
+func TestTokenCacheRevocation(t *testing.T) {
+	manager := revocationManager(newFakeRedis())
+	manager.tokens = newTokenCache(10)
+
+	token, claims, err := manager.IssueJWT("tenant1", 100, []int{0}, []string{"*"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	validate := func() error {
+		req := httptest.NewRequest("POST", "/v1/command", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, err := manager.ValidateRequest(req)
+		return err
+	}
+
+	if err := validate(); err != nil {
+		t.Fatalf("Expected token to be valid before revocation, got %v", err)
+	}
+	if err := manager.Revocations().RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+	if err := validate(); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected a cached token to be revoked, got %v", err)
+	}
+}
@@ -1,24 +1,71 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"gopkg.in/yaml.v3"
+	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/secrets"
+	"github.com/scaler/serverless-redis/internal/server"
 	"github.com/scaler/serverless-redis/internal/types"
 )
 
 // LoadConfig loads configuration from file or environment variables
 func LoadConfig() (*types.Config, error) {
-	config := &types.Config{}
-	
-	// Try to load from config file first
+	return LoadConfigFile(Path())
+}
+
+// Path is the config file LoadConfig reads: $CONFIG_PATH or config.yaml,
+// or "" if that does not exist.
+func Path() string {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 		configPath = "config.yaml"
 	}
 	
-	if _, err := os.Stat(configPath); err == nil {
-		data, err := os.ReadFile(configPath)
+	if _, err := os.Stat(configPath); err != nil {
+		return ""
+	}
+	return configPath
+}
+
+// LoadConfigFile loads configuration from the file at path, which must
+// exist unless path is empty, and from environment variables.
+func LoadConfigFile(path string) (*types.Config, error) {
+	return NewLoader(path).Load()
+}
+
+// Loader loads configuration like LoadConfigFile, including the document
+// in etcd or Consul that remote points to, and waits for that document to
+// change.
+type Loader struct {
+	path    string
+	remote  RemoteSource
+	version uint64
+}
+
+// NewLoader returns a loader for the config file at path.
+func NewLoader(path string) *Loader {
+	return &Loader{path: path}
+}
+
+// Load loads the configuration as it is now.
+func (l *Loader) Load() (*types.Config, error) {
+	config := &types.Config{}
+	path := l.path
+	
+	if path != "" {
+		data, err := os.ReadFile(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
@@ -28,8 +75,17 @@ func LoadConfig() (*types.Config, error) {
 		}
 	}
 	
-	// Override with environment variables
-	overrideWithEnv(config)
+	if err := overrideWithAllEnv(config); err != nil {
+		return nil, err
+	}
+	
+	// A document shared through etcd or Consul goes over the file, with the
+	// environment again taking precedence
+	if config.Remote.Provider != "" {
+		if err := l.loadRemote(config); err != nil {
+			return nil, err
+		}
+	}
 	
 	// Set defaults
 	setDefaults(config)
@@ -39,9 +95,192 @@ func LoadConfig() (*types.Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 	
+	// A secret store has the last word on the secrets it holds
+	if config.Secrets.Provider != "" {
+		store, err := secrets.NewStore(config.Secrets)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: secrets: %w", err)
+		}
+		
+		ctx, cancel := context.WithTimeout(context.Background(), config.Secrets.Timeout)
+		defer cancel()
+		fetched, err := store.Fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+		}
+		
+		if config, err = ApplySecrets(config, fetched); err != nil {
+			return nil, err
+		}
+	}
+	
 	return config, nil
 }
 
+// Watch blocks until the remote document changes or ctx is done; the next
+// Load picks up the change.
+func (l *Loader) Watch(ctx context.Context) error {
+	if l.remote == nil {
+		return errors.New("no remote config to watch")
+	}
+	
+	version, err := l.remote.Watch(ctx, l.version)
+	if err != nil {
+		return err
+	}
+	l.version = version
+	return nil
+}
+
+// loadRemote decodes the remote document over config, keeping the remote
+// settings that pointed to it.
+func (l *Loader) loadRemote(config *types.Config) error {
+	remote := config.Remote
+	if remote.Timeout == 0 {
+		remote.Timeout = defaultRemoteTimeout
+	}
+	
+	if l.remote == nil {
+		source, err := NewRemoteSource(remote)
+		if err != nil {
+			return fmt.Errorf("invalid configuration: remote: %w", err)
+		}
+		l.remote = source
+	}
+	
+	document, version, err := l.remote.Get(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load remote config: %w", err)
+	}
+	if err := yaml.Unmarshal(document, config); err != nil {
+		return fmt.Errorf("failed to parse remote config: %w", err)
+	}
+	config.Remote = remote
+	l.version = version
+	
+	return overrideWithAllEnv(config)
+}
+
+// overrideWithAllEnv applies environment variables, SR_ ones last as the
+// most specific.
+func overrideWithAllEnv(config *types.Config) error {
+	overrideWithEnv(config)
+	if err := overrideWithEnvFiles(config); err != nil {
+		return fmt.Errorf("invalid environment variable %w", err)
+	}
+	if err := overrideWithPrefixedEnv(config); err != nil {
+		return fmt.Errorf("invalid environment variable %w", err)
+	}
+	return nil
+}
+
+// validateListeners checks server.listeners and server.admin.
+func validateListeners(server types.ServerConfig) error {
+	listeners := server.Listeners
+	if server.Admin.Address != "" {
+		if server.Admin.Routes != "" && server.Admin.Routes != types.ListenerRoutesAdmin {
+			return fmt.Errorf("server admin listener must serve the admin routes, got %q", server.Admin.Routes)
+		}
+		admin := server.Admin
+		admin.Name = "admin"
+		admin.Routes = types.ListenerRoutesAdmin
+		listeners = append(listeners[:len(listeners):len(listeners)], admin)
+	}
+	
+	adminRoutes := false
+	addresses := make(map[string]bool)
+	for i, listener := range listeners {
+		name := listener.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		
+		switch listener.Network {
+		case "", types.ListenerTCP:
+			if _, _, err := net.SplitHostPort(listener.Address); err != nil {
+				return fmt.Errorf("server listener %s: address: %w", name, err)
+			}
+		case types.ListenerUnix:
+			if listener.Address == "" {
+				return fmt.Errorf("server listener %s: address must be a socket path", name)
+			}
+			if listener.SocketMode != "" {
+				if _, err := strconv.ParseUint(listener.SocketMode, 8, 32); err != nil {
+					return fmt.Errorf("server listener %s: socket_mode must be octal, e.g. \"0660\"", name)
+				}
+			}
+		default:
+			return fmt.Errorf("server listener %s: network must be tcp or unix, got %q", name, listener.Network)
+		}
+		
+		if addresses[listener.Address] {
+			return fmt.Errorf("server listener %s: address %s is used twice", name, listener.Address)
+		}
+		addresses[listener.Address] = true
+		
+		switch listener.Routes {
+		case types.ListenerRoutesAdmin:
+			adminRoutes = true
+		case "", types.ListenerRoutesAPI:
+		default:
+			return fmt.Errorf("server listener %s: routes must be api or admin, got %q", name, listener.Routes)
+		}
+		
+		if err := validateTLS(listener.TLS); err != nil {
+			return fmt.Errorf("server listener %s: tls: %w", name, err)
+		}
+	}
+	
+	if server.Pprof && !adminRoutes {
+		return fmt.Errorf("server pprof needs server.admin or a listener with routes admin")
+	}
+	return nil
+}
+
+// validateTLS checks that enabled TLS has a certificate and key.
+func validateTLS(tls types.TLSConfig) error {
+	if tls.Enabled && (tls.CertFile == "" || tls.KeyFile == "") {
+		return errors.New("cert_file and key_file are required")
+	}
+	return nil
+}
+
+// ApplySecrets returns a copy of config with the values fetched from its
+// secret store in place, checked like the rest of the configuration.
+func ApplySecrets(config *types.Config, fetched types.Secrets) (*types.Config, error) {
+	updated := *config
+	if fetched.RedisPassword != "" {
+		updated.Redis.Primary.Password = fetched.RedisPassword
+	}
+	if fetched.DragonflyPassword != "" {
+		updated.Redis.Dragonfly.Password = fetched.DragonflyPassword
+	}
+	if fetched.APIKeys != nil {
+		updated.Auth.APIKeys = fetched.APIKeys
+	}
+	
+	if err := validateConfig(&updated); err != nil {
+		return nil, fmt.Errorf("invalid secrets: %w", err)
+	}
+	return &updated, nil
+}
+
+// CheckUnknownFields reports keys in the config file at path that match no
+// setting, usually typos that LoadConfigFile silently ignores.
+func CheckUnknownFields(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&types.Config{}); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
 func overrideWithEnv(config *types.Config) {
 	if port := os.Getenv("PORT"); port != "" {
 		_, _ = fmt.Sscanf(port, "%d", &config.Server.Port)
@@ -55,6 +294,10 @@ func overrideWithEnv(config *types.Config) {
 		config.Redis.Primary.Addr = redisURL
 	}
 	
+	if redisMode := os.Getenv("REDIS_MODE"); redisMode != "" {
+		config.Redis.Mode = redisMode
+	}
+	
 	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
 		config.Redis.Primary.Password = redisPassword
 	}
@@ -63,6 +306,27 @@ func overrideWithEnv(config *types.Config) {
 		config.Auth.JWTSecret = jwtSecret
 	}
 	
+	// Standard OpenTelemetry variables, so the proxy picks up a collector
+	// injected by the serverless platform
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		config.Observability.OTLP.Enabled = true
+		config.Observability.OTLP.Endpoint = endpoint
+	}
+	
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.Observability.OTLP.ServiceName = serviceName
+	}
+	
+	// Sentry's standard variables
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		config.Observability.Errors.Enabled = true
+		config.Observability.Errors.SentryDSN = dsn
+	}
+	
+	if environment := os.Getenv("SENTRY_ENVIRONMENT"); environment != "" {
+		config.Observability.Errors.Environment = environment
+	}
+	
 	if dragonflyURL := os.Getenv("DRAGONFLY_URL"); dragonflyURL != "" {
 		config.Redis.Dragonfly.Enabled = true
 		config.Redis.Dragonfly.Addr = dragonflyURL
@@ -82,6 +346,225 @@ func setDefaults(config *types.Config) {
 		config.Redis.Primary.Addr = "localhost:6379"
 	}
 	
+	if config.Redis.Databases == 0 {
+		config.Redis.Databases = 16
+	}
+	
+	if config.Redis.ClientName == "" {
+		config.Redis.ClientName = "serverless-redis"
+	}
+	
+	if config.Redis.Instance == "" {
+		config.Redis.Instance, _ = os.Hostname()
+	}
+	
+	if config.Server.MaxPipelineCommands == 0 {
+		config.Server.MaxPipelineCommands = 1000
+	}
+	
+	if config.Scripts.CheckInterval == 0 {
+		config.Scripts.CheckInterval = 30 * time.Second
+	}
+	
+	if config.Server.MaxRequestTimeout == 0 {
+		config.Server.MaxRequestTimeout = 30 * time.Second
+	}
+	
+	if config.Server.LargeValues.Threshold == 0 {
+		config.Server.LargeValues.Threshold = 1 << 20
+	}
+	
+	if config.Server.LargeValues.ChunkSize == 0 {
+		config.Server.LargeValues.ChunkSize = 256 << 10
+	}
+	
+	if config.Server.LargeValues.MaxSize == 0 {
+		// Redis's own limit on a string
+		config.Server.LargeValues.MaxSize = 512 << 20
+	}
+	
+	if config.Server.Concurrency.InitialLimit == 0 {
+		config.Server.Concurrency.InitialLimit = 100
+	}
+	
+	if config.Server.Concurrency.MinLimit == 0 {
+		config.Server.Concurrency.MinLimit = 10
+	}
+	
+	if config.Server.Concurrency.MaxLimit == 0 {
+		config.Server.Concurrency.MaxLimit = 1000
+	}
+	
+	if config.Server.Concurrency.SampleWindow == 0 {
+		config.Server.Concurrency.SampleWindow = 50
+	}
+	
+	if config.Server.Concurrency.Tolerance == 0 {
+		config.Server.Concurrency.Tolerance = 2
+	}
+	
+	if config.Server.Concurrency.Backoff == 0 {
+		config.Server.Concurrency.Backoff = 0.9
+	}
+	
+	if config.Server.Concurrency.QueueTimeout == 0 {
+		config.Server.Concurrency.QueueTimeout = 100 * time.Millisecond
+	}
+	
+	if config.Server.Concurrency.DefaultTier == "" && len(config.Server.Concurrency.Tiers) > 0 {
+		config.Server.Concurrency.DefaultTier = config.Server.Concurrency.Tiers[0].Name
+	}
+	
+	if config.BigValues.WarnBytes == 0 {
+		config.BigValues.WarnBytes = 1 << 20
+	}
+	
+	if config.BigValues.ReportSize == 0 {
+		config.BigValues.ReportSize = 100
+	}
+	
+	if config.SlowLog.RequestThreshold == 0 {
+		config.SlowLog.RequestThreshold = time.Second
+	}
+	
+	if config.SlowLog.CommandThreshold == 0 {
+		config.SlowLog.CommandThreshold = 100 * time.Millisecond
+	}
+	
+	if config.SlowLog.Size == 0 {
+		config.SlowLog.Size = 128
+	}
+	
+	if config.RateLimit.DefaultCost == 0 {
+		config.RateLimit.DefaultCost = 1
+	}
+	
+	if config.RateLimit.Shared.KeyPrefix == "" {
+		config.RateLimit.Shared.KeyPrefix = "sr:ratelimit:"
+	}
+	
+	if config.RateLimit.Shared.SyncInterval == 0 {
+		config.RateLimit.Shared.SyncInterval = 100 * time.Millisecond
+	}
+	
+	if config.Recording.SampleRate == 0 {
+		config.Recording.SampleRate = 1
+	}
+	
+	if config.Recording.MaxBodyBytes == 0 {
+		config.Recording.MaxBodyBytes = 64 * 1024
+	}
+	
+	if config.Recording.MaxLen == 0 {
+		config.Recording.MaxLen = 100000
+	}
+	
+	if config.OpLog.MaxEntries == 0 {
+		config.OpLog.MaxEntries = 10000
+	}
+	
+	if config.OpLog.MaxBytes == 0 {
+		config.OpLog.MaxBytes = 64 * 1024 * 1024
+	}
+	
+	if config.OpLog.RetryInterval == 0 {
+		config.OpLog.RetryInterval = time.Second
+	}
+	
+	for i := range config.Faults.Rules {
+		rule := &config.Faults.Rules[i]
+		if rule.LatencyRate == 0 {
+			rule.LatencyRate = 1
+		}
+		if rule.Error == "" {
+			rule.Error = "ERR injected fault"
+		}
+	}
+	
+	if config.Delay.KeyPrefix == "" {
+		config.Delay.KeyPrefix = "sr:delay:"
+	}
+	
+	if config.Delay.PollInterval == 0 {
+		config.Delay.PollInterval = time.Second
+	}
+	
+	if config.Delay.BatchSize == 0 {
+		config.Delay.BatchSize = 100
+	}
+	
+	if config.Delay.MaxDelay == 0 {
+		config.Delay.MaxDelay = 7 * 24 * time.Hour
+	}
+	
+	if config.Delay.MaxAttempts == 0 {
+		config.Delay.MaxAttempts = 3
+	}
+	
+	if config.Delay.WebhookTimeout == 0 {
+		config.Delay.WebhookTimeout = 10 * time.Second
+	}
+	
+	if config.Flags.KeyPrefix == "" {
+		config.Flags.KeyPrefix = "sr:flags:"
+	}
+	
+	if config.Flags.MaxFlags == 0 {
+		config.Flags.MaxFlags = 1000
+	}
+	
+	if config.Flags.Heartbeat == 0 {
+		config.Flags.Heartbeat = 15 * time.Second
+	}
+	
+	if config.TxSessions.IdleTimeout == 0 {
+		config.TxSessions.IdleTimeout = 30 * time.Second
+	}
+	
+	if config.TxSessions.MaxAge == 0 {
+		config.TxSessions.MaxAge = 5 * time.Minute
+	}
+	
+	if config.TxSessions.MaxSessions == 0 {
+		config.TxSessions.MaxSessions = 100
+	}
+	
+	if config.TxSessions.MaxPerTenant == 0 {
+		config.TxSessions.MaxPerTenant = 10
+	}
+	
+	if config.Secrets.RefreshInterval == 0 {
+		config.Secrets.RefreshInterval = 5 * time.Minute
+	}
+	
+	if config.Secrets.Timeout == 0 {
+		config.Secrets.Timeout = 10 * time.Second
+	}
+	
+	if config.Remote.Timeout == 0 {
+		config.Remote.Timeout = defaultRemoteTimeout
+	}
+	
+	if config.Redis.ClientCache.MaxBytes == 0 {
+		config.Redis.ClientCache.MaxBytes = 64 << 20
+	}
+	
+	if config.Redis.ClientCache.PoolSize == 0 {
+		config.Redis.ClientCache.PoolSize = 10
+	}
+	
+	if config.Cache.MaxBytes == 0 {
+		config.Cache.MaxBytes = 64 << 20
+	}
+	
+	if config.Cache.TenantMaxBytes == 0 {
+		config.Cache.TenantMaxBytes = config.Cache.MaxBytes / 4
+	}
+	
+	if config.Server.ShutdownTimeout == 0 {
+		config.Server.ShutdownTimeout = 30 * time.Second
+	}
+	
 	if config.Pool.MinIdleConns == 0 {
 		config.Pool.MinIdleConns = 5
 	}
@@ -102,6 +585,138 @@ func setDefaults(config *types.Config) {
 		config.Metrics.Path = "/metrics"
 	}
 	
+	if config.Metrics.HistoryInterval == 0 {
+		config.Metrics.HistoryInterval = time.Minute
+	}
+	
+	if config.Metrics.HistoryRetention == 0 {
+		config.Metrics.HistoryRetention = 24 * time.Hour
+	}
+	
+	if config.Metrics.Tenants.Label == "" {
+		config.Metrics.Tenants.Label = types.TenantLabelID
+	}
+	
+	if config.Metrics.Histograms.NativeBucketFactor == 0 {
+		config.Metrics.Histograms.NativeBucketFactor = 1.1
+	}
+	
+	if config.Metrics.Histograms.NativeMaxBuckets == 0 {
+		config.Metrics.Histograms.NativeMaxBuckets = 160
+	}
+	
+	if config.Auth.TokenCacheSize == 0 {
+		config.Auth.TokenCacheSize = 10000
+	}
+	
+	if config.Auth.Anonymous.RateLimit == 0 {
+		config.Auth.Anonymous.RateLimit = 100
+	}
+	
+	if config.Auth.Lockout.MaxFailures == 0 {
+		config.Auth.Lockout.MaxFailures = 20
+	}
+	
+	if config.Auth.Lockout.Window == 0 {
+		config.Auth.Lockout.Window = time.Minute
+	}
+	
+	if config.Auth.Lockout.Duration == 0 {
+		config.Auth.Lockout.Duration = 5 * time.Minute
+	}
+	
+	if config.Auth.Revocation.KeyPrefix == "" {
+		config.Auth.Revocation.KeyPrefix = "sr:revoked:"
+	}
+	
+	if config.Auth.Revocation.CacheTTL == 0 {
+		config.Auth.Revocation.CacheTTL = 5 * time.Second
+	}
+	
+	if config.Auth.Revocation.Retention == 0 {
+		config.Auth.Revocation.Retention = 30 * 24 * time.Hour
+	}
+	
+	if config.Redis.OOMCooldown == 0 {
+		config.Redis.OOMCooldown = 30 * time.Second
+	}
+	
+	if config.Redis.Retry.MaxAttempts == 0 {
+		config.Redis.Retry.MaxAttempts = 3
+	}
+	
+	if config.Redis.Retry.InitialBackoff == 0 {
+		config.Redis.Retry.InitialBackoff = 50 * time.Millisecond
+	}
+	
+	if config.Redis.Retry.MaxBackoff == 0 {
+		config.Redis.Retry.MaxBackoff = time.Second
+	}
+	
+	if config.Redis.Retry.Jitter == 0 {
+		config.Redis.Retry.Jitter = 0.2
+	}
+	
+	if config.Redis.Connect.InitialBackoff == 0 {
+		config.Redis.Connect.InitialBackoff = 500 * time.Millisecond
+	}
+	
+	if config.Redis.Connect.MaxBackoff == 0 {
+		config.Redis.Connect.MaxBackoff = 30 * time.Second
+	}
+	
+	if config.Redis.Dragonfly.Routing.Mode == "" {
+		config.Redis.Dragonfly.Routing.Mode = types.RoutingLatency
+	}
+	
+	if config.Redis.Dragonfly.Routing.ExploreRatio == 0 {
+		config.Redis.Dragonfly.Routing.ExploreRatio = 0.05
+	}
+	
+	if config.Redis.Dragonfly.Routing.Hysteresis == 0 {
+		config.Redis.Dragonfly.Routing.Hysteresis = 0.2
+	}
+	
+	if config.Redis.Dragonfly.Routing.MinSamples == 0 {
+		config.Redis.Dragonfly.Routing.MinSamples = 20
+	}
+	
+	if config.Redis.Dragonfly.Routing.Smoothing == 0 {
+		config.Redis.Dragonfly.Routing.Smoothing = 0.1
+	}
+	
+	if config.Redis.ReplicaOffsetInterval == 0 {
+		config.Redis.ReplicaOffsetInterval = 100 * time.Millisecond
+	}
+	
+	if config.Observability.OTLP.Endpoint == "" {
+		config.Observability.OTLP.Endpoint = "http://localhost:4318"
+	}
+	
+	if config.Observability.OTLP.ServiceName == "" {
+		config.Observability.OTLP.ServiceName = "serverless-redis"
+	}
+	
+	if config.Observability.OTLP.ExportInterval == 0 {
+		config.Observability.OTLP.ExportInterval = 10 * time.Second
+	}
+	
+	if config.Observability.OTLP.Timeout == 0 {
+		config.Observability.OTLP.Timeout = 5 * time.Second
+	}
+	
+	if config.Observability.Errors.SampleRate == 0 {
+		config.Observability.Errors.SampleRate = 1
+	}
+	
+	if config.Observability.Errors.Timeout == 0 {
+		config.Observability.Errors.Timeout = 5 * time.Second
+	}
+	
+	if config.OpenAPI.UIPath == "" {
+		config.OpenAPI.UIPath = "/docs"
+	}
+	
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -109,6 +724,14 @@ func setDefaults(config *types.Config) {
 	if config.Logging.Format == "" {
 		config.Logging.Format = "json"
 	}
+	
+	if config.Logging.Access.SampleRate == 0 {
+		config.Logging.Access.SampleRate = 1
+	}
+	
+	if config.Logging.Access.Exclude == nil {
+		config.Logging.Access.Exclude = []string{"/health", config.Metrics.Path}
+	}
 }
 
 func validateConfig(config *types.Config) error {
@@ -116,6 +739,18 @@ func validateConfig(config *types.Config) error {
 		return fmt.Errorf("invalid server port: %d", config.Server.Port)
 	}
 	
+	if err := validateTLS(config.Server.TLS); err != nil {
+		return fmt.Errorf("server tls: %w", err)
+	}
+	
+	if err := validateListeners(config.Server); err != nil {
+		return err
+	}
+	
+	if large := config.Server.LargeValues; large.Threshold < 0 || large.ChunkSize < 0 || large.MaxSize < 0 {
+		return fmt.Errorf("server large_values threshold, chunk_size and max_size must not be negative")
+	}
+	
 	if config.Redis.Primary.Addr == "" {
 		return fmt.Errorf("redis primary address is required")
 	}
@@ -132,6 +767,527 @@ func validateConfig(config *types.Config) error {
 		return fmt.Errorf("max_idle_conns must be >= min_idle_conns")
 	}
 	
+	if config.Redis.Retry.Jitter < 0 || config.Redis.Retry.Jitter > 1 {
+		return fmt.Errorf("redis retry jitter must be between 0 and 1")
+	}
+	
+	if config.Redis.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("redis retry max_attempts must not be negative")
+	}
+	
+	if connect := config.Redis.Connect; connect.InitialBackoff < 0 || connect.InitialBackoff > connect.MaxBackoff {
+		return fmt.Errorf("redis connect backoffs must satisfy 0 <= initial_backoff <= max_backoff")
+	}
+	
+	shards := map[string]bool{"primary": true}
+	for _, shard := range config.Redis.Shards {
+		if shard.Name == "" || shards[shard.Name] {
+			return fmt.Errorf("redis shards need unique names other than primary")
+		}
+		shards[shard.Name] = true
+		
+		if shard.Addr == "" {
+			return fmt.Errorf("redis shard %s: addr is required", shard.Name)
+		}
+	}
+	
+	if routing := config.Redis.Dragonfly.Routing; config.Redis.Dragonfly.Enabled {
+		if routing.Mode != types.RoutingLatency && routing.Mode != types.RoutingStatic {
+			return fmt.Errorf("dragonfly routing mode must be latency or static")
+		}
+		
+		if routing.ExploreRatio < 0 || routing.ExploreRatio >= 1 || routing.Hysteresis < 0 || routing.Hysteresis >= 1 {
+			return fmt.Errorf("dragonfly routing explore_ratio and hysteresis must be between 0 and 1")
+		}
+		
+		if routing.Smoothing <= 0 || routing.Smoothing > 1 {
+			return fmt.Errorf("dragonfly routing smoothing must be in (0, 1]")
+		}
+	}
+	
+	switch config.Redis.Mode {
+	case "", types.RedisModeServer:
+	case types.RedisModeEmbedded:
+		if len(config.Redis.Replicas) > 0 || len(config.Redis.Shards) > 0 || config.Redis.Dragonfly.Enabled {
+			return fmt.Errorf("redis mode embedded cannot be combined with replicas, shards or dragonfly")
+		}
+	default:
+		return fmt.Errorf("redis mode must be server or embedded, got %q", config.Redis.Mode)
+	}
+	
+	if len(config.Redis.Shards) > 0 && (config.Redis.ReadFromReplicas || config.Redis.Dragonfly.Enabled) {
+		return fmt.Errorf("redis shards cannot be combined with read_from_replicas or dragonfly")
+	}
+	
+	if config.Redis.ClientCache.Enabled && (len(config.Redis.Shards) > 0 || config.Redis.Dragonfly.Enabled) {
+		return fmt.Errorf("redis client_cache cannot be combined with shards or dragonfly")
+	}
+	
+	backends := shards
+	if config.Redis.Dragonfly.Enabled {
+		backends["dragonfly"] = true
+	}
+	for name, database := range config.Redis.NamedDatabases {
+		if !backends[database.Backend] {
+			return fmt.Errorf("redis named database %s: backend %q is not primary, dragonfly or a shard", name, database.Backend)
+		}
+		
+		if database.DB < 0 || (config.Redis.Databases > 0 && database.DB >= config.Redis.Databases) {
+			return fmt.Errorf("redis named database %s: db %d is out of range", name, database.DB)
+		}
+		
+		if config.Auth.Revocation.Enabled && database.Backend == "primary" && database.DB == config.Auth.Revocation.DB {
+			return fmt.Errorf("redis named database %s must not map to the auth revocation db", name)
+		}
+	}
+	
+	for _, key := range config.Auth.APIKeys {
+		for _, name := range key.AllowedDatabases {
+			if _, ok := config.Redis.NamedDatabases[name]; !ok && name != "*" {
+				return fmt.Errorf("allowed_databases of tenant %s: %s is not a named database", key.TenantID, name)
+			}
+		}
+	}
+	
+	if concurrency := config.Server.Concurrency; concurrency.Enabled {
+		if concurrency.MinLimit < 1 || concurrency.MinLimit > concurrency.InitialLimit || concurrency.InitialLimit > concurrency.MaxLimit {
+			return fmt.Errorf("server concurrency limits must satisfy 1 <= min_limit <= initial_limit <= max_limit")
+		}
+		
+		if concurrency.Tolerance <= 1 {
+			return fmt.Errorf("server concurrency tolerance must be greater than 1")
+		}
+		
+		if concurrency.Backoff <= 0 || concurrency.Backoff >= 1 {
+			return fmt.Errorf("server concurrency backoff must be between 0 and 1")
+		}
+	}
+	
+	tiers := make(map[string]bool)
+	for _, tier := range config.Server.Concurrency.Tiers {
+		if tier.Name == "" || tiers[tier.Name] {
+			return fmt.Errorf("concurrency tiers need unique names")
+		}
+		tiers[tier.Name] = true
+		
+		if tier.Weight < 1 || tier.MaxQueue < 0 {
+			return fmt.Errorf("concurrency tier %s: weight must be positive and max_queue non-negative", tier.Name)
+		}
+	}
+	
+	if defaultTier := config.Server.Concurrency.DefaultTier; defaultTier != "" && !tiers[defaultTier] {
+		return fmt.Errorf("concurrency default_tier %s is not a configured tier", defaultTier)
+	}
+	
+	if errs := config.Observability.Errors; errs.Enabled {
+		if errs.SentryDSN == "" && errs.WebhookURL == "" {
+			return fmt.Errorf("observability errors needs a sentry_dsn or a webhook_url")
+		}
+		if errs.SentryDSN != "" {
+			dsn, err := url.Parse(errs.SentryDSN)
+			if err != nil || dsn.User == nil || dsn.Host == "" || strings.Trim(dsn.Path, "/") == "" {
+				return fmt.Errorf("observability errors sentry_dsn must look like https://key@host/project")
+			}
+		}
+		if errs.SampleRate < 0 || errs.SampleRate > 1 {
+			return fmt.Errorf("observability errors sample_rate must be between 0 and 1, got %v", errs.SampleRate)
+		}
+	}
+	
+	// CLIENT SETNAME rejects names with spaces
+	if strings.ContainsAny(config.Redis.ClientName+config.Redis.Instance, " \t\r\n") {
+		return fmt.Errorf("redis client_name and instance must not contain whitespace")
+	}
+	
+	if config.BigValues.MaxValueBytes < 0 || config.BigValues.MaxKeyBytes < 0 {
+		return fmt.Errorf("big_values max_value_bytes and max_key_bytes must not be negative")
+	}
+	
+	if config.SlowLog.RequestThreshold < 0 || config.SlowLog.CommandThreshold < 0 || config.SlowLog.Size < 0 {
+		return fmt.Errorf("slowlog thresholds and size must not be negative")
+	}
+	
+	if config.RateLimit.DefaultCost < 0 {
+		return fmt.Errorf("rate_limit default_cost must not be negative")
+	}
+	for command, cost := range config.RateLimit.Costs {
+		if cost < 0 {
+			return fmt.Errorf("rate_limit cost of %s must not be negative", command)
+		}
+	}
+	
+	if config.RateLimit.Shared.SyncInterval < 0 {
+		return fmt.Errorf("rate_limit shared sync_interval must not be negative")
+	}
+	
+	if shared := config.RateLimit.Shared; shared.DB < 0 || (config.Redis.Databases > 0 && shared.DB >= config.Redis.Databases) {
+		return fmt.Errorf("rate_limit shared db %d is out of range", shared.DB)
+	}
+	
+	switch config.Secrets.Provider {
+	case "", secrets.ProviderVault, secrets.ProviderAWS:
+	default:
+		return fmt.Errorf("secrets provider must be vault or aws, got %q", config.Secrets.Provider)
+	}
+	
+	if config.Secrets.RefreshInterval < 0 || config.Secrets.Timeout < 0 {
+		return fmt.Errorf("secrets refresh_interval and timeout must not be negative")
+	}
+	
+	switch config.Remote.Provider {
+	case "", RemoteEtcd, RemoteConsul:
+	default:
+		return fmt.Errorf("remote provider must be etcd or consul, got %q", config.Remote.Provider)
+	}
+	
+	if config.Cache.MaxBytes < 0 || config.Cache.TenantMaxBytes < 0 || config.Cache.TenantMaxBytes > config.Cache.MaxBytes {
+		return fmt.Errorf("cache max_bytes and tenant_max_bytes must not be negative, and tenant_max_bytes at most max_bytes")
+	}
+	
+	cacheRules := make(map[string]bool)
+	for _, rule := range config.Cache.Rules {
+		target := rule.Path + rule.Command
+		if (rule.Path == "") == (rule.Command == "") {
+			return fmt.Errorf("cache rules need exactly one of path or command")
+		}
+		if cacheRules[strings.ToUpper(target)] {
+			return fmt.Errorf("cache rule for %s is configured twice", target)
+		}
+		cacheRules[strings.ToUpper(target)] = true
+		
+		if rule.TTL <= 0 || rule.StaleWhileRevalidate < 0 {
+			return fmt.Errorf("cache rule for %s: ttl must be positive and stale_while_revalidate non-negative", target)
+		}
+		
+		// Paths are cached before requests are authenticated, so the
+		// tenant isn't known yet; command replies always belong to one
+		switch {
+		case rule.Path != "" && rule.Key != "" && rule.Key != types.CacheKeyPublic && rule.Key != types.CacheKeyAuth:
+			return fmt.Errorf("cache rule for %s: key must be public or auth, got %q", target, rule.Key)
+		case rule.Command != "" && rule.Key != "" && rule.Key != types.CacheKeyTenant:
+			return fmt.Errorf("cache rule for %s: key must be tenant, got %q", target, rule.Key)
+		}
+	}
+	
+	if config.Metrics.HistoryInterval != 0 && config.Metrics.HistoryInterval < time.Second {
+		return fmt.Errorf("metrics history_interval must be at least 1s")
+	}
+	
+	if access := config.Metrics.Access; (access.Username == "") != (access.Password == "") {
+		return fmt.Errorf("metrics access username and password must be set together")
+	}
+	if _, err := auth.ParseCIDRs(config.Metrics.Access.AllowedCIDRs); err != nil {
+		return fmt.Errorf("metrics access allowed_cidrs: %w", err)
+	}
+	
+	switch config.Metrics.Tenants.Label {
+	case "", types.TenantLabelID, types.TenantLabelGroup, types.TenantLabelNone:
+	default:
+		return fmt.Errorf("metrics tenants label must be id, group or none, got %q", config.Metrics.Tenants.Label)
+	}
+	if config.Metrics.Tenants.Buckets < 0 {
+		return fmt.Errorf("metrics tenants buckets must not be negative")
+	}
+	
+	for name, buckets := range map[string][]float64{
+		"http_buckets":  config.Metrics.Histograms.HTTPBuckets,
+		"redis_buckets": config.Metrics.Histograms.RedisBuckets,
+	} {
+		for i, bound := range buckets {
+			if bound <= 0 || (i > 0 && bound <= buckets[i-1]) {
+				return fmt.Errorf("metrics histograms %s must be positive and increasing", name)
+			}
+		}
+	}
+	if config.Metrics.Histograms.Native && config.Metrics.Histograms.NativeBucketFactor <= 1 {
+		return fmt.Errorf("metrics histograms native_bucket_factor must be greater than 1")
+	}
+	
+	if lockout := config.Auth.Lockout; lockout.Window < 0 || lockout.Duration < 0 {
+		return fmt.Errorf("auth lockout window and duration must not be negative")
+	}
+	
+	if anonymous := config.Auth.Anonymous; anonymous.Enabled {
+		if len(anonymous.Commands) == 0 || anonymous.KeyPrefix == "" {
+			return fmt.Errorf("auth anonymous access needs commands and a key_prefix")
+		}
+		for _, command := range anonymous.Commands {
+			if strings.Contains(command, "*") {
+				return fmt.Errorf("auth anonymous commands must be listed explicitly, got %s", command)
+			}
+		}
+		if anonymous.DB < 0 || (config.Redis.Databases > 0 && anonymous.DB >= config.Redis.Databases) {
+			return fmt.Errorf("auth anonymous db %d is out of range", anonymous.DB)
+		}
+		if anonymous.RateLimit < 0 {
+			return fmt.Errorf("auth anonymous rate_limit must not be negative")
+		}
+	}
+	
+	redisUsers := make(map[string]string)
+	tenantTiers := make(map[string]string)
+	tenantValueLimits := make(map[string]int)
+	tenantKeyLimits := make(map[string]int)
+	tenantTTLs := make(map[string][2]time.Duration)
+	tenantKeyPatterns := make(map[string]string)
+	if _, err := auth.CompileKeyPattern(config.Auth.KeyPattern, ""); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	for _, key := range config.Auth.APIKeys {
+		if _, err := auth.ParseCIDRs(key.AllowedCIDRs); err != nil {
+			return fmt.Errorf("api key for tenant %s: allowed_cidrs: %w", key.TenantID, err)
+		}
+		
+		if _, err := auth.CompileKeyPattern(key.KeyPattern, key.TenantID); err != nil {
+			return fmt.Errorf("api key for tenant %s: %w", key.TenantID, err)
+		}
+		
+		if pattern, seen := tenantKeyPatterns[key.TenantID]; seen && pattern != key.KeyPattern {
+			return fmt.Errorf("api keys for tenant %s use different key_pattern values", key.TenantID)
+		}
+		tenantKeyPatterns[key.TenantID] = key.KeyPattern
+		
+		if (key.KeyID == "") != (key.SigningSecret == "") {
+			return fmt.Errorf("api key for tenant %s: key_id and signing_secret must be set together", key.TenantID)
+		}
+		
+		if key.Key == "" && key.KeyID == "" {
+			return fmt.Errorf("api key for tenant %s: key or key_id is required", key.TenantID)
+		}
+		
+		if key.RedisPassword != "" && key.RedisUsername == "" {
+			return fmt.Errorf("api key for tenant %s: redis_password requires redis_username", key.TenantID)
+		}
+		
+		// JWTs carry only the tenant ID, so a tenant must map to one Redis user
+		if user, seen := redisUsers[key.TenantID]; seen && user != key.RedisUsername {
+			return fmt.Errorf("api keys for tenant %s use different redis_username values", key.TenantID)
+		}
+		redisUsers[key.TenantID] = key.RedisUsername
+		
+		if key.Tier != "" && !tiers[key.Tier] {
+			return fmt.Errorf("api key for tenant %s: tier %s is not a configured concurrency tier", key.TenantID, key.Tier)
+		}
+		
+		if tier, seen := tenantTiers[key.TenantID]; seen && tier != key.Tier {
+			return fmt.Errorf("api keys for tenant %s use different tier values", key.TenantID)
+		}
+		tenantTiers[key.TenantID] = key.Tier
+		
+		if key.MaxValueBytes < 0 {
+			return fmt.Errorf("api key for tenant %s: max_value_bytes must not be negative", key.TenantID)
+		}
+		
+		if limit, seen := tenantValueLimits[key.TenantID]; seen && limit != key.MaxValueBytes {
+			return fmt.Errorf("api keys for tenant %s use different max_value_bytes values", key.TenantID)
+		}
+		tenantValueLimits[key.TenantID] = key.MaxValueBytes
+		
+		if key.MaxKeyBytes < 0 {
+			return fmt.Errorf("api key for tenant %s: max_key_bytes must not be negative", key.TenantID)
+		}
+		
+		if limit, seen := tenantKeyLimits[key.TenantID]; seen && limit != key.MaxKeyBytes {
+			return fmt.Errorf("api keys for tenant %s use different max_key_bytes values", key.TenantID)
+		}
+		tenantKeyLimits[key.TenantID] = key.MaxKeyBytes
+		
+		if key.DefaultTTL < 0 || key.MaxTTL < 0 {
+			return fmt.Errorf("api key for tenant %s: default_ttl and max_ttl must not be negative", key.TenantID)
+		}
+		
+		if (key.DefaultTTL > 0 && key.DefaultTTL < time.Second) || (key.MaxTTL > 0 && key.MaxTTL < time.Second) {
+			return fmt.Errorf("api key for tenant %s: default_ttl and max_ttl must be at least 1s", key.TenantID)
+		}
+		
+		if key.MaxTTL > 0 && key.DefaultTTL > key.MaxTTL {
+			return fmt.Errorf("api key for tenant %s: default_ttl (%v) must not exceed max_ttl (%v)", key.TenantID, key.DefaultTTL, key.MaxTTL)
+		}
+		
+		ttls := [2]time.Duration{key.DefaultTTL, key.MaxTTL}
+		if seen, ok := tenantTTLs[key.TenantID]; ok && seen != ttls {
+			return fmt.Errorf("api keys for tenant %s use different default_ttl or max_ttl values", key.TenantID)
+		}
+		tenantTTLs[key.TenantID] = ttls
+	}
+	
+	scriptNames := make(map[string]bool)
+	for _, script := range config.Scripts.Preload {
+		if script.Name == "" {
+			return fmt.Errorf("preloaded scripts need a name")
+		}
+		if scriptNames[script.Name] {
+			return fmt.Errorf("script %s is defined twice", script.Name)
+		}
+		scriptNames[script.Name] = true
+		
+		if (script.Source == "") == (script.File == "") {
+			return fmt.Errorf("script %s: exactly one of source and file is required", script.Name)
+		}
+	}
+	
+	macroNames := make(map[string]bool)
+	for _, macro := range config.Macros {
+		if macro.Name == "" {
+			return fmt.Errorf("macros need a name")
+		}
+		if macroNames[macro.Name] {
+			return fmt.Errorf("macro %s is defined twice", macro.Name)
+		}
+		macroNames[macro.Name] = true
+		
+		if len(macro.Commands) == 0 {
+			return fmt.Errorf("macro %s has no commands", macro.Name)
+		}
+		for i, cmd := range macro.Commands {
+			if cmd.Command == "" {
+				return fmt.Errorf("macro %s: command %d is empty", macro.Name, i)
+			}
+		}
+		
+		declared := make(map[string]bool)
+		for _, param := range macro.Params {
+			declared[param] = true
+		}
+		used := server.MacroPlaceholders(macro)
+		for _, param := range used {
+			if !declared[param] {
+				return fmt.Errorf("macro %s uses undeclared param %s", macro.Name, param)
+			}
+		}
+		if len(used) != len(declared) {
+			return fmt.Errorf("macro %s declares params it doesn't use", macro.Name)
+		}
+	}
+	
+	access := config.Logging.Access
+	if access.SampleRate < 0 || access.SampleRate > 1 {
+		return fmt.Errorf("logging access sample_rate must be between 0 and 1, got %v", access.SampleRate)
+	}
+	for _, field := range access.Fields {
+		if !server.IsAccessLogField(field) {
+			return fmt.Errorf("logging access field %q is unknown", field)
+		}
+	}
+	
+	// Tenants able to reach the revocation database could delete entries
+	if config.Auth.Revocation.Enabled {
+		for _, key := range config.Auth.APIKeys {
+			for _, db := range key.AllowedDBs {
+				if db == config.Auth.Revocation.DB {
+					return fmt.Errorf("auth revocation db %d must not be in allowed_dbs of tenant %s", db, key.TenantID)
+				}
+			}
+		}
+	}
+	
+	if config.Delay.PollInterval < 0 || config.Delay.BatchSize < 0 || config.Delay.MaxDelay < 0 ||
+		config.Delay.MaxAttempts < 0 || config.Delay.WebhookTimeout < 0 {
+		return fmt.Errorf("delay poll_interval, batch_size, max_delay, max_attempts and webhook_timeout must not be negative")
+	}
+	
+	// Tenants able to reach the delay database could read or forge tasks
+	if config.Delay.Enabled {
+		if config.Delay.DB < 0 || (config.Redis.Databases > 0 && config.Delay.DB >= config.Redis.Databases) {
+			return fmt.Errorf("delay db %d is out of range", config.Delay.DB)
+		}
+		for _, key := range config.Auth.APIKeys {
+			for _, db := range key.AllowedDBs {
+				if db == config.Delay.DB {
+					return fmt.Errorf("delay db %d must not be in allowed_dbs of tenant %s", db, key.TenantID)
+				}
+			}
+		}
+	}
+	
+	if config.Flags.MaxFlags < 0 || config.Flags.Heartbeat < 0 {
+		return fmt.Errorf("flags max_flags and heartbeat must not be negative")
+	}
+	
+	// Tenants able to reach the flags database could read or change other
+	// tenants' flags
+	if config.Flags.Enabled {
+		if config.Flags.DB < 0 || (config.Redis.Databases > 0 && config.Flags.DB >= config.Redis.Databases) {
+			return fmt.Errorf("flags db %d is out of range", config.Flags.DB)
+		}
+		for _, key := range config.Auth.APIKeys {
+			for _, db := range key.AllowedDBs {
+				if db == config.Flags.DB {
+					return fmt.Errorf("flags db %d must not be in allowed_dbs of tenant %s", db, key.TenantID)
+				}
+			}
+		}
+	}
+	
+	if sessions := config.TxSessions; sessions.IdleTimeout < 0 || sessions.MaxAge < 0 || sessions.MaxSessions < 0 || sessions.MaxPerTenant < 0 {
+		return fmt.Errorf("tx_sessions idle_timeout, max_age, max_sessions and max_per_tenant must not be negative")
+	}
+	
+	// Every session holds a connection; they must not exhaust the pool
+	if config.TxSessions.Enabled && config.TxSessions.MaxSessions >= config.Pool.MaxActiveConns {
+		return fmt.Errorf("tx_sessions max_sessions (%d) must be less than pool max_active_conns (%d)", config.TxSessions.MaxSessions, config.Pool.MaxActiveConns)
+	}
+	
+	// Tenants able to reach the recording stream could read other tenants' traffic
+	if recording := config.Recording; recording.File != "" || recording.Stream != "" || recording.Enabled {
+		if (recording.File == "") == (recording.Stream == "") {
+			return fmt.Errorf("recording needs exactly one of file or stream")
+		}
+		if recording.SampleRate < 0 || recording.SampleRate > 1 || recording.MaxBodyBytes < 0 || recording.MaxLen < 0 {
+			return fmt.Errorf("recording sample_rate must be between 0 and 1, and max_body_bytes and max_len not negative")
+		}
+		if recording.Stream != "" {
+			if recording.StreamDB < 0 || (config.Redis.Databases > 0 && recording.StreamDB >= config.Redis.Databases) {
+				return fmt.Errorf("recording stream_db %d is out of range", recording.StreamDB)
+			}
+			for _, key := range config.Auth.APIKeys {
+				for _, db := range key.AllowedDBs {
+					if db == recording.StreamDB {
+						return fmt.Errorf("recording stream_db %d must not be in allowed_dbs of tenant %s", db, key.TenantID)
+					}
+				}
+			}
+		}
+	}
+	
+	if oplog := config.OpLog; oplog.Enabled {
+		if oplog.File == "" || len(oplog.Tenants) == 0 {
+			return fmt.Errorf("oplog needs a file and the tenants whose writes it queues")
+		}
+		if oplog.MaxEntries < 0 || oplog.MaxBytes < 0 || oplog.RetryInterval < 0 {
+			return fmt.Errorf("oplog max_entries, max_bytes and retry_interval must not be negative")
+		}
+		// Replayed writes run as the proxy; Redis passwords aren't written to disk
+		for _, key := range config.Auth.APIKeys {
+			if key.RedisUsername != "" && slices.Contains(oplog.Tenants, key.TenantID) {
+				return fmt.Errorf("oplog tenant %s runs as Redis ACL user %s, which replayed writes could not", key.TenantID, key.RedisUsername)
+			}
+		}
+	}
+	
+	for i, rule := range config.Faults.Rules {
+		if rule.Latency < 0 || rule.Jitter < 0 {
+			return fmt.Errorf("faults rule %d: latency and jitter must not be negative", i)
+		}
+		for _, rate := range []float64{rule.LatencyRate, rule.DropRate, rule.ErrorRate} {
+			if rate < 0 || rate > 1 {
+				return fmt.Errorf("faults rule %d: latency_rate, drop_rate and error_rate must be between 0 and 1", i)
+			}
+		}
+	}
+	
+	for _, rule := range config.Deprecation.Rules {
+		if !strings.HasPrefix(rule.Path, "/") {
+			return fmt.Errorf("deprecation rule path %q must start with /", rule.Path)
+		}
+		if rule.Since.IsZero() {
+			return fmt.Errorf("deprecation rule for %s needs since", rule.Path)
+		}
+		if !rule.Sunset.IsZero() && rule.Sunset.Before(rule.Since) {
+			return fmt.Errorf("deprecation rule for %s: sunset must not be before since", rule.Path)
+		}
+	}
+	
 	if config.Auth.Enabled && config.Auth.JWTSecret == "change-this-secret-key" {
 		return fmt.Errorf("JWT secret must be changed in production")
 	}
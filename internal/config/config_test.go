@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/scaler/serverless-redis/internal/types"
 )
@@ -207,6 +210,827 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Invalid allowed CIDR",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					Enabled:   true,
+					JWTSecret: "valid-secret",
+					APIKeys: []types.APIKey{
+						{Key: "key", TenantID: "tenant1", AllowedCIDRs: []string{"10.0.0.0/33"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Signing key without secret",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					Enabled:   true,
+					JWTSecret: "valid-secret",
+					APIKeys: []types.APIKey{
+						{KeyID: "ak_1", TenantID: "tenant1"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Tenant with two Redis users",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					Enabled:   true,
+					JWTSecret: "valid-secret",
+					APIKeys: []types.APIKey{
+						{Key: "key1", TenantID: "tenant1", RedisUsername: "alice"},
+						{Key: "key2", TenantID: "tenant1", RedisUsername: "bob"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "API key with unknown tier",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+					Concurrency: types.ConcurrencyConfig{
+						Tiers: []types.TierConfig{{Name: "free", Weight: 1}},
+					},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key1", TenantID: "tenant1", Tier: "gold"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Concurrency backoff out of range",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+					Concurrency: types.ConcurrencyConfig{
+						Enabled:      true,
+						InitialLimit: 100,
+						MinLimit:     10,
+						MaxLimit:     1000,
+						Tolerance:    2,
+						Backoff:      1.5,
+					},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Shard named primary",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+					Shards: []types.ShardConfig{
+						{Name: "primary", Addr: "localhost:6380"},
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Named database on unknown backend",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+					NamedDatabases: map[string]types.NamedDatabaseConfig{
+						"cache": {Backend: "dragonfly", DB: 0},
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Cache rule with path and command",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Cache: types.CacheConfig{
+					Rules: []types.CacheRule{{Path: "/health", Command: "GET", TTL: time.Second}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Metrics access username without password",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Metrics: types.MetricsConfig{
+					Access: types.OperatorAccessConfig{Username: "prometheus"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Cache rule for a command keyed publicly",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Cache: types.CacheConfig{
+					Rules: []types.CacheRule{{Command: "HGETALL", Key: types.CacheKeyPublic, TTL: time.Second}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown access log field",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Logging: types.LoggingConfig{
+					Access: types.AccessLogConfig{Enabled: true, Fields: []string{"method", "user_agent"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative slowlog threshold",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				SlowLog: types.SlowLogConfig{CommandThreshold: -time.Millisecond},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error reporting without a destination",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Observability: types.ObservabilityConfig{
+					Errors: types.ErrorReportingConfig{Enabled: true, SampleRate: 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown secrets provider",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Secrets: types.SecretsConfig{Provider: "etcd"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Listener with unknown routes",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+					Listeners: []types.ListenerConfig{
+						{Network: types.ListenerUnix, Address: "/run/sr.sock", SocketMode: "0660"},
+						{Address: "10.0.0.1:9090", Routes: "metrics"},
+					},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Pprof without an admin listener",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port:  8080,
+					Pprof: true,
+					Listeners: []types.ListenerConfig{
+						{Address: "0.0.0.0:8080", Routes: types.ListenerRoutesAPI},
+					},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Admin listener on a listener's address",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+					Listeners: []types.ListenerConfig{
+						{Address: "127.0.0.1:9090"},
+					},
+					Admin: types.ListenerConfig{Address: "127.0.0.1:9090"},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative large value chunk size",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port:        8080,
+					LargeValues: types.LargeValueConfig{ChunkSize: -1},
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "API keys of a tenant with different key limits",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key-1", TenantID: "tenant", MaxKeyBytes: 256},
+						{Key: "key-2", TenantID: "tenant", MaxKeyBytes: 512},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Default TTL longer than the maximum",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key-1", TenantID: "tenant", DefaultTTL: 48 * time.Hour, MaxTTL: 24 * time.Hour},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Op log tenant running as a Redis ACL user",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key-1", TenantID: "tenant", RedisUsername: "tenant", RedisPassword: "secret"},
+					},
+				},
+				OpLog: types.OpLogConfig{Enabled: true, File: "oplog.jsonl", Tenants: []string{"tenant"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid key pattern",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					KeyPattern: "^{tenant}:(",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative command cost",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				RateLimit: types.RateLimitConfig{
+					Costs: map[string]int{"KEYS": -1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative shared rate limit sync interval",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				RateLimit: types.RateLimitConfig{
+					Shared: types.SharedRateLimitConfig{
+						Enabled:      true,
+						SyncInterval: -time.Second,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Delay db reachable by a tenant",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key", TenantID: "acme", AllowedDBs: []int{0, 15}},
+					},
+				},
+				Delay: types.DelayConfig{
+					Enabled: true,
+					DB:      15,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Flags db reachable by a tenant",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					APIKeys: []types.APIKey{
+						{Key: "key", TenantID: "acme", AllowedDBs: []int{0, 14}},
+					},
+				},
+				Flags: types.FlagsConfig{
+					Enabled: true,
+					DB:      14,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Transaction sessions able to take the whole pool",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 100,
+				},
+				TxSessions: types.TxSessionsConfig{
+					Enabled:     true,
+					MaxSessions: 100,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Histogram buckets not increasing",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Metrics: types.MetricsConfig{
+					Histograms: types.HistogramConfig{RedisBuckets: []float64{0.01, 0.001}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Embedded Redis with shards",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Mode: types.RedisModeEmbedded,
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+					Shards: []types.ShardConfig{{Name: "b", Addr: "localhost:6380"}},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Anonymous access without a key prefix",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					Anonymous: types.AnonymousConfig{Enabled: true, Commands: []string{"GET"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative auth lockout duration",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Auth: types.AuthConfig{
+					Lockout: types.LockoutConfig{MaxFailures: 5, Duration: -time.Minute},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Recording to both a file and a stream",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Recording: types.RecordingConfig{
+					File:   "/tmp/traffic.jsonl",
+					Stream: "sr:recording",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fault error rate above 1",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Faults: types.FaultsConfig{
+					Enabled: true,
+					Rules:   []types.FaultRule{{ErrorRate: 1.5}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Deprecation sunset before since",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Deprecation: types.DeprecationConfig{
+					Rules: []types.DeprecationRule{{
+						Path:   "/v1/mget",
+						Since:  time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+						Sunset: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Macro with undeclared param",
+			config: &types.Config{
+				Server: types.ServerConfig{
+					Port: 8080,
+				},
+				Redis: types.RedisConfig{
+					Primary: types.RedisInstanceConfig{
+						Addr: "localhost:6379",
+					},
+				},
+				Pool: types.PoolConfig{
+					MinIdleConns:   5,
+					MaxIdleConns:   100,
+					MaxActiveConns: 1000,
+				},
+				Macros: []types.MacroConfig{{
+					Name:     "visit",
+					Params:   []string{"user"},
+					Commands: []types.MacroCommand{{Command: "INCR", Args: []string{"visits:{{page}}"}}},
+				}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -246,4 +1070,83 @@ func TestSetDefaults(t *testing.T) {
 	if config.Logging.Format != "json" {
 		t.Errorf("Expected default log format json, got %s", config.Logging.Format)
 	}
-}
\ No newline at end of file
+
+	if config.Auth.TokenCacheSize != 10000 {
+		t.Errorf("Expected default token cache size 10000, got %d", config.Auth.TokenCacheSize)
+	}
+
+	if lockout := config.Auth.Lockout; lockout.MaxFailures != 20 || lockout.Window != time.Minute || lockout.Duration != 5*time.Minute {
+		t.Errorf("Expected default lockout after 20 failures in 1m for 5m, got %+v", lockout)
+	}
+
+	if flags := config.Flags; flags.KeyPrefix != "sr:flags:" || flags.MaxFlags != 1000 || flags.Heartbeat != 15*time.Second {
+		t.Errorf("Expected default flags prefix sr:flags:, 1000 flags and a 15s heartbeat, got %+v", flags)
+	}
+}
+func TestCheckUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", "server:\n  port: 9000\nredis:\n  primary:\n    addr: \"localhost:6379\"\n")
+	if err := CheckUnknownFields(valid); err != nil {
+		t.Errorf("Expected no error for known fields, got %v", err)
+	}
+
+	if err := CheckUnknownFields(write("empty.yaml", "")); err != nil {
+		t.Errorf("Expected no error for an empty file, got %v", err)
+	}
+
+	typo := write("typo.yaml", "server:\n  prot: 9000\n")
+	err := CheckUnknownFields(typo)
+	if err == nil || !strings.Contains(err.Error(), "field prot not found") {
+		t.Errorf("Expected unknown field error, got %v", err)
+	}
+
+	// LoadConfigFile ignores the typo
+	if _, err := LoadConfigFile(typo); err != nil {
+		t.Errorf("Expected LoadConfigFile to ignore unknown fields, got %v", err)
+	}
+
+	if _, err := LoadConfigFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected error for a missing config file")
+	}
+}
+
+func TestApplySecrets(t *testing.T) {
+	config := &types.Config{
+		Server: types.ServerConfig{Port: 8080},
+		Redis: types.RedisConfig{
+			Primary: types.RedisInstanceConfig{Addr: "localhost:6379", Password: "old"},
+		},
+		Pool: types.PoolConfig{MinIdleConns: 5, MaxIdleConns: 100, MaxActiveConns: 1000},
+		Auth: types.AuthConfig{
+			APIKeys: []types.APIKey{{Key: "old-key", TenantID: "acme"}},
+		},
+	}
+
+	updated, err := ApplySecrets(config, types.Secrets{
+		RedisPassword: "new",
+		APIKeys:       []types.APIKey{{Key: "new-key", TenantID: "acme"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Redis.Primary.Password != "new" || updated.Auth.APIKeys[0].Key != "new-key" {
+		t.Errorf("Expected secrets applied, got %+v %+v", updated.Redis.Primary, updated.Auth.APIKeys)
+	}
+	if config.Redis.Primary.Password != "old" || config.Auth.APIKeys[0].Key != "old-key" {
+		t.Error("Expected the original config left unchanged")
+	}
+
+	// Keys from a secret store are checked like configured ones
+	_, err = ApplySecrets(config, types.Secrets{APIKeys: []types.APIKey{{TenantID: "acme"}}})
+	if err == nil {
+		t.Error("Expected error for an API key without key or key_id")
+	}
+}
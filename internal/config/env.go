@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// EnvPrefix starts the environment variables that override config fields.
+const EnvPrefix = "SR_"
+
+// FileSuffix marks variables naming a file that holds the value, as
+// Docker and Kubernetes secrets are mounted: JWT_SECRET_FILE stands for
+// JWT_SECRET and SR_REDIS_DRAGONFLY_PASSWORD_FILE for
+// SR_REDIS_DRAGONFLY_PASSWORD.
+const FileSuffix = "_FILE"
+
+// overrideWithEnvFiles applies the _FILE forms of the secrets
+// overrideWithEnv reads.
+func overrideWithEnvFiles(config *types.Config) error {
+	targets := []struct {
+		name  string
+		field *string
+	}{
+		{"REDIS_PASSWORD", &config.Redis.Primary.Password},
+		{"JWT_SECRET", &config.Auth.JWTSecret},
+	}
+	for _, target := range targets {
+		value, ok, err := envFile(target.name, os.LookupEnv)
+		if err != nil {
+			return err
+		}
+		if ok {
+			*target.field = value
+		}
+	}
+	return nil
+}
+
+// envFile reads the file name+FileSuffix points to, minus the trailing
+// newline most secret files end with.
+func envFile(name string, lookup func(string) (string, bool)) (string, bool, error) {
+	path, ok := lookup(name + FileSuffix)
+	if !ok {
+		return "", false, nil
+	}
+	if _, set := lookup(name); set {
+		return "", false, fmt.Errorf("%s: both %s and %s%s are set", name, name, name, FileSuffix)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", name, FileSuffix, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// overrideWithPrefixedEnv sets every config field that has an SR_
+// variable, named after the field's YAML path in upper case:
+// SR_POOL_MAX_ACTIVE_CONNS sets pool.max_active_conns and
+// SR_SERVER_HTTP2_ENABLED sets server.http2.enabled.
+func overrideWithPrefixedEnv(config *types.Config) error {
+	return applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, os.LookupEnv)
+}
+
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		name := prefix + strings.ToUpper(tag)
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			if err := applyEnv(value, name+"_", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if env, ok := lookup(name); ok {
+			if err := setFromEnv(value, env); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if value.Kind() == reflect.String {
+			env, ok, err := envFile(name, lookup)
+			if err != nil {
+				return err
+			}
+			if ok {
+				value.SetString(env)
+			}
+		}
+	}
+	return nil
+}
+
+// setFromEnv parses env into field. Strings are taken as they are; lists
+// of scalars may be comma separated; everything else, durations included,
+// is parsed as YAML, so lists of objects and maps can be given as JSON.
+func setFromEnv(field reflect.Value, env string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(env)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(env), "[") {
+		switch field.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice:
+		default:
+			env = "[" + env + "]"
+		}
+	}
+
+	parsed := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(env), parsed.Interface()); err != nil {
+		return err
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"SR_SERVER_PORT":                          "9090",
+		"SR_SERVER_HTTP2_ENABLED":                 "true",
+		"SR_SERVER_SHUTDOWN_TIMEOUT":              "45s",
+		"SR_POOL_MAX_ACTIVE_CONNS":                "250",
+		"SR_REDIS_PRIMARY_ADDR":                   "redis.internal:6379",
+		"SR_METRICS_HISTOGRAMS_REDIS_BUCKETS":     "0.001, 0.01, 0.1",
+		"SR_LOGGING_ACCESS_EXCLUDE":               "/health,/ready",
+		"SR_OBSERVABILITY_OTLP_HEADERS":           `{"x-api-key": "collector-key"}`,
+		"SR_AUTH_API_KEYS":                        `[{"key": "k1", "tenant_id": "acme"}]`,
+		"SR_OBSERVABILITY_ERRORS_WEBHOOK_HEADERS": "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config := &types.Config{}
+	config.Server.Host = "0.0.0.0"
+	if err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, lookup); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Server.Port != 9090 || !config.Server.HTTP2.Enabled || config.Server.ShutdownTimeout != 45*time.Second {
+		t.Errorf("Unexpected server config: %+v", config.Server)
+	}
+	if config.Server.Host != "0.0.0.0" {
+		t.Errorf("Expected fields without a variable kept, got host %q", config.Server.Host)
+	}
+	if config.Pool.MaxActiveConns != 250 || config.Redis.Primary.Addr != "redis.internal:6379" {
+		t.Errorf("Unexpected pool or Redis config: %+v %+v", config.Pool, config.Redis.Primary)
+	}
+	if !reflect.DeepEqual(config.Metrics.Histograms.RedisBuckets, []float64{0.001, 0.01, 0.1}) {
+		t.Errorf("Unexpected buckets %v", config.Metrics.Histograms.RedisBuckets)
+	}
+	if !reflect.DeepEqual(config.Logging.Access.Exclude, []string{"/health", "/ready"}) {
+		t.Errorf("Unexpected exclude %v", config.Logging.Access.Exclude)
+	}
+	if config.Observability.OTLP.Headers["x-api-key"] != "collector-key" {
+		t.Errorf("Unexpected OTLP headers %v", config.Observability.OTLP.Headers)
+	}
+	if len(config.Auth.APIKeys) != 1 || config.Auth.APIKeys[0].TenantID != "acme" {
+		t.Errorf("Unexpected API keys %+v", config.Auth.APIKeys)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return "soon", name == "SR_POOL_POOL_TIMEOUT"
+	}
+
+	err := applyEnv(reflect.ValueOf(&types.Config{}).Elem(), EnvPrefix, lookup)
+	if err == nil || err.Error()[:len("SR_POOL_POOL_TIMEOUT")] != "SR_POOL_POOL_TIMEOUT" {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "jwt_secret")
+	if err := os.WriteFile(secretFile, []byte("from-a-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "dragonfly_password")
+	if err := os.WriteFile(passwordFile, []byte("df-pass"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_SECRET_FILE", secretFile)
+	config := &types.Config{}
+	if err := overrideWithEnvFiles(config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Auth.JWTSecret != "from-a-file" {
+		t.Errorf("Expected JWT secret from the file minus its newline, got %q", config.Auth.JWTSecret)
+	}
+
+	env := map[string]string{"SR_REDIS_DRAGONFLY_PASSWORD_FILE": passwordFile}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	if err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, lookup); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Redis.Dragonfly.Password != "df-pass" {
+		t.Errorf("Expected dragonfly password from the file, got %q", config.Redis.Dragonfly.Password)
+	}
+
+	env["SR_REDIS_DRAGONFLY_PASSWORD"] = "inline"
+	err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, lookup)
+	if err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("Expected error when a variable and its _FILE form are both set, got %v", err)
+	}
+
+	t.Setenv("REDIS_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	if err := overrideWithEnvFiles(config); err == nil || !strings.Contains(err.Error(), "REDIS_PASSWORD_FILE") {
+		t.Errorf("Expected error naming REDIS_PASSWORD_FILE, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Remote config providers of remote.provider.
+const (
+	RemoteEtcd   = "etcd"
+	RemoteConsul = "consul"
+)
+
+// defaultRemoteTimeout bounds reading the remote document.
+const defaultRemoteTimeout = 5 * time.Second
+
+// consulWait is how long one Consul blocking query waits for a change.
+const consulWait = 5 * time.Minute
+
+// RemoteSource is a configuration document kept in a key-value store.
+type RemoteSource interface {
+	// Get returns the document and a version that changes with it
+	Get(ctx context.Context) ([]byte, uint64, error)
+
+	// Watch blocks until the document's version is no longer version and
+	// returns the new one
+	Watch(ctx context.Context, version uint64) (uint64, error)
+}
+
+// NewRemoteSource returns the source config.Provider names.
+func NewRemoteSource(config types.RemoteConfig) (RemoteSource, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("remote endpoints are required")
+	}
+	if config.Key == "" {
+		return nil, errors.New("remote key is required")
+	}
+	endpoints := make([]string, len(config.Endpoints))
+	for i, endpoint := range config.Endpoints {
+		endpoints[i] = strings.TrimSuffix(endpoint, "/")
+	}
+
+	switch config.Provider {
+	case RemoteEtcd:
+		return &etcdSource{endpoints: endpoints, config: config, httpClient: &http.Client{}}, nil
+	case RemoteConsul:
+		token := config.Token
+		if token == "" {
+			token = os.Getenv("CONSUL_HTTP_TOKEN")
+		}
+		return &consulSource{endpoints: endpoints, key: strings.TrimPrefix(config.Key, "/"), token: token,
+			timeout: config.Timeout, httpClient: &http.Client{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown remote provider %q", config.Provider)
+	}
+}
+
+// eachEndpoint calls try with every endpoint until one succeeds.
+func eachEndpoint(endpoints []string, try func(endpoint string) error) error {
+	var err error
+	for _, endpoint := range endpoints {
+		if err = try(endpoint); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// responseError describes a failed response with the start of its body.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// etcdSource reads a key through the etcd v3 JSON gateway, which takes
+// and returns keys and values as base64, as encoding/json does []byte.
+type etcdSource struct {
+	endpoints  []string
+	config     types.RemoteConfig
+	httpClient *http.Client
+}
+
+type etcdKeyValue struct {
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+func (s *etcdSource) Get(ctx context.Context) ([]byte, uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	var document []byte
+	var version uint64
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		var response struct {
+			KVs []etcdKeyValue `json:"kvs"`
+		}
+		if err := s.call(ctx, endpoint, "/v3/kv/range", map[string]interface{}{"key": []byte(s.config.Key)}, &response); err != nil {
+			return err
+		}
+		if len(response.KVs) == 0 {
+			return fmt.Errorf("etcd key %s not found", s.config.Key)
+		}
+		document, version = response.KVs[0].Value, uint64(response.KVs[0].ModRevision)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("etcd: %w", err)
+	}
+	return document, version, nil
+}
+
+func (s *etcdSource) Watch(ctx context.Context, version uint64) (uint64, error) {
+	var changed uint64
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		request := map[string]interface{}{
+			"create_request": map[string]interface{}{"key": []byte(s.config.Key), "start_revision": strconv.FormatUint(version+1, 10)},
+		}
+		resp, err := s.post(ctx, endpoint, "/v3/watch", request)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		// The gateway streams one JSON message per watch response
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var message struct {
+				Result struct {
+					Header   etcdHeader `json:"header"`
+					Canceled bool       `json:"canceled"`
+					Events   []struct {
+						KV etcdKeyValue `json:"kv"`
+					} `json:"events"`
+				} `json:"result"`
+			}
+			if err := decoder.Decode(&message); err != nil {
+				return err
+			}
+
+			// A watch cancelled because its revision was compacted away
+			// may have missed changes
+			result := message.Result
+			if result.Canceled {
+				changed = uint64(result.Header.Revision)
+				return nil
+			}
+			if len(result.Events) > 0 {
+				changed = uint64(result.Events[len(result.Events)-1].KV.ModRevision)
+				if changed == 0 {
+					changed = uint64(result.Header.Revision)
+				}
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("etcd: %w", err)
+	}
+	return changed, nil
+}
+
+// call posts request to an etcd gateway path and decodes the response.
+func (s *etcdSource) call(ctx context.Context, endpoint, path string, request, response interface{}) error {
+	resp, err := s.post(ctx, endpoint, path, request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// post sends request, authenticated when a username is configured.
+func (s *etcdSource) post(ctx context.Context, endpoint, path string, request interface{}) (*http.Response, error) {
+	token := ""
+	if s.config.Username != "" {
+		credentials := map[string]string{"name": s.config.Username, "password": s.config.Password}
+		resp, err := s.send(ctx, endpoint, "/v3/auth/authenticate", credentials, "")
+		if err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+		var auth struct {
+			Token string `json:"token"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&auth)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+		token = auth.Token
+	}
+	return s.send(ctx, endpoint, path, request, token)
+}
+
+func (s *etcdSource) send(ctx context.Context, endpoint, path string, request interface{}, token string) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// consulSource reads a key from the Consul KV store, watching it with
+// blocking queries.
+type consulSource struct {
+	endpoints  []string
+	key        string
+	token      string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+func (s *consulSource) Get(ctx context.Context) ([]byte, uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var document []byte
+	var version uint64
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		var err error
+		document, version, err = s.get(ctx, endpoint, url.Values{})
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul: %w", err)
+	}
+	return document, version, nil
+}
+
+func (s *consulSource) Watch(ctx context.Context, version uint64) (uint64, error) {
+	query := url.Values{}
+	query.Set("index", strconv.FormatUint(version, 10))
+	query.Set("wait", consulWait.String())
+
+	var changed uint64
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		for {
+			_, index, err := s.get(ctx, endpoint, query)
+			if err != nil {
+				return err
+			}
+			// Queries return unchanged when the wait ends; an index going
+			// backwards means the store was reset
+			if index != version {
+				changed = index
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("consul: %w", err)
+	}
+	return changed, nil
+}
+
+func (s *consulSource) get(ctx context.Context, endpoint string, query url.Values) ([]byte, uint64, error) {
+	query.Set("raw", "true")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/v1/kv/"+s.key+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.token != "" {
+		req.Header.Set("X-Consul-Token", s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("consul key %s not found", s.key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, responseError(resp)
+	}
+
+	document, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	index, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid X-Consul-Index: %w", err)
+	}
+	return document, index, nil
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeConsul serves one KV key with blocking queries.
+type fakeConsul struct {
+	mutex    sync.Mutex
+	document string
+	index    uint64
+	changed  chan struct{}
+}
+
+func newFakeConsul(document string) *fakeConsul {
+	return &fakeConsul{document: document, index: 10, changed: make(chan struct{})}
+}
+
+func (c *fakeConsul) set(document string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.document = document
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/kv/proxy/config" || r.Header.Get("X-Consul-Token") != "acl-token" {
+		http.NotFound(w, r)
+		return
+	}
+
+	c.mutex.Lock()
+	changed := c.changed
+	blocking := r.URL.Query().Get("index") == fmt.Sprint(c.index)
+	c.mutex.Unlock()
+	if blocking {
+		select {
+		case <-changed:
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w.Header().Set("X-Consul-Index", fmt.Sprint(c.index))
+	fmt.Fprint(w, c.document)
+}
+
+func TestLoaderWithConsul(t *testing.T) {
+	consul := newFakeConsul("auth:\n  api_keys:\n    - key: remote-key\n      tenant_id: acme\nserver:\n  port: 9000\n")
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	local := fmt.Sprintf("server:\n  port: 8080\n  host: 127.0.0.1\nremote:\n  provider: consul\n  endpoints: [%q]\n  key: /proxy/config\n  token: acl-token\n", server.URL)
+	if err := os.WriteFile(path, []byte(local), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SR_SERVER_PORT", "9100")
+
+	loader := NewLoader(path)
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if config.Server.Host != "127.0.0.1" {
+		t.Errorf("Expected settings only in the file kept, got host %q", config.Server.Host)
+	}
+	if config.Server.Port != 9100 {
+		t.Errorf("Expected the environment over the remote document, got port %d", config.Server.Port)
+	}
+	if len(config.Auth.APIKeys) != 1 || config.Auth.APIKeys[0].Key != "remote-key" {
+		t.Errorf("Expected API keys from the remote document, got %+v", config.Auth.APIKeys)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- loader.Watch(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	consul.set("auth:\n  api_keys:\n    - key: rotated-key\n      tenant_id: acme\nremote:\n  key: elsewhere\n")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch did not return after the document changed")
+	}
+
+	config, err = loader.Load()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if config.Auth.APIKeys[0].Key != "rotated-key" {
+		t.Errorf("Expected rotated key, got %+v", config.Auth.APIKeys)
+	}
+	if config.Remote.Key != "/proxy/config" {
+		t.Errorf("Expected the document unable to move itself, got key %q", config.Remote.Key)
+	}
+}
+
+func TestEtcdSource(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("/proxy/config"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			fmt.Fprint(w, `{"token": "etcd-token"}`)
+		case "/v3/kv/range":
+			if r.Header.Get("Authorization") != "etcd-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var request map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if request["key"] != key {
+				t.Errorf("Expected base64 key, got %q", request["key"])
+			}
+			value := base64.StdEncoding.EncodeToString([]byte("server:\n  port: 9000\n"))
+			fmt.Fprintf(w, `{"header": {"revision": "42"}, "kvs": [{"key": %q, "value": %q, "mod_revision": "40"}], "count": "1"}`, key, value)
+		case "/v3/watch":
+			var request struct {
+				CreateRequest struct {
+					StartRevision string `json:"start_revision"`
+				} `json:"create_request"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if request.CreateRequest.StartRevision != "41" {
+				t.Errorf("Expected watch from revision 41, got %s", request.CreateRequest.StartRevision)
+			}
+			fmt.Fprintln(w, `{"result": {"header": {"revision": "42"}, "created": true}}`)
+			fmt.Fprintln(w, `{"result": {"header": {"revision": "43"}, "events": [{"kv": {"mod_revision": "43"}}]}}`)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewRemoteSource(types.RemoteConfig{
+		Provider:  RemoteEtcd,
+		Endpoints: []string{"http://127.0.0.1:1", server.URL},
+		Key:       "/proxy/config",
+		Username:  "proxy",
+		Password:  "secret",
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRemoteSource failed: %v", err)
+	}
+
+	document, version, err := source.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(document) != "server:\n  port: 9000\n" || version != 40 {
+		t.Errorf("Unexpected document %q at version %d", document, version)
+	}
+
+	changed, err := source.Watch(context.Background(), version)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if changed != 43 {
+		t.Errorf("Expected version 43, got %d", changed)
+	}
+}
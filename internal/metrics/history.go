@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// History keeps a fixed-size ring of periodic samples so operators can look
+// back at recent pool and traffic behaviour without external monitoring.
+type History struct {
+	mutex   sync.RWMutex
+	samples []types.HistorySample
+	next    int
+	full    bool
+}
+
+// NewHistory creates a history holding up to capacity samples.
+func NewHistory(capacity int) *History {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &History{samples: make([]types.HistorySample, capacity)}
+}
+
+// Add appends a sample, overwriting the oldest once the ring is full.
+func (h *History) Add(sample types.HistorySample) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Since returns up to limit samples taken strictly after since, oldest
+// first. A limit of zero or less returns all of them.
+func (h *History) Since(since time.Time, limit int) []types.HistorySample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	start, count := 0, h.next
+	if h.full {
+		start, count = h.next, len(h.samples)
+	}
+
+	result := make([]types.HistorySample, 0)
+	for i := 0; i < count; i++ {
+		sample := h.samples[(start+i)%len(h.samples)]
+		if !sample.Timestamp.After(since) {
+			continue
+		}
+		result = append(result, sample)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// Len returns the number of samples held.
+func (h *History) Len() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.full {
+		return len(h.samples)
+	}
+	return h.next
+}
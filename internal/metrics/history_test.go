@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestHistoryRing(t *testing.T) {
+	history := NewHistory(3)
+	base := time.Now()
+
+	for i := 0; i < 5; i++ {
+		history.Add(types.HistorySample{Timestamp: base.Add(time.Duration(i) * time.Minute), Commands: uint64(i)})
+	}
+
+	if history.Len() != 3 {
+		t.Errorf("Expected 3 samples, got %d", history.Len())
+	}
+
+	samples := history.Since(time.Time{}, 0)
+	if len(samples) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(samples))
+	}
+
+	// Oldest two samples were overwritten; the rest come back oldest first
+	for i, sample := range samples {
+		if sample.Commands != uint64(i+2) {
+			t.Errorf("Sample %d: expected commands %d, got %d", i, i+2, sample.Commands)
+		}
+	}
+}
+
+func TestHistorySince(t *testing.T) {
+	history := NewHistory(10)
+	base := time.Now()
+
+	for i := 0; i < 4; i++ {
+		history.Add(types.HistorySample{Timestamp: base.Add(time.Duration(i) * time.Minute)})
+	}
+
+	samples := history.Since(base.Add(time.Minute), 0)
+	if len(samples) != 2 {
+		t.Errorf("Expected 2 samples after the second, got %d", len(samples))
+	}
+
+	limited := history.Since(time.Time{}, 3)
+	if len(limited) != 3 {
+		t.Errorf("Expected limit of 3 samples, got %d", len(limited))
+	}
+
+	if len(NewHistory(5).Since(time.Time{}, 0)) != 0 {
+		t.Error("Expected empty history to return no samples")
+	}
+}
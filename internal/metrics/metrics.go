@@ -5,11 +5,14 @@ import (
 	"net/http"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/scaler/serverless-redis/internal/auth"
+	"github.com/scaler/serverless-redis/internal/server"
 	"github.com/scaler/serverless-redis/internal/types"
 )
 
@@ -23,21 +26,84 @@ type Collector struct {
 	redisCommands   *prometheus.CounterVec
 	redisLatency    *prometheus.HistogramVec
 	redisErrors     *prometheus.CounterVec
+	redisRetries    *prometheus.CounterVec
+	
+	// Latency-aware routing between Redis and DragonflyDB
+	routes        *prometheus.CounterVec
+	routeLatency  *prometheus.GaugeVec
+	routeSwitches *prometheus.CounterVec
 	
 	// Connection pool metrics
 	poolConnections *prometheus.GaugeVec
 	poolHits        *prometheus.CounterVec
 	poolMisses      *prometheus.CounterVec
+	poolInUse       *prometheus.GaugeVec
+	poolSaturation  *prometheus.GaugeVec
+	
+	// Last cumulative hits and misses seen per pool
+	poolMutex  sync.Mutex
+	poolTotals map[string]map[string]int
+	
+	// Adaptive concurrency metrics
+	concurrencyLimit    prometheus.Gauge
+	concurrencyInFlight prometheus.Gauge
+	concurrencyRejected *prometheus.CounterVec
+	
+	// Value size metrics
+	bigValues     *prometheus.CounterVec
+	valueTooLarge *prometheus.CounterVec
+	keyTooLarge   *prometheus.CounterVec
+	
+	// Requests over the tenant's rate limit, and the cost let through
+	rateLimited *prometheus.CounterVec
+	commandCost *prometheus.CounterVec
+	
+	// Delayed task deliveries by target and outcome
+	delayedTasks *prometheus.CounterVec
+	
+	// Requests using deprecated endpoints or options
+	deprecatedRequests *prometheus.CounterVec
+	
+	// Faults injected into commands for testing
+	injectedFaults *prometheus.CounterVec
+	
+	// Failed authentications and client lockouts
+	authEvents *prometheus.CounterVec
+	
+	// Writes queued in the op log while Redis was unreachable
+	opLogWrites  *prometheus.CounterVec
+	opLogPending prometheus.Gauge
+	
+	// Requests and commands above the slow log thresholds
+	slowEntries *prometheus.CounterVec
 	
 	// System metrics
 	memoryUsage     prometheus.Gauge
 	goroutines      prometheus.Gauge
 	uptime          prometheus.Gauge
+	maintenance     prometheus.Gauge
 	
 	startTime       time.Time
+	
+	// Running totals behind the stats history
+	commandCount    atomic.Uint64
+	commandErrors   atomic.Uint64
+	lastSample      historyCounters
+	history         *History
+	
+	tenants *tenantLabeler
+}
+
+type historyCounters struct {
+	at       time.Time
+	commands uint64
+	errors   uint64
 }
 
-func NewCollector() *Collector {
+// defaultRedisBuckets are the Redis latency buckets unless configured.
+var defaultRedisBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0}
+
+func NewCollector(config types.MetricsConfig) *Collector {
 	return &Collector{
 		httpRequests: promauto.NewCounterVec(
 			prometheus.CounterOpts{
@@ -48,11 +114,10 @@ func NewCollector() *Collector {
 		),
 		
 		httpDuration: promauto.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "redis_proxy_http_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
+			histogramOpts(config.Histograms, config.Histograms.HTTPBuckets, prometheus.DefBuckets, prometheus.HistogramOpts{
+				Name: "redis_proxy_http_duration_seconds",
+				Help: "HTTP request duration in seconds",
+			}),
 			[]string{"method", "endpoint", "tenant"},
 		),
 		
@@ -73,11 +138,10 @@ func NewCollector() *Collector {
 		),
 		
 		redisLatency: promauto.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "redis_proxy_redis_latency_seconds",
-				Help:    "Redis command latency in seconds",
-				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0},
-			},
+			histogramOpts(config.Histograms, config.Histograms.RedisBuckets, defaultRedisBuckets, prometheus.HistogramOpts{
+				Name: "redis_proxy_redis_latency_seconds",
+				Help: "Redis command latency in seconds",
+			}),
 			[]string{"command", "tenant"},
 		),
 		
@@ -89,6 +153,38 @@ func NewCollector() *Collector {
 			[]string{"command", "error_type", "tenant"},
 		),
 		
+		redisRetries: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_redis_retries_total",
+				Help: "Total number of retries of read commands after transient Redis errors, and of transactions aborted by WATCH",
+			},
+			[]string{"command", "outcome"},
+		),
+		
+		routes: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_routes_total",
+				Help: "Total number of commands routed to each backend, by reason (faster, explore, warmup, static)",
+			},
+			[]string{"command", "backend", "reason"},
+		),
+		
+		routeLatency: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_route_latency_seconds",
+				Help: "Average latency of each command on each backend, as used for routing",
+			},
+			[]string{"command", "backend"},
+		),
+		
+		routeSwitches: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_route_switches_total",
+				Help: "Total number of times a command's preferred backend changed, by new backend",
+			},
+			[]string{"command", "backend"},
+		),
+		
 		poolConnections: promauto.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "redis_proxy_pool_connections",
@@ -113,6 +209,135 @@ func NewCollector() *Collector {
 			[]string{"pool"},
 		),
 		
+		poolInUse: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_pool_in_use",
+				Help: "Current number of pool connections checked out by commands",
+			},
+			[]string{"pool"},
+		),
+		
+		poolSaturation: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_pool_saturation",
+				Help: "Fraction of the pool's maximum connections in use, from 0 to 1",
+			},
+			[]string{"pool"},
+		),
+		
+		concurrencyLimit: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_concurrency_limit",
+				Help: "Current adaptive limit on in-flight requests",
+			},
+		),
+		
+		concurrencyInFlight: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_concurrency_in_flight",
+				Help: "Current number of requests holding a concurrency slot",
+			},
+		),
+		
+		concurrencyRejected: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_concurrency_rejected_total",
+				Help: "Total number of requests rejected by the adaptive concurrency limit",
+			},
+			[]string{"tier"},
+		),
+		
+		bigValues: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_big_values_total",
+				Help: "Total number of values read or written above the big value threshold",
+			},
+			[]string{"direction", "tenant"},
+		),
+		
+		valueTooLarge: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_value_too_large_total",
+				Help: "Total number of writes rejected for exceeding the value size limit",
+			},
+			[]string{"tenant"},
+		),
+		keyTooLarge: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_key_too_large_total",
+				Help: "Total number of writes rejected for exceeding the key length limit",
+			},
+			[]string{"tenant"},
+		),
+		rateLimited: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_rate_limited_total",
+				Help: "Total number of requests rejected for exceeding the tenant's rate limit",
+			},
+			[]string{"tenant"},
+		),
+		commandCost: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_command_cost_total",
+				Help: "Total rate limit cost of the commands let through",
+			},
+			[]string{"tenant"},
+		),
+		delayedTasks: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_delayed_tasks_total",
+				Help: "Total number of delayed tasks enqueued, delivered, retried or dropped",
+			},
+			[]string{"target", "outcome"},
+		),
+		
+		deprecatedRequests: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_deprecated_requests_total",
+				Help: "Total number of requests to deprecated endpoints or using deprecated options",
+			},
+			[]string{"path", "option", "tenant"},
+		),
+		
+		injectedFaults: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_injected_faults_total",
+				Help: "Total number of faults injected into commands by fault injection",
+			},
+			[]string{"fault", "command"},
+		),
+		
+		authEvents: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_auth_events_total",
+				Help: "Total number of failed authentications, client lockouts and requests refused while locked out",
+			},
+			[]string{"event"},
+		),
+		
+		opLogWrites: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_oplog_writes_total",
+				Help: "Total number of writes queued in the op log, replayed, failed on replay or rejected because it was full",
+			},
+			[]string{"outcome"},
+		),
+		
+		opLogPending: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_oplog_pending",
+				Help: "Current number of writes in the op log waiting to be replayed",
+			},
+		),
+		
+		slowEntries: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "redis_proxy_slow_total",
+				Help: "Total number of requests and Redis commands above the slow log thresholds",
+			},
+			[]string{"kind", "tenant"},
+		),
+		
 		memoryUsage: promauto.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "redis_proxy_memory_usage_bytes",
@@ -134,49 +359,150 @@ func NewCollector() *Collector {
 			},
 		),
 		
+		maintenance: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "redis_proxy_maintenance_active",
+				Help: "1 while a maintenance window is active, 0 otherwise",
+			},
+		),
+		
 		startTime: time.Now(),
+		lastSample: historyCounters{at: time.Now()},
+		history:    NewHistory(1440), // 24h of one-minute samples
+		tenants:    newTenantLabeler(types.TenantMetricsConfig{}),
 	}
 }
 
-func (c *Collector) RecordHTTPRequest(method, endpoint, status string, tenant *types.Tenant, duration time.Duration) {
-	tenantID := "unknown"
-	if tenant != nil {
-		tenantID = tenant.ID
+// histogramOpts completes opts with the configured buckets, or fallback,
+// and the native histogram settings.
+func histogramOpts(config types.HistogramConfig, buckets, fallback []float64, opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+	opts.Buckets = fallback
+	if len(buckets) > 0 {
+		opts.Buckets = buckets
+	}
+	if config.Native {
+		opts.NativeHistogramBucketFactor = config.NativeBucketFactor
+		opts.NativeHistogramMaxBucketNumber = config.NativeMaxBuckets
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return opts
+}
+
+// SetHistoryCapacity replaces the stats history with an empty one holding
+// capacity samples.
+func (c *Collector) SetHistoryCapacity(capacity int) {
+	c.history = NewHistory(capacity)
+}
+
+// SetTenantLabels sets how tenants are labeled in per-tenant metrics.
+func (c *Collector) SetTenantLabels(config types.TenantMetricsConfig) {
+	c.tenants = newTenantLabeler(config)
+}
+
+// History returns the rolling stats history.
+func (c *Collector) History() *History {
+	return c.history
+}
+
+// RecordHistorySample adds a sample to the stats history covering the
+// interval since the previous call, with the given pool stats.
+func (c *Collector) RecordHistorySample(pool map[string]int) {
+	now := time.Now()
+	current := historyCounters{
+		at:       now,
+		commands: c.commandCount.Load(),
+		errors:   c.commandErrors.Load(),
+	}
+
+	sample := types.HistorySample{
+		Timestamp:     now,
+		Commands:      current.commands - c.lastSample.commands,
+		CommandErrors: current.errors - c.lastSample.errors,
+		Pool:          pool,
+	}
+
+	if elapsed := now.Sub(c.lastSample.at).Seconds(); elapsed > 0 {
+		sample.QPS = float64(sample.Commands) / elapsed
+	}
+	if sample.Commands > 0 {
+		sample.ErrorRate = float64(sample.CommandErrors) / float64(sample.Commands)
 	}
+
+	c.lastSample = current
+	c.history.Add(sample)
+}
+
+func (c *Collector) RecordHTTPRequest(method, endpoint, status string, tenant *types.Tenant, duration time.Duration) {
+	tenantID := c.tenants.label(tenant)
 	
 	c.httpRequests.WithLabelValues(method, endpoint, status, tenantID).Inc()
 	c.httpDuration.WithLabelValues(method, endpoint, tenantID).Observe(duration.Seconds())
 }
 
 func (c *Collector) RecordHTTPError(method, endpoint, errorType string, tenant *types.Tenant) {
-	tenantID := "unknown"
-	if tenant != nil {
-		tenantID = tenant.ID
-	}
+	tenantID := c.tenants.label(tenant)
 	
 	c.httpErrors.WithLabelValues(method, endpoint, errorType, tenantID).Inc()
 }
 
 func (c *Collector) RecordRedisCommand(command, status string, tenant *types.Tenant, duration time.Duration) {
-	tenantID := "unknown"
-	if tenant != nil {
-		tenantID = tenant.ID
-	}
+	tenantID := c.tenants.label(tenant)
 	
+	c.commandCount.Add(1)
 	c.redisCommands.WithLabelValues(command, status, tenantID).Inc()
 	c.redisLatency.WithLabelValues(command, tenantID).Observe(duration.Seconds())
 }
 
 func (c *Collector) RecordRedisError(command, errorType string, tenant *types.Tenant) {
-	tenantID := "unknown"
-	if tenant != nil {
-		tenantID = tenant.ID
-	}
+	tenantID := c.tenants.label(tenant)
 	
+	c.commandErrors.Add(1)
 	c.redisErrors.WithLabelValues(command, errorType, tenantID).Inc()
 }
 
+// RecordRedisRetries counts the retries made for one command. The outcome
+// is "recovered" if a retry succeeded and "exhausted" otherwise.
+func (c *Collector) RecordRedisRetries(command string, retries int, err error) {
+	outcome := "recovered"
+	if err != nil {
+		outcome = "exhausted"
+	}
+	
+	c.redisRetries.WithLabelValues(command, outcome).Add(float64(retries))
+}
+
+// RecordRoute counts a command routed to backend and why.
+func (c *Collector) RecordRoute(command, backend, reason string) {
+	c.routes.WithLabelValues(command, backend, reason).Inc()
+}
+
+// RecordRouteLatency publishes the average latency routing uses for
+// command on backend.
+func (c *Collector) RecordRouteLatency(command, backend string, average time.Duration) {
+	c.routeLatency.WithLabelValues(command, backend).Set(average.Seconds())
+}
+
+// RecordRouteSwitch counts command's traffic moving to backend.
+func (c *Collector) RecordRouteSwitch(command, backend string) {
+	c.routeSwitches.WithLabelValues(command, backend).Inc()
+}
+
+// UpdatePoolStats publishes a pool's connection counts. hits and misses
+// are the pool's cumulative totals; only their growth since the previous
+// call is added to the counters.
 func (c *Collector) UpdatePoolStats(poolName string, stats map[string]int) {
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	
+	if c.poolTotals == nil {
+		c.poolTotals = make(map[string]map[string]int)
+	}
+	last, ok := c.poolTotals[poolName]
+	if !ok {
+		last = make(map[string]int)
+		c.poolTotals[poolName] = last
+	}
+	
 	for statName, value := range stats {
 		switch statName {
 		case "total_conns":
@@ -186,13 +512,128 @@ func (c *Collector) UpdatePoolStats(poolName string, stats map[string]int) {
 		case "stale_conns":
 			c.poolConnections.WithLabelValues(poolName, "stale").Set(float64(value))
 		case "hits":
-			c.poolHits.WithLabelValues(poolName).Add(float64(value))
+			c.poolHits.WithLabelValues(poolName).Add(float64(poolDelta(last, statName, value)))
 		case "misses":
-			c.poolMisses.WithLabelValues(poolName).Add(float64(value))
+			c.poolMisses.WithLabelValues(poolName).Add(float64(poolDelta(last, statName, value)))
 		}
 	}
 }
 
+// poolDelta returns how much a cumulative pool stat grew since last, and
+// remembers value. A smaller value means the pool was recreated, so all of
+// it is new.
+func poolDelta(last map[string]int, stat string, value int) int {
+	delta := value - last[stat]
+	if delta < 0 {
+		delta = value
+	}
+	last[stat] = value
+	return delta
+}
+
+// UpdatePoolUsage records how many of a pool's max connections are in use,
+// so autoscalers can add capacity before requests start being rejected.
+func (c *Collector) UpdatePoolUsage(pool string, inUse, max int) {
+	c.poolInUse.WithLabelValues(pool).Set(float64(inUse))
+	
+	saturation := 0.0
+	if max > 0 {
+		saturation = float64(inUse) / float64(max)
+	}
+	c.poolSaturation.WithLabelValues(pool).Set(saturation)
+}
+
+// UpdateConcurrency records the adaptive concurrency limit and how much of
+// it is in use.
+func (c *Collector) UpdateConcurrency(limit, inFlight int) {
+	c.concurrencyLimit.Set(float64(limit))
+	c.concurrencyInFlight.Set(float64(inFlight))
+}
+
+// RecordConcurrencyRejected counts a request of the given tier shed by the
+// concurrency limit.
+func (c *Collector) RecordConcurrencyRejected(tier string) {
+	c.concurrencyRejected.WithLabelValues(tier).Inc()
+}
+
+// RecordBigValue counts a value above the big value threshold.
+func (c *Collector) RecordBigValue(write bool, tenant *types.Tenant) {
+	tenantID := c.tenants.label(tenant)
+	
+	direction := "read"
+	if write {
+		direction = "write"
+	}
+	c.bigValues.WithLabelValues(direction, tenantID).Inc()
+}
+
+// RecordValueTooLarge counts a write rejected by the value size limit.
+func (c *Collector) RecordValueTooLarge(tenant *types.Tenant) {
+	tenantID := c.tenants.label(tenant)
+	
+	c.valueTooLarge.WithLabelValues(tenantID).Inc()
+}
+
+// RecordRateLimit counts a request of the given cost let through by the
+// rate limit, or one rejected by it.
+func (c *Collector) RecordRateLimit(tenant *types.Tenant, cost int, allowed bool) {
+	tenantID := c.tenants.label(tenant)
+	
+	if !allowed {
+		c.rateLimited.WithLabelValues(tenantID).Inc()
+		return
+	}
+	c.commandCost.WithLabelValues(tenantID).Add(float64(cost))
+}
+
+// RecordDelayedTask counts a delayed task enqueued, or the outcome of a
+// delivery attempt, by target.
+func (c *Collector) RecordDelayedTask(target, outcome string) {
+	c.delayedTasks.WithLabelValues(target, outcome).Inc()
+}
+
+// RecordDeprecated counts a request matching the deprecation rule for path
+// and option, which is empty for a whole endpoint.
+func (c *Collector) RecordDeprecated(path, option string, tenant *types.Tenant) {
+	tenantID := c.tenants.label(tenant)
+	
+	c.deprecatedRequests.WithLabelValues(path, option, tenantID).Inc()
+}
+
+// RecordInjectedFault counts a latency, error or drop fault injected into
+// command.
+func (c *Collector) RecordInjectedFault(fault, command string) {
+	c.injectedFaults.WithLabelValues(fault, command).Inc()
+}
+
+// RecordAuthEvent counts a failed authentication, a client lockout or a
+// request refused while locked out.
+func (c *Collector) RecordAuthEvent(event string) {
+	c.authEvents.WithLabelValues(event).Inc()
+}
+
+// RecordOpLog counts a write queued in the op log, replayed, failed on
+// replay or rejected, and records how many are left waiting.
+func (c *Collector) RecordOpLog(outcome string, pending int) {
+	c.opLogWrites.WithLabelValues(outcome).Inc()
+	c.opLogPending.Set(float64(pending))
+}
+
+// RecordKeyTooLarge counts a write rejected by the key length limit.
+func (c *Collector) RecordKeyTooLarge(tenant *types.Tenant) {
+	tenantID := c.tenants.label(tenant)
+	
+	c.keyTooLarge.WithLabelValues(tenantID).Inc()
+}
+
+// RecordSlow counts a request or command, per kind, above its slow log
+// threshold.
+func (c *Collector) RecordSlow(kind string, tenant *types.Tenant) {
+	tenantID := c.tenants.label(tenant)
+	
+	c.slowEntries.WithLabelValues(kind, tenantID).Inc()
+}
+
 func (c *Collector) UpdateSystemMetrics() {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -202,6 +643,16 @@ func (c *Collector) UpdateSystemMetrics() {
 	c.uptime.Set(time.Since(c.startTime).Seconds())
 }
 
+// SetMaintenanceActive records whether a maintenance window is active, so
+// alert rules can be silenced while it is.
+func (c *Collector) SetMaintenanceActive(active bool) {
+	if active {
+		c.maintenance.Set(1)
+	} else {
+		c.maintenance.Set(0)
+	}
+}
+
 func (c *Collector) GetMemoryStats() types.MemoryStats {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -254,6 +705,11 @@ func (c *Collector) HTTPMetricsMiddleware(next http.Handler) http.Handler {
 		
 		if wrapped.statusCode >= 400 {
 			errorType := getErrorTypeFromStatus(wrapped.statusCode)
+			// Rejections caused by planned maintenance aren't incidents;
+			// keep them out of the error types alerts are built on
+			if wrapped.Header().Get(server.MaintenanceHeader) != "" {
+				errorType = "maintenance"
+			}
 			c.RecordHTTPError(r.Method, endpoint, errorType, tenant)
 		}
 	})
@@ -269,6 +725,17 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush keeps streaming handlers working behind the middleware.
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func statusCodeToString(code int) string {
 	switch {
 	case code >= 200 && code < 300:
@@ -310,6 +777,14 @@ func getErrorTypeFromStatus(code int) string {
 }
 
 func getEndpointFromPath(path string) string {
+	// /v2 serves the /v1 routes in an envelope
+	if strings.HasPrefix(path, "/v2/") {
+		if endpoint := getEndpointFromPath("/v1" + path[3:]); endpoint != "other" {
+			return "/v2" + endpoint[3:]
+		}
+		return "other"
+	}
+
 	switch {
 	case strings.HasPrefix(path, "/v1/command"):
 		return "/v1/command"
@@ -317,6 +792,16 @@ func getEndpointFromPath(path string) string {
 		return "/v1/pipeline"
 	case strings.HasPrefix(path, "/v1/transaction"):
 		return "/v1/transaction"
+	case strings.HasPrefix(path, "/v1/set"):
+		return "/v1/set"
+	case strings.HasPrefix(path, "/v1/scan"):
+		return "/v1/scan"
+	case strings.HasPrefix(path, "/v1/unique"):
+		return "/v1/unique"
+	case strings.HasPrefix(path, "/v1/keys"):
+		return "/v1/keys"
+	case strings.HasPrefix(path, "/admin"):
+		return "/admin"
 	case strings.HasPrefix(path, "/health"):
 		return "/health"
 	case strings.HasPrefix(path, "/metrics"):
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestHistogramOpts(t *testing.T) {
+	opts := histogramOpts(types.HistogramConfig{}, nil, defaultRedisBuckets, prometheus.HistogramOpts{Name: "latency"})
+	if !reflect.DeepEqual(opts.Buckets, defaultRedisBuckets) || opts.NativeHistogramBucketFactor != 0 {
+		t.Errorf("Expected the default buckets only, got %+v", opts)
+	}
+
+	config := types.HistogramConfig{
+		RedisBuckets:       []float64{0.0001, 0.0005, 0.001},
+		Native:             true,
+		NativeBucketFactor: 1.1,
+		NativeMaxBuckets:   100,
+	}
+	opts = histogramOpts(config, config.RedisBuckets, defaultRedisBuckets, prometheus.HistogramOpts{Name: "latency"})
+	if !reflect.DeepEqual(opts.Buckets, config.RedisBuckets) {
+		t.Errorf("Expected configured buckets, got %v", opts.Buckets)
+	}
+	if opts.NativeHistogramBucketFactor != 1.1 || opts.NativeHistogramMaxBucketNumber != 100 {
+		t.Errorf("Expected native histogram settings, got %+v", opts)
+	}
+}
+
+func TestUpdatePoolStatsAddsDeltas(t *testing.T) {
+	c := &Collector{
+		poolConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "connections"}, []string{"pool", "state"}),
+		poolHits:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "hits"}, []string{"pool"}),
+		poolMisses:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "misses"}, []string{"pool"}),
+	}
+
+	c.UpdatePoolStats("primary", map[string]int{"hits": 10, "misses": 2, "total_conns": 4})
+	c.UpdatePoolStats("primary", map[string]int{"hits": 15, "misses": 2, "total_conns": 3})
+	if hits := testutil.ToFloat64(c.poolHits.WithLabelValues("primary")); hits != 15 {
+		t.Errorf("Expected 15 hits, got %v", hits)
+	}
+	if misses := testutil.ToFloat64(c.poolMisses.WithLabelValues("primary")); misses != 2 {
+		t.Errorf("Expected 2 misses, got %v", misses)
+	}
+	if total := testutil.ToFloat64(c.poolConnections.WithLabelValues("primary", "total")); total != 3 {
+		t.Errorf("Expected 3 connections, got %v", total)
+	}
+
+	// A recreated pool starts counting from zero again
+	c.UpdatePoolStats("primary", map[string]int{"hits": 3})
+	if hits := testutil.ToFloat64(c.poolHits.WithLabelValues("primary")); hits != 18 {
+		t.Errorf("Expected 18 hits after the pool was recreated, got %v", hits)
+	}
+}
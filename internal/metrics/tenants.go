@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// tenantLabeler picks the tenant label value of per-tenant metrics, so that
+// many tenants don't mean as many series.
+type tenantLabeler struct {
+	config    types.TenantMetricsConfig
+	allowlist map[string]bool
+}
+
+func newTenantLabeler(config types.TenantMetricsConfig) *tenantLabeler {
+	allowlist := make(map[string]bool, len(config.Allowlist))
+	for _, id := range config.Allowlist {
+		allowlist[id] = true
+	}
+	return &tenantLabeler{config: config, allowlist: allowlist}
+}
+
+func (l *tenantLabeler) label(tenant *types.Tenant) string {
+	if tenant == nil {
+		return "unknown"
+	}
+
+	switch {
+	case l.config.Label == types.TenantLabelID || l.config.Label == "" || l.allowlist[tenant.ID]:
+		return tenant.ID
+	case l.config.Label == types.TenantLabelNone:
+		return ""
+	}
+
+	if group, ok := l.config.Groups[tenant.ID]; ok {
+		return group
+	}
+	if l.config.Buckets > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(tenant.ID))
+		return "bucket-" + strconv.Itoa(int(h.Sum32()%uint32(l.config.Buckets)))
+	}
+	return "other"
+}
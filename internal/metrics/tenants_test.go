@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTenantLabeler(t *testing.T) {
+	vip := &types.Tenant{ID: "vip"}
+	acme := &types.Tenant{ID: "acme"}
+	other := &types.Tenant{ID: "tenant-42"}
+
+	tests := []struct {
+		name   string
+		config types.TenantMetricsConfig
+		tenant *types.Tenant
+		want   string
+	}{
+		{"default is the ID", types.TenantMetricsConfig{}, other, "tenant-42"},
+		{"no tenant", types.TenantMetricsConfig{Label: types.TenantLabelNone}, nil, "unknown"},
+		{"none", types.TenantMetricsConfig{Label: types.TenantLabelNone}, other, ""},
+		{"none keeps allowlisted", types.TenantMetricsConfig{Label: types.TenantLabelNone, Allowlist: []string{"vip"}}, vip, "vip"},
+		{"group", types.TenantMetricsConfig{Label: types.TenantLabelGroup, Groups: map[string]string{"acme": "enterprise"}}, acme, "enterprise"},
+		{"ungrouped is other", types.TenantMetricsConfig{Label: types.TenantLabelGroup}, other, "other"},
+		{"allowlist beats group", types.TenantMetricsConfig{Label: types.TenantLabelGroup, Groups: map[string]string{"vip": "enterprise"}, Allowlist: []string{"vip"}}, vip, "vip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTenantLabeler(tt.config).label(tt.tenant); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Ungrouped tenants spread over the buckets, each always in the same one
+	labeler := newTenantLabeler(types.TenantMetricsConfig{Label: types.TenantLabelGroup, Buckets: 4})
+	label := labeler.label(other)
+	if !strings.HasPrefix(label, "bucket-") || labeler.label(other) != label {
+		t.Errorf("Expected a stable bucket label, got %q", label)
+	}
+	seen := make(map[string]bool)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		seen[labeler.label(&types.Tenant{ID: id})] = true
+	}
+	if len(seen) > 4 {
+		t.Errorf("Expected at most 4 buckets, got %v", seen)
+	}
+}
@@ -0,0 +1,457 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	// maxQueuedErrors bounds the events waiting to be sent; more are
+	// dropped while Sentry or the webhook is slow or down
+	maxQueuedErrors = 100
+
+	// maxErrorBody is how much of a 5xx response body is kept to find its
+	// error message
+	maxErrorBody = 8 << 10
+
+	redacted = "[redacted]"
+)
+
+// sensitiveNames are matched against header and query parameter names,
+// lower case, to keep credentials out of reports.
+var sensitiveNames = []string{"authorization", "cookie", "token", "secret", "password", "key", "session", "signature"}
+
+// ErrorEvent is a panic, 5xx response or client lockout, as posted to the
+// webhook.
+type ErrorEvent struct {
+	EventID     string       `json:"event_id"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Kind        string       `json:"kind"` // "panic", "http_error" or "auth_lockout"
+	Level       string       `json:"level"`
+	Message     string       `json:"message"`
+	Code        string       `json:"code,omitempty"`
+	Status      int          `json:"status"`
+	Stack       string       `json:"stack,omitempty"`
+	Tenant      string       `json:"tenant,omitempty"`
+	TraceID     string       `json:"trace_id,omitempty"`
+	Request     ErrorRequest `json:"request"`
+	Release     string       `json:"release"`
+	Environment string       `json:"environment,omitempty"`
+	ServerName  string       `json:"server_name,omitempty"`
+}
+
+// ErrorRequest is the request an error happened on, with credentials in
+// headers and query parameters redacted. Bodies are never included.
+type ErrorRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Query   string            `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ErrorReporter sends panics and 5xx responses to Sentry and/or a webhook
+// from a bounded queue, so reporting never blocks requests.
+type ErrorReporter struct {
+	config     types.ErrorReportingConfig
+	client     *http.Client
+	sentry     *sentryTarget
+	release    string
+	serverName string
+
+	queue    chan ErrorEvent
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewErrorReporter creates a reporter for config, tagging events with the
+// proxy's version as release.
+func NewErrorReporter(config types.ErrorReportingConfig, version string) (*ErrorReporter, error) {
+	r := &ErrorReporter{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		release: "serverless-redis@" + version,
+		queue:   make(chan ErrorEvent, maxQueuedErrors),
+		done:    make(chan struct{}),
+	}
+	r.serverName, _ = os.Hostname()
+
+	if config.SentryDSN != "" {
+		target, err := parseSentryDSN(config.SentryDSN, version)
+		if err != nil {
+			return nil, err
+		}
+		r.sentry = target
+	}
+	return r, nil
+}
+
+// Start sends queued events until Shutdown.
+func (r *ErrorReporter) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.done:
+				return
+			case event := <-r.queue:
+				ctx, cancel := context.WithTimeout(context.Background(), r.config.Timeout)
+				r.send(ctx, event)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Shutdown stops the sender and sends the events still queued, until ctx
+// is done.
+func (r *ErrorReporter) Shutdown(ctx context.Context) {
+	r.stopOnce.Do(func() { close(r.done) })
+	r.wg.Wait()
+	for {
+		select {
+		case event := <-r.queue:
+			r.send(ctx, event)
+		default:
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Report queues event, filling in what the reporter knows. It is dropped
+// if the queue is full.
+func (r *ErrorReporter) Report(event ErrorEvent) {
+	event.EventID = randomHex(16)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.Release = r.release
+	event.Environment = r.config.Environment
+	event.ServerName = r.serverName
+
+	select {
+	case r.queue <- event:
+	default:
+	}
+}
+
+// errorContext collects what handlers learn about a request for its
+// report, and whether a panic was already reported for it.
+type errorContext struct {
+	mutex    sync.Mutex
+	tenant   string
+	reported bool
+}
+
+type errorContextKey struct{}
+
+// SetErrorTenant records the tenant of the request for error reports.
+func SetErrorTenant(ctx context.Context, tenant string) {
+	if ec, ok := ctx.Value(errorContextKey{}).(*errorContext); ok {
+		ec.mutex.Lock()
+		ec.tenant = tenant
+		ec.mutex.Unlock()
+	}
+}
+
+// Middleware reports requests answered with 5xx, sampled by SampleRate.
+// It must wrap the panic recovery middleware, so that a panic reported by
+// ReportPanic isn't reported again as the 500 it turns into.
+func (r *ErrorReporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ec := &errorContext{}
+		recorder := &errorRecorder{ResponseWriter: w, status: http.StatusOK}
+		req = req.WithContext(context.WithValue(req.Context(), errorContextKey{}, ec))
+		next.ServeHTTP(recorder, req)
+
+		ec.mutex.Lock()
+		reported, tenant := ec.reported, ec.tenant
+		ec.mutex.Unlock()
+		if reported || recorder.status < 500 {
+			return
+		}
+		if r.config.SampleRate < 1 && rand.Float64() >= r.config.SampleRate {
+			return
+		}
+
+		var response types.ErrorResponse
+		_ = json.Unmarshal(recorder.body.Bytes(), &response)
+		message := response.Error
+		if response.Details != "" {
+			message += ": " + response.Details
+		}
+		if message == "" {
+			message = http.StatusText(recorder.status)
+		}
+
+		r.Report(ErrorEvent{
+			Kind:    "http_error",
+			Level:   "error",
+			Message: message,
+			Code:    response.Code,
+			Status:  recorder.status,
+			Tenant:  tenant,
+			TraceID: TraceIDFromContext(req.Context()),
+			Request: errorRequest(req),
+		})
+	})
+}
+
+// ReportPanic reports a panic recovered while serving req, with the stack
+// of the goroutine that panicked.
+func (r *ErrorReporter) ReportPanic(req *http.Request, value interface{}, stack []byte) {
+	tenant := ""
+	if ec, ok := req.Context().Value(errorContextKey{}).(*errorContext); ok {
+		ec.mutex.Lock()
+		ec.reported = true
+		tenant = ec.tenant
+		ec.mutex.Unlock()
+	}
+
+	r.Report(ErrorEvent{
+		Kind:    "panic",
+		Level:   "fatal",
+		Message: fmt.Sprint(value),
+		Status:  http.StatusInternalServerError,
+		Stack:   string(stack),
+		Tenant:  tenant,
+		TraceID: TraceIDFromContext(req.Context()),
+		Request: errorRequest(req),
+	})
+}
+
+func (r *ErrorReporter) send(ctx context.Context, event ErrorEvent) {
+	if r.sentry != nil {
+		r.post(ctx, r.sentry.storeURL, map[string]string{"X-Sentry-Auth": r.sentry.auth}, sentryPayload(event))
+	}
+	if r.config.WebhookURL != "" {
+		r.post(ctx, r.config.WebhookURL, r.config.WebhookHeaders, event)
+	}
+}
+
+// post sends payload as JSON. Failures are dropped: logging them would
+// feed the log exporter with more errors while the target is down.
+func (r *ErrorReporter) post(ctx context.Context, target string, headers map[string]string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// errorRequest describes req without its credentials.
+func errorRequest(req *http.Request) ErrorRequest {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	query := req.URL.Query()
+	for name := range query {
+		if isSensitive(name) {
+			query[name] = []string{redacted}
+		}
+	}
+
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if isSensitive(name) {
+			value = redacted
+		}
+		headers[name] = value
+	}
+
+	return ErrorRequest{
+		Method:  req.Method,
+		URL:     scheme + "://" + req.Host + req.URL.Path,
+		Query:   query.Encode(),
+		Headers: headers,
+	}
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorRecorder records the status of a response and the start of its
+// body if it is a server error.
+type errorRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (er *errorRecorder) WriteHeader(code int) {
+	if !er.wroteHeader {
+		er.status = code
+		er.wroteHeader = true
+	}
+	er.ResponseWriter.WriteHeader(code)
+}
+
+func (er *errorRecorder) Write(data []byte) (int, error) {
+	er.wroteHeader = true
+	if er.status >= 500 && er.body.Len() < maxErrorBody {
+		er.body.Write(data[:min(len(data), maxErrorBody-er.body.Len())])
+	}
+	return er.ResponseWriter.Write(data)
+}
+
+func (er *errorRecorder) Flush() {
+	if flusher, ok := er.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (er *errorRecorder) Unwrap() http.ResponseWriter {
+	return er.ResponseWriter
+}
+
+// sentryTarget is where and how events are sent to Sentry's store API.
+type sentryTarget struct {
+	storeURL string
+	auth     string
+}
+
+// parseSentryDSN turns a DSN, https://key@host/project or with a path
+// before the project, into the project's store endpoint and auth header.
+func parseSentryDSN(dsn, version string) (*sentryTarget, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	path := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || parsed.Host == "" || path == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: expected https://key@host/project")
+	}
+
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=serverless-redis/%s, sentry_key=%s", version, parsed.User.Username())
+	if secret, ok := parsed.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	return &sentryTarget{
+		storeURL: fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, project),
+		auth:     auth,
+	}, nil
+}
+
+// Sentry event model. Only the fields the proxy sends are declared.
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger"`
+	Release     string                 `json:"release"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Message     string                 `json:"message"`
+	Exception   *sentryExceptions      `json:"exception,omitempty"`
+	Request     sentryRequest          `json:"request"`
+	User        *sentryUser            `json:"user,omitempty"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryRequest struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+func sentryPayload(event ErrorEvent) sentryEvent {
+	payload := sentryEvent{
+		EventID:     event.EventID,
+		Timestamp:   event.Timestamp.UTC().Format(time.RFC3339Nano),
+		Level:       event.Level,
+		Platform:    "go",
+		Logger:      "serverless-redis",
+		Release:     event.Release,
+		Environment: event.Environment,
+		ServerName:  event.ServerName,
+		Message:     event.Message,
+		Request: sentryRequest{
+			URL:         event.Request.URL,
+			Method:      event.Request.Method,
+			QueryString: event.Request.Query,
+			Headers:     event.Request.Headers,
+		},
+		Tags: map[string]string{
+			"kind":   event.Kind,
+			"status": fmt.Sprint(event.Status),
+		},
+	}
+	if event.Code != "" {
+		payload.Tags["code"] = event.Code
+	}
+	if event.TraceID != "" {
+		payload.Tags["trace_id"] = event.TraceID
+	}
+	if event.Tenant != "" {
+		payload.User = &sentryUser{ID: event.Tenant}
+	}
+	if event.Kind == "panic" {
+		payload.Exception = &sentryExceptions{Values: []sentryException{{Type: "panic", Value: event.Message}}}
+		payload.Extra = map[string]interface{}{"stack": event.Stack}
+	}
+	return payload
+}
@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// OTLP severity numbers for the levels the proxy logs at.
+const (
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+)
+
+type logRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           attributeValue `json:"body"`
+}
+
+type logsPayload struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+func (p *Pipeline) logsPayload(logs []logRecord) logsPayload {
+	return logsPayload{ResourceLogs: []resourceLogs{{
+		Resource:  p.resource,
+		ScopeLogs: []scopeLogs{{Scope: scope{Name: scopeName}, LogRecords: logs}},
+	}}}
+}
+
+// logWriter turns each write from the standard logger into a log record.
+type logWriter struct {
+	pipeline *Pipeline
+}
+
+// LogWriter returns a writer for log.SetOutput that exports every line as
+// an OTLP log record. Combine it with io.MultiWriter to keep local output.
+func (p *Pipeline) LogWriter() io.Writer {
+	return &logWriter{pipeline: p}
+}
+
+func (lw *logWriter) Write(data []byte) (int, error) {
+	message := strings.TrimRight(string(data), "\n")
+	severity, text := severityFor(message)
+
+	lw.pipeline.mutex.Lock()
+	lw.pipeline.logs = appendBounded(lw.pipeline.logs, logRecord{
+		TimeUnixNano:   unixNano(time.Now()),
+		SeverityNumber: severity,
+		SeverityText:   text,
+		Body:           attributeValue{StringValue: &message},
+	})
+	lw.pipeline.mutex.Unlock()
+
+	return len(data), nil
+}
+
+// severityFor infers a severity from the message, since the standard
+// logger has no levels.
+func severityFor(message string) (int, string) {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "fatal"), strings.Contains(lower, "error"), strings.Contains(lower, "failed"):
+		return severityError, "ERROR"
+	case strings.Contains(lower, "warn"):
+		return severityWarn, "WARN"
+	default:
+		return severityInfo, "INFO"
+	}
+}
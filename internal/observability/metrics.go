@@ -0,0 +1,165 @@
+package observability
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// OTLP aggregation temporality for cumulative Prometheus counters.
+const temporalityCumulative = 2
+
+type metricsPayload struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Sum         *sum       `json:"sum,omitempty"`
+	Gauge       *gauge     `json:"gauge,omitempty"`
+	Histogram   *histogram `json:"histogram,omitempty"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type numberDataPoint struct {
+	Attributes        []attribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string      `json:"timeUnixNano"`
+	AsDouble          float64     `json:"asDouble"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []attribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	TimeUnixNano      string      `json:"timeUnixNano"`
+	Count             string      `json:"count"`
+	Sum               float64     `json:"sum"`
+	BucketCounts      []string    `json:"bucketCounts"`
+	ExplicitBounds    []float64   `json:"explicitBounds"`
+}
+
+// metricsPayload converts the gathered Prometheus families into OTLP
+// metrics: counters become monotonic sums, gauges stay gauges and
+// histograms keep their bucket layout. Summaries are not exported.
+func (p *Pipeline) metricsPayload() (metricsPayload, error) {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return metricsPayload{}, err
+	}
+
+	now := unixNano(time.Now())
+	start := unixNano(p.startTime)
+
+	metrics := make([]metric, 0, len(families))
+	for _, family := range families {
+		m := metric{Name: family.GetName(), Description: family.GetHelp()}
+
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			points := make([]numberDataPoint, 0, len(family.Metric))
+			for _, pm := range family.Metric {
+				points = append(points, numberDataPoint{
+					Attributes:        labelAttributes(pm.Label),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      now,
+					AsDouble:          pm.GetCounter().GetValue(),
+				})
+			}
+			m.Sum = &sum{DataPoints: points, AggregationTemporality: temporalityCumulative, IsMonotonic: true}
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			points := make([]numberDataPoint, 0, len(family.Metric))
+			for _, pm := range family.Metric {
+				value := pm.GetGauge().GetValue()
+				if family.GetType() == dto.MetricType_UNTYPED {
+					value = pm.GetUntyped().GetValue()
+				}
+				points = append(points, numberDataPoint{
+					Attributes:   labelAttributes(pm.Label),
+					TimeUnixNano: now,
+					AsDouble:     value,
+				})
+			}
+			m.Gauge = &gauge{DataPoints: points}
+		case dto.MetricType_HISTOGRAM:
+			points := make([]histogramDataPoint, 0, len(family.Metric))
+			for _, pm := range family.Metric {
+				points = append(points, histogramPoint(pm, start, now))
+			}
+			m.Histogram = &histogram{DataPoints: points, AggregationTemporality: temporalityCumulative}
+		default:
+			continue
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return metricsPayload{ResourceMetrics: []resourceMetrics{{
+		Resource:     p.resource,
+		ScopeMetrics: []scopeMetrics{{Scope: scope{Name: scopeName}, Metrics: metrics}},
+	}}}, nil
+}
+
+// histogramPoint converts cumulative Prometheus buckets into OTLP's
+// per-bucket counts, which include a final overflow bucket.
+func histogramPoint(pm *dto.Metric, start, now string) histogramDataPoint {
+	h := pm.GetHistogram()
+
+	bounds := make([]float64, 0, len(h.Bucket))
+	counts := make([]string, 0, len(h.Bucket)+1)
+
+	var previous uint64
+	for _, bucket := range h.Bucket {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		bounds = append(bounds, bucket.GetUpperBound())
+		counts = append(counts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+		previous = bucket.GetCumulativeCount()
+	}
+	counts = append(counts, strconv.FormatUint(h.GetSampleCount()-previous, 10))
+
+	return histogramDataPoint{
+		Attributes:        labelAttributes(pm.Label),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      now,
+		Count:             strconv.FormatUint(h.GetSampleCount(), 10),
+		Sum:               h.GetSampleSum(),
+		BucketCounts:      counts,
+		ExplicitBounds:    bounds,
+	}
+}
+
+func labelAttributes(labels []*dto.LabelPair) []attribute {
+	attributes := make([]attribute, 0, len(labels))
+	for _, label := range labels {
+		attributes = append(attributes, stringAttribute(label.GetName(), label.GetValue()))
+	}
+	return attributes
+}
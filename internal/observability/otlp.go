@@ -0,0 +1,225 @@
+// Package observability exports traces, metrics and logs to a single OTLP
+// collector endpoint using the OTLP/HTTP JSON encoding.
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+const (
+	scopeName = "github.com/scaler/serverless-redis"
+
+	// maxBufferedRecords bounds spans and logs held between exports; older
+	// records are dropped rather than growing memory when the collector is
+	// unreachable.
+	maxBufferedRecords = 10000
+)
+
+// Pipeline batches spans and log records and periodically exports them,
+// together with the Prometheus metrics, to one OTLP collector.
+type Pipeline struct {
+	config   types.OTLPConfig
+	client   *http.Client
+	resource resource
+	gatherer prometheus.Gatherer
+
+	mutex sync.Mutex
+	spans []span
+	logs  []logRecord
+
+	startTime time.Time
+	done      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPipeline creates a pipeline for config. Resource attributes are shared
+// by every exported signal.
+func NewPipeline(config types.OTLPConfig, version string, gatherer prometheus.Gatherer) *Pipeline {
+	attributes := map[string]string{
+		"service.name":    config.ServiceName,
+		"service.version": version,
+	}
+	if config.Region != "" {
+		attributes["cloud.region"] = config.Region
+	}
+	for key, value := range config.ResourceAttributes {
+		attributes[key] = value
+	}
+
+	return &Pipeline{
+		config:    config,
+		client:    &http.Client{Timeout: config.Timeout},
+		resource:  resource{Attributes: toAttributes(attributes)},
+		gatherer:  gatherer,
+		startTime: time.Now(),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start exports buffered data every ExportInterval until Shutdown.
+func (p *Pipeline) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.config.ExportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+				p.Flush(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Shutdown stops periodic exports and flushes whatever is still buffered.
+func (p *Pipeline) Shutdown(ctx context.Context) {
+	p.stopOnce.Do(func() { close(p.done) })
+	p.wg.Wait()
+	p.Flush(ctx)
+}
+
+// Flush exports all buffered spans and logs and a snapshot of the metrics.
+// Export failures are reported on the returned error but otherwise dropped.
+func (p *Pipeline) Flush(ctx context.Context) error {
+	p.mutex.Lock()
+	spans, logs := p.spans, p.logs
+	p.spans, p.logs = nil, nil
+	p.mutex.Unlock()
+
+	var errs []string
+
+	if len(spans) > 0 {
+		if err := p.export(ctx, "/v1/traces", p.tracesPayload(spans)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(logs) > 0 {
+		if err := p.export(ctx, "/v1/logs", p.logsPayload(logs)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if p.gatherer != nil {
+		payload, err := p.metricsPayload()
+		if err == nil {
+			err = p.export(ctx, "/v1/metrics", payload)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("otlp export failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p *Pipeline) export(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.config.Endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range p.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: collector returned %s", path, resp.Status)
+	}
+	return nil
+}
+
+// appendBounded appends to a buffer, dropping the oldest records once full.
+func appendBounded[T any](buffer []T, record T) []T {
+	if len(buffer) >= maxBufferedRecords {
+		buffer = buffer[1:]
+	}
+	return append(buffer, record)
+}
+
+// OTLP JSON model. Only the fields the proxy emits are declared.
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func stringAttribute(key, value string) attribute {
+	return attribute{Key: key, Value: attributeValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) attribute {
+	encoded := strconv.FormatInt(value, 10)
+	return attribute{Key: key, Value: attributeValue{IntValue: &encoded}}
+}
+
+// toAttributes converts a map into attributes sorted by key, keeping the
+// payloads deterministic.
+func toAttributes(values map[string]string) []attribute {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]attribute, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, stringAttribute(key, values[key]))
+	}
+	return attributes
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
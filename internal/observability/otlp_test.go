@@ -0,0 +1,190 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// collector records the payloads posted to each OTLP path.
+type collector struct {
+	mutex    sync.Mutex
+	payloads map[string][]byte
+	headers  http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{payloads: make(map[string][]byte)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mutex.Lock()
+		c.payloads[r.URL.Path] = body
+		c.headers = r.Header.Clone()
+		c.mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func testConfig(endpoint string) types.OTLPConfig {
+	return types.OTLPConfig{
+		Enabled:            true,
+		Endpoint:           endpoint,
+		Headers:            map[string]string{"X-Api-Key": "secret"},
+		ServiceName:        "serverless-redis",
+		Region:             "eu-west-1",
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		ExportInterval:     time.Hour,
+		Timeout:            time.Second,
+	}
+}
+
+func TestPipelineExportsAllSignals(t *testing.T) {
+	c, srv := newCollector(t)
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_requests_total", Help: "Requests"}, []string{"endpoint"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_duration_seconds", Buckets: []float64{0.1, 1}})
+	registry.MustRegister(counter, histogram)
+	counter.WithLabelValues("command").Add(3)
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	pipeline := NewPipeline(testConfig(srv.URL), "1.2.3", registry)
+
+	handler := pipeline.TracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if TraceIDFromContext(r.Context()) != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected incoming trace ID to be continued, got %q", TraceIDFromContext(r.Context()))
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !strings.HasPrefix(w.Header().Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Errorf("Expected traceparent response header, got %q", w.Header().Get("traceparent"))
+	}
+
+	if _, err := pipeline.LogWriter().Write([]byte("Failed to connect\n")); err != nil {
+		t.Fatalf("Unexpected log write error: %v", err)
+	}
+
+	if err := pipeline.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	if c.headers.Get("X-Api-Key") != "secret" {
+		t.Error("Expected configured headers on export requests")
+	}
+
+	var traces tracesPayload
+	if err := json.Unmarshal(c.payloads["/v1/traces"], &traces); err != nil {
+		t.Fatalf("Failed to decode traces: %v", err)
+	}
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Expected parent span ID from traceparent, got %q", spans[0].ParentSpanID)
+	}
+	if spans[0].Status.Code != statusCodeError {
+		t.Errorf("Expected error status for 500 response, got %d", spans[0].Status.Code)
+	}
+	if !hasAttribute(traces.ResourceSpans[0].Resource, "cloud.region", "eu-west-1") ||
+		!hasAttribute(traces.ResourceSpans[0].Resource, "deployment.environment", "test") {
+		t.Error("Expected region and custom resource attributes on traces")
+	}
+
+	var logs logsPayload
+	if err := json.Unmarshal(c.payloads["/v1/logs"], &logs); err != nil {
+		t.Fatalf("Failed to decode logs: %v", err)
+	}
+	records := logs.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 1 || *records[0].Body.StringValue != "Failed to connect" || records[0].SeverityText != "ERROR" {
+		t.Errorf("Unexpected log records: %+v", records)
+	}
+	if !hasAttribute(logs.ResourceLogs[0].Resource, "service.version", "1.2.3") {
+		t.Error("Expected service.version resource attribute on logs")
+	}
+
+	var metrics metricsPayload
+	if err := json.Unmarshal(c.payloads["/v1/metrics"], &metrics); err != nil {
+		t.Fatalf("Failed to decode metrics: %v", err)
+	}
+	byName := make(map[string]metric)
+	for _, m := range metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+
+	requests := byName["test_requests_total"]
+	if requests.Sum == nil || !requests.Sum.IsMonotonic || requests.Sum.DataPoints[0].AsDouble != 3 {
+		t.Errorf("Expected monotonic sum of 3, got %+v", requests.Sum)
+	}
+
+	duration := byName["test_duration_seconds"]
+	if duration.Histogram == nil {
+		t.Fatal("Expected histogram metric")
+	}
+	point := duration.Histogram.DataPoints[0]
+	if point.Count != "3" || strings.Join(point.BucketCounts, ",") != "1,1,1" || len(point.ExplicitBounds) != 2 {
+		t.Errorf("Unexpected histogram point: %+v", point)
+	}
+}
+
+func TestPipelineFlushReportsCollectorErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	pipeline := NewPipeline(testConfig(srv.URL), "test", nil)
+	pipeline.LogWriter().Write([]byte("hello\n"))
+
+	if err := pipeline.Flush(context.Background()); err == nil {
+		t.Error("Expected error when collector rejects export")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		traceID string
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"00-not-hex-01", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		traceID, _ := parseTraceparent(tt.header)
+		if traceID != tt.traceID {
+			t.Errorf("parseTraceparent(%q) = %q, want %q", tt.header, traceID, tt.traceID)
+		}
+	}
+}
+
+func hasAttribute(r resource, key, value string) bool {
+	for _, attr := range r.Attributes {
+		if attr.Key == key && attr.Value.StringValue != nil && *attr.Value.StringValue == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OTLP span kind and status codes.
+const (
+	spanKindServer  = 2
+	statusCodeUnset = 0
+	statusCodeError = 2
+)
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []attribute `json:"attributes,omitempty"`
+	Status            spanStatus  `json:"status"`
+}
+
+type spanStatus struct {
+	Code int `json:"code"`
+}
+
+type tracesPayload struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+func (p *Pipeline) tracesPayload(spans []span) tracesPayload {
+	return tracesPayload{ResourceSpans: []resourceSpans{{
+		Resource:   p.resource,
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: scopeName}, Spans: spans}},
+	}}}
+}
+
+// SpanContext identifies the span a request is being served under.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+type spanContextKey struct{}
+
+// SpanFromContext returns the span context set by TracingMiddleware.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// TraceIDFromContext returns the current trace ID, or "" outside a trace.
+func TraceIDFromContext(ctx context.Context) string {
+	sc, _ := SpanFromContext(ctx)
+	return sc.TraceID
+}
+
+// TracingMiddleware records a server span for every request, continuing
+// the caller's trace when a W3C traceparent header is present.
+func (p *Pipeline) TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		traceID, parentID := parseTraceparent(r.Header.Get("traceparent"))
+		if traceID == "" {
+			traceID = randomHex(16)
+		}
+		sc := SpanContext{TraceID: traceID, SpanID: randomHex(8)}
+
+		w.Header().Set("traceparent", fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID))
+
+		wrapped := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), spanContextKey{}, sc)))
+
+		status := spanStatus{Code: statusCodeUnset}
+		if wrapped.status >= 500 {
+			status.Code = statusCodeError
+		}
+
+		p.mutex.Lock()
+		p.spans = appendBounded(p.spans, span{
+			TraceID:           sc.TraceID,
+			SpanID:            sc.SpanID,
+			ParentSpanID:      parentID,
+			Name:              r.Method + " " + r.URL.Path,
+			Kind:              spanKindServer,
+			StartTimeUnixNano: unixNano(start),
+			EndTimeUnixNano:   unixNano(time.Now()),
+			Attributes: []attribute{
+				stringAttribute("http.request.method", r.Method),
+				stringAttribute("url.path", r.URL.Path),
+				intAttribute("http.response.status_code", int64(wrapped.status)),
+			},
+			Status: status,
+		})
+		p.mutex.Unlock()
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streaming handlers working behind the middleware.
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// parseTraceparent extracts the trace and parent span IDs from a W3C
+// traceparent header, returning empty strings if it is malformed.
+func parseTraceparent(header string) (string, string) {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+
+	if _, err := hex.DecodeString(parts[1] + parts[2]); err != nil {
+		return "", ""
+	}
+
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", ""
+	}
+
+	return parts[1], parts[2]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,279 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Document is the subset of the OpenAPI 3.0 document model used by the proxy.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string              `json:"summary"`
+	OperationID string              `json:"operationId"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	// Security is a pointer so that an explicitly empty list, marking a
+	// public operation, survives omitempty.
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type SecurityRequirement map[string][]string
+
+// Endpoint describes one operation to add to the document. Request and
+// Response are example values whose types are converted to schemas.
+type Endpoint struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Parameters  []Parameter
+	Request     interface{}
+	Response    interface{}
+	ContentType string
+	Public      bool
+}
+
+// Builder assembles a Document, registering a named component schema for
+// every struct type it encounters.
+type Builder struct {
+	doc *Document
+}
+
+// NewBuilder creates a builder for a document with the standard security
+// schemes accepted by the auth middleware.
+func NewBuilder(title, version string) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    Info{Title: title, Version: version},
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas: make(map[string]*Schema),
+				SecuritySchemes: map[string]SecurityScheme{
+					"apiKey":     {Type: "apiKey", In: "header", Name: "Authorization"},
+					"bearerAuth": {Type: "http", Scheme: "bearer"},
+					"basicAuth":  {Type: "http", Scheme: "basic"},
+					"requestSignature": {
+						Type: "apiKey", In: "header", Name: "Authorization",
+						Description: "SR-HMAC-SHA256 KeyId=<id>, Signature=<hex>, with X-SR-Date and X-SR-Nonce headers",
+					},
+				},
+			},
+			Security: []SecurityRequirement{{"apiKey": {}}, {"bearerAuth": {}}, {"basicAuth": {}}, {"requestSignature": {}}},
+		},
+	}
+}
+
+// Add registers an endpoint in the document.
+func (b *Builder) Add(e Endpoint) {
+	contentType := e.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	op := &Operation{
+		Summary:     e.Summary,
+		OperationID: operationID(e.Method, e.Path),
+		Parameters:  e.Parameters,
+		Responses:   make(map[string]Response),
+	}
+
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+
+	if e.Public {
+		op.Security = &[]SecurityRequirement{}
+	}
+
+	if e.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{contentType: {Schema: b.SchemaFor(e.Request)}},
+		}
+	}
+
+	success := Response{Description: "Successful response"}
+	if e.Response != nil {
+		success.Content = map[string]MediaType{"application/json": {Schema: b.SchemaFor(e.Response)}}
+	}
+	op.Responses["200"] = success
+
+	errorContent := map[string]MediaType{"application/json": {Schema: b.SchemaFor(types.ErrorResponse{})}}
+	op.Responses["default"] = Response{Description: "Error response", Content: errorContent}
+
+	item, ok := b.doc.Paths[e.Path]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[e.Path] = item
+	}
+	item[strings.ToLower(e.Method)] = op
+}
+
+// Document returns the assembled document.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// SchemaFor returns the schema for v's type. Struct types are registered
+// as components and referenced by name.
+func (b *Builder) SchemaFor(v interface{}) *Schema {
+	if schema, ok := v.(*Schema); ok {
+		return schema
+	}
+	return b.schemaForType(reflect.TypeOf(v))
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+func (b *Builder) schemaForType(t reflect.Type) *Schema {
+	if t == nil || t == rawMessageType {
+		return &Schema{}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format := "int32"
+		if t.Bits() == 64 {
+			format = "int64"
+		}
+		return &Schema{Type: "integer", Format: format}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaForType(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	default:
+		// interface{} values can hold any Redis reply.
+		return &Schema{}
+	}
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, exists := b.doc.Components.Schemas[name]; exists {
+		return ref
+	}
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	// Register before walking fields so recursive types terminate.
+	b.doc.Components.Schemas[name] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		fieldName, options, _ := strings.Cut(tag, ",")
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		schema.Properties[fieldName] = b.schemaForType(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+
+	return ref
+}
+
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_' || r == '.'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type testRequest struct {
+	Name    string            `json:"name"`
+	Count   int64             `json:"count,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Payload interface{}       `json:"payload"`
+	Nested  *testNested       `json:"nested,omitempty"`
+	hidden  string
+}
+
+type testNested struct {
+	Enabled bool `json:"enabled"`
+}
+
+func TestSchemaForStruct(t *testing.T) {
+	builder := NewBuilder("test", "1.0")
+
+	ref := builder.SchemaFor(testRequest{})
+	if ref.Ref != "#/components/schemas/testRequest" {
+		t.Fatalf("Expected component reference, got %q", ref.Ref)
+	}
+
+	schema := builder.Document().Components.Schemas["testRequest"]
+	if schema == nil {
+		t.Fatal("Expected testRequest to be registered as a component")
+	}
+
+	if len(schema.Properties) != 6 {
+		t.Errorf("Expected 6 properties, got %d", len(schema.Properties))
+	}
+
+	if _, ok := schema.Properties["hidden"]; ok {
+		t.Error("Expected unexported fields to be skipped")
+	}
+
+	if schema.Properties["count"].Type != "integer" || schema.Properties["count"].Format != "int64" {
+		t.Errorf("Expected int64 integer for count, got %+v", schema.Properties["count"])
+	}
+
+	if schema.Properties["tags"].Type != "array" || schema.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Expected array of strings for tags, got %+v", schema.Properties["tags"])
+	}
+
+	if schema.Properties["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("Expected map of strings for labels, got %+v", schema.Properties["labels"])
+	}
+
+	if schema.Properties["nested"].Ref != "#/components/schemas/testNested" {
+		t.Errorf("Expected nested struct reference, got %+v", schema.Properties["nested"])
+	}
+
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	if !required["name"] || !required["payload"] || required["count"] {
+		t.Errorf("Unexpected required fields: %v", schema.Required)
+	}
+}
+
+func TestBuilderAdd(t *testing.T) {
+	builder := NewBuilder("test", "1.0")
+	builder.Add(Endpoint{
+		Method:     "POST",
+		Path:       "/v1/things/{id}",
+		Summary:    "Create a thing",
+		Parameters: []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}},
+		Request:    testRequest{},
+		Response:   testNested{},
+	})
+	builder.Add(Endpoint{Method: "GET", Path: "/health", Public: true})
+
+	doc := builder.Document()
+
+	op := doc.Paths["/v1/things/{id}"]["post"]
+	if op == nil {
+		t.Fatal("Expected POST operation to be registered")
+	}
+
+	if op.OperationID != "postV1ThingsId" {
+		t.Errorf("Expected operationId postV1ThingsId, got %s", op.OperationID)
+	}
+
+	if op.RequestBody == nil || op.RequestBody.Content["application/json"].Schema.Ref == "" {
+		t.Error("Expected JSON request body schema")
+	}
+
+	if _, ok := op.Responses["default"]; !ok {
+		t.Error("Expected default error response")
+	}
+
+	health := doc.Paths["/health"]["get"]
+	if health.Security == nil || len(*health.Security) != 0 {
+		t.Error("Expected public endpoint to override security with an empty list")
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal document: %v", err)
+	}
+
+	if decoded["openapi"] != "3.0.3" {
+		t.Errorf("Expected openapi 3.0.3, got %v", decoded["openapi"])
+	}
+
+	paths := decoded["paths"].(map[string]interface{})
+	healthGet := paths["/health"].(map[string]interface{})["get"].(map[string]interface{})
+	if security, ok := healthGet["security"].([]interface{}); !ok || len(security) != 0 {
+		t.Errorf("Expected empty security list in JSON, got %v", healthGet["security"])
+	}
+}
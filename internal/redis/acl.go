@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Credentials identify a Redis 6 ACL user. Commands executed with a
+// context carrying credentials use a dedicated pool authenticated as that
+// user, so Redis enforces the user's ACL rules itself.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type credentialsKey struct{}
+
+// WithCredentials attaches the Redis user for the commands executed with
+// ctx. Empty credentials leave the proxy's own user in place.
+func WithCredentials(ctx context.Context, user Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, user)
+}
+
+func credentialsFromContext(ctx context.Context) Credentials {
+	user, _ := ctx.Value(credentialsKey{}).(Credentials)
+	return user
+}
+
+// rotatingPassword is a backend password that can change while the proxy
+// runs. Connections opened after a change authenticate with the new one;
+// open ones stay authenticated.
+type rotatingPassword struct {
+	value atomic.Value
+}
+
+func newRotatingPassword(password string) *rotatingPassword {
+	p := &rotatingPassword{}
+	p.value.Store(password)
+	return p
+}
+
+func (p *rotatingPassword) set(password string) {
+	p.value.Store(password)
+}
+
+// credentials is a redis.Options.CredentialsProvider.
+func (p *rotatingPassword) credentials() (string, string) {
+	return "", p.value.Load().(string)
+}
+
+// SetPasswords changes the password new connections to the primary and
+// DragonflyDB authenticate with, e.g. after a secret store rotated them.
+// Pools for other databases of the same backend follow; shards, replicas
+// and tenant ACL users keep their own.
+func (c *Client) SetPasswords(primary, dragonfly string) {
+	c.primaryPassword.set(primary)
+	c.dragonflyPassword.set(dragonfly)
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPoolPerRedisUser(t *testing.T) {
+	primary := redis.NewClient(&redis.Options{Addr: "primary:6379"})
+	c := &Client{primary: primary, pools: make(map[poolKey]*redis.Client), done: make(chan struct{})}
+	defer c.Close()
+
+	ctx := context.Background()
+	if got := c.pool(ctx, primary, 0); got != primary {
+		t.Error("Expected the base client without a user or database")
+	}
+
+	alice := WithCredentials(ctx, Credentials{Username: "alice", Password: "secret"})
+	pool := c.pool(alice, primary, 0)
+	if pool == primary {
+		t.Fatal("Expected a dedicated pool for the ACL user")
+	}
+	if opts := pool.Options(); opts.Username != "alice" || opts.Password != "secret" || opts.Addr != "primary:6379" {
+		t.Errorf("Unexpected pool options: user=%q addr=%q", opts.Username, opts.Addr)
+	}
+	if c.pool(alice, primary, 0) != pool {
+		t.Error("Expected the pool to be reused")
+	}
+
+	if other := c.pool(alice, primary, 3); other == pool || other.Options().DB != 3 {
+		t.Error("Expected a separate pool per database")
+	}
+	bob := WithCredentials(ctx, Credentials{Username: "bob"})
+	if c.pool(bob, primary, 0) == pool {
+		t.Error("Expected a separate pool per user")
+	}
+}
@@ -2,7 +2,10 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,20 +15,73 @@ import (
 type Client struct {
 	primary   *redis.Client
 	dragonfly *redis.Client
+	router    *latencyRouter
 	config    *types.Config
+	
+	replicas    []*replica
+	nextReplica atomic.Uint32
+	done        chan struct{}
+	
+	// Backends keys are spread over, the primary first, when shards are
+	// configured
+	shards []backend
+	ring   *hashRing
+	
+	// Backends and dbs of redis.named_databases
+	databases map[string]namedDatabase
+	
+	// GETs cached in memory, kept correct by CLIENT TRACKING
+	clientCache *clientCache
+	
+	// Connection pools for non-default databases and tenant ACL users,
+	// keyed by base client. SELECT or AUTH on a pooled connection would
+	// leak into later requests.
+	poolMutex sync.Mutex
+	pools     map[poolKey]*redis.Client
+	
+	retryObserver RetryObserver
+	
+	// Why the backends could not all be reached yet, nil once they were
+	connectErr atomic.Pointer[error]
+	
+	// Passwords of the primary and DragonflyDB, which may be rotated
+	primaryPassword   *rotatingPassword
+	dragonflyPassword *rotatingPassword
+}
+
+type poolKey struct {
+	base  *redis.Client
+	db    int
+	user  Credentials
+	resp3 bool
 }
 
 func NewClient(config *types.Config) (*Client, error) {
+	name := connectionName(config.Redis.ClientName, config.Redis.Instance)
+	primaryPassword := newRotatingPassword(config.Redis.Primary.Password)
+	dragonflyPassword := newRotatingPassword(config.Redis.Dragonfly.Password)
+	
 	// Initialize primary Redis client
 	primaryOpts := &redis.Options{
+		ClientName:   connectionName(name, "primary"),
 		Addr:         config.Redis.Primary.Addr,
-		Password:     config.Redis.Primary.Password,
 		DB:           config.Redis.Primary.DB,
 		MaxRetries:   config.Redis.Primary.MaxRetries,
 		DialTimeout:  config.Redis.Primary.DialTimeout,
 		ReadTimeout:  config.Redis.Primary.ReadTimeout,
 		WriteTimeout: config.Redis.Primary.WriteTimeout,
 		
+		// Let request deadlines (timeout_ms, X-Request-Timeout) bound
+		// network I/O instead of only ReadTimeout/WriteTimeout
+		ContextTimeoutEnabled: true,
+		
+		// Read on every new connection, so the password can be rotated
+		CredentialsProvider: primaryPassword.credentials,
+		
+		// RESP3 maps arrive as Go maps, which lose the order Redis sent
+		// hash fields in; RESP2 keeps them as ordered flat arrays
+		Protocol: 2,
+		
 		// Connection pool settings
 		MinIdleConns:    config.Pool.MinIdleConns,
 		MaxIdleConns:    config.Pool.MaxIdleConns,
@@ -37,25 +93,27 @@ func NewClient(config *types.Config) (*Client, error) {
 	
 	primary := redis.NewClient(primaryOpts)
 	
-	// Test primary connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := primary.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to primary Redis: %w", err)
-	}
-	
 	client := &Client{
 		primary: primary,
 		config:  config,
+		done:    make(chan struct{}),
+		
+		pools: make(map[poolKey]*redis.Client),
+		
+		primaryPassword:   primaryPassword,
+		dragonflyPassword: dragonflyPassword,
 	}
 	
 	// Initialize DragonflyDB client if enabled
 	if config.Redis.Dragonfly.Enabled {
 		dragonflyOpts := &redis.Options{
 			Addr:     config.Redis.Dragonfly.Addr,
-			Password: config.Redis.Dragonfly.Password,
 			DB:       config.Redis.Dragonfly.DB,
+			Protocol: 2,
+			
+			ClientName:            connectionName(name, "dragonfly"),
+			ContextTimeoutEnabled: true,
+			CredentialsProvider:   dragonflyPassword.credentials,
 			
 			// Use same pool settings
 			MinIdleConns:    config.Pool.MinIdleConns,
@@ -68,26 +126,79 @@ func NewClient(config *types.Config) (*Client, error) {
 		
 		dragonfly := redis.NewClient(dragonflyOpts)
 		
-		// Test DragonflyDB connection
-		if err := dragonfly.Ping(ctx).Err(); err != nil {
-			return nil, fmt.Errorf("failed to connect to DragonflyDB: %w", err)
+		client.dragonfly = dragonfly
+		client.router = newLatencyRouter(config.Redis.Dragonfly.Routing)
+	}
+	
+	// Initialize shards, which share the primary's connection settings
+	if len(config.Redis.Shards) > 0 {
+		client.shards = []backend{{name: "primary", client: primary}}
+		names := []string{"primary"}
+		for _, shardConfig := range config.Redis.Shards {
+			shardOpts := *primaryOpts
+			shardOpts.ClientName = connectionName(name, shardConfig.Name)
+			shardOpts.Addr = shardConfig.Addr
+			shardOpts.Password = shardConfig.Password
+			shardOpts.CredentialsProvider = nil
+			shardOpts.DB = shardConfig.DB
+			
+			shard := redis.NewClient(&shardOpts)
+			client.shards = append(client.shards, backend{name: shardConfig.Name, client: shard})
+			names = append(names, shardConfig.Name)
+		}
+		client.ring = newHashRing(names)
+	}
+	
+	if err := client.resolveNamedDatabases(config.Redis.NamedDatabases); err != nil {
+		return nil, err
+	}
+	
+	// Test connections; in lazy mode keep trying in the background
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	if err := client.ping(ctx); err != nil {
+		if !config.Redis.Connect.Lazy {
+			return nil, err
+		}
+		client.connectErr.Store(&err)
+		go client.connect(config.Redis.Connect, err)
+	}
+	
+	if config.Redis.ClientCache.Enabled {
+		client.clientCache = newClientCache(primaryOpts, config.Redis.ClientCache)
+	}
+	
+	// Initialize read replicas; unreachable replicas are skipped for reads
+	// until the offset monitor sees them again
+	if config.Redis.ReadFromReplicas {
+		for i, replicaConfig := range config.Redis.Replicas {
+			replicaOpts := *primaryOpts
+			replicaOpts.ClientName = connectionName(name, fmt.Sprintf("replica%d", i))
+			replicaOpts.Addr = replicaConfig.Addr
+			replicaOpts.Password = replicaConfig.Password
+			replicaOpts.CredentialsProvider = nil
+			
+			r := &replica{client: redis.NewClient(&replicaOpts)}
+			r.offset.Store(-1)
+			client.replicas = append(client.replicas, r)
 		}
 		
-		client.dragonfly = dragonfly
+		if len(client.replicas) > 0 {
+			go client.monitorReplicas(config.Redis.ReplicaOffsetInterval)
+		}
 	}
 	
 	return client, nil
 }
 
 func (c *Client) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
-	// Select the appropriate client (DragonflyDB for performance, Redis for compatibility)
-	redisClient := c.selectClient(req.Command)
-	
-	// Switch to the correct database if specified
-	if req.DB != 0 {
-		if err := redisClient.Do(ctx, "SELECT", req.DB).Err(); err != nil {
-			return nil, fmt.Errorf("failed to select database %d: %w", req.DB, err)
-		}
+	if req.RESP3 {
+		ctx = withRESP3(ctx)
+	}
+	req, err := applyTTLPolicy(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 	
 	// Prepare command arguments
@@ -95,29 +206,112 @@ func (c *Client) ExecuteCommand(ctx context.Context, req types.CommandRequest) (
 	args[0] = req.Command
 	copy(args[1:], req.Args)
 	
-	// Execute the command
-	result := redisClient.Do(ctx, args...)
-	if result.Err() != nil {
-		return nil, result.Err()
+	// Named databases pin the backend; otherwise, with shards, the
+	// command's keys decide it
+	target, db, err := c.database(req.Database, req.DB)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		if target, err = c.shardClient(req); err != nil {
+			return nil, err
+		}
 	}
 	
-	return result.Val(), nil
+	if c.clientCache != nil {
+		if key, ok := cacheableGet(ctx, req, target, db); ok {
+			result, err := c.withRetry(ctx, req.Command, func() (interface{}, error) {
+				return c.clientCache.get(ctx, key)
+			})
+			if err != errClientCacheDown {
+				return result, err
+			}
+		} else {
+			defer c.clientCache.wrote(req)
+		}
+	}
+	
+	// Execute the command, retrying reads that hit a transient error
+	return c.withRetry(ctx, req.Command, func() (interface{}, error) {
+		// Select the appropriate client (DragonflyDB for performance, Redis
+		// for compatibility) per attempt, so a retry may use another replica
+		redisClient, backend := target, ""
+		if redisClient == nil {
+			redisClient, backend = c.selectClient(ctx, req.Command)
+		}
+		
+		// Use the pool for the requested database and tenant
+		redisClient = c.pool(ctx, redisClient, db)
+		
+		start := time.Now()
+		result := redisClient.Do(ctx, args...)
+		if backend != "" {
+			c.router.observe(req.Command, backend, time.Since(start), result.Err())
+		}
+		if result.Err() != nil {
+			return nil, result.Err()
+		}
+		
+		return shapeReply(req, result.Val()), nil
+	})
 }
 
 func (c *Client) ExecutePipeline(ctx context.Context, req types.PipelineRequest) []types.CommandResponse {
-	redisClient := c.selectClient("")
+	if req.RESP3 {
+		ctx = withRESP3(ctx)
+		req.Commands = resp3Commands(req.Commands)
+	}
+	if c.clientCache != nil {
+		defer c.clientCache.wrote(req.Commands...)
+	}
 	
-	// Create pipeline
-	pipe := redisClient.Pipeline()
+	commands, err := applyTTLPolicies(ctx, req.Commands)
+	if err != nil {
+		return failedPipeline(len(req.Commands), err)
+	}
+	req.Commands = commands
 	
-	// Switch to correct database if specified
-	if req.DB != 0 {
-		pipe.Do(ctx, "SELECT", req.DB)
+	target, db, err := c.database(req.Database, req.DB)
+	if err != nil {
+		return failedPipeline(len(req.Commands), err)
+	}
+	if target != nil {
+		return runParallelPipeline(ctx, c.pool(ctx, target, db), req.Commands, req.Parallelism)
+	}
+	
+	if c.ring != nil {
+		return c.executeShardedPipeline(ctx, req)
 	}
 	
+	// Read-only pipelines may go to a replica
+	var redisClient *redis.Client
+	if pipelineReadOnly(req.Commands) {
+		redisClient = c.readClient(ctx)
+	} else {
+		redisClient = c.writeClient(ctx)
+	}
+	
+	return runParallelPipeline(ctx, c.pool(ctx, redisClient, req.DB), req.Commands, req.Parallelism)
+}
+
+// failedPipeline returns the results of a pipeline of n commands that
+// failed as a whole with err.
+func failedPipeline(n int, err error) []types.CommandResponse {
+	results := make([]types.CommandResponse, n)
+	for i := range results {
+		results[i].Error = err.Error()
+	}
+	return results
+}
+
+// runPipeline executes commands in one pipeline on redisClient.
+func runPipeline(ctx context.Context, redisClient *redis.Client, commands []types.CommandRequest) []types.CommandResponse {
+	// Create pipeline
+	pipe := redisClient.Pipeline()
+	
 	// Add all commands to pipeline
-	cmds := make([]*redis.Cmd, len(req.Commands))
-	for i, cmdReq := range req.Commands {
+	cmds := make([]*redis.Cmd, len(commands))
+	for i, cmdReq := range commands {
 		args := make([]interface{}, len(cmdReq.Args)+1)
 		args[0] = cmdReq.Command
 		copy(args[1:], cmdReq.Args)
@@ -130,17 +324,17 @@ func (c *Client) ExecutePipeline(ctx context.Context, req types.PipelineRequest)
 	duration := time.Since(start).Seconds() * 1000
 	
 	// Collect results
-	results := make([]types.CommandResponse, len(req.Commands))
+	results := make([]types.CommandResponse, len(commands))
 	for i, cmd := range cmds {
 		response := types.CommandResponse{
-			Time: duration / float64(len(req.Commands)), // Distribute time across commands
+			Time: duration / float64(len(commands)), // Distribute time across commands
 		}
 		
 		if cmd.Err() != nil {
 			response.Error = cmd.Err().Error()
 		} else {
-			response.Result = cmd.Val()
-			response.Type = string(inferResponseType(cmd.Val()))
+			response.Result = shapeReply(commands[i], cmd.Val())
+			response.Type = string(InferResponseType(response.Result))
 		}
 		
 		results[i] = response
@@ -160,47 +354,54 @@ func (c *Client) ExecutePipeline(ctx context.Context, req types.PipelineRequest)
 }
 
 func (c *Client) ExecuteTransaction(ctx context.Context, req types.TransactionRequest) (*types.TransactionResponse, error) {
-	redisClient := c.selectClient("")
-	
-	start := time.Now()
-	
-	// Handle WATCH keys if specified
-	if len(req.Watch) > 0 {
-		watchArgs := make([]interface{}, len(req.Watch))
-		for i, key := range req.Watch {
-			watchArgs[i] = key
-		}
-		if err := redisClient.Do(ctx, append([]interface{}{"WATCH"}, watchArgs...)...).Err(); err != nil {
-			return nil, fmt.Errorf("WATCH failed: %w", err)
-		}
+	if req.RESP3 {
+		ctx = withRESP3(ctx)
+		req.Commands = resp3Commands(req.Commands)
+	}
+	commands, err := applyTTLPolicies(ctx, req.Commands)
+	if err != nil {
+		return nil, err
+	}
+	req.Commands = commands
+	if c.clientCache != nil {
+		defer c.clientCache.wrote(req.Commands...)
 	}
 	
-	// Execute transaction
-	results, err := redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-		// Switch to correct database if specified
-		if req.DB != 0 {
-			pipe.Do(ctx, "SELECT", req.DB)
-		}
-		
-		// Add all commands to transaction
-		for _, cmdReq := range req.Commands {
-			args := make([]interface{}, len(cmdReq.Args)+1)
-			args[0] = cmdReq.Command
-			copy(args[1:], cmdReq.Args)
-			pipe.Do(ctx, args...)
+	// Named databases pin the backend; otherwise transactions run on one
+	// shard, so every key must be on the same one
+	redisClient, db, err := c.database(req.Database, req.DB)
+	if err != nil {
+		return nil, err
+	}
+	if redisClient == nil {
+		redisClient, err = c.shardClient(transactionCommands(req)...)
+		if err != nil {
+			return nil, err
 		}
-		
-		return nil
-	})
+	}
+	if redisClient == nil {
+		redisClient = c.writeClient(ctx)
+	}
+	redisClient = c.pool(ctx, redisClient, db)
 	
+	start := time.Now()
+	results, attempts, preconditionFailed, err := c.runTransaction(ctx, redisClient, req)
 	duration := time.Since(start).Seconds() * 1000
 	
 	response := &types.TransactionResponse{
-		Queued: len(req.Commands),
-		Time:   duration,
+		Queued:             len(req.Commands),
+		Time:               duration,
+		Attempts:           attempts,
+		PreconditionFailed: preconditionFailed,
 	}
 	
-	if err != nil {
+	// Connection failures, and failed preconditions, are errors; Redis
+	// error replies are the transaction's
+	var redisErr redis.Error
+	if err != nil && !errors.Is(err, redis.TxFailedErr) && !errors.As(err, &redisErr) {
+		return nil, err
+	}
+	if err != nil || preconditionFailed {
 		// Transaction was discarded (WATCH key modified)
 		response.Exec = false
 		return response, nil
@@ -220,6 +421,9 @@ func (c *Client) ExecuteTransaction(ctx context.Context, req types.TransactionRe
 		} else {
 			// Handle different Redis result types
 			switch cmd := result.(type) {
+			case *redis.Cmd:
+				val, _ := cmd.Result()
+				cmdResponse.Result = shapeReply(req.Commands[i], val)
 			case *redis.StringCmd:
 				val, _ := cmd.Result()
 				cmdResponse.Result = val
@@ -236,7 +440,7 @@ func (c *Client) ExecuteTransaction(ctx context.Context, req types.TransactionRe
 				// Fallback for other command types
 				cmdResponse.Result = "OK"
 			}
-			cmdResponse.Type = string(inferResponseType(cmdResponse.Result))
+			cmdResponse.Type = string(InferResponseType(cmdResponse.Result))
 		}
 		
 		response.Results[i] = cmdResponse
@@ -268,12 +472,56 @@ func (c *Client) GetConnectionStats() map[string]int {
 		stats["dragonfly_stale_conns"] = int(poolStats.StaleConns)
 	}
 	
+	for i, r := range c.replicas {
+		poolStats := r.client.PoolStats()
+		stats[fmt.Sprintf("replica%d_total_conns", i)] = int(poolStats.TotalConns)
+		stats[fmt.Sprintf("replica%d_idle_conns", i)] = int(poolStats.IdleConns)
+	}
+	
+	for _, shard := range c.shards[min(1, len(c.shards)):] {
+		poolStats := shard.client.PoolStats()
+		stats[fmt.Sprintf("shard_%s_total_conns", shard.name)] = int(poolStats.TotalConns)
+		stats[fmt.Sprintf("shard_%s_idle_conns", shard.name)] = int(poolStats.IdleConns)
+	}
+	
+	if c.clientCache != nil {
+		c.clientCache.stats(stats)
+	}
+	
 	return stats
 }
 
 func (c *Client) Close() error {
 	var err error
 	
+	close(c.done)
+	
+	if c.clientCache != nil {
+		if closeErr := c.clientCache.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	
+	c.poolMutex.Lock()
+	for _, pool := range c.pools {
+		if closeErr := pool.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	c.poolMutex.Unlock()
+	
+	for _, r := range c.replicas {
+		if closeErr := r.client.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	
+	for _, shard := range c.shards[min(1, len(c.shards)):] {
+		if closeErr := shard.client.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	
 	if c.primary != nil {
 		if closeErr := c.primary.Close(); closeErr != nil {
 			err = closeErr
@@ -289,23 +537,81 @@ func (c *Client) Close() error {
 	return err
 }
 
-// selectClient chooses between DragonflyDB and Redis based on command type
-func (c *Client) selectClient(command string) *redis.Client {
-	// Use DragonflyDB for high-performance operations if available
-	if c.dragonfly != nil {
-		// Commands that benefit from DragonflyDB's multi-threading
-		switch command {
-		case "MGET", "MSET", "HMGET", "HMSET", "ZADD", "ZRANGE", "ZRANGEBYSCORE":
-			return c.dragonfly
+// IsNil reports whether err is the Redis nil reply, returned for missing keys.
+func IsNil(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
+
+// selectClient chooses between DragonflyDB and Redis for command. If both
+// are available it also returns which one was picked, for the router to
+// learn from the command's latency.
+func (c *Client) selectClient(ctx context.Context, command string) (*redis.Client, string) {
+	backend := ""
+	if c.router != nil {
+		if backend = c.router.route(command); backend == BackendDragonfly {
+			return c.dragonfly, backend
 		}
 	}
 	
+	// Reads may be served by a replica that has caught up with the session
+	if IsReadOnly(command) {
+		return c.readClient(ctx), backend
+	}
+	
 	// Default to Redis for maximum compatibility
-	return c.primary
+	return c.writeClient(ctx), backend
+}
+
+// pool returns a client for db on the same server as base, authenticated
+// as the Redis user attached to ctx if there is one. Database 0 means the
+// server's configured database.
+func (c *Client) pool(ctx context.Context, base *redis.Client, db int) *redis.Client {
+	if db == 0 {
+		db = base.Options().DB
+	}
+	user := credentialsFromContext(ctx)
+	resp3 := resp3FromContext(ctx) && base.Options().Protocol != 3
+	if db == base.Options().DB && user.Username == "" && !resp3 {
+		return base
+	}
+	
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	
+	key := poolKey{base: base, db: db, user: user, resp3: resp3}
+	if pool, exists := c.pools[key]; exists {
+		return pool
+	}
+	
+	opts := *base.Options()
+	opts.DB = db
+	opts.ClientName = connectionName(opts.ClientName, fmt.Sprintf("db%d", db))
+	if user.Username != "" {
+		opts.Username = user.Username
+		opts.Password = user.Password
+		opts.CredentialsProvider = nil
+		opts.ClientName = connectionName(opts.ClientName, "user-"+user.Username)
+	}
+	if resp3 {
+		opts.Protocol = 3
+		opts.ClientName = connectionName(opts.ClientName, "resp3")
+	}
+	pool := redis.NewClient(&opts)
+	c.pools[key] = pool
+	return pool
+}
+
+func pipelineReadOnly(commands []types.CommandRequest) bool {
+	for _, cmd := range commands {
+		if !IsReadOnly(cmd.Command) {
+			return false
+		}
+	}
+	return true
 }
 
-// inferResponseType determines the response type for JSON serialization
-func inferResponseType(val interface{}) types.ResponseType {
+// InferResponseType determines the response type for JSON serialization
+func InferResponseType(val interface{}) types.ResponseType {
 	if val == nil {
 		return types.ResponseTypeNil
 	}
@@ -321,8 +627,18 @@ func inferResponseType(val interface{}) types.ResponseType {
 		return types.ResponseTypeBool
 	case []interface{}:
 		return types.ResponseTypeArray
-	case map[string]interface{}:
+	case map[string]interface{}, []types.HashField:
 		return types.ResponseTypeHash
+	case types.MapReply:
+		return types.ResponseTypeMap
+	case types.SetReply:
+		return types.ResponseTypeSet
+	case types.DoubleReply:
+		return types.ResponseTypeDouble
+	case types.BigNumberReply:
+		return types.ResponseTypeBigNumber
+	case types.VerbatimReply:
+		return types.ResponseTypeVerbatim
 	default:
 		return types.ResponseTypeString
 	}
@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"sort"
+	"strings"
+)
+
+// readOnlyCommands are commands that never modify data and may be served
+// by a replica.
+var readOnlyCommands = map[string]bool{
+	"GET": true, "MGET": true, "GETRANGE": true, "STRLEN": true, "SUBSTR": true, "LCS": true,
+	"EXISTS": true, "TYPE": true, "TTL": true, "PTTL": true, "EXPIRETIME": true, "PEXPIRETIME": true,
+	"KEYS": true, "SCAN": true, "RANDOMKEY": true, "DBSIZE": true, "DUMP": true, "OBJECT": true, "TOUCH": true,
+	"HGET": true, "HMGET": true, "HGETALL": true, "HKEYS": true, "HVALS": true, "HLEN": true,
+	"HEXISTS": true, "HSTRLEN": true, "HSCAN": true, "HRANDFIELD": true,
+	"LRANGE": true, "LINDEX": true, "LLEN": true, "LPOS": true,
+	"SMEMBERS": true, "SISMEMBER": true, "SMISMEMBER": true, "SCARD": true, "SRANDMEMBER": true,
+	"SINTER": true, "SINTERCARD": true, "SUNION": true, "SDIFF": true, "SSCAN": true,
+	"ZRANGE": true, "ZRANGEBYSCORE": true, "ZREVRANGEBYSCORE": true, "ZRANGEBYLEX": true, "ZREVRANGEBYLEX": true,
+	"ZREVRANGE": true, "ZSCORE": true, "ZMSCORE": true, "ZRANK": true, "ZREVRANK": true, "ZCARD": true,
+	"ZCOUNT": true, "ZLEXCOUNT": true, "ZSCAN": true, "ZRANDMEMBER": true, "ZINTER": true, "ZUNION": true, "ZDIFF": true,
+	"PFCOUNT": true, "GETBIT": true, "BITCOUNT": true, "BITPOS": true,
+	"GEOPOS": true, "GEODIST": true, "GEOHASH": true, "GEOSEARCH": true,
+	"XRANGE": true, "XREVRANGE": true, "XLEN": true, "XREAD": true, "XINFO": true, "XPENDING": true,
+	"FT.SEARCH": true, "BF.EXISTS": true, "BF.MEXISTS": true, "BF.CARD": true, "BF.INFO": true,
+	"JSON.GET": true, "JSON.MGET": true, "JSON.TYPE": true, "JSON.STRLEN": true, "JSON.ARRLEN": true, "JSON.OBJKEYS": true,
+	"VSIM": true, "VGETATTR": true, "VCARD": true, "VDIM": true, "VEMB": true, "VINFO": true,
+	"PING": true, "ECHO": true, "TIME": true,
+}
+
+// writeCommands are the commands that modify data, or may, which the proxy
+// passes through. Together with readOnlyCommands they are the commands
+// tenants' permissions are expanded against.
+var writeCommands = map[string]bool{
+	"SET": true, "SETNX": true, "SETEX": true, "PSETEX": true, "MSET": true, "MSETNX": true,
+	"GETSET": true, "GETDEL": true, "GETEX": true, "APPEND": true, "SETRANGE": true,
+	"INCR": true, "INCRBY": true, "INCRBYFLOAT": true, "DECR": true, "DECRBY": true,
+	"DEL": true, "UNLINK": true, "EXPIRE": true, "PEXPIRE": true, "EXPIREAT": true, "PEXPIREAT": true,
+	"PERSIST": true, "RENAME": true, "RENAMENX": true, "COPY": true, "MOVE": true, "RESTORE": true,
+	"SORT": true, "FLUSHDB": true, "FLUSHALL": true,
+	"HSET": true, "HSETNX": true, "HMSET": true, "HDEL": true, "HINCRBY": true, "HINCRBYFLOAT": true,
+	"LPUSH": true, "RPUSH": true, "LPUSHX": true, "RPUSHX": true, "LPOP": true, "RPOP": true,
+	"LSET": true, "LINSERT": true, "LREM": true, "LTRIM": true, "LMOVE": true, "RPOPLPUSH": true,
+	"BLPOP": true, "BRPOP": true, "BLMOVE": true,
+	"SADD": true, "SREM": true, "SPOP": true, "SMOVE": true,
+	"SINTERSTORE": true, "SUNIONSTORE": true, "SDIFFSTORE": true,
+	"ZADD": true, "ZREM": true, "ZINCRBY": true, "ZPOPMIN": true, "ZPOPMAX": true,
+	"ZREMRANGEBYSCORE": true, "ZREMRANGEBYRANK": true, "ZREMRANGEBYLEX": true,
+	"ZUNIONSTORE": true, "ZINTERSTORE": true, "ZDIFFSTORE": true, "ZRANGESTORE": true,
+	"PFADD": true, "PFMERGE": true, "SETBIT": true, "BITOP": true, "BITFIELD": true,
+	"GEOADD": true, "GEOSEARCHSTORE": true,
+	"XADD": true, "XDEL": true, "XTRIM": true, "XGROUP": true, "XACK": true,
+	"XCLAIM": true, "XAUTOCLAIM": true, "XREADGROUP": true,
+	"PUBLISH": true, "EVAL": true, "EVALSHA": true, "FCALL": true, "SCRIPT": true,
+	"FT.CREATE": true, "FT.DROPINDEX": true,
+	"BF.ADD": true, "BF.MADD": true, "BF.INSERT": true, "BF.RESERVE": true,
+	"JSON.SET": true, "JSON.MERGE": true, "JSON.DEL": true, "JSON.NUMINCRBY": true, "JSON.ARRAPPEND": true,
+	"VADD": true, "VREM": true, "VSETATTR": true,
+}
+
+// KnownCommands returns every command in the proxy's command table,
+// sorted.
+func KnownCommands() []string {
+	commands := make([]string, 0, len(readOnlyCommands)+len(writeCommands))
+	for command := range readOnlyCommands {
+		commands = append(commands, command)
+	}
+	for command := range writeCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// IsReadOnly reports whether command is known not to modify data. Unknown
+// commands are treated as writes.
+func IsReadOnly(command string) bool {
+	return readOnlyCommands[strings.ToUpper(command)]
+}
+
+// unqueueableCommands are writes the op log doesn't queue: their reply is
+// what they are sent for, they block, or running them later would do
+// something else.
+var unqueueableCommands = map[string]bool{
+	"GETSET": true, "GETDEL": true, "GETEX": true, "SORT": true, "FLUSHDB": true, "FLUSHALL": true,
+	"LPOP": true, "RPOP": true, "LMOVE": true, "RPOPLPUSH": true, "BLPOP": true, "BRPOP": true, "BLMOVE": true,
+	"SPOP": true, "ZPOPMIN": true, "ZPOPMAX": true, "XREADGROUP": true, "XCLAIM": true, "XAUTOCLAIM": true,
+	"PUBLISH": true, "SCRIPT": true, "FT.CREATE": true, "FT.DROPINDEX": true,
+}
+
+// IsQueueable reports whether command may be queued in the op log while
+// Redis can't be reached: a known write wanted for its effect rather than
+// its reply.
+func IsQueueable(command string) bool {
+	command = strings.ToUpper(command)
+	return writeCommands[command] && !unqueueableCommands[command]
+}
+
+// deprecatedCommands maps commands Redis has deprecated to what replaces
+// them.
+var deprecatedCommands = map[string]string{
+	"GETSET":            "SET with GET",
+	"SETNX":             "SET with NX",
+	"SETEX":             "SET with EX",
+	"PSETEX":            "SET with PX",
+	"HMSET":             "HSET",
+	"RPOPLPUSH":         "LMOVE",
+	"BRPOPLPUSH":        "BLMOVE",
+	"GEORADIUS":         "GEOSEARCH",
+	"GEORADIUS_RO":      "GEOSEARCH",
+	"GEORADIUSBYMEMBER": "GEOSEARCH",
+	"ZRANGEBYSCORE":     "ZRANGE with BYSCORE",
+	"ZREVRANGEBYSCORE":  "ZRANGE with BYSCORE and REV",
+	"ZRANGEBYLEX":       "ZRANGE with BYLEX",
+	"ZREVRANGEBYLEX":    "ZRANGE with BYLEX and REV",
+	"ZREVRANGE":         "ZRANGE with REV",
+	"SUBSTR":            "GETRANGE",
+}
+
+// Deprecated returns what replaces command if Redis has deprecated it.
+func Deprecated(command string) (string, bool) {
+	replacement, ok := deprecatedCommands[strings.ToUpper(command)]
+	return replacement, ok
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestKnownCommands(t *testing.T) {
+	commands := KnownCommands()
+	if !sort.StringsAreSorted(commands) {
+		t.Error("Expected the commands to be sorted")
+	}
+
+	seen := make(map[string]bool)
+	for _, command := range commands {
+		if seen[command] {
+			t.Errorf("Expected %s to be either read-only or a write, not both", command)
+		}
+		seen[command] = true
+	}
+	for _, command := range []string{"GET", "SET", "HGETALL", "ZADD", "EVAL"} {
+		if !seen[command] {
+			t.Errorf("Expected %s to be known", command)
+		}
+	}
+
+	// Commands the proxy knows must pass signature validation
+	for command := range seen {
+		if !types.IsKnownCommand(command) {
+			t.Errorf("Expected %s to be in the command table", command)
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	if replacement, ok := Deprecated("getset"); !ok || replacement != "SET with GET" {
+		t.Errorf("Expected GETSET to be deprecated for SET with GET, got %q, %v", replacement, ok)
+	}
+	if _, ok := Deprecated("GET"); ok {
+		t.Error("Expected GET not to be deprecated")
+	}
+}
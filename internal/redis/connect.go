@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// connectPingTimeout bounds each connection attempt of lazy connect.
+const connectPingTimeout = 5 * time.Second
+
+// ping checks that the primary, DragonflyDB and every shard answer.
+func (c *Client) ping(ctx context.Context) error {
+	if err := c.primary.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to primary Redis: %w", err)
+	}
+	if c.dragonfly != nil {
+		if err := c.dragonfly.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("failed to connect to DragonflyDB: %w", err)
+		}
+	}
+	for _, shard := range c.shards[min(1, len(c.shards)):] {
+		if err := shard.client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("failed to connect to Redis shard %s: %w", shard.name, err)
+		}
+	}
+	return nil
+}
+
+// connect retries ping with exponential backoff until it succeeds or the
+// client is closed. err is why the first attempt failed.
+func (c *Client) connect(config types.ConnectConfig, err error) {
+	policy := types.RetryConfig{InitialBackoff: config.InitialBackoff, MaxBackoff: config.MaxBackoff, Jitter: 0.2}
+	for attempt := 0; ; attempt++ {
+		delay := backoff(policy, attempt)
+		log.Printf("Redis not reachable, retrying in %s: %v", delay.Round(time.Millisecond), err)
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), connectPingTimeout)
+		err = c.ping(ctx)
+		cancel()
+		if err == nil {
+			c.connectErr.Store(nil)
+			log.Printf("Connected to Redis after %d retries", attempt+1)
+			return
+		}
+		c.connectErr.Store(&err)
+	}
+}
+
+// Connected returns nil once every backend has answered, or why they
+// could not all be reached yet when connecting lazily.
+func (c *Client) Connected() error {
+	if err := c.connectErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
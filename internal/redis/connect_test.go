@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// serveFakeRedis answers PING with PONG, HELLO with an error so the client
+// falls back to RESP2, and everything else with OK.
+func serveFakeRedis(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				args, err := readCommand(reader)
+				if err != nil {
+					return
+				}
+				reply := "+OK\r\n"
+				switch strings.ToUpper(args[0]) {
+				case "PING":
+					reply = "+PONG\r\n"
+				case "HELLO":
+					reply = "-ERR unknown command 'HELLO'\r\n"
+				}
+				if _, err := io.WriteString(conn, reply); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestLazyConnect(t *testing.T) {
+	// Find a free address, with nothing listening on it yet
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary = types.RedisInstanceConfig{Addr: addr, MaxRetries: -1, DialTimeout: 100 * time.Millisecond}
+
+	if _, err := NewClient(config); err == nil {
+		t.Fatal("Expected NewClient to fail without lazy connect")
+	}
+
+	config.Redis.Connect = types.ConnectConfig{Lazy: true, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("Expected lazy NewClient to succeed, got %v", err)
+	}
+	defer client.Close()
+	if err := client.Connected(); err == nil || !strings.Contains(err.Error(), "primary") {
+		t.Fatalf("Expected not connected to the primary, got %v", err)
+	}
+
+	listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Address reused before the test could listen: %v", err)
+	}
+	defer listener.Close()
+	go serveFakeRedis(listener)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.Connected() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected to connect once Redis appeared, still %v", client.Connected())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,274 @@
+package redis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Table is a command reply flattened into rows for CSV output.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// WriteCSV writes the header followed by the rows.
+func (t Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// Tabulate flattens the reply to req into a table:
+//   - MGET: one key,value row per requested key
+//   - HGETALL: one field,value row per field
+//   - ZRANGE and friends: one member row, or member,score WITH SCORES
+//   - FT.SEARCH: one row per document with an id column, a score column
+//     WITHSCORES, and a column per returned field
+//
+// Any other array is one value per row and any other map one key,value row
+// per entry; nested values are JSON encoded into their cell. Both RESP2
+// and RESP3 reply shapes are accepted.
+func Tabulate(req types.CommandRequest, result interface{}) Table {
+	switch strings.ToUpper(req.Command) {
+	case "MGET":
+		return tabulateMGet(req.Args, result)
+	case "HGETALL":
+		return tabulatePairs([]string{"field", "value"}, result)
+	case "ZRANGE", "ZREVRANGE", "ZRANGEBYSCORE", "ZREVRANGEBYSCORE":
+		if hasArg(req.Args, "WITHSCORES") {
+			return tabulatePairs([]string{"member", "score"}, result)
+		}
+		return tabulateValues("member", result)
+	case "FT.SEARCH":
+		return tabulateSearch(hasArg(req.Args, "WITHSCORES"), result)
+	}
+
+	switch result.(type) {
+	case map[interface{}]interface{}, map[string]interface{}, types.MapReply, []types.HashField:
+		return tabulatePairs([]string{"key", "value"}, result)
+	}
+	return tabulateValues("value", result)
+}
+
+func tabulateMGet(args []interface{}, result interface{}) Table {
+	table := Table{Header: []string{"key", "value"}}
+	values, _ := result.([]interface{})
+	for i, value := range values {
+		key := ""
+		if i < len(args) {
+			key = csvCell(args[i])
+		}
+		table.Rows = append(table.Rows, []string{key, csvCell(value)})
+	}
+	return table
+}
+
+// tabulatePairs handles replies that are hash pairs, a map, a RESP3 array
+// of pairs or a flat RESP2 array of alternating elements.
+func tabulatePairs(header []string, result interface{}) Table {
+	table := Table{Header: header}
+
+	switch reply := result.(type) {
+	case []types.HashField:
+		for _, pair := range reply {
+			table.Rows = append(table.Rows, []string{pair.Field, csvCell(pair.Value)})
+		}
+	case map[interface{}]interface{}:
+		for key, value := range reply {
+			table.Rows = append(table.Rows, []string{csvCell(key), csvCell(value)})
+		}
+		sortRows(table.Rows)
+	case map[string]interface{}:
+		for key, value := range reply {
+			table.Rows = append(table.Rows, []string{key, csvCell(value)})
+		}
+		sortRows(table.Rows)
+	case types.MapReply:
+		return tabulatePairs(header, map[string]interface{}(reply))
+	case []interface{}:
+		if len(reply) > 0 {
+			if _, nested := reply[0].([]interface{}); nested {
+				for _, item := range reply {
+					pair, _ := item.([]interface{})
+					row := make([]string, 2)
+					for i := 0; i < len(pair) && i < 2; i++ {
+						row[i] = csvCell(pair[i])
+					}
+					table.Rows = append(table.Rows, row)
+				}
+				return table
+			}
+		}
+		for i := 0; i+1 < len(reply); i += 2 {
+			table.Rows = append(table.Rows, []string{csvCell(reply[i]), csvCell(reply[i+1])})
+		}
+	}
+	return table
+}
+
+func tabulateValues(column string, result interface{}) Table {
+	table := Table{Header: []string{column}}
+
+	switch reply := result.(type) {
+	case nil:
+	case []interface{}:
+		for _, value := range reply {
+			table.Rows = append(table.Rows, []string{csvCell(value)})
+		}
+	default:
+		table.Rows = append(table.Rows, []string{csvCell(reply)})
+	}
+	return table
+}
+
+// searchDocument is one FT.SEARCH hit before it is laid out in columns.
+type searchDocument struct {
+	id     string
+	score  string
+	fields map[string]string
+}
+
+func tabulateSearch(withScores bool, result interface{}) Table {
+	var docs []searchDocument
+	switch reply := result.(type) {
+	case []interface{}:
+		docs = parseSearchRESP2(withScores, reply)
+	case map[interface{}]interface{}:
+		docs = parseSearchRESP3(reply)
+	}
+
+	// Field columns follow the order fields are first seen in
+	var fieldNames []string
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		names := make([]string, 0, len(doc.fields))
+		for name := range doc.fields {
+			if !seen[name] {
+				names = append(names, name)
+				seen[name] = true
+			}
+		}
+		sort.Strings(names)
+		fieldNames = append(fieldNames, names...)
+	}
+
+	table := Table{Header: []string{"id"}}
+	if withScores {
+		table.Header = append(table.Header, "score")
+	}
+	table.Header = append(table.Header, fieldNames...)
+
+	for _, doc := range docs {
+		row := []string{doc.id}
+		if withScores {
+			row = append(row, doc.score)
+		}
+		for _, name := range fieldNames {
+			row = append(row, doc.fields[name])
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// parseSearchRESP2 reads [total, id, (score,) [field, value, ...], ...].
+// With NOCONTENT the field lists are absent.
+func parseSearchRESP2(withScores bool, reply []interface{}) []searchDocument {
+	var docs []searchDocument
+	for i := 1; i < len(reply); {
+		doc := searchDocument{id: csvCell(reply[i]), fields: make(map[string]string)}
+		i++
+		if withScores && i < len(reply) {
+			doc.score = csvCell(reply[i])
+			i++
+		}
+		if i < len(reply) {
+			if fields, ok := reply[i].([]interface{}); ok {
+				for j := 0; j+1 < len(fields); j += 2 {
+					doc.fields[csvCell(fields[j])] = csvCell(fields[j+1])
+				}
+				i++
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// parseSearchRESP3 reads {"results": [{"id", "score", "extra_attributes"}]}.
+func parseSearchRESP3(reply map[interface{}]interface{}) []searchDocument {
+	results, _ := reply["results"].([]interface{})
+
+	docs := make([]searchDocument, 0, len(results))
+	for _, item := range results {
+		hit, _ := item.(map[interface{}]interface{})
+		doc := searchDocument{
+			id:     csvCell(hit["id"]),
+			fields: make(map[string]string),
+		}
+		if score, ok := hit["score"]; ok {
+			doc.score = csvCell(score)
+		}
+		attributes, _ := hit["extra_attributes"].(map[interface{}]interface{})
+		for name, value := range attributes {
+			doc.fields[csvCell(name)] = csvCell(value)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// csvCell formats a single reply element. Nil becomes an empty cell.
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[csvCell(key)] = item
+		}
+		return csvCell(converted)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func hasArg(args []interface{}, name string) bool {
+	for _, arg := range args {
+		if s, ok := arg.(string); ok && strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortRows(rows [][]string) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+}
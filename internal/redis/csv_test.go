@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTabulateMGet(t *testing.T) {
+	table := Tabulate(types.CommandRequest{Command: "mget", Args: []interface{}{"a", "b"}},
+		[]interface{}{"1", nil})
+
+	want := [][]string{{"a", "1"}, {"b", ""}}
+	if !reflect.DeepEqual(table.Header, []string{"key", "value"}) || !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("Unexpected table: %+v", table)
+	}
+}
+
+func TestTabulateHGetAll(t *testing.T) {
+	req := types.CommandRequest{Command: "HGETALL", Args: []interface{}{"user:1"}}
+	want := [][]string{{"age", "30"}, {"name", "Ada"}}
+
+	resp2 := Tabulate(req, []interface{}{"age", "30", "name", "Ada"})
+	if !reflect.DeepEqual(resp2.Rows, want) {
+		t.Errorf("Unexpected RESP2 rows: %v", resp2.Rows)
+	}
+
+	resp3 := Tabulate(req, map[interface{}]interface{}{"name": "Ada", "age": "30"})
+	if !reflect.DeepEqual(resp3.Rows, want) {
+		t.Errorf("Unexpected RESP3 rows: %v", resp3.Rows)
+	}
+}
+
+func TestTabulateZRangeWithScores(t *testing.T) {
+	req := types.CommandRequest{Command: "ZRANGE", Args: []interface{}{"board", 0, -1, "withscores"}}
+	want := [][]string{{"alice", "1.5"}, {"bob", "2"}}
+
+	resp2 := Tabulate(req, []interface{}{"alice", "1.5", "bob", "2"})
+	if !reflect.DeepEqual(resp2.Header, []string{"member", "score"}) || !reflect.DeepEqual(resp2.Rows, want) {
+		t.Errorf("Unexpected RESP2 table: %+v", resp2)
+	}
+
+	resp3 := Tabulate(req, []interface{}{[]interface{}{"alice", 1.5}, []interface{}{"bob", float64(2)}})
+	if !reflect.DeepEqual(resp3.Rows, want) {
+		t.Errorf("Unexpected RESP3 rows: %v", resp3.Rows)
+	}
+
+	plain := Tabulate(types.CommandRequest{Command: "ZRANGE", Args: []interface{}{"board", 0, -1}},
+		[]interface{}{"alice", "bob"})
+	if !reflect.DeepEqual(plain.Rows, [][]string{{"alice"}, {"bob"}}) {
+		t.Errorf("Unexpected rows without scores: %v", plain.Rows)
+	}
+}
+
+func TestTabulateSearch(t *testing.T) {
+	req := types.CommandRequest{Command: "FT.SEARCH", Args: []interface{}{"idx", "*"}}
+	reply := []interface{}{
+		int64(2),
+		"doc:1", []interface{}{"title", "Hello", "views", "10"},
+		"doc:2", []interface{}{"title", "World", "author", "Bo"},
+	}
+
+	table := Tabulate(req, reply)
+	wantHeader := []string{"id", "title", "views", "author"}
+	wantRows := [][]string{{"doc:1", "Hello", "10", ""}, {"doc:2", "World", "", "Bo"}}
+	if !reflect.DeepEqual(table.Header, wantHeader) || !reflect.DeepEqual(table.Rows, wantRows) {
+		t.Errorf("Unexpected table: %+v", table)
+	}
+
+	resp3 := Tabulate(req, map[interface{}]interface{}{
+		"total_results": int64(1),
+		"results": []interface{}{
+			map[interface{}]interface{}{"id": "doc:1", "extra_attributes": map[interface{}]interface{}{"title": "Hello"}},
+		},
+	})
+	if !reflect.DeepEqual(resp3.Rows, [][]string{{"doc:1", "Hello"}}) {
+		t.Errorf("Unexpected RESP3 rows: %v", resp3.Rows)
+	}
+}
+
+func TestTableWriteCSV(t *testing.T) {
+	table := Tabulate(types.CommandRequest{Command: "LRANGE"}, []interface{}{"a,b", int64(3), []interface{}{"x"}})
+
+	var buf bytes.Buffer
+	if err := table.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if want := "value\n\"a,b\"\n3\n\"[\"\"x\"\"]\"\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// namedDatabase is where a named database lives.
+type namedDatabase struct {
+	client *redis.Client
+	db     int
+}
+
+// resolveNamedDatabases maps each configured database name to its backend.
+func (c *Client) resolveNamedDatabases(config map[string]types.NamedDatabaseConfig) error {
+	clients := map[string]*redis.Client{"primary": c.primary}
+	if c.dragonfly != nil {
+		clients["dragonfly"] = c.dragonfly
+	}
+	for _, shard := range c.shards {
+		clients[shard.name] = shard.client
+	}
+
+	c.databases = make(map[string]namedDatabase, len(config))
+	for name, database := range config {
+		client, ok := clients[database.Backend]
+		if !ok {
+			return fmt.Errorf("named database %s: unknown backend %q", name, database.Backend)
+		}
+		c.databases[name] = namedDatabase{client: client, db: database.DB}
+	}
+	return nil
+}
+
+// database returns the backend and db a request addresses. Requests that
+// don't name a database get a nil backend and their own db, leaving the
+// backend to the usual routing.
+func (c *Client) database(name string, db int) (*redis.Client, int, error) {
+	if name == "" {
+		return nil, db, nil
+	}
+	database, ok := c.databases[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("database %q is not configured", name)
+	}
+	return database.client, database.db, nil
+}
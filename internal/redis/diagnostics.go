@@ -0,0 +1,131 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// BackendNames lists the backends INFO and SLOWLOG can be read from.
+func (c *Client) BackendNames() []string {
+	backends := c.backends()
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.name
+	}
+	return names
+}
+
+// BackendInfo runs INFO, for section or the default sections if it is
+// empty, on every backend, or only on the one called name if it is set.
+// Backends are queried concurrently; each failure is reported in its
+// entry.
+func (c *Client) BackendInfo(ctx context.Context, name, section string) []types.BackendInfo {
+	var sections []string
+	if section != "" {
+		sections = []string{section}
+	}
+
+	backends := c.selectBackends(name)
+	infos := make([]types.BackendInfo, len(backends))
+
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		go func(i int, b backend) {
+			defer wg.Done()
+			infos[i].Backend = b.name
+
+			info, err := b.client.Info(ctx, sections...).Result()
+			if err != nil {
+				infos[i].Error = err.Error()
+				return
+			}
+			infos[i].Sections = ParseInfo(info)
+		}(i, b)
+	}
+	wg.Wait()
+
+	return infos
+}
+
+// BackendSlowLog runs SLOWLOG GET count on every backend, or only on the
+// one called name if it is set.
+func (c *Client) BackendSlowLog(ctx context.Context, name string, count int64) []types.BackendSlowLog {
+	backends := c.selectBackends(name)
+	logs := make([]types.BackendSlowLog, len(backends))
+
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		go func(i int, b backend) {
+			defer wg.Done()
+			logs[i].Backend = b.name
+
+			entries, err := b.client.SlowLogGet(ctx, count).Result()
+			if err != nil {
+				logs[i].Error = err.Error()
+				return
+			}
+			logs[i].Entries = make([]types.RedisSlowEntry, len(entries))
+			for j, entry := range entries {
+				logs[i].Entries[j] = types.RedisSlowEntry{
+					ID:         entry.ID,
+					Time:       entry.Time,
+					DurationUs: entry.Duration.Microseconds(),
+					Args:       entry.Args,
+					ClientAddr: entry.ClientAddr,
+					ClientName: entry.ClientName,
+				}
+			}
+		}(i, b)
+	}
+	wg.Wait()
+
+	return logs
+}
+
+func (c *Client) selectBackends(name string) []backend {
+	backends := c.backends()
+	if name == "" {
+		return backends
+	}
+	for _, b := range backends {
+		if b.name == name {
+			return []backend{b}
+		}
+	}
+	return nil
+}
+
+// ParseInfo splits an INFO reply into its sections, keyed by lower-case
+// section name, each with its fields.
+func ParseInfo(info string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	current := "default"
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			current = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if sections[current] == nil {
+			sections[current] = make(map[string]string)
+		}
+		sections[current][key] = value
+	}
+
+	return sections
+}
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseInfo(t *testing.T) {
+	info := "# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:3600\r\n\r\n# Memory\r\nused_memory:943718\r\nmaxmemory_policy:noeviction\r\n\r\n# Keyspace\r\ndb0:keys=10,expires=2,avg_ttl=0\r\n"
+
+	sections := ParseInfo(info)
+	if len(sections) != 3 {
+		t.Fatalf("Expected 3 sections, got %v", sections)
+	}
+	if sections["server"]["redis_version"] != "7.2.4" || sections["memory"]["used_memory"] != "943718" {
+		t.Errorf("Unexpected sections: %v", sections)
+	}
+	if sections["keyspace"]["db0"] != "keys=10,expires=2,avg_ttl=0" {
+		t.Errorf("Expected values with colons kept whole, got %q", sections["keyspace"]["db0"])
+	}
+}
+
+func TestBackendInfoUnreachable(t *testing.T) {
+	primary := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	c := &Client{primary: primary, pools: make(map[poolKey]*redis.Client), done: make(chan struct{})}
+	defer c.Close()
+
+	infos := c.BackendInfo(context.Background(), "", "memory")
+	if len(infos) != 1 || infos[0].Backend != "primary" || infos[0].Error == "" || infos[0].Sections != nil {
+		t.Errorf("Expected the primary's error, got %+v", infos)
+	}
+	if infos := c.BackendInfo(context.Background(), "replica0", ""); len(infos) != 0 {
+		t.Errorf("Expected no unknown backend, got %+v", infos)
+	}
+}
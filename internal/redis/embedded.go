@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"github.com/alicebob/miniredis/v2"
+)
+
+// Embedded is an in-memory Redis served from inside the proxy process, for
+// running the API locally or in CI without a Redis server. Its data is lost
+// when the proxy stops, it implements most but not all commands, and it has
+// no modules, persistence, replication or memory limit.
+type Embedded struct {
+	server *miniredis.Miniredis
+}
+
+// StartEmbedded starts an embedded Redis on a random local port.
+func StartEmbedded() (*Embedded, error) {
+	server := miniredis.NewMiniRedis()
+	if err := server.Start(); err != nil {
+		return nil, err
+	}
+	return &Embedded{server: server}, nil
+}
+
+// Addr returns the address the embedded Redis listens on.
+func (e *Embedded) Addr() string {
+	return e.server.Addr()
+}
+
+// Close stops the embedded Redis, discarding its data.
+func (e *Embedded) Close() {
+	e.server.Close()
+}
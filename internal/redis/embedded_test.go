@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestEmbedded(t *testing.T) {
+	embedded, err := StartEmbedded()
+	if err != nil {
+		t.Fatalf("StartEmbedded() error = %v", err)
+	}
+	defer embedded.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary.Addr = embedded.Addr()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.ExecuteCommand(ctx, types.CommandRequest{Command: "SET", Args: []interface{}{"greeting", "hello"}}); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	result, err := client.ExecuteCommand(ctx, types.CommandRequest{Command: "GET", Args: []interface{}{"greeting"}})
+	if err != nil || result != "hello" {
+		t.Errorf("Expected GET to return hello, got %v, %v", result, err)
+	}
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ParseGeoSearch converts the reply of GEOSEARCH ... WITHDIST WITHCOORD,
+// an array of [name, distance, [lon, lat]] entries, into results.
+func ParseGeoSearch(reply interface{}) ([]types.GeoResult, error) {
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected GEOSEARCH reply %T", reply)
+	}
+
+	results := make([]types.GeoResult, 0, len(entries))
+	for _, entry := range entries {
+		fields, _ := entry.([]interface{})
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected GEOSEARCH entry %v", entry)
+		}
+		coords, _ := fields[2].([]interface{})
+		if len(coords) != 2 {
+			return nil, fmt.Errorf("unexpected GEOSEARCH coordinates %v", fields[2])
+		}
+
+		var result types.GeoResult
+		result.Name = fmt.Sprint(fields[0])
+		var err error
+		if result.Distance, err = replyFloat(fields[1]); err != nil {
+			return nil, err
+		}
+		if result.Lon, err = replyFloat(coords[0]); err != nil {
+			return nil, err
+		}
+		if result.Lat, err = replyFloat(coords[1]); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// replyFloat reads a number such as a distance or score, a bulk string
+// under RESP2 and a double under RESP3.
+func replyFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("unexpected number %T", value)
+}
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestParseGeoSearch(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"Palermo", "190.4424", []interface{}{"13.36138933897018433", "38.11555639549629859"}},
+		[]interface{}{"Catania", 56.4413, []interface{}{15.08726745843887329, 37.50266842333162032}},
+	}
+
+	results, err := ParseGeoSearch(reply)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	expected := []types.GeoResult{
+		{Name: "Palermo", Distance: 190.4424, Lon: 13.36138933897018433, Lat: 38.11555639549629859},
+		{Name: "Catania", Distance: 56.4413, Lon: 15.08726745843887329, Lat: 37.50266842333162032},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+
+	if results, err := ParseGeoSearch([]interface{}{}); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results, got %v, %v", results, err)
+	}
+	if _, err := ParseGeoSearch([]interface{}{"Palermo"}); err == nil {
+		t.Error("Expected an entry without distance and coordinates to fail")
+	}
+}
@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// mapReply says how a command's reply holds maps, which RESP2 sends as flat
+// arrays of alternating fields and values and RESP3 as maps.
+type mapReply int
+
+const (
+	noMap mapReply = iota
+	// singleMap replies are one map, such as HGETALL's
+	singleMap
+	// mapList replies are an array of maps, such as XINFO GROUPS'
+	mapList
+)
+
+// mapReplies lists the commands replying with maps, by command or command
+// and subcommand.
+var mapReplies = map[string]mapReply{
+	"HGETALL":             singleMap,
+	"CONFIG GET":          singleMap,
+	"XINFO STREAM":        singleMap,
+	"MEMORY STATS":        singleMap,
+	"CLIENT TRACKINGINFO": singleMap,
+	"FUNCTION STATS":      singleMap,
+	"HELLO":               singleMap,
+	"XINFO GROUPS":        mapList,
+	"XINFO CONSUMERS":     mapList,
+	"MODULE LIST":         mapList,
+	"FUNCTION LIST":       mapList,
+	"ACL LOG":             mapList,
+}
+
+// mapReplyOf returns how req's reply holds maps. HRANDFIELD only replies
+// with fields and values WITHVALUES.
+func mapReplyOf(req types.CommandRequest) mapReply {
+	command := strings.ToUpper(req.Command)
+	if command == "HRANDFIELD" {
+		if hasArg(req.Args, "WITHVALUES") {
+			return singleMap
+		}
+		return noMap
+	}
+	if kind, ok := mapReplies[command]; ok {
+		return kind
+	}
+	if len(req.Args) > 0 {
+		if sub, ok := req.Args[0].(string); ok {
+			return mapReplies[command+" "+strings.ToUpper(sub)]
+		}
+	}
+	return noMap
+}
+
+// shapeReply returns the maps in replies in req's hash format, trimmed to
+// req's fields and max items. Other replies are returned unchanged, unless
+// req asked for RESP3.
+func shapeReply(req types.CommandRequest, val interface{}) interface{} {
+	if req.RESP3 {
+		val = typedReply(req, val)
+	} else {
+		val = shapeMaps(req, val, func(value interface{}) interface{} { return value })
+	}
+	return projectReply(req, val)
+}
+
+// shapeMaps shapes the maps of req's reply with shapeHash, converting
+// their values with convert. Replies that aren't in the expected shape,
+// such as a nil for a missing stream, are returned converted.
+func shapeMaps(req types.CommandRequest, val interface{}, convert func(interface{}) interface{}) interface{} {
+	switch mapReplyOf(req) {
+	case singleMap:
+		if pairs, ok := hashPairs(val, convert); ok {
+			return shapeHash(req, pairs)
+		}
+	case mapList:
+		if list, ok := val.([]interface{}); ok {
+			shaped := make([]interface{}, len(list))
+			for i, item := range list {
+				pairs, ok := hashPairs(item, convert)
+				if !ok {
+					return convert(val)
+				}
+				shaped[i] = shapeHash(req, pairs)
+			}
+			return shaped
+		}
+	}
+	return convert(val)
+}
+
+// hashPairs reads the fields and values of a map reply: a flat RESP2 array,
+// a RESP3 map, or a RESP3 array of pairs as HRANDFIELD sends. Go maps don't
+// keep the order Redis sent, so the pairs of a RESP3 map are sorted.
+func hashPairs(val interface{}, convert func(interface{}) interface{}) ([]types.HashField, bool) {
+	switch reply := val.(type) {
+	case map[interface{}]interface{}:
+		pairs := make([]types.HashField, 0, len(reply))
+		for field, value := range reply {
+			pairs = append(pairs, types.HashField{Field: fmt.Sprint(field), Value: convert(value)})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Field < pairs[j].Field })
+		return pairs, true
+	case []interface{}:
+		if len(reply) > 0 {
+			if _, nested := reply[0].([]interface{}); nested {
+				pairs := make([]types.HashField, 0, len(reply))
+				for _, item := range reply {
+					pair, ok := item.([]interface{})
+					if !ok || len(pair) != 2 {
+						return nil, false
+					}
+					pairs = append(pairs, types.HashField{Field: fmt.Sprint(pair[0]), Value: convert(pair[1])})
+				}
+				return pairs, true
+			}
+		}
+		if len(reply)%2 != 0 {
+			return nil, false
+		}
+		pairs := make([]types.HashField, 0, len(reply)/2)
+		for i := 0; i+1 < len(reply); i += 2 {
+			pairs = append(pairs, types.HashField{Field: fmt.Sprint(reply[i]), Value: convert(reply[i+1])})
+		}
+		return pairs, true
+	}
+	return nil, false
+}
+
+// shapeHash returns the fields of a hash reply in req's hash format.
+func shapeHash(req types.CommandRequest, pairs []types.HashField) interface{} {
+	switch req.HashFormat {
+	case types.HashFormatPairs:
+		return pairs
+	case types.HashFormatSorted:
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Field < pairs[j].Field })
+		return pairs
+	}
+
+	// encoding/json writes object keys in sorted order
+	object := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		object[pair.Field] = pair.Value
+	}
+	return object
+}
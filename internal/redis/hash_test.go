@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestShapeReplyHashFormats(t *testing.T) {
+	reply := []interface{}{"zeta", "1", "alpha", "2"}
+
+	object := shapeReply(types.CommandRequest{Command: "hgetall"}, reply)
+	encoded, _ := json.Marshal(object)
+	if string(encoded) != `{"alpha":"2","zeta":"1"}` {
+		t.Errorf("Expected object with sorted keys, got %s", encoded)
+	}
+
+	pairs := shapeReply(types.CommandRequest{Command: "HGETALL", HashFormat: types.HashFormatPairs}, reply)
+	want := []types.HashField{{Field: "zeta", Value: "1"}, {Field: "alpha", Value: "2"}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("Expected pairs in Redis order, got %v", pairs)
+	}
+
+	sorted := shapeReply(types.CommandRequest{Command: "HGETALL", HashFormat: types.HashFormatSorted}, reply)
+	want = []types.HashField{{Field: "alpha", Value: "2"}, {Field: "zeta", Value: "1"}}
+	if !reflect.DeepEqual(sorted, want) {
+		t.Errorf("Expected pairs sorted by field, got %v", sorted)
+	}
+
+	config := shapeReply(types.CommandRequest{Command: "CONFIG", Args: []interface{}{"get", "maxmemory"}},
+		[]interface{}{"maxmemory", "0"})
+	if !reflect.DeepEqual(config, map[string]interface{}{"maxmemory": "0"}) {
+		t.Errorf("Expected CONFIG GET to be shaped as a hash, got %v", config)
+	}
+
+	if got := shapeReply(types.CommandRequest{Command: "LRANGE"}, reply); !reflect.DeepEqual(got, reply) {
+		t.Errorf("Expected non-hash replies unchanged, got %v", got)
+	}
+}
+
+func TestShapeReplyMapCommands(t *testing.T) {
+	object := map[string]interface{}{"a": "1", "b": "2"}
+	pairs := []types.HashField{{Field: "a", Value: "1"}, {Field: "b", Value: "2"}}
+
+	tests := []struct {
+		name  string
+		req   types.CommandRequest
+		reply interface{}
+		want  interface{}
+	}{
+		{"HGETALL", types.CommandRequest{Command: "HGETALL"}, []interface{}{"a", "1", "b", "2"}, object},
+		{"HGETALL RESP3", types.CommandRequest{Command: "HGETALL", RESP3: true},
+			map[interface{}]interface{}{"b": "2", "a": "1"}, object},
+		{"HRANDFIELD WITHVALUES", types.CommandRequest{Command: "HRANDFIELD", Args: []interface{}{"h", 2, "withvalues"}},
+			[]interface{}{"a", "1", "b", "2"}, object},
+		{"HRANDFIELD WITHVALUES RESP3", types.CommandRequest{Command: "HRANDFIELD", Args: []interface{}{"h", 2, "WITHVALUES"}, RESP3: true, HashFormat: types.HashFormatPairs},
+			[]interface{}{[]interface{}{"a", "1"}, []interface{}{"b", "2"}}, pairs},
+		{"HRANDFIELD", types.CommandRequest{Command: "HRANDFIELD", Args: []interface{}{"h", 2}},
+			[]interface{}{"a", "b"}, []interface{}{"a", "b"}},
+		{"CONFIG GET", types.CommandRequest{Command: "config", Args: []interface{}{"get", "*"}},
+			[]interface{}{"a", "1", "b", "2"}, object},
+		{"XINFO STREAM", types.CommandRequest{Command: "XINFO", Args: []interface{}{"STREAM", "s"}, HashFormat: types.HashFormatPairs},
+			[]interface{}{"a", "1", "b", "2"}, pairs},
+		{"MEMORY STATS RESP3", types.CommandRequest{Command: "MEMORY", Args: []interface{}{"STATS"}, RESP3: true},
+			map[interface{}]interface{}{"a": "1", "b": "2"}, object},
+		{"XINFO GROUPS", types.CommandRequest{Command: "XINFO", Args: []interface{}{"GROUPS", "s"}},
+			[]interface{}{[]interface{}{"a", "1", "b", "2"}, []interface{}{}},
+			[]interface{}{object, map[string]interface{}{}}},
+		{"XINFO CONSUMERS RESP3", types.CommandRequest{Command: "XINFO", Args: []interface{}{"CONSUMERS", "s", "g"}, RESP3: true, HashFormat: types.HashFormatSorted},
+			[]interface{}{map[interface{}]interface{}{"b": "2", "a": "1"}},
+			[]interface{}{pairs}},
+		{"MODULE LIST empty", types.CommandRequest{Command: "MODULE", Args: []interface{}{"LIST"}},
+			[]interface{}{}, []interface{}{}},
+		{"XINFO STREAM missing", types.CommandRequest{Command: "XINFO", Args: []interface{}{"STREAM", "s"}},
+			nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shapeReply(tt.req, tt.reply); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
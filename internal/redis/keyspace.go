@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyspaceEvents are the notify-keyspace-events classes WatchKeyspace
+// needs: keyspace channels, with generic events such as DEL and EXPIRE,
+// and hash events.
+const keyspaceEvents = "Kgh"
+
+// ConfigureKeyspaceEvents adds keyspaceEvents to the primary's
+// notify-keyspace-events, keeping the classes already enabled. Managed
+// Redis services often refuse CONFIG SET; there it must be set through
+// the provider.
+func (c *Client) ConfigureKeyspaceEvents(ctx context.Context) error {
+	current, err := c.primary.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+	events := mergeKeyspaceEvents(current["notify-keyspace-events"], keyspaceEvents)
+	if events == current["notify-keyspace-events"] {
+		return nil
+	}
+	return c.primary.ConfigSet(ctx, "notify-keyspace-events", events).Err()
+}
+
+// mergeKeyspaceEvents adds the classes in wanted to the
+// notify-keyspace-events value current. A covers every class of event.
+func mergeKeyspaceEvents(current, wanted string) string {
+	events := current
+	for _, class := range wanted {
+		covered := strings.ContainsRune(events, class) ||
+			strings.ContainsRune(events, 'A') && strings.ContainsRune("g$lshzxetd", class)
+		if !covered {
+			events += string(class)
+		}
+	}
+	return events
+}
+
+// WatchKeyspace calls fn with every key in db starting with prefix that
+// changes, from keyspace notifications of the primary, until ctx is done.
+// Redis only sends them once notify-keyspace-events is set, see
+// ConfigureKeyspaceEvents. Notifications sent while the subscription is
+// down are lost, so fn is called with an empty key once it is back.
+func (c *Client) WatchKeyspace(ctx context.Context, db int, prefix string, fn func(key string)) {
+	channel := fmt.Sprintf("__keyspace@%d__:", db)
+	pubsub := c.primary.PSubscribe(ctx, channel+escapeGlob(prefix)+"*")
+	defer pubsub.Close()
+
+	lost := false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, time.Minute)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.Is(err, redis.ErrClosed):
+				return
+			case errors.As(err, &netErr) && netErr.Timeout():
+				// Quiet, not necessarily gone; the PONG is the next message
+				_ = pubsub.Ping(ctx)
+			case errors.Is(err, io.EOF) || errors.As(err, &netErr):
+				// The next receive reconnects
+				if !lost {
+					log.Printf("Keyspace notification connection lost: %v", err)
+					lost = true
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if lost {
+				lost = false
+				fn("")
+			}
+		case *redis.Message:
+			fn(strings.TrimPrefix(msg.Channel, channel))
+		}
+	}
+}
+
+// escapeGlob escapes the characters PSUBSCRIBE patterns treat specially.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestMergeKeyspaceEvents(t *testing.T) {
+	tests := []struct {
+		current, want string
+	}{
+		{"", "Kgh"},
+		{"Ex", "ExKgh"},
+		{"KA", "KA"},
+		{"hEK", "hEKg"},
+	}
+	for _, tt := range tests {
+		if got := mergeKeyspaceEvents(tt.current, "Kgh"); got != tt.want {
+			t.Errorf("mergeKeyspaceEvents(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestWatchKeyspace(t *testing.T) {
+	embedded, err := StartEmbedded()
+	if err != nil {
+		t.Fatalf("StartEmbedded() error = %v", err)
+	}
+	defer embedded.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary.Addr = embedded.Addr()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys := make(chan string, 10)
+	go client.WatchKeyspace(ctx, 2, "sr:flags:", func(key string) { keys <- key })
+
+	// The embedded Redis doesn't send keyspace notifications itself, so
+	// publish them until the subscription is up
+	publish := func(channel string) {
+		_, _ = client.ExecuteCommand(ctx, types.CommandRequest{Command: "PUBLISH", Args: []interface{}{channel, "hset"}})
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		publish("__keyspace@0__:sr:flags:acme")
+		publish("__keyspace@2__:other")
+		publish("__keyspace@2__:sr:flags:acme")
+		select {
+		case key := <-keys:
+			if key != "sr:flags:acme" {
+				t.Fatalf("Expected only sr:flags:acme in db 2, got %q", key)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Expected a notification")
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// uploadTTL expires the temporary key of an upload the proxy didn't finish,
+// e.g. because it was killed halfway through.
+const uploadTTL = time.Hour
+
+// LargeValue is a string value longer than the threshold it was read with.
+// Only its first bytes have been read; WriteTo streams the rest.
+type LargeValue struct {
+	client    *redis.Client
+	key       string
+	head      string
+	chunkSize int
+}
+
+// Head returns the bytes of the value read so far, at least enough to
+// sniff its content type from.
+func (v *LargeValue) Head() string {
+	return v.head
+}
+
+// WriteTo writes the value to w, reading what follows the head with one
+// GETRANGE per chunk, so no more than a chunk is held at a time. The
+// chunks are separate reads: a value overwritten while it streams may
+// come out as a mix of both.
+func (v *LargeValue) WriteTo(ctx context.Context, w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, v.head)
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for {
+		chunk, err := v.client.GetRange(ctx, v.key, written, written+int64(v.chunkSize)-1).Result()
+		if err != nil {
+			return written, err
+		}
+		n, err := io.WriteString(w, chunk)
+		written += int64(n)
+		if err != nil || len(chunk) < v.chunkSize {
+			return written, err
+		}
+	}
+}
+
+// valueClient returns the client for streaming the value at key with
+// command, picked the way ExecuteCommand would.
+func (c *Client) valueClient(ctx context.Context, command, key string, db int) (*redis.Client, error) {
+	target, err := c.shardClient(types.CommandRequest{Command: command, Args: []interface{}{key}})
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		target, _ = c.selectClient(ctx, command)
+	}
+	return c.pool(ctx, target, db), nil
+}
+
+// ReadValue reads the string at key with one round trip, like GET, as long
+// as it is at most threshold bytes. Longer values are returned as a
+// *LargeValue holding their first threshold+1 bytes, to be streamed in
+// chunks of chunkSize. A missing key returns redis.Nil. Values in the
+// client-side cache are served from it, but misses don't fill it.
+func (c *Client) ReadValue(ctx context.Context, db int, key string, threshold, chunkSize int) (interface{}, error) {
+	if c.clientCache != nil {
+		req := types.CommandRequest{Command: "GET", Args: []interface{}{key}}
+		if _, ok := cacheableGet(ctx, req, nil, db); ok && c.ring == nil {
+			if value, hit, err := c.clientCache.cached(key); hit {
+				return value, err
+			}
+		}
+	}
+
+	client, err := c.valueClient(ctx, "GETRANGE", key, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists *redis.IntCmd
+	var head *redis.StringCmd
+	_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		exists = pipe.Exists(ctx, key)
+		head = pipe.GetRange(ctx, key, 0, int64(threshold))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if exists.Val() == 0 {
+		return nil, redis.Nil
+	}
+
+	if len(head.Val()) <= threshold {
+		return head.Val(), nil
+	}
+	return &LargeValue{client: client, key: key, head: head.Val(), chunkSize: chunkSize}, nil
+}
+
+// WriteValue sets key to everything read from r, sending it in chunks of
+// chunkSize so no more than a chunk is held at a time. The chunks are
+// appended to a temporary key on the same backend, which replaces key once
+// complete: readers never see a partial value, and a failed upload leaves
+// key as it was. Like SET, it clears any TTL on key, or sets the one of
+// the TTL policy.
+func (c *Client) WriteValue(ctx context.Context, db int, key string, r io.Reader, chunkSize int) (int64, error) {
+	client, err := c.valueClient(ctx, "SET", key, db)
+	if err != nil {
+		return 0, err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return 0, err
+	}
+	tempKey := key + ":upload:" + hex.EncodeToString(suffix)
+
+	written, err := upload(ctx, client, tempKey, r, chunkSize)
+	if err == nil {
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Rename(ctx, tempKey, key)
+			if ttl := ttlPolicyFromContext(ctx).ttl(); ttl > 0 {
+				pipe.Expire(ctx, key, ttl)
+			} else {
+				pipe.Persist(ctx, key)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		client.Del(context.WithoutCancel(ctx), tempKey)
+		return written, err
+	}
+
+	if c.clientCache != nil {
+		c.clientCache.wrote(types.CommandRequest{Command: "SET", Args: []interface{}{key}})
+	}
+	return written, nil
+}
+
+// upload copies r to key one chunk at a time.
+func upload(ctx context.Context, client *redis.Client, key string, r io.Reader, chunkSize int) (int64, error) {
+	if err := client.Set(ctx, key, "", uploadTTL).Err(); err != nil {
+		return 0, err
+	}
+
+	chunk := make([]byte, chunkSize)
+	var written int64
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			if appendErr := client.Do(ctx, "APPEND", key, chunk[:n]).Err(); appendErr != nil {
+				return written, appendErr
+			}
+			written += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// MemoryInfo is the subset of INFO memory used to report memory pressure.
+type MemoryInfo struct {
+	UsedMemory      int64
+	MaxMemory       int64
+	MaxMemoryPolicy string
+}
+
+// MemoryInfo reads memory usage and the eviction policy from the primary.
+func (c *Client) MemoryInfo(ctx context.Context) (MemoryInfo, error) {
+	info, err := c.primary.Info(ctx, "memory").Result()
+	if err != nil {
+		return MemoryInfo{}, err
+	}
+	return parseMemoryInfo(info), nil
+}
+
+func parseMemoryInfo(info string) MemoryInfo {
+	var mem MemoryInfo
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "used_memory":
+			mem.UsedMemory, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory":
+			mem.MaxMemory, _ = strconv.ParseInt(value, 10, 64)
+		case "maxmemory_policy":
+			mem.MaxMemoryPolicy = value
+		}
+	}
+
+	return mem
+}
@@ -0,0 +1,12 @@
+package redis
+
+import "testing"
+
+func TestParseMemoryInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:943718\r\nused_memory_human:921.60K\r\nmaxmemory:1048576\r\nmaxmemory_policy:noeviction\r\n"
+
+	mem := parseMemoryInfo(info)
+	if mem.UsedMemory != 943718 || mem.MaxMemory != 1048576 || mem.MaxMemoryPolicy != "noeviction" {
+		t.Errorf("Unexpected memory info: %+v", mem)
+	}
+}
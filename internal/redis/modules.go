@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Module names as MODULE LIST reports them, lowercased
+const (
+	ModuleBloom = "bf"
+	ModuleJSON  = "rejson"
+
+	// Vector sets, built into Redis 8
+	ModuleVectorSet = "vectorset"
+)
+
+// Modules runs MODULE LIST on every writable backend and returns the
+// modules, by lowercase name, that all of them have loaded, since a
+// command may be routed to any of them. A backend that fails to answer is
+// reported in the error, with the modules of the others still returned.
+func (c *Client) Modules(ctx context.Context) (map[string]bool, error) {
+	var modules map[string]bool
+	var failed []string
+	for _, b := range c.backends() {
+		if b.readOnly {
+			continue
+		}
+
+		reply, err := b.client.Do(ctx, "MODULE", "LIST").Result()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", b.name, err))
+			continue
+		}
+
+		loaded := make(map[string]bool)
+		for _, name := range moduleNames(reply) {
+			if modules == nil || modules[name] {
+				loaded[name] = true
+			}
+		}
+		modules = loaded
+	}
+
+	if modules == nil {
+		modules = make(map[string]bool)
+	}
+	if len(failed) > 0 {
+		return modules, fmt.Errorf("MODULE LIST failed on %s", strings.Join(failed, ", "))
+	}
+	return modules, nil
+}
+
+// moduleNames extracts the module names from a MODULE LIST reply, an array
+// of flat name/value arrays under RESP2 or of maps under RESP3.
+func moduleNames(reply interface{}) []string {
+	entries, _ := reply.([]interface{})
+
+	var names []string
+	for _, entry := range entries {
+		var name interface{}
+		switch entry := entry.(type) {
+		case []interface{}:
+			for i := 0; i+1 < len(entry); i += 2 {
+				if entry[i] == "name" {
+					name = entry[i+1]
+				}
+			}
+		case map[interface{}]interface{}:
+			name = entry["name"]
+		}
+		if name, ok := name.(string); ok {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	return names
+}
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestModuleNames(t *testing.T) {
+	resp2 := []interface{}{
+		[]interface{}{"name", "bf", "ver", int64(20612), "path", "/opt/redis-stack/lib/redisbloom.so", "args", []interface{}{}},
+		[]interface{}{"name", "ReJSON", "ver", int64(20609)},
+	}
+	if names := moduleNames(resp2); !reflect.DeepEqual(names, []string{"bf", "rejson"}) {
+		t.Errorf("Expected bf and rejson, got %v", names)
+	}
+
+	resp3 := []interface{}{
+		map[interface{}]interface{}{"name": "bf", "ver": int64(20612)},
+	}
+	if names := moduleNames(resp3); !reflect.DeepEqual(names, []string{"bf"}) {
+		t.Errorf("Expected bf, got %v", names)
+	}
+
+	if names := moduleNames([]interface{}{}); len(names) != 0 {
+		t.Errorf("Expected no modules, got %v", names)
+	}
+}
+
+func TestModulesUnreachable(t *testing.T) {
+	primary := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	c := &Client{primary: primary, pools: make(map[poolKey]*redis.Client), done: make(chan struct{})}
+	defer c.Close()
+
+	modules, err := c.Modules(context.Background())
+	if err == nil || len(modules) != 0 {
+		t.Errorf("Expected no modules and an error, got %v, %v", modules, err)
+	}
+}
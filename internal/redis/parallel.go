@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// runParallelPipeline executes commands as up to parallelism pipelines on
+// separate connections of redisClient, returning the results in order.
+// Commands sharing a key stay in one pipeline, in their original order,
+// so splitting only reorders commands that can't observe each other.
+func runParallelPipeline(ctx context.Context, redisClient *redis.Client, commands []types.CommandRequest, parallelism int) []types.CommandResponse {
+	groups := partitionPipeline(commands, parallelism)
+	if len(groups) <= 1 {
+		return runPipeline(ctx, redisClient, commands)
+	}
+
+	results := make([]types.CommandResponse, len(commands))
+	var wg sync.WaitGroup
+	for _, indexes := range groups {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+
+			group := make([]types.CommandRequest, len(indexes))
+			for i, index := range indexes {
+				group[i] = commands[index]
+			}
+			for i, result := range runPipeline(ctx, redisClient, group) {
+				results[indexes[i]] = result
+			}
+		}(indexes)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// partitionPipeline splits the indexes of commands into at most n groups
+// with no key in two groups, balanced by command count. A command without
+// keys may depend on every key, like FLUSHDB or SCAN, so a pipeline with
+// one isn't split.
+func partitionPipeline(commands []types.CommandRequest, n int) [][]int {
+	if n <= 1 || len(commands) <= 1 {
+		return nil
+	}
+
+	// Union commands that share a key
+	parent := make([]int, len(commands))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	owner := make(map[string]int)
+	for i, cmd := range commands {
+		keys := commandKeys(cmd)
+		if len(keys) == 0 {
+			return nil
+		}
+		for _, key := range keys {
+			if j, ok := owner[key]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[key] = i
+			}
+		}
+	}
+
+	components := make(map[int][]int)
+	for i := range commands {
+		root := find(i)
+		components[root] = append(components[root], i)
+	}
+	if len(components) == 1 {
+		return nil
+	}
+
+	// Largest components first, each to the group with the fewest commands
+	sorted := make([][]int, 0, len(components))
+	for _, indexes := range components {
+		sorted = append(sorted, indexes)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i][0] < sorted[j][0]
+	})
+
+	groups := make([][]int, min(n, len(sorted)))
+	for _, indexes := range sorted {
+		smallest := 0
+		for g := range groups {
+			if len(groups[g]) < len(groups[smallest]) {
+				smallest = g
+			}
+		}
+		groups[smallest] = append(groups[smallest], indexes...)
+	}
+	for _, group := range groups {
+		sort.Ints(group)
+	}
+	return groups
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestPartitionPipeline(t *testing.T) {
+	cmd := func(command string, args ...interface{}) types.CommandRequest {
+		return types.CommandRequest{Command: command, Args: args}
+	}
+
+	commands := []types.CommandRequest{
+		cmd("SET", "a", "1"),
+		cmd("SET", "b", "1"),
+		cmd("INCR", "a"),
+		cmd("SET", "c", "1"),
+		cmd("RENAME", "b", "d"),
+		cmd("GET", "d"),
+		cmd("GET", "e"),
+	}
+
+	// a; b, d; c; e: commands on the same keys stay together and in order
+	groups := partitionPipeline(commands, 2)
+	want := [][]int{{1, 4, 5, 6}, {0, 2, 3}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("Expected groups %v, got %v", want, groups)
+	}
+
+	if groups := partitionPipeline(commands, 1); groups != nil {
+		t.Errorf("Expected no split without parallelism, got %v", groups)
+	}
+
+	// FLUSHDB touches every key
+	withFlush := append(commands[:2:2], cmd("FLUSHDB"))
+	if groups := partitionPipeline(withFlush, 4); groups != nil {
+		t.Errorf("Expected no split with a keyless command, got %v", groups)
+	}
+
+	sameKey := []types.CommandRequest{cmd("INCR", "a"), cmd("INCR", "a")}
+	if groups := partitionPipeline(sameKey, 4); groups != nil {
+		t.Errorf("Expected no split of commands on one key, got %v", groups)
+	}
+}
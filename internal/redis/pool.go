@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IsPoolTimeout reports whether err means no connection became free within
+// the pool timeout, i.e. the connection pool is exhausted. go-redis doesn't
+// export the error, so it is matched by message.
+func IsPoolTimeout(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "connection pool timeout")
+}
+
+// connectionName joins the non-empty parts of a CLIENT SETNAME name with
+// slashes, replacing whitespace, which Redis rejects. An empty prefix
+// leaves connections unnamed.
+func connectionName(prefix string, parts ...string) string {
+	if prefix == "" {
+		return ""
+	}
+	name := prefix
+	for _, part := range parts {
+		if part != "" {
+			name += "/" + part
+		}
+	}
+	return strings.Join(strings.Fields(name), "-")
+}
+
+// PoolUsage is how busy one connection pool is.
+type PoolUsage struct {
+	Name     string
+	InUse    int
+	Max      int
+	Timeouts uint32
+
+	// Connection counts and the pool's cumulative hits and misses
+	Total  int
+	Idle   int
+	Stale  int
+	Hits   int
+	Misses int
+}
+
+// Stats returns the pool's stats keyed as metrics.Collector.UpdatePoolStats
+// expects.
+func (u PoolUsage) Stats() map[string]int {
+	return map[string]int{
+		"total_conns": u.Total,
+		"idle_conns":  u.Idle,
+		"stale_conns": u.Stale,
+		"hits":        u.Hits,
+		"misses":      u.Misses,
+	}
+}
+
+// PoolUsage reports the usage of every backend's pool, including the pools
+// opened for other databases and Redis ACL users.
+func (c *Client) PoolUsage() []PoolUsage {
+	names := make(map[*redis.Client]string)
+	var usage []PoolUsage
+	for _, b := range c.backends() {
+		names[b.client] = b.name
+		usage = append(usage, poolUsage(b.name, b.client))
+	}
+
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+
+	derived := make([]PoolUsage, 0, len(c.pools))
+	for key, client := range c.pools {
+		name := fmt.Sprintf("%s_db%d", names[key.base], key.db)
+		if key.user.Username != "" {
+			name += "_" + key.user.Username
+		}
+		if key.resp3 {
+			name += "_resp3"
+		}
+		derived = append(derived, poolUsage(name, client))
+	}
+	sort.Slice(derived, func(i, j int) bool { return derived[i].Name < derived[j].Name })
+
+	return append(usage, derived...)
+}
+
+func poolUsage(name string, client *redis.Client) PoolUsage {
+	stats := client.PoolStats()
+	opts := client.Options()
+
+	max := opts.MaxActiveConns
+	if max <= 0 || (opts.PoolSize > 0 && opts.PoolSize < max) {
+		max = opts.PoolSize
+	}
+
+	return PoolUsage{
+		Name:     name,
+		InUse:    int(stats.TotalConns) - int(stats.IdleConns),
+		Max:      max,
+		Timeouts: stats.Timeouts,
+		Total:    int(stats.TotalConns),
+		Idle:     int(stats.IdleConns),
+		Stale:    int(stats.StaleConns),
+		Hits:     int(stats.Hits),
+		Misses:   int(stats.Misses),
+	}
+}
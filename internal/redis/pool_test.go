@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIsPoolTimeout(t *testing.T) {
+	if !IsPoolTimeout(errors.New("redis: connection pool timeout")) {
+		t.Error("Expected pool timeout to be detected")
+	}
+	if IsPoolTimeout(nil) || IsPoolTimeout(errors.New("i/o timeout")) {
+		t.Error("Expected other errors not to be pool timeouts")
+	}
+}
+
+func TestPoolUsage(t *testing.T) {
+	primary := redis.NewClient(&redis.Options{Addr: "primary:6379", PoolSize: 20, MaxActiveConns: 8})
+	c := &Client{primary: primary, pools: make(map[poolKey]*redis.Client), done: make(chan struct{})}
+	defer c.Close()
+
+	c.pool(WithCredentials(context.Background(), Credentials{Username: "alice"}), primary, 2)
+	c.pool(withRESP3(context.Background()), primary, 0)
+
+	usage := c.PoolUsage()
+	if len(usage) != 3 {
+		t.Fatalf("Expected primary and derived pool, got %+v", usage)
+	}
+	if usage[0].Name != "primary" || usage[0].Max != 8 || usage[0].InUse != 0 {
+		t.Errorf("Unexpected primary usage: %+v", usage[0])
+	}
+	if usage[1].Name != "primary_db0_resp3" || usage[2].Name != "primary_db2_alice" {
+		t.Errorf("Expected derived pools primary_db0_resp3 and primary_db2_alice, got %q and %q", usage[1].Name, usage[2].Name)
+	}
+	if stats := usage[0].Stats(); stats["hits"] != 0 || stats["total_conns"] != 0 {
+		t.Errorf("Expected an unused pool, got %v", stats)
+	}
+}
+
+func TestConnectionNames(t *testing.T) {
+	if name := connectionName("serverless-redis", "proxy 1", "", "primary"); name != "serverless-redis/proxy-1/primary" {
+		t.Errorf("Expected serverless-redis/proxy-1/primary, got %q", name)
+	}
+	if name := connectionName("", "primary"); name != "" {
+		t.Errorf("Expected unnamed connections without a prefix, got %q", name)
+	}
+
+	primary := redis.NewClient(&redis.Options{Addr: "primary:6379", ClientName: "serverless-redis/proxy-1/primary"})
+	c := &Client{primary: primary, pools: make(map[poolKey]*redis.Client), done: make(chan struct{})}
+	defer c.Close()
+
+	pool := c.pool(withRESP3(WithCredentials(context.Background(), Credentials{Username: "alice"})), primary, 3)
+	if name := pool.Options().ClientName; name != "serverless-redis/proxy-1/primary/db3/user-alice/resp3" {
+		t.Errorf("Unexpected derived pool name %q", name)
+	}
+}
@@ -0,0 +1,63 @@
+package redis
+
+import "github.com/scaler/serverless-redis/internal/types"
+
+// projectReply trims a shaped reply to req's fields and max items: maps,
+// hash pairs and arrays of them keep only the requested fields, and
+// arrays keep only their first MaxItems elements.
+func projectReply(req types.CommandRequest, val interface{}) interface{} {
+	if req.MaxItems > 0 {
+		switch v := val.(type) {
+		case []interface{}:
+			val = v[:min(len(v), req.MaxItems)]
+		case types.SetReply:
+			val = v[:min(len(v), req.MaxItems)]
+		case []types.HashField:
+			val = v[:min(len(v), req.MaxItems)]
+		}
+	}
+	if len(req.Fields) == 0 {
+		return val
+	}
+
+	keep := make(map[string]bool, len(req.Fields))
+	for _, field := range req.Fields {
+		keep[field] = true
+	}
+	return projectFields(val, keep, true)
+}
+
+// projectFields keeps the fields in keep of a map or hash reply. Arrays of
+// them, such as XINFO GROUPS replies, are projected element by element.
+func projectFields(val interface{}, keep map[string]bool, top bool) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(keep))
+		for field, value := range v {
+			if keep[field] {
+				projected[field] = value
+			}
+		}
+		return projected
+	case types.MapReply:
+		return types.MapReply(projectFields(map[string]interface{}(v), keep, false).(map[string]interface{}))
+	case []types.HashField:
+		projected := make([]types.HashField, 0, len(keep))
+		for _, pair := range v {
+			if keep[pair.Field] {
+				projected = append(projected, pair)
+			}
+		}
+		return projected
+	case []interface{}:
+		if !top {
+			return v
+		}
+		projected := make([]interface{}, len(v))
+		for i, item := range v {
+			projected[i] = projectFields(item, keep, false)
+		}
+		return projected
+	}
+	return val
+}
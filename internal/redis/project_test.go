@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestProjectReply(t *testing.T) {
+	hash := []interface{}{"name", "Ada", "plan", "pro", "bio", "long text"}
+
+	tests := []struct {
+		name  string
+		req   types.CommandRequest
+		reply interface{}
+		want  interface{}
+	}{
+		{"fields of object", types.CommandRequest{Command: "HGETALL", Fields: []string{"name", "plan", "missing"}},
+			hash, map[string]interface{}{"name": "Ada", "plan": "pro"}},
+		{"fields of pairs", types.CommandRequest{Command: "HGETALL", HashFormat: types.HashFormatPairs, Fields: []string{"plan", "name"}},
+			hash, []types.HashField{{Field: "name", Value: "Ada"}, {Field: "plan", Value: "pro"}}},
+		{"fields of RESP3 map", types.CommandRequest{Command: "COMMAND", Args: []interface{}{"DOCS"}, RESP3: true, Fields: []string{"get"}},
+			map[interface{}]interface{}{"get": "a", "set": "b"}, types.MapReply{"get": "a"}},
+		{"fields of map list", types.CommandRequest{Command: "XINFO", Args: []interface{}{"GROUPS", "s"}, Fields: []string{"name"}},
+			[]interface{}{[]interface{}{"name", "g1", "pending", int64(2)}},
+			[]interface{}{map[string]interface{}{"name": "g1"}}},
+		{"max items", types.CommandRequest{Command: "LRANGE", MaxItems: 2},
+			[]interface{}{"a", "b", "c"}, []interface{}{"a", "b"}},
+		{"max items beyond length", types.CommandRequest{Command: "LRANGE", MaxItems: 5},
+			[]interface{}{"a"}, []interface{}{"a"}},
+		{"max items of set", types.CommandRequest{Command: "SMEMBERS", RESP3: true, MaxItems: 1},
+			[]interface{}{"a", "b"}, types.SetReply{"a"}},
+		{"scalars unchanged", types.CommandRequest{Command: "GET", Fields: []string{"a"}, MaxItems: 1},
+			"value", "value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shapeReply(tt.req, tt.reply); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replica is a read replica with the replication offset it was last seen
+// at; -1 marks a replica that could not be reached.
+type replica struct {
+	client *redis.Client
+	offset atomic.Int64
+}
+
+// Session tracks read-your-writes state for one request. Reads are only
+// sent to replicas that have replicated at least MinOffset, and writes are
+// recorded so the caller can hand out a new session token.
+type Session struct {
+	MinOffset int64
+
+	mutex sync.Mutex
+	wrote bool
+}
+
+// Wrote reports whether any write was executed under the session.
+func (s *Session) Wrote() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.wrote
+}
+
+func (s *Session) markWrite() {
+	s.mutex.Lock()
+	s.wrote = true
+	s.mutex.Unlock()
+}
+
+type sessionKey struct{}
+
+// WithSession attaches session to ctx for the commands executed with it.
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+func sessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// HasReplicas reports whether reads can be routed to replicas.
+func (c *Client) HasReplicas() bool {
+	return len(c.replicas) > 0
+}
+
+// PrimaryOffset returns the primary's current replication offset.
+func (c *Client) PrimaryOffset(ctx context.Context) (int64, error) {
+	return replicationOffset(ctx, c.primary)
+}
+
+// readClient picks the client for a read-only command: the next replica
+// that has caught up with the session, or the primary if none has.
+func (c *Client) readClient(ctx context.Context) *redis.Client {
+	if len(c.replicas) == 0 {
+		return c.primary
+	}
+
+	var minOffset int64
+	if session := sessionFromContext(ctx); session != nil {
+		minOffset = session.MinOffset
+	}
+
+	start := c.nextReplica.Add(1)
+	for i := 0; i < len(c.replicas); i++ {
+		r := c.replicas[(int(start)+i)%len(c.replicas)]
+		if offset := r.offset.Load(); offset >= 0 && offset >= minOffset {
+			return r.client
+		}
+	}
+
+	return c.primary
+}
+
+// writeClient returns the primary and marks the session as having written.
+func (c *Client) writeClient(ctx context.Context) *redis.Client {
+	if session := sessionFromContext(ctx); session != nil {
+		session.markWrite()
+	}
+	return c.primary
+}
+
+// monitorReplicas refreshes replica offsets every interval until Close.
+func (c *Client) monitorReplicas(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.refreshReplicaOffsets()
+
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) refreshReplicaOffsets() {
+	for _, r := range c.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		offset, err := replicationOffset(ctx, r.client)
+		cancel()
+
+		if err != nil {
+			offset = -1
+		}
+		r.offset.Store(offset)
+	}
+}
+
+// replicationOffset reads master_repl_offset from INFO replication, which
+// on a replica is the offset it has processed.
+func replicationOffset(ctx context.Context, client *redis.Client) (int64, error) {
+	info, err := client.Info(ctx, "replication").Result()
+	if err != nil {
+		return 0, err
+	}
+	return parseReplicationOffset(info)
+}
+
+func parseReplicationOffset(info string) (int64, error) {
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "master_repl_offset:"); ok {
+			return strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("master_repl_offset missing from INFO replication")
+}
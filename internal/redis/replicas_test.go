@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseReplicationOffset(t *testing.T) {
+	info := "# Replication\r\nrole:slave\r\nmaster_host:10.0.0.1\r\nslave_repl_offset:1234\r\nmaster_repl_offset:1234\r\n"
+
+	offset, err := parseReplicationOffset(info)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if offset != 1234 {
+		t.Errorf("Expected offset 1234, got %d", offset)
+	}
+
+	if _, err := parseReplicationOffset("# Replication\r\nrole:master\r\n"); err == nil {
+		t.Error("Expected error when master_repl_offset is missing")
+	}
+}
+
+func TestReadClientHonoursSessionOffset(t *testing.T) {
+	primary := redis.NewClient(&redis.Options{Addr: "primary:6379"})
+	behind := &replica{client: redis.NewClient(&redis.Options{Addr: "behind:6379"})}
+	caughtUp := &replica{client: redis.NewClient(&redis.Options{Addr: "caught-up:6379"})}
+	down := &replica{client: redis.NewClient(&redis.Options{Addr: "down:6379"})}
+	behind.offset.Store(100)
+	caughtUp.offset.Store(500)
+	down.offset.Store(-1)
+
+	c := &Client{primary: primary, replicas: []*replica{behind, caughtUp, down}}
+
+	session := &Session{MinOffset: 400}
+	ctx := WithSession(context.Background(), session)
+	for i := 0; i < 6; i++ {
+		if got := c.readClient(ctx); got != caughtUp.client {
+			t.Errorf("Expected caught-up replica, got %s", got.Options().Addr)
+		}
+	}
+
+	session.MinOffset = 1000
+	if got := c.readClient(ctx); got != primary {
+		t.Errorf("Expected primary when no replica has caught up, got %s", got.Options().Addr)
+	}
+
+	for i := 0; i < 6; i++ {
+		if got := c.readClient(context.Background()); got == down.client {
+			t.Error("Expected unreachable replica to be skipped")
+		}
+	}
+
+	if session.Wrote() {
+		t.Error("Expected reads not to mark the session as written")
+	}
+	c.selectClient(ctx, "SET")
+	if !session.Wrote() {
+		t.Error("Expected write to mark the session")
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	for _, cmd := range []string{"GET", "mget", "HGETALL", "ZRANGE", "PFCOUNT"} {
+		if !IsReadOnly(cmd) {
+			t.Errorf("Expected %s to be read-only", cmd)
+		}
+	}
+	for _, cmd := range []string{"SET", "DEL", "EVAL", "UNKNOWN"} {
+		if IsReadOnly(cmd) {
+			t.Errorf("Expected %s not to be read-only", cmd)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+type resp3Key struct{}
+
+// withRESP3 makes the commands executed with ctx use RESP3 connections.
+// go-redis falls back to RESP2 on servers without HELLO.
+func withRESP3(ctx context.Context) context.Context {
+	return context.WithValue(ctx, resp3Key{}, true)
+}
+
+func resp3FromContext(ctx context.Context) bool {
+	resp3, _ := ctx.Value(resp3Key{}).(bool)
+	return resp3
+}
+
+// resp3Commands returns a copy of the commands of a RESP3 pipeline or
+// transaction, each marked to have its reply typed.
+func resp3Commands(commands []types.CommandRequest) []types.CommandRequest {
+	marked := make([]types.CommandRequest, len(commands))
+	for i, cmd := range commands {
+		cmd.RESP3 = true
+		marked[i] = cmd
+	}
+	return marked
+}
+
+// go-redis reads RESP3 sets as arrays and drops the format of verbatim
+// strings, so the commands replying with them are listed here.
+var (
+	setCommands = map[string]bool{
+		"SMEMBERS": true,
+		"SINTER":   true,
+		"SUNION":   true,
+		"SDIFF":    true,
+	}
+	verbatimCommands = map[string]bool{
+		"INFO":           true,
+		"CLIENT INFO":    true,
+		"CLIENT LIST":    true,
+		"LATENCY DOCTOR": true,
+		"MEMORY DOCTOR":  true,
+		"LOLWUT":         true,
+	}
+)
+
+// typedReply returns a RESP3 reply with its maps, sets, doubles, big
+// numbers and verbatim strings as the matching reply types. The maps of
+// commands in mapReplies keep req's hash format instead.
+func typedReply(req types.CommandRequest, val interface{}) interface{} {
+	if mapReplyOf(req) != noMap {
+		return shapeMaps(req, val, resp3Value)
+	}
+
+	command := strings.ToUpper(req.Command)
+	if len(req.Args) > 0 {
+		if sub, ok := req.Args[0].(string); ok {
+			if full := command + " " + strings.ToUpper(sub); verbatimCommands[full] {
+				command = full
+			}
+		}
+	}
+
+	switch v := val.(type) {
+	case []interface{}:
+		if setCommands[command] {
+			return types.SetReply(resp3Value(v).([]interface{}))
+		}
+	case string:
+		if verbatimCommands[command] {
+			return types.VerbatimReply(v)
+		}
+	}
+	return resp3Value(val)
+}
+
+// resp3Value converts the RESP3 types go-redis reads in val.
+func resp3Value(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(types.MapReply, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = resp3Value(value)
+		}
+		return m
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, value := range v {
+			values[i] = resp3Value(value)
+		}
+		return values
+	case float64:
+		return types.DoubleReply(v)
+	case *big.Int:
+		return types.BigNumberReply(v.String())
+	}
+	return val
+}
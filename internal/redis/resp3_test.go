@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTypedReply(t *testing.T) {
+	huge, _ := new(big.Int).SetString("1234567890123456789012345678901234567890", 10)
+
+	tests := []struct {
+		name     string
+		req      types.CommandRequest
+		reply    interface{}
+		want     interface{}
+		wantType types.ResponseType
+	}{
+		{
+			name:     "map",
+			req:      types.CommandRequest{Command: "COMMAND", Args: []interface{}{"DOCS", "get"}},
+			reply:    map[interface{}]interface{}{"length": int64(2), int64(1): "one"},
+			want:     types.MapReply{"length": int64(2), "1": "one"},
+			wantType: types.ResponseTypeMap,
+		},
+		{
+			name:     "hash keeps its format",
+			req:      types.CommandRequest{Command: "HGETALL", Args: []interface{}{"h"}, HashFormat: types.HashFormatPairs},
+			reply:    map[interface{}]interface{}{"zeta": "1", "alpha": "2"},
+			want:     []types.HashField{{Field: "alpha", Value: "2"}, {Field: "zeta", Value: "1"}},
+			wantType: types.ResponseTypeHash,
+		},
+		{
+			name:     "set",
+			req:      types.CommandRequest{Command: "smembers", Args: []interface{}{"s"}},
+			reply:    []interface{}{"a", "b"},
+			want:     types.SetReply{"a", "b"},
+			wantType: types.ResponseTypeSet,
+		},
+		{
+			name:     "double",
+			req:      types.CommandRequest{Command: "ZSCORE", Args: []interface{}{"z", "m"}},
+			reply:    1.5,
+			want:     types.DoubleReply(1.5),
+			wantType: types.ResponseTypeDouble,
+		},
+		{
+			name:     "big number",
+			req:      types.CommandRequest{Command: "EVAL"},
+			reply:    huge,
+			want:     types.BigNumberReply("1234567890123456789012345678901234567890"),
+			wantType: types.ResponseTypeBigNumber,
+		},
+		{
+			name:     "verbatim",
+			req:      types.CommandRequest{Command: "CLIENT", Args: []interface{}{"info"}},
+			reply:    "id=3 addr=127.0.0.1:6379",
+			want:     types.VerbatimReply("id=3 addr=127.0.0.1:6379"),
+			wantType: types.ResponseTypeVerbatim,
+		},
+		{
+			name:     "nested",
+			req:      types.CommandRequest{Command: "ZRANGE", Args: []interface{}{"z", 0, -1, "WITHSCORES"}},
+			reply:    []interface{}{[]interface{}{"m", 2.0}},
+			want:     []interface{}{[]interface{}{"m", types.DoubleReply(2)}},
+			wantType: types.ResponseTypeArray,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.RESP3 = true
+			got := shapeReply(tt.req, tt.reply)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+			if gotType := InferResponseType(got); gotType != tt.wantType {
+				t.Errorf("Expected type %s, got %s", tt.wantType, gotType)
+			}
+		})
+	}
+}
+
+func TestDoubleReplyJSON(t *testing.T) {
+	encoded, err := json.Marshal([]interface{}{
+		types.DoubleReply(0.25), types.DoubleReply(math.Inf(1)),
+		types.DoubleReply(math.Inf(-1)), types.DoubleReply(math.NaN()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `[0.25,"inf","-inf","nan"]` {
+		t.Errorf("Unexpected encoding %s", encoded)
+	}
+}
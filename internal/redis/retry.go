@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// RetryObserver is told about every command that needed retries: how many
+// retries were made and the final error, nil if a retry succeeded.
+type RetryObserver func(command string, retries int, err error)
+
+// SetRetryObserver registers fn to be called for retried commands.
+func (c *Client) SetRetryObserver(fn RetryObserver) {
+	c.retryObserver = fn
+}
+
+// withRetry runs exec, retrying read-only commands that fail with a
+// transient error according to the retry policy. Writes are never retried
+// since they may have been applied before the error.
+func (c *Client) withRetry(ctx context.Context, command string, exec func() (interface{}, error)) (interface{}, error) {
+	policy := c.config.Redis.Retry
+	result, err := exec()
+	if !policy.Enabled || !IsReadOnly(command) {
+		return result, err
+	}
+
+	retries := 0
+	for ; retries < policy.MaxAttempts-1 && IsTransient(err); retries++ {
+		if !sleepContext(ctx, backoff(policy, retries)) {
+			break
+		}
+		result, err = exec()
+	}
+
+	if retries > 0 && c.retryObserver != nil {
+		c.retryObserver(command, retries, err)
+	}
+	return result, err
+}
+
+// backoff returns the delay before retry n (from 0): exponential from the
+// initial backoff, capped at the maximum, with the jitter fraction of it
+// randomised so clients that failed together don't retry together.
+func backoff(policy types.RetryConfig, n int) time.Duration {
+	delay := policy.InitialBackoff << n
+	if delay <= 0 || delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	if policy.Jitter > 0 {
+		delay -= time.Duration(policy.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// sleepContext waits for d, returning false if ctx ends first or would
+// end before d has passed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// transientPrefixes are Redis error replies that clear up on their own.
+var transientPrefixes = []string{"LOADING", "TRYAGAIN", "CLUSTERDOWN", "MASTERDOWN"}
+
+// IsTransient reports whether err is worth retrying: a connection or
+// network error, a pool timeout, or a Redis reply saying it is temporarily
+// unavailable. Cancelled requests and missing keys are not transient.
+func IsTransient(err error) bool {
+	if err == nil || IsNil(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	if IsPoolTimeout(err) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, prefix := range transientPrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnavailable reports whether err means Redis couldn't be reached at
+// all: the connection was refused or couldn't be dialed, so the command
+// was never sent.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
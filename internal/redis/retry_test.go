@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func retryClient() *Client {
+	return &Client{config: &types.Config{Redis: types.RedisConfig{Retry: types.RetryConfig{
+		Enabled:        true,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Jitter:         0.5,
+	}}}}
+}
+
+func TestWithRetryRecoversReads(t *testing.T) {
+	c := retryClient()
+	var observed int
+	c.SetRetryObserver(func(command string, retries int, err error) {
+		if err != nil {
+			t.Errorf("Expected recovered command, got %v", err)
+		}
+		observed = retries
+	})
+
+	attempts := 0
+	result, err := c.withRetry(context.Background(), "GET", func() (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, io.EOF
+		}
+		return "value", nil
+	})
+	if err != nil || result != "value" {
+		t.Fatalf("Expected success after retries, got %v, %v", result, err)
+	}
+	if attempts != 3 || observed != 2 {
+		t.Errorf("Expected 3 attempts and 2 observed retries, got %d and %d", attempts, observed)
+	}
+}
+
+func TestWithRetrySkipsWritesAndPermanentErrors(t *testing.T) {
+	c := retryClient()
+
+	attempts := 0
+	_, _ = c.withRetry(context.Background(), "SET", func() (interface{}, error) {
+		attempts++
+		return nil, io.EOF
+	})
+	if attempts != 1 {
+		t.Errorf("Expected writes not to be retried, got %d attempts", attempts)
+	}
+
+	attempts = 0
+	_, _ = c.withRetry(context.Background(), "GET", func() (interface{}, error) {
+		attempts++
+		return nil, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	})
+	if attempts != 1 {
+		t.Errorf("Expected permanent errors not to be retried, got %d attempts", attempts)
+	}
+
+	attempts = 0
+	_, err := c.withRetry(context.Background(), "GET", func() (interface{}, error) {
+		attempts++
+		return nil, errors.New("LOADING Redis is loading the dataset in memory")
+	})
+	if attempts != 3 || err == nil {
+		t.Errorf("Expected retries to stop at max attempts, got %d attempts, err %v", attempts, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := types.RetryConfig{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}
+	for n, expected := range want {
+		if got := backoff(policy, n); got != expected {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, expected)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if got := backoff(policy, 1); got < 10*time.Millisecond || got > 20*time.Millisecond {
+			t.Errorf("Expected jittered backoff within [10ms, 20ms], got %v", got)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	if !IsTransient(io.ErrUnexpectedEOF) || !IsTransient(errors.New("redis: connection pool timeout")) {
+		t.Error("Expected connection errors to be transient")
+	}
+	if IsTransient(context.Canceled) || IsTransient(errors.New("ERR syntax error")) || IsTransient(nil) {
+		t.Error("Expected cancellation and command errors not to be transient")
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	if !IsUnavailable(dial) || !IsUnavailable(fmt.Errorf("failed: %w", syscall.ECONNREFUSED)) {
+		t.Error("Expected refused and failed dials to mean Redis is unavailable")
+	}
+	read := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	if IsUnavailable(read) || IsUnavailable(io.EOF) || IsUnavailable(errors.New("LOADING Redis is loading")) || IsUnavailable(nil) {
+		t.Error("Expected errors after a command may have been sent not to mean Redis is unavailable")
+	}
+}
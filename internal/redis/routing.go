@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Backends commands are routed between
+const (
+	BackendRedis     = "redis"
+	BackendDragonfly = "dragonfly"
+)
+
+// Reasons a command was routed to a backend
+const (
+	// RouteFaster is the backend with the lower average latency
+	RouteFaster = "faster"
+	// RouteExplore samples the slower backend
+	RouteExplore = "explore"
+	// RouteWarmup samples a backend with too few measurements to compare
+	RouteWarmup = "warmup"
+	// RouteStatic follows the static command list
+	RouteStatic = "static"
+)
+
+// dragonflyCommands benefit from DragonflyDB's multi-threading. They are
+// always sent to DragonflyDB with static routing, and start out there with
+// latency routing.
+var dragonflyCommands = map[string]bool{
+	"MGET": true, "MSET": true, "HMGET": true, "HMSET": true,
+	"ZADD": true, "ZRANGE": true, "ZRANGEBYSCORE": true,
+}
+
+// maxRoutedCommands bounds how many commands keep latency averages, since
+// command names come from clients. Others use static routing.
+const maxRoutedCommands = 512
+
+// RoutingObserver is told how commands are routed: every decision with its
+// reason, updated latency averages, and when a command's preferred backend
+// changes.
+type RoutingObserver interface {
+	RecordRoute(command, backend, reason string)
+	RecordRouteLatency(command, backend string, average time.Duration)
+	RecordRouteSwitch(command, backend string)
+}
+
+// SetRoutingObserver registers observer for routing decisions.
+func (c *Client) SetRoutingObserver(observer RoutingObserver) {
+	if c.router != nil {
+		c.router.observer = observer
+	}
+}
+
+// latencyRouter picks DragonflyDB or Redis per command from the average
+// latency each has shown for it.
+type latencyRouter struct {
+	config   atomic.Pointer[types.RoutingConfig]
+	observer RoutingObserver
+
+	mutex    sync.Mutex
+	commands map[string]*commandRoute
+}
+
+// commandRoute is the routing state of one command. Index 0 is Redis and
+// 1 DragonflyDB.
+type commandRoute struct {
+	average   [2]float64
+	samples   [2]int
+	preferred int
+}
+
+var routeBackends = [2]string{BackendRedis, BackendDragonfly}
+
+func newLatencyRouter(config types.RoutingConfig) *latencyRouter {
+	r := &latencyRouter{commands: make(map[string]*commandRoute)}
+	r.config.Store(&config)
+	return r
+}
+
+// SetRouting changes how commands are routed between Redis and
+// DragonflyDB. Latencies measured so far are kept.
+func (c *Client) SetRouting(config types.RoutingConfig) {
+	if c.router != nil {
+		c.router.config.Store(&config)
+	}
+}
+
+// route returns the backend for command.
+func (r *latencyRouter) route(command string) string {
+	command = strings.ToUpper(command)
+	backend, reason := r.choose(command)
+	if r.observer != nil {
+		r.observer.RecordRoute(command, backend, reason)
+	}
+	return backend
+}
+
+func (r *latencyRouter) choose(command string) (string, string) {
+	static := BackendRedis
+	if dragonflyCommands[command] {
+		static = BackendDragonfly
+	}
+	config := r.config.Load()
+	if config.Mode == types.RoutingStatic {
+		return static, RouteStatic
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	route, ok := r.commands[command]
+	if !ok {
+		if len(r.commands) >= maxRoutedCommands {
+			return static, RouteStatic
+		}
+		route = &commandRoute{}
+		if static == BackendDragonfly {
+			route.preferred = 1
+		}
+		r.commands[command] = route
+	}
+
+	// Measure both backends before comparing them
+	for _, i := range []int{route.preferred, 1 - route.preferred} {
+		if route.samples[i] < config.MinSamples {
+			return routeBackends[i], RouteWarmup
+		}
+	}
+
+	if config.ExploreRatio > 0 && rand.Float64() < config.ExploreRatio {
+		return routeBackends[1-route.preferred], RouteExplore
+	}
+	return routeBackends[route.preferred], RouteFaster
+}
+
+// observe records how long command took on backend. Failed commands say
+// little about a backend's speed and are ignored.
+func (r *latencyRouter) observe(command, backend string, duration time.Duration, err error) {
+	config := r.config.Load()
+	if config.Mode == types.RoutingStatic || (err != nil && !IsNil(err)) {
+		return
+	}
+	command = strings.ToUpper(command)
+
+	i := 0
+	if backend == BackendDragonfly {
+		i = 1
+	}
+
+	r.mutex.Lock()
+	route, ok := r.commands[command]
+	if !ok {
+		r.mutex.Unlock()
+		return
+	}
+
+	sample := duration.Seconds()
+	if route.samples[i] == 0 {
+		route.average[i] = sample
+	} else {
+		route.average[i] += config.Smoothing * (sample - route.average[i])
+	}
+	route.samples[i]++
+	average := route.average[i]
+
+	// Only move to the other backend once it is clearly faster, so that
+	// similar latencies don't make traffic flap between backends
+	switched := false
+	other := 1 - route.preferred
+	if route.samples[0] >= config.MinSamples && route.samples[1] >= config.MinSamples &&
+		route.average[other] < route.average[route.preferred]*(1-config.Hysteresis) {
+		route.preferred = other
+		switched = true
+	}
+	r.mutex.Unlock()
+
+	if r.observer != nil {
+		r.observer.RecordRouteLatency(command, backend, time.Duration(average*float64(time.Second)))
+		if switched {
+			r.observer.RecordRouteSwitch(command, routeBackends[other])
+		}
+	}
+}
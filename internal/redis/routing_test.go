@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestLatencyRouter(t *testing.T) {
+	router := newLatencyRouter(types.RoutingConfig{
+		Mode:       types.RoutingLatency,
+		Hysteresis: 0.2,
+		MinSamples: 3,
+		Smoothing:  0.5,
+	})
+
+	// Warm up both backends, MGET starting on DragonflyDB
+	feed := func(dragonfly, redis time.Duration) {
+		for i := 0; i < 3; i++ {
+			router.observe("MGET", router.route("MGET"), dragonfly, nil)
+		}
+		for i := 0; i < 3; i++ {
+			router.observe("MGET", router.route("MGET"), redis, nil)
+		}
+	}
+	if got := router.route("mget"); got != BackendDragonfly {
+		t.Fatalf("Expected MGET to start on dragonfly, got %s", got)
+	}
+	feed(10*time.Millisecond, 9*time.Millisecond)
+
+	// Redis is faster, but not by more than the hysteresis
+	if got := router.route("MGET"); got != BackendDragonfly {
+		t.Fatalf("Expected MGET to stay on dragonfly within the hysteresis, got %s", got)
+	}
+
+	// A clear difference moves the command over
+	for i := 0; i < 3; i++ {
+		router.observe("MGET", BackendRedis, 2*time.Millisecond, nil)
+	}
+	if got := router.route("MGET"); got != BackendRedis {
+		t.Fatalf("Expected MGET to move to redis, got %s", got)
+	}
+
+	// Errors aren't latency samples
+	for i := 0; i < 10; i++ {
+		router.observe("MGET", BackendDragonfly, time.Microsecond, errors.New("ERR wrong type"))
+	}
+	if got := router.route("MGET"); got != BackendRedis {
+		t.Fatalf("Expected failed commands to be ignored, got %s", got)
+	}
+}
+
+func TestStaticRouting(t *testing.T) {
+	router := newLatencyRouter(types.RoutingConfig{Mode: types.RoutingStatic})
+
+	if got := router.route("ZADD"); got != BackendDragonfly {
+		t.Errorf("Expected ZADD on dragonfly, got %s", got)
+	}
+	if got := router.route("GET"); got != BackendRedis {
+		t.Errorf("Expected GET on redis, got %s", got)
+	}
+}
+
+func TestSetRouting(t *testing.T) {
+	client := &Client{router: newLatencyRouter(types.RoutingConfig{Mode: types.RoutingLatency, MinSamples: 1, Smoothing: 1})}
+	router := client.router
+
+	router.observe("GET", router.route("GET"), time.Millisecond, nil)
+	router.observe("GET", router.route("GET"), 10*time.Millisecond, nil)
+	if got := router.route("GET"); got != BackendRedis {
+		t.Fatalf("Expected GET on redis, got %s", got)
+	}
+
+	client.SetRouting(types.RoutingConfig{Mode: types.RoutingStatic})
+	if got := router.route("MGET"); got != BackendDragonfly {
+		t.Errorf("Expected static routing of MGET to dragonfly after SetRouting, got %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// script is a registered script with its source resolved.
+type script struct {
+	config types.ScriptConfig
+	source string
+	sha    string
+}
+
+// ScriptRegistry preloads named Lua scripts and function libraries into
+// every backend and keeps track of where they are present.
+type ScriptRegistry struct {
+	client  *Client
+	scripts []script
+
+	mutex  sync.RWMutex
+	status []types.ScriptStatus
+}
+
+// NewScriptRegistry reads the configured scripts, failing if a file can't
+// be read or a script doesn't match its expected SHA.
+func NewScriptRegistry(client *Client, configs []types.ScriptConfig) (*ScriptRegistry, error) {
+	registry := &ScriptRegistry{client: client}
+
+	for _, cfg := range configs {
+		source := cfg.Source
+		if cfg.File != "" {
+			data, err := os.ReadFile(cfg.File)
+			if err != nil {
+				return nil, fmt.Errorf("script %s: %w", cfg.Name, err)
+			}
+			source = string(data)
+		}
+
+		sum := sha1.Sum([]byte(source))
+		sha := hex.EncodeToString(sum[:])
+		if cfg.SHA != "" && !strings.EqualFold(cfg.SHA, sha) {
+			return nil, fmt.Errorf("script %s: source has SHA %s, expected %s", cfg.Name, sha, cfg.SHA)
+		}
+
+		registry.scripts = append(registry.scripts, script{config: cfg, source: source, sha: sha})
+	}
+
+	return registry, nil
+}
+
+// Preload loads every script that is missing from a backend and records
+// the result. Scripts are loaded with SCRIPT LOAD everywhere; function
+// libraries are loaded on writable backends and only checked on replicas,
+// which receive them through replication.
+func (r *ScriptRegistry) Preload(ctx context.Context) []types.ScriptStatus {
+	status := make([]types.ScriptStatus, len(r.scripts))
+	for i, s := range r.scripts {
+		status[i] = types.ScriptStatus{
+			Name:     s.config.Name,
+			Function: s.config.Function,
+			Required: s.config.Required,
+		}
+		if !s.config.Function {
+			status[i].SHA = s.sha
+		}
+	}
+
+	for _, backend := range r.client.backends() {
+		for i, s := range r.scripts {
+			if err := ensureScript(ctx, backend, s); err != nil {
+				status[i].Missing = append(status[i].Missing, backend.name)
+				status[i].Error = err.Error()
+			}
+		}
+	}
+
+	for i := range status {
+		status[i].Loaded = len(status[i].Missing) == 0
+	}
+
+	r.mutex.Lock()
+	r.status = status
+	r.mutex.Unlock()
+
+	return status
+}
+
+// Status returns the result of the last Preload.
+func (r *ScriptRegistry) Status() []types.ScriptStatus {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.status
+}
+
+// MissingRequired names the required scripts that were not loaded on
+// every backend by the last Preload.
+func (r *ScriptRegistry) MissingRequired() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var missing []string
+	for _, status := range r.status {
+		if status.Required && !status.Loaded {
+			missing = append(missing, status.Name)
+		}
+	}
+	return missing
+}
+
+// backend is a Redis server scripts are preloaded into.
+type backend struct {
+	name     string
+	client   *redis.Client
+	readOnly bool
+}
+
+func (c *Client) backends() []backend {
+	backends := []backend{{name: "primary", client: c.primary}}
+	if c.dragonfly != nil {
+		backends = append(backends, backend{name: "dragonfly", client: c.dragonfly})
+	}
+	for i, r := range c.replicas {
+		backends = append(backends, backend{name: fmt.Sprintf("replica%d", i), client: r.client, readOnly: true})
+	}
+	backends = append(backends, c.shards[min(1, len(c.shards)):]...)
+	return backends
+}
+
+func ensureScript(ctx context.Context, b backend, s script) error {
+	if s.config.Function {
+		return ensureFunction(ctx, b, s)
+	}
+
+	exists, err := b.client.ScriptExists(ctx, s.sha).Result()
+	if err != nil {
+		return err
+	}
+	if len(exists) == 1 && exists[0] {
+		return nil
+	}
+
+	sha, err := b.client.ScriptLoad(ctx, s.source).Result()
+	if err != nil {
+		return err
+	}
+	if sha != s.sha {
+		return fmt.Errorf("loaded with SHA %s, expected %s", sha, s.sha)
+	}
+	return nil
+}
+
+func ensureFunction(ctx context.Context, b backend, s script) error {
+	libraries, err := b.client.FunctionList(ctx, redis.FunctionListQuery{LibraryNamePattern: s.config.Name}).Result()
+	if err != nil {
+		return err
+	}
+	for _, library := range libraries {
+		if library.Name == s.config.Name {
+			return nil
+		}
+	}
+
+	if b.readOnly {
+		return fmt.Errorf("library %s not replicated yet", s.config.Name)
+	}
+
+	name, err := b.client.FunctionLoadReplace(ctx, s.source).Result()
+	if err != nil {
+		return err
+	}
+	if name != s.config.Name {
+		return fmt.Errorf("source defines library %s, expected %s", name, s.config.Name)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestNewScriptRegistry(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "incr.lua")
+	if err := os.WriteFile(file, []byte("return redis.call('INCR', KEYS[1])"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewScriptRegistry(&Client{}, []types.ScriptConfig{
+		{Name: "ping", Source: "return 'pong'", SHA: "8a4e2d9ab4d2f2f8ef6ea8a5ea3bbd94e6be0c4b"},
+		{Name: "incr", File: file},
+	})
+	if err == nil {
+		t.Fatal("Expected a SHA mismatch to be rejected")
+	}
+
+	registry, err = NewScriptRegistry(&Client{}, []types.ScriptConfig{
+		{Name: "incr", File: file, Required: true},
+	})
+	if err != nil {
+		t.Fatalf("NewScriptRegistry failed: %v", err)
+	}
+	if len(registry.scripts[0].sha) != 40 {
+		t.Errorf("Expected a SHA1 to be computed, got %q", registry.scripts[0].sha)
+	}
+
+	if _, err := NewScriptRegistry(&Client{}, []types.ScriptConfig{{Name: "gone", File: file + ".missing"}}); err == nil {
+		t.Error("Expected an unreadable file to be rejected")
+	}
+}
+
+func TestScriptRegistryMissingRequired(t *testing.T) {
+	registry := &ScriptRegistry{status: []types.ScriptStatus{
+		{Name: "a", Required: true, Loaded: true},
+		{Name: "b", Required: true, Missing: []string{"replica0"}},
+		{Name: "c", Missing: []string{"primary"}},
+	}}
+
+	missing := registry.MissingRequired()
+	if len(missing) != 1 || missing[0] != "b" {
+		t.Errorf("Expected only required script b to be reported, got %v", missing)
+	}
+}
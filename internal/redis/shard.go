@@ -0,0 +1,264 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrCrossShard is returned for commands whose keys live on different
+// shards.
+var ErrCrossShard = errors.New("keys map to different shards; use a hash tag such as {user:1} to keep them together")
+
+// ringReplicas is how many points each shard has on the hash ring. More
+// points spread keys more evenly.
+const ringReplicas = 160
+
+// hashRing maps keys to shards by consistent hashing, so adding or
+// removing a shard only moves the keys of that shard.
+type hashRing struct {
+	points []uint32
+	owners []int
+}
+
+func newHashRing(names []string) *hashRing {
+	type point struct {
+		hash  uint32
+		owner int
+	}
+	points := make([]point, 0, len(names)*ringReplicas)
+	for owner, name := range names {
+		for i := 0; i < ringReplicas; i++ {
+			points = append(points, point{hash: hashKey(name + "#" + strconv.Itoa(i)), owner: owner})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+
+	ring := &hashRing{
+		points: make([]uint32, len(points)),
+		owners: make([]int, len(points)),
+	}
+	for i, p := range points {
+		ring.points[i] = p.hash
+		ring.owners[i] = p.owner
+	}
+	return ring
+}
+
+// locate returns the index of the shard owning key.
+func (r *hashRing) locate(key string) int {
+	hash := hashKey(hashTag(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// hashTag returns the part of key between the first { and the following },
+// if it is not empty, as Redis Cluster does: keys with the same tag always
+// land on the same shard.
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// CommandKeys returns the keys cmd operates on, as found for sharding.
+func CommandKeys(cmd types.CommandRequest) []string {
+	return commandKeys(cmd)
+}
+
+// commandKeys returns the keys cmd operates on, at the positions the
+// command table gives. Commands missing from the table are assumed to take
+// their first argument as their key.
+func commandKeys(cmd types.CommandRequest) []string {
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = fmt.Sprint(arg)
+	}
+
+	switch command := strings.ToUpper(cmd.Command); {
+	case len(args) == 0:
+		return nil
+
+	case command == "XREAD" || command == "XREADGROUP":
+		// ... STREAMS key... id...
+		for i, arg := range args {
+			if strings.EqualFold(arg, "STREAMS") {
+				streams := args[i+1:]
+				return streams[:len(streams)/2]
+			}
+		}
+		return nil
+
+	case command == "MEMORY" || command == "OBJECT" || command == "XINFO" || command == "XGROUP":
+		// subcommand key ...
+		if len(args) > 1 {
+			return args[1:2]
+		}
+		return nil
+	}
+
+	if keys, ok := types.CommandKeys(cmd.Command, args); ok {
+		return keys
+	}
+	return args[:1]
+}
+
+// Sharded reports whether keys are spread over several backends.
+func (c *Client) Sharded() bool {
+	return c.ring != nil
+}
+
+// KeyShard returns the index of the shard holding key, or 0 if the client
+// isn't sharded or database names a database, which isn't.
+func (c *Client) KeyShard(key, database string) int {
+	if c.ring == nil || database != "" {
+		return 0
+	}
+	return c.ring.locate(key)
+}
+
+// shardOf returns the index of the shard holding every key of commands.
+func (c *Client) shardOf(commands ...types.CommandRequest) (int, error) {
+	shard := -1
+	for _, cmd := range commands {
+		for _, key := range commandKeys(cmd) {
+			owner := c.ring.locate(key)
+			if shard >= 0 && owner != shard {
+				return 0, ErrCrossShard
+			}
+			shard = owner
+		}
+	}
+	return max(shard, 0), nil
+}
+
+// shardClient returns the shard for commands, or nil if the client isn't
+// sharded.
+func (c *Client) shardClient(commands ...types.CommandRequest) (*redis.Client, error) {
+	if c.ring == nil {
+		return nil, nil
+	}
+	shard, err := c.shardOf(commands...)
+	if err != nil {
+		return nil, err
+	}
+	return c.shards[shard].client, nil
+}
+
+// ValidateCommandShard checks that the keys of req live on one shard.
+// Commands on a named database aren't sharded.
+func (c *Client) ValidateCommandShard(req types.CommandRequest) error {
+	if c.ring == nil || req.Database != "" {
+		return nil
+	}
+	if _, err := c.shardOf(req); err != nil {
+		return types.NewValidationError(types.ErrCodeCrossShard, "args", "%v", err)
+	}
+	return nil
+}
+
+// ValidatePipelineShards checks that the keys of each command in req live
+// on one shard. Pipelines are split by shard, so commands may use
+// different ones.
+func (c *Client) ValidatePipelineShards(req types.PipelineRequest) error {
+	if c.ring == nil || req.Database != "" {
+		return nil
+	}
+	return c.validateCommandShards(req.Commands)
+}
+
+// ValidateTransactionShard checks that all keys of req, including watched
+// ones, live on one shard, since a transaction runs on a single backend.
+func (c *Client) ValidateTransactionShard(req types.TransactionRequest) error {
+	if c.ring == nil || req.Database != "" {
+		return nil
+	}
+	if err := c.validateCommandShards(req.Commands); err != nil {
+		return err
+	}
+	if _, err := c.shardOf(transactionCommands(req)...); err != nil {
+		return types.NewValidationError(types.ErrCodeCrossShard, "commands", "%v", err)
+	}
+	return nil
+}
+
+func (c *Client) validateCommandShards(commands []types.CommandRequest) error {
+	for i, cmd := range commands {
+		if _, err := c.shardOf(cmd); err != nil {
+			return types.NewValidationError(types.ErrCodeCrossShard, fmt.Sprintf("commands[%d].args", i), "%v", err)
+		}
+	}
+	return nil
+}
+
+func watchCommand(keys []string) types.CommandRequest {
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	return types.CommandRequest{Command: "WATCH", Args: args}
+}
+
+// transactionCommands returns every command of a transaction with keys:
+// its commands, the WATCH and the precondition's EVAL.
+func transactionCommands(req types.TransactionRequest) []types.CommandRequest {
+	commands := append(req.Commands[:len(req.Commands):len(req.Commands)], watchCommand(req.Watch))
+	if req.Precondition != nil {
+		commands = append(commands, req.Precondition.Command())
+	}
+	return commands
+}
+
+// executeShardedPipeline runs the commands of each shard in their own
+// pipeline, concurrently, and returns the results in request order.
+func (c *Client) executeShardedPipeline(ctx context.Context, req types.PipelineRequest) []types.CommandResponse {
+	results := make([]types.CommandResponse, len(req.Commands))
+	groups := make(map[int][]int)
+	for i, cmd := range req.Commands {
+		shard, err := c.shardOf(cmd)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		groups[shard] = append(groups[shard], i)
+	}
+
+	var wg sync.WaitGroup
+	for shard, indexes := range groups {
+		wg.Add(1)
+		go func(client *redis.Client, indexes []int) {
+			defer wg.Done()
+
+			commands := make([]types.CommandRequest, len(indexes))
+			for i, index := range indexes {
+				commands[i] = req.Commands[index]
+			}
+			for i, result := range runPipeline(ctx, c.pool(ctx, client, req.DB), commands) {
+				results[indexes[i]] = result
+			}
+		}(c.shards[shard].client, indexes)
+	}
+	wg.Wait()
+
+	return results
+}
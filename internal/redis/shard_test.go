@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []interface{}
+		want    []string
+	}{
+		{"GET", []interface{}{"a"}, []string{"a"}},
+		{"set", []interface{}{"a", "1", "EX", 10.0}, []string{"a"}},
+		{"MGET", []interface{}{"a", "b"}, []string{"a", "b"}},
+		{"MSET", []interface{}{"a", "1", "b", "2"}, []string{"a", "b"}},
+		{"BLPOP", []interface{}{"a", "b", 5.0}, []string{"a", "b"}},
+		{"RENAME", []interface{}{"a", "b"}, []string{"a", "b"}},
+		{"EVAL", []interface{}{"return 1", 2.0, "a", "b", "arg"}, []string{"a", "b"}},
+		{"ZUNIONSTORE", []interface{}{"dst", 2.0, "a", "b", "WEIGHTS", 1.0, 2.0}, []string{"dst", "a", "b"}},
+		{"XREAD", []interface{}{"COUNT", 10.0, "STREAMS", "a", "b", "0", "0"}, []string{"a", "b"}},
+		{"OBJECT", []interface{}{"ENCODING", "a"}, []string{"a"}},
+		{"PING", nil, nil},
+		{"DBSIZE", nil, nil},
+		{"SCAN", []interface{}{"0", "MATCH", "a*"}, nil},
+		{"PUBLISH", []interface{}{"channel", "message"}, nil},
+		{"BITOP", []interface{}{"AND", "dst", "a", "b"}, []string{"dst", "a", "b"}},
+		{"JSON.MGET", []interface{}{"a", "b", "$"}, []string{"a", "b"}},
+		{"JSON.MSET", []interface{}{"a", "$", "1", "b", "$", "2"}, []string{"a", "b"}},
+		{"ZINTERCARD", []interface{}{2.0, "a", "b", "LIMIT", 1.0}, []string{"a", "b"}},
+		{"CUSTOM.CMD", []interface{}{"a", "b"}, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		got := commandKeys(types.CommandRequest{Command: tt.command, Args: tt.args})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("commandKeys(%s %v) = %v, want %v", tt.command, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestHashRing(t *testing.T) {
+	ring := newHashRing([]string{"primary", "a", "b"})
+
+	counts := make([]int, 3)
+	for i := 0; i < 3000; i++ {
+		counts[ring.locate(fmt.Sprintf("key:%d", i))]++
+	}
+	for shard, count := range counts {
+		if count < 500 {
+			t.Errorf("Shard %d got %d of 3000 keys, expected a roughly even spread", shard, count)
+		}
+	}
+
+	// Adding a shard only moves keys onto the new shard
+	grown := newHashRing([]string{"primary", "a", "b", "c"})
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		if before, after := ring.locate(key), grown.locate(key); after != before && after != 3 {
+			t.Fatalf("Key %s moved from shard %d to %d", key, before, after)
+		}
+	}
+
+	if ring.locate("{user:1}:profile") != ring.locate("{user:1}:cart") {
+		t.Error("Expected keys with the same hash tag on the same shard")
+	}
+}
+
+func TestValidateShards(t *testing.T) {
+	c := &Client{ring: newHashRing([]string{"primary", "a", "b"})}
+
+	// Find two keys on different shards
+	other := "key:0"
+	for i := 1; c.ring.locate(other) == c.ring.locate("key:0"); i++ {
+		other = fmt.Sprintf("key:%d", i)
+	}
+
+	err := c.ValidateCommandShard(types.CommandRequest{Command: "MGET", Args: []interface{}{"key:0", other}})
+	var validationErr *types.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != types.ErrCodeCrossShard {
+		t.Fatalf("Expected ERR_CROSS_SHARD, got %v", err)
+	}
+
+	if err := c.ValidateCommandShard(types.CommandRequest{Command: "MGET", Args: []interface{}{"{u}key:0", "{u}" + other}}); err != nil {
+		t.Errorf("Expected hash-tagged keys to pass, got %v", err)
+	}
+
+	// Pipelines may span shards, transactions may not
+	commands := []types.CommandRequest{
+		{Command: "GET", Args: []interface{}{"key:0"}},
+		{Command: "GET", Args: []interface{}{other}},
+	}
+	if err := c.ValidatePipelineShards(types.PipelineRequest{Commands: commands}); err != nil {
+		t.Errorf("Expected pipeline across shards to pass, got %v", err)
+	}
+	if err := c.ValidateTransactionShard(types.TransactionRequest{Commands: commands[:1], Watch: []string{other}}); err == nil {
+		t.Error("Expected transaction watching a key on another shard to fail")
+	}
+
+	if err := (&Client{}).ValidateCommandShard(types.CommandRequest{Command: "MGET", Args: []interface{}{"key:0", other}}); err != nil {
+		t.Errorf("Expected unsharded client to accept any keys, got %v", err)
+	}
+}
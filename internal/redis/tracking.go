@@ -0,0 +1,358 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// invalidationChannel is where Redis publishes the keys tracked
+// connections have read and that have since changed.
+const invalidationChannel = "__redis__:invalidate"
+
+// trackedPoolGrace is how long a replaced tracked pool stays open for the
+// requests still using it.
+const trackedPoolGrace = time.Minute
+
+// clientCache answers GETs on the configured database from memory. GETs
+// that miss are sent over connections with CLIENT TRACKING redirected to
+// a subscription on invalidationChannel, so Redis reports when any key
+// they read changes. The go-redis version in use can't receive RESP3
+// pushes, hence the RESP2 redirect.
+type clientCache struct {
+	cache   *trackingCache
+	options redis.Options
+
+	mutex    sync.Mutex
+	redirect int64 // ID of the invalidation connection, 0 while it is down
+	tracked  *redis.Client
+
+	invalidator *redis.Client
+	pubsub      *redis.PubSub
+
+	hits, misses, invalidations atomic.Int64
+}
+
+func newClientCache(base *redis.Options, config types.ClientCacheConfig) *clientCache {
+	cc := &clientCache{cache: newTrackingCache(config.MaxBytes), options: *base}
+	cc.options.PoolSize = config.PoolSize
+	cc.options.MinIdleConns = 0
+	cc.options.MaxIdleConns = config.PoolSize
+	cc.options.MaxActiveConns = config.PoolSize
+	cc.options.ClientName = connectionName(base.ClientName, "tracked")
+
+	// Each new invalidation connection has a new ID, which the tracked
+	// connections must redirect to
+	invalidatorOpts := *base
+	invalidatorOpts.PoolSize = 1
+	invalidatorOpts.MinIdleConns = 0
+	invalidatorOpts.MaxIdleConns = 0
+	invalidatorOpts.MaxActiveConns = 0
+	invalidatorOpts.ClientName = connectionName(base.ClientName, "invalidator")
+	invalidatorOpts.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		id, err := cn.ClientID(ctx).Result()
+		if err != nil {
+			return err
+		}
+		cc.connected(id)
+		return nil
+	}
+	cc.invalidator = redis.NewClient(&invalidatorOpts)
+	cc.pubsub = cc.invalidator.Subscribe(context.Background(), invalidationChannel)
+
+	go cc.listen()
+	return cc
+}
+
+// connected starts over with a new invalidation connection: keys read
+// through connections redirecting to the old one are no longer tracked.
+func (cc *clientCache) connected(id int64) {
+	opts := cc.options
+	opts.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		cmd := redis.NewStatusCmd(ctx, "CLIENT", "TRACKING", "ON", "REDIRECT", id)
+		_ = cn.Process(ctx, cmd)
+		return cmd.Err()
+	}
+
+	cc.mutex.Lock()
+	old := cc.tracked
+	cc.redirect = id
+	cc.tracked = redis.NewClient(&opts)
+	cc.cache.clear()
+	cc.mutex.Unlock()
+
+	if old != nil {
+		time.AfterFunc(trackedPoolGrace, func() { old.Close() })
+	}
+}
+
+// disconnected stops caching until the invalidation connection is back,
+// reporting whether it was up.
+func (cc *clientCache) disconnected() bool {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	wasUp := cc.redirect != 0
+	cc.redirect = 0
+	cc.cache.clear()
+	return wasUp
+}
+
+// listen drops invalidated keys until the client is closed.
+func (cc *clientCache) listen() {
+	ctx := context.Background()
+	for {
+		msg, err := cc.pubsub.ReceiveTimeout(ctx, time.Minute)
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.Is(err, redis.ErrClosed):
+				return
+			case errors.As(err, &netErr) && netErr.Timeout():
+				// Quiet, not necessarily gone; the PONG is the next message
+				_ = cc.pubsub.Ping(ctx)
+			case errors.Is(err, io.EOF) || errors.As(err, &netErr):
+				// Invalidations may have been lost; the next receive
+				// reconnects
+				if cc.disconnected() {
+					log.Printf("Client cache invalidation connection lost: %v", err)
+				}
+				time.Sleep(time.Second)
+			default:
+				// A flush invalidates everything with a message without
+				// keys, which go-redis can't parse
+				cc.cache.clear()
+				cc.invalidations.Add(1)
+			}
+			continue
+		}
+
+		if message, ok := msg.(*redis.Message); ok {
+			keys := message.PayloadSlice
+			if message.Payload != "" {
+				keys = append(keys, message.Payload)
+			}
+			cc.cache.invalidate(keys...)
+			cc.invalidations.Add(int64(len(keys)))
+		}
+	}
+}
+
+// get answers GET key from the cache or a tracked connection.
+func (cc *clientCache) get(ctx context.Context, key string) (interface{}, error) {
+	if value, ok := cc.cache.get(key); ok {
+		cc.hits.Add(1)
+		if value.missing {
+			return nil, redis.Nil
+		}
+		return value.value, nil
+	}
+	cc.misses.Add(1)
+
+	cc.mutex.Lock()
+	tracked, redirect := cc.tracked, cc.redirect
+	cc.mutex.Unlock()
+	if redirect == 0 {
+		return nil, errClientCacheDown
+	}
+
+	read := cc.cache.begin(key)
+	value, err := tracked.Get(ctx, key).Result()
+	if err == nil || IsNil(err) {
+		cc.cache.store(key, read, value, IsNil(err))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// cached returns key's value if the cache holds it, without reading it
+// from Redis otherwise.
+func (cc *clientCache) cached(key string) (interface{}, bool, error) {
+	value, ok := cc.cache.get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	cc.hits.Add(1)
+	if value.missing {
+		return nil, true, redis.Nil
+	}
+	return value.value, true, nil
+}
+
+// errClientCacheDown makes ExecuteCommand fall back to an untracked GET.
+var errClientCacheDown = errors.New("client cache invalidation connection is down")
+
+// cacheableGet returns the key of a GET the client cache can answer: on
+// the primary's configured database, without a tenant's Redis user, whose
+// ACLs could deny keys other tenants have cached.
+func cacheableGet(ctx context.Context, req types.CommandRequest, target *redis.Client, db int) (string, bool) {
+	if target != nil || db != 0 || len(req.Args) != 1 || !strings.EqualFold(req.Command, "GET") ||
+		credentialsFromContext(ctx).Username != "" {
+		return "", false
+	}
+	key, ok := req.Args[0].(string)
+	return key, ok
+}
+
+// wrote drops the keys write commands sent through this proxy changed,
+// without waiting for Redis to report them, so the next read sees the
+// writes.
+func (cc *clientCache) wrote(commands ...types.CommandRequest) {
+	for _, cmd := range commands {
+		if IsReadOnly(cmd.Command) {
+			continue
+		}
+		switch strings.ToUpper(cmd.Command) {
+		case "FLUSHDB", "FLUSHALL", "SWAPDB":
+			cc.cache.clear()
+		default:
+			cc.cache.invalidate(commandKeys(cmd)...)
+		}
+	}
+}
+
+func (cc *clientCache) stats(stats map[string]int) {
+	stats["client_cache_keys"] = cc.cache.len()
+	stats["client_cache_hits"] = int(cc.hits.Load())
+	stats["client_cache_misses"] = int(cc.misses.Load())
+	stats["client_cache_invalidations"] = int(cc.invalidations.Load())
+}
+
+func (cc *clientCache) Close() error {
+	err := cc.pubsub.Close()
+	cc.mutex.Lock()
+	if cc.tracked != nil {
+		if closeErr := cc.tracked.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	cc.mutex.Unlock()
+	if closeErr := cc.invalidator.Close(); closeErr != nil {
+		err = closeErr
+	}
+	return err
+}
+
+// trackingCache holds GET replies in least-recently-used order, bounded
+// by the bytes of their keys and values.
+type trackingCache struct {
+	mutex    sync.Mutex
+	maxBytes int
+	bytes    int
+	entries  map[string]*list.Element
+	lru      *list.List
+
+	// pending holds the read number of keys being fetched. Invalidating a
+	// key removes it, so a reply read before the change isn't stored.
+	pending  map[string]uint64
+	nextRead uint64
+}
+
+type trackedValue struct {
+	key     string
+	value   string
+	missing bool
+}
+
+func newTrackingCache(maxBytes int) *trackingCache {
+	return &trackingCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		pending:  make(map[string]uint64),
+	}
+}
+
+func (t *trackingCache) get(key string) (trackedValue, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	element, ok := t.entries[key]
+	if !ok {
+		return trackedValue{}, false
+	}
+	t.lru.MoveToFront(element)
+	return *element.Value.(*trackedValue), true
+}
+
+// begin registers a read of key, returning the number to store it with.
+func (t *trackingCache) begin(key string) uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.nextRead++
+	t.pending[key] = t.nextRead
+	return t.nextRead
+}
+
+// store caches the reply of read, unless key was invalidated since.
+func (t *trackingCache) store(key string, read uint64, value string, missing bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pending[key] != read {
+		return
+	}
+	delete(t.pending, key)
+
+	size := len(key) + len(value)
+	if size > t.maxBytes {
+		return
+	}
+	if element, ok := t.entries[key]; ok {
+		t.remove(element)
+	}
+	for t.bytes+size > t.maxBytes {
+		t.remove(t.lru.Back())
+	}
+	t.entries[key] = t.lru.PushFront(&trackedValue{key: key, value: value, missing: missing})
+	t.bytes += size
+}
+
+func (t *trackingCache) invalidate(keys ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(t.pending, key)
+		if element, ok := t.entries[key]; ok {
+			t.remove(element)
+		}
+	}
+}
+
+func (t *trackingCache) clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.entries = make(map[string]*list.Element)
+	t.lru.Init()
+	t.pending = make(map[string]uint64)
+	t.bytes = 0
+}
+
+func (t *trackingCache) len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.entries)
+}
+
+// remove drops an entry; the caller holds the lock.
+func (t *trackingCache) remove(element *list.Element) {
+	value := t.lru.Remove(element).(*trackedValue)
+	delete(t.entries, value.key)
+	t.bytes -= len(value.key) + len(value.value)
+}
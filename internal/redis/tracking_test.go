@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTrackingCache(t *testing.T) {
+	cache := newTrackingCache(12)
+
+	read := cache.begin("a")
+	cache.store("a", read, "1234", false)
+	if value, ok := cache.get("a"); !ok || value.value != "1234" {
+		t.Fatalf("Expected a cached, got %+v %v", value, ok)
+	}
+
+	// A reply read before an invalidation isn't stored
+	read = cache.begin("b")
+	cache.invalidate("b")
+	cache.store("b", read, "stale", false)
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected a reply from before an invalidation to be dropped")
+	}
+
+	// Missing keys are cached too
+	read = cache.begin("c")
+	cache.store("c", read, "", true)
+	if value, ok := cache.get("c"); !ok || !value.missing {
+		t.Errorf("Expected c cached as missing, got %+v %v", value, ok)
+	}
+
+	// Storing past the byte limit evicts the least recently used key
+	cache.get("a")
+	read = cache.begin("d")
+	cache.store("d", read, "123456", false)
+	if _, ok := cache.get("c"); ok {
+		t.Error("Expected c to be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Error("Expected a, used more recently, to stay")
+	}
+}
+
+func TestClientCacheWrote(t *testing.T) {
+	cc := &clientCache{cache: newTrackingCache(1 << 10)}
+	for _, key := range []string{"a", "b", "c"} {
+		cc.cache.store(key, cc.cache.begin(key), "v", false)
+	}
+
+	cc.wrote(
+		types.CommandRequest{Command: "GET", Args: []interface{}{"a"}},
+		types.CommandRequest{Command: "MSET", Args: []interface{}{"b", "1", "x", "2"}},
+	)
+	if _, ok := cc.cache.get("a"); !ok {
+		t.Error("Expected reads to leave a cached")
+	}
+	if _, ok := cc.cache.get("b"); ok {
+		t.Error("Expected MSET to invalidate b")
+	}
+
+	cc.wrote(types.CommandRequest{Command: "flushdb"})
+	if cc.cache.len() != 0 {
+		t.Error("Expected FLUSHDB to clear the cache")
+	}
+}
@@ -0,0 +1,261 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrTTLPolicy is returned for commands that would leave a key without
+// the expiry a tenant's TTL policy requires.
+var ErrTTLPolicy = errors.New("not allowed by the tenant's TTL policy")
+
+// TTLPolicy bounds how long the keys a tenant writes with the SET family
+// live. Keys written without an expiry get Default, or Max if Default is
+// 0, and expiries longer than Max are cut to it. Zero durations leave
+// keys as written.
+type TTLPolicy struct {
+	Default time.Duration
+	Max     time.Duration
+}
+
+type ttlPolicyKey struct{}
+
+// WithTTLPolicy applies policy to the commands executed with ctx.
+func WithTTLPolicy(ctx context.Context, policy TTLPolicy) context.Context {
+	return context.WithValue(ctx, ttlPolicyKey{}, policy)
+}
+
+func ttlPolicyFromContext(ctx context.Context) TTLPolicy {
+	policy, _ := ctx.Value(ttlPolicyKey{}).(TTLPolicy)
+	return policy
+}
+
+// applyTTLPolicy returns req with the TTL policy of ctx applied.
+func applyTTLPolicy(ctx context.Context, req types.CommandRequest) (types.CommandRequest, error) {
+	policy := ttlPolicyFromContext(ctx)
+	if policy == (TTLPolicy{}) {
+		return req, nil
+	}
+	req, _, err := policy.Apply(req, time.Now())
+	return req, err
+}
+
+// applyTTLPolicies returns commands with the TTL policy of ctx applied, in
+// a new slice if there is one.
+func applyTTLPolicies(ctx context.Context, commands []types.CommandRequest) ([]types.CommandRequest, error) {
+	if ttlPolicyFromContext(ctx) == (TTLPolicy{}) {
+		return commands, nil
+	}
+	applied := make([]types.CommandRequest, len(commands))
+	for i, cmd := range commands {
+		var err error
+		if applied[i], err = applyTTLPolicy(ctx, cmd); err != nil {
+			return nil, err
+		}
+	}
+	return applied, nil
+}
+
+// Apply returns req with an expiry added or capped as the policy requires,
+// and whether that changed it. Commands that can't be given an expiry
+// while keeping their reply, such as SETNX, MSET and PERSIST, are refused,
+// as are SET with KEEPTTL and scripts.
+func (p TTLPolicy) Apply(req types.CommandRequest, now time.Time) (types.CommandRequest, bool, error) {
+	ttl := p.ttl()
+	if ttl <= 0 {
+		return req, false, nil
+	}
+
+	command := strings.ToUpper(req.Command)
+	switch command {
+	case "SET":
+		if len(req.Args) < 2 {
+			return req, false, nil
+		}
+		return p.applyExpiryOption(req, 2, ttl, now)
+
+	case "GETEX":
+		if len(req.Args) < 1 {
+			return req, false, nil
+		}
+		for _, arg := range req.Args[1:] {
+			if strings.EqualFold(fmt.Sprint(arg), "PERSIST") {
+				return req, false, fmt.Errorf("%w: GETEX with PERSIST would remove the key's expiry", ErrTTLPolicy)
+			}
+		}
+		// GETEX without an option leaves the expiry alone
+		return p.capExpiryOption(req, 1, now)
+
+	case "GETSET":
+		// SET with GET replies the same
+		if len(req.Args) != 2 {
+			return req, false, nil
+		}
+		option, value := expiryOption(ttl)
+		return types.CommandRequest{Command: "SET", Args: []interface{}{req.Args[0], req.Args[1], "GET", option, value},
+			DB: req.DB, Database: req.Database, RESP3: req.RESP3, TimeoutMs: req.TimeoutMs}, true, nil
+
+	case "SETEX", "PSETEX", "EXPIRE", "PEXPIRE":
+		unit := time.Second
+		if command[0] == 'P' {
+			unit = time.Millisecond
+		}
+		return p.capDuration(req, command, 1, unit)
+
+	case "EXPIREAT", "PEXPIREAT":
+		unit := time.Second
+		if command[0] == 'P' {
+			unit = time.Millisecond
+		}
+		return p.capDeadline(req, 1, unit, now)
+
+	case "SETNX", "MSET", "MSETNX":
+		return req, false, fmt.Errorf("%w: %s can't set an expiry; use SET with EX", ErrTTLPolicy, command)
+
+	case "PERSIST":
+		return req, false, fmt.Errorf("%w: PERSIST would remove the key's expiry", ErrTTLPolicy)
+
+	case "EVAL", "EVALSHA", "FCALL":
+		// The writes of scripts and functions can't be seen to apply it
+		return req, false, fmt.Errorf("%w: %s may write keys without an expiry", ErrTTLPolicy, command)
+	}
+	return req, false, nil
+}
+
+// ttl returns the expiry given to keys written without one.
+func (p TTLPolicy) ttl() time.Duration {
+	if p.Default > 0 {
+		return p.Default
+	}
+	return p.Max
+}
+
+// applyExpiryOption gives a SET without an expiry option, from args[from]
+// on, an expiry of ttl, and caps the one it has.
+func (p TTLPolicy) applyExpiryOption(req types.CommandRequest, from int, ttl time.Duration, now time.Time) (types.CommandRequest, bool, error) {
+	for _, arg := range req.Args[from:] {
+		switch strings.ToUpper(fmt.Sprint(arg)) {
+		case "EX", "PX", "EXAT", "PXAT":
+			return p.capExpiryOption(req, from, now)
+		case "KEEPTTL":
+			return req, false, fmt.Errorf("%w: SET with KEEPTTL would keep a key without an expiry; use EX", ErrTTLPolicy)
+		}
+	}
+
+	option, value := expiryOption(ttl)
+	args := make([]interface{}, len(req.Args), len(req.Args)+2)
+	copy(args, req.Args)
+	req.Args = append(args, option, value)
+	return req, true, nil
+}
+
+// capExpiryOption cuts an EX, PX, EXAT or PXAT option, from args[from] on,
+// longer than Max down to Max: PX and PXAT to PX, in milliseconds, and EX
+// and EXAT to EX, unless Max isn't whole seconds.
+func (p TTLPolicy) capExpiryOption(req types.CommandRequest, from int, now time.Time) (types.CommandRequest, bool, error) {
+	if p.Max <= 0 {
+		return req, false, nil
+	}
+	for i := from; i < len(req.Args)-1; i++ {
+		var longer bool
+		option, capped := expiryOption(p.Max)
+		value, ok := numberArg(req.Args[i+1])
+		// Durations are compared in their unit, as converting them to a
+		// Duration could overflow
+		switch strings.ToUpper(fmt.Sprint(req.Args[i])) {
+		case "EX":
+			longer = value > int64(p.Max/time.Second)
+		case "PX":
+			longer = value > p.Max.Milliseconds()
+			option, capped = "PX", p.Max.Milliseconds()
+		case "EXAT":
+			longer = value > now.Add(p.Max).Unix()
+		case "PXAT":
+			longer = value > now.Add(p.Max).UnixMilli()
+			option, capped = "PX", p.Max.Milliseconds()
+		default:
+			continue
+		}
+		if !ok || !longer {
+			return req, false, nil
+		}
+		args := make([]interface{}, len(req.Args))
+		copy(args, req.Args)
+		args[i], args[i+1] = option, capped
+		req.Args = args
+		return req, true, nil
+	}
+	return req, false, nil
+}
+
+// capDuration cuts the duration in args[at], in unit, down to Max. A
+// command in seconds becomes its millisecond variant, such as PEXPIRE, if
+// Max isn't whole seconds.
+func (p TTLPolicy) capDuration(req types.CommandRequest, command string, at int, unit time.Duration) (types.CommandRequest, bool, error) {
+	if p.Max <= 0 || len(req.Args) <= at {
+		return req, false, nil
+	}
+	// Compared in unit, as converting value to a Duration could overflow
+	value, ok := numberArg(req.Args[at])
+	if !ok || value <= int64(p.Max/unit) {
+		return req, false, nil
+	}
+	args := make([]interface{}, len(req.Args))
+	copy(args, req.Args)
+	args[at] = int64(p.Max / unit)
+	if p.Max%unit != 0 {
+		req.Command, args[at] = "P"+command, p.Max.Milliseconds()
+	}
+	req.Args = args
+	return req, true, nil
+}
+
+// capDeadline cuts the Unix time in args[at], in unit, down to Max from
+// now.
+func (p TTLPolicy) capDeadline(req types.CommandRequest, at int, unit time.Duration, now time.Time) (types.CommandRequest, bool, error) {
+	if p.Max <= 0 || len(req.Args) <= at {
+		return req, false, nil
+	}
+	value, ok := numberArg(req.Args[at])
+	latest := now.Add(p.Max).UnixNano() / int64(unit)
+	if !ok || value <= latest {
+		return req, false, nil
+	}
+	args := make([]interface{}, len(req.Args))
+	copy(args, req.Args)
+	args[at] = latest
+	req.Args = args
+	return req, true, nil
+}
+
+// numberArg reads an integer argument, sent as a JSON number or a string.
+// Anything else is left for Redis to reject.
+func numberArg(arg interface{}) (int64, bool) {
+	switch v := arg.(type) {
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// expiryOption returns the SET option giving a key ttl: EX in seconds, or
+// PX in milliseconds, at least one, if ttl isn't whole seconds.
+func expiryOption(ttl time.Duration) (string, int64) {
+	if ttl%time.Second == 0 {
+		return "EX", int64(ttl / time.Second)
+	}
+	return "PX", max(ttl.Milliseconds(), 1)
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTTLPolicyApply(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy := TTLPolicy{Default: time.Hour, Max: 24 * time.Hour}
+
+	tests := []struct {
+		name    string
+		policy  TTLPolicy
+		command string
+		args    []interface{}
+		want    []interface{}
+		wantErr bool
+	}{
+		{"SET without an expiry gets the default", policy, "SET", []interface{}{"k", "v"}, []interface{}{"k", "v", "EX", int64(3600)}, false},
+		{"SET NX keeps its option", policy, "set", []interface{}{"k", "v", "NX"}, []interface{}{"k", "v", "NX", "EX", int64(3600)}, false},
+		{"SET within max_ttl is left alone", policy, "SET", []interface{}{"k", "v", "EX", float64(60)}, []interface{}{"k", "v", "EX", float64(60)}, false},
+		{"SET past max_ttl is capped", policy, "SET", []interface{}{"k", "v", "px", "172800000"}, []interface{}{"k", "v", "PX", int64(86400000)}, false},
+		{"SET with a huge EX is capped", policy, "SET", []interface{}{"k", "v", "EX", "10000000000"}, []interface{}{"k", "v", "EX", int64(86400)}, false},
+		{"SET with a huge PX is capped", policy, "SET", []interface{}{"k", "v", "PX", "9223372036854775807"}, []interface{}{"k", "v", "PX", int64(86400000)}, false},
+		{"Sub-second max_ttl is set in milliseconds", TTLPolicy{Max: 1500 * time.Millisecond}, "SET", []interface{}{"k", "v", "EX", "5"}, []interface{}{"k", "v", "PX", int64(1500)}, false},
+		{"Sub-second default is set in milliseconds", TTLPolicy{Max: 500 * time.Millisecond}, "SET", []interface{}{"k", "v"}, []interface{}{"k", "v", "PX", int64(500)}, false},
+		{"SET EXAT past max_ttl is capped", policy, "SET", []interface{}{"k", "v", "EXAT", float64(now.Unix() + 7*86400)}, []interface{}{"k", "v", "EX", int64(86400)}, false},
+		{"SET KEEPTTL is refused", policy, "SET", []interface{}{"k", "v", "KEEPTTL"}, nil, true},
+		{"max_ttl alone is the default", TTLPolicy{Max: time.Minute}, "SET", []interface{}{"k", "v"}, []interface{}{"k", "v", "EX", int64(60)}, false},
+		{"SETEX is capped", policy, "SETEX", []interface{}{"k", float64(999999), "v"}, []interface{}{"k", int64(86400), "v"}, false},
+		{"EXPIRE with a huge value is capped", policy, "EXPIRE", []interface{}{"k", "10000000000"}, []interface{}{"k", int64(86400)}, false},
+		{"PEXPIRE is capped", policy, "PEXPIRE", []interface{}{"k", "999999999999"}, []interface{}{"k", int64(86400000)}, false},
+		{"EXPIREAT is capped", policy, "EXPIREAT", []interface{}{"k", float64(now.Unix() + 7*86400)}, []interface{}{"k", now.Unix() + 86400}, false},
+		{"GETSET becomes SET with GET", policy, "GETSET", []interface{}{"k", "v"}, []interface{}{"k", "v", "GET", "EX", int64(3600)}, false},
+		{"GETEX past max_ttl is capped", policy, "GETEX", []interface{}{"k", "EX", float64(999999)}, []interface{}{"k", "EX", int64(86400)}, false},
+		{"GETEX PERSIST is refused", policy, "GETEX", []interface{}{"k", "PERSIST"}, nil, true},
+		{"PERSIST is refused", policy, "PERSIST", []interface{}{"k"}, nil, true},
+		{"SETNX is refused", policy, "SETNX", []interface{}{"k", "v"}, nil, true},
+		{"EVAL is refused", policy, "EVAL", []interface{}{"return redis.call('SET', KEYS[1], 'v')", float64(1), "k"}, nil, true},
+		{"FCALL is refused", policy, "fcall", []interface{}{"setter", float64(1), "k"}, nil, true},
+		{"Reads are left alone", policy, "GET", []interface{}{"k"}, []interface{}{"k"}, false},
+		{"No policy leaves SET alone", TTLPolicy{}, "SET", []interface{}{"k", "v"}, []interface{}{"k", "v"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tt.policy.Apply(types.CommandRequest{Command: tt.command, Args: tt.args}, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTTLPolicy) {
+					t.Fatalf("Expected ErrTTLPolicy, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Args, tt.want) {
+				t.Errorf("Expected args %v, got %v", tt.want, got.Args)
+			}
+		})
+	}
+}
+
+func TestTTLPolicySubSecondMax(t *testing.T) {
+	// Cut to whole seconds, EXPIRE would outlive max_ttl or expire at once
+	got, changed, err := TTLPolicy{Max: 1500 * time.Millisecond}.Apply(types.CommandRequest{Command: "EXPIRE", Args: []interface{}{"k", "10"}}, time.Now())
+	if err != nil || !changed {
+		t.Fatalf("Apply() = %v, %v", changed, err)
+	}
+	if got.Command != "PEXPIRE" || !reflect.DeepEqual(got.Args, []interface{}{"k", int64(1500)}) {
+		t.Errorf("Expected PEXPIRE k 1500, got %s %v", got.Command, got.Args)
+	}
+}
+
+func TestTTLPolicyExecute(t *testing.T) {
+	embedded, err := StartEmbedded()
+	if err != nil {
+		t.Fatalf("StartEmbedded() error = %v", err)
+	}
+	defer embedded.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary.Addr = embedded.Addr()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := WithTTLPolicy(context.Background(), TTLPolicy{Default: time.Minute, Max: time.Hour})
+	if _, err := client.ExecuteCommand(ctx, types.CommandRequest{Command: "SET", Args: []interface{}{"a", "1"}}); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	results := client.ExecutePipeline(ctx, types.PipelineRequest{Commands: []types.CommandRequest{
+		{Command: "SET", Args: []interface{}{"b", "2", "EX", float64(86400)}},
+	}})
+	if results[0].Error != "" {
+		t.Fatalf("Pipeline SET error = %s", results[0].Error)
+	}
+
+	for key, want := range map[string]int64{"a": 60, "b": 3600} {
+		ttl, err := client.ExecuteCommand(context.Background(), types.CommandRequest{Command: "TTL", Args: []interface{}{key}})
+		if err != nil || ttl != want {
+			t.Errorf("Expected %s to expire in %ds, got %v, %v", key, want, ttl, err)
+		}
+	}
+
+	_, err = client.ExecuteTransaction(ctx, types.TransactionRequest{Commands: []types.CommandRequest{{Command: "PERSIST", Args: []interface{}{"a"}}}})
+	if !errors.Is(err, ErrTTLPolicy) {
+		t.Errorf("Expected PERSIST in a transaction to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrTxConnLost is returned by a TxConn whose connection failed. Its
+// watched keys and queued commands went with the connection, so it can't
+// be used again.
+var ErrTxConnLost = errors.New("transaction connection lost")
+
+// TxConn holds one Redis connection for a transaction spanning several
+// requests, so WATCH, MULTI, the queued commands and EXEC all reach it.
+// It is not safe for concurrent use.
+type TxConn struct {
+	client *Client
+	conn   *redis.Conn
+
+	// base is the backend of the connection; with shards, commands with
+	// keys must live on it
+	base    *redis.Client
+	sharded bool
+
+	multi  bool
+	queued []types.CommandRequest
+	lost   bool
+}
+
+// OpenTxConn takes a connection for a transaction on the named database,
+// or db, and WATCHes keys on it. With shards, the keys pick the shard.
+func (c *Client) OpenTxConn(ctx context.Context, database string, db int, keys []string) (*TxConn, error) {
+	base, db, err := c.database(database, db)
+	if err != nil {
+		return nil, err
+	}
+	sharded := false
+	if base == nil {
+		if base, err = c.shardClient(watchCommand(keys)); err != nil {
+			return nil, err
+		}
+		sharded = base != nil
+	}
+	if base == nil {
+		base = c.writeClient(ctx)
+	}
+
+	tx := &TxConn{client: c, conn: c.pool(ctx, base, db).Conn(), base: base, sharded: sharded}
+	if len(keys) > 0 {
+		if _, err := tx.Do(ctx, watchCommand(keys)); err != nil {
+			tx.Close()
+			return nil, fmt.Errorf("WATCH failed: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// Do runs req on the connection. Within MULTI, write commands are queued
+// until Exec and reply QUEUED.
+func (t *TxConn) Do(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	if t.lost {
+		return nil, ErrTxConnLost
+	}
+	if strings.EqualFold(req.Command, "EXEC") || strings.EqualFold(req.Command, "DISCARD") {
+		return nil, fmt.Errorf("%s must go through Exec or Discard", strings.ToUpper(req.Command))
+	}
+	req, err := applyTTLPolicy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if t.sharded && len(commandKeys(req)) > 0 {
+		if owner, err := t.client.shardClient(req); err != nil {
+			return nil, err
+		} else if owner != t.base {
+			return nil, ErrCrossShard
+		}
+	}
+
+	args := make([]interface{}, len(req.Args)+1)
+	args[0] = req.Command
+	copy(args[1:], req.Args)
+	result, err := t.do(ctx, args...).Result()
+	if err != nil {
+		return nil, t.check(err)
+	}
+
+	switch {
+	case strings.EqualFold(req.Command, "MULTI"):
+		t.multi, t.queued = true, nil
+	case t.multi:
+		t.queued = append(t.queued, req)
+		return result, nil
+	}
+	return shapeReply(req, result), nil
+}
+
+// Exec runs the queued commands. If a watched key changed, they are
+// discarded and the response has Exec false.
+func (t *TxConn) Exec(ctx context.Context) (*types.TransactionResponse, error) {
+	if t.lost {
+		return nil, ErrTxConnLost
+	}
+	queued := t.queued
+	t.multi, t.queued = false, nil
+
+	start := time.Now()
+	replies, err := t.do(ctx, "EXEC").Slice()
+	duration := time.Since(start).Seconds() * 1000
+
+	response := &types.TransactionResponse{Queued: len(queued), Time: duration}
+	if IsNil(err) {
+		return response, nil
+	}
+	if err != nil {
+		return nil, t.check(err)
+	}
+	if t.client.clientCache != nil {
+		t.client.clientCache.wrote(queued...)
+	}
+
+	response.Exec = true
+	response.Results = make([]types.CommandResponse, len(replies))
+	for i, reply := range replies {
+		result := types.CommandResponse{Time: duration / float64(len(replies))}
+		if replyErr, ok := reply.(error); ok {
+			result.Error = replyErr.Error()
+		} else {
+			if i < len(queued) {
+				reply = shapeReply(queued[i], reply)
+			}
+			result.Result = reply
+			result.Type = string(InferResponseType(reply))
+		}
+		response.Results[i] = result
+	}
+	return response, nil
+}
+
+// Discard drops the queued commands and unwatches every key.
+func (t *TxConn) Discard(ctx context.Context) error {
+	if t.lost {
+		return ErrTxConnLost
+	}
+	t.multi, t.queued = false, nil
+	return t.check(t.do(ctx, "DISCARD").Err())
+}
+
+// Queued returns how many commands are queued for Exec.
+func (t *TxConn) Queued() int {
+	return len(t.queued)
+}
+
+// Close ends any transaction and returns the connection to its pool.
+func (t *TxConn) Close() error {
+	if !t.lost {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if t.multi {
+			_ = t.do(ctx, "DISCARD").Err()
+		}
+		_ = t.do(ctx, "UNWATCH").Err()
+	}
+	return t.conn.Close()
+}
+
+// do runs a command on the connection. Conn has no Do of its own.
+func (t *TxConn) do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	_ = t.conn.Process(ctx, cmd)
+	return cmd
+}
+
+// check marks the connection lost if err is anything but a Redis error
+// reply: a failed connection may have been replaced, silently dropping
+// the watched keys.
+func (t *TxConn) check(err error) error {
+	var redisErr redis.Error
+	if err == nil || errors.As(err, &redisErr) {
+		return err
+	}
+	t.lost = true
+	return fmt.Errorf("%w: %v", ErrTxConnLost, err)
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestTxConn(t *testing.T) {
+	embedded, err := StartEmbedded()
+	if err != nil {
+		t.Fatalf("StartEmbedded() error = %v", err)
+	}
+	defer embedded.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary.Addr = embedded.Addr()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	set := func(value string) types.CommandRequest {
+		return types.CommandRequest{Command: "SET", Args: []interface{}{"balance", value}}
+	}
+	if _, err := client.ExecuteCommand(ctx, set("10")); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+
+	// A write by someone else between WATCH and EXEC aborts the transaction
+	tx, err := client.OpenTxConn(ctx, "", 0, []string{"balance"})
+	if err != nil {
+		t.Fatalf("OpenTxConn() error = %v", err)
+	}
+	defer tx.Close()
+	if balance, err := tx.Do(ctx, types.CommandRequest{Command: "GET", Args: []interface{}{"balance"}}); err != nil || balance != "10" {
+		t.Fatalf("Expected the balance to read 10, got %v, %v", balance, err)
+	}
+	if _, err := tx.Do(ctx, types.CommandRequest{Command: "MULTI"}); err != nil {
+		t.Fatalf("MULTI error = %v", err)
+	}
+	if reply, err := tx.Do(ctx, set("5")); err != nil || reply != "QUEUED" || tx.Queued() != 1 {
+		t.Fatalf("Expected SET to be queued, got %v, %v", reply, err)
+	}
+	if _, err := client.ExecuteCommand(ctx, set("20")); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	response, err := tx.Exec(ctx)
+	if err != nil || response.Exec || response.Queued != 1 {
+		t.Fatalf("Expected EXEC to abort after the watched key changed, got %+v, %v", response, err)
+	}
+
+	// Retried without interference, it goes through
+	for _, cmd := range []types.CommandRequest{{Command: "WATCH", Args: []interface{}{"balance"}}, {Command: "MULTI"}, set("15")} {
+		if _, err := tx.Do(ctx, cmd); err != nil {
+			t.Fatalf("%s error = %v", cmd.Command, err)
+		}
+	}
+	response, err = tx.Exec(ctx)
+	if err != nil || !response.Exec || len(response.Results) != 1 || response.Results[0].Result != "OK" {
+		t.Fatalf("Expected EXEC to run SET, got %+v, %v", response, err)
+	}
+	if balance, _ := client.ExecuteCommand(ctx, types.CommandRequest{Command: "GET", Args: []interface{}{"balance"}}); balance != "15" {
+		t.Errorf("Expected the balance to be 15, got %v", balance)
+	}
+
+	if _, err := tx.Do(ctx, types.CommandRequest{Command: "EXEC"}); err == nil {
+		t.Error("Expected EXEC through Do to be refused")
+	}
+}
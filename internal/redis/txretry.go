@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrPrecondition is returned for transactions whose precondition script
+// failed to run.
+var ErrPrecondition = errors.New("transaction precondition failed")
+
+// txRetryPolicy spaces out the retries of transactions aborted by WATCH,
+// so those contending for a key don't collide again straight away.
+var txRetryPolicy = types.RetryConfig{InitialBackoff: 2 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Jitter: 0.5}
+
+// runTransaction runs req's commands in MULTI/EXEC on redisClient. With
+// watched keys it holds one connection for WATCH, the precondition and
+// EXEC, and tries again up to req.Retries times while EXEC is aborted by a
+// change to them. It returns the command results, the attempts made and
+// whether the precondition failed, which skips the transaction.
+func (c *Client) runTransaction(ctx context.Context, redisClient *redis.Client, req types.TransactionRequest) ([]redis.Cmder, int, bool, error) {
+	queue := func(pipe redis.Pipeliner) error {
+		for _, cmdReq := range req.Commands {
+			args := make([]interface{}, len(cmdReq.Args)+1)
+			args[0] = cmdReq.Command
+			copy(args[1:], cmdReq.Args)
+			pipe.Do(ctx, args...)
+		}
+		return nil
+	}
+	if len(req.Watch) == 0 {
+		results, err := redisClient.TxPipelined(ctx, queue)
+		return results, 1, false, err
+	}
+
+	var results []redis.Cmder
+	preconditionFailed := false
+	attempt := func(tx *redis.Tx) error {
+		if req.Precondition != nil {
+			ok, err := checkPrecondition(ctx, tx, req.Precondition)
+			if err != nil || !ok {
+				preconditionFailed = !ok
+				return err
+			}
+		}
+		var err error
+		results, err = tx.TxPipelined(ctx, queue)
+		return err
+	}
+
+	attempts := 1
+	err := redisClient.Watch(ctx, attempt, req.Watch...)
+	for ; errors.Is(err, redis.TxFailedErr) && attempts <= req.Retries; attempts++ {
+		if !sleepContext(ctx, backoff(txRetryPolicy, attempts-1)) {
+			break
+		}
+		err = redisClient.Watch(ctx, attempt, req.Watch...)
+	}
+	if attempts > 1 && c.retryObserver != nil {
+		outcome := err
+		if preconditionFailed {
+			outcome = nil
+		}
+		c.retryObserver("MULTI", attempts-1, outcome)
+	}
+	return results, attempts, preconditionFailed, err
+}
+
+// checkPrecondition runs a precondition on the watched connection, telling
+// whether it holds: it returned anything but nil, false or 0.
+func checkPrecondition(ctx context.Context, tx *redis.Tx, precondition *types.TransactionPrecondition) (bool, error) {
+	result, err := tx.Eval(ctx, precondition.Script, precondition.Keys, precondition.Args...).Result()
+	switch {
+	case IsNil(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%w: %v", ErrPrecondition, err)
+	}
+	return result != int64(0), nil
+}
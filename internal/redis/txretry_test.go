@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestExecuteTransactionRetries(t *testing.T) {
+	embedded, err := StartEmbedded()
+	if err != nil {
+		t.Fatalf("StartEmbedded() error = %v", err)
+	}
+	defer embedded.Close()
+
+	config := &types.Config{}
+	config.Redis.Primary.Addr = embedded.Addr()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// The precondition changes the watched key, aborting EXEC, on its
+	// first two runs
+	const contended = `local n = tonumber(redis.call('GET', KEYS[1]) or '0')
+if n < 2 then redis.call('SET', KEYS[1], n + 1) end
+return 1`
+	ctx := context.Background()
+	transaction := func(retries int, script string) types.TransactionRequest {
+		return types.TransactionRequest{
+			Commands:     []types.CommandRequest{{Command: "SET", Args: []interface{}{"result", "done"}}},
+			Watch:        []string{"writes"},
+			Retries:      retries,
+			Precondition: &types.TransactionPrecondition{Script: script, Keys: []string{"writes"}},
+		}
+	}
+
+	response, err := client.ExecuteTransaction(ctx, transaction(1, contended))
+	if err != nil || response.Exec || response.Attempts != 2 {
+		t.Fatalf("Expected 2 aborted attempts, got %+v, %v", response, err)
+	}
+
+	if _, err := client.ExecuteCommand(ctx, types.CommandRequest{Command: "DEL", Args: []interface{}{"writes"}}); err != nil {
+		t.Fatalf("DEL error = %v", err)
+	}
+	response, err = client.ExecuteTransaction(ctx, transaction(5, contended))
+	if err != nil || !response.Exec || response.Attempts != 3 || len(response.Results) != 1 {
+		t.Fatalf("Expected the third attempt to succeed, got %+v, %v", response, err)
+	}
+
+	response, err = client.ExecuteTransaction(ctx, transaction(5, `return redis.call('EXISTS', 'missing')`))
+	if err != nil || response.Exec || !response.PreconditionFailed || response.Attempts != 1 {
+		t.Fatalf("Expected the precondition to skip the transaction, got %+v, %v", response, err)
+	}
+
+	if _, err := client.ExecuteTransaction(ctx, transaction(0, `return redis.call('NOPE')`)); !errors.Is(err, ErrPrecondition) {
+		t.Errorf("Expected a failing script to return ErrPrecondition, got %v", err)
+	}
+}
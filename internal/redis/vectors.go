@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ParseVectorScores converts the reply of VSIM ... WITHSCORES, a flat
+// array of elements and scores under RESP2 or a map under RESP3, into
+// matches, most similar first.
+func ParseVectorScores(reply interface{}) ([]types.VectorMatch, error) {
+	var matches []types.VectorMatch
+	switch reply := reply.(type) {
+	case []interface{}:
+		if len(reply)%2 != 0 {
+			return nil, fmt.Errorf("unexpected VSIM reply of %d items", len(reply))
+		}
+		matches = make([]types.VectorMatch, 0, len(reply)/2)
+		for i := 0; i < len(reply); i += 2 {
+			score, err := replyFloat(reply[i+1])
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, types.VectorMatch{ID: fmt.Sprint(reply[i]), Score: score})
+		}
+	case map[interface{}]interface{}:
+		matches = make([]types.VectorMatch, 0, len(reply))
+		for id, value := range reply {
+			score, err := replyFloat(value)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, types.VectorMatch{ID: fmt.Sprint(id), Score: score})
+		}
+	default:
+		return nil, fmt.Errorf("unexpected VSIM reply %T", reply)
+	}
+
+	// RESP3 maps are unordered; RESP2 replies are sorted already
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestParseVectorScores(t *testing.T) {
+	expected := []types.VectorMatch{{ID: "doc:2", Score: 0.98}, {ID: "doc:7", Score: 0.91}}
+
+	resp2 := []interface{}{"doc:2", "0.98", "doc:7", "0.91"}
+	if matches, err := ParseVectorScores(resp2); err != nil || !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected %+v, got %+v, %v", expected, matches, err)
+	}
+
+	resp3 := map[interface{}]interface{}{"doc:7": 0.91, "doc:2": 0.98}
+	if matches, err := ParseVectorScores(resp3); err != nil || !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected RESP3 scores sorted, got %+v, %v", matches, err)
+	}
+
+	if _, err := ParseVectorScores([]interface{}{"doc:2"}); err == nil {
+		t.Error("Expected an element without a score to fail")
+	}
+}
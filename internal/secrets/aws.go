@@ -0,0 +1,184 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// awsService is the Secrets Manager service name in signatures.
+const awsService = "secretsmanager"
+
+// awsStore reads a JSON secret from AWS Secrets Manager, signing requests
+// with Signature Version 4.
+type awsStore struct {
+	endpoint     string
+	region       string
+	secretID     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	httpClient   *http.Client
+	now          func() time.Time
+}
+
+func newAWSStore(config types.AWSSecretsConfig, httpClient *http.Client, now func() time.Time) (*awsStore, error) {
+	region := config.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	store := &awsStore{
+		endpoint:     config.Endpoint,
+		region:       region,
+		secretID:     config.SecretID,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		httpClient:   httpClient,
+		now:          now,
+	}
+
+	switch {
+	case region == "":
+		return nil, errors.New("aws region or AWS_REGION is required")
+	case config.SecretID == "":
+		return nil, errors.New("aws secret_id is required")
+	case store.accessKey == "" || store.secretKey == "":
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+	if store.endpoint == "" {
+		store.endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsService, region)
+	}
+	return store, nil
+}
+
+func (s *awsStore) Fetch(ctx context.Context) (types.Secrets, error) {
+	body, _ := json.Marshal(map[string]string{"SecretId": s.secretID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return types.Secrets{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, body)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return types.Secrets{}, fmt.Errorf("aws secrets manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return types.Secrets{}, fmt.Errorf("aws secrets manager: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var secret struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return types.Secrets{}, fmt.Errorf("aws secrets manager: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(secret.SecretString), &data); err != nil {
+		return types.Secrets{}, fmt.Errorf("aws secrets manager: secret is not a JSON object: %w", err)
+	}
+	secrets, err := parseSecrets(data)
+	if err != nil {
+		return types.Secrets{}, fmt.Errorf("aws secrets manager: %w", err)
+	}
+	return secrets, nil
+}
+
+// sign adds the Signature Version 4 headers for body to req.
+func (s *awsStore) sign(req *http.Request, body []byte) {
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	signV4(req, body, s.accessKey, s.secretKey, s.region, awsService, s.now())
+}
+
+// signV4 signs req and every header it carries, plus Host, with AWS
+// Signature Version 4.
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	signed := make([]string, 0, len(headers))
+	for name := range headers {
+		signed = append(signed, name)
+	}
+	sort.Strings(signed)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQueryString encodes query sorted by key, spaces as %20.
+func canonicalQueryString(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
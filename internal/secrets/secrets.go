@@ -0,0 +1,63 @@
+// Package secrets fetches API keys and Redis passwords from an external
+// secret store: HashiCorp Vault or AWS Secrets Manager.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Provider names of secrets.provider.
+const (
+	ProviderVault = "vault"
+	ProviderAWS   = "aws"
+)
+
+// Store fetches the current secrets.
+type Store interface {
+	Fetch(ctx context.Context) (types.Secrets, error)
+}
+
+// NewStore returns the store config.Provider names.
+func NewStore(config types.SecretsConfig) (Store, error) {
+	httpClient := &http.Client{Timeout: config.Timeout}
+
+	switch config.Provider {
+	case ProviderVault:
+		return newVaultStore(config.Vault, httpClient)
+	case ProviderAWS:
+		return newAWSStore(config.AWS, httpClient, time.Now)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q", config.Provider)
+	}
+}
+
+// parseSecrets decodes the fields of a secret. Secret stores often hold
+// structured values as strings, so api_keys may be given as JSON text as
+// well as an array.
+func parseSecrets(data map[string]interface{}) (types.Secrets, error) {
+	var secrets types.Secrets
+
+	if keys, ok := data["api_keys"].(string); ok {
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(keys), &parsed); err != nil {
+			return secrets, fmt.Errorf("api_keys: %w", err)
+		}
+		data["api_keys"] = parsed
+	}
+
+	encoded, err := yaml.Marshal(data)
+	if err != nil {
+		return secrets, err
+	}
+	if err := yaml.Unmarshal(encoded, &secrets); err != nil {
+		return secrets, err
+	}
+	return secrets, nil
+}
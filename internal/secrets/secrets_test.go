@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestVaultStoreFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/proxy" {
+			t.Errorf("Expected KV v2 path, got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// api_keys stored as JSON text, as the Vault UI does
+		_, _ = io.WriteString(w, `{"data": {"data": {
+			"redis_password": "rotated",
+			"api_keys": "[{\"key\": \"k1\", \"tenant_id\": \"acme\", \"permissions\": [\"GET\"]}]"
+		}, "metadata": {"version": 3}}}`)
+	}))
+	defer server.Close()
+
+	store, err := NewStore(types.SecretsConfig{
+		Provider: ProviderVault,
+		Vault:    types.VaultConfig{Address: server.URL + "/", Token: "s.token", Namespace: "team", Path: "/secret/data/proxy"},
+	})
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	secrets, err := store.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if secrets.RedisPassword != "rotated" {
+		t.Errorf("Expected redis_password rotated, got %q", secrets.RedisPassword)
+	}
+	if len(secrets.APIKeys) != 1 || secrets.APIKeys[0].TenantID != "acme" || secrets.APIKeys[0].Permissions[0] != "GET" {
+		t.Errorf("Expected acme's API key, got %+v", secrets.APIKeys)
+	}
+}
+
+func TestVaultStoreErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := NewStore(types.SecretsConfig{
+		Provider: ProviderVault,
+		Vault:    types.VaultConfig{Address: server.URL, Token: "bad", Path: "secret/data/proxy"},
+	})
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if _, err := store.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected permission denied error, got %v", err)
+	}
+
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := NewStore(types.SecretsConfig{Provider: ProviderVault, Vault: types.VaultConfig{Address: server.URL, Path: "p"}}); err == nil {
+		t.Error("Expected error without a token")
+	}
+	if _, err := NewStore(types.SecretsConfig{Provider: "etcd"}); err == nil {
+		t.Error("Expected error for an unknown provider")
+	}
+}
+
+func TestAWSStoreFetch(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Errorf("Expected GetSecretValue, got %q", r.Header.Get("X-Amz-Target"))
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240101/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, ") {
+			t.Errorf("Unexpected Authorization header %q", auth)
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["SecretId"] != "proxy/prod" {
+			t.Errorf("Expected SecretId proxy/prod, got %v", body)
+		}
+
+		secret, _ := json.Marshal(map[string]interface{}{
+			"redis_password":     "primary-pass",
+			"dragonfly_password": "df-pass",
+		})
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": string(secret)})
+	}))
+	defer server.Close()
+
+	now := func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	store, err := newAWSStore(types.AWSSecretsConfig{Region: "eu-west-1", SecretID: "proxy/prod", Endpoint: server.URL}, http.DefaultClient, now)
+	if err != nil {
+		t.Fatalf("newAWSStore failed: %v", err)
+	}
+
+	secrets, err := store.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if secrets.RedisPassword != "primary-pass" || secrets.DragonflyPassword != "df-pass" {
+		t.Errorf("Unexpected secrets %+v", secrets)
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req := httptest.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	req.Header = http.Header{}
+	signV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
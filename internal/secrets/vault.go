@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// vaultStore reads a secret from a Vault KV version 2 engine.
+type vaultStore struct {
+	url        string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+func newVaultStore(config types.VaultConfig, httpClient *http.Client) (*vaultStore, error) {
+	address := config.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := config.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	switch {
+	case address == "":
+		return nil, errors.New("vault address or VAULT_ADDR is required")
+	case token == "":
+		return nil, errors.New("vault token or VAULT_TOKEN is required")
+	case config.Path == "":
+		return nil, errors.New("vault path is required")
+	}
+
+	return &vaultStore{
+		url:        strings.TrimSuffix(address, "/") + "/v1/" + strings.TrimPrefix(config.Path, "/"),
+		token:      token,
+		namespace:  config.Namespace,
+		httpClient: httpClient,
+	}, nil
+}
+
+func (s *vaultStore) Fetch(ctx context.Context) (types.Secrets, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return types.Secrets{}, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return types.Secrets{}, fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return types.Secrets{}, fmt.Errorf("vault: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// KV version 2 nests the secret's fields under data.data
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return types.Secrets{}, fmt.Errorf("vault: %w", err)
+	}
+	if secret.Data.Data == nil {
+		return types.Secrets{}, errors.New("vault: secret has no data; is path a KV version 2 data path?")
+	}
+
+	secrets, err := parseSecrets(secret.Data.Data)
+	if err != nil {
+		return types.Secrets{}, fmt.Errorf("vault: %w", err)
+	}
+	return secrets, nil
+}
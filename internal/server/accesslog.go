@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// AccessLogFields are the fields an access log entry can carry, in their
+// default order.
+var AccessLogFields = []string{"method", "path", "status", "latency", "tenant", "command", "bytes", "trace_id"}
+
+// IsAccessLogField reports whether field is one of AccessLogFields.
+func IsAccessLogField(field string) bool {
+	for _, known := range AccessLogFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Log levels, lowest first.
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+// AccessLog logs one line per request, as JSON or as key=value text.
+type AccessLog struct {
+	config types.AccessLogConfig
+	fields []string
+	format string
+	level  int
+
+	// Writer for the entries; nil means the standard logger's output, so
+	// that entries follow log.SetOutput
+	out   io.Writer
+	mutex sync.Mutex
+
+	traceID func(context.Context) string
+}
+
+// NewAccessLog creates an access log writing to out, or to the standard
+// logger's output if out is nil. traceID returns the trace ID of a
+// request's context, or "" if it has none.
+func NewAccessLog(config types.LoggingConfig, out io.Writer, traceID func(context.Context) string) *AccessLog {
+	fields := config.Access.Fields
+	if len(fields) == 0 {
+		fields = AccessLogFields
+	}
+	return &AccessLog{
+		config:  config.Access,
+		fields:  fields,
+		format:  config.Format,
+		level:   logLevels[strings.ToLower(config.Level)],
+		out:     out,
+		traceID: traceID,
+	}
+}
+
+// accessLogEntry collects what handlers learn about a request.
+type accessLogEntry struct {
+	mutex   sync.Mutex
+	tenant  string
+	command string
+}
+
+type accessLogContextKey struct{}
+
+// SetAccessLogTenant records the tenant of the request being logged.
+func SetAccessLogTenant(ctx context.Context, tenant string) {
+	if entry, ok := ctx.Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		entry.mutex.Lock()
+		entry.tenant = tenant
+		entry.mutex.Unlock()
+	}
+}
+
+// SetAccessLogCommand records the command of the request being logged.
+// Only the first one is kept, so requests running several commands are
+// logged with the one that names them.
+func SetAccessLogCommand(ctx context.Context, command string) {
+	if entry, ok := ctx.Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		entry.mutex.Lock()
+		if entry.command == "" {
+			entry.command = strings.ToUpper(command)
+		}
+		entry.mutex.Unlock()
+	}
+}
+
+// Middleware logs every request that isn't excluded. Successful requests
+// are sampled; 4xx are logged at warn and 5xx at error level, and entries
+// below the configured level are dropped.
+func (a *AccessLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range a.config.Exclude {
+			if prefix != "" && strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		start := time.Now()
+		entry := &accessLogEntry{}
+		recorder := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessLogContextKey{}, entry)))
+
+		level := "info"
+		switch {
+		case recorder.status >= 500:
+			level = "error"
+		case recorder.status >= 400:
+			level = "warn"
+		}
+		if logLevels[level] < a.level {
+			return
+		}
+		if level == "info" && a.config.SampleRate < 1 && rand.Float64() >= a.config.SampleRate {
+			return
+		}
+
+		entry.mutex.Lock()
+		values := map[string]interface{}{
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"status":  recorder.status,
+			"latency": time.Since(start).Seconds(),
+			"tenant":  entry.tenant,
+			"command": entry.command,
+			"bytes":   recorder.bytes,
+		}
+		entry.mutex.Unlock()
+		if a.traceID != nil {
+			values["trace_id"] = a.traceID(r.Context())
+		}
+
+		a.write(start, level, values)
+	})
+}
+
+func (a *AccessLog) write(at time.Time, level string, values map[string]interface{}) {
+	var line bytes.Buffer
+	timestamp := at.UTC().Format(time.RFC3339Nano)
+
+	if a.format == "text" {
+		fmt.Fprintf(&line, "time=%s level=%s", timestamp, level)
+		for _, field := range a.fields {
+			value := values[field]
+			if s, ok := value.(string); ok && (s == "" || strings.ContainsAny(s, " \"=")) {
+				value = fmt.Sprintf("%q", s)
+			}
+			fmt.Fprintf(&line, " %s=%v", field, value)
+		}
+	} else {
+		fmt.Fprintf(&line, `{"time":%q,"level":%q`, timestamp, level)
+		for _, field := range a.fields {
+			value, _ := json.Marshal(values[field])
+			fmt.Fprintf(&line, `,%q:%s`, field, value)
+		}
+		line.WriteByte('}')
+	}
+	line.WriteByte('\n')
+
+	out := a.out
+	if out == nil {
+		out = log.Writer()
+	}
+	a.mutex.Lock()
+	out.Write(line.Bytes())
+	a.mutex.Unlock()
+}
+
+// accessLogWriter records the status and body size of a response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (aw *accessLogWriter) WriteHeader(code int) {
+	if !aw.wroteHeader {
+		aw.status = code
+		aw.wroteHeader = true
+	}
+	aw.ResponseWriter.WriteHeader(code)
+}
+
+func (aw *accessLogWriter) Write(data []byte) (int, error) {
+	aw.wroteHeader = true
+	n, err := aw.ResponseWriter.Write(data)
+	aw.bytes += n
+	return n, err
+}
+
+func (aw *accessLogWriter) Flush() {
+	if flusher, ok := aw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g.
+// to enable full duplex for NDJSON batches.
+func (aw *accessLogWriter) Unwrap() http.ResponseWriter {
+	return aw.ResponseWriter
+}
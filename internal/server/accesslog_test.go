@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestAccessLogJSON(t *testing.T) {
+	var out bytes.Buffer
+	config := types.LoggingConfig{Level: "info", Format: "json", Access: types.AccessLogConfig{SampleRate: 1}}
+	traceID := func(context.Context) string { return "4bf92f3577b34da6a3ce929d0e0e4736" }
+	accessLog := NewAccessLog(config, &out, traceID)
+
+	handler := accessLog.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetAccessLogTenant(r.Context(), "acme")
+		SetAccessLogCommand(r.Context(), "get")
+		SetAccessLogCommand(r.Context(), "SET")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/command", nil))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+	}
+	expected := map[string]interface{}{
+		"level":    "info",
+		"method":   "POST",
+		"path":     "/v1/command",
+		"status":   float64(201),
+		"tenant":   "acme",
+		"command":  "GET",
+		"bytes":    float64(5),
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	for field, value := range expected {
+		if entry[field] != value {
+			t.Errorf("Expected %s %v, got %v", field, value, entry[field])
+		}
+	}
+}
+
+func TestAccessLogFieldsAndText(t *testing.T) {
+	var out bytes.Buffer
+	config := types.LoggingConfig{
+		Level:  "info",
+		Format: "text",
+		Access: types.AccessLogConfig{Fields: []string{"status", "path"}, SampleRate: 1},
+	}
+	handler := NewAccessLog(config, &out, nil).Middleware(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/keys/a", nil))
+
+	line := strings.TrimSpace(out.String())
+	if !strings.HasSuffix(line, "level=warn status=404 path=/v1/keys/a") {
+		t.Errorf("Expected the configured fields in order, got %q", line)
+	}
+}
+
+func TestAccessLogFiltering(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	tests := []struct {
+		name    string
+		config  types.LoggingConfig
+		handler http.Handler
+		path    string
+		logged  bool
+	}{
+		{"excluded path", types.LoggingConfig{Access: types.AccessLogConfig{SampleRate: 1, Exclude: []string{"/health"}}}, ok, "/health", false},
+		{"below level", types.LoggingConfig{Level: "warn", Access: types.AccessLogConfig{SampleRate: 1}}, ok, "/v1/command", false},
+		{"error above level", types.LoggingConfig{Level: "warn", Access: types.AccessLogConfig{SampleRate: 1}}, failing, "/v1/command", true},
+		{"successes sampled out", types.LoggingConfig{Access: types.AccessLogConfig{SampleRate: 0}}, ok, "/v1/command", false},
+		{"errors never sampled out", types.LoggingConfig{Access: types.AccessLogConfig{SampleRate: 0}}, failing, "/v1/command", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			NewAccessLog(tt.config, &out, nil).Middleware(tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+			if logged := out.Len() > 0; logged != tt.logged {
+				t.Errorf("Expected logged %v, got %q", tt.logged, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrValueTooLarge is returned for writes above the tenant's value size
+// limit.
+var ErrValueTooLarge = errors.New("value exceeds the maximum size")
+
+// ErrKeyTooLarge is returned for writes to keys above the tenant's key
+// length limit.
+var ErrKeyTooLarge = errors.New("key exceeds the maximum length")
+
+// valueCommands are the commands whose first argument is a key holding a
+// value worth sizing, and whether they write it.
+var valueCommands = map[string]bool{
+	"GET": false, "GETDEL": false, "GETEX": false, "GETRANGE": false,
+	"HGET": false, "HGETALL": false, "HMGET": false, "HVALS": false,
+	"LRANGE": false, "LINDEX": false, "SMEMBERS": false,
+	"ZRANGE": false, "ZRANGEBYSCORE": false, "ZREVRANGE": false,
+	"XRANGE": false, "XREVRANGE": false, "JSON.GET": false,
+
+	"SET": true, "SETEX": true, "PSETEX": true, "SETNX": true, "GETSET": true, "APPEND": true,
+	"HSET": true, "HMSET": true, "HSETNX": true,
+	"LPUSH": true, "RPUSH": true, "SADD": true, "ZADD": true,
+	"XADD": true, "JSON.SET": true, "JSON.MERGE": true,
+}
+
+// ValueAccess describes the value a command reads or writes.
+type ValueAccess struct {
+	Key   string
+	Write bool
+}
+
+// ValueKey returns the key whose value cmd reads or writes, if cmd is one
+// of the commands whose values are tracked.
+func ValueKey(cmd types.CommandRequest) (ValueAccess, bool) {
+	write, ok := valueCommands[strings.ToUpper(cmd.Command)]
+	if !ok || len(cmd.Args) == 0 {
+		return ValueAccess{}, false
+	}
+	return ValueAccess{Key: fmt.Sprint(cmd.Args[0]), Write: write}, true
+}
+
+// WriteSize estimates how many bytes a write stores: the size of its
+// arguments after the key.
+func WriteSize(cmd types.CommandRequest) int {
+	size := 0
+	for _, arg := range cmd.Args[min(1, len(cmd.Args)):] {
+		size += ValueSize(arg)
+	}
+	return size
+}
+
+// ValueSize estimates the size of a command argument or reply in bytes.
+// Containers count the size of their elements.
+func ValueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case []interface{}:
+		size := 0
+		for _, item := range v {
+			size += ValueSize(item)
+		}
+		return size
+	case []types.HashField:
+		size := 0
+		for _, field := range v {
+			size += len(field.Field) + ValueSize(field.Value)
+		}
+		return size
+	case map[string]interface{}:
+		size := 0
+		for key, item := range v {
+			size += len(key) + ValueSize(item)
+		}
+		return size
+	case map[interface{}]interface{}:
+		size := 0
+		for key, item := range v {
+			size += ValueSize(key) + ValueSize(item)
+		}
+		return size
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+type bigValueKey struct {
+	tenant string
+	db     int
+	key    string
+}
+
+// BigValueTracker keeps the largest values seen above a threshold, up to
+// a fixed number of keys, for the big value report.
+type BigValueTracker struct {
+	threshold int
+	capacity  int
+
+	mutex  sync.Mutex
+	values map[bigValueKey]*types.BigValue
+}
+
+// NewBigValueTracker creates a tracker reporting values above threshold
+// bytes and keeping at most capacity keys.
+func NewBigValueTracker(threshold, capacity int) *BigValueTracker {
+	return &BigValueTracker{
+		threshold: threshold,
+		capacity:  capacity,
+		values:    make(map[bigValueKey]*types.BigValue),
+	}
+}
+
+// Threshold is the size in bytes above which values are tracked.
+func (t *BigValueTracker) Threshold() int {
+	return t.threshold
+}
+
+// Record notes a value of the given size and reports whether it is above
+// the threshold and a key not tracked before, which callers may log. When
+// the tracker is full the smallest tracked value makes way for a bigger
+// one.
+func (t *BigValueTracker) Record(tenant string, db int, command string, access ValueAccess, bytes int) bool {
+	if bytes <= t.threshold {
+		return false
+	}
+
+	direction := "read"
+	if access.Write {
+		direction = "write"
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := bigValueKey{tenant: tenant, db: db, key: access.Key}
+	if value, ok := t.values[id]; ok {
+		value.Count++
+		value.LastSeen = time.Now()
+		if bytes >= value.Bytes {
+			value.Bytes = bytes
+			value.Command = strings.ToUpper(command)
+			value.Direction = direction
+		}
+		return false
+	}
+
+	if len(t.values) >= t.capacity {
+		var smallest *types.BigValue
+		var smallestID bigValueKey
+		for key, value := range t.values {
+			if smallest == nil || value.Bytes < smallest.Bytes {
+				smallest, smallestID = value, key
+			}
+		}
+		if smallest.Bytes >= bytes {
+			return false
+		}
+		delete(t.values, smallestID)
+	}
+
+	t.values[id] = &types.BigValue{
+		Key:       access.Key,
+		DB:        db,
+		Tenant:    tenant,
+		Command:   strings.ToUpper(command),
+		Direction: direction,
+		Bytes:     bytes,
+		Count:     1,
+		LastSeen:  time.Now(),
+	}
+	return true
+}
+
+// Report returns the tracked values, biggest first.
+func (t *BigValueTracker) Report() types.BigValueReport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	values := make([]types.BigValue, 0, len(t.values))
+	for _, value := range t.values {
+		values = append(values, *value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Bytes > values[j].Bytes })
+
+	return types.BigValueReport{ThresholdBytes: t.threshold, Values: values}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestValueKeyAndSizes(t *testing.T) {
+	set := types.CommandRequest{Command: "set", Args: []interface{}{"k", strings.Repeat("x", 10), "EX", 60.0}}
+	access, ok := ValueKey(set)
+	if !ok || access.Key != "k" || !access.Write {
+		t.Fatalf("Expected SET to write key k, got %+v (%v)", access, ok)
+	}
+	if size := WriteSize(set); size != 14 {
+		t.Errorf("Expected write size 14, got %d", size)
+	}
+
+	if _, ok := ValueKey(types.CommandRequest{Command: "EVAL", Args: []interface{}{"return 1", 0.0}}); ok {
+		t.Error("Expected EVAL not to be tracked")
+	}
+
+	reply := []types.HashField{{Field: "name", Value: "ada"}, {Field: "tags", Value: []interface{}{"a", "bc"}}}
+	if size := ValueSize(reply); size != 4+3+4+3 {
+		t.Errorf("Expected hash reply size 14, got %d", size)
+	}
+}
+
+func TestBigValueTracker(t *testing.T) {
+	tracker := NewBigValueTracker(100, 2)
+	read := ValueAccess{Key: "small"}
+
+	if tracker.Record("t1", 0, "GET", read, 50) {
+		t.Error("Expected values under the threshold to be ignored")
+	}
+	if !tracker.Record("t1", 0, "GET", ValueAccess{Key: "a"}, 200) {
+		t.Error("Expected a new big value to be reported")
+	}
+	if tracker.Record("t1", 0, "SET", ValueAccess{Key: "a", Write: true}, 300) {
+		t.Error("Expected a known key not to be reported again")
+	}
+	tracker.Record("t1", 0, "GET", ValueAccess{Key: "b"}, 150)
+
+	// Full: a bigger value evicts the smallest, a smaller one is dropped
+	tracker.Record("t1", 0, "GET", ValueAccess{Key: "c"}, 500)
+	tracker.Record("t1", 0, "GET", ValueAccess{Key: "d"}, 120)
+
+	report := tracker.Report()
+	if len(report.Values) != 2 || report.Values[0].Key != "c" || report.Values[1].Key != "a" {
+		t.Fatalf("Expected c then a, got %+v", report.Values)
+	}
+	if a := report.Values[1]; a.Bytes != 300 || a.Count != 2 || a.Direction != "write" {
+		t.Errorf("Expected a to keep its largest write, got %+v", a)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BudgetHeader lets clients declare how long they are willing to wait for
+// a response, in milliseconds.
+const BudgetHeader = "X-SR-Budget-Ms"
+
+// ErrBudgetExceeded is returned when a request's latency budget was spent
+// before it could be dispatched to Redis, e.g. while queued in the proxy.
+var ErrBudgetExceeded = errors.New("latency budget exceeded")
+
+// BudgetPhase records how much of the budget had elapsed when a phase ended.
+type BudgetPhase struct {
+	Name    string
+	Elapsed time.Duration
+}
+
+// Budget tracks time spent by a request against its declared budget.
+type Budget struct {
+	start  time.Time
+	limit  time.Duration
+	mutex  sync.Mutex
+	phases []BudgetPhase
+}
+
+// NewBudget starts a budget of limit at start.
+func NewBudget(start time.Time, limit time.Duration) *Budget {
+	return &Budget{start: start, limit: limit}
+}
+
+// Mark records the end of a named phase.
+func (b *Budget) Mark(phase string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.phases = append(b.phases, BudgetPhase{Name: phase, Elapsed: time.Since(b.start)})
+}
+
+// Elapsed returns the time spent since the request arrived.
+func (b *Budget) Elapsed() time.Duration {
+	return time.Since(b.start)
+}
+
+// Remaining returns the unspent budget, which may be negative.
+func (b *Budget) Remaining() time.Duration {
+	return b.limit - b.Elapsed()
+}
+
+// Check returns an error wrapping ErrBudgetExceeded, with a breakdown of
+// the phases so far, if the budget has been exhausted.
+func (b *Budget) Check() error {
+	elapsed := b.Elapsed()
+	if elapsed < b.limit {
+		return nil
+	}
+
+	b.mutex.Lock()
+	phases := make([]string, len(b.phases))
+	for i, phase := range b.phases {
+		phases[i] = fmt.Sprintf("%s=%dms", phase.Name, phase.Elapsed.Milliseconds())
+	}
+	b.mutex.Unlock()
+
+	return fmt.Errorf("%w: %dms budget exhausted after %dms before dispatch to Redis [%s]",
+		ErrBudgetExceeded, b.limit.Milliseconds(), elapsed.Milliseconds(), strings.Join(phases, ", "))
+}
+
+type budgetContextKey struct{}
+
+// BudgetFromContext returns the budget attached by BudgetMiddleware.
+func BudgetFromContext(ctx context.Context) (*Budget, bool) {
+	budget, ok := ctx.Value(budgetContextKey{}).(*Budget)
+	return budget, ok
+}
+
+// CheckBudget marks the end of phase and checks the request's budget. It
+// returns nil for requests without a budget.
+func CheckBudget(ctx context.Context, phase string) error {
+	budget, ok := BudgetFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	budget.Mark(phase)
+	return budget.Check()
+}
+
+// BudgetMiddleware attaches a Budget to requests carrying X-SR-Budget-Ms.
+// It should be the outermost middleware so that time spent in the rest of
+// the stack is charged to the request.
+func BudgetMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(BudgetHeader)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			http.Error(w, fmt.Sprintf(`{"error": "Invalid %s header", "details": "must be a positive integer"}`, BudgetHeader), http.StatusBadRequest)
+			return
+		}
+
+		budget := NewBudget(start, time.Duration(ms)*time.Millisecond)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), budgetContextKey{}, budget)))
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudgetCheck(t *testing.T) {
+	budget := NewBudget(time.Now(), time.Minute)
+	if err := budget.Check(); err != nil {
+		t.Errorf("Expected fresh budget to pass, got %v", err)
+	}
+
+	if budget.Remaining() <= 0 {
+		t.Error("Expected remaining budget to be positive")
+	}
+
+	spent := NewBudget(time.Now().Add(-50*time.Millisecond), 10*time.Millisecond)
+	spent.Mark("auth")
+
+	err := spent.Check()
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "auth=") {
+		t.Errorf("Expected phase breakdown in error, got %v", err)
+	}
+}
+
+func TestBudgetMiddleware(t *testing.T) {
+	var checkErr error
+	handler := BudgetMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		checkErr = CheckBudget(r.Context(), "handler")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// No header: no budget is attached
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if checkErr != nil {
+		t.Errorf("Expected no budget error without header, got %v", checkErr)
+	}
+
+	// Generous budget
+	req.Header.Set(BudgetHeader, "10000")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if checkErr != nil {
+		t.Errorf("Expected budget to be available, got %v", checkErr)
+	}
+
+	// Budget spent before dispatch
+	req.Header.Set(BudgetHeader, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !errors.Is(checkErr, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", checkErr)
+	}
+
+	// Invalid header
+	req.Header.Set(BudgetHeader, "soon")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid budget, got %d", w.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize keeps the occasional huge response from pinning its
+// buffer in the pool.
+const maxPooledBufferSize = 64 << 10
+
+var (
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	gzipPool   = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+)
+
+// GetBuffer returns an empty buffer from the pool shared by the
+// middlewares and handlers that buffer request and response bodies.
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns buffer to the pool. Nothing may use it or its bytes
+// afterwards.
+func PutBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
+
+// getGzipWriter returns a pooled gzip writer writing to w. Setting one up
+// allocates far more than a buffer, so they are reused too.
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	gzipWriter := gzipPool.Get().(*gzip.Writer)
+	gzipWriter.Reset(w)
+	return gzipWriter
+}
+
+// putGzipWriter returns a closed gzip writer to the pool.
+func putGzipWriter(gzipWriter *gzip.Writer) {
+	gzipWriter.Reset(io.Discard)
+	gzipPool.Put(gzipWriter)
+}
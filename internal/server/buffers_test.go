@@ -0,0 +1,60 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPooledGzipWriters(t *testing.T) {
+	handler := CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Query().Get("body")))
+	}))
+
+	// Writers go back to the pool after each response, so later responses
+	// reuse them and must still be complete streams of their own
+	for _, body := range []string{"first", "second", strings.Repeat("third", 1000)} {
+		req := httptest.NewRequest("GET", "/?body="+body, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Failed to create gzip reader: %v", err)
+		}
+		decompressed, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Failed to read decompressed content: %v", err)
+		}
+		if string(decompressed) != body {
+			t.Errorf("Expected %q, got %q", body, decompressed)
+		}
+	}
+}
+
+func TestCachedBodyOutlivesPooledBuffer(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 0)
+	handler := CachingMiddleware(cache, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Reuse pooled buffers for other responses
+	for i := 0; i < 10; i++ {
+		buffer := GetBuffer()
+		buffer.WriteString(strings.Repeat("x", 64))
+		PutBuffer(buffer)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("X-Cache") != "HIT" || w.Body.String() != `{"path":"/health"}` {
+		t.Errorf("Expected the cached body intact, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
@@ -1,14 +1,20 @@
 package server
 
 import (
+	"bytes"
+	"container/list"
+	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
 )
 
 // CacheEntry represents a cached response
@@ -18,77 +24,184 @@ type CacheEntry struct {
 	StatusCode int
 	Timestamp  time.Time
 	TTL        time.Duration
+
+	// StaleWhileRevalidate is how long after TTL the entry may still be
+	// served while a fresh response is fetched
+	StaleWhileRevalidate time.Duration
+
+	// Tenant is the tenant the response belongs to, if any, so a tenant's
+	// entries can be purged together
+	Tenant string
 }
 
-// IsExpired checks if the cache entry has expired
+// IsExpired checks if the cache entry has expired; it may still be served
+// stale until its stale window ends too
 func (ce *CacheEntry) IsExpired() bool {
 	return time.Since(ce.Timestamp) > ce.TTL
 }
 
-// InMemoryCache provides simple in-memory caching for responses
+// isUnservable reports whether the entry is past its stale window
+func (ce *CacheEntry) isUnservable() bool {
+	return time.Since(ce.Timestamp) > ce.TTL+ce.StaleWhileRevalidate
+}
+
+// InMemoryCache caches responses in least-recently-used order, bounded by
+// their total size in bytes. Each tenant's entries form a partition with
+// its own byte limit and LRU list, so one tenant filling the cache evicts
+// its own entries rather than everyone else's.
 type InMemoryCache struct {
-	entries map[string]*CacheEntry
-	mutex   sync.RWMutex
-	maxSize int
+	mutex      sync.Mutex
+	entries    map[string]*list.Element
+	partitions map[string]*cachePartition
+	bytes      int
+	maxBytes   int
+	tenantMax  int
+
+	// refreshing holds the keys being revalidated in the background
+	refreshing map[string]bool
+}
+
+// cachePartition is one tenant's entries, most recently used first.
+type cachePartition struct {
+	lru   *list.List
+	bytes int
 }
 
-// NewInMemoryCache creates a new in-memory cache
-func NewInMemoryCache(maxSize int) *InMemoryCache {
+// cacheItem is the value of a partition's list elements.
+type cacheItem struct {
+	key   string
+	entry *CacheEntry
+	size  int
+}
+
+// NewInMemoryCache creates a cache holding up to maxBytes of responses, of
+// which a single tenant may hold up to tenantMaxBytes; 0 means maxBytes.
+func NewInMemoryCache(maxBytes, tenantMaxBytes int) *InMemoryCache {
+	if tenantMaxBytes <= 0 || tenantMaxBytes > maxBytes {
+		tenantMaxBytes = maxBytes
+	}
 	return &InMemoryCache{
-		entries: make(map[string]*CacheEntry),
-		maxSize: maxSize,
+		entries:    make(map[string]*list.Element),
+		partitions: make(map[string]*cachePartition),
+		maxBytes:   maxBytes,
+		tenantMax:  tenantMaxBytes,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// entrySize approximates the memory an entry takes up.
+func entrySize(key string, entry *CacheEntry) int {
+	size := len(key) + len(entry.Data) + len(entry.Tenant)
+	for name, values := range entry.Headers {
+		size += len(name)
+		for _, value := range values {
+			size += len(value)
+		}
 	}
+	return size
 }
 
-// Get retrieves a cache entry
+// Get retrieves a cache entry, which may be expired but within its stale
+// window, and marks it recently used
 func (c *InMemoryCache) Get(key string) (*CacheEntry, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	
-	entry, exists := c.entries[key]
-	if !exists || entry.IsExpired() {
-		if exists {
-			// Clean up expired entry
-			go func() {
-				c.mutex.Lock()
-				delete(c.entries, key)
-				c.mutex.Unlock()
-			}()
-		}
+	element, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	item := element.Value.(*cacheItem)
+	if item.entry.isUnservable() {
+		c.remove(element)
 		return nil, false
 	}
 	
-	return entry, true
+	c.partitions[item.entry.Tenant].lru.MoveToFront(element)
+	return item.entry, true
 }
 
-// Set stores a cache entry
-func (c *InMemoryCache) Set(key string, entry *CacheEntry) {
+// Set stores a cache entry, evicting the least recently used entries of
+// its tenant, or of the largest tenant, to make room. Entries larger than
+// a tenant may hold are not cached, which Set reports by returning false.
+func (c *InMemoryCache) Set(key string, entry *CacheEntry) bool {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
-	// Simple eviction strategy: remove oldest entries if at capacity
-	if len(c.entries) >= c.maxSize {
-		c.evictOldest()
+	if element, exists := c.entries[key]; exists {
+		c.remove(element)
 	}
 	
-	c.entries[key] = entry
+	size := entrySize(key, entry)
+	if size > c.tenantMax {
+		return false
+	}
+	
+	partition, ok := c.partitions[entry.Tenant]
+	if !ok {
+		partition = &cachePartition{lru: list.New()}
+		c.partitions[entry.Tenant] = partition
+	}
+	for partition.bytes+size > c.tenantMax {
+		c.remove(partition.lru.Back())
+	}
+	for c.bytes+size > c.maxBytes {
+		c.remove(c.largestPartition().lru.Back())
+	}
+	
+	// Evicting may have dropped the tenant's emptied partition
+	if c.partitions[entry.Tenant] == nil {
+		c.partitions[entry.Tenant] = partition
+	}
+	c.entries[key] = partition.lru.PushFront(&cacheItem{key: key, entry: entry, size: size})
+	partition.bytes += size
+	c.bytes += size
+	return true
 }
 
-// evictOldest removes the oldest cache entry
-func (c *InMemoryCache) evictOldest() {
-	var oldestKey string
-	var oldestTime time.Time
-	
-	for key, entry := range c.entries {
-		if oldestKey == "" || entry.Timestamp.Before(oldestTime) {
-			oldestKey = key
-			oldestTime = entry.Timestamp
+// largestPartition returns the partition holding the most bytes.
+func (c *InMemoryCache) largestPartition() *cachePartition {
+	var largest *cachePartition
+	for _, partition := range c.partitions {
+		if largest == nil || partition.bytes > largest.bytes {
+			largest = partition
 		}
 	}
+	return largest
+}
+
+// remove drops an entry's element; the caller holds the lock.
+func (c *InMemoryCache) remove(element *list.Element) {
+	item := element.Value.(*cacheItem)
+	partition := c.partitions[item.entry.Tenant]
+	partition.lru.Remove(element)
+	partition.bytes -= item.size
+	if partition.lru.Len() == 0 {
+		delete(c.partitions, item.entry.Tenant)
+	}
+	delete(c.entries, item.key)
+	c.bytes -= item.size
+}
+
+// Revalidate runs refresh in the background to replace the stale entry for
+// key, unless a refresh of key is already running.
+func (c *InMemoryCache) Revalidate(key string, refresh func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	
-	if oldestKey != "" {
-		delete(c.entries, oldestKey)
+	if c.refreshing[key] {
+		return
 	}
+	c.refreshing[key] = true
+	
+	go func() {
+		defer func() {
+			c.mutex.Lock()
+			delete(c.refreshing, key)
+			c.mutex.Unlock()
+		}()
+		refresh()
+	}()
 }
 
 // ClearExpired removes all expired entries
@@ -96,29 +209,79 @@ func (c *InMemoryCache) ClearExpired() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
-	for key, entry := range c.entries {
-		if entry.IsExpired() {
-			delete(c.entries, key)
+	for _, element := range c.entries {
+		if element.Value.(*cacheItem).entry.isUnservable() {
+			c.remove(element)
 		}
 	}
 }
 
+// Delete removes the entry for key, reporting whether there was one.
+func (c *InMemoryCache) Delete(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	element, exists := c.entries[key]
+	if exists {
+		c.remove(element)
+	}
+	return exists
+}
+
+// DeleteTenant removes every entry belonging to tenant and returns how
+// many there were.
+func (c *InMemoryCache) DeleteTenant(tenant string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	partition, ok := c.partitions[tenant]
+	if !ok {
+		return 0
+	}
+	removed := partition.lru.Len()
+	for partition.lru.Len() > 0 {
+		c.remove(partition.lru.Back())
+	}
+	return removed
+}
+
+// Clear removes every entry and returns how many there were.
+func (c *InMemoryCache) Clear() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	removed := len(c.entries)
+	c.entries = make(map[string]*list.Element)
+	c.partitions = make(map[string]*cachePartition)
+	c.bytes = 0
+	return removed
+}
+
 // Size returns the number of entries in cache
 func (c *InMemoryCache) Size() int {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	return len(c.entries)
 }
 
-// generateCacheKey creates a cache key from request details
-func generateCacheKey(r *http.Request, body []byte) string {
+// Bytes returns the approximate size of the cached entries
+func (c *InMemoryCache) Bytes() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.bytes
+}
+
+// generateCacheKey creates a cache key from request details, under the
+// types.CacheKey policy keyPolicy
+func generateCacheKey(r *http.Request, body []byte, keyPolicy string) string {
 	h := md5.New()
 	h.Write([]byte(r.Method))
 	h.Write([]byte(r.URL.Path))
 	h.Write([]byte(r.URL.RawQuery))
 	
-	// Include authorization header in key for tenant isolation
-	if auth := r.Header.Get("Authorization"); auth != "" {
+	// Include authorization header in key for tenant isolation, unless the
+	// response is the same for everyone
+	if auth := r.Header.Get("Authorization"); auth != "" && keyPolicy != types.CacheKeyPublic {
 		h.Write([]byte(auth))
 	}
 	
@@ -130,30 +293,148 @@ func generateCacheKey(r *http.Request, body []byte) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-// CacheableResponseWriter captures response data for caching
+// CacheReasonHeader explains, with cache.debug, why a response was or
+// wasn't cached.
+const CacheReasonHeader = "X-Cache-Reason"
+
+// Reasons in CacheReasonHeader, which is followed by details
+const (
+	CacheReasonCached  = "cached"        // served from or stored in the cache
+	CacheReasonMethod  = "method"        // the method is never cached
+	CacheReasonNoRule  = "no-rule"       // no TTL policy covers the path or command
+	CacheReasonWrite   = "write-command" // the command may write
+	CacheReasonFormat  = "format"        // raw and CSV replies aren't cached
+	CacheReasonNoCache = "no-cache"      // the client asked for a fresh response
+	CacheReasonStatus  = "status"        // failed or empty responses aren't cached
+	CacheReasonSize    = "size-limit"    // larger than a tenant's share of the cache
+)
+
+// ExplainCache sets CacheReasonHeader to reason and detail and logs them,
+// if debug is on.
+func ExplainCache(w http.ResponseWriter, r *http.Request, debug bool, reason, detail string) {
+	if !debug {
+		return
+	}
+	w.Header().Set(CacheReasonHeader, reason+"; "+detail)
+	log.Printf("Cache decision for %s %s: %s (%s)", r.Method, r.URL.Path, reason, detail)
+}
+
+// NoCache reports whether the client asked for a fresh response with
+// Cache-Control: no-cache (or no-store), bypassing cached entries.
+func NoCache(r *http.Request) bool {
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store":
+			return true
+		}
+	}
+	return false
+}
+
+// CacheableResponseWriter buffers a response so it can be cached and
+// given an ETag before it is sent. The buffer comes from the shared pool,
+// so release must be called once the response is sent.
 type CacheableResponseWriter struct {
-	http.ResponseWriter
 	statusCode int
-	buffer     []byte
-	headers    http.Header
+	header     http.Header
+	buffer     *bytes.Buffer
+}
+
+func newCacheableResponseWriter() *CacheableResponseWriter {
+	return &CacheableResponseWriter{statusCode: http.StatusOK, header: make(http.Header), buffer: GetBuffer()}
+}
+
+func (crw *CacheableResponseWriter) release() {
+	PutBuffer(crw.buffer)
+	crw.buffer = nil
+}
+
+func (crw *CacheableResponseWriter) Header() http.Header {
+	return crw.header
 }
 
 func (crw *CacheableResponseWriter) WriteHeader(statusCode int) {
 	crw.statusCode = statusCode
-	crw.ResponseWriter.WriteHeader(statusCode)
 }
 
 func (crw *CacheableResponseWriter) Write(data []byte) (int, error) {
-	crw.buffer = append(crw.buffer, data...)
-	return crw.ResponseWriter.Write(data)
+	return crw.buffer.Write(data)
+}
+
+// ETag returns an entity tag for a response body. It is weak because the
+// body may still be compressed on the way out, which a strong tag would
+// have to distinguish.
+func ETag(data []byte) string {
+	return fmt.Sprintf(`W/"%x"`, md5.Sum(data))
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison If-None-Match calls for.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeCacheEntry writes a cached response, or 304 Not Modified when the
+// client's If-None-Match already has it.
+func ServeCacheEntry(w http.ResponseWriter, r *http.Request, entry *CacheEntry) {
+	for key, values := range entry.Headers {
+		w.Header()[key] = values
+	}
+	w.Header().Set("X-Cache-Age", strconv.Itoa(int(time.Since(entry.Timestamp).Seconds())))
+	writeTagged(w, r, entry.StatusCode, entry.Data)
+}
+
+// writeTagged writes a response with its ETag, or 304 Not Modified if it
+// matches If-None-Match.
+func writeTagged(w http.ResponseWriter, r *http.Request, statusCode int, data []byte) {
+	if statusCode == http.StatusOK {
+		etag := ETag(data)
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(data)
 }
 
-// CachingMiddleware provides intelligent caching for read-only Redis operations
-func CachingMiddleware(cache *InMemoryCache) func(http.Handler) http.Handler {
+// CachingMiddleware provides intelligent caching for read-only Redis
+// operations. Rules with a path add or override the cache policy for GET
+// requests to that path, including whether credentials are part of the
+// cache key. With debug, responses explain the decision in
+// CacheReasonHeader.
+func CachingMiddleware(cache *InMemoryCache, debug bool, rules ...types.CacheRule) func(http.Handler) http.Handler {
+	paths := make(map[string]types.CacheRule)
+	for _, rule := range rules {
+		if rule.Path != "" {
+			paths[rule.Path] = rule
+		}
+	}
+	
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Only cache GET requests and specific read-only Redis commands
-			if !isCacheable(r) {
+			policy, ok := cachePolicy(r, paths)
+			if !ok {
+				// Handlers caching on their own, like /v1/command, explain
+				// their decisions over this one
+				if r.Method == "GET" {
+					ExplainCache(w, r, debug, CacheReasonNoRule, "no cache rule for "+r.URL.Path)
+				} else {
+					ExplainCache(w, r, debug, CacheReasonMethod, r.Method+" requests are not cached by path")
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -161,59 +442,127 @@ func CachingMiddleware(cache *InMemoryCache) func(http.Handler) http.Handler {
 			// Read request body for cache key generation
 			var body []byte
 			if r.Body != nil {
-				body, _ = io.ReadAll(r.Body)
-				r.Body = io.NopCloser(strings.NewReader(string(body)))
+				buffer := GetBuffer()
+				defer PutBuffer(buffer)
+				_, _ = buffer.ReadFrom(r.Body)
+				body = buffer.Bytes()
+				r.Body = io.NopCloser(bytes.NewReader(body))
 			}
 			
 			// Generate cache key
-			cacheKey := generateCacheKey(r, body)
+			cacheKey := generateCacheKey(r, body, policy.Key)
+			w.Header().Set("X-Cache-Key", cacheKey)
 			
-			// Check cache
-			if entry, found := cache.Get(cacheKey); found {
-				// Serve from cache
-				for key, values := range entry.Headers {
-					for _, value := range values {
-						w.Header().Add(key, value)
-					}
+			// Check cache, unless the client wants a fresh response
+			bypass := NoCache(r)
+			if entry, found := cache.Get(cacheKey); found && !bypass {
+				if entry.IsExpired() {
+					// Serve the stale entry now and refresh it for later requests
+					w.Header().Set("X-Cache", "STALE")
+					// The refresh may outlive the pooled body
+					body := bytes.Clone(body)
+					cache.Revalidate(cacheKey, func() {
+						refresh := r.Clone(context.WithoutCancel(r.Context()))
+						refresh.Body = io.NopCloser(bytes.NewReader(body))
+						crw := newCacheableResponseWriter()
+						defer crw.release()
+						next.ServeHTTP(crw, refresh)
+						cacheResponse(cache, cacheKey, crw, policy)
+					})
+				} else {
+					w.Header().Set("X-Cache", "HIT")
 				}
-				w.Header().Set("X-Cache", "HIT")
-				w.Header().Set("X-Cache-Age", strconv.Itoa(int(time.Since(entry.Timestamp).Seconds())))
-				w.WriteHeader(entry.StatusCode)
-				_, _ = w.Write(entry.Data)
+				ExplainCache(w, r, debug, CacheReasonCached, describePolicy(policy))
+				ServeCacheEntry(w, r, entry)
 				return
 			}
 			
 			// Cache miss - capture response
-			crw := &CacheableResponseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK,
-				headers:        make(http.Header),
-			}
-			
-			// Copy headers before processing
-			for key, values := range w.Header() {
-				crw.headers[key] = values
-			}
-			
+			crw := newCacheableResponseWriter()
+			defer crw.release()
 			next.ServeHTTP(crw, r)
 			
-			// Cache the response if successful and cacheable
-			if crw.statusCode == http.StatusOK && len(crw.buffer) > 0 {
-				ttl := getCacheTTL(r)
-				entry := &CacheEntry{
-					Data:       crw.buffer,
-					Headers:    crw.headers,
-					StatusCode: crw.statusCode,
-					Timestamp:  time.Now(),
-					TTL:        ttl,
+			for key, values := range crw.header {
+				w.Header()[key] = values
+			}
+			reason, detail := cacheResponse(cache, cacheKey, crw, policy)
+			if reason == CacheReasonCached {
+				if bypass {
+					w.Header().Set("X-Cache", "BYPASS")
+					reason, detail = CacheReasonNoCache, "refreshed at the client's request; "+detail
+				} else {
+					w.Header().Set("X-Cache", "MISS")
 				}
-				cache.Set(cacheKey, entry)
-				w.Header().Set("X-Cache", "MISS")
 			}
+			ExplainCache(w, r, debug, reason, detail)
+			writeTagged(w, r, crw.statusCode, crw.buffer.Bytes())
 		})
 	}
 }
 
+// cacheResponse caches a captured response if it was successful,
+// returning the CacheReasonHeader reason and detail of the decision.
+func cacheResponse(cache *InMemoryCache, key string, crw *CacheableResponseWriter, policy types.CacheRule) (string, string) {
+	if crw.statusCode != http.StatusOK {
+		return CacheReasonStatus, fmt.Sprintf("status %d is not cached", crw.statusCode)
+	}
+	if crw.buffer.Len() == 0 {
+		return CacheReasonStatus, "empty responses are not cached"
+	}
+	// Content-Length no longer holds once the body is compressed
+	headers := crw.header.Clone()
+	headers.Del("Content-Length")
+	stored := cache.Set(key, &CacheEntry{
+		Data:                 bytes.Clone(crw.buffer.Bytes()),
+		Headers:              headers,
+		StatusCode:           crw.statusCode,
+		Timestamp:            time.Now(),
+		TTL:                  policy.TTL,
+		StaleWhileRevalidate: policy.StaleWhileRevalidate,
+	})
+	if !stored {
+		return CacheReasonSize, fmt.Sprintf("%d bytes are more than a tenant may cache", crw.buffer.Len())
+	}
+	return CacheReasonCached, describePolicy(policy)
+}
+
+// describePolicy details a cache rule for CacheReasonHeader.
+func describePolicy(policy types.CacheRule) string {
+	detail := fmt.Sprintf("ttl %v", policy.TTL)
+	if policy.StaleWhileRevalidate > 0 {
+		detail += fmt.Sprintf(", stale_while_revalidate %v", policy.StaleWhileRevalidate)
+	}
+	return detail
+}
+
+// cachePolicy returns how long to cache the response to r, and how to key
+// it: by the rule for its path if there is one, else by the built-in
+// defaults.
+func cachePolicy(r *http.Request, paths map[string]types.CacheRule) (types.CacheRule, bool) {
+	if rule, ok := paths[r.URL.Path]; ok && r.Method == "GET" {
+		if rule.Key == "" {
+			rule.Key = defaultCacheKey(r)
+		}
+		return rule, true
+	}
+	if !isCacheable(r) {
+		return types.CacheRule{}, false
+	}
+	return types.CacheRule{TTL: getCacheTTL(r), Key: defaultCacheKey(r)}, true
+}
+
+// defaultCacheKey returns the cache key policy for r without one
+// configured: health and metrics are the same for every caller, so they
+// are shared rather than cached once per API key.
+func defaultCacheKey(r *http.Request) string {
+	switch r.URL.Path {
+	case "/health", "/metrics":
+		return types.CacheKeyPublic
+	default:
+		return types.CacheKeyAuth
+	}
+}
+
 // isCacheable determines if a request can be cached
 func isCacheable(r *http.Request) bool {
 	// Cache GET requests (health, metrics)
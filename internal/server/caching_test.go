@@ -1,14 +1,20 @@
 package server
 
 import (
+	"compress/gzip"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
 )
 
 func TestInMemoryCache(t *testing.T) {
-	cache := NewInMemoryCache(3) // Small cache for testing
+	cache := NewInMemoryCache(1<<20, 0)
 
 	// Test Set and Get
 	entry := &CacheEntry{
@@ -38,7 +44,7 @@ func TestInMemoryCache(t *testing.T) {
 }
 
 func TestCacheExpiration(t *testing.T) {
-	cache := NewInMemoryCache(10)
+	cache := NewInMemoryCache(1<<20, 0)
 
 	// Create expired entry
 	expiredEntry := &CacheEntry{
@@ -64,67 +70,49 @@ func TestCacheExpiration(t *testing.T) {
 }
 
 func TestCacheEviction(t *testing.T) {
-	cache := NewInMemoryCache(2) // Very small cache
-
-	// Fill cache to capacity
-	entry1 := &CacheEntry{
-		Data:       []byte("data1"),
-		Headers:    make(http.Header),
-		StatusCode: 200,
-		Timestamp:  time.Now(),
-		TTL:        time.Minute,
-	}
-
-	entry2 := &CacheEntry{
-		Data:       []byte("data2"),
-		Headers:    make(http.Header),
-		StatusCode: 200,
-		Timestamp:  time.Now().Add(time.Second), // Newer
-		TTL:        time.Minute,
+	newEntry := func(tenant string) *CacheEntry {
+		return &CacheEntry{Data: make([]byte, 95), StatusCode: 200, Timestamp: time.Now(), TTL: time.Minute, Tenant: tenant}
 	}
 
-	cache.Set("key1", entry1)
-	cache.Set("key2", entry2)
+	// Room for three 100-byte entries (key, data and tenant), two per tenant
+	cache := NewInMemoryCache(300, 200)
+	cache.Set("key1", newEntry("a"))
+	cache.Set("key2", newEntry("a"))
 
-	if cache.Size() != 2 {
-		t.Errorf("Expected cache size 2, got %d", cache.Size())
+	// Using key1 makes key2 the least recently used
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("Expected key1 to be cached")
 	}
-
-	// Add third entry, should evict oldest
-	entry3 := &CacheEntry{
-		Data:       []byte("data3"),
-		Headers:    make(http.Header),
-		StatusCode: 200,
-		Timestamp:  time.Now().Add(2 * time.Second),
-		TTL:        time.Minute,
+	cache.Set("key3", newEntry("a"))
+	if _, found := cache.Get("key2"); found {
+		t.Error("Expected the least recently used entry to be evicted")
 	}
-
-	cache.Set("key3", entry3)
-
-	if cache.Size() != 2 {
-		t.Errorf("Expected cache size 2 after eviction, got %d", cache.Size())
+	if cache.Size() != 2 || cache.Bytes() != 200 {
+		t.Errorf("Expected 2 entries of 200 bytes, got %d of %d", cache.Size(), cache.Bytes())
 	}
 
-	// key1 should be evicted (oldest)
-	_, found := cache.Get("key1")
-	if found {
-		t.Error("Expected oldest entry to be evicted")
+	// Another tenant gets its own share; once the cache is full, the
+	// largest tenant gives way
+	cache.Set("key4", newEntry("b"))
+	cache.Set("key5", newEntry("b"))
+	if _, found := cache.Get("key1"); found {
+		t.Error("Expected tenant a's least recently used entry to make room")
 	}
-
-	// key2 and key3 should still exist
-	_, found = cache.Get("key2")
-	if !found {
-		t.Error("Expected key2 to still exist")
+	for _, key := range []string{"key3", "key4", "key5"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("Expected %s to still exist", key)
+		}
 	}
 
-	_, found = cache.Get("key3")
-	if !found {
-		t.Error("Expected key3 to still exist")
+	// Entries larger than a tenant's share aren't cached
+	cache.Set("big", &CacheEntry{Data: make([]byte, 250), Timestamp: time.Now(), TTL: time.Minute})
+	if _, found := cache.Get("big"); found || cache.Size() != 3 {
+		t.Error("Expected an oversized entry to be skipped")
 	}
 }
 
 func TestClearExpired(t *testing.T) {
-	cache := NewInMemoryCache(10)
+	cache := NewInMemoryCache(1<<20, 0)
 
 	// Add valid entry
 	validEntry := &CacheEntry{
@@ -181,9 +169,9 @@ func TestGenerateCacheKey(t *testing.T) {
 	req3 := httptest.NewRequest("POST", "/command", nil)
 	req3.Header.Set("Authorization", "Bearer token1")
 
-	key1 := generateCacheKey(req1, nil)
-	key2 := generateCacheKey(req2, nil)
-	key3 := generateCacheKey(req3, nil)
+	key1 := generateCacheKey(req1, nil, types.CacheKeyAuth)
+	key2 := generateCacheKey(req2, nil, types.CacheKeyAuth)
+	key3 := generateCacheKey(req3, nil, types.CacheKeyAuth)
 
 	// Different auth tokens should generate different keys
 	if key1 == key2 {
@@ -196,14 +184,47 @@ func TestGenerateCacheKey(t *testing.T) {
 	}
 
 	// Same request should generate same key
-	key1Duplicate := generateCacheKey(req1, nil)
+	key1Duplicate := generateCacheKey(req1, nil, types.CacheKeyAuth)
 	if key1 != key1Duplicate {
 		t.Error("Expected same cache key for identical requests")
 	}
+
+	// Public responses are shared whatever the credentials
+	if generateCacheKey(req1, nil, types.CacheKeyPublic) != generateCacheKey(req2, nil, types.CacheKeyPublic) {
+		t.Error("Expected the same public cache key for different auth tokens")
+	}
+}
+
+func TestCachingMiddlewareKeyPolicy(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 0)
+	var requests atomic.Int32
+	handler := CachingMiddleware(cache, false, types.CacheRule{Path: "/v1/info", TTL: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}))
+
+	get := func(path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	get("/health", "key-a")
+	if w := get("/health", "key-b"); w.Header().Get("X-Cache") != "HIT" || requests.Load() != 1 {
+		t.Errorf("Expected /health to be cached once for every caller, got %s after %d requests", w.Header().Get("X-Cache"), requests.Load())
+	}
+
+	get("/v1/info", "key-a")
+	if w := get("/v1/info", "key-b"); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "key-b" {
+		t.Errorf("Expected path rules to be cached per credentials by default, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
 }
 
 func TestCachingMiddleware(t *testing.T) {
-	cache := NewInMemoryCache(10)
+	cache := NewInMemoryCache(1<<20, 0)
 	requestCount := 0
 
 	// Create handler that increments counter
@@ -214,7 +235,7 @@ func TestCachingMiddleware(t *testing.T) {
 		_, _ = w.Write([]byte(`{"count": ` + string(rune(requestCount+48)) + `}`))
 	})
 
-	handler := CachingMiddleware(cache)(testHandler)
+	handler := CachingMiddleware(cache, false)(testHandler)
 
 	// First request - should hit handler
 	req := httptest.NewRequest("GET", "/health", nil)
@@ -292,4 +313,204 @@ func TestGetCacheTTL(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestCachePurge(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 0)
+	for _, entry := range []struct{ key, tenant string }{{"a", "acme"}, {"b", "acme"}, {"c", "globex"}, {"d", ""}} {
+		cache.Set(entry.key, &CacheEntry{Timestamp: time.Now(), TTL: time.Minute, Tenant: entry.tenant})
+	}
+
+	if !cache.Delete("c") || cache.Delete("c") {
+		t.Error("Expected Delete to report whether the key was cached")
+	}
+	if removed := cache.DeleteTenant("acme"); removed != 2 {
+		t.Errorf("Expected 2 acme entries purged, got %d", removed)
+	}
+	if removed := cache.Clear(); removed != 1 {
+		t.Errorf("Expected 1 entry left to clear, got %d", removed)
+	}
+}
+
+func TestCachingMiddlewareNoCache(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 0)
+	calls := 0
+	handler := CachingMiddleware(cache, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Cache-Control", "max-age=0, no-cache")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if calls != 2 {
+		t.Errorf("Expected no-cache to reach the handler, got %d calls", calls)
+	}
+	if w.Header().Get("X-Cache") != "BYPASS" {
+		t.Errorf("Expected X-Cache: BYPASS, got %q", w.Header().Get("X-Cache"))
+	}
+}
+
+func TestCachingMiddlewareETag(t *testing.T) {
+	handler := CachingMiddleware(NewInMemoryCache(1<<20, 0), false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"healthy"}`))
+	}))
+
+	w1 := httptest.NewRecorder()
+	handler.ServeHTTP(w1, httptest.NewRequest("GET", "/health", nil))
+	etag := w1.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag on the first response")
+	}
+
+	// Both the cached and a freshly generated response honor If-None-Match
+	for _, cacheControl := range []string{"", "no-cache"} {
+		req := httptest.NewRequest("GET", "/health", nil)
+		req.Header.Set("If-None-Match", `"other", `+etag)
+		req.Header.Set("Cache-Control", cacheControl)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("Expected an empty 304 (Cache-Control %q), got %d with %q", cacheControl, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"healthy"}` {
+		t.Errorf("Expected the full response for a stale ETag, got %d with %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCachingMiddlewareStaleWhileRevalidate(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 0)
+	var calls atomic.Int32
+	refreshed := make(chan struct{}, 1)
+	handler := CachingMiddleware(cache, false, types.CacheRule{Path: "/status", TTL: time.Millisecond, StaleWhileRevalidate: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) > 1 {
+				defer func() { refreshed <- struct{}{} }()
+			}
+			_, _ = w.Write([]byte("v" + strconv.Itoa(int(calls.Load()))))
+		}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/status", nil))
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired entry is served at once and refreshed in the background
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Header().Get("X-Cache") != "STALE" || w.Body.String() != "v1" {
+		t.Fatalf("Expected the stale v1, got X-Cache %q with %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a background refresh")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if entry, _ := cache.Get(generateCacheKey(httptest.NewRequest("GET", "/status", nil), nil, types.CacheKeyAuth)); string(entry.Data) == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the refreshed response to be cached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachingMiddlewareBehindCompression(t *testing.T) {
+	body := `{"status":"healthy","checks":{"redis":"ok"}}`
+	handler := ContentEncodingMiddleware(CachingMiddleware(NewInMemoryCache(1<<20, 0), false)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("X-Handler", "health")
+			_, _ = w.Write([]byte(body))
+		})))
+
+	request := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/health", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var etag string
+	for _, want := range []string{"MISS", "HIT"} {
+		w := request("")
+		result := w.Result()
+		if got := result.Header.Get("X-Cache"); got != want {
+			t.Errorf("Expected X-Cache %s to reach the client, got %q", want, got)
+		}
+		if result.Header.Get("X-Handler") != "health" || result.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected the handler's headers and gzip encoding on a %s, got %v", want, result.Header)
+		}
+		if result.Header.Get("Content-Length") != "" {
+			t.Errorf("Expected no uncompressed Content-Length on a %s", want)
+		}
+
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Expected a gzip body on a %s: %v", want, err)
+		}
+		if decompressed, _ := io.ReadAll(reader); string(decompressed) != body {
+			t.Errorf("Expected %s on a %s, got %s", body, want, decompressed)
+		}
+		etag = result.Header.Get("ETag")
+	}
+
+	// A 304 has no body, not even an empty gzip stream
+	w := request(etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty 304, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
+
+func TestCachingMiddlewareExplain(t *testing.T) {
+	cache := NewInMemoryCache(1<<20, 64)
+	handler := CachingMiddleware(cache, true, types.CacheRule{Path: "/big", TTL: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/big" {
+				_, _ = w.Write(make([]byte, 100))
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/health", CacheReasonCached + "; ttl 30s"},
+		{"GET", "/health", CacheReasonCached + "; ttl 30s"},
+		{"GET", "/v1/keys/a", CacheReasonNoRule + "; no cache rule for /v1/keys/a"},
+		{"POST", "/v1/pipeline", CacheReasonMethod + "; POST requests are not cached by path"},
+		{"GET", "/big", CacheReasonSize + "; 100 bytes are more than a tenant may cache"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := w.Header().Get(CacheReasonHeader); got != tt.want {
+			t.Errorf("%s %s: expected %s %q, got %q", tt.method, tt.path, CacheReasonHeader, tt.want, got)
+		}
+	}
+
+	// Without debug, decisions aren't explained
+	w := httptest.NewRecorder()
+	CachingMiddleware(cache, false)(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest("GET", "/v1/keys/a", nil))
+	if got := w.Header().Get(CacheReasonHeader); got != "" {
+		t.Errorf("Expected no %s without debug, got %q", CacheReasonHeader, got)
+	}
+}
@@ -11,17 +11,65 @@ type CompressedResponseWriter struct {
 	http.ResponseWriter
 	writer io.Writer
 	gzipWriter *gzip.Writer
+
+	// noBody is set for statuses that mustn't have a body, such as 304,
+	// where even an empty gzip stream would be one
+	noBody      bool
+	wroteHeader bool
+}
+
+func (crw *CompressedResponseWriter) WriteHeader(statusCode int) {
+	if crw.wroteHeader {
+		return
+	}
+	crw.wroteHeader = true
+	if statusCode == http.StatusNotModified || statusCode == http.StatusNoContent {
+		crw.noBody = true
+	}
+	// The compressed length isn't known up front
+	crw.Header().Del("Content-Length")
+	crw.ResponseWriter.WriteHeader(statusCode)
 }
 
 func (crw *CompressedResponseWriter) Write(b []byte) (int, error) {
+	if !crw.wroteHeader {
+		crw.WriteHeader(http.StatusOK)
+	}
+	if crw.noBody {
+		return 0, http.ErrBodyNotAllowed
+	}
 	return crw.writer.Write(b)
 }
 
+// Flush sends what has been compressed so far, so streams keep streaming.
+func (crw *CompressedResponseWriter) Flush() {
+	if !crw.wroteHeader {
+		crw.WriteHeader(http.StatusOK)
+	}
+	if crw.gzipWriter != nil && !crw.noBody {
+		_ = crw.gzipWriter.Flush()
+	}
+	if flusher, ok := crw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (crw *CompressedResponseWriter) Unwrap() http.ResponseWriter {
+	return crw.ResponseWriter
+}
+
+// Close finishes the gzip stream and returns its writer to the pool.
 func (crw *CompressedResponseWriter) Close() error {
-	if crw.gzipWriter != nil {
-		return crw.gzipWriter.Close()
+	if crw.gzipWriter == nil {
+		return nil
+	}
+	var err error
+	if !crw.noBody {
+		err = crw.gzipWriter.Close()
 	}
-	return nil
+	putGzipWriter(crw.gzipWriter)
+	crw.gzipWriter = nil
+	return err
 }
 
 // CompressionMiddleware adds gzip compression support
@@ -42,9 +90,8 @@ func CompressionMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
-		// Create gzip writer
-		gzipWriter := gzip.NewWriter(w)
-		defer gzipWriter.Close()
+		// Take a gzip writer from the pool
+		gzipWriter := getGzipWriter(w)
 
 		// Set compression headers
 		w.Header().Set("Content-Encoding", "gzip")
@@ -56,6 +103,7 @@ func CompressionMiddleware(next http.Handler) http.Handler {
 			writer:         gzipWriter,
 			gzipWriter:     gzipWriter,
 		}
+		defer crw.Close()
 
 		next.ServeHTTP(crw, r)
 	})
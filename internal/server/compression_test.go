@@ -178,6 +178,28 @@ func TestCompressedResponseWriter(t *testing.T) {
 	}
 }
 
+func TestCompressedResponseWriterFlush(t *testing.T) {
+	handler := CompressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+	}))
+	req := httptest.NewRequest("GET", "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !w.Flushed {
+		t.Error("Expected Flush to reach the underlying writer")
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "data: first\n\n" {
+		t.Errorf("Expected the flushed event, got %q", data)
+	}
+}
+
 func TestCompressionRatio(t *testing.T) {
 	// Create large repetitive data that compresses well
 	largeData := strings.Repeat(`{"key": "value", "number": 12345, "text": "This is repeated data"}`, 100)
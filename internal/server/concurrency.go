@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrOverloaded is returned for requests rejected because the adaptive
+// concurrency limit was reached.
+var ErrOverloaded = errors.New("too many requests in flight for current Redis latency")
+
+// ConcurrencyLimiter caps in-flight requests with a limit that adapts to
+// Redis latency (AIMD): it creeps up by one per sample window while latency
+// stays near the best seen, and is cut multiplicatively once latency
+// exceeds that baseline by the configured tolerance. Queueing inside Redis
+// shows up as latency before it shows up as errors, so shedding load early
+// keeps a spike from overwhelming the backend.
+//
+// With tiers configured, requests over the limit wait in their tier's
+// queue and freed slots go to the waiting tiers in proportion to their
+// weight (smooth weighted round robin), so higher tiers get a larger share
+// of capacity and lower tiers, with shorter queues, are shed first.
+type ConcurrencyLimiter struct {
+	config types.ConcurrencyConfig
+	queues map[string]*tierQueue
+	order  []*tierQueue
+
+	mutex    sync.Mutex
+	limit    float64
+	inFlight int
+	peak     int
+	baseline time.Duration
+	count    int
+	sum      time.Duration
+}
+
+// tierQueue holds the requests of one tier waiting for a slot.
+type tierQueue struct {
+	config  types.TierConfig
+	waiters []chan struct{}
+	credit  int
+}
+
+// NewConcurrencyLimiter creates a limiter starting at the initial limit.
+func NewConcurrencyLimiter(config types.ConcurrencyConfig) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		config: config,
+		queues: make(map[string]*tierQueue),
+		limit:  float64(config.InitialLimit),
+	}
+	for _, tier := range config.Tiers {
+		queue := &tierQueue{config: tier}
+		l.queues[tier.Name] = queue
+		l.order = append(l.order, queue)
+	}
+	return l
+}
+
+// Acquire takes an in-flight slot for a request of the given tier, waiting
+// up to the queue timeout in the tier's queue if none is free. It returns
+// false if the request was shed. Every successful Acquire must be paired
+// with a Release.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, tier string) bool {
+	l.mutex.Lock()
+	if l.inFlight < int(l.limit) && !l.waiting() {
+		l.take()
+		l.mutex.Unlock()
+		return true
+	}
+
+	queue := l.queue(tier)
+	if queue == nil || len(queue.waiters) >= queue.config.MaxQueue {
+		l.mutex.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	queue.waiters = append(queue.waiters, ready)
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i, waiter := range queue.waiters {
+		if waiter == ready {
+			queue.waiters = append(queue.waiters[:i], queue.waiters[i+1:]...)
+			return false
+		}
+	}
+	// Granted a slot while giving up; keep it
+	return true
+}
+
+// Release frees a slot taken by Acquire, handing it to a waiting request
+// if there is one.
+func (l *ConcurrencyLimiter) Release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.inFlight--
+	l.dispatch()
+}
+
+func (l *ConcurrencyLimiter) queue(tier string) *tierQueue {
+	if queue, ok := l.queues[tier]; ok {
+		return queue
+	}
+	return l.queues[l.config.DefaultTier]
+}
+
+func (l *ConcurrencyLimiter) take() {
+	l.inFlight++
+	if l.inFlight > l.peak {
+		l.peak = l.inFlight
+	}
+}
+
+func (l *ConcurrencyLimiter) waiting() bool {
+	for _, queue := range l.order {
+		if len(queue.waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch grants free slots to waiting requests. Each round every waiting
+// tier earns its weight in credit and the richest is served and pays the
+// total, which interleaves tiers in proportion to their weights.
+func (l *ConcurrencyLimiter) dispatch() {
+	for l.inFlight < int(l.limit) {
+		var next *tierQueue
+		total := 0
+		for _, queue := range l.order {
+			if len(queue.waiters) == 0 {
+				continue
+			}
+			queue.credit += queue.config.Weight
+			total += queue.config.Weight
+			if next == nil || queue.credit > next.credit {
+				next = queue
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		next.credit -= total
+		ready := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		l.take()
+		close(ready)
+	}
+}
+
+// Observe records the latency of a Redis round trip and adjusts the limit
+// once a full sample window has been seen.
+func (l *ConcurrencyLimiter) Observe(latency time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.count++
+	l.sum += latency
+	if l.count < l.config.SampleWindow {
+		return
+	}
+
+	average := l.sum / time.Duration(l.count)
+	l.count, l.sum = 0, 0
+
+	// The baseline follows improvements at once but drifts up slowly, so a
+	// lasting change in the workload becomes the new normal
+	if l.baseline == 0 || average < l.baseline {
+		l.baseline = average
+	} else {
+		l.baseline += (average - l.baseline) / 20
+	}
+
+	switch {
+	case float64(average) > float64(l.baseline)*l.config.Tolerance:
+		l.limit *= l.config.Backoff
+	case l.peak*2 >= int(l.limit):
+		// Only grow a limit that is actually being used
+		l.limit++
+	}
+	l.peak = l.inFlight
+
+	if l.limit < float64(l.config.MinLimit) {
+		l.limit = float64(l.config.MinLimit)
+	}
+	if l.limit > float64(l.config.MaxLimit) {
+		l.limit = float64(l.config.MaxLimit)
+	}
+	l.dispatch()
+}
+
+// Snapshot returns the current limit and number of requests in flight.
+func (l *ConcurrencyLimiter) Snapshot() (limit, inFlight int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return int(l.limit), l.inFlight
+}
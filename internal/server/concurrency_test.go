@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func testConcurrencyConfig() types.ConcurrencyConfig {
+	return types.ConcurrencyConfig{
+		Enabled:      true,
+		InitialLimit: 4,
+		MinLimit:     2,
+		MaxLimit:     8,
+		SampleWindow: 2,
+		Tolerance:    2,
+		Backoff:      0.5,
+	}
+}
+
+func TestConcurrencyLimiterAcquire(t *testing.T) {
+	limiter := NewConcurrencyLimiter(testConcurrencyConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if !limiter.Acquire(ctx, "") {
+			t.Fatalf("Expected slot %d to be free", i)
+		}
+	}
+	if limiter.Acquire(ctx, "") {
+		t.Error("Expected acquire beyond the limit to fail")
+	}
+
+	limiter.Release()
+	if !limiter.Acquire(ctx, "") {
+		t.Error("Expected a released slot to be reusable")
+	}
+	if limit, inFlight := limiter.Snapshot(); limit != 4 || inFlight != 4 {
+		t.Errorf("Expected limit 4 with 4 in flight, got %d/%d", limit, inFlight)
+	}
+}
+
+func TestConcurrencyLimiterAdapts(t *testing.T) {
+	limiter := NewConcurrencyLimiter(testConcurrencyConfig())
+	ctx := context.Background()
+
+	// Establish a baseline while busy, so the limit grows
+	for i := 0; i < 2; i++ {
+		limiter.Acquire(ctx, "")
+	}
+	limiter.Observe(time.Millisecond)
+	limiter.Observe(time.Millisecond)
+	if limit, _ := limiter.Snapshot(); limit != 5 {
+		t.Errorf("Expected additive increase to 5, got %d", limit)
+	}
+
+	// Latency well above the baseline halves the limit
+	limiter.Observe(10 * time.Millisecond)
+	limiter.Observe(10 * time.Millisecond)
+	if limit, _ := limiter.Snapshot(); limit != 2 {
+		t.Errorf("Expected multiplicative decrease to 2, got %d", limit)
+	}
+
+	// Never below the minimum
+	limiter.Observe(50 * time.Millisecond)
+	limiter.Observe(50 * time.Millisecond)
+	if limit, _ := limiter.Snapshot(); limit != 2 {
+		t.Errorf("Expected limit to stay at the minimum of 2, got %d", limit)
+	}
+}
+
+func TestConcurrencyLimiterTiers(t *testing.T) {
+	config := testConcurrencyConfig()
+	config.InitialLimit = 2
+	config.QueueTimeout = time.Second
+	config.Tiers = []types.TierConfig{
+		{Name: "free", Weight: 1, MaxQueue: 1},
+		{Name: "pro", Weight: 3, MaxQueue: 10},
+	}
+	config.DefaultTier = "free"
+	limiter := NewConcurrencyLimiter(config)
+	ctx := context.Background()
+
+	limiter.Acquire(ctx, "pro")
+	limiter.Acquire(ctx, "pro")
+
+	// Queue one free and four pro requests, recording the order slots go
+	// to them in
+	granted := make(chan string, 5)
+
+	var started int
+	enqueue := func(tier string) {
+		started++
+		go func() {
+			if limiter.Acquire(ctx, tier) {
+				granted <- tier
+			}
+		}()
+		waitQueued(t, limiter, started)
+	}
+	enqueue("free")
+	for i := 0; i < 4; i++ {
+		enqueue("pro")
+	}
+
+	// The free queue is full, so another free request is shed at once
+	if limiter.Acquire(ctx, "unknown") {
+		t.Fatal("Expected a request over a full default-tier queue to be shed")
+	}
+
+	var order []string
+	for i := 0; i < 5; i++ {
+		limiter.Release()
+		order = append(order, <-granted)
+	}
+
+	// With weights 3:1 the first four slots go three to pro, one to free
+	expected := []string{"pro", "free", "pro", "pro", "pro"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected grant order %v, got %v", expected, order)
+		}
+	}
+}
+
+// waitQueued waits until n requests are queued in the limiter.
+func waitQueued(t *testing.T, limiter *ConcurrencyLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		limiter.mutex.Lock()
+		queued := 0
+		for _, queue := range limiter.order {
+			queued += len(queue.waiters)
+		}
+		limiter.mutex.Unlock()
+		if queued >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d queued requests", n)
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrTaskNotFound is returned for delayed tasks that don't exist, or were
+// delivered or cancelled already.
+var ErrTaskNotFound = errors.New("delayed task not found")
+
+// ErrWebhookHost is returned for webhooks to hosts not in
+// delay.webhook_hosts.
+var ErrWebhookHost = errors.New("webhook host not allowed")
+
+// Delivery outcomes passed to DelayObserver
+const (
+	DelayDelivered = "delivered"
+	DelayRetried   = "retried"
+	DelayDropped   = "dropped"
+)
+
+// DelayObserver is told the outcome of every delivery attempt.
+type DelayObserver func(target, outcome string)
+
+// DelayQueue holds payloads until they are due and delivers them to a
+// list, a stream or a webhook. Task IDs wait in a sorted set scored by
+// due time, with the tasks themselves in a hash. Any number of proxy
+// instances may poll the queue: each claims a due task by removing it from
+// the sorted set, so only one delivers it. Lists and streams get a task at
+// most once; webhooks that fail are retried with backoff.
+type DelayQueue struct {
+	executor CommandExecutor
+	config   types.DelayConfig
+	client   *http.Client
+	observer DelayObserver
+	now      func() time.Time
+}
+
+// NewDelayQueue creates a queue backed by executor.
+func NewDelayQueue(executor CommandExecutor, config types.DelayConfig) *DelayQueue {
+	q := &DelayQueue{
+		executor: executor,
+		config:   config,
+		now:      time.Now,
+	}
+	q.client = &http.Client{Timeout: config.WebhookTimeout, CheckRedirect: q.checkRedirect}
+	return q
+}
+
+// checkRedirect follows webhook redirects only to hosts webhooks may be
+// sent to, so a redirect can't reach a host that isn't allowed.
+func (q *DelayQueue) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return q.CheckWebhook(req.URL.String())
+}
+
+// SetObserver registers fn to be called for every delivery attempt.
+func (q *DelayQueue) SetObserver(fn DelayObserver) {
+	q.observer = fn
+}
+
+// CheckWebhook checks that rawURL is on a host webhooks may be sent to.
+func (q *DelayQueue) CheckWebhook(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	for _, host := range q.config.WebhookHosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrWebhookHost, u.Hostname())
+}
+
+// Enqueue stores task, giving it an ID, for delivery at task.DeliverAt.
+func (q *DelayQueue) Enqueue(ctx context.Context, task *types.DelayedTask) error {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	task.ID = hex.EncodeToString(b)
+	task.EnqueuedAt = q.now()
+
+	// The task is stored before it is queued, so pollers never find an ID
+	// without its task
+	if err := q.store(ctx, task); err != nil {
+		return err
+	}
+	return q.schedule(ctx, task)
+}
+
+// Get returns the task with ID id while it waits for delivery.
+func (q *DelayQueue) Get(ctx context.Context, id string) (*types.DelayedTask, error) {
+	// HMGET replies nil for a missing task rather than failing
+	result, err := q.exec(ctx, "HMGET", q.tasksKey(), id)
+	if err != nil {
+		return nil, err
+	}
+	replies, _ := result.([]interface{})
+	if len(replies) != 1 || replies[0] == nil {
+		return nil, ErrTaskNotFound
+	}
+	encoded, ok := replies[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected HMGET reply %T", replies[0])
+	}
+
+	var task types.DelayedTask
+	if err := json.Unmarshal([]byte(encoded), &task); err != nil {
+		return nil, fmt.Errorf("corrupt delayed task %s: %w", id, err)
+	}
+	return &task, nil
+}
+
+// Cancel removes the task with ID id before it is delivered.
+func (q *DelayQueue) Cancel(ctx context.Context, id string) error {
+	claimed, err := q.claim(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrTaskNotFound
+	}
+	_, err = q.exec(ctx, "HDEL", q.tasksKey(), id)
+	return err
+}
+
+// Poll delivers up to delay.batch_size due tasks, returning how many were
+// delivered.
+func (q *DelayQueue) Poll(ctx context.Context) (int, error) {
+	result, err := q.exec(ctx, "ZRANGEBYSCORE", q.queueKey(), "-inf", q.now().UnixMilli(), "LIMIT", 0, q.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	ids, _ := result.([]interface{})
+
+	delivered := 0
+	for _, id := range ids {
+		id, _ := id.(string)
+		claimed, err := q.claim(ctx, id)
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed {
+			continue // another instance got it first
+		}
+
+		task, err := q.Get(ctx, id)
+		if errors.Is(err, ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return delivered, err
+		}
+
+		if q.deliver(ctx, task) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// deliver delivers a claimed task, scheduling a retry if a webhook fails,
+// and reports whether it was delivered.
+func (q *DelayQueue) deliver(ctx context.Context, task *types.DelayedTask) bool {
+	err := q.send(ctx, task)
+	task.Attempts++
+
+	outcome := DelayDelivered
+	if err != nil {
+		outcome = DelayDropped
+		if task.Target == types.DelayTargetWebhook && task.Attempts < q.config.MaxAttempts {
+			task.DeliverAt = q.now().Add(time.Duration(1<<task.Attempts) * time.Second)
+			if err = q.store(ctx, task); err == nil {
+				err = q.schedule(ctx, task)
+			}
+			if err == nil {
+				outcome = DelayRetried
+			}
+		}
+	}
+	if outcome == DelayDropped {
+		log.Printf("Dropped delayed task %s of tenant %s after %d attempts: %v", task.ID, task.TenantID, task.Attempts, err)
+	}
+	if outcome != DelayRetried {
+		_, _ = q.exec(ctx, "HDEL", q.tasksKey(), task.ID)
+	}
+
+	if q.observer != nil {
+		q.observer(task.Target, outcome)
+	}
+	return outcome == DelayDelivered
+}
+
+// send delivers task to its target once.
+func (q *DelayQueue) send(ctx context.Context, task *types.DelayedTask) error {
+	switch task.Target {
+	case types.DelayTargetList:
+		_, err := q.executor.ExecuteCommand(ctx, types.CommandRequest{
+			Command: "RPUSH", Args: []interface{}{task.Key, payloadText(task.Payload)}, DB: task.DB,
+		})
+		return err
+	case types.DelayTargetStream:
+		_, err := q.executor.ExecuteCommand(ctx, types.CommandRequest{
+			Command: "XADD", Args: []interface{}{task.Key, "*", "id", task.ID, "payload", payloadText(task.Payload)}, DB: task.DB,
+		})
+		return err
+	case types.DelayTargetWebhook:
+		return q.post(ctx, task)
+	}
+	return fmt.Errorf("unknown delay target %q", task.Target)
+}
+
+// post sends task to its webhook as JSON, expecting a 2xx reply.
+func (q *DelayQueue) post(ctx context.Context, task *types.DelayedTask) error {
+	if err := q.CheckWebhook(task.URL); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, task.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-SR-Delay-ID", task.ID)
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook replied %s", resp.Status)
+	}
+	return nil
+}
+
+// payloadText is what lists and streams receive: JSON strings unquoted,
+// anything else as JSON text.
+func payloadText(payload json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(payload, &text); err == nil {
+		return text
+	}
+	return string(payload)
+}
+
+func (q *DelayQueue) store(ctx context.Context, task *types.DelayedTask) error {
+	encoded, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = q.exec(ctx, "HSET", q.tasksKey(), task.ID, string(encoded))
+	return err
+}
+
+func (q *DelayQueue) schedule(ctx context.Context, task *types.DelayedTask) error {
+	_, err := q.exec(ctx, "ZADD", q.queueKey(), task.DeliverAt.UnixMilli(), task.ID)
+	return err
+}
+
+// claim removes id from the queue, reporting whether this call did.
+func (q *DelayQueue) claim(ctx context.Context, id string) (bool, error) {
+	result, err := q.exec(ctx, "ZREM", q.queueKey(), id)
+	if err != nil {
+		return false, err
+	}
+	removed, _ := result.(int64)
+	return removed == 1, nil
+}
+
+func (q *DelayQueue) exec(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	return q.executor.ExecuteCommand(ctx, types.CommandRequest{Command: command, Args: args, DB: q.config.DB})
+}
+
+func (q *DelayQueue) queueKey() string {
+	return q.config.KeyPrefix + "queue"
+}
+
+func (q *DelayQueue) tasksKey() string {
+	return q.config.KeyPrefix + "tasks"
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeDelayRedis implements the commands DelayQueue sends.
+type fakeDelayRedis struct {
+	mutex  sync.Mutex
+	hashes map[string]map[string]string
+	zsets  map[string]map[string]int64
+	lists  map[string][]string
+}
+
+func newFakeDelayRedis() *fakeDelayRedis {
+	return &fakeDelayRedis{
+		hashes: make(map[string]map[string]string),
+		zsets:  make(map[string]map[string]int64),
+		lists:  make(map[string][]string),
+	}
+}
+
+func (f *fakeDelayRedis) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	arg := func(i int) string {
+		s, _ := req.Args[i].(string)
+		return s
+	}
+	switch req.Command {
+	case "HSET":
+		if f.hashes[arg(0)] == nil {
+			f.hashes[arg(0)] = make(map[string]string)
+		}
+		f.hashes[arg(0)][arg(1)] = arg(2)
+		return int64(1), nil
+	case "HMGET":
+		if value, ok := f.hashes[arg(0)][arg(1)]; ok {
+			return []interface{}{value}, nil
+		}
+		return []interface{}{nil}, nil
+	case "HDEL":
+		delete(f.hashes[arg(0)], arg(1))
+		return int64(1), nil
+	case "ZADD":
+		if f.zsets[arg(0)] == nil {
+			f.zsets[arg(0)] = make(map[string]int64)
+		}
+		f.zsets[arg(0)][arg(2)] = req.Args[1].(int64)
+		return int64(1), nil
+	case "ZRANGEBYSCORE":
+		max := req.Args[2].(int64)
+		var ids []string
+		for id, score := range f.zsets[arg(0)] {
+			if score <= max {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		reply := make([]interface{}, len(ids))
+		for i, id := range ids {
+			reply[i] = id
+		}
+		return reply, nil
+	case "ZREM":
+		if _, ok := f.zsets[arg(0)][arg(1)]; !ok {
+			return int64(0), nil
+		}
+		delete(f.zsets[arg(0)], arg(1))
+		return int64(1), nil
+	case "RPUSH":
+		f.lists[arg(0)] = append(f.lists[arg(0)], arg(1))
+		return int64(len(f.lists[arg(0)])), nil
+	}
+	return nil, nil
+}
+
+func TestDelayQueueDeliversToLists(t *testing.T) {
+	fake := newFakeDelayRedis()
+	queue := NewDelayQueue(fake, types.DelayConfig{KeyPrefix: "d:", BatchSize: 10, MaxAttempts: 3})
+	now := time.Unix(1700000000, 0)
+	queue.now = func() time.Time { return now }
+
+	var outcomes []string
+	queue.SetObserver(func(target, outcome string) { outcomes = append(outcomes, target+":"+outcome) })
+
+	ctx := context.Background()
+	for _, payload := range []string{`"first"`, `{"n": 2}`} {
+		task := &types.DelayedTask{Target: types.DelayTargetList, Key: "jobs", Payload: json.RawMessage(payload), DeliverAt: now.Add(time.Minute)}
+		if err := queue.Enqueue(ctx, task); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+	cancelled := &types.DelayedTask{Target: types.DelayTargetList, Key: "jobs", Payload: json.RawMessage(`"never"`), DeliverAt: now.Add(time.Minute)}
+	if err := queue.Enqueue(ctx, cancelled); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if task, err := queue.Get(ctx, cancelled.ID); err != nil || task.Key != "jobs" {
+		t.Fatalf("Expected the queued task, got %v, %v", task, err)
+	}
+	if err := queue.Cancel(ctx, cancelled.ID); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	if err := queue.Cancel(ctx, cancelled.ID); err != ErrTaskNotFound {
+		t.Errorf("Expected a second cancel to find nothing, got %v", err)
+	}
+
+	if delivered, err := queue.Poll(ctx); err != nil || delivered != 0 {
+		t.Fatalf("Expected nothing due yet, got %d, %v", delivered, err)
+	}
+
+	now = now.Add(time.Minute)
+	if delivered, err := queue.Poll(ctx); err != nil || delivered != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d, %v", delivered, err)
+	}
+	got := append([]string(nil), fake.lists["jobs"]...)
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "first" || got[1] != `{"n":2}` {
+		t.Errorf("Unexpected list contents %q", got)
+	}
+	if len(fake.hashes["d:tasks"]) != 0 {
+		t.Errorf("Expected delivered tasks to be deleted, got %d", len(fake.hashes["d:tasks"]))
+	}
+	if len(outcomes) != 2 || outcomes[0] != "list:delivered" {
+		t.Errorf("Unexpected outcomes %v", outcomes)
+	}
+}
+
+func TestDelayQueueRetriesWebhooks(t *testing.T) {
+	var calls int
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var task types.DelayedTask
+		if err := json.NewDecoder(r.Body).Decode(&task); err != nil || r.Header.Get("X-SR-Delay-ID") != task.ID {
+			t.Errorf("Unexpected webhook body %+v, %v", task, err)
+		}
+	}))
+	defer hook.Close()
+	hookURL, _ := url.Parse(hook.URL)
+
+	fake := newFakeDelayRedis()
+	queue := NewDelayQueue(fake, types.DelayConfig{KeyPrefix: "d:", BatchSize: 10, MaxAttempts: 3, WebhookHosts: []string{hookURL.Hostname()}})
+	now := time.Unix(1700000000, 0)
+	queue.now = func() time.Time { return now }
+
+	if err := queue.CheckWebhook("https://elsewhere.example.com/hook"); err == nil {
+		t.Error("Expected a host not listed to be refused")
+	}
+
+	ctx := context.Background()
+	task := &types.DelayedTask{Target: types.DelayTargetWebhook, URL: hook.URL, Payload: json.RawMessage(`{}`), DeliverAt: now}
+	if err := queue.Enqueue(ctx, task); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	if delivered, _ := queue.Poll(ctx); delivered != 0 {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	retried, err := queue.Get(ctx, task.ID)
+	if err != nil || retried.Attempts != 1 || !retried.DeliverAt.After(now) {
+		t.Fatalf("Expected the task to be rescheduled, got %+v, %v", retried, err)
+	}
+
+	now = retried.DeliverAt
+	if delivered, _ := queue.Poll(ctx); delivered != 1 || calls != 2 {
+		t.Errorf("Expected the retry to be delivered, got %d after %d calls", delivered, calls)
+	}
+}
+
+func TestDelayQueueWebhookRedirects(t *testing.T) {
+	var reached bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer target.Close()
+	targetURL, _ := url.Parse(target.URL)
+
+	// The allowed host redirects to the same server under a name that
+	// isn't allowed
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:"+targetURL.Port()+"/hook", http.StatusTemporaryRedirect)
+	}))
+	defer hook.Close()
+	hookURL, _ := url.Parse(hook.URL)
+
+	queue := NewDelayQueue(newFakeDelayRedis(), types.DelayConfig{KeyPrefix: "d:", BatchSize: 10, MaxAttempts: 1, WebhookHosts: []string{hookURL.Hostname()}})
+	task := &types.DelayedTask{ID: "t1", Target: types.DelayTargetWebhook, URL: hook.URL, Payload: json.RawMessage(`{}`)}
+	if err := queue.post(context.Background(), task); !errors.Is(err, ErrWebhookHost) {
+		t.Errorf("Expected the redirect to be refused, got %v", err)
+	}
+	if reached {
+		t.Error("Expected the redirect not to be followed")
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// DeprecationMiddleware marks responses to requests matching a deprecation
+// rule with a Deprecation header (RFC 9745), the earliest Sunset (RFC 8594)
+// and a Link to each rule's documentation, and passes each matching rule
+// to record. /v2 responses also get a deprecated_api warning.
+func DeprecationMiddleware(rules []types.DeprecationRule, record func(r *http.Request, rule types.DeprecationRule)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var matched []types.DeprecationRule
+			var fields map[string]json.RawMessage
+			bodyRead := false
+			for _, rule := range rules {
+				if !matchDeprecation(rule, r) {
+					continue
+				}
+				if rule.Option != "" && !r.URL.Query().Has(rule.Option) {
+					if !bodyRead {
+						fields = peekJSONFields(r)
+						bodyRead = true
+					}
+					if _, ok := fields[rule.Option]; !ok {
+						continue
+					}
+				}
+				matched = append(matched, rule)
+			}
+			if len(matched) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			since, sunset := matched[0].Since, matched[0].Sunset
+			for _, rule := range matched {
+				if rule.Since.Before(since) {
+					since = rule.Since
+				}
+				if !rule.Sunset.IsZero() && (sunset.IsZero() || rule.Sunset.Before(sunset)) {
+					sunset = rule.Sunset
+				}
+				if rule.Link != "" {
+					w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, rule.Link))
+				}
+
+				record(r, rule)
+				AddWarning(r.Context(), types.WarningDeprecatedAPI, "%s", deprecationMessage(rule))
+			}
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchDeprecation reports whether r is to rule's path and method.
+func matchDeprecation(rule types.DeprecationRule, r *http.Request) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+
+	patterns := strings.Split(rule.Path, "/")
+	segments := strings.Split(r.URL.Path, "/")
+	if len(patterns) != len(segments) {
+		return false
+	}
+	for i, pattern := range patterns {
+		variable := strings.HasPrefix(pattern, "{") && strings.HasSuffix(pattern, "}")
+		if !variable && pattern != segments[i] || variable && segments[i] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// maxPeekBytes bounds the request bodies searched for deprecated options.
+const maxPeekBytes = 1 << 20
+
+// peekJSONFields returns the top-level fields of a JSON object request
+// body, leaving the body to be read again. Other bodies, such as raw
+// values, and larger bodies have no fields.
+func peekJSONFields(r *http.Request) map[string]json.RawMessage {
+	if r.Body == nil {
+		return nil
+	}
+	reader := bufio.NewReader(r.Body)
+	r.Body = readCloser{Reader: reader, Closer: r.Body}
+	if start, err := reader.Peek(1); err != nil || start[0] != '{' {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxPeekBytes+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), reader), Closer: r.Body}
+	if err != nil || len(body) > maxPeekBytes {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(body, &fields)
+	return fields
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func deprecationMessage(rule types.DeprecationRule) string {
+	message := rule.Path + " is deprecated"
+	if rule.Option != "" {
+		message = fmt.Sprintf("option %s of %s is deprecated", rule.Option, rule.Path)
+	}
+	if !rule.Sunset.IsZero() {
+		message += " and will be removed on " + rule.Sunset.UTC().Format("2006-01-02")
+	}
+	if rule.Link != "" {
+		message += "; see " + rule.Link
+	}
+	return message
+}
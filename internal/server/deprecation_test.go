@@ -0,0 +1,95 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestMatchDeprecation(t *testing.T) {
+	tests := []struct {
+		rule     types.DeprecationRule
+		method   string
+		path     string
+		expected bool
+	}{
+		{types.DeprecationRule{Path: "/v1/mget"}, "POST", "/v1/mget", true},
+		{types.DeprecationRule{Path: "/v1/mget"}, "POST", "/v1/mset", false},
+		{types.DeprecationRule{Path: "/v1/keys/{key}"}, "GET", "/v1/keys/user", true},
+		{types.DeprecationRule{Path: "/v1/keys/{key}"}, "GET", "/v1/keys/user/get-or-set", false},
+		{types.DeprecationRule{Path: "/v1/keys/{key}", Method: "delete"}, "DELETE", "/v1/keys/user", true},
+		{types.DeprecationRule{Path: "/v1/keys/{key}", Method: "DELETE"}, "GET", "/v1/keys/user", false},
+		{types.DeprecationRule{Path: "/v1/*"}, "POST", "/v1/command", true},
+		{types.DeprecationRule{Path: "/v1/*"}, "POST", "/v2/command", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := matchDeprecation(tt.rule, r); got != tt.expected {
+			t.Errorf("matchDeprecation(%+v, %s %s) = %v, want %v", tt.rule, tt.method, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestDeprecationMiddleware(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	rules := []types.DeprecationRule{
+		{Path: "/v1/mget", Since: since, Sunset: sunset, Link: "https://example.com/mget"},
+		{Path: "/v1/command", Option: "database", Since: since},
+	}
+
+	var recorded []string
+	var body string
+	handler := DeprecationMiddleware(rules, func(r *http.Request, rule types.DeprecationRule) {
+		recorded = append(recorded, rule.Path+"|"+rule.Option)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/mget", nil))
+	if got := w.Header().Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("Expected Deprecation @1767225600, got %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Unexpected Sunset %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `<https://example.com/mget>; rel="deprecation"` {
+		t.Errorf("Unexpected Link %q", got)
+	}
+
+	// Options match in the JSON body or the query, and the body is left intact
+	command := `{"command":"GET","args":["k"],"database":"cache"}`
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/command", strings.NewReader(command)))
+	if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") != "" {
+		t.Errorf("Expected only Deprecation for the body option, got %v", w.Header())
+	}
+	if body != command {
+		t.Errorf("Expected the body to be readable again, got %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/command?database=cache", strings.NewReader("GET k")))
+	if w.Header().Get("Deprecation") == "" || body != "GET k" {
+		t.Errorf("Expected the query option to match, got %v and body %q", w.Header(), body)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/command", strings.NewReader(`{"command":"GET"}`)))
+	if w.Header().Get("Deprecation") != "" {
+		t.Error("Expected no Deprecation without the option")
+	}
+
+	expected := []string{"/v1/mget|", "/v1/command|database", "/v1/command|database"}
+	if strings.Join(recorded, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v recorded, got %v", expected, recorded)
+	}
+}
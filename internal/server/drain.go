@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Drainer tracks in-flight requests by generation, so that after a switch
+// such as entering maintenance the caller can wait for the requests
+// admitted before it, while new ones keep flowing.
+type Drainer struct {
+	mutex   sync.Mutex
+	current *sync.WaitGroup
+}
+
+// NewDrainer creates a drainer with no requests in flight.
+func NewDrainer() *Drainer {
+	return &Drainer{current: &sync.WaitGroup{}}
+}
+
+// Middleware counts requests in the generation they started in.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.mutex.Lock()
+		generation := d.current
+		generation.Add(1)
+		d.mutex.Unlock()
+		defer generation.Done()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Drain starts a new generation and waits for the requests of the previous
+// one to finish, returning ctx's error if they don't before it is done.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mutex.Lock()
+	previous := d.current
+	d.current = &sync.WaitGroup{}
+	d.mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		previous.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
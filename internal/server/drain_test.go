@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainerWaitsForPreviousGeneration(t *testing.T) {
+	drainer := NewDrainer()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := drainer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/command", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := drainer.Drain(ctx); err == nil {
+		t.Fatal("Expected drain to time out while a request is in flight")
+	}
+
+	// The next drain waits for the requests started since the last one
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/command", nil))
+	<-started
+	close(release)
+
+	if err := drainer.Drain(context.Background()); err != nil {
+		t.Errorf("Expected drain to finish, got %v", err)
+	}
+}
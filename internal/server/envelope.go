@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// maxRequestIDLength bounds the X-Request-ID a client may choose.
+const maxRequestIDLength = 128
+
+type envelopeContextKey struct{}
+
+// requestMeta collects what a /v2 response reports about its request as
+// the request is served.
+type requestMeta struct {
+	mutex     sync.Mutex
+	start     time.Time
+	queue     time.Duration
+	redis     time.Duration
+	redisDone time.Time
+	warnings  []types.Warning
+	cursor    string
+}
+
+func metaFromContext(ctx context.Context) *requestMeta {
+	meta, _ := ctx.Value(envelopeContextKey{}).(*requestMeta)
+	return meta
+}
+
+// AddQueueTime records time the request spent waiting for a concurrency
+// slot.
+func AddQueueTime(ctx context.Context, d time.Duration) {
+	if meta := metaFromContext(ctx); meta != nil {
+		meta.mutex.Lock()
+		meta.queue += d
+		meta.mutex.Unlock()
+	}
+}
+
+// AddRedisTime records a Redis round trip of the request that just ended.
+func AddRedisTime(ctx context.Context, d time.Duration) {
+	if meta := metaFromContext(ctx); meta != nil {
+		meta.mutex.Lock()
+		meta.redis += d
+		meta.redisDone = time.Now()
+		meta.mutex.Unlock()
+	}
+}
+
+// AddWarning adds a warning to the request's response. A warning already
+// given is not repeated.
+func AddWarning(ctx context.Context, code, format string, args ...interface{}) {
+	meta := metaFromContext(ctx)
+	if meta == nil {
+		return
+	}
+
+	warning := types.Warning{Code: code, Message: fmt.Sprintf(format, args...)}
+	meta.mutex.Lock()
+	defer meta.mutex.Unlock()
+	for _, given := range meta.warnings {
+		if given == warning {
+			return
+		}
+	}
+	meta.warnings = append(meta.warnings, warning)
+}
+
+// SetCursor records the cursor that continues a paginated response.
+func SetCursor(ctx context.Context, cursor string) {
+	if meta := metaFromContext(ctx); meta != nil {
+		meta.mutex.Lock()
+		meta.cursor = cursor
+		meta.mutex.Unlock()
+	}
+}
+
+// EnvelopeMiddleware wraps JSON responses in a types.Envelope, with the
+// request ID, timing, warnings and cursor collected while serving them.
+// The request ID is the client's X-Request-ID, or a new one, and is echoed
+// in the header. Responses that aren't JSON, such as streams, CSV and raw
+// values, are passed through unwrapped.
+func EnvelopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := &requestMeta{start: time.Now()}
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			requestID = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ew := &envelopeWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(ew, r.WithContext(context.WithValue(r.Context(), envelopeContextKey{}, meta)))
+		if ew.passthrough {
+			return
+		}
+
+		body := bytes.TrimSpace(ew.body.Bytes())
+		if len(body) == 0 {
+			w.WriteHeader(ew.status)
+			return
+		}
+
+		envelope := types.Envelope{Meta: meta.snapshot(requestID)}
+		if ew.status >= 400 {
+			envelope.Error = body
+		} else {
+			envelope.Data = body
+		}
+		encoded, err := json.Marshal(envelope)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		timing := envelope.Meta.Timing
+		w.Header().Set("Server-Timing", fmt.Sprintf("queue;dur=%.3f, redis;dur=%.3f, serialize;dur=%.3f, total;dur=%.3f",
+			timing.QueueMs, timing.RedisMs, timing.SerializeMs, timing.TotalMs))
+		w.Header().Set("Content-Length", strconv.Itoa(len(encoded)+1))
+		w.WriteHeader(ew.status)
+		_, _ = w.Write(append(encoded, '\n'))
+	})
+}
+
+// snapshot returns the response meta as of now. Serialization is timed
+// from the last Redis reply, or from now for requests without one.
+func (m *requestMeta) snapshot(requestID string) types.ResponseMeta {
+	now := time.Now()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	serialize := time.Duration(0)
+	if !m.redisDone.IsZero() {
+		serialize = now.Sub(m.redisDone)
+	}
+	warnings := append([]types.Warning{}, m.warnings...)
+	return types.ResponseMeta{
+		RequestID: requestID,
+		Timing: types.ServerTiming{
+			TotalMs:     milliseconds(now.Sub(m.start)),
+			QueueMs:     milliseconds(m.queue),
+			RedisMs:     milliseconds(m.redis),
+			SerializeMs: milliseconds(serialize),
+		},
+		Warnings: warnings,
+		Cursor:   m.cursor,
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// envelopeWriter holds back a JSON response for EnvelopeMiddleware to
+// wrap, and passes anything else straight through.
+type envelopeWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func (ew *envelopeWriter) WriteHeader(code int) {
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.status = code
+
+	if !strings.HasPrefix(ew.Header().Get("Content-Type"), "application/json") {
+		ew.passthrough = true
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	ew.Header().Del("Content-Length")
+}
+
+func (ew *envelopeWriter) Write(data []byte) (int, error) {
+	if !ew.wroteHeader {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(data)
+	}
+	return ew.body.Write(data)
+}
+
+// Flush flushes responses passed through, so streams keep streaming.
+func (ew *envelopeWriter) Flush() {
+	if flusher, ok := ew.ResponseWriter.(http.Flusher); ok && ew.passthrough {
+		flusher.Flush()
+	}
+}
+
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
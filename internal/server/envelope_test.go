@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestEnvelopeMiddleware(t *testing.T) {
+	handler := EnvelopeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddQueueTime(r.Context(), 2*time.Millisecond)
+		AddRedisTime(r.Context(), 3*time.Millisecond)
+		AddWarning(r.Context(), types.WarningDeprecatedCommand, "%s is deprecated", "GETSET")
+		AddWarning(r.Context(), types.WarningDeprecatedCommand, "%s is deprecated", "GETSET")
+		SetCursor(r.Context(), "next")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"result":"OK"}` + "\n"))
+	}))
+
+	req := httptest.NewRequest("POST", "/v2/command", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Request-ID"); got != "req-1" {
+		t.Errorf("Expected the request ID to be echoed, got %q", got)
+	}
+	if !strings.HasPrefix(w.Header().Get("Server-Timing"), "queue;dur=2.000, redis;dur=3.000") {
+		t.Errorf("Unexpected Server-Timing %q", w.Header().Get("Server-Timing"))
+	}
+
+	var envelope types.Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Invalid envelope %q: %v", w.Body.String(), err)
+	}
+	if string(envelope.Data) != `{"result":"OK"}` || string(envelope.Error) != "null" {
+		t.Errorf("Expected the response as data, got data %s, error %s", envelope.Data, envelope.Error)
+	}
+	meta := envelope.Meta
+	if meta.RequestID != "req-1" || meta.Cursor != "next" {
+		t.Errorf("Unexpected meta %+v", meta)
+	}
+	if meta.Timing.QueueMs != 2 || meta.Timing.RedisMs != 3 || meta.Timing.TotalMs < meta.Timing.SerializeMs {
+		t.Errorf("Unexpected timing %+v", meta.Timing)
+	}
+	if len(meta.Warnings) != 1 || meta.Warnings[0].Message != "GETSET is deprecated" {
+		t.Errorf("Expected one deprecation warning, got %+v", meta.Warnings)
+	}
+}
+
+func TestEnvelopeMiddlewareError(t *testing.T) {
+	handler := EnvelopeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"Invalid request"}`))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v2/command", nil))
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid envelope %q: %v", w.Body.String(), err)
+	}
+	if string(body["data"]) != "null" || string(body["error"]) != `{"error":"Invalid request"}` {
+		t.Errorf("Expected the response as error, got %s", w.Body.String())
+	}
+	if !strings.Contains(string(body["meta"]), `"warnings":[]`) {
+		t.Errorf("Expected an empty warnings array, got %s", body["meta"])
+	}
+	if len(w.Header().Get("X-Request-ID")) != 32 {
+		t.Errorf("Expected a generated request ID, got %q", w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestEnvelopeMiddlewarePassthrough(t *testing.T) {
+	handler := EnvelopeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write([]byte("a,b\n"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v2/keys/k", nil))
+	if w.Body.String() != "a,b\n" {
+		t.Errorf("Expected non-JSON responses unwrapped, got %q", w.Body.String())
+	}
+}
+
+func TestEnvelopeHelpersWithoutMeta(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	AddQueueTime(req.Context(), time.Millisecond)
+	AddRedisTime(req.Context(), time.Millisecond)
+	AddWarning(req.Context(), types.WarningLargeValue, "large")
+	SetCursor(req.Context(), "next")
+}
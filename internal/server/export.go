@@ -0,0 +1,29 @@
+package server
+
+import "github.com/scaler/serverless-redis/internal/types"
+
+// ExportCommand returns the command reading the whole value of key, given
+// its TYPE. Types without one, such as module types, can only be exported
+// as DUMP payloads.
+func ExportCommand(key, keyType string, db int) (types.CommandRequest, bool) {
+	var args []interface{}
+	command := ""
+	switch keyType {
+	case "string":
+		command, args = "GET", []interface{}{key}
+	case "hash":
+		// Pairs keep fields in the order Redis returns them
+		return types.CommandRequest{Command: "HGETALL", Args: []interface{}{key}, DB: db, HashFormat: types.HashFormatPairs}, true
+	case "list":
+		command, args = "LRANGE", []interface{}{key, 0, -1}
+	case "set":
+		command, args = "SMEMBERS", []interface{}{key}
+	case "zset":
+		command, args = "ZRANGE", []interface{}{key, 0, -1, "WITHSCORES"}
+	case "stream":
+		command, args = "XRANGE", []interface{}{key, "-", "+"}
+	default:
+		return types.CommandRequest{}, false
+	}
+	return types.CommandRequest{Command: command, Args: args, DB: db}, true
+}
@@ -0,0 +1,24 @@
+package server
+
+import "testing"
+
+func TestExportCommand(t *testing.T) {
+	tests := map[string]string{
+		"string": "GET",
+		"hash":   "HGETALL",
+		"list":   "LRANGE",
+		"set":    "SMEMBERS",
+		"zset":   "ZRANGE",
+		"stream": "XRANGE",
+	}
+	for keyType, want := range tests {
+		cmd, ok := ExportCommand("k", keyType, 3)
+		if !ok || cmd.Command != want || cmd.Args[0] != "k" || cmd.DB != 3 {
+			t.Errorf("ExportCommand(%s) = %+v, %v; want %s on k in db 3", keyType, cmd, ok, want)
+		}
+	}
+
+	if _, ok := ExportCommand("k", "ReJSON-RL", 0); ok {
+		t.Error("Expected no export command for module types")
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrInjectedDrop is returned for a command whose client connection the
+// fault injector drops.
+var ErrInjectedDrop = errors.New("connection dropped by fault injection")
+
+// Fault kinds, as passed to a FaultObserver
+const (
+	FaultLatency = "latency"
+	FaultError   = "error"
+	FaultDrop    = "drop"
+)
+
+// FaultObserver is called for every fault injected into command.
+type FaultObserver func(fault, command string)
+
+// FaultInjector injects the synthetic latency, errors and connection
+// drops of its rules into commands, for testing clients against a
+// misbehaving proxy.
+type FaultInjector struct {
+	rules    []types.FaultRule
+	observer FaultObserver
+	random   func() float64
+}
+
+// NewFaultInjector creates an injector for rules.
+func NewFaultInjector(rules []types.FaultRule) *FaultInjector {
+	return &FaultInjector{rules: rules, random: rand.Float64}
+}
+
+// SetObserver registers fn to be called for every injected fault.
+func (f *FaultInjector) SetObserver(fn FaultObserver) {
+	f.observer = fn
+}
+
+// Inject applies the faults of the rules matching command from tenant. It
+// waits out injected latency, or until ctx is done, and then returns an
+// injected error, ErrInjectedDrop, or nil to run the command. A drop also
+// makes FaultMiddleware abort the response, when the request went through
+// it.
+func (f *FaultInjector) Inject(ctx context.Context, command, tenant string) error {
+	for _, rule := range f.rules {
+		if !matchFault(rule, command, tenant) {
+			continue
+		}
+
+		if rule.Latency > 0 && f.random() < rule.LatencyRate {
+			f.observe(FaultLatency, command)
+			delay := rule.Latency + time.Duration(f.random()*float64(rule.Jitter))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if rule.DropRate > 0 && f.random() < rule.DropRate {
+			f.observe(FaultDrop, command)
+			if state, ok := ctx.Value(faultContextKey{}).(*faultState); ok {
+				state.drop()
+			}
+			return ErrInjectedDrop
+		}
+		if rule.ErrorRate > 0 && f.random() < rule.ErrorRate {
+			f.observe(FaultError, command)
+			return errors.New(rule.Error)
+		}
+	}
+	return nil
+}
+
+func (f *FaultInjector) observe(fault, command string) {
+	if f.observer != nil {
+		f.observer(fault, strings.ToUpper(command))
+	}
+}
+
+// matchFault reports whether rule covers command from tenant; empty lists
+// cover everything.
+func matchFault(rule types.FaultRule, command, tenant string) bool {
+	matches := func(values []string, value string, fold bool) bool {
+		if len(values) == 0 {
+			return true
+		}
+		for _, v := range values {
+			if v == value || fold && strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return matches(rule.Commands, command, true) && matches(rule.Tenants, tenant, false)
+}
+
+type faultContextKey struct{}
+
+// faultState records whether a request's connection is to be dropped.
+type faultState struct {
+	mutex   sync.Mutex
+	dropped bool
+}
+
+func (s *faultState) drop() {
+	s.mutex.Lock()
+	s.dropped = true
+	s.mutex.Unlock()
+}
+
+func (s *faultState) isDropped() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.dropped
+}
+
+// FaultMiddleware drops the client connection of requests with a command
+// the fault injector dropped, without a response: anything written after
+// the drop is discarded, and the handler is aborted once it returns.
+func FaultMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &faultState{}
+		next.ServeHTTP(&faultWriter{ResponseWriter: w, state: state}, r.WithContext(context.WithValue(r.Context(), faultContextKey{}, state)))
+		if state.isDropped() {
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// faultWriter discards the response of a dropped request.
+type faultWriter struct {
+	http.ResponseWriter
+	state *faultState
+}
+
+func (fw *faultWriter) WriteHeader(code int) {
+	if !fw.state.isDropped() {
+		fw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (fw *faultWriter) Write(data []byte) (int, error) {
+	if fw.state.isDropped() {
+		return len(data), nil
+	}
+	return fw.ResponseWriter.Write(data)
+}
+
+func (fw *faultWriter) Flush() {
+	if flusher, ok := fw.ResponseWriter.(http.Flusher); ok && !fw.state.isDropped() {
+		flusher.Flush()
+	}
+}
+
+func (fw *faultWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
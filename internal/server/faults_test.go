@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestFaultInjector(t *testing.T) {
+	injector := NewFaultInjector([]types.FaultRule{
+		{Commands: []string{"GET"}, Tenants: []string{"acme"}, ErrorRate: 0.5, Error: "LOADING Redis is loading"},
+		{Commands: []string{"set"}, Latency: 20 * time.Millisecond, LatencyRate: 1},
+		{Commands: []string{"DEL"}, DropRate: 1},
+	})
+	var observed []string
+	injector.SetObserver(func(fault, command string) {
+		observed = append(observed, fault+" "+command)
+	})
+	injector.random = func() float64 { return 0.25 }
+
+	ctx := context.Background()
+	if err := injector.Inject(ctx, "get", "acme"); err == nil || err.Error() != "LOADING Redis is loading" {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+	if err := injector.Inject(ctx, "GET", "other"); err != nil {
+		t.Errorf("Expected no fault for another tenant, got %v", err)
+	}
+	injector.random = func() float64 { return 0.75 }
+	if err := injector.Inject(ctx, "GET", "acme"); err != nil {
+		t.Errorf("Expected no fault above the error rate, got %v", err)
+	}
+
+	start := time.Now()
+	if err := injector.Inject(ctx, "SET", ""); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Expected SET to be delayed, got %v after %v", err, time.Since(start))
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := injector.Inject(cancelled, "SET", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the latency to end with the context, got %v", err)
+	}
+
+	if err := injector.Inject(ctx, "DEL", ""); err != ErrInjectedDrop {
+		t.Errorf("Expected a drop, got %v", err)
+	}
+
+	expected := []string{"error GET", "latency SET", "latency SET", "drop DEL"}
+	if len(observed) != len(expected) {
+		t.Fatalf("Expected %v observed, got %v", expected, observed)
+	}
+	for i := range expected {
+		if observed[i] != expected[i] {
+			t.Errorf("Expected %v observed, got %v", expected, observed)
+			break
+		}
+	}
+}
+
+func TestFaultMiddleware(t *testing.T) {
+	injector := NewFaultInjector([]types.FaultRule{{DropRate: 1}})
+	handler := FaultMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := injector.Inject(r.Context(), "GET", "")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(err.Error()))
+	}))
+
+	w := httptest.NewRecorder()
+	defer func() {
+		if value := recover(); value != http.ErrAbortHandler {
+			t.Errorf("Expected the handler to be aborted, got %v", value)
+		}
+		if w.Body.Len() != 0 || w.Code != http.StatusOK {
+			t.Errorf("Expected no response, got %d %q", w.Code, w.Body.String())
+		}
+	}()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/command", nil))
+}
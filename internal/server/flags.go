@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrFlagNotFound is returned for flags that don't exist.
+var ErrFlagNotFound = errors.New("flag not found")
+
+// ErrTooManyFlags is returned when a tenant already has flags.max_flags
+// flags.
+var ErrTooManyFlags = errors.New("too many flags")
+
+// FlagStore keeps feature flags, each tenant's in a hash of its own keyed
+// by flag name, and tells watchers when a tenant's flags change. Changes
+// made through this instance are announced at once; those made through
+// others arrive through Changed, from keyspace notifications.
+type FlagStore struct {
+	executor CommandExecutor
+	config   types.FlagsConfig
+	now      func() time.Time
+
+	mutex    sync.Mutex
+	watchers map[string]map[chan struct{}]struct{}
+}
+
+// NewFlagStore creates a flag store backed by executor.
+func NewFlagStore(executor CommandExecutor, config types.FlagsConfig) *FlagStore {
+	return &FlagStore{
+		executor: executor,
+		config:   config,
+		now:      time.Now,
+		watchers: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// List returns the flags of tenant, sorted by name.
+func (s *FlagStore) List(ctx context.Context, tenant string) ([]types.Flag, error) {
+	result, err := s.executor.ExecuteCommand(ctx, types.CommandRequest{
+		Command: "HGETALL", Args: []interface{}{s.Key(tenant)}, DB: s.config.DB, HashFormat: types.HashFormatSorted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := result.([]types.HashField)
+
+	flags := make([]types.Flag, 0, len(fields))
+	for _, field := range fields {
+		flag, err := decodeFlag(field.Field, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	return flags, nil
+}
+
+// Get returns the flag of tenant called name.
+func (s *FlagStore) Get(ctx context.Context, tenant, name string) (*types.Flag, error) {
+	// HMGET replies nil for a missing flag rather than failing
+	result, err := s.exec(ctx, "HMGET", s.Key(tenant), name)
+	if err != nil {
+		return nil, err
+	}
+	replies, _ := result.([]interface{})
+	if len(replies) != 1 || replies[0] == nil {
+		return nil, ErrFlagNotFound
+	}
+	return decodeFlag(name, replies[0])
+}
+
+// Put creates or replaces a flag of tenant, stamping it with the time.
+func (s *FlagStore) Put(ctx context.Context, tenant string, flag *types.Flag) error {
+	if s.config.MaxFlags > 0 {
+		if _, err := s.Get(ctx, tenant, flag.Name); errors.Is(err, ErrFlagNotFound) {
+			count, err := s.exec(ctx, "HLEN", s.Key(tenant))
+			if err != nil {
+				return err
+			}
+			if n, _ := count.(int64); n >= int64(s.config.MaxFlags) {
+				return fmt.Errorf("%w: tenant has the maximum of %d", ErrTooManyFlags, s.config.MaxFlags)
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	flag.UpdatedAt = s.now().UTC()
+	encoded, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+	if _, err := s.exec(ctx, "HSET", s.Key(tenant), flag.Name, string(encoded)); err != nil {
+		return err
+	}
+	s.notify(tenant)
+	return nil
+}
+
+// Delete removes the flag of tenant called name.
+func (s *FlagStore) Delete(ctx context.Context, tenant, name string) error {
+	result, err := s.exec(ctx, "HDEL", s.Key(tenant), name)
+	if err != nil {
+		return err
+	}
+	if removed, _ := result.(int64); removed == 0 {
+		return ErrFlagNotFound
+	}
+	s.notify(tenant)
+	return nil
+}
+
+// Key returns the key of the hash holding the flags of tenant.
+func (s *FlagStore) Key(tenant string) string {
+	return s.config.KeyPrefix + tenant
+}
+
+// Watch returns a channel that receives a value after the flags of tenant
+// change, and a function to stop watching. Changes in quick succession
+// may be coalesced into one value.
+func (s *FlagStore) Watch(tenant string) (<-chan struct{}, func()) {
+	changes := make(chan struct{}, 1)
+
+	s.mutex.Lock()
+	if s.watchers[tenant] == nil {
+		s.watchers[tenant] = make(map[chan struct{}]struct{})
+	}
+	s.watchers[tenant][changes] = struct{}{}
+	s.mutex.Unlock()
+
+	return changes, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.watchers[tenant], changes)
+		if len(s.watchers[tenant]) == 0 {
+			delete(s.watchers, tenant)
+		}
+	}
+}
+
+// Changed tells the watchers of the tenant whose flags are at key that
+// they changed, or every watcher if key is empty, as when notifications
+// may have been missed.
+func (s *FlagStore) Changed(key string) {
+	if key == "" {
+		s.mutex.Lock()
+		tenants := make([]string, 0, len(s.watchers))
+		for tenant := range s.watchers {
+			tenants = append(tenants, tenant)
+		}
+		s.mutex.Unlock()
+		for _, tenant := range tenants {
+			s.notify(tenant)
+		}
+		return
+	}
+	if tenant, ok := strings.CutPrefix(key, s.config.KeyPrefix); ok {
+		s.notify(tenant)
+	}
+}
+
+func (s *FlagStore) notify(tenant string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for changes := range s.watchers[tenant] {
+		select {
+		case changes <- struct{}{}:
+		default: // a change is pending already
+		}
+	}
+}
+
+func (s *FlagStore) exec(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	return s.executor.ExecuteCommand(ctx, types.CommandRequest{Command: command, Args: args, DB: s.config.DB})
+}
+
+func decodeFlag(name string, reply interface{}) (*types.Flag, error) {
+	encoded, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected flag reply %T", reply)
+	}
+	var flag types.Flag
+	if err := json.Unmarshal([]byte(encoded), &flag); err != nil {
+		return nil, fmt.Errorf("corrupt flag %s: %w", name, err)
+	}
+	flag.Name = name
+	return &flag, nil
+}
+
+// EvaluateFlag reports whether flag is on for subject. Subjects outside
+// the flag's list are bucketed by a hash of the flag name and subject, so
+// a subject stays on one side of a rollout while it grows, and different
+// flags roll out to different subjects. Without a subject, only flags
+// rolled out fully are on.
+func EvaluateFlag(flag types.Flag, subject string) types.FlagEvaluation {
+	evaluation := types.FlagEvaluation{Name: flag.Name}
+	switch {
+	case !flag.Enabled:
+		evaluation.Reason = types.FlagReasonDisabled
+	case subject != "" && slices.Contains(flag.Subjects, subject):
+		evaluation.Enabled, evaluation.Reason = true, types.FlagReasonSubject
+	case flag.Rollout >= 100 || subject != "" && rolloutBucket(flag.Name, subject) < flag.Rollout:
+		evaluation.Enabled, evaluation.Reason = true, types.FlagReasonRollout
+	default:
+		evaluation.Reason = types.FlagReasonExcluded
+	}
+	return evaluation
+}
+
+// rolloutBucket places subject in one of 100 buckets for flag name.
+func rolloutBucket(name, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeFlagRedis implements the hash commands FlagStore sends.
+type fakeFlagRedis struct {
+	mutex  sync.Mutex
+	hashes map[string]map[string]string
+}
+
+func (f *fakeFlagRedis) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	arg := func(i int) string {
+		s, _ := req.Args[i].(string)
+		return s
+	}
+	hash := f.hashes[arg(0)]
+	switch req.Command {
+	case "HSET":
+		if hash == nil {
+			hash = make(map[string]string)
+			f.hashes[arg(0)] = hash
+		}
+		hash[arg(1)] = arg(2)
+		return int64(1), nil
+	case "HMGET":
+		if value, ok := hash[arg(1)]; ok {
+			return []interface{}{value}, nil
+		}
+		return []interface{}{nil}, nil
+	case "HGETALL":
+		var reply []types.HashField
+		for field, value := range hash {
+			reply = append(reply, types.HashField{Field: field, Value: value})
+		}
+		sort.Slice(reply, func(i, j int) bool { return reply[i].Field < reply[j].Field })
+		return reply, nil
+	case "HLEN":
+		return int64(len(hash)), nil
+	case "HDEL":
+		if _, ok := hash[arg(1)]; !ok {
+			return int64(0), nil
+		}
+		delete(hash, arg(1))
+		return int64(1), nil
+	}
+	return nil, fmt.Errorf("unexpected command %s", req.Command)
+}
+
+func TestFlagStore(t *testing.T) {
+	redis := &fakeFlagRedis{hashes: make(map[string]map[string]string)}
+	store := NewFlagStore(redis, types.FlagsConfig{KeyPrefix: "sr:flags:", MaxFlags: 2})
+	ctx := context.Background()
+
+	changes, stop := store.Watch("acme")
+	defer stop()
+	other, stopOther := store.Watch("globex")
+	defer stopOther()
+
+	for _, name := range []string{"b", "a"} {
+		if err := store.Put(ctx, "acme", &types.Flag{Name: name, Enabled: true, Rollout: 100}); err != nil {
+			t.Fatalf("Put(%s) error = %v", name, err)
+		}
+	}
+	if err := store.Put(ctx, "acme", &types.Flag{Name: "c"}); !errors.Is(err, ErrTooManyFlags) {
+		t.Errorf("Expected a third flag to be refused, got %v", err)
+	}
+	if err := store.Put(ctx, "acme", &types.Flag{Name: "a", Rollout: 50}); err != nil {
+		t.Errorf("Expected replacing a flag at the limit to succeed, got %v", err)
+	}
+
+	flags, err := store.List(ctx, "acme")
+	if err != nil || len(flags) != 2 || flags[0].Name != "a" || flags[1].Name != "b" {
+		t.Fatalf("Expected flags a and b, got %+v, %v", flags, err)
+	}
+	if flags[0].Rollout != 50 || flags[0].UpdatedAt.IsZero() {
+		t.Errorf("Expected a replaced and stamped, got %+v", flags[0])
+	}
+	if _, err := store.Get(ctx, "globex", "a"); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("Expected other tenants not to see acme's flags, got %v", err)
+	}
+
+	select {
+	case <-changes:
+	default:
+		t.Error("Expected a change to be announced")
+	}
+	select {
+	case <-changes:
+		t.Error("Expected changes in quick succession to be coalesced")
+	default:
+	}
+
+	if err := store.Delete(ctx, "acme", "b"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, "acme", "b"); !errors.Is(err, ErrFlagNotFound) {
+		t.Errorf("Expected deleting a missing flag to fail, got %v", err)
+	}
+	<-changes
+
+	// Keyspace notifications name the hash
+	store.Changed("sr:flags:globex")
+	select {
+	case <-other:
+	case <-time.After(time.Second):
+		t.Error("Expected a notification for globex's hash to reach its watcher")
+	}
+	select {
+	case <-changes:
+		t.Error("Expected acme's watcher to ignore globex's changes")
+	default:
+	}
+	store.Changed("")
+	if len(changes) != 1 || len(other) != 1 {
+		t.Error("Expected a resync to reach every watcher")
+	}
+}
+
+func TestEvaluateFlag(t *testing.T) {
+	flag := types.Flag{Name: "checkout", Enabled: true, Rollout: 30, Subjects: []string{"beta-user"}}
+
+	if e := EvaluateFlag(flag, "beta-user"); !e.Enabled || e.Reason != types.FlagReasonSubject {
+		t.Errorf("Expected listed subjects to get the flag, got %+v", e)
+	}
+	if e := EvaluateFlag(flag, ""); e.Enabled || e.Reason != types.FlagReasonExcluded {
+		t.Errorf("Expected a partial rollout to be off without a subject, got %+v", e)
+	}
+	disabled := flag
+	disabled.Enabled = false
+	if e := EvaluateFlag(disabled, "beta-user"); e.Enabled || e.Reason != types.FlagReasonDisabled {
+		t.Errorf("Expected a disabled flag to be off, got %+v", e)
+	}
+
+	// Roughly the rollout percentage of subjects get the flag, and every
+	// one of them keeps it as the rollout grows
+	on := 0
+	for i := 0; i < 10000; i++ {
+		subject := fmt.Sprintf("user-%d", i)
+		if !EvaluateFlag(flag, subject).Enabled {
+			continue
+		}
+		on++
+		wider := flag
+		wider.Rollout = 60
+		if !EvaluateFlag(wider, subject).Enabled {
+			t.Fatalf("Expected %s to keep the flag as the rollout grows", subject)
+		}
+	}
+	if on < 2700 || on > 3300 {
+		t.Errorf("Expected about 30%% of subjects to get the flag, got %d of 10000", on)
+	}
+}
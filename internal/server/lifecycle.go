@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Lifecycle owns the proxy's background goroutines and the cleanup steps
+// that must run between draining HTTP requests and closing Redis clients,
+// so shutdown happens in a fixed order instead of racing.
+type Lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex    sync.Mutex
+	hooks    []shutdownHook
+	draining atomic.Bool
+	drained  chan struct{} // closed by StartDraining
+}
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewLifecycle creates a lifecycle whose background context stays live
+// until Stop.
+func NewLifecycle() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{ctx: ctx, cancel: cancel, drained: make(chan struct{})}
+}
+
+// Go runs fn in a background goroutine. fn must return once ctx is done.
+func (l *Lifecycle) Go(fn func(ctx context.Context)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		fn(l.ctx)
+	}()
+}
+
+// OnShutdown registers fn to run once requests have drained and background
+// goroutines have stopped, but before Redis clients are closed; e.g. to
+// flush write-behind buffers or close subscription streams. Hooks run in
+// registration order.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.hooks = append(l.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// StartDraining marks the proxy as shutting down, so health checks can
+// steer load balancers away before the listener closes.
+func (l *Lifecycle) StartDraining() {
+	if l.draining.CompareAndSwap(false, true) {
+		close(l.drained)
+	}
+}
+
+// Draining reports whether shutdown has begun.
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// DrainingStarted returns a channel closed once shutdown begins, for
+// streams to end on rather than hold their connection open until the
+// drain deadline.
+func (l *Lifecycle) DrainingStarted() <-chan struct{} {
+	return l.drained
+}
+
+// Stop cancels the background context and waits for the goroutines
+// started with Go to return, or for ctx to be done.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background goroutines still running: %w", ctx.Err())
+	}
+}
+
+// RunShutdownHooks runs every registered hook, even if earlier ones fail,
+// and returns their errors joined.
+func (l *Lifecycle) RunShutdownHooks(ctx context.Context) error {
+	l.mutex.Lock()
+	hooks := l.hooks
+	l.mutex.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
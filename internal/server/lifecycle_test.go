@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStopWaitsForGoroutines(t *testing.T) {
+	lifecycle := NewLifecycle()
+
+	stopped := false
+	lifecycle.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lifecycle.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if !stopped {
+		t.Error("Expected Stop to wait for the goroutine to return")
+	}
+}
+
+func TestLifecycleStopDeadline(t *testing.T) {
+	lifecycle := NewLifecycle()
+	release := make(chan struct{})
+	defer close(release)
+	lifecycle.Go(func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lifecycle.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error for a stuck goroutine, got %v", err)
+	}
+}
+
+func TestLifecycleShutdownHooks(t *testing.T) {
+	lifecycle := NewLifecycle()
+	if lifecycle.Draining() {
+		t.Error("Expected not to be draining before shutdown")
+	}
+	select {
+	case <-lifecycle.DrainingStarted():
+		t.Error("Expected streams to stay open before shutdown")
+	default:
+	}
+	lifecycle.StartDraining()
+	lifecycle.StartDraining()
+	if !lifecycle.Draining() {
+		t.Error("Expected draining after StartDraining")
+	}
+	select {
+	case <-lifecycle.DrainingStarted():
+	default:
+		t.Error("Expected streams to be told to close after StartDraining")
+	}
+
+	var order []string
+	lifecycle.OnShutdown("flush", func(ctx context.Context) error {
+		order = append(order, "flush")
+		return errors.New("buffer unavailable")
+	})
+	lifecycle.OnShutdown("streams", func(ctx context.Context) error {
+		order = append(order, "streams")
+		return nil
+	})
+
+	err := lifecycle.RunShutdownHooks(context.Background())
+	if err == nil || err.Error() != "flush: buffer unavailable" {
+		t.Errorf("Expected the failing hook's error, got %v", err)
+	}
+	if len(order) != 2 || order[0] != "flush" || order[1] != "streams" {
+		t.Errorf("Expected every hook to run in order, got %v", order)
+	}
+}
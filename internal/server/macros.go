@@ -0,0 +1,70 @@
+package server
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// macroPlaceholder matches a {{param}} placeholder in a macro argument.
+var macroPlaceholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// MacroPlaceholders returns the names of the params macro's arguments
+// refer to, in order of first use.
+func MacroPlaceholders(macro types.MacroConfig) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, cmd := range macro.Commands {
+		for _, arg := range cmd.Args {
+			for _, match := range macroPlaceholder.FindAllStringSubmatch(arg, -1) {
+				if !seen[match[1]] {
+					seen[match[1]] = true
+					names = append(names, match[1])
+				}
+			}
+		}
+	}
+	return names
+}
+
+// ExpandMacro returns macro's commands with their placeholders replaced
+// by params. Every param the macro declares must be given, and no other.
+func ExpandMacro(macro types.MacroConfig, params map[string]interface{}) ([]types.CommandRequest, error) {
+	values := make(map[string]string, len(macro.Params))
+	for _, name := range macro.Params {
+		value, ok := params[name]
+		if !ok {
+			return nil, types.NewValidationError(types.ErrCodeMissingField, "params."+name, "param %s is required", name)
+		}
+		switch v := value.(type) {
+		case string:
+			values[name] = v
+		case float64:
+			values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		default:
+			return nil, types.NewValidationError(types.ErrCodeInvalidArgument, "params."+name,
+				"param %s must be a string, number or boolean", name)
+		}
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, types.NewValidationError(types.ErrCodeInvalidArgument, "params."+name,
+				"macro %s has no param %s", macro.Name, name)
+		}
+	}
+
+	commands := make([]types.CommandRequest, len(macro.Commands))
+	for i, cmd := range macro.Commands {
+		args := make([]interface{}, len(cmd.Args))
+		for j, arg := range cmd.Args {
+			args[j] = macroPlaceholder.ReplaceAllStringFunc(arg, func(placeholder string) string {
+				return values[macroPlaceholder.FindStringSubmatch(placeholder)[1]]
+			})
+		}
+		commands[i] = types.CommandRequest{Command: cmd.Command, Args: args}
+	}
+	return commands, nil
+}
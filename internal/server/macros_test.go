@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestExpandMacro(t *testing.T) {
+	macro := types.MacroConfig{
+		Name:   "visit",
+		Params: []string{"user", "page"},
+		Commands: []types.MacroCommand{
+			{Command: "INCR", Args: []string{"visits:{{page}}"}},
+			{Command: "SADD", Args: []string{"visitors:{{ page }}", "{{user}}"}},
+			{Command: "EXPIRE", Args: []string{"visitors:{{page}}", "3600"}},
+		},
+	}
+
+	if got := MacroPlaceholders(macro); !reflect.DeepEqual(got, []string{"page", "user"}) {
+		t.Errorf("Expected placeholders [page user], got %v", got)
+	}
+
+	commands, err := ExpandMacro(macro, map[string]interface{}{"user": float64(42), "page": "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []types.CommandRequest{
+		{Command: "INCR", Args: []interface{}{"visits:home"}},
+		{Command: "SADD", Args: []interface{}{"visitors:home", "42"}},
+		{Command: "EXPIRE", Args: []interface{}{"visitors:home", "3600"}},
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Expected %v, got %v", want, commands)
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		code   string
+	}{
+		{"missing param", map[string]interface{}{"user": "a"}, types.ErrCodeMissingField},
+		{"unknown param", map[string]interface{}{"user": "a", "page": "b", "extra": "c"}, types.ErrCodeInvalidArgument},
+		{"nested param", map[string]interface{}{"user": []interface{}{"a"}, "page": "b"}, types.ErrCodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExpandMacro(macro, tt.params)
+			var validationErr *types.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// MaintenanceHeader names the active maintenance window on responses
+// affected by it, so clients and metrics can tell planned unavailability
+// apart from failures.
+const MaintenanceHeader = "X-SR-Maintenance"
+
+// ErrMaintenance is returned for writes rejected during a read-only
+// maintenance window.
+var ErrMaintenance = errors.New("writes are disabled during a maintenance window")
+
+// ErrFullMaintenance is returned for requests rejected while the proxy is
+// in full maintenance mode.
+var ErrFullMaintenance = errors.New("the proxy is in full maintenance mode")
+
+// ManualMaintenanceID identifies the operator-set maintenance mode in the
+// maintenance header.
+const ManualMaintenanceID = "manual"
+
+// manualRetryAfter is the Retry-After hint during a maintenance mode with
+// no planned end.
+const manualRetryAfter = 30 * time.Second
+
+// MaintenanceScheduler holds planned maintenance windows and the maintenance
+// mode set by an operator. While either is active the proxy can run
+// read-only, and Retry-After hints on rejected requests are extended to
+// the end of the maintenance.
+type MaintenanceScheduler struct {
+	mutex   sync.RWMutex
+	windows []types.MaintenanceWindow
+	mode    *types.MaintenanceMode
+}
+
+// NewMaintenanceScheduler creates a scheduler with the configured windows.
+func NewMaintenanceScheduler(windows []types.MaintenanceWindow) (*MaintenanceScheduler, error) {
+	s := &MaintenanceScheduler{}
+	for _, window := range windows {
+		if _, err := s.Add(window); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add schedules window, assigning it an ID if it has none.
+func (s *MaintenanceScheduler) Add(window types.MaintenanceWindow) (types.MaintenanceWindow, error) {
+	if window.Start.IsZero() || window.End.IsZero() {
+		return window, types.NewValidationError(types.ErrCodeMissingField, "start", "start and end are required")
+	}
+	if !window.End.After(window.Start) {
+		return window, types.NewValidationError(types.ErrCodeInvalidArgument, "end", "end must be after start")
+	}
+	if window.ID == "" {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		window.ID = hex.EncodeToString(b)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, existing := range s.windows {
+		if existing.ID == window.ID {
+			return window, types.NewValidationError(types.ErrCodeInvalidArgument, "id", "window %q already exists", window.ID)
+		}
+	}
+
+	s.windows = append(s.windows, window)
+	sort.Slice(s.windows, func(i, j int) bool { return s.windows[i].Start.Before(s.windows[j].Start) })
+	return window, nil
+}
+
+// Remove deletes the window with id, reporting whether it existed.
+func (s *MaintenanceScheduler) Remove(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, window := range s.windows {
+		if window.ID == id {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// List returns windows that have not ended yet, earliest first.
+func (s *MaintenanceScheduler) List(now time.Time) []types.MaintenanceWindow {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	windows := make([]types.MaintenanceWindow, 0, len(s.windows))
+	for _, window := range s.windows {
+		if window.End.After(now) {
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// Active returns the window in effect at now, if any. When windows
+// overlap, the one ending last wins.
+func (s *MaintenanceScheduler) Active(now time.Time) (types.MaintenanceWindow, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var active types.MaintenanceWindow
+	found := false
+	for _, window := range s.windows {
+		if !now.Before(window.Start) && now.Before(window.End) && (!found || window.End.After(active.End)) {
+			active, found = window, true
+		}
+	}
+	return active, found
+}
+
+// SetMode switches the maintenance mode, taking precedence over windows
+// until it is switched off or its Until time passes.
+func (s *MaintenanceScheduler) SetMode(mode types.MaintenanceMode) types.MaintenanceMode {
+	mode.Since = time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if mode.Mode == types.MaintenanceOff {
+		s.mode = nil
+	} else {
+		s.mode = &mode
+	}
+	return mode
+}
+
+// Mode returns the maintenance mode in effect at now, if any.
+func (s *MaintenanceScheduler) Mode(now time.Time) (types.MaintenanceMode, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.mode == nil || (s.mode.Until != nil && !now.Before(*s.mode.Until)) {
+		return types.MaintenanceMode{Mode: types.MaintenanceOff}, false
+	}
+	return *s.mode, true
+}
+
+// Current returns the maintenance in effect at now: the maintenance mode
+// as a window with ID ManualMaintenanceID if one is set, else the active
+// window. A mode without an Until time has a zero End.
+func (s *MaintenanceScheduler) Current(now time.Time) (types.MaintenanceWindow, bool) {
+	if mode, on := s.Mode(now); on {
+		window := types.MaintenanceWindow{
+			ID:       ManualMaintenanceID,
+			Name:     mode.Reason,
+			Start:    mode.Since,
+			ReadOnly: true,
+		}
+		if mode.Until != nil {
+			window.End = *mode.Until
+		}
+		return window, true
+	}
+	return s.Active(now)
+}
+
+// Watch calls onChange whenever maintenance starts or ends, checking every
+// interval until ctx is done. Expired windows are pruned as it goes.
+func (s *MaintenanceScheduler) Watch(ctx context.Context, interval time.Duration, onChange func(window types.MaintenanceWindow, active bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var current string
+	for {
+		now := time.Now()
+		window, active := s.Current(now)
+
+		switch {
+		case active && window.ID != current:
+			onChange(window, true)
+			current = window.ID
+		case !active && current != "":
+			onChange(types.MaintenanceWindow{ID: current}, false)
+			current = ""
+		}
+		s.prune(now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *MaintenanceScheduler) prune(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	kept := s.windows[:0]
+	for _, window := range s.windows {
+		if window.End.After(now) {
+			kept = append(kept, window)
+		}
+	}
+	s.windows = kept
+}
+
+// Middleware extends Retry-After on 429 and 503 responses to at least the
+// end of the active maintenance, so clients don't retry into it.
+func (s *MaintenanceScheduler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		window, active := s.Current(time.Now())
+		if !active {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&retryAfterWriter{ResponseWriter: w, window: window}, r)
+	})
+}
+
+type retryAfterWriter struct {
+	http.ResponseWriter
+	window      types.MaintenanceWindow
+	wroteHeader bool
+}
+
+func (rw *retryAfterWriter) WriteHeader(code int) {
+	if !rw.wroteHeader && (code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable) {
+		until := time.Until(rw.window.End)
+		if rw.window.End.IsZero() {
+			until = manualRetryAfter
+		}
+		remaining := RetryAfterSeconds(until)
+		if current, err := strconv.Atoi(rw.Header().Get("Retry-After")); err != nil || current < remaining {
+			rw.Header().Set("Retry-After", strconv.Itoa(remaining))
+		}
+		rw.Header().Set(MaintenanceHeader, rw.window.ID)
+	}
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *retryAfterWriter) Write(data []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(data)
+}
+
+func (rw *retryAfterWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *retryAfterWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RetryAfterSeconds rounds d up to whole seconds for a Retry-After header.
+func RetryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestMaintenanceSchedulerActive(t *testing.T) {
+	now := time.Now()
+	scheduler, err := NewMaintenanceScheduler([]types.MaintenanceWindow{
+		{Name: "later", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+		{Name: "now", Start: now.Add(-time.Minute), End: now.Add(time.Minute), ReadOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("NewMaintenanceScheduler failed: %v", err)
+	}
+
+	window, active := scheduler.Active(now)
+	if !active || window.Name != "now" || !window.ReadOnly {
+		t.Errorf("Expected the current window to be active, got %+v active=%v", window, active)
+	}
+	if window.ID == "" {
+		t.Error("Expected an ID to be assigned")
+	}
+
+	windows := scheduler.List(now)
+	if len(windows) != 2 || windows[0].Name != "now" {
+		t.Errorf("Expected windows sorted by start, got %+v", windows)
+	}
+
+	if !scheduler.Remove(window.ID) || scheduler.Remove(window.ID) {
+		t.Error("Expected Remove to succeed exactly once")
+	}
+	if _, active := scheduler.Active(now); active {
+		t.Error("Expected no active window after removal")
+	}
+}
+
+func TestMaintenanceSchedulerValidation(t *testing.T) {
+	scheduler, _ := NewMaintenanceScheduler(nil)
+	now := time.Now()
+
+	if _, err := scheduler.Add(types.MaintenanceWindow{Start: now}); err == nil {
+		t.Error("Expected missing end to be rejected")
+	}
+	if _, err := scheduler.Add(types.MaintenanceWindow{Start: now, End: now.Add(-time.Minute)}); err == nil {
+		t.Error("Expected end before start to be rejected")
+	}
+
+	window := types.MaintenanceWindow{ID: "upgrade", Start: now, End: now.Add(time.Minute)}
+	if _, err := scheduler.Add(window); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := scheduler.Add(window); err == nil {
+		t.Error("Expected duplicate ID to be rejected")
+	}
+}
+
+func TestMaintenanceMiddlewareRetryAfter(t *testing.T) {
+	now := time.Now()
+	scheduler, _ := NewMaintenanceScheduler([]types.MaintenanceWindow{
+		{ID: "upgrade", Start: now.Add(-time.Minute), End: now.Add(10 * time.Minute)},
+	})
+
+	handler := scheduler.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/busy", nil))
+	retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if retryAfter < 590 || retryAfter > 600 {
+		t.Errorf("Expected Retry-After extended to the window end, got %d", retryAfter)
+	}
+	if rec.Header().Get(MaintenanceHeader) != "upgrade" {
+		t.Errorf("Expected %s header, got %q", MaintenanceHeader, rec.Header().Get(MaintenanceHeader))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ok", nil))
+	if rec.Header().Get("Retry-After") != "" || rec.Header().Get(MaintenanceHeader) != "" {
+		t.Error("Expected successful responses to be left alone")
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	now := time.Now()
+	scheduler, _ := NewMaintenanceScheduler([]types.MaintenanceWindow{
+		{ID: "upgrade", Start: now.Add(-time.Minute), End: now.Add(10 * time.Minute)},
+	})
+
+	scheduler.SetMode(types.MaintenanceMode{Mode: types.MaintenanceFull, Reason: "migration"})
+	window, active := scheduler.Current(time.Now())
+	if !active || window.ID != ManualMaintenanceID || !window.ReadOnly || window.Name != "migration" {
+		t.Errorf("Expected the mode to take precedence over the window, got %+v", window)
+	}
+
+	handler := scheduler.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get(MaintenanceHeader) != ManualMaintenanceID {
+		t.Errorf("Expected default Retry-After and manual header, got %q %q",
+			rec.Header().Get("Retry-After"), rec.Header().Get(MaintenanceHeader))
+	}
+
+	scheduler.SetMode(types.MaintenanceMode{Mode: types.MaintenanceOff})
+	if window, _ := scheduler.Current(time.Now()); window.ID != "upgrade" {
+		t.Errorf("Expected the window once the mode is off, got %q", window.ID)
+	}
+
+	until := time.Now().Add(-time.Second)
+	scheduler.SetMode(types.MaintenanceMode{Mode: types.MaintenanceReadOnly, Until: &until})
+	if _, on := scheduler.Mode(time.Now()); on {
+		t.Error("Expected the mode to end at its until time")
+	}
+}
@@ -0,0 +1,17 @@
+package server
+
+import "strings"
+
+// EscapeGlob escapes the characters that are special in Redis glob
+// patterns, so that s only matches itself in SCAN MATCH.
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package server
+
+import "testing"
+
+func TestEscapeGlob(t *testing.T) {
+	tests := map[string]string{
+		"tenant:42:":  "tenant:42:",
+		"a*b?":        `a\*b\?`,
+		`[x]\y`:       `\[x\]\\y`,
+		"ünïcode:{t}": "ünïcode:{t}",
+	}
+	for in, want := range tests {
+		if got := EscapeGlob(in); got != want {
+			t.Errorf("EscapeGlob(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
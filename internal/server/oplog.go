@@ -0,0 +1,399 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrOpLogFull is returned for writes that would take the op log past
+// max_entries or max_bytes.
+var ErrOpLogFull = errors.New("write-ahead op log is full")
+
+// ErrOpLogPending is returned for writes that can't be queued behind the
+// writes waiting in the op log and would otherwise be applied before them.
+var ErrOpLogPending = errors.New("queued writes are waiting to be replayed")
+
+// Op log outcomes passed to OpLogObserver
+const (
+	OpLogQueued   = "queued"
+	OpLogReplayed = "replayed"
+	OpLogFailed   = "failed"
+	OpLogRejected = "rejected"
+)
+
+// OpLogObserver is told of every write queued, replayed, failed on replay
+// or rejected because the log is full, with the number of writes left.
+type OpLogObserver func(outcome string, pending int)
+
+// OpLog is a write-ahead log of write commands made while Redis couldn't
+// be reached. Commands are appended to a local file, one JSON line each,
+// synced before they are acknowledged, and replayed in order once Redis
+// answers again. Commands Redis rejects on replay are dropped. The file
+// is rewritten after each replay, so a crash during one replays its
+// commands again on restart: queued writes are applied at least once.
+type OpLog struct {
+	config    types.OpLogConfig
+	executor  CommandExecutor
+	retryable func(error) bool
+	isNil     func(error) bool
+	tenants   map[string]bool
+	observer  OpLogObserver
+	now       func() time.Time
+
+	// replaying allows one replay at a time
+	replaying sync.Mutex
+
+	mutex     sync.Mutex
+	file      *os.File
+	entries   []opLogEntry
+	bytes     int64 // of the entries waiting
+	size      int64 // of the file, with replayed entries not yet compacted
+	seq       int64
+	queued    int64
+	replayed  int64
+	failed    int64
+	rejected  int64
+	lastError string
+}
+
+// opLogEntry is a queued command with the length of its line in the file.
+type opLogEntry struct {
+	types.OpLogEntry
+	size int64
+}
+
+// NewOpLog opens the op log at config.File, creating it if needed, and
+// loads the commands still waiting in it. executor replays them; replay
+// stops at errors for which retryable is true, to be tried again later.
+// Nil replies, for which isNil is true, are replayed writes that didn't
+// apply, such as SET NX on an existing key.
+func NewOpLog(config types.OpLogConfig, executor CommandExecutor, retryable, isNil func(error) bool) (*OpLog, error) {
+	file, err := os.OpenFile(config.File, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &OpLog{
+		config:    config,
+		executor:  executor,
+		retryable: retryable,
+		isNil:     isNil,
+		tenants:   make(map[string]bool),
+		file:      file,
+		now:       time.Now,
+	}
+	for _, tenant := range config.Tenants {
+		l.tenants[tenant] = true
+	}
+	if err := l.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read op log %s: %w", config.File, err)
+	}
+	return l, nil
+}
+
+// load reads the entries in the file. A last line cut short by a crash
+// was never acknowledged, so it is dropped.
+func (l *OpLog) load() error {
+	reader := bufio.NewReader(l.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("WARNING: dropping a partly written entry at the end of op log %s", l.config.File)
+				return l.file.Truncate(l.size)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry types.OpLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("entry %d: %w", len(l.entries)+1, err)
+		}
+		l.entries = append(l.entries, opLogEntry{OpLogEntry: entry, size: int64(len(line))})
+		l.bytes += int64(len(line))
+		l.size += int64(len(line))
+		l.seq = max(l.seq, entry.Seq)
+	}
+}
+
+// SetObserver registers fn to be called for every write queued, replayed,
+// failed or rejected.
+func (l *OpLog) SetObserver(fn OpLogObserver) {
+	l.observer = fn
+}
+
+func (l *OpLog) observe(outcome string, pending int) {
+	if l.observer != nil {
+		l.observer(outcome, pending)
+	}
+}
+
+// Covers reports whether writes of tenant may be queued.
+func (l *OpLog) Covers(tenant string) bool {
+	return l.tenants[tenant]
+}
+
+// Pending returns the number of writes waiting to be replayed.
+func (l *OpLog) Pending() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.entries)
+}
+
+// Append queues req, made by tenant, returning its entry and the number
+// of writes now waiting.
+func (l *OpLog) Append(tenant string, req types.CommandRequest) (types.OpLogEntry, int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.append(tenant, req)
+}
+
+// AppendIfPending queues req like Append, but only if other writes are
+// waiting; req must then wait behind them for its effects to be applied
+// in order. It reports whether req was queued.
+func (l *OpLog) AppendIfPending(tenant string, req types.CommandRequest) (types.OpLogEntry, int, bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.entries) == 0 {
+		return types.OpLogEntry{}, 0, false, nil
+	}
+	entry, pending, err := l.append(tenant, req)
+	return entry, pending, true, err
+}
+
+func (l *OpLog) append(tenant string, req types.CommandRequest) (types.OpLogEntry, int, error) {
+	entry := types.OpLogEntry{
+		Seq:      l.seq + 1,
+		Time:     l.now().UTC(),
+		Tenant:   tenant,
+		Command:  req.Command,
+		Args:     make([]string, len(req.Args)),
+		DB:       req.DB,
+		Database: req.Database,
+	}
+	for i, arg := range req.Args {
+		entry.Args[i] = opLogArg(arg)
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return types.OpLogEntry{}, len(l.entries), err
+	}
+	line = append(line, '\n')
+
+	if len(l.entries) >= l.config.MaxEntries || l.bytes+int64(len(line)) > l.config.MaxBytes {
+		l.rejected++
+		l.observe(OpLogRejected, len(l.entries))
+		return types.OpLogEntry{}, len(l.entries), ErrOpLogFull
+	}
+
+	// A write that didn't reach the disk isn't acknowledged; the file is
+	// cut back so the next one doesn't follow a partial line
+	if _, err := l.file.Write(line); err != nil {
+		_ = l.file.Truncate(l.size)
+		return types.OpLogEntry{}, len(l.entries), err
+	}
+	if err := l.file.Sync(); err != nil {
+		_ = l.file.Truncate(l.size)
+		return types.OpLogEntry{}, len(l.entries), err
+	}
+
+	l.seq = entry.Seq
+	l.entries = append(l.entries, opLogEntry{OpLogEntry: entry, size: int64(len(line))})
+	l.bytes += int64(len(line))
+	l.size += int64(len(line))
+	l.queued++
+	l.observe(OpLogQueued, len(l.entries))
+	return entry, len(l.entries), nil
+}
+
+// opLogArg returns arg as the string sent to Redis, so it replays the
+// same after being read back from the file. Whole numbers are written
+// without an exponent.
+func opLogArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(arg)
+}
+
+// Replay sends the waiting writes to Redis in order, returning how many
+// it replayed. It stops at the first retryable error, which it returns,
+// leaving that write and those after it for the next replay.
+func (l *OpLog) Replay(ctx context.Context) (int, error) {
+	l.replaying.Lock()
+	defer l.replaying.Unlock()
+
+	replayed := 0
+	var err error
+	for {
+		l.mutex.Lock()
+		if len(l.entries) == 0 {
+			l.mutex.Unlock()
+			break
+		}
+		entry := l.entries[0]
+		l.mutex.Unlock()
+
+		if _, err = l.executor.ExecuteCommand(ctx, entry.request()); err != nil && (ctx.Err() != nil || l.retryable(err)) {
+			l.mutex.Lock()
+			l.lastError = err.Error()
+			l.mutex.Unlock()
+			break
+		}
+
+		l.mutex.Lock()
+		l.entries = l.entries[1:]
+		l.bytes -= entry.size
+		outcome := OpLogReplayed
+		if err != nil && !l.isNil(err) {
+			outcome = OpLogFailed
+			l.failed++
+			l.lastError = err.Error()
+			log.Printf("Op log: Redis rejected %s #%d of tenant %s, dropping it: %v", entry.Command, entry.Seq, entry.Tenant, err)
+		} else {
+			l.replayed++
+		}
+		l.observe(outcome, len(l.entries))
+		l.mutex.Unlock()
+		replayed++
+		err = nil
+	}
+
+	if replayed > 0 {
+		if compactErr := l.compact(); compactErr != nil {
+			log.Printf("WARNING: failed to rewrite op log %s, replayed writes may be replayed again after a restart: %v", l.config.File, compactErr)
+		}
+	}
+	return replayed, err
+}
+
+// compact rewrites the file with only the writes still waiting, empty if
+// none are.
+func (l *OpLog) compact() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.entries) == 0 {
+		if err := l.file.Truncate(0); err != nil {
+			return err
+		}
+		l.size = 0
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range l.entries {
+		line, err := json.Marshal(entry.OpLogEntry)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	// Written aside and renamed over the log, so a crash leaves one or
+	// the other whole
+	tmp := l.config.File + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.config.File); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.config.File, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = l.file.Close()
+	l.file = file
+	l.size = int64(buf.Len())
+	return nil
+}
+
+// Run replays waiting writes every retry_interval until ctx is done.
+// Writes still waiting then stay in the file for the next start.
+func (l *OpLog) Run(ctx context.Context) {
+	defer func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		_ = l.file.Close()
+	}()
+
+	ticker := time.NewTicker(l.config.RetryInterval)
+	defer ticker.Stop()
+	// Only the first failed replay of a run of them is logged
+	failing := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if l.Pending() == 0 {
+				continue
+			}
+			replayed, err := l.Replay(ctx)
+			if replayed > 0 {
+				log.Printf("Op log: replayed %d queued write(s), %d waiting", replayed, l.Pending())
+			}
+			if err != nil && !failing && ctx.Err() == nil {
+				log.Printf("Op log: replay stopped, %d write(s) waiting: %v", l.Pending(), err)
+			}
+			failing = err != nil
+		}
+	}
+}
+
+// Status reports the writes waiting and what the log did since startup.
+func (l *OpLog) Status() types.OpLogStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	status := types.OpLogStatus{
+		File:      l.config.File,
+		Tenants:   l.config.Tenants,
+		Pending:   len(l.entries),
+		Bytes:     l.bytes,
+		Queued:    l.queued,
+		Replayed:  l.replayed,
+		Failed:    l.failed,
+		Rejected:  l.rejected,
+		LastError: l.lastError,
+	}
+	if len(l.entries) > 0 {
+		oldest := l.entries[0].Time
+		status.Oldest = &oldest
+	}
+	return status
+}
+
+// request returns the command of the entry.
+func (e opLogEntry) request() types.CommandRequest {
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = arg
+	}
+	return types.CommandRequest{Command: e.Command, Args: args, DB: e.DB, Database: e.Database}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+var errUnreachable = errors.New("dial tcp: connection refused")
+
+// fakeOpLogRedis records the commands replayed to it, failing with err
+// while it is set, and rejecting the command named reject.
+type fakeOpLogRedis struct {
+	err      error
+	reject   string
+	commands [][]interface{}
+}
+
+func (f *fakeOpLogRedis) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if req.Command == f.reject {
+		return nil, errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	}
+	f.commands = append(f.commands, append([]interface{}{req.Command}, req.Args...))
+	return "OK", nil
+}
+
+func newTestOpLog(t *testing.T, path string, redis *fakeOpLogRedis) *OpLog {
+	t.Helper()
+	config := types.OpLogConfig{File: path, Tenants: []string{"acme"}, MaxEntries: 3, MaxBytes: 1 << 20, RetryInterval: time.Second}
+	retryable := func(err error) bool { return err == errUnreachable }
+	isNil := func(err error) bool { return false }
+	l, err := NewOpLog(config, redis, retryable, isNil)
+	if err != nil {
+		t.Fatalf("NewOpLog() error = %v", err)
+	}
+	return l
+}
+
+func TestOpLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oplog.jsonl")
+	redis := &fakeOpLogRedis{err: errUnreachable}
+	l := newTestOpLog(t, path, redis)
+
+	if !l.Covers("acme") || l.Covers("globex") {
+		t.Error("Expected only acme's writes to be covered")
+	}
+	if _, _, queued, _ := l.AppendIfPending("acme", types.CommandRequest{Command: "SET", Args: []interface{}{"a", "1"}}); queued {
+		t.Error("Expected no write to be queued behind an empty log")
+	}
+
+	// Numbers are kept as Redis gets them, not as floats
+	entry, pending, err := l.Append("acme", types.CommandRequest{Command: "INCRBY", Args: []interface{}{"n", float64(1000000)}})
+	if err != nil || entry.Seq != 1 || pending != 1 {
+		t.Fatalf("Append() = %d, %d, %v", entry.Seq, pending, err)
+	}
+	if _, _, queued, err := l.AppendIfPending("acme", types.CommandRequest{Command: "SET", Args: []interface{}{"a", "1"}, DB: 2}); !queued || err != nil {
+		t.Fatalf("Expected the write to be queued behind the waiting one, got %v", err)
+	}
+	if _, _, err := l.Append("acme", types.CommandRequest{Command: "LPUSH", Args: []interface{}{"a", "x"}}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, _, err := l.Append("acme", types.CommandRequest{Command: "DEL", Args: []interface{}{"a"}}); !errors.Is(err, ErrOpLogFull) {
+		t.Errorf("Expected a fourth write to be rejected, got %v", err)
+	}
+
+	// Replay stops while Redis is unreachable
+	if replayed, err := l.Replay(context.Background()); replayed != 0 || err != errUnreachable {
+		t.Errorf("Replay() = %d, %v, want 0 and the dial error", replayed, err)
+	}
+
+	// Waiting writes survive a restart, and a partly written line is dropped
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	_, _ = file.WriteString(`{"seq":4,"command":"DE`)
+	file.Close()
+	l = newTestOpLog(t, path, redis)
+	if l.Pending() != 3 {
+		t.Fatalf("Expected 3 writes after a restart, got %d", l.Pending())
+	}
+
+	// Writes are replayed in order; rejected ones are dropped
+	redis.err = nil
+	redis.reject = "LPUSH"
+	if replayed, err := l.Replay(context.Background()); replayed != 3 || err != nil {
+		t.Fatalf("Replay() = %d, %v", replayed, err)
+	}
+	want := [][]interface{}{{"INCRBY", "n", "1000000"}, {"SET", "a", "1"}}
+	if !reflect.DeepEqual(redis.commands, want) {
+		t.Errorf("Replayed %v, want %v", redis.commands, want)
+	}
+
+	status := l.Status()
+	if status.Pending != 0 || status.Bytes != 0 || status.Replayed != 2 || status.Failed != 1 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("Expected the file to be emptied after replay, got %v", err)
+	}
+
+	// Sequence numbers go on from the last write
+	if entry, _, err := l.Append("acme", types.CommandRequest{Command: "DEL", Args: []interface{}{"a"}}); err != nil || entry.Seq != 4 {
+		t.Errorf("Append() = %d, %v, want seq 4", entry.Seq, err)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PriorityHeader lets clients mark requests as "low" priority, making
+// their writes the first to be shed when Redis runs out of memory.
+const PriorityHeader = "X-SR-Priority"
+
+// ErrWritesPaused is returned for low-priority writes rejected during an
+// out-of-memory cool-down.
+var ErrWritesPaused = errors.New("low-priority writes paused after Redis out-of-memory error")
+
+// IsOOM reports whether err is Redis refusing a command because used
+// memory exceeds maxmemory.
+func IsOOM(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "OOM ")
+}
+
+// IsLowPriority reports whether the request was marked as low priority.
+func IsLowPriority(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(PriorityHeader), "low")
+}
+
+// MemoryGuard tracks out-of-memory errors from Redis. Every OOM error
+// (re)starts a cool-down window during which low-priority writes are
+// rejected without reaching Redis, leaving headroom for the rest.
+type MemoryGuard struct {
+	cooldown time.Duration
+
+	mutex       sync.Mutex
+	lastOOM     time.Time
+	oomCount    uint64
+	pausedUntil time.Time
+}
+
+// NewMemoryGuard creates a guard with the given cool-down window.
+func NewMemoryGuard(cooldown time.Duration) *MemoryGuard {
+	return &MemoryGuard{cooldown: cooldown}
+}
+
+// RecordOOM notes an OOM error and extends the cool-down.
+func (g *MemoryGuard) RecordOOM() {
+	now := time.Now()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.lastOOM = now
+	g.oomCount++
+	g.pausedUntil = now.Add(g.cooldown)
+}
+
+// WritesPaused reports whether low-priority writes are paused and, if so,
+// for how much longer.
+func (g *MemoryGuard) WritesPaused() (bool, time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	remaining := time.Until(g.pausedUntil)
+	return remaining > 0, remaining
+}
+
+// Snapshot returns the time of the last OOM error, the number seen so far
+// and the end of the current cool-down, which is zero if none is active.
+func (g *MemoryGuard) Snapshot() (time.Time, uint64, time.Time) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	pausedUntil := g.pausedUntil
+	if !time.Now().Before(pausedUntil) {
+		pausedUntil = time.Time{}
+	}
+	return g.lastOOM, g.oomCount, pausedUntil
+}
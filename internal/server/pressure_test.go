@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsOOM(t *testing.T) {
+	if !IsOOM(errors.New("OOM command not allowed when used memory > 'maxmemory'.")) {
+		t.Error("Expected Redis OOM error to be detected")
+	}
+	if IsOOM(errors.New("ERR unknown command")) || IsOOM(nil) {
+		t.Error("Expected non-OOM errors not to be detected")
+	}
+}
+
+func TestMemoryGuardCooldown(t *testing.T) {
+	guard := NewMemoryGuard(50 * time.Millisecond)
+
+	if paused, _ := guard.WritesPaused(); paused {
+		t.Error("Expected writes not to be paused before any OOM")
+	}
+
+	guard.RecordOOM()
+	paused, remaining := guard.WritesPaused()
+	if !paused || remaining <= 0 || remaining > 50*time.Millisecond {
+		t.Errorf("Expected writes paused for up to 50ms, got paused=%v remaining=%v", paused, remaining)
+	}
+
+	lastOOM, count, pausedUntil := guard.Snapshot()
+	if lastOOM.IsZero() || count != 1 || pausedUntil.IsZero() {
+		t.Errorf("Unexpected snapshot: last=%v count=%d until=%v", lastOOM, count, pausedUntil)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if paused, _ := guard.WritesPaused(); paused {
+		t.Error("Expected pause to end after the cool-down")
+	}
+	if _, _, pausedUntil := guard.Snapshot(); !pausedUntil.IsZero() {
+		t.Error("Expected no active cool-down in snapshot")
+	}
+}
+
+func TestIsLowPriority(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	if IsLowPriority(req) {
+		t.Error("Expected requests to default to normal priority")
+	}
+
+	req.Header.Set(PriorityHeader, "LOW")
+	if !IsLowPriority(req) {
+		t.Error("Expected X-SR-Priority: low to mark the request low priority")
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrRateLimited is returned for requests over the tenant's rate limit.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// sweepInterval is how often buckets are swept. Those that have refilled
+// since they were last used are dropped: a new one starts full anyway.
+const sweepInterval = time.Minute
+
+// RateLimiter limits each tenant to its rate_limit in cost units per
+// second, weighing commands by the load they put on Redis rather than
+// counting requests. Every tenant has a token bucket holding up to one
+// second of its rate, so short bursts pass while the average is held to
+// the rate. A request costing more than a full bucket is let through once
+// the bucket is full, leaving it in debt for the excess.
+//
+// With rate_limit.shared enabled, tenants are limited across all proxy
+// instances through a sliding window in Redis instead, with the buckets
+// as a fallback while Redis is unreachable.
+type RateLimiter struct {
+	costs       map[string]int
+	defaultCost int
+	shared      *sharedUsage
+
+	mutex   sync.Mutex
+	buckets map[string]*costBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type costBucket struct {
+	tokens float64
+	rate   float64
+	last   time.Time
+}
+
+// refilled returns the tokens in the bucket at now.
+func (b *costBucket) refilled(now time.Time) float64 {
+	return math.Min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+}
+
+// defaultCosts weigh commands that scan whole keyspaces or collections, or
+// run scripts, unless rate_limit.costs overrides them.
+var defaultCosts = map[string]int{
+	"KEYS": 100, "FLUSHDB": 100, "FLUSHALL": 100, "SORT": 20,
+	"SCAN": 10, "HSCAN": 10, "SSCAN": 10, "ZSCAN": 10,
+	"EVAL": 10, "EVALSHA": 10, "FCALL": 10, "FT.SEARCH": 10,
+	"SUNION": 10, "SINTER": 10, "SDIFF": 10, "ZUNIONSTORE": 10, "ZINTERSTORE": 10,
+	"HGETALL": 5, "SMEMBERS": 5, "LRANGE": 5, "ZRANGE": 5, "ZRANGEBYSCORE": 5,
+	"ZREVRANGE": 5, "XRANGE": 5, "XREVRANGE": 5,
+}
+
+// NewRateLimiter creates a limiter with the configured command costs.
+// executor runs the shared window's script when rate_limit.shared is
+// enabled and may be nil otherwise.
+func NewRateLimiter(config types.RateLimitConfig, executor CommandExecutor) *RateLimiter {
+	costs := make(map[string]int, len(defaultCosts)+len(config.Costs))
+	for command, cost := range defaultCosts {
+		costs[command] = cost
+	}
+	for command, cost := range config.Costs {
+		costs[strings.ToUpper(command)] = cost
+	}
+	limiter := &RateLimiter{
+		costs:       costs,
+		defaultCost: config.DefaultCost,
+		buckets:     make(map[string]*costBucket),
+		now:         time.Now,
+	}
+	if config.Shared.Enabled && executor != nil {
+		limiter.shared = newSharedUsage(executor, config.Shared)
+	}
+	return limiter
+}
+
+// Run shares usage with the other proxy instances until ctx is done. It
+// returns at once unless rate_limit.shared is enabled.
+func (l *RateLimiter) Run(ctx context.Context) {
+	if l.shared == nil {
+		return
+	}
+	l.shared.run(ctx, l.now)
+}
+
+// Cost returns what commands cost together.
+func (l *RateLimiter) Cost(commands ...types.CommandRequest) int {
+	total := 0
+	for _, cmd := range commands {
+		cost, ok := l.costs[strings.ToUpper(cmd.Command)]
+		if !ok {
+			cost = l.defaultCost
+		}
+		total += cost
+	}
+	return total
+}
+
+// Take spends cost from the bucket of tenant, which refills at rate per
+// second. It reports whether the request may run and, if not, how long
+// until it could. A rate of 0 or less is unlimited.
+func (l *RateLimiter) Take(tenant string, rate, cost int) (bool, time.Duration) {
+	if rate <= 0 || cost <= 0 {
+		return true, 0
+	}
+
+	if l.shared != nil {
+		if allowed, retryAfter, ok := l.shared.take(tenant, rate, cost, l.now()); ok {
+			return allowed, retryAfter
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[tenant]
+	if !ok {
+		bucket = &costBucket{tokens: float64(rate), last: now}
+		l.buckets[tenant] = bucket
+	}
+	// A tenant's keys may have different rates; the current one applies
+	bucket.rate = float64(rate)
+	bucket.tokens = bucket.refilled(now)
+	bucket.last = now
+
+	need := math.Min(float64(cost), float64(rate))
+	if bucket.tokens < need {
+		wait := time.Duration((need - bucket.tokens) / float64(rate) * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens -= float64(cost)
+	return true, 0
+}
+
+// Remaining returns the cost tenant could spend right now at rate without
+// spending it, negative while the tenant is in debt.
+func (l *RateLimiter) Remaining(tenant string, rate int) float64 {
+	if l.shared != nil {
+		if remaining, ok := l.shared.remaining(tenant, rate); ok {
+			return remaining
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	bucket, ok := l.buckets[tenant]
+	if !ok {
+		return float64(rate)
+	}
+	refilled := *bucket
+	refilled.rate = float64(rate)
+	return refilled.refilled(l.now())
+}
+
+// sweep drops the buckets that have refilled, at most once per
+// sweepInterval.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+	for tenant, bucket := range l.buckets {
+		if bucket.refilled(now) >= bucket.rate {
+			delete(l.buckets, tenant)
+		}
+	}
+}
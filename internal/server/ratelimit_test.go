@@ -0,0 +1,119 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestRateLimiterCost(t *testing.T) {
+	limiter := NewRateLimiter(types.RateLimitConfig{
+		Costs:       map[string]int{"zrange": 3, "KEYS": 50},
+		DefaultCost: 1,
+	}, nil)
+
+	tests := []struct {
+		commands []types.CommandRequest
+		want     int
+	}{
+		{[]types.CommandRequest{{Command: "GET"}}, 1},
+		{[]types.CommandRequest{{Command: "keys"}}, 50},
+		{[]types.CommandRequest{{Command: "ZRANGE"}}, 3},
+		{[]types.CommandRequest{{Command: "HGETALL"}}, defaultCosts["HGETALL"]},
+		{[]types.CommandRequest{{Command: "GET"}, {Command: "ZRANGE"}, {Command: "SET"}}, 5},
+	}
+	for _, tt := range tests {
+		if got := limiter.Cost(tt.commands...); got != tt.want {
+			t.Errorf("Cost(%v) = %d, want %d", tt.commands, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter := NewRateLimiter(types.RateLimitConfig{DefaultCost: 1}, nil)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	// A full bucket holds one second of the rate
+	for i := 0; i < 10; i++ {
+		if ok, _ := limiter.Take("acme", 10, 1); !ok {
+			t.Fatalf("Expected request %d within the burst to pass", i)
+		}
+	}
+	ok, retryAfter := limiter.Take("acme", 10, 1)
+	if ok || retryAfter != 100*time.Millisecond {
+		t.Errorf("Expected a rejection for 100ms, got %v, %v", ok, retryAfter)
+	}
+
+	// Other tenants have buckets of their own
+	if ok, _ := limiter.Take("other", 10, 5); !ok {
+		t.Error("Expected another tenant to pass")
+	}
+
+	// Tokens come back at the rate
+	now = now.Add(300 * time.Millisecond)
+	if ok, _ := limiter.Take("acme", 10, 3); !ok {
+		t.Error("Expected the refilled cost to pass")
+	}
+	if ok, _ := limiter.Take("acme", 10, 1); ok {
+		t.Error("Expected the bucket to be spent again")
+	}
+
+	// A cost above the whole bucket passes once it is full, leaving a debt
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Take("acme", 10, 30); !ok {
+		t.Error("Expected an expensive request to pass with a full bucket")
+	}
+	now = now.Add(2 * time.Second)
+	if ok, retryAfter := limiter.Take("acme", 10, 1); ok || retryAfter != 100*time.Millisecond {
+		t.Errorf("Expected the debt to be paid off first, got %v, %v", ok, retryAfter)
+	}
+
+	if ok, _ := limiter.Take("unlimited", 0, 1000); !ok {
+		t.Error("Expected a rate of 0 to be unlimited")
+	}
+}
+
+func TestRateLimiterRemaining(t *testing.T) {
+	limiter := NewRateLimiter(types.RateLimitConfig{DefaultCost: 1}, nil)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	if got := limiter.Remaining("acme", 10); got != 10 {
+		t.Errorf("Expected a full budget for a new tenant, got %v", got)
+	}
+
+	limiter.Take("acme", 10, 4)
+	if got := limiter.Remaining("acme", 10); got != 6 {
+		t.Errorf("Expected 6 remaining, got %v", got)
+	}
+	// Asking spends nothing
+	if got := limiter.Remaining("acme", 10); got != 6 {
+		t.Errorf("Expected 6 still remaining, got %v", got)
+	}
+
+	now = now.Add(time.Second)
+	limiter.Take("acme", 10, 20)
+	if got := limiter.Remaining("acme", 10); got != -10 {
+		t.Errorf("Expected a debt of 10, got %v", got)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	limiter := NewRateLimiter(types.RateLimitConfig{DefaultCost: 1}, nil)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Take("idle", 10, 10)
+	limiter.Take("indebted", 1, 120)
+
+	now = now.Add(sweepInterval)
+	limiter.Take("active", 10, 1)
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("Expected the refilled bucket to be dropped")
+	}
+	if _, ok := limiter.buckets["indebted"]; !ok {
+		t.Error("Expected the bucket still in debt to be kept")
+	}
+}
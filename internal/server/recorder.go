@@ -0,0 +1,341 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// recorderQueueSize bounds the exchanges waiting for the sink; more are
+// dropped rather than slowing requests down.
+const recorderQueueSize = 1024
+
+// redactedValue replaces the values of redacted fields.
+const redactedValue = "[REDACTED]"
+
+// recordedHeaders are the request headers kept in recordings, those that
+// change how a request is served. Credentials are never recorded.
+var recordedHeaders = []string{"Content-Type", "Accept", "X-Request-Timeout", "X-SR-Priority", "X-SR-Budget-Ms"}
+
+// RecordSink stores recorded exchanges.
+type RecordSink interface {
+	Record(ctx context.Context, exchange types.RecordedExchange) error
+	Close() error
+}
+
+// FileSink appends exchanges to a file as JSON lines.
+type FileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Record appends exchange as one line.
+func (s *FileSink) Record(ctx context.Context, exchange types.RecordedExchange) error {
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// StreamSink adds exchanges to a Redis stream as an exchange field holding
+// the JSON, trimming it to about maxLen entries.
+type StreamSink struct {
+	executor CommandExecutor
+	key      string
+	db       int
+	maxLen   int
+}
+
+// NewStreamSink creates a sink adding to the stream key in db.
+func NewStreamSink(executor CommandExecutor, key string, db, maxLen int) *StreamSink {
+	return &StreamSink{executor: executor, key: key, db: db, maxLen: maxLen}
+}
+
+// Record adds exchange to the stream.
+func (s *StreamSink) Record(ctx context.Context, exchange types.RecordedExchange) error {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	_, err = s.executor.ExecuteCommand(ctx, types.CommandRequest{
+		Command: "XADD",
+		Args:    []interface{}{s.key, "MAXLEN", "~", s.maxLen, "*", "exchange", string(data)},
+		DB:      s.db,
+	})
+	return err
+}
+
+// Close does nothing; the executor is closed with the server.
+func (s *StreamSink) Close() error {
+	return nil
+}
+
+// Recorder captures sampled API requests and their responses while it is
+// enabled, and writes them to its sink in the background.
+type Recorder struct {
+	config   types.RecordingConfig
+	sink     RecordSink
+	name     string
+	tenantOf func(r *http.Request) string
+	random   func() float64
+
+	enabled  atomic.Bool
+	queue    chan types.RecordedExchange
+	recorded atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	redact   map[string]bool
+}
+
+// NewRecorder creates a recorder writing to sink, described by name in its
+// status. tenantOf returns the tenant a request was made by.
+func NewRecorder(config types.RecordingConfig, sink RecordSink, name string, tenantOf func(r *http.Request) string) *Recorder {
+	redact := make(map[string]bool)
+	for _, field := range config.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+
+	recorder := &Recorder{
+		config:   config,
+		sink:     sink,
+		name:     name,
+		tenantOf: tenantOf,
+		random:   rand.Float64,
+		queue:    make(chan types.RecordedExchange, recorderQueueSize),
+		redact:   redact,
+	}
+	recorder.enabled.Store(config.Enabled)
+	return recorder
+}
+
+// SetEnabled starts or stops recording.
+func (rec *Recorder) SetEnabled(enabled bool) {
+	rec.enabled.Store(enabled)
+}
+
+// Status reports whether the recorder is recording and what it recorded.
+func (rec *Recorder) Status() types.RecordingStatus {
+	return types.RecordingStatus{
+		Enabled:  rec.enabled.Load(),
+		Sink:     rec.name,
+		Recorded: rec.recorded.Load(),
+		Dropped:  rec.dropped.Load(),
+		Failed:   rec.failed.Load(),
+	}
+}
+
+// Run writes recorded exchanges to the sink until ctx is done, then
+// writes those still queued and closes the sink.
+func (rec *Recorder) Run(ctx context.Context) {
+	defer func() { _ = rec.sink.Close() }()
+	for {
+		select {
+		case exchange := <-rec.queue:
+			rec.write(ctx, exchange)
+		case <-ctx.Done():
+			for {
+				select {
+				case exchange := <-rec.queue:
+					rec.write(context.WithoutCancel(ctx), exchange)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (rec *Recorder) write(ctx context.Context, exchange types.RecordedExchange) {
+	if err := rec.sink.Record(ctx, exchange); err != nil {
+		rec.failed.Add(1)
+		return
+	}
+	rec.recorded.Add(1)
+}
+
+// Middleware records sampled requests and their responses while the
+// recorder is enabled.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rec.enabled.Load() || rec.config.SampleRate < 1 && rec.random() >= rec.config.SampleRate {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		body := &cappedBuffer{limit: rec.config.MaxBodyBytes}
+		if r.Body != nil {
+			r.Body = readCloser{Reader: io.TeeReader(r.Body, body), Closer: r.Body}
+		}
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK, body: cappedBuffer{limit: rec.config.MaxBodyBytes}}
+		next.ServeHTTP(rw, r)
+
+		exchange := types.RecordedExchange{
+			Time:              start.UTC(),
+			Tenant:            rec.tenantOf(r),
+			Method:            r.Method,
+			Path:              r.URL.Path,
+			Query:             rec.redactQuery(r.URL.RawQuery),
+			Body:              rec.redactJSON(body.String()),
+			Status:            rw.status,
+			Response:          rec.redactJSON(rw.body.String()),
+			Duration:          float64(time.Since(start)) / float64(time.Millisecond),
+			BodyTruncated:     body.truncated,
+			ResponseTruncated: rw.body.truncated,
+		}
+		for _, name := range recordedHeaders {
+			if value := r.Header.Get(name); value != "" {
+				if exchange.Header == nil {
+					exchange.Header = make(map[string]string)
+				}
+				exchange.Header[name] = value
+			}
+		}
+
+		select {
+		case rec.queue <- exchange:
+		default:
+			rec.dropped.Add(1)
+		}
+	})
+}
+
+// redactQuery masks query parameters named in redact_fields.
+func (rec *Recorder) redactQuery(rawQuery string) string {
+	if rawQuery == "" || len(rec.redact) == 0 {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if rec.redact[strings.ToLower(name)] {
+			params[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactJSON masks fields named in redact_fields, at any depth, of a JSON
+// body. Other bodies, and truncated ones that don't parse, are returned as
+// they are.
+func (rec *Recorder) redactJSON(body string) string {
+	if len(rec.redact) == 0 || !strings.HasPrefix(strings.TrimSpace(body), "{") {
+		return body
+	}
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+	if !rec.redactValue(value) {
+		return body
+	}
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return string(redacted)
+}
+
+// redactValue masks redacted fields in value, reporting whether it had any.
+func (rec *Recorder) redactValue(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if rec.redact[strings.ToLower(name)] {
+				v[name] = redactedValue
+				changed = true
+			} else if rec.redactValue(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if rec.redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// cappedBuffer keeps the first limit bytes written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(data []byte) (int, error) {
+	if room := b.limit - b.Len(); len(data) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(data[:room])
+		}
+		return len(data), nil
+	}
+	return b.Buffer.Write(data)
+}
+
+// recordingWriter records the status and start of a response.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        cappedBuffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(data []byte) (int, error) {
+	rw.wroteHeader = true
+	_, _ = rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
+
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+type memorySink struct {
+	exchanges []types.RecordedExchange
+}
+
+func (s *memorySink) Record(ctx context.Context, exchange types.RecordedExchange) error {
+	s.exchanges = append(s.exchanges, exchange)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestRecorderMiddleware(t *testing.T) {
+	sink := &memorySink{}
+	config := types.RecordingConfig{SampleRate: 1, MaxBodyBytes: 64, RedactFields: []string{"password"}}
+	recorder := NewRecorder(config, sink, "memory", func(r *http.Request) string { return "acme" })
+	handler := recorder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/command?password=hunter2&db=1", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	send(`{"command":"GET","args":["k"]}`)
+	recorder.SetEnabled(true)
+	body := `{"command":"AUTH","args":["u"],"options":{"password":"hunter2"}}`
+	if w := send(body); w.Body.String() != body {
+		t.Errorf("Expected the handler to see the whole body, got %q", w.Body.String())
+	}
+	send(strings.Repeat("x", 100))
+
+	if status := recorder.Status(); !status.Enabled || status.Sink != "memory" {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder.Run(ctx)
+
+	if len(sink.exchanges) != 2 {
+		t.Fatalf("Expected 2 exchanges recorded while enabled, got %d", len(sink.exchanges))
+	}
+	exchange := sink.exchanges[0]
+	if exchange.Tenant != "acme" || exchange.Method != "POST" || exchange.Path != "/v1/command" || exchange.Status != http.StatusCreated {
+		t.Errorf("Unexpected exchange %+v", exchange)
+	}
+	if exchange.Query != "password=[REDACTED]&db=1" {
+		t.Errorf("Expected the password parameter redacted, got %q", exchange.Query)
+	}
+	if strings.Contains(exchange.Body, "hunter2") || strings.Contains(exchange.Response, "hunter2") {
+		t.Errorf("Expected the password field redacted, got %q and %q", exchange.Body, exchange.Response)
+	}
+	if exchange.Header["Authorization"] != "" || exchange.Header["Content-Type"] != "application/json" {
+		t.Errorf("Expected only safe headers recorded, got %v", exchange.Header)
+	}
+
+	truncated := sink.exchanges[1]
+	if !truncated.BodyTruncated || !truncated.ResponseTruncated || len(truncated.Body) != 64 {
+		t.Errorf("Expected the long body truncated to 64 bytes, got %d bytes", len(truncated.Body))
+	}
+	if status := recorder.Status(); status.Recorded != 2 || status.Dropped != 0 {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/v1/command", "/v1/pipeline"} {
+		if err := sink.Record(context.Background(), types.RecordedExchange{Method: "POST", Path: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var paths []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var exchange types.RecordedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			t.Fatalf("Invalid line %q: %v", scanner.Text(), err)
+		}
+		paths = append(paths, exchange.Path)
+	}
+	if strings.Join(paths, ",") != "/v1/command,/v1/pipeline" {
+		t.Errorf("Expected one line per exchange, got %v", paths)
+	}
+}
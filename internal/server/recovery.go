@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// RecoveryMiddleware turns a panic in a handler into a 500 response
+// instead of a dropped connection, after passing it with its stack to
+// onPanic. http.ErrAbortHandler, which handlers panic with on purpose to
+// abort a response, is passed on.
+func RecoveryMiddleware(onPanic func(r *http.Request, value interface{}, stack []byte)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &headerRecorder{ResponseWriter: w}
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				if value == http.ErrAbortHandler {
+					panic(value)
+				}
+
+				onPanic(r, value, debug.Stack())
+				if !recorder.wroteHeader {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(types.ErrorResponse{
+						Error:   "Internal server error",
+						Code:    http.StatusText(http.StatusInternalServerError),
+						Details: "the request handler panicked",
+						Time:    time.Now().Unix(),
+					})
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// headerRecorder records whether a response was started.
+type headerRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (hr *headerRecorder) WriteHeader(code int) {
+	hr.wroteHeader = true
+	hr.ResponseWriter.WriteHeader(code)
+}
+
+func (hr *headerRecorder) Write(data []byte) (int, error) {
+	hr.wroteHeader = true
+	return hr.ResponseWriter.Write(data)
+}
+
+func (hr *headerRecorder) Flush() {
+	if flusher, ok := hr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (hr *headerRecorder) Unwrap() http.ResponseWriter {
+	return hr.ResponseWriter
+}
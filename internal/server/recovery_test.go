@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var recovered interface{}
+	handler := RecoveryMiddleware(func(r *http.Request, value interface{}, stack []byte) {
+		recovered = value
+		if !strings.Contains(string(stack), "TestRecoveryMiddleware") {
+			t.Errorf("Expected the panicking goroutine's stack, got %s", stack)
+		}
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/command", nil))
+
+	if recovered != "boom" {
+		t.Errorf("Expected the panic value, got %v", recovered)
+	}
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Internal server error") {
+		t.Errorf("Expected a 500 error response, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMiddlewarePassesAbort(t *testing.T) {
+	handler := RecoveryMiddleware(func(*http.Request, interface{}, []byte) {
+		t.Error("Expected ErrAbortHandler not to be reported")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if value := recover(); value != http.ErrAbortHandler {
+			t.Errorf("Expected ErrAbortHandler to propagate, got %v", value)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// redisErrorStatuses map Redis error codes to the HTTP status that tells
+// clients what went wrong. Codes not listed keep 200.
+var redisErrorStatuses = map[string]int{
+	"WRONGTYPE": http.StatusConflict,
+	"BUSYKEY":   http.StatusConflict,
+	"EXECABORT": http.StatusConflict,
+	"NOPERM":    http.StatusForbidden,
+	"NOSCRIPT":  http.StatusNotFound,
+	"OOM":       http.StatusInsufficientStorage,
+
+	// Redis can't serve the command right now
+	"BUSY":       http.StatusServiceUnavailable,
+	"LOADING":    http.StatusServiceUnavailable,
+	"MASTERDOWN": http.StatusServiceUnavailable,
+	"READONLY":   http.StatusServiceUnavailable,
+	"TRYAGAIN":   http.StatusServiceUnavailable,
+}
+
+// RedisErrorCode returns the code Redis prefixes its error replies with,
+// such as WRONGTYPE or ERR, or "" for errors that are not Redis replies.
+func RedisErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	message := err.Error()
+	code, _, _ := strings.Cut(message, " ")
+	if len(code) < 2 {
+		return ""
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return ""
+		}
+	}
+	return code
+}
+
+// RedisErrorStatus returns the HTTP status for a command that failed with
+// redisCode, or with the proxy's own error code for errors that are not
+// Redis replies. Errors without a more meaningful status get 200, the
+// error being in the response body.
+func RedisErrorStatus(redisCode, code string) int {
+	switch code {
+	case types.ErrCodeTimeout:
+		return http.StatusGatewayTimeout
+	case types.ErrCodeTTLPolicy:
+		return http.StatusBadRequest
+	}
+	if status, ok := redisErrorStatuses[redisCode]; ok {
+		return status
+	}
+	return http.StatusOK
+}
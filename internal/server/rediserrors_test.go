@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestRedisErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), "WRONGTYPE"},
+		{errors.New("NOPERM User tenant has no permissions to run the 'flushall' command"), "NOPERM"},
+		{errors.New("OOM command not allowed when used memory > 'maxmemory'."), "OOM"},
+		{errors.New("ERR value is not an integer or out of range"), "ERR"},
+		{errors.New("redis: nil"), ""},
+		{context.DeadlineExceeded, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := RedisErrorCode(tt.err); got != tt.want {
+			t.Errorf("RedisErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRedisErrorStatus(t *testing.T) {
+	tests := []struct {
+		redisCode string
+		code      string
+		want      int
+	}{
+		{"WRONGTYPE", "", http.StatusConflict},
+		{"NOPERM", "", http.StatusForbidden},
+		{"OOM", types.ErrCodeOutOfMemory, http.StatusInsufficientStorage},
+		{"LOADING", "", http.StatusServiceUnavailable},
+		{"", types.ErrCodeTimeout, http.StatusGatewayTimeout},
+		{"", types.ErrCodeTTLPolicy, http.StatusBadRequest},
+		{"ERR", "", http.StatusOK},
+		{"", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := RedisErrorStatus(tt.redisCode, tt.code); got != tt.want {
+			t.Errorf("RedisErrorStatus(%q, %q) = %d, want %d", tt.redisCode, tt.code, got, tt.want)
+		}
+	}
+}
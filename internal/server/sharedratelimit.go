@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// CommandExecutor runs a single Redis command. The proxy's Redis client
+// satisfies it.
+type CommandExecutor interface {
+	ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error)
+}
+
+// slidingWindowScript adds ARGV[1] to the current one-second window of the
+// hash at KEYS[1] and returns the cost spent in the last second across all
+// instances: the current window plus the part of the previous one still
+// inside the sliding window. ARGV[2] is the time in milliseconds.
+const slidingWindowScript = `
+local now = tonumber(ARGV[2])
+local window = math.floor(now / 1000)
+local cost = tonumber(ARGV[1])
+local current = tonumber(redis.call('HGET', KEYS[1], window) or '0')
+if cost > 0 then
+	current = redis.call('HINCRBY', KEYS[1], window, cost)
+end
+local previous = tonumber(redis.call('HGET', KEYS[1], window - 1) or '0')
+for _, field in ipairs(redis.call('HKEYS', KEYS[1])) do
+	if tonumber(field) < window - 1 then
+		redis.call('HDEL', KEYS[1], field)
+	end
+end
+redis.call('PEXPIRE', KEYS[1], 2000)
+return tostring(previous * (1 - (now % 1000) / 1000) + current)
+`
+
+// sharedIdleAge is how long a tenant goes without requests before this
+// instance stops following its usage.
+const sharedIdleAge = 2 * time.Second
+
+// sharedUsage follows tenants' cost across proxy instances in Redis. Each
+// instance decides locally from the total it last read plus what it has
+// let through since, and adds the latter every sync interval, so requests
+// never wait on Redis. Instances can overshoot a limit by what they let
+// through in one interval.
+type sharedUsage struct {
+	executor CommandExecutor
+	config   types.SharedRateLimitConfig
+
+	mutex   sync.Mutex
+	tenants map[string]*sharedTenant
+	healthy bool
+}
+
+type sharedTenant struct {
+	used     float64 // cost in the window across instances, as last read
+	pending  int     // cost let through here since
+	lastUsed time.Time
+}
+
+func newSharedUsage(executor CommandExecutor, config types.SharedRateLimitConfig) *sharedUsage {
+	return &sharedUsage{
+		executor: executor,
+		config:   config,
+		tenants:  make(map[string]*sharedTenant),
+		healthy:  true,
+	}
+}
+
+// take is RateLimiter.Take against the shared window. It returns false
+// for ok while Redis is unreachable, for the caller to limit locally. The
+// tenant stays active either way, so it is still followed once Redis is
+// back.
+func (u *sharedUsage) take(tenant string, rate, cost int, now time.Time) (allowed bool, retryAfter time.Duration, ok bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	t, found := u.tenants[tenant]
+	if !found {
+		t = &sharedTenant{}
+		u.tenants[tenant] = t
+	}
+	t.lastUsed = now
+
+	if !u.healthy {
+		return false, 0, false
+	}
+
+	used := t.used + float64(t.pending)
+	need := math.Min(float64(cost), float64(rate))
+	if float64(rate)-used < need {
+		// The window drains at about the rate
+		wait := time.Duration((used + need - float64(rate)) / float64(rate) * float64(time.Second))
+		return false, wait, true
+	}
+	t.pending += cost
+	return true, 0, true
+}
+
+// remaining is RateLimiter.Remaining against the shared window, with ok
+// false while Redis is unreachable.
+func (u *sharedUsage) remaining(tenant string, rate int) (float64, bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if !u.healthy {
+		return 0, false
+	}
+	t, found := u.tenants[tenant]
+	if !found {
+		return float64(rate), true
+	}
+	return float64(rate) - t.used - float64(t.pending), true
+}
+
+// run syncs every sync interval until ctx is done.
+func (u *sharedUsage) run(ctx context.Context, now func() time.Time) {
+	ticker := time.NewTicker(u.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.sync(ctx, now())
+		}
+	}
+}
+
+// sync adds the cost let through since the last sync for every active
+// tenant and reads back their totals. Cost that fails to reach Redis is
+// dropped, and limits fall back to this instance alone until a sync
+// succeeds again. A sync with no tenants to add for pings Redis instead,
+// so Redis is only taken to be reachable once it has replied.
+func (u *sharedUsage) sync(ctx context.Context, now time.Time) {
+	u.mutex.Lock()
+	pending := make(map[string]int, len(u.tenants))
+	for tenant, t := range u.tenants {
+		if t.pending == 0 && now.Sub(t.lastUsed) > sharedIdleAge {
+			delete(u.tenants, tenant)
+			continue
+		}
+		pending[tenant] = t.pending
+		t.pending = 0
+	}
+	u.mutex.Unlock()
+
+	var syncErr error
+	if len(pending) == 0 {
+		syncErr = u.ping(ctx)
+	}
+	for tenant, cost := range pending {
+		used, err := u.add(ctx, tenant, cost, now)
+		if err != nil {
+			syncErr = err
+			continue
+		}
+		u.mutex.Lock()
+		if t, ok := u.tenants[tenant]; ok {
+			t.used = used
+		}
+		u.mutex.Unlock()
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	switch {
+	case syncErr != nil && u.healthy:
+		log.Printf("Shared rate limits unavailable, limiting per instance: %v", syncErr)
+	case syncErr == nil && !u.healthy:
+		log.Printf("Shared rate limits available again")
+	}
+	u.healthy = syncErr == nil
+}
+
+// ping checks Redis is reachable.
+func (u *sharedUsage) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	_, err := u.executor.ExecuteCommand(ctx, types.CommandRequest{Command: "PING", DB: u.config.DB})
+	return err
+}
+
+// add runs slidingWindowScript for tenant.
+func (u *sharedUsage) add(ctx context.Context, tenant string, cost int, now time.Time) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	result, err := u.executor.ExecuteCommand(ctx, types.CommandRequest{
+		Command: "EVAL",
+		Args:    []interface{}{slidingWindowScript, 1, u.config.KeyPrefix + tenant, cost, now.UnixMilli()},
+		DB:      u.config.DB,
+	})
+	if err != nil {
+		return 0, err
+	}
+	reply, ok := result.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected rate limit reply %T", result)
+	}
+	return strconv.ParseFloat(reply, 64)
+}
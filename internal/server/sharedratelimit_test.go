@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeWindow stands in for Redis running slidingWindowScript, keeping one
+// total per key for the whole window.
+type fakeWindow struct {
+	mutex sync.Mutex
+	used  map[string]int
+	err   error
+}
+
+func (f *fakeWindow) ExecuteCommand(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if req.Command == "PING" {
+		return "PONG", nil
+	}
+	key := req.Args[2].(string)
+	f.used[key] += req.Args[3].(int)
+	return strconv.Itoa(f.used[key]), nil
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	window := &fakeWindow{used: make(map[string]int)}
+	config := types.RateLimitConfig{
+		DefaultCost: 1,
+		Shared:      types.SharedRateLimitConfig{Enabled: true, KeyPrefix: "rl:", SyncInterval: 100 * time.Millisecond},
+	}
+	now := time.Unix(1700000000, 0)
+	instances := []*RateLimiter{NewRateLimiter(config, window), NewRateLimiter(config, window)}
+	for _, limiter := range instances {
+		limiter.now = func() time.Time { return now }
+	}
+
+	// Until they sync, each instance only knows its own usage
+	for _, limiter := range instances {
+		for i := 0; i < 6; i++ {
+			if ok, _ := limiter.Take("acme", 10, 1); !ok {
+				t.Fatalf("Expected request %d to pass before syncing", i)
+			}
+		}
+		limiter.shared.sync(context.Background(), now)
+	}
+	if window.used["rl:acme"] != 12 {
+		t.Errorf("Expected the window to total 12, got %d", window.used["rl:acme"])
+	}
+
+	// After syncing, the second instance sees the tenant is over its limit
+	ok, retryAfter := instances[1].Take("acme", 10, 1)
+	if ok || retryAfter != 300*time.Millisecond {
+		t.Errorf("Expected a rejection for 300ms, got %v, %v", ok, retryAfter)
+	}
+
+	// Tenants not used for a while are no longer synced
+	now = now.Add(sharedIdleAge + time.Second)
+	instances[1].shared.sync(context.Background(), now)
+	if _, ok := instances[1].shared.tenants["acme"]; ok {
+		t.Error("Expected the idle tenant to be dropped")
+	}
+}
+
+func TestSharedRateLimiterFallback(t *testing.T) {
+	window := &fakeWindow{used: make(map[string]int), err: errors.New("connection refused")}
+	limiter := NewRateLimiter(types.RateLimitConfig{
+		DefaultCost: 1,
+		Shared:      types.SharedRateLimitConfig{Enabled: true, KeyPrefix: "rl:", SyncInterval: 100 * time.Millisecond},
+	}, window)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Take("acme", 10, 1)
+	limiter.shared.sync(context.Background(), now)
+
+	// With Redis unreachable, the local bucket limits the tenant
+	for i := 0; i < 10; i++ {
+		if ok, _ := limiter.Take("acme", 10, 1); !ok {
+			t.Fatalf("Expected request %d within the local burst to pass", i)
+		}
+	}
+	if ok, _ := limiter.Take("acme", 10, 1); ok {
+		t.Error("Expected the local bucket to be spent")
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected a local bucket, got %d", len(limiter.buckets))
+	}
+
+	// Once a sync succeeds, the shared window applies again
+	window.err = nil
+	limiter.shared.sync(context.Background(), now)
+	if ok, _ := limiter.Take("acme", 10, 1); !ok {
+		t.Error("Expected the shared window to apply again")
+	}
+}
+
+func TestSharedRateLimiterRecovery(t *testing.T) {
+	window := &fakeWindow{used: make(map[string]int), err: errors.New("connection refused")}
+	limiter := NewRateLimiter(types.RateLimitConfig{
+		DefaultCost: 1,
+		Shared:      types.SharedRateLimitConfig{Enabled: true, KeyPrefix: "rl:", SyncInterval: 100 * time.Millisecond},
+	}, window)
+	now := time.Unix(1700000000, 0)
+	limiter.now = func() time.Time { return now }
+
+	// A sync with nothing to add still has to reach Redis
+	limiter.shared.sync(context.Background(), now)
+	limiter.shared.sync(context.Background(), now)
+	if limiter.shared.healthy {
+		t.Fatal("Expected the shared window to stay unavailable without a reply from Redis")
+	}
+
+	// Tenants limited locally meanwhile are still followed
+	now = now.Add(sharedIdleAge + time.Second)
+	limiter.Take("acme", 10, 1)
+	limiter.shared.sync(context.Background(), now)
+	if _, ok := limiter.shared.tenants["acme"]; !ok {
+		t.Error("Expected the tenant limited locally to stay active")
+	}
+
+	window.err = nil
+	limiter.shared.sync(context.Background(), now)
+	if !limiter.shared.healthy {
+		t.Error("Expected the shared window to apply again once Redis replies")
+	}
+}
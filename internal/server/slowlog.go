@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// Like Redis's SLOWLOG, entries keep at most slowMaxArgs arguments of at
+// most slowMaxArgLen bytes each.
+const (
+	slowMaxArgs   = 32
+	slowMaxArgLen = 128
+)
+
+// SlowLog keeps the most recent requests and Redis commands that took
+// longer than their thresholds, in a fixed-size ring.
+type SlowLog struct {
+	requestThreshold time.Duration
+	commandThreshold time.Duration
+
+	mutex   sync.Mutex
+	entries []types.SlowEntry
+	next    int
+	lastID  uint64
+}
+
+// NewSlowLog creates a slow log with the configured thresholds and size.
+func NewSlowLog(config types.SlowLogConfig) *SlowLog {
+	return &SlowLog{
+		requestThreshold: config.RequestThreshold,
+		commandThreshold: config.CommandThreshold,
+		entries:          make([]types.SlowEntry, 0, config.Size),
+	}
+}
+
+// IsSlow reports whether duration is above the threshold of kind.
+func (l *SlowLog) IsSlow(kind string, duration time.Duration) bool {
+	threshold := l.requestThreshold
+	if kind == types.SlowCommand {
+		threshold = l.commandThreshold
+	}
+	return threshold > 0 && duration > threshold
+}
+
+// Record adds entry, which callers have checked with IsSlow, replacing the
+// oldest one when the log is full. Command arguments are truncated.
+func (l *SlowLog) Record(entry types.SlowEntry) {
+	entry.Command = strings.ToUpper(entry.Command)
+	entry.Args = truncateArgs(entry.Args)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lastID++
+	entry.ID = l.lastID
+
+	if cap(l.entries) == 0 {
+		return
+	}
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, entry)
+	} else {
+		l.entries[l.next] = entry
+	}
+	l.next = (l.next + 1) % cap(l.entries)
+}
+
+// Report returns up to limit entries of kind, newest first. An empty kind
+// matches both, and limit 0 returns them all.
+func (l *SlowLog) Report(kind string, limit int) types.SlowLogReport {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entries := make([]types.SlowEntry, 0, len(l.entries))
+	for i := 1; i <= len(l.entries); i++ {
+		entry := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if kind != "" && entry.Kind != kind {
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) == limit {
+			break
+		}
+	}
+
+	return types.SlowLogReport{
+		RequestThresholdMs: float64(l.requestThreshold) / float64(time.Millisecond),
+		CommandThresholdMs: float64(l.commandThreshold) / float64(time.Millisecond),
+		Entries:            entries,
+	}
+}
+
+// Reset drops every entry.
+func (l *SlowLog) Reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.entries = l.entries[:0]
+	l.next = 0
+}
+
+func truncateArgs(args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	truncated := make([]interface{}, 0, min(len(args), slowMaxArgs))
+	for i, arg := range args {
+		if i == slowMaxArgs-1 && len(args) > slowMaxArgs {
+			truncated = append(truncated, fmt.Sprintf("... (%d more arguments)", len(args)-i))
+			break
+		}
+		if s, ok := arg.(string); ok && len(s) > slowMaxArgLen {
+			arg = fmt.Sprintf("%s... (%d more bytes)", s[:slowMaxArgLen], len(s)-slowMaxArgLen)
+		}
+		truncated = append(truncated, arg)
+	}
+	return truncated
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+func TestSlowLogThresholds(t *testing.T) {
+	l := NewSlowLog(types.SlowLogConfig{RequestThreshold: time.Second, CommandThreshold: 10 * time.Millisecond, Size: 4})
+
+	if !l.IsSlow(types.SlowCommand, 20*time.Millisecond) || l.IsSlow(types.SlowRequest, 20*time.Millisecond) {
+		t.Error("Expected each kind to use its own threshold")
+	}
+	if l.IsSlow(types.SlowCommand, 10*time.Millisecond) {
+		t.Error("Expected a command at the threshold not to be slow")
+	}
+}
+
+func TestSlowLogRing(t *testing.T) {
+	l := NewSlowLog(types.SlowLogConfig{RequestThreshold: time.Second, CommandThreshold: time.Millisecond, Size: 3})
+
+	for _, command := range []string{"get", "set", "del", "incr"} {
+		l.Record(types.SlowEntry{Kind: types.SlowCommand, Command: command})
+	}
+	l.Record(types.SlowEntry{Kind: types.SlowRequest, Path: "/v1/pipeline"})
+
+	report := l.Report("", 0)
+	if len(report.Entries) != 3 {
+		t.Fatalf("Expected the 3 newest entries, got %+v", report.Entries)
+	}
+	if report.Entries[0].ID != 5 || report.Entries[0].Kind != types.SlowRequest || report.Entries[2].Command != "DEL" {
+		t.Errorf("Expected newest first, got %+v", report.Entries)
+	}
+	if report.CommandThresholdMs != 1 || report.RequestThresholdMs != 1000 {
+		t.Errorf("Unexpected thresholds %v and %v", report.CommandThresholdMs, report.RequestThresholdMs)
+	}
+
+	commands := l.Report(types.SlowCommand, 1)
+	if len(commands.Entries) != 1 || commands.Entries[0].Command != "INCR" {
+		t.Errorf("Expected the newest command, got %+v", commands.Entries)
+	}
+
+	l.Reset()
+	if entries := l.Report("", 0).Entries; len(entries) != 0 {
+		t.Errorf("Expected no entries after reset, got %+v", entries)
+	}
+}
+
+func TestSlowLogTruncatesArgs(t *testing.T) {
+	l := NewSlowLog(types.SlowLogConfig{Size: 1})
+
+	args := make([]interface{}, 40)
+	for i := range args {
+		args[i] = "field"
+	}
+	args[0] = strings.Repeat("x", 200)
+	l.Record(types.SlowEntry{Kind: types.SlowCommand, Command: "HMGET", Args: args})
+
+	got := l.Report("", 0).Entries[0].Args
+	if len(got) != slowMaxArgs {
+		t.Fatalf("Expected %d args, got %d", slowMaxArgs, len(got))
+	}
+	if first := got[0].(string); !strings.HasSuffix(first, "... (72 more bytes)") {
+		t.Errorf("Expected the long argument truncated, got %q", first)
+	}
+	if last := got[slowMaxArgs-1]; last != "... (9 more arguments)" {
+		t.Errorf("Expected a count of the dropped arguments, got %v", last)
+	}
+}
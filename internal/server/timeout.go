@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader lets clients bound how long a request may run, as
+// milliseconds ("250") or a Go duration ("250ms", "2s").
+const TimeoutHeader = "X-Request-Timeout"
+
+// ParseTimeout parses a TimeoutHeader value.
+func ParseTimeout(value string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(value); err == nil {
+		if ms <= 0 {
+			return 0, errors.New("must be positive")
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, errors.New("must be positive milliseconds or a duration such as 250ms")
+	}
+	return timeout, nil
+}
+
+// WithTimeout derives a context that ends after timeout, capped at max.
+// A zero timeout leaves ctx without a new deadline.
+func WithTimeout(ctx context.Context, timeout, max time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	if max > 0 && timeout > max {
+		timeout = max
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TimeoutMiddleware gives requests carrying X-Request-Timeout a context
+// deadline, capped at max, that Redis commands run under.
+func TimeoutMiddleware(max time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(TimeoutHeader)
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			timeout, err := ParseTimeout(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error": "Invalid %s header", "details": %q}`, TimeoutHeader, err.Error()), http.StatusBadRequest)
+				return
+			}
+
+			ctx, cancel := WithTimeout(r.Context(), timeout, max)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"250", 250 * time.Millisecond, true},
+		{"2s", 2 * time.Second, true},
+		{"0", 0, false},
+		{"-5ms", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		timeout, err := ParseTimeout(tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseTimeout(%q) error = %v, want valid %v", tt.value, err, tt.valid)
+			continue
+		}
+		if timeout != tt.expected {
+			t.Errorf("ParseTimeout(%q) = %v, want %v", tt.value, timeout, tt.expected)
+		}
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	var remaining time.Duration
+	var hasDeadline bool
+	handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/command", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if hasDeadline {
+		t.Error("Expected no deadline without the header")
+	}
+
+	req = httptest.NewRequest("POST", "/v1/command", nil)
+	req.Header.Set(TimeoutHeader, "1m")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !hasDeadline || remaining > time.Second {
+		t.Errorf("Expected deadline capped at 1s, got %v (set %v)", remaining, hasDeadline)
+	}
+
+	req = httptest.NewRequest("POST", "/v1/command", nil)
+	req.Header.Set(TimeoutHeader, "never")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid header, got %d", w.Code)
+	}
+}
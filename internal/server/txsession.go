@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// ErrTxSessionNotFound is returned for transaction sessions that don't
+// exist, belong to another tenant, or were closed.
+var ErrTxSessionNotFound = errors.New("transaction session not found")
+
+// ErrTooManyTxSessions is returned when tx_sessions.max_sessions, or a
+// tenant's max_per_tenant, sessions are open already.
+var ErrTooManyTxSessions = errors.New("too many transaction sessions")
+
+// TxConn is the Redis connection a transaction session holds.
+type TxConn interface {
+	Do(ctx context.Context, req types.CommandRequest) (interface{}, error)
+	Exec(ctx context.Context) (*types.TransactionResponse, error)
+	Discard(ctx context.Context) error
+	Close() error
+}
+
+// TxSession is a transaction spanning requests, on a connection of its
+// own. Requests using it are served one at a time.
+type TxSession struct {
+	ID      string
+	Tenant  string
+	Expires time.Time
+
+	mutex    sync.Mutex
+	conn     TxConn
+	lastUsed time.Time
+	closed   bool
+}
+
+// TxSessions keeps the open transaction sessions, closing those idle for
+// tx_sessions.idle_timeout or open for max_age. Each holds a Redis
+// connection until it is closed, so their number is bounded.
+type TxSessions struct {
+	config types.TxSessionsConfig
+	now    func() time.Time
+
+	mutex     sync.Mutex
+	sessions  map[string]*TxSession
+	perTenant map[string]int
+}
+
+// NewTxSessions creates an empty set of sessions.
+func NewTxSessions(config types.TxSessionsConfig) *TxSessions {
+	return &TxSessions{
+		config:    config,
+		now:       time.Now,
+		sessions:  make(map[string]*TxSession),
+		perTenant: make(map[string]int),
+	}
+}
+
+// Open starts a session for tenant on the connection connect returns,
+// if the limits leave room for one.
+func (s *TxSessions) Open(tenant string, connect func() (TxConn, error)) (*TxSession, error) {
+	// Reserve the slot first, so concurrent opens can't overshoot
+	s.mutex.Lock()
+	if s.config.MaxSessions > 0 && len(s.sessions) >= s.config.MaxSessions {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("%w: %d open", ErrTooManyTxSessions, len(s.sessions))
+	}
+	if s.config.MaxPerTenant > 0 && s.perTenant[tenant] >= s.config.MaxPerTenant {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("%w: tenant has the maximum of %d open", ErrTooManyTxSessions, s.config.MaxPerTenant)
+	}
+	now := s.now()
+	session := &TxSession{ID: newSessionID(), Tenant: tenant, Expires: now.Add(s.config.MaxAge), lastUsed: now}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	s.sessions[session.ID] = session
+	s.perTenant[tenant]++
+	s.mutex.Unlock()
+
+	conn, err := connect()
+	if err != nil {
+		session.closed = true
+		s.forget(session)
+		return nil, err
+	}
+	session.conn = conn
+	return session, nil
+}
+
+// Use runs fn with the connection of tenant's session id. A session that
+// has expired is closed instead.
+func (s *TxSessions) Use(id, tenant string, fn func(conn TxConn) error) error {
+	s.mutex.Lock()
+	session, ok := s.sessions[id]
+	s.mutex.Unlock()
+	if !ok || session.Tenant != tenant {
+		return ErrTxSessionNotFound
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	if session.closed {
+		return ErrTxSessionNotFound
+	}
+	if s.now().After(session.Expires) {
+		s.closeLocked(session)
+		return ErrTxSessionNotFound
+	}
+	err := fn(session.conn)
+	session.lastUsed = s.now()
+	return err
+}
+
+// Close closes tenant's session id, discarding anything queued.
+func (s *TxSessions) Close(id, tenant string) error {
+	s.mutex.Lock()
+	session, ok := s.sessions[id]
+	s.mutex.Unlock()
+	if !ok || session.Tenant != tenant {
+		return ErrTxSessionNotFound
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	if session.closed {
+		return ErrTxSessionNotFound
+	}
+	s.closeLocked(session)
+	return nil
+}
+
+// Len returns the number of open sessions.
+func (s *TxSessions) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.sessions)
+}
+
+// Run closes idle and expired sessions every second until ctx is done,
+// and then every session.
+func (s *TxSessions) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.closeAll()
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// sweep closes the sessions idle for longer than idle_timeout or past
+// max_age. Sessions serving a request are busy, not idle; they are left
+// for the request, or the next sweep.
+func (s *TxSessions) sweep() {
+	now := s.now()
+	for _, session := range s.snapshot() {
+		if !session.mutex.TryLock() {
+			continue
+		}
+		if !session.closed && (now.Sub(session.lastUsed) > s.config.IdleTimeout || now.After(session.Expires)) {
+			s.closeLocked(session)
+		}
+		session.mutex.Unlock()
+	}
+}
+
+func (s *TxSessions) closeAll() {
+	for _, session := range s.snapshot() {
+		session.mutex.Lock()
+		if !session.closed {
+			s.closeLocked(session)
+		}
+		session.mutex.Unlock()
+	}
+}
+
+func (s *TxSessions) snapshot() []*TxSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sessions := make([]*TxSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// closeLocked closes session, whose mutex is held.
+func (s *TxSessions) closeLocked(session *TxSession) {
+	session.closed = true
+	_ = session.conn.Close()
+	s.forget(session)
+}
+
+func (s *TxSessions) forget(session *TxSession) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, session.ID)
+	if s.perTenant[session.Tenant]--; s.perTenant[session.Tenant] <= 0 {
+		delete(s.perTenant, session.Tenant)
+	}
+}
+
+// newSessionID returns an unguessable session token: it is all a client
+// needs to use the session.
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
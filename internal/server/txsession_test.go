@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scaler/serverless-redis/internal/types"
+)
+
+// fakeTxConn counts the commands it runs and whether it was closed.
+type fakeTxConn struct {
+	commands int
+	closed   bool
+}
+
+func (f *fakeTxConn) Do(ctx context.Context, req types.CommandRequest) (interface{}, error) {
+	f.commands++
+	return "OK", nil
+}
+
+func (f *fakeTxConn) Exec(ctx context.Context) (*types.TransactionResponse, error) {
+	return &types.TransactionResponse{Exec: true}, nil
+}
+
+func (f *fakeTxConn) Discard(ctx context.Context) error { return nil }
+
+func (f *fakeTxConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestTxSessions(t *testing.T) {
+	sessions := NewTxSessions(types.TxSessionsConfig{
+		IdleTimeout:  30 * time.Second,
+		MaxAge:       5 * time.Minute,
+		MaxSessions:  3,
+		MaxPerTenant: 2,
+	})
+	now := time.Unix(1700000000, 0)
+	sessions.now = func() time.Time { return now }
+
+	var conns []*fakeTxConn
+	open := func(tenant string) (*TxSession, error) {
+		return sessions.Open(tenant, func() (TxConn, error) {
+			conn := &fakeTxConn{}
+			conns = append(conns, conn)
+			return conn, nil
+		})
+	}
+
+	first, err := open("acme")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := open("acme"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := open("acme"); !errors.Is(err, ErrTooManyTxSessions) {
+		t.Errorf("Expected a third session for acme to be refused, got %v", err)
+	}
+	if _, err := open("globex"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := open("initech"); !errors.Is(err, ErrTooManyTxSessions) {
+		t.Errorf("Expected a fourth session to be refused, got %v", err)
+	}
+
+	// A failed connection frees its slot
+	if _, err := sessions.Open("initech", func() (TxConn, error) { return nil, errors.New("refused") }); err == nil {
+		t.Error("Expected Open() to return the connection error")
+	}
+	if sessions.Len() != 3 {
+		t.Errorf("Expected 3 open sessions, got %d", sessions.Len())
+	}
+
+	// Sessions are only found by their own tenant
+	use := func(conn TxConn) error {
+		_, err := conn.Do(context.Background(), types.CommandRequest{Command: "GET", Args: []interface{}{"k"}})
+		return err
+	}
+	if err := sessions.Use(first.ID, "globex", use); !errors.Is(err, ErrTxSessionNotFound) {
+		t.Errorf("Expected another tenant's session not to be found, got %v", err)
+	}
+	if err := sessions.Close(first.ID, "globex"); !errors.Is(err, ErrTxSessionNotFound) {
+		t.Errorf("Expected another tenant not to close the session, got %v", err)
+	}
+	if err := sessions.Use(first.ID, "acme", use); err != nil || conns[0].commands != 1 {
+		t.Fatalf("Expected the command to run on the session, got %v", err)
+	}
+
+	// Sessions idle past idle_timeout are closed; the one just used isn't
+	now = now.Add(20 * time.Second)
+	if err := sessions.Use(first.ID, "acme", use); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	sessions.sweep()
+	if sessions.Len() != 1 || conns[0].closed || !conns[1].closed || !conns[2].closed {
+		t.Errorf("Expected only the session in use to stay open, %d open", sessions.Len())
+	}
+
+	// Sessions in use are closed at max_age all the same
+	now = now.Add(5 * time.Minute)
+	if err := sessions.Use(first.ID, "acme", use); !errors.Is(err, ErrTxSessionNotFound) || !conns[0].closed {
+		t.Errorf("Expected the session to be closed at max_age, got %v", err)
+	}
+	if sessions.Len() != 0 {
+		t.Errorf("Expected no open sessions, got %d", sessions.Len())
+	}
+
+	// Closed sessions make room for new ones
+	second, err := open("acme")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := sessions.Close(second.ID, "acme"); err != nil || !conns[3].closed {
+		t.Errorf("Expected Close() to close the connection, got %v", err)
+	}
+	if err := sessions.Close(second.ID, "acme"); !errors.Is(err, ErrTxSessionNotFound) {
+		t.Errorf("Expected a closed session not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// argKind is what a command takes at one of its argument positions. It
+// decides how a JSON number or boolean given there is sent to Redis.
+type argKind byte
+
+const (
+	argAny   argKind = iota // strings, numbers and booleans
+	argInt                  // integers, and booleans as 1 and 0
+	argFloat                // numbers
+)
+
+// argSignature describes the arguments of a command for coercion.
+type argSignature struct {
+	// args are the kinds of the leading arguments, counted after nested
+	// arrays are flattened; any past them are argAny
+	args []argKind
+
+	// pairs is set for commands ending in field/value pairs, which may
+	// then be given as a JSON object
+	pairs bool
+}
+
+func (s argSignature) kind(pos int) argKind {
+	if pos < len(s.args) {
+		return s.args[pos]
+	}
+	return argAny
+}
+
+var (
+	keyInt      = argSignature{args: []argKind{argAny, argInt}}
+	keyIntInt   = argSignature{args: []argKind{argAny, argInt, argInt}}
+	keyFloat    = argSignature{args: []argKind{argAny, argFloat}}
+	keyScores   = argSignature{args: []argKind{argAny, argFloat, argFloat}}
+	fieldValues = argSignature{pairs: true}
+)
+
+// argSignatures are the commands whose arguments are coerced by position.
+// Arguments of other commands are coerced as argAny.
+var argSignatures = map[string]argSignature{
+	"INCRBY":           keyInt,
+	"DECRBY":           keyInt,
+	"INCRBYFLOAT":      keyFloat,
+	"HINCRBY":          {args: []argKind{argAny, argAny, argInt}},
+	"HINCRBYFLOAT":     {args: []argKind{argAny, argAny, argFloat}},
+	"ZINCRBY":          keyFloat,
+	"EXPIRE":           keyInt,
+	"PEXPIRE":          keyInt,
+	"EXPIREAT":         keyInt,
+	"PEXPIREAT":        keyInt,
+	"SETEX":            keyInt,
+	"PSETEX":           keyInt,
+	"GETRANGE":         keyIntInt,
+	"SETRANGE":         keyInt,
+	"SETBIT":           keyIntInt,
+	"GETBIT":           keyInt,
+	"LRANGE":           keyIntInt,
+	"LTRIM":            keyIntInt,
+	"LINDEX":           keyInt,
+	"LSET":             keyInt,
+	"LREM":             keyInt,
+	"LPOP":             keyInt,
+	"RPOP":             keyInt,
+	"SPOP":             keyInt,
+	"SRANDMEMBER":      keyInt,
+	"ZREMRANGEBYRANK":  keyIntInt,
+	"ZCOUNT":           keyScores,
+	"ZRANGEBYSCORE":    keyScores,
+	"ZREVRANGEBYSCORE": keyScores,
+	"ZREMRANGEBYSCORE": keyScores,
+	"HSET":             fieldValues,
+	"HMSET":            fieldValues,
+	"MSET":             fieldValues,
+	"MSETNX":           fieldValues,
+	"XADD":             fieldValues,
+}
+
+// maxExactInt is the largest magnitude up to which every integer decoded
+// from a JSON number into a float64 is exact.
+const maxExactInt = 1 << 53
+
+// coerceArgs returns args as the strings Redis is sent, so that keys,
+// cache entries and the op log see the arguments Redis does. Numbers are
+// written in full, never with an exponent, and booleans as 1 and 0.
+// Arrays are flattened into the arguments around them, and objects into
+// sorted field/value pairs for commands taking pairs. Anything else,
+// such as null, is an error naming the argument at field. args is
+// coerced in place unless it has to grow.
+func coerceArgs(command string, args []interface{}, field string) ([]interface{}, error) {
+	upper := strings.ToUpper(command)
+	signature := argSignatures[upper]
+
+	coerced := args[:0]
+	if slices.ContainsFunc(args, isNestedArg) {
+		coerced = make([]interface{}, 0, len(args))
+	}
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case []interface{}:
+			for j, item := range v {
+				s, err := coerceArg(upper, item, signature.kind(len(coerced)))
+				if err != nil {
+					return nil, argError(fmt.Sprintf("%s[%d][%d]", field, i, j), err)
+				}
+				coerced = append(coerced, s)
+			}
+		case map[string]interface{}:
+			if !signature.pairs {
+				return nil, NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("%s[%d]", field, i),
+					"%s doesn't take field/value pairs, so objects aren't accepted", upper)
+			}
+			fields := make([]string, 0, len(v))
+			for name := range v {
+				fields = append(fields, name)
+			}
+			slices.Sort(fields)
+			for _, name := range fields {
+				s, err := coerceArg(upper, v[name], argAny)
+				if err != nil {
+					return nil, argError(fmt.Sprintf("%s[%d].%s", field, i, name), err)
+				}
+				coerced = append(coerced, name, s)
+			}
+		default:
+			s, err := coerceArg(upper, arg, signature.kind(len(coerced)))
+			if err != nil {
+				return nil, argError(fmt.Sprintf("%s[%d]", field, i), err)
+			}
+			coerced = append(coerced, s)
+		}
+	}
+	return coerced, nil
+}
+
+func isNestedArg(arg interface{}) bool {
+	switch arg.(type) {
+	case []interface{}, map[string]interface{}:
+		return true
+	}
+	return false
+}
+
+func argError(field string, err error) error {
+	return NewValidationError(ErrCodeInvalidArgument, field, "%s", err.Error())
+}
+
+// coerceArg returns a single argument of command, of the given kind, as
+// a string.
+func coerceArg(command string, arg interface{}, kind argKind) (string, error) {
+	switch v := arg.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return coerceNumber(command, v, kind)
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		if kind == argFloat {
+			return "", fmt.Errorf("%s takes a number here, got %t", command, v)
+		}
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case nil:
+		return "", errors.New("null is not a Redis argument; send an empty string instead")
+	case []interface{}:
+		return "", errors.New("arrays may only be nested one level deep")
+	case map[string]interface{}:
+		return "", errors.New("objects may not be nested in arrays")
+	}
+	return "", fmt.Errorf("arguments must be strings, numbers or booleans, got %T", arg)
+}
+
+// coerceNumber formats a JSON number. Whole numbers are integers, unless
+// too large for the float64 they were decoded into to hold exactly.
+func coerceNumber(command string, f float64, kind argKind) (string, error) {
+	whole := f == math.Trunc(f)
+	switch {
+	case kind == argFloat:
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case whole && math.Abs(f) <= maxExactInt:
+		return strconv.FormatInt(int64(f), 10), nil
+	case whole:
+		return "", fmt.Errorf("%s is too large to be sent exactly as a JSON number; send it as a string",
+			strconv.FormatFloat(f, 'g', -1, 64))
+	case kind == argInt:
+		return "", fmt.Errorf("%s takes an integer here, got %s", command, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
@@ -0,0 +1,224 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// commandSignature is the shape of a command as Redis's COMMAND INFO
+// describes it. arity counts the command name; a negative arity is a
+// minimum. Keys are the arguments from firstKey to lastKey, step apart,
+// counting the command name as 0; a negative lastKey counts from the end,
+// and a firstKey of 0 means the command takes no keys at fixed positions.
+// CommandKeys finds keys, for sharding among others, from these.
+type commandSignature struct {
+	arity    int
+	firstKey int
+	lastKey  int
+	step     int
+}
+
+// commandSignatures are the commands the proxy knows, checked before they
+// are sent to Redis.
+var commandSignatures = map[string]commandSignature{
+	// Strings
+	"GET": {2, 1, 1, 1}, "SET": {-3, 1, 1, 1}, "SETNX": {3, 1, 1, 1}, "SETEX": {4, 1, 1, 1},
+	"PSETEX": {4, 1, 1, 1}, "MSET": {-3, 1, -1, 2}, "MSETNX": {-3, 1, -1, 2}, "MGET": {-2, 1, -1, 1},
+	"GETSET": {3, 1, 1, 1}, "GETDEL": {2, 1, 1, 1}, "GETEX": {-2, 1, 1, 1}, "APPEND": {3, 1, 1, 1},
+	"SETRANGE": {4, 1, 1, 1}, "GETRANGE": {4, 1, 1, 1}, "SUBSTR": {4, 1, 1, 1}, "STRLEN": {2, 1, 1, 1},
+	"LCS": {-3, 1, 2, 1}, "INCR": {2, 1, 1, 1}, "INCRBY": {3, 1, 1, 1}, "INCRBYFLOAT": {3, 1, 1, 1},
+	"DECR": {2, 1, 1, 1}, "DECRBY": {3, 1, 1, 1},
+
+	// Keys
+	"DEL": {-2, 1, -1, 1}, "UNLINK": {-2, 1, -1, 1}, "EXISTS": {-2, 1, -1, 1}, "TOUCH": {-2, 1, -1, 1},
+	"TYPE": {2, 1, 1, 1}, "TTL": {2, 1, 1, 1}, "PTTL": {2, 1, 1, 1}, "EXPIRETIME": {2, 1, 1, 1},
+	"PEXPIRETIME": {2, 1, 1, 1}, "EXPIRE": {-3, 1, 1, 1}, "PEXPIRE": {-3, 1, 1, 1}, "EXPIREAT": {-3, 1, 1, 1},
+	"PEXPIREAT": {-3, 1, 1, 1}, "PERSIST": {2, 1, 1, 1}, "RENAME": {3, 1, 2, 1}, "RENAMENX": {3, 1, 2, 1},
+	"COPY": {-3, 1, 2, 1}, "MOVE": {3, 1, 1, 1}, "RESTORE": {-4, 1, 1, 1}, "DUMP": {2, 1, 1, 1},
+	"SORT": {-2, 1, 1, 1}, "SORT_RO": {-2, 1, 1, 1}, "OBJECT": {-2, 0, 0, 0}, "KEYS": {2, 0, 0, 0},
+	"SCAN": {-2, 0, 0, 0}, "RANDOMKEY": {1, 0, 0, 0}, "DBSIZE": {1, 0, 0, 0}, "FLUSHDB": {-1, 0, 0, 0},
+	"FLUSHALL": {-1, 0, 0, 0}, "WAIT": {3, 0, 0, 0},
+
+	// Hashes
+	"HSET": {-4, 1, 1, 1}, "HSETNX": {4, 1, 1, 1}, "HMSET": {-4, 1, 1, 1}, "HGET": {3, 1, 1, 1},
+	"HMGET": {-3, 1, 1, 1}, "HGETALL": {2, 1, 1, 1}, "HKEYS": {2, 1, 1, 1}, "HVALS": {2, 1, 1, 1},
+	"HLEN": {2, 1, 1, 1}, "HEXISTS": {3, 1, 1, 1}, "HSTRLEN": {3, 1, 1, 1}, "HDEL": {-3, 1, 1, 1},
+	"HINCRBY": {4, 1, 1, 1}, "HINCRBYFLOAT": {4, 1, 1, 1}, "HSCAN": {-3, 1, 1, 1}, "HRANDFIELD": {-2, 1, 1, 1},
+
+	// Lists
+	"LPUSH": {-3, 1, 1, 1}, "RPUSH": {-3, 1, 1, 1}, "LPUSHX": {-3, 1, 1, 1}, "RPUSHX": {-3, 1, 1, 1},
+	"LPOP": {-2, 1, 1, 1}, "RPOP": {-2, 1, 1, 1}, "LRANGE": {4, 1, 1, 1}, "LINDEX": {3, 1, 1, 1},
+	"LLEN": {2, 1, 1, 1}, "LPOS": {-3, 1, 1, 1}, "LSET": {4, 1, 1, 1}, "LINSERT": {5, 1, 1, 1},
+	"LREM": {4, 1, 1, 1}, "LTRIM": {4, 1, 1, 1}, "LMOVE": {5, 1, 2, 1}, "BLMOVE": {6, 1, 2, 1},
+	"RPOPLPUSH": {3, 1, 2, 1}, "BRPOPLPUSH": {4, 1, 2, 1}, "BLPOP": {-3, 1, -2, 1}, "BRPOP": {-3, 1, -2, 1},
+	"LMPOP": {-4, 0, 0, 0}, "BLMPOP": {-5, 0, 0, 0},
+
+	// Sets
+	"SADD": {-3, 1, 1, 1}, "SREM": {-3, 1, 1, 1}, "SMEMBERS": {2, 1, 1, 1}, "SISMEMBER": {3, 1, 1, 1},
+	"SMISMEMBER": {-3, 1, 1, 1}, "SCARD": {2, 1, 1, 1}, "SPOP": {-2, 1, 1, 1}, "SRANDMEMBER": {-2, 1, 1, 1},
+	"SMOVE": {4, 1, 2, 1}, "SINTER": {-2, 1, -1, 1}, "SUNION": {-2, 1, -1, 1}, "SDIFF": {-2, 1, -1, 1},
+	"SINTERSTORE": {-3, 1, -1, 1}, "SUNIONSTORE": {-3, 1, -1, 1}, "SDIFFSTORE": {-3, 1, -1, 1},
+	"SINTERCARD": {-3, 0, 0, 0}, "SSCAN": {-3, 1, 1, 1},
+
+	// Sorted sets
+	"ZADD": {-4, 1, 1, 1}, "ZREM": {-3, 1, 1, 1}, "ZINCRBY": {4, 1, 1, 1}, "ZSCORE": {3, 1, 1, 1},
+	"ZMSCORE": {-3, 1, 1, 1}, "ZRANK": {-3, 1, 1, 1}, "ZREVRANK": {-3, 1, 1, 1}, "ZCARD": {2, 1, 1, 1},
+	"ZCOUNT": {4, 1, 1, 1}, "ZLEXCOUNT": {4, 1, 1, 1}, "ZRANGE": {-4, 1, 1, 1}, "ZRANGEBYSCORE": {-4, 1, 1, 1},
+	"ZREVRANGEBYSCORE": {-4, 1, 1, 1}, "ZRANGEBYLEX": {-4, 1, 1, 1}, "ZREVRANGEBYLEX": {-4, 1, 1, 1},
+	"ZREVRANGE": {-4, 1, 1, 1}, "ZRANGESTORE": {-5, 1, 2, 1}, "ZREMRANGEBYSCORE": {4, 1, 1, 1},
+	"ZREMRANGEBYRANK": {4, 1, 1, 1}, "ZREMRANGEBYLEX": {4, 1, 1, 1}, "ZPOPMIN": {-2, 1, 1, 1},
+	"ZPOPMAX": {-2, 1, 1, 1}, "BZPOPMIN": {-3, 1, -2, 1}, "BZPOPMAX": {-3, 1, -2, 1},
+	"ZRANDMEMBER": {-2, 1, 1, 1}, "ZSCAN": {-3, 1, 1, 1}, "ZUNION": {-3, 0, 0, 0}, "ZINTER": {-3, 0, 0, 0},
+	"ZDIFF": {-3, 0, 0, 0}, "ZINTERCARD": {-3, 0, 0, 0}, "ZUNIONSTORE": {-4, 1, 1, 1},
+	"ZINTERSTORE": {-4, 1, 1, 1}, "ZDIFFSTORE": {-4, 1, 1, 1}, "ZMPOP": {-4, 0, 0, 0}, "BZMPOP": {-5, 0, 0, 0},
+
+	// HyperLogLogs and bitmaps
+	"PFADD": {-2, 1, 1, 1}, "PFCOUNT": {-2, 1, -1, 1}, "PFMERGE": {-2, 1, -1, 1},
+	"SETBIT": {4, 1, 1, 1}, "GETBIT": {3, 1, 1, 1}, "BITCOUNT": {-2, 1, 1, 1}, "BITPOS": {-3, 1, 1, 1},
+	"BITOP": {-4, 2, -1, 1}, "BITFIELD": {-2, 1, 1, 1}, "BITFIELD_RO": {-2, 1, 1, 1},
+
+	// Geo sets
+	"GEOADD": {-5, 1, 1, 1}, "GEOPOS": {-2, 1, 1, 1}, "GEODIST": {-4, 1, 1, 1}, "GEOHASH": {-2, 1, 1, 1},
+	"GEOSEARCH": {-7, 1, 1, 1}, "GEOSEARCHSTORE": {-8, 1, 2, 1}, "GEORADIUS": {-6, 1, 1, 1},
+	"GEORADIUS_RO": {-6, 1, 1, 1}, "GEORADIUSBYMEMBER": {-5, 1, 1, 1}, "GEORADIUSBYMEMBER_RO": {-5, 1, 1, 1},
+
+	// Streams
+	"XADD": {-5, 1, 1, 1}, "XDEL": {-3, 1, 1, 1}, "XTRIM": {-4, 1, 1, 1}, "XLEN": {2, 1, 1, 1},
+	"XRANGE": {-4, 1, 1, 1}, "XREVRANGE": {-4, 1, 1, 1}, "XREAD": {-4, 0, 0, 0}, "XREADGROUP": {-7, 0, 0, 0},
+	"XGROUP": {-2, 0, 0, 0}, "XACK": {-4, 1, 1, 1}, "XCLAIM": {-6, 1, 1, 1}, "XAUTOCLAIM": {-6, 1, 1, 1},
+	"XPENDING": {-3, 1, 1, 1}, "XINFO": {-2, 0, 0, 0}, "XSETID": {-3, 1, 1, 1},
+
+	// Pub/sub
+	"PUBLISH": {3, 0, 0, 0}, "SPUBLISH": {3, 1, 1, 1}, "PUBSUB": {-2, 0, 0, 0},
+	"SUBSCRIBE": {-2, 0, 0, 0}, "PSUBSCRIBE": {-2, 0, 0, 0}, "SSUBSCRIBE": {-2, 1, -1, 1},
+	"UNSUBSCRIBE": {-1, 0, 0, 0}, "PUNSUBSCRIBE": {-1, 0, 0, 0}, "SUNSUBSCRIBE": {-1, 1, -1, 1},
+
+	// Scripting and transactions
+	"EVAL": {-3, 0, 0, 0}, "EVALSHA": {-3, 0, 0, 0}, "EVAL_RO": {-3, 0, 0, 0}, "EVALSHA_RO": {-3, 0, 0, 0},
+	"FCALL": {-3, 0, 0, 0}, "FCALL_RO": {-3, 0, 0, 0}, "SCRIPT": {-2, 0, 0, 0}, "FUNCTION": {-2, 0, 0, 0},
+	"MULTI": {1, 0, 0, 0}, "EXEC": {1, 0, 0, 0}, "DISCARD": {1, 0, 0, 0}, "WATCH": {-2, 1, -1, 1},
+	"UNWATCH": {1, 0, 0, 0},
+
+	// Connection and server
+	"PING": {-1, 0, 0, 0}, "ECHO": {2, 0, 0, 0}, "TIME": {1, 0, 0, 0}, "SELECT": {2, 0, 0, 0},
+	"SWAPDB": {3, 0, 0, 0}, "AUTH": {-2, 0, 0, 0}, "HELLO": {-1, 0, 0, 0}, "RESET": {1, 0, 0, 0},
+	"QUIT": {-1, 0, 0, 0}, "CLIENT": {-2, 0, 0, 0}, "INFO": {-1, 0, 0, 0}, "CONFIG": {-2, 0, 0, 0},
+	"COMMAND": {-1, 0, 0, 0}, "LASTSAVE": {1, 0, 0, 0}, "SAVE": {1, 0, 0, 0}, "BGSAVE": {-1, 0, 0, 0},
+	"BGREWRITEAOF": {1, 0, 0, 0}, "MEMORY": {-2, 0, 0, 0}, "LATENCY": {-2, 0, 0, 0}, "SLOWLOG": {-2, 0, 0, 0},
+	"ACL": {-2, 0, 0, 0}, "MODULE": {-2, 0, 0, 0}, "ROLE": {1, 0, 0, 0}, "MONITOR": {1, 0, 0, 0},
+	"SYNC": {1, 0, 0, 0}, "PSYNC": {-3, 0, 0, 0}, "REPLICAOF": {3, 0, 0, 0}, "CLUSTER": {-2, 0, 0, 0},
+	"READONLY": {1, 0, 0, 0}, "READWRITE": {1, 0, 0, 0}, "LOLWUT": {-1, 0, 0, 0},
+
+	// Modules: search, Bloom filters, JSON and vector sets
+	"FT.SEARCH": {-3, 0, 0, 0}, "FT.AGGREGATE": {-3, 0, 0, 0}, "FT.CREATE": {-3, 0, 0, 0},
+	"FT.DROPINDEX": {-2, 0, 0, 0}, "FT.INFO": {2, 0, 0, 0}, "FT._LIST": {1, 0, 0, 0},
+	"BF.ADD": {3, 1, 1, 1}, "BF.EXISTS": {3, 1, 1, 1}, "BF.MADD": {-3, 1, 1, 1}, "BF.MEXISTS": {-3, 1, 1, 1},
+	"BF.INSERT": {-4, 1, 1, 1}, "BF.RESERVE": {-4, 1, 1, 1}, "BF.CARD": {2, 1, 1, 1}, "BF.INFO": {-2, 1, 1, 1},
+	"JSON.GET": {-2, 1, 1, 1}, "JSON.SET": {-4, 1, 1, 1}, "JSON.MGET": {-3, 1, -2, 1}, "JSON.MSET": {-4, 1, -1, 3},
+	"JSON.TYPE": {-2, 1, 1, 1}, "JSON.STRLEN": {-2, 1, 1, 1}, "JSON.ARRLEN": {-2, 1, 1, 1},
+	"JSON.OBJKEYS": {-2, 1, 1, 1}, "JSON.MERGE": {4, 1, 1, 1}, "JSON.DEL": {-2, 1, 1, 1},
+	"JSON.NUMINCRBY": {4, 1, 1, 1}, "JSON.ARRAPPEND": {-4, 1, 1, 1},
+	"VADD": {-5, 1, 1, 1}, "VREM": {3, 1, 1, 1}, "VSIM": {-4, 1, 1, 1}, "VSETATTR": {4, 1, 1, 1},
+	"VGETATTR": {3, 1, 1, 1}, "VCARD": {2, 1, 1, 1}, "VDIM": {2, 1, 1, 1}, "VEMB": {-3, 1, 1, 1},
+	"VINFO": {2, 1, 1, 1},
+}
+
+// numKeysArgs are the commands naming their keys with a numkeys argument,
+// mapped to its position, counting the command name as 0.
+var numKeysArgs = map[string]int{
+	"EVAL": 2, "EVALSHA": 2, "EVAL_RO": 2, "EVALSHA_RO": 2, "FCALL": 2, "FCALL_RO": 2,
+	"ZUNION": 1, "ZINTER": 1, "ZDIFF": 1, "ZINTERCARD": 1, "SINTERCARD": 1, "LMPOP": 1, "ZMPOP": 1,
+	"ZUNIONSTORE": 2, "ZINTERSTORE": 2, "ZDIFFSTORE": 2, "BLMPOP": 2, "BZMPOP": 2,
+}
+
+// CommandKeys returns the keys of command, given its args: those at the
+// positions the command table gives, then those counted by a numkeys
+// argument. ok is false for commands missing from the table.
+func CommandKeys(command string, args []string) (keys []string, ok bool) {
+	command = strings.ToUpper(command)
+	signature, ok := commandSignatures[command]
+	if !ok {
+		return nil, false
+	}
+
+	if signature.firstKey > 0 {
+		last := signature.lastKey
+		if last < 0 {
+			last += len(args) + 1
+		}
+		for i := signature.firstKey; i <= min(last, len(args)); i += signature.step {
+			keys = append(keys, args[i-1])
+		}
+	}
+	if at, found := numKeysArgs[command]; found && at <= len(args) {
+		if n, err := strconv.Atoi(args[at-1]); err == nil && n > 0 {
+			keys = append(keys, args[at:min(at+n, len(args))]...)
+		}
+	}
+	return keys, true
+}
+
+// IsKnownCommand reports whether command is in the proxy's command table.
+func IsKnownCommand(command string) bool {
+	_, ok := commandSignatures[strings.ToUpper(command)]
+	return ok
+}
+
+// checkSignature checks the arity of cmd, whose arguments are coerced:
+// its argument count, that keys taken in groups come in whole groups, and
+// that a numkeys argument is a count the arguments after it can cover.
+// Commands missing from the table are rejected unless limits allow
+// unknown commands.
+func checkSignature(cmd *CommandRequest, prefix string, limits ValidationLimits) error {
+	command := strings.ToUpper(cmd.Command)
+	signature, ok := commandSignatures[command]
+	if !ok {
+		if limits.AllowUnknownCommands {
+			return nil
+		}
+		return NewValidationError(ErrCodeUnknownCommand, prefix+"command", "unknown command %s", command)
+	}
+
+	argc := len(cmd.Args) + 1
+	switch {
+	case signature.arity > 0 && argc != signature.arity:
+		return NewValidationError(ErrCodeWrongArity, prefix+"args",
+			"%s takes %s, got %d", command, arguments(signature.arity-1), len(cmd.Args))
+	case signature.arity < 0 && argc < -signature.arity:
+		return NewValidationError(ErrCodeWrongArity, prefix+"args",
+			"%s takes at least %s, got %d", command, arguments(-signature.arity-1), len(cmd.Args))
+	}
+
+	// Keys running to the end in groups, such as MSET's key/value pairs,
+	// must leave none short
+	if signature.lastKey < 0 && signature.step > 1 && (argc-signature.firstKey)%signature.step != 0 {
+		return NewValidationError(ErrCodeWrongArity, prefix+"args",
+			"%s takes keys in groups of %d arguments, got %d from the first key",
+			command, signature.step, argc-signature.firstKey)
+	}
+
+	if at, ok := numKeysArgs[command]; ok {
+		n, err := strconv.Atoi(fmt.Sprint(cmd.Args[at-1]))
+		if err != nil || n < 0 {
+			return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("%sargs[%d]", prefix, at-1),
+				"%s takes the number of keys here, got %v", command, cmd.Args[at-1])
+		}
+		if n > argc-at-1 {
+			return NewValidationError(ErrCodeWrongArity, prefix+"args",
+				"%s names %d key(s), more than the %d argument(s) after the number", command, n, argc-at-1)
+		}
+	}
+
+	return nil
+}
+
+func arguments(n int) string {
+	switch n {
+	case 0:
+		return "no arguments"
+	case 1:
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
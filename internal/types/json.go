@@ -0,0 +1,504 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DecodeCommandRequest decodes a /v1/command body into req without
+// reflection. req is reset first, but keeps the array behind req.Args so a
+// reused request doesn't allocate a new one. Bodies outside the common
+// shape, such as differently cased or unknown fields, null fields, or
+// nested args, are decoded by encoding/json instead, so the result and any
+// error are always the ones a json.Decoder would give.
+func DecodeCommandRequest(data []byte, req *CommandRequest) error {
+	*req = CommandRequest{Args: req.Args[:0]}
+	d := commandDecoder{data: data}
+	if d.request(req) {
+		return nil
+	}
+
+	*req = CommandRequest{Args: req.Args[:0]}
+	return json.NewDecoder(bytes.NewReader(data)).Decode(req)
+}
+
+// commandDecoder is the fast path of DecodeCommandRequest. Its methods
+// return false for anything they don't handle.
+type commandDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *commandDecoder) request(req *CommandRequest) bool {
+	if !d.consume('{') {
+		return false
+	}
+	if d.consume('}') {
+		return true
+	}
+
+	for {
+		key, ok := d.key()
+		if !ok || !d.consume(':') {
+			return false
+		}
+
+		switch key {
+		case "command":
+			ok = d.stringInto(&req.Command)
+		case "args":
+			req.Args, ok = d.args(req.Args[:0])
+		case "db":
+			ok = d.intInto(&req.DB)
+		case "hash_format":
+			var format string
+			ok = d.stringInto(&format)
+			req.HashFormat = HashFormat(format)
+		case "database":
+			ok = d.stringInto(&req.Database)
+		case "timeout_ms":
+			ok = d.intInto(&req.TimeoutMs)
+		case "resp3":
+			ok = d.boolInto(&req.RESP3)
+		case "max_items":
+			ok = d.intInto(&req.MaxItems)
+		default:
+			// Including fields, which is rare enough to leave to encoding/json
+			return false
+		}
+		if !ok {
+			return false
+		}
+
+		if d.consume('}') {
+			return true
+		}
+		if !d.consume(',') {
+			return false
+		}
+	}
+}
+
+// args decodes an array of strings, numbers, booleans and nulls.
+func (d *commandDecoder) args(args []interface{}) ([]interface{}, bool) {
+	if !d.consume('[') {
+		return args, false
+	}
+	if d.consume(']') {
+		return args, true
+	}
+
+	for {
+		d.skipSpace()
+		if d.pos >= len(d.data) {
+			return args, false
+		}
+
+		switch c := d.data[d.pos]; {
+		case c == '"':
+			s, ok := d.string()
+			if !ok {
+				return args, false
+			}
+			args = append(args, s)
+		case c == '-' || c >= '0' && c <= '9':
+			number, ok := d.number()
+			if !ok {
+				return args, false
+			}
+			f, err := strconv.ParseFloat(string(number), 64)
+			if err != nil {
+				return args, false
+			}
+			args = append(args, f)
+		case d.literal("true"):
+			args = append(args, true)
+		case d.literal("false"):
+			args = append(args, false)
+		case d.literal("null"):
+			args = append(args, nil)
+		default:
+			return args, false
+		}
+
+		if d.consume(']') {
+			return args, true
+		}
+		if !d.consume(',') {
+			return args, false
+		}
+	}
+}
+
+func (d *commandDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips whitespace and then c, if it is next.
+func (d *commandDecoder) consume(c byte) bool {
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// literal consumes word if it is next.
+func (d *commandDecoder) literal(word string) bool {
+	if bytes.HasPrefix(d.data[d.pos:], []byte(word)) {
+		d.pos += len(word)
+		return true
+	}
+	return false
+}
+
+// key returns the next object key, which must be plain ASCII.
+func (d *commandDecoder) key() (string, bool) {
+	if !d.consume('"') {
+		return "", false
+	}
+	start := d.pos
+	for d.pos < len(d.data) {
+		c := d.data[d.pos]
+		if c == '"' {
+			d.pos++
+			return string(d.data[start : d.pos-1]), true
+		}
+		if c == '\\' || c < 0x20 || c >= utf8.RuneSelf {
+			return "", false
+		}
+		d.pos++
+	}
+	return "", false
+}
+
+// string returns the string starting at the current position. Strings
+// with escapes are unquoted by encoding/json.
+func (d *commandDecoder) string() (string, bool) {
+	start := d.pos
+	d.pos++
+	escaped := false
+	for d.pos < len(d.data) {
+		switch c := d.data[d.pos]; {
+		case c == '"':
+			d.pos++
+			raw := d.data[start+1 : d.pos-1]
+			if !escaped {
+				if !utf8.Valid(raw) {
+					return "", false
+				}
+				return string(raw), true
+			}
+			var s string
+			if err := json.Unmarshal(d.data[start:d.pos], &s); err != nil {
+				return "", false
+			}
+			return s, true
+		case c == '\\':
+			escaped = true
+			d.pos += 2
+		case c < 0x20:
+			return "", false
+		default:
+			d.pos++
+		}
+	}
+	return "", false
+}
+
+func (d *commandDecoder) stringInto(s *string) bool {
+	d.skipSpace()
+	if d.pos >= len(d.data) || d.data[d.pos] != '"' {
+		return false
+	}
+	value, ok := d.string()
+	if ok {
+		*s = value
+	}
+	return ok
+}
+
+// number returns the JSON number at the current position.
+func (d *commandDecoder) number() ([]byte, bool) {
+	start := d.pos
+	if d.data[d.pos] == '-' {
+		d.pos++
+	}
+	integer := d.pos
+	if !d.digits() || d.data[integer] == '0' && d.pos-integer > 1 {
+		// No digits, or leading zeros
+		return nil, false
+	}
+	if d.pos < len(d.data) && d.data[d.pos] == '.' {
+		d.pos++
+		if !d.digits() {
+			return nil, false
+		}
+	}
+	if d.pos < len(d.data) && (d.data[d.pos] == 'e' || d.data[d.pos] == 'E') {
+		d.pos++
+		if d.pos < len(d.data) && (d.data[d.pos] == '+' || d.data[d.pos] == '-') {
+			d.pos++
+		}
+		if !d.digits() {
+			return nil, false
+		}
+	}
+	return d.data[start:d.pos], true
+}
+
+// digits consumes a run of digits, returning false if there are none.
+func (d *commandDecoder) digits() bool {
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9' {
+		d.pos++
+	}
+	return d.pos > start
+}
+
+// intInto decodes an integer; numbers with a fraction or exponent are
+// left to encoding/json, which rejects them for int fields.
+func (d *commandDecoder) intInto(n *int) bool {
+	d.skipSpace()
+	if d.pos >= len(d.data) {
+		return false
+	}
+	number, ok := d.number()
+	if !ok {
+		return false
+	}
+	value, err := strconv.Atoi(string(number))
+	if err != nil {
+		return false
+	}
+	*n = value
+	return true
+}
+
+func (d *commandDecoder) boolInto(b *bool) bool {
+	d.skipSpace()
+	switch {
+	case d.literal("true"):
+		*b = true
+	case d.literal("false"):
+		*b = false
+	default:
+		return false
+	}
+	return true
+}
+
+// AppendJSON appends r encoded as encoding/json would, without the
+// trailing newline of a json.Encoder. Results are encoded without
+// reflection when they are made of the values Redis replies decode to;
+// others go through encoding/json.
+func (r CommandResponse) AppendJSON(dst []byte) ([]byte, error) {
+	if encoded, ok := r.appendJSON(dst); ok {
+		return encoded, nil
+	}
+	encoded, err := json.Marshal(r)
+	return append(dst, encoded...), err
+}
+
+func (r CommandResponse) appendJSON(dst []byte) ([]byte, bool) {
+	ok := true
+	dst = append(dst, '{')
+	if r.Result != nil {
+		dst = append(dst, `"result":`...)
+		dst, ok = appendValue(dst, r.Result)
+		dst = append(dst, ',')
+	}
+	if r.Error != "" {
+		dst = append(dst, `"error":`...)
+		dst = appendString(dst, r.Error, &ok)
+		dst = append(dst, ',')
+	}
+	if r.Code != "" {
+		dst = append(dst, `"code":`...)
+		dst = appendString(dst, r.Code, &ok)
+		dst = append(dst, ',')
+	}
+	if r.RedisCode != "" {
+		dst = append(dst, `"redis_code":`...)
+		dst = appendString(dst, r.RedisCode, &ok)
+		dst = append(dst, ',')
+	}
+	dst = append(dst, `"type":`...)
+	dst = appendString(dst, r.Type, &ok)
+	dst = append(dst, `,"time":`...)
+	dst = appendFloat(dst, r.Time, &ok)
+	return append(dst, '}'), ok
+}
+
+// appendValue appends v if it is one of the types a command result is
+// made of, returning false otherwise.
+func appendValue(dst []byte, v interface{}) ([]byte, bool) {
+	ok := true
+	switch v := v.(type) {
+	case nil:
+		dst = append(dst, "null"...)
+	case string:
+		dst = appendString(dst, v, &ok)
+	case int64:
+		dst = strconv.AppendInt(dst, v, 10)
+	case int:
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	case float64:
+		dst = appendFloat(dst, v, &ok)
+	case bool:
+		dst = strconv.AppendBool(dst, v)
+	case []interface{}:
+		if v == nil {
+			return append(dst, "null"...), true
+		}
+		dst = append(dst, '[')
+		for i := 0; i < len(v) && ok; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst, ok = appendValue(dst, v[i])
+		}
+		dst = append(dst, ']')
+	case []string:
+		if v == nil {
+			return append(dst, "null"...), true
+		}
+		dst = append(dst, '[')
+		for i, item := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendString(dst, item, &ok)
+		}
+		dst = append(dst, ']')
+	case map[string]interface{}:
+		if v == nil {
+			return append(dst, "null"...), true
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		dst = append(dst, '{')
+		for i := 0; i < len(keys) && ok; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendString(dst, keys[i], &ok)
+			dst = append(dst, ':')
+			if ok {
+				dst, ok = appendValue(dst, v[keys[i]])
+			}
+		}
+		dst = append(dst, '}')
+	case []HashField:
+		if v == nil {
+			return append(dst, "null"...), true
+		}
+		dst = append(dst, '[')
+		for i := 0; i < len(v) && ok; i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, `{"field":`...)
+			dst = appendString(dst, v[i].Field, &ok)
+			dst = append(dst, `,"value":`...)
+			if ok {
+				dst, ok = appendValue(dst, v[i].Value)
+			}
+			dst = append(dst, '}')
+		}
+		dst = append(dst, ']')
+	default:
+		return dst, false
+	}
+	return dst, ok
+}
+
+// appendFloat formats f like encoding/json. It clears ok for NaN and
+// infinities, which encoding/json rejects.
+func appendFloat(dst []byte, f float64, ok *bool) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		*ok = false
+		return dst
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Shorten e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString quotes s like encoding/json with HTML escaping. It clears
+// ok for invalid UTF-8, whose replacement differs between Go releases.
+func appendString(dst []byte, s string, ok *bool) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				dst = append(dst, '\\', c)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			*ok = false
+			return dst
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			start = i + size
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCommandRequest(t *testing.T) {
+	bodies := []string{
+		`{"command":"SET","args":["key","value"]}`,
+		` { "command" : "GET" , "args" : [ "key" ] , "db" : 3 } `,
+		`{"command":"SET","args":["k",1.5,-2,0,10,1e3,true,false,null],"timeout_ms":250,"resp3":true}`,
+		`{"command":"HGETALL","args":["h"],"hash_format":"pairs","database":"sessions","max_items":10}`,
+		`{"command":"SET","args":["k","quote \" and \\u00e9 \n"]}`,
+		`{"command":"SET","args":["héllo"," "]}`,
+		`{"command":"HGET","args":["h"],"fields":["a","b"]}`,
+		`{"Command":"GET","ARGS":["key"]}`,
+		`{"command":"GET","args":[["nested"]]}`,
+		`{"command":"GET","args":[{"a":1}]}`,
+		`{"command":"GET","unknown":1}`,
+		`{"command":null,"args":null}`,
+		`{"command":"GET","args":["k"]} trailing`,
+		`{}`,
+		"",
+		`{"command":"GET",}`,
+		`{"command":"GET","db":1.5}`,
+		`{"command":"GET","db":"1"}`,
+		`{"command":"GET","args":[01]}`,
+		`{"command":"GET","args":[1e400]}`,
+		`{"command":"GET","args":["` + "\xff" + `"]}`,
+		`{"command":"GET","args":["k"`,
+		`[1,2]`,
+	}
+
+	for _, body := range bodies {
+		var want CommandRequest
+		wantErr := json.NewDecoder(bytes.NewReader([]byte(body))).Decode(&want)
+
+		got := CommandRequest{Command: "stale", Args: make([]interface{}, 0, 4)}
+		got.DB = 7
+		err := DecodeCommandRequest([]byte(body), &got)
+
+		if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Errorf("%s: expected error %v, got %v", body, wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(want.Args) == 0 && len(got.Args) == 0 {
+			want.Args, got.Args = nil, nil
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %+v, got %+v", body, want, got)
+		}
+	}
+}
+
+func TestDecodeCommandRequestReusesArgs(t *testing.T) {
+	req := CommandRequest{Args: make([]interface{}, 0, 8)}
+	if err := DecodeCommandRequest([]byte(`{"command":"MSET","args":["a","1","b","2"]}`), &req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cap(req.Args) != 8 {
+		t.Errorf("Expected the args slice reused, got capacity %d", cap(req.Args))
+	}
+}
+
+func TestCommandResponseAppendJSON(t *testing.T) {
+	responses := []CommandResponse{
+		{Result: "OK", Type: "string", Time: 1.25},
+		{Type: "nil", Time: 0},
+		{Result: int64(-42), Type: "integer", Time: 0.0000001},
+		{Result: 3.5, Type: "float", Time: 1e21},
+		{Result: true, Type: "boolean", Time: 12},
+		{Result: []interface{}{"a", int64(1), nil, []interface{}{"nested"}}, Type: "array", Time: 0.5},
+		{Result: []interface{}(nil), Type: "array"},
+		{Result: map[string]interface{}{"b": "2", "a": int64(1), "<&>": "x"}, Type: "hash"},
+		{Result: []HashField{{Field: "f", Value: "v"}, {Field: "g", Value: int64(2)}}, Type: "hash"},
+		{Result: []string{"x", "y"}, Type: "array"},
+		{Result: "<script>&\"\\\b\f\n\r\t\x01\x7f  é\xff", Type: "string"},
+		{Error: "ERR wrong number of arguments", Code: "ERR_REDIS", Type: "error", Time: 0.3},
+		{Error: "WRONGTYPE Operation against a key", RedisCode: "WRONGTYPE", Type: "error"},
+		{Result: map[interface{}]interface{}{"a": 1}, Type: "map"},
+		{Result: struct{ A int }{1}, Type: "other"},
+	}
+
+	for _, response := range responses {
+		want, wantErr := json.Marshal(response)
+		got, err := response.AppendJSON([]byte("prefix"))
+		if (err == nil) != (wantErr == nil) {
+			t.Errorf("%+v: expected error %v, got %v", response, wantErr, err)
+			continue
+		}
+		if err == nil && string(got) != "prefix"+string(want) {
+			t.Errorf("Expected prefix%s, got %s", want, got)
+		}
+	}
+
+	if _, err := (CommandResponse{Result: math.NaN()}).AppendJSON(nil); err == nil {
+		t.Error("Expected an error for NaN, like encoding/json")
+	}
+}
+
+func BenchmarkDecodeCommandRequest(b *testing.B) {
+	body := []byte(`{"command":"SET","args":["user:1001","{\"name\":\"Ada\"}",60],"db":0}`)
+	req := CommandRequest{Args: make([]interface{}, 0, 8)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := DecodeCommandRequest(body, &req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCommandResponseAppendJSON(b *testing.B) {
+	response := CommandResponse{Result: []interface{}{"a", int64(1), "b"}, Type: "array", Time: 0.42}
+	buffer := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buffer, err = response.AppendJSON(buffer[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
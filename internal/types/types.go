@@ -1,24 +1,542 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 // API Request/Response Types
 type CommandRequest struct {
-	Command string        `json:"command"`
-	Args    []interface{} `json:"args,omitempty"`
-	DB      int           `json:"db,omitempty"`
+	Command    string        `json:"command"`
+	Args       []interface{} `json:"args,omitempty"`
+	DB         int           `json:"db,omitempty"`
+	HashFormat HashFormat    `json:"hash_format,omitempty"`
+
+	// Database names one of redis.named_databases, in place of DB
+	Database string `json:"database,omitempty"`
+
+	// TimeoutMs bounds how long the command may run, capped by the
+	// server's max_request_timeout
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+
+	// RESP3 runs the command over RESP3, returning maps, sets, doubles,
+	// big numbers and verbatim strings as such
+	RESP3 bool `json:"resp3,omitempty"`
+
+	// Fields keeps only these fields of hash and map replies
+	Fields []string `json:"fields,omitempty"`
+	// MaxItems keeps only the first MaxItems elements of array replies
+	MaxItems int `json:"max_items,omitempty"`
+}
+
+// HashFormat selects how hash replies such as HGETALL are returned.
+type HashFormat string
+
+const (
+	// HashFormatObject returns a JSON object, with fields sorted by name.
+	HashFormatObject HashFormat = "object"
+	// HashFormatPairs returns field/value pairs in the order Redis sent them.
+	HashFormatPairs HashFormat = "pairs"
+	// HashFormatSorted returns field/value pairs sorted by field.
+	HashFormatSorted HashFormat = "sorted"
+)
+
+// HashField is one entry of a hash returned as pairs.
+type HashField struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"`
 }
 
 type CommandResponse struct {
-	Result interface{} `json:"result,omitempty"`
-	Error  string      `json:"error,omitempty"`
-	Type   string      `json:"type"`
-	Time   float64     `json:"time"`
+	Result    interface{} `json:"result,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Code      string      `json:"code,omitempty"`
+	RedisCode string      `json:"redis_code,omitempty"`
+	Type      string      `json:"type"`
+	Time      float64     `json:"time"`
+}
+
+// MacroRequest runs a macro with values for its params.
+type MacroRequest struct {
+	Params   map[string]interface{} `json:"params,omitempty"`
+	DB       int                    `json:"db,omitempty"`
+	Database string                 `json:"database,omitempty"`
+}
+
+// GetOrSetResponse is the reply of /v1/keys/{key}/get-or-set: the key's
+// value, and whether it was just set to the default.
+type GetOrSetResponse struct {
+	Result  interface{} `json:"result"`
+	Type    string      `json:"type"`
+	Created bool        `json:"created"`
+	Time    float64     `json:"time"`
+}
+
+// SetRequest is a structured SET with its options as typed fields rather
+// than positional arguments.
+type SetRequest struct {
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value"`
+	DB      int         `json:"db,omitempty"`
+	NX      bool        `json:"nx,omitempty"`
+	XX      bool        `json:"xx,omitempty"`
+	EX      int64       `json:"ex,omitempty"`
+	PX      int64       `json:"px,omitempty"`
+	KeepTTL bool        `json:"keepttl,omitempty"`
+	Get     bool        `json:"get,omitempty"`
+}
+
+// Validate checks that the SET options form a valid combination.
+func (r *SetRequest) Validate() error {
+	if r.Key == "" {
+		return NewValidationError(ErrCodeMissingField, "key", "key is required")
+	}
+
+	switch r.Value.(type) {
+	case string, float64, bool:
+	case nil:
+		return NewValidationError(ErrCodeMissingField, "value", "value is required")
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, "value", "value must be a string, number or boolean")
+	}
+
+	if r.NX && r.XX {
+		return NewValidationError(ErrCodeConflictingField, "nx", "nx and xx are mutually exclusive")
+	}
+
+	if r.EX < 0 || r.PX < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, "ex", "ex and px must be positive")
+	}
+
+	expiries := 0
+	if r.EX > 0 {
+		expiries++
+	}
+	if r.PX > 0 {
+		expiries++
+	}
+	if r.KeepTTL {
+		expiries++
+	}
+	if expiries > 1 {
+		return NewValidationError(ErrCodeConflictingField, "ex", "only one of ex, px and keepttl may be set")
+	}
+
+	return nil
+}
+
+// Command converts the request into the equivalent SET command.
+func (r *SetRequest) Command() CommandRequest {
+	args := []interface{}{r.Key, r.Value}
+
+	switch {
+	case r.NX:
+		args = append(args, "NX")
+	case r.XX:
+		args = append(args, "XX")
+	}
+
+	switch {
+	case r.EX > 0:
+		args = append(args, "EX", r.EX)
+	case r.PX > 0:
+		args = append(args, "PX", r.PX)
+	case r.KeepTTL:
+		args = append(args, "KEEPTTL")
+	}
+
+	if r.Get {
+		args = append(args, "GET")
+	}
+
+	return CommandRequest{Command: "SET", Args: args, DB: r.DB}
+}
+
+// MGetRequest reads any number of keys, which the proxy splits into MGET
+// commands of bounded size.
+type MGetRequest struct {
+	Keys     []string `json:"keys"`
+	DB       int      `json:"db,omitempty"`
+	Database string   `json:"database,omitempty"`
+}
+
+// MGetResponse maps each requested key to its value, null for missing
+// keys.
+type MGetResponse struct {
+	Values map[string]interface{} `json:"values"`
+	Found  int                    `json:"found"`
+	Chunks int                    `json:"chunks"`
+	Time   float64                `json:"time"`
+}
+
+// MSetRequest writes any number of keys, which the proxy splits into MSET
+// commands of bounded size.
+type MSetRequest struct {
+	Values   map[string]interface{} `json:"values"`
+	DB       int                    `json:"db,omitempty"`
+	Database string                 `json:"database,omitempty"`
+}
+
+type MSetResponse struct {
+	Keys   int     `json:"keys"`
+	Chunks int     `json:"chunks"`
+	Time   float64 `json:"time"`
+}
+
+// Delayed task targets
+const (
+	DelayTargetList    = "list"
+	DelayTargetStream  = "stream"
+	DelayTargetWebhook = "webhook"
+)
+
+// DelayRequest enqueues Payload for delivery after DelayMs, or at
+// DeliverAt: pushed onto the list at Key, added to the stream at Key, or
+// POSTed to URL.
+type DelayRequest struct {
+	Target    string          `json:"target"`
+	Key       string          `json:"key,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	DelayMs   int64           `json:"delay_ms,omitempty"`
+	DeliverAt *time.Time      `json:"deliver_at,omitempty"`
+	DB        int             `json:"db,omitempty"`
+}
+
+// DelayedTask is a payload waiting for delivery, as stored by the proxy
+// and returned by /v1/delay.
+type DelayedTask struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	Target     string          `json:"target"`
+	Key        string          `json:"key,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	DB         int             `json:"db"`
+	Payload    json.RawMessage `json:"payload"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+	DeliverAt  time.Time       `json:"deliver_at"`
+	Attempts   int             `json:"attempts,omitempty"`
+}
+
+// FlagRequest creates or replaces a feature flag of /v1/flags. Rollout is
+// the percentage of subjects an enabled flag is on for, 100 if omitted;
+// Subjects get it regardless.
+type FlagRequest struct {
+	Enabled     bool     `json:"enabled"`
+	Rollout     *int     `json:"rollout,omitempty"`
+	Subjects    []string `json:"subjects,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Flag is a feature flag, as stored by the proxy and returned by
+// /v1/flags.
+type Flag struct {
+	Name        string    `json:"name"`
+	Enabled     bool      `json:"enabled"`
+	Rollout     int       `json:"rollout"`
+	Subjects    []string  `json:"subjects,omitempty"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// FlagsResponse lists a tenant's flags.
+type FlagsResponse struct {
+	Flags []Flag `json:"flags"`
+}
+
+// FlagEvaluationRequest evaluates the named Flags, or all of them, for
+// Subject, such as a user ID.
+type FlagEvaluationRequest struct {
+	Subject string   `json:"subject,omitempty"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// Reasons a flag evaluated as it did
+const (
+	FlagReasonDisabled = "disabled"
+	FlagReasonSubject  = "subject"
+	FlagReasonRollout  = "rollout"
+	FlagReasonExcluded = "excluded"
+	FlagReasonNotFound = "not_found"
+)
+
+// FlagEvaluation is whether flag Name is on for a subject, and why.
+type FlagEvaluation struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// FlagEvaluationResponse has the evaluations of the flags requested.
+type FlagEvaluationResponse struct {
+	Subject string           `json:"subject,omitempty"`
+	Flags   []FlagEvaluation `json:"flags"`
+}
+
+// UniqueAddRequest records identifiers in a metric's daily HyperLogLog.
+type UniqueAddRequest struct {
+	IDs           []string `json:"ids"`
+	Date          string   `json:"date,omitempty"`
+	RetentionDays int      `json:"retention_days,omitempty"`
+	DB            int      `json:"db,omitempty"`
+}
+
+type UniqueAddResponse struct {
+	Metric  string  `json:"metric"`
+	Date    string  `json:"date"`
+	Updated bool    `json:"updated"`
+	Time    float64 `json:"time"`
+}
+
+type UniqueCountResponse struct {
+	Metric string           `json:"metric"`
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Unique int64            `json:"unique"`
+	Daily  map[string]int64 `json:"daily,omitempty"`
+	Time   float64          `json:"time"`
+}
+
+// HLLAddRequest adds elements to a HyperLogLog.
+type HLLAddRequest struct {
+	Elements []string `json:"elements"`
+	DB       int      `json:"db,omitempty"`
+}
+
+type HLLAddResponse struct {
+	Key     string  `json:"key"`
+	Updated bool    `json:"updated"`
+	Time    float64 `json:"time"`
+}
+
+type HLLCountResponse struct {
+	Key   string  `json:"key"`
+	Count int64   `json:"count"`
+	Time  float64 `json:"time"`
+}
+
+// BloomRequest adds items to, or checks items against, a RedisBloom filter.
+type BloomRequest struct {
+	Items []string `json:"items"`
+	DB    int      `json:"db,omitempty"`
+}
+
+// BloomAddResponse tells for each item whether it was newly added; false
+// means it was, or may have been, added before.
+type BloomAddResponse struct {
+	Key   string          `json:"key"`
+	Added map[string]bool `json:"added"`
+	Time  float64         `json:"time"`
+}
+
+// BloomExistsResponse tells for each item whether it may be in the filter.
+// False is certain; true may be a false positive.
+type BloomExistsResponse struct {
+	Key    string          `json:"key"`
+	Exists map[string]bool `json:"exists"`
+	Time   float64         `json:"time"`
+}
+
+// JSONDocumentResponse is a RedisJSON value, embedded as JSON rather than
+// as an encoded string.
+type JSONDocumentResponse struct {
+	Key   string          `json:"key"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value"`
+	Time  float64         `json:"time"`
+}
+
+// JSONWriteResponse tells whether a JSON.SET or JSON.MERGE was applied;
+// false means its NX or XX condition wasn't met.
+type JSONWriteResponse struct {
+	Key     string  `json:"key"`
+	Path    string  `json:"path"`
+	Updated bool    `json:"updated"`
+	Time    float64 `json:"time"`
+}
+
+// GeoPoint is a position in degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// GeoMember is a named position in a geo set.
+type GeoMember struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// GeoAddRequest adds members to a geo set with GEOADD.
+type GeoAddRequest struct {
+	Members []GeoMember `json:"members"`
+	NX      bool        `json:"nx,omitempty"`
+	XX      bool        `json:"xx,omitempty"`
+	DB      int         `json:"db,omitempty"`
+}
+
+type GeoAddResponse struct {
+	Key   string  `json:"key"`
+	Added int64   `json:"added"`
+	Time  float64 `json:"time"`
+}
+
+// GeoBox is the size of a box search, in the request's unit.
+type GeoBox struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// GeoSearchRequest finds members of a geo set within a radius or box
+// around a member or a point, with GEOSEARCH.
+type GeoSearchRequest struct {
+	Member string    `json:"member,omitempty"`
+	Center *GeoPoint `json:"center,omitempty"`
+	Radius float64   `json:"radius,omitempty"`
+	Box    *GeoBox   `json:"box,omitempty"`
+
+	// Unit of radius, box and the distances returned: m (default), km, mi
+	// or ft
+	Unit string `json:"unit,omitempty"`
+
+	// Order by distance, asc or desc; unordered if empty, except that
+	// with Count the nearest are returned first
+	Order string `json:"order,omitempty"`
+	Count int    `json:"count,omitempty"`
+	DB    int    `json:"db,omitempty"`
+}
+
+// GeoResult is a member found by a search, with its distance from the
+// center in the search's unit.
+type GeoResult struct {
+	Name     string  `json:"name"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Distance float64 `json:"distance"`
+}
+
+type GeoSearchResponse struct {
+	Key     string      `json:"key"`
+	Unit    string      `json:"unit"`
+	Results []GeoResult `json:"results"`
+	Time    float64     `json:"time"`
+}
+
+// VectorItem is an element of a vector set and its embedding, given as
+// numbers or as base64 of little-endian float32s.
+type VectorItem struct {
+	ID         string          `json:"id"`
+	Vector     []float32       `json:"vector,omitempty"`
+	VectorB64  string          `json:"vector_b64,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+// VectorUpsertRequest adds or replaces elements of a vector set.
+type VectorUpsertRequest struct {
+	Items []VectorItem `json:"items"`
+	DB    int          `json:"db,omitempty"`
+}
+
+type VectorUpsertResponse struct {
+	Key     string  `json:"key"`
+	Added   int     `json:"added"`
+	Updated int     `json:"updated"`
+	Time    float64 `json:"time"`
+}
+
+// VectorSearchRequest finds the K elements of a vector set most similar to
+// a vector, or to an element of the set.
+type VectorSearchRequest struct {
+	Vector    []float32 `json:"vector,omitempty"`
+	VectorB64 string    `json:"vector_b64,omitempty"`
+	ID        string    `json:"id,omitempty"`
+	K         int       `json:"k,omitempty"`
+
+	// Filter is a VSIM FILTER expression on attributes, e.g.
+	// .year > 2020
+	Filter         string `json:"filter,omitempty"`
+	WithAttributes bool   `json:"with_attributes,omitempty"`
+	DB             int    `json:"db,omitempty"`
+}
+
+// VectorMatch is an element found by a search, with its similarity from 0
+// to 1.
+type VectorMatch struct {
+	ID         string          `json:"id"`
+	Score      float64         `json:"score"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+type VectorSearchResponse struct {
+	Key     string        `json:"key"`
+	Matches []VectorMatch `json:"matches"`
+	Time    float64       `json:"time"`
+}
+
+// Envelope wraps every /v2 response: the /v1 response body as data on
+// success or as error on failure, and meta either way.
+type Envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error json.RawMessage `json:"error"`
+	Meta  ResponseMeta    `json:"meta"`
+}
+
+// ResponseMeta describes how a /v2 request was served.
+type ResponseMeta struct {
+	RequestID string       `json:"request_id"`
+	Timing    ServerTiming `json:"timing"`
+	Warnings  []Warning    `json:"warnings"`
+
+	// Cursor continues a paginated response, empty on its last page
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ServerTiming breaks down where a request's time went: waiting for a
+// concurrency slot, in Redis round trips, and from the last Redis reply to
+// the response being encoded.
+type ServerTiming struct {
+	TotalMs     float64 `json:"total_ms"`
+	QueueMs     float64 `json:"queue_ms"`
+	RedisMs     float64 `json:"redis_ms"`
+	SerializeMs float64 `json:"serialize_ms"`
+}
+
+// Warning codes
+const (
+	WarningDeprecatedCommand = "deprecated_command"
+	WarningLargeValue        = "large_value"
+	WarningDeprecatedAPI     = "deprecated_api"
+)
+
+// Warning is something about a successful request the client may want to
+// act on.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ScanResponse is a page of keys. Cursor is a signed continuation token,
+// empty once the iteration is complete.
+type ScanResponse struct {
+	Keys   []string `json:"keys"`
+	Cursor string   `json:"cursor,omitempty"`
+	Time   float64  `json:"time"`
 }
 
 type PipelineRequest struct {
 	Commands []CommandRequest `json:"commands"`
 	DB       int              `json:"db,omitempty"`
+	Database string           `json:"database,omitempty"`
+	RESP3    bool             `json:"resp3,omitempty"`
+
+	// Parallelism splits the commands across up to this many connections;
+	// commands sharing a key stay on one, in order
+	Parallelism int `json:"parallelism,omitempty"`
 }
 
 type PipelineResponse struct {
@@ -31,6 +549,32 @@ type TransactionRequest struct {
 	Commands []CommandRequest `json:"commands"`
 	Watch    []string         `json:"watch,omitempty"`
 	DB       int              `json:"db,omitempty"`
+	Database string           `json:"database,omitempty"`
+	RESP3    bool             `json:"resp3,omitempty"`
+
+	// Retries is how many more times the transaction is attempted when a
+	// watched key changes before EXEC, re-checking Precondition each time
+	Retries      int                      `json:"retries,omitempty"`
+	Precondition *TransactionPrecondition `json:"precondition,omitempty"`
+}
+
+// TransactionPrecondition is a Lua script run before each attempt of a
+// transaction, once its keys are watched. If it returns nil, false or 0,
+// the transaction is not run.
+type TransactionPrecondition struct {
+	Script string        `json:"script"`
+	Keys   []string      `json:"keys,omitempty"`
+	Args   []interface{} `json:"args,omitempty"`
+}
+
+// Command returns the EVAL running the precondition.
+func (p *TransactionPrecondition) Command() CommandRequest {
+	args := make([]interface{}, 0, 2+len(p.Keys)+len(p.Args))
+	args = append(args, p.Script, float64(len(p.Keys)))
+	for _, key := range p.Keys {
+		args = append(args, key)
+	}
+	return CommandRequest{Command: "EVAL", Args: append(args, p.Args...)}
 }
 
 type TransactionResponse struct {
@@ -38,6 +582,28 @@ type TransactionResponse struct {
 	Queued  int               `json:"queued"`
 	Exec    bool              `json:"exec"`
 	Time    float64           `json:"time"`
+
+	// Attempts is how many times the transaction was tried, more than one
+	// if watched keys changed and it was retried
+	Attempts           int  `json:"attempts,omitempty"`
+	PreconditionFailed bool `json:"precondition_failed,omitempty"`
+}
+
+// TxSessionRequest opens a transaction session of /v1/session on DB or
+// the named Database, WATCHing the keys in Watch.
+type TxSessionRequest struct {
+	Watch    []string `json:"watch,omitempty"`
+	DB       int      `json:"db,omitempty"`
+	Database string   `json:"database,omitempty"`
+}
+
+// TxSessionResponse is an opened transaction session. Session is the
+// token for its requests; it closes after IdleTimeoutMs without one, and
+// at ExpiresAt regardless.
+type TxSessionResponse struct {
+	Session       string    `json:"session"`
+	IdleTimeoutMs int64     `json:"idle_timeout_ms"`
+	ExpiresAt     time.Time `json:"expires_at"`
 }
 
 type HealthResponse struct {
@@ -46,6 +612,159 @@ type HealthResponse struct {
 	Connections map[string]int `json:"connections"`
 	Uptime      int64          `json:"uptime"`
 	Memory      MemoryStats    `json:"memory"`
+
+	MemoryPressure *MemoryPressure     `json:"memory_pressure,omitempty"`
+	Maintenance    *MaintenanceWindow `json:"maintenance,omitempty"`
+}
+
+// MaintenanceWindow is a planned backend maintenance period. While it is
+// active and ReadOnly is set, the proxy rejects writes.
+type MaintenanceWindow struct {
+	ID       string    `json:"id" yaml:"id"`
+	Name     string    `json:"name" yaml:"name"`
+	Start    time.Time `json:"start" yaml:"start"`
+	End      time.Time `json:"end" yaml:"end"`
+	ReadOnly bool      `json:"read_only" yaml:"read_only"`
+}
+
+type MaintenanceListResponse struct {
+	Windows []MaintenanceWindow `json:"windows"`
+	Active  *MaintenanceWindow  `json:"active,omitempty"`
+	Mode    *MaintenanceMode    `json:"mode,omitempty"`
+}
+
+// Maintenance modes an operator can switch the proxy into.
+const (
+	MaintenanceOff      = "off"
+	MaintenanceReadOnly = "read_only"
+	MaintenanceFull     = "full"
+)
+
+// MaintenanceMode is a maintenance state switched on by an operator, e.g.
+// for a Redis migration, as opposed to a scheduled window. With
+// CacheReads, reads are answered from responses cached since the mode was
+// entered, which lets a full maintenance still serve known reads. Until
+// optionally ends the mode automatically.
+type MaintenanceMode struct {
+	Mode       string     `json:"mode"`
+	Reason     string     `json:"reason,omitempty"`
+	CacheReads bool       `json:"cache_reads,omitempty"`
+	Since      time.Time  `json:"since,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+}
+
+// MaintenanceModeResponse reports the mode after a switch and, when asked
+// to drain, whether every request admitted under the previous mode
+// finished in time.
+type MaintenanceModeResponse struct {
+	Mode    MaintenanceMode `json:"mode"`
+	Drained *bool           `json:"drained,omitempty"`
+}
+
+// RecordedExchange is an API request and its response, recorded with
+// credentials and redacted fields removed so it can be replayed against
+// another environment. Bodies longer than the recording limit are cut
+// short and marked truncated.
+type RecordedExchange struct {
+	Time   time.Time         `json:"time"`
+	Tenant string            `json:"tenant,omitempty"`
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  string            `json:"query,omitempty"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+
+	Status   int     `json:"status"`
+	Response string  `json:"response,omitempty"`
+	Duration float64 `json:"duration_ms"`
+
+	BodyTruncated     bool `json:"body_truncated,omitempty"`
+	ResponseTruncated bool `json:"response_truncated,omitempty"`
+}
+
+// RecordingRequest turns traffic recording on or off.
+type RecordingRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// RecordingStatus reports whether traffic is being recorded, where to, and
+// how many exchanges have been recorded, or dropped because the sink fell
+// behind, since startup.
+type RecordingStatus struct {
+	Enabled  bool   `json:"enabled"`
+	Sink     string `json:"sink"`
+	Recorded int64  `json:"recorded"`
+	Dropped  int64  `json:"dropped"`
+	Failed   int64  `json:"failed"`
+}
+
+// OpLogEntry is a write queued in the op log, with its arguments as the
+// strings sent to Redis. Seq orders the writes.
+type OpLogEntry struct {
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Tenant   string    `json:"tenant"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args,omitempty"`
+	DB       int       `json:"db,omitempty"`
+	Database string    `json:"database,omitempty"`
+}
+
+// OpLogStatus reports the writes waiting in the op log, the time of the
+// oldest, and how many writes were queued, replayed, dropped because
+// Redis rejected them on replay, or rejected because the log was full,
+// since startup.
+type OpLogStatus struct {
+	File      string     `json:"file"`
+	Tenants   []string   `json:"tenants"`
+	Pending   int        `json:"pending"`
+	Bytes     int64      `json:"bytes"`
+	Oldest    *time.Time `json:"oldest,omitempty"`
+	Queued    int64      `json:"queued"`
+	Replayed  int64      `json:"replayed"`
+	Failed    int64      `json:"failed"`
+	Rejected  int64      `json:"rejected"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// QueuedCommandResponse answers a write accepted into the op log instead
+// of being run. It has no result: the write is applied once Redis can be
+// reached, after the Pending writes queued before it, Seq giving its
+// place.
+type QueuedCommandResponse struct {
+	Queued  bool  `json:"queued"`
+	Seq     int64 `json:"seq"`
+	Pending int   `json:"pending"`
+}
+
+// MemoryPressure reports Redis memory usage and recent out-of-memory
+// errors, with advice on how to relieve it.
+type MemoryPressure struct {
+	UsedMemory      int64      `json:"used_memory,omitempty"`
+	MaxMemory       int64      `json:"max_memory,omitempty"`
+	UsedRatio       float64    `json:"used_ratio,omitempty"`
+	MaxMemoryPolicy string     `json:"maxmemory_policy,omitempty"`
+	OOMErrors       uint64     `json:"oom_errors"`
+	LastOOM         *time.Time `json:"last_oom,omitempty"`
+	WritesPaused    bool       `json:"writes_paused"`
+	PausedUntil     *time.Time `json:"paused_until,omitempty"`
+	Advice          []string   `json:"advice,omitempty"`
+}
+
+// HistorySample is one periodic snapshot of proxy activity. Counts cover
+// the interval since the previous sample.
+type HistorySample struct {
+	Timestamp     time.Time      `json:"timestamp"`
+	Commands      uint64         `json:"commands"`
+	CommandErrors uint64         `json:"command_errors"`
+	QPS           float64        `json:"qps"`
+	ErrorRate     float64        `json:"error_rate"`
+	Pool          map[string]int `json:"pool"`
+}
+
+type HistoryResponse struct {
+	Samples []HistorySample `json:"samples"`
+	Cursor  string          `json:"cursor,omitempty"`
 }
 
 type MemoryStats struct {
@@ -56,13 +775,298 @@ type MemoryStats struct {
 	NumGoroutine int    `json:"num_goroutine"`
 }
 
+// ErrorResponse is returned for failed requests. Code is a machine-readable
+// ERR_* code where one applies and the HTTP status text otherwise.
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Code    string `json:"code"`
+	Field   string `json:"field,omitempty"`
 	Details string `json:"details,omitempty"`
 	Time    int64  `json:"time"`
 }
 
+// TokenRequest asks the admin API to mint a scoped JWT.
+type TokenRequest struct {
+	TenantID    string   `json:"tenant_id"`
+	Permissions []string `json:"permissions"`
+	AllowedDBs  []int    `json:"allowed_dbs"`
+	RateLimit   int      `json:"rate_limit"`
+	TTL         string   `json:"ttl,omitempty"`
+}
+
+type TokenResponse struct {
+	Token     string `json:"token"`
+	ID        string `json:"jti"`
+	TenantID  string `json:"tenant_id"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// RevokeRequest revokes either a single token by ID or every token issued
+// to a tenant so far. ExpiresAt (unix seconds) lets the revocation entry
+// expire together with the token.
+type RevokeRequest struct {
+	JTI       string `json:"jti,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+}
+
+type RevokeResponse struct {
+	JTI       string `json:"jti,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	RevokedAt int64  `json:"revoked_at"`
+}
+
+// CachePurgeRequest removes response cache entries: the one with Key (as
+// returned in X-Cache-Key), every entry of TenantID, or with All, every
+// entry. Exactly one must be set.
+type CachePurgeRequest struct {
+	Key      string `json:"key,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	All      bool   `json:"all,omitempty"`
+}
+
+type CachePurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// ScriptStatus reports whether a preloaded script is present on every
+// backend. Backends lists the ones it is missing from.
+type ScriptStatus struct {
+	Name     string   `json:"name"`
+	SHA      string   `json:"sha,omitempty"`
+	Function bool     `json:"function,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Loaded   bool     `json:"loaded"`
+	Missing  []string `json:"missing,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type ScriptListResponse struct {
+	Scripts []ScriptStatus `json:"scripts"`
+}
+
+// BigValue is a key whose value was seen above the big value threshold.
+// Bytes is the largest size seen; Direction is "read" or "write".
+type BigValue struct {
+	Key       string    `json:"key"`
+	DB        int       `json:"db"`
+	Tenant    string    `json:"tenant"`
+	Command   string    `json:"command"`
+	Direction string    `json:"direction"`
+	Bytes     int       `json:"bytes"`
+	Count     uint64    `json:"count"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// BigValueReport lists the largest values seen, biggest first.
+type BigValueReport struct {
+	ThresholdBytes int        `json:"threshold_bytes"`
+	Values         []BigValue `json:"values"`
+}
+
+// BackendInfo is the INFO of one Redis backend, by section and field.
+// Error is set instead if the backend couldn't be queried.
+type BackendInfo struct {
+	Backend  string                       `json:"backend"`
+	Sections map[string]map[string]string `json:"sections,omitempty"`
+	Error    string                       `json:"error,omitempty"`
+}
+
+// RedisInfoResponse is INFO from every backend.
+type RedisInfoResponse struct {
+	Backends []BackendInfo `json:"backends"`
+}
+
+// RedisSlowEntry is an entry of a backend's SLOWLOG.
+type RedisSlowEntry struct {
+	ID         int64     `json:"id"`
+	Time       time.Time `json:"time"`
+	DurationUs int64     `json:"duration_us"`
+	Args       []string  `json:"args"`
+	ClientAddr string    `json:"client_addr,omitempty"`
+	ClientName string    `json:"client_name,omitempty"`
+}
+
+// BackendSlowLog is the SLOWLOG of one Redis backend, newest first.
+type BackendSlowLog struct {
+	Backend string           `json:"backend"`
+	Entries []RedisSlowEntry `json:"entries"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// RedisSlowLogResponse is SLOWLOG GET from every backend.
+type RedisSlowLogResponse struct {
+	Backends []BackendSlowLog `json:"backends"`
+}
+
+// Slow log entry kinds
+const (
+	SlowRequest = "request"
+	SlowCommand = "command"
+)
+
+// SlowEntry is a request or Redis command that took longer than its slow
+// log threshold. Request entries carry the HTTP method, path and status;
+// command entries the command, its arguments (truncated like Redis's own
+// SLOWLOG) and database.
+type SlowEntry struct {
+	ID         uint64        `json:"id"`
+	Kind       string        `json:"kind"`
+	Time       time.Time     `json:"time"`
+	DurationMs float64       `json:"duration_ms"`
+	Tenant     string        `json:"tenant,omitempty"`
+	TraceID    string        `json:"trace_id,omitempty"`
+	Method     string        `json:"method,omitempty"`
+	Path       string        `json:"path,omitempty"`
+	Status     int           `json:"status,omitempty"`
+	Command    string        `json:"command,omitempty"`
+	Args       []interface{} `json:"args,omitempty"`
+	DB         int           `json:"db,omitempty"`
+}
+
+// SlowLogReport lists the most recent slow entries, newest first.
+type SlowLogReport struct {
+	RequestThresholdMs float64     `json:"request_threshold_ms"`
+	CommandThresholdMs float64     `json:"command_threshold_ms"`
+	Entries            []SlowEntry `json:"entries"`
+}
+
+// Export formats for /admin/export
+const (
+	// ExportJSON writes each value as JSON, by type
+	ExportJSON = "json"
+	// ExportDump writes each value as its base64 DUMP payload, in Redis'
+	// RDB serialization, for RESTORE
+	ExportDump = "dump"
+)
+
+// ExportRecord is one key of an /admin/export stream. TTLMs is -1 for
+// keys without an expiry.
+type ExportRecord struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type,omitempty"`
+	TTLMs int64       `json:"ttl_ms"`
+	Value interface{} `json:"value,omitempty"`
+	Dump  string      `json:"dump,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// ExportPage is the last line of an /admin/export stream. Cursor fetches
+// the next page and is empty once the export is complete.
+type ExportPage struct {
+	Count  int    `json:"count"`
+	Cursor string `json:"cursor,omitempty"`
+	Done   bool   `json:"done"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NamespaceProgress is one line of a namespace flush or expire stream,
+// written after each batch with running totals. The last line is Done, or
+// has a Cursor to continue from when the request ran out of time or keys.
+type NamespaceProgress struct {
+	Scanned int     `json:"scanned"`
+	Deleted int     `json:"deleted,omitempty"`
+	Expired int     `json:"expired,omitempty"`
+	DryRun  bool    `json:"dry_run,omitempty"`
+	Done    bool    `json:"done"`
+	Cursor  string  `json:"cursor,omitempty"`
+	Error   string  `json:"error,omitempty"`
+	Time    float64 `json:"time"`
+}
+
+// TTLAuditReport describes the keys without an expiry among a sample of
+// the keys under a prefix.
+type TTLAuditReport struct {
+	Prefix          string   `json:"prefix"`
+	Sampled         int      `json:"sampled"`
+	WithoutTTL      int      `json:"without_ttl"`
+	WithoutTTLRatio float64  `json:"without_ttl_ratio"`
+	Examples        []string `json:"examples,omitempty"`
+
+	// Complete is set when the sample covers every key under the prefix
+	Complete bool    `json:"complete"`
+	Time     float64 `json:"time"`
+}
+
+// ReadyResponse is served by /ready; NotReady explains a failing check.
+type ReadyResponse struct {
+	Ready    bool     `json:"ready"`
+	NotReady []string `json:"not_ready,omitempty"`
+}
+
+// NegotiateRequest declares what an SDK supports, most preferred first.
+type NegotiateRequest struct {
+	SDK             string   `json:"sdk,omitempty"`
+	SDKVersion      string   `json:"sdk_version,omitempty"`
+	Compression     []string `json:"compression,omitempty"`
+	Formats         []string `json:"formats,omitempty"`
+	Streaming       bool     `json:"streaming,omitempty"`
+	MaxPayloadBytes int64    `json:"max_payload_bytes,omitempty"`
+}
+
+// Validate checks the declared limits.
+func (r *NegotiateRequest) Validate() error {
+	if r.MaxPayloadBytes < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, "max_payload_bytes", "max_payload_bytes must not be negative")
+	}
+	return nil
+}
+
+// NegotiateResponse carries the parameters the server chose for an SDK and
+// which features are available to its tenant, keyed by feature name.
+type NegotiateResponse struct {
+	ServerVersion       string          `json:"server_version"`
+	Compression         string          `json:"compression"`
+	Format              string          `json:"format"`
+	Formats             []string        `json:"formats"`
+	Streaming           bool            `json:"streaming"`
+	MaxPayloadBytes     int64           `json:"max_payload_bytes"`
+	MaxPipelineCommands int             `json:"max_pipeline_commands"`
+	Databases           []int           `json:"databases"`
+	HashFormats         []HashFormat    `json:"hash_formats"`
+	Features            map[string]bool `json:"features"`
+}
+
+// WhoAmIResponse describes the calling tenant as the proxy sees it, for
+// SDKs to adapt to and developers to debug auth with. Authenticated is
+// false when authentication is disabled and the caller has no tenant.
+type WhoAmIResponse struct {
+	Authenticated    bool            `json:"authenticated"`
+	TenantID         string          `json:"tenant_id,omitempty"`
+	Permissions      []string        `json:"permissions"`
+	AllowedDBs       []int           `json:"allowed_dbs"`
+	AllowedDatabases []string        `json:"allowed_databases,omitempty"`
+	Admin            bool            `json:"admin"`
+	Tier             string          `json:"tier,omitempty"`
+	MaxValueBytes    int             `json:"max_value_bytes"`
+	MaxKeyBytes      int             `json:"max_key_bytes"`
+	KeyPattern       string          `json:"key_pattern,omitempty"`
+	RateLimit        RateLimitStatus `json:"rate_limit"`
+}
+
+// RateLimitStatus is a tenant's rate limit in command cost per second and
+// how much of it is in use. Used and Remaining are only reported while
+// the limit is enforced; Remaining is 0 while the tenant is in debt.
+type RateLimitStatus struct {
+	Limit     int  `json:"limit"`
+	Enforced  bool `json:"enforced"`
+	Used      int  `json:"used"`
+	Remaining int  `json:"remaining"`
+}
+
+// CommandsResponse lists which commands of the proxy's command table the
+// calling tenant may run, with its permissions expanded. Unlisted is true
+// when the permissions also match commands outside the table; Macros are
+// the configured macros it may run.
+type CommandsResponse struct {
+	TenantID    string   `json:"tenant_id,omitempty"`
+	Permissions []string `json:"permissions"`
+	Allowed     []string `json:"allowed"`
+	Denied      []string `json:"denied"`
+	Unlisted    bool     `json:"unlisted"`
+	Macros      []string `json:"macros"`
+}
+
 // Configuration Types
 type Config struct {
 	Server  ServerConfig  `yaml:"server"`
@@ -71,6 +1075,370 @@ type Config struct {
 	Auth    AuthConfig    `yaml:"auth"`
 	Metrics MetricsConfig `yaml:"metrics"`
 	Logging LoggingConfig `yaml:"logging"`
+	OpenAPI OpenAPIConfig `yaml:"openapi"`
+
+	Observability ObservabilityConfig `yaml:"observability"`
+
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
+
+	Scripts ScriptsConfig `yaml:"scripts"`
+
+	BigValues BigValuesConfig `yaml:"big_values"`
+
+	SlowLog SlowLogConfig `yaml:"slowlog"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	Cache CacheConfig `yaml:"cache"`
+
+	Macros []MacroConfig `yaml:"macros"`
+
+	Secrets SecretsConfig `yaml:"secrets"`
+
+	Remote RemoteConfig `yaml:"remote"`
+
+	Delay DelayConfig `yaml:"delay"`
+
+	Flags FlagsConfig `yaml:"flags"`
+
+	TxSessions TxSessionsConfig `yaml:"tx_sessions"`
+
+	Deprecation DeprecationConfig `yaml:"deprecation"`
+
+	Faults FaultsConfig `yaml:"faults"`
+
+	Recording RecordingConfig `yaml:"recording"`
+
+	OpLog OpLogConfig `yaml:"oplog"`
+}
+
+// DelayConfig enables /v1/delay. Tasks wait in a sorted set in DB, which
+// should be a database no tenant may access, and every proxy instance
+// polls it every PollInterval for up to BatchSize due tasks. Webhooks may
+// only be sent to WebhookHosts, and are tried up to MaxAttempts times.
+type DelayConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	DB             int           `yaml:"db"`
+	KeyPrefix      string        `yaml:"key_prefix"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	BatchSize      int           `yaml:"batch_size"`
+	MaxDelay       time.Duration `yaml:"max_delay"`
+	MaxAttempts    int           `yaml:"max_attempts"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+	WebhookHosts   []string      `yaml:"webhook_hosts"`
+}
+
+// FlagsConfig enables /v1/flags. Each tenant's flags are a hash in DB,
+// which should be a database no tenant may access. Streams hear of changes
+// made through other proxy instances from keyspace notifications, which
+// ConfigureNotifications turns on in Redis, and reload every Heartbeat in
+// case one was missed.
+type FlagsConfig struct {
+	Enabled                bool          `yaml:"enabled"`
+	DB                     int           `yaml:"db"`
+	KeyPrefix              string        `yaml:"key_prefix"`
+	MaxFlags               int           `yaml:"max_flags"`
+	Heartbeat              time.Duration `yaml:"heartbeat"`
+	ConfigureNotifications bool          `yaml:"configure_notifications"`
+}
+
+// TxSessionsConfig enables /v1/session, transactions whose WATCH, queued
+// commands and EXEC span requests. Each open session holds a Redis
+// connection, so at most MaxSessions are open, MaxPerTenant of them for
+// one tenant. Sessions close after IdleTimeout without a request, and
+// after MaxAge regardless.
+type TxSessionsConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	MaxAge       time.Duration `yaml:"max_age"`
+	MaxSessions  int           `yaml:"max_sessions"`
+	MaxPerTenant int           `yaml:"max_per_tenant"`
+}
+
+// DeprecationConfig marks API endpoints, or options of them, deprecated.
+// Requests matching a rule get Deprecation and Sunset headers and are
+// counted, so clients still relying on them can be found before removal.
+type DeprecationConfig struct {
+	Rules []DeprecationRule `yaml:"rules"`
+}
+
+// DeprecationRule matches requests to Path, a route such as
+// /v1/keys/{key} or a prefix ending in * such as /v1/*, optionally only
+// with Method. With Option, only requests using that query parameter or
+// top-level JSON body field match. Since is when the deprecation took
+// effect, Sunset when the endpoint or option goes away, and Link documents
+// the migration.
+type DeprecationRule struct {
+	Path   string `yaml:"path"`
+	Method string `yaml:"method"`
+	Option string `yaml:"option"`
+
+	Since  time.Time `yaml:"since"`
+	Sunset time.Time `yaml:"sunset"`
+	Link   string    `yaml:"link"`
+}
+
+// RecordingConfig captures sampled API requests and their responses to a
+// JSON lines File or to a Redis Stream in StreamDB, capped at about MaxLen
+// entries, for replaying with cmd/replay. Recording starts with Enabled and
+// can be switched with /admin/recording. Request and response bodies are
+// cut at MaxBodyBytes, and JSON fields named in RedactFields are masked.
+type RecordingConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	File     string `yaml:"file"`
+	Stream   string `yaml:"stream"`
+	StreamDB int    `yaml:"stream_db"`
+	MaxLen   int    `yaml:"max_len"`
+
+	SampleRate   float64  `yaml:"sample_rate"`
+	MaxBodyBytes int      `yaml:"max_body_bytes"`
+	RedactFields []string `yaml:"redact_fields"`
+}
+
+// OpLogConfig enables the write-ahead op log. While Redis can't be
+// reached, write commands that Tenants send to /v1/command are appended
+// to File instead of failing, and replayed in order once Redis answers
+// again, which is checked every RetryInterval. The log holds at most
+// MaxEntries writes and MaxBytes of them; writes past that fail as they
+// would without it.
+type OpLogConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	File          string        `yaml:"file"`
+	Tenants       []string      `yaml:"tenants"`
+	MaxEntries    int           `yaml:"max_entries"`
+	MaxBytes      int64         `yaml:"max_bytes"`
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+// FaultsConfig injects synthetic failures into commands, so clients'
+// retry and timeout handling can be tested against a misbehaving proxy.
+// Never enable it in production.
+type FaultsConfig struct {
+	Enabled bool        `yaml:"enabled"`
+	Rules   []FaultRule `yaml:"rules"`
+}
+
+// FaultRule applies to Commands from Tenants, each matching everything
+// when empty. A matching command is delayed by Latency plus up to Jitter
+// with probability LatencyRate, has its client connection dropped with
+// probability DropRate, and otherwise fails with Error with probability
+// ErrorRate.
+type FaultRule struct {
+	Commands []string `yaml:"commands"`
+	Tenants  []string `yaml:"tenants"`
+
+	Latency     time.Duration `yaml:"latency"`
+	Jitter      time.Duration `yaml:"jitter"`
+	LatencyRate float64       `yaml:"latency_rate"`
+
+	DropRate  float64 `yaml:"drop_rate"`
+	ErrorRate float64 `yaml:"error_rate"`
+	Error     string  `yaml:"error"`
+}
+
+// RemoteConfig loads a configuration document from a key in etcd or
+// Consul on top of the local file, so a fleet of proxies can share one.
+// With Watch, changes to the document are picked up while running; see
+// the README for which settings apply without a restart. The document
+// cannot change these settings itself.
+type RemoteConfig struct {
+	Provider  string   `yaml:"provider"`
+	Endpoints []string `yaml:"endpoints"`
+	Key       string   `yaml:"key"`
+
+	// Username and Password authenticate to etcd; Token is a Consul ACL
+	// token, defaulting to CONSUL_HTTP_TOKEN
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+
+	Timeout time.Duration `yaml:"timeout"`
+	Watch   bool          `yaml:"watch"`
+}
+
+// SecretsConfig fetches API keys and Redis passwords from an external
+// secret store at startup and again every RefreshInterval, so they can be
+// rotated without a restart. Provider is "vault" or "aws"; empty turns
+// the store off.
+type SecretsConfig struct {
+	Provider        string        `yaml:"provider"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	Timeout         time.Duration `yaml:"timeout"`
+
+	Vault VaultConfig      `yaml:"vault"`
+	AWS   AWSSecretsConfig `yaml:"aws"`
+}
+
+// VaultConfig reads one secret from a HashiCorp Vault KV version 2 engine.
+// Path is the API path, e.g. "secret/data/serverless-redis"; Address and
+// Token default to VAULT_ADDR and VAULT_TOKEN.
+type VaultConfig struct {
+	Address   string `yaml:"address"`
+	Token     string `yaml:"token"`
+	Namespace string `yaml:"namespace"`
+	Path      string `yaml:"path"`
+}
+
+// AWSSecretsConfig reads one JSON secret from AWS Secrets Manager with the
+// credentials in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN. Region defaults to AWS_REGION; Endpoint overrides the
+// regional endpoint, e.g. for a VPC endpoint.
+type AWSSecretsConfig struct {
+	Region   string `yaml:"region"`
+	SecretID string `yaml:"secret_id"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// Secrets are the values a secret store may hold. Empty ones keep the
+// configured value; APIKeys, when present, replace auth.api_keys.
+type Secrets struct {
+	RedisPassword     string   `yaml:"redis_password"`
+	DragonflyPassword string   `yaml:"dragonfly_password"`
+	APIKeys           []APIKey `yaml:"api_keys"`
+}
+
+// CacheConfig sets how responses are cached. Each rule covers either a GET
+// path in the response cache or a read-only command sent to /v1/command.
+type CacheConfig struct {
+	Rules []CacheRule `yaml:"rules"`
+
+	// MaxBytes bounds each per-tenant cache (command replies and reads
+	// cached for maintenance); TenantMaxBytes bounds one tenant's share
+	MaxBytes       int `yaml:"max_bytes"`
+	TenantMaxBytes int `yaml:"tenant_max_bytes"`
+
+	// Debug explains in X-Cache-Reason, and the log, why each response
+	// was or wasn't cached
+	Debug bool `yaml:"debug"`
+}
+
+// CacheRule caches responses for TTL. For StaleWhileRevalidate after that,
+// an expired response is still served immediately while a fresh one is
+// fetched in the background. Key is the CacheKey policy deciding whose
+// requests share a cached response.
+type CacheRule struct {
+	Path    string `yaml:"path"`
+	Command string `yaml:"command"`
+	Key     string `yaml:"key"`
+
+	TTL                  time.Duration `yaml:"ttl"`
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate"`
+}
+
+// Cache key policies of CacheRule. Paths default to CacheKeyPublic for
+// /health and /metrics and CacheKeyAuth otherwise; commands are always
+// cached per tenant.
+const (
+	// CacheKeyPublic shares responses between all callers, ignoring
+	// credentials; only for responses that don't depend on them
+	CacheKeyPublic = "public"
+
+	// CacheKeyAuth shares responses between requests with the same
+	// Authorization header
+	CacheKeyAuth = "auth"
+
+	// CacheKeyTenant shares responses within a tenant
+	CacheKeyTenant = "tenant"
+)
+
+// BigValuesConfig controls detection of large values, which block Redis
+// while they are serialized and strain the network.
+type BigValuesConfig struct {
+	// WarnBytes is the size above which values are reported
+	WarnBytes int `yaml:"warn_bytes"`
+
+	// ReportSize is how many of the largest values are kept for the report
+	ReportSize int `yaml:"report_size"`
+
+	// MaxValueBytes rejects writes of larger values unless overridden per
+	// API key; 0 means no limit
+	MaxValueBytes int `yaml:"max_value_bytes"`
+
+	// MaxKeyBytes rejects writes to longer keys unless overridden per API
+	// key; 0 means no limit
+	MaxKeyBytes int `yaml:"max_key_bytes"`
+}
+
+// RateLimitConfig enforces each tenant's rate_limit as command cost per
+// second. Costs weigh commands by the load they put on Redis, on top of
+// built-in defaults for the expensive ones; other commands cost
+// DefaultCost.
+type RateLimitConfig struct {
+	Enabled     bool                  `yaml:"enabled"`
+	Costs       map[string]int        `yaml:"costs"`
+	DefaultCost int                   `yaml:"default_cost"`
+	Shared      SharedRateLimitConfig `yaml:"shared"`
+}
+
+// SharedRateLimitConfig holds tenants to their rate limits across all
+// proxy instances, through a sliding window per tenant at KeyPrefix plus
+// the tenant ID in DB. Each instance adds what it let through every
+// SyncInterval rather than on every request.
+type SharedRateLimitConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	DB           int           `yaml:"db"`
+	KeyPrefix    string        `yaml:"key_prefix"`
+	SyncInterval time.Duration `yaml:"sync_interval"`
+}
+
+// SlowLogConfig sets when requests and Redis commands count as slow. Slow
+// ones are logged, counted in metrics and kept for /admin/slowlog.
+type SlowLogConfig struct {
+	RequestThreshold time.Duration `yaml:"request_threshold"`
+	CommandThreshold time.Duration `yaml:"command_threshold"`
+
+	// Size is how many of the most recent slow entries are kept
+	Size int `yaml:"size"`
+}
+
+// ScriptsConfig lists Lua scripts and function libraries preloaded into
+// every backend, so the first EVALSHA or FCALL after a failover doesn't
+// hit NOSCRIPT.
+type ScriptsConfig struct {
+	Preload []ScriptConfig `yaml:"preload"`
+
+	// CheckInterval is how often backends are checked for missing scripts,
+	// which are loaded again, e.g. after a failover or SCRIPT FLUSH
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// ScriptConfig is one named script, given inline or as a file. With
+// Function set the source is a FUNCTION LOAD library named Name.
+type ScriptConfig struct {
+	Name     string `yaml:"name"`
+	Source   string `yaml:"source"`
+	File     string `yaml:"file"`
+	Function bool   `yaml:"function"`
+
+	// SHA, if set, must match the SHA1 of the script's source
+	SHA string `yaml:"sha"`
+
+	// Required scripts must be loaded everywhere for /ready to pass
+	Required bool `yaml:"required"`
+}
+
+// MacroConfig is a named sequence of commands run as a pipeline, or a
+// transaction, by POST /v1/macros/{name}. Arguments may contain {{param}}
+// placeholders for the caller's params. Tenants need the MACRO:<name>
+// permission to run it, rather than one for each of its commands.
+type MacroConfig struct {
+	Name        string         `yaml:"name"`
+	Params      []string       `yaml:"params"`
+	Commands    []MacroCommand `yaml:"commands"`
+	Transaction bool           `yaml:"transaction"`
+}
+
+// MacroCommand is one command of a macro.
+type MacroCommand struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+// MaintenanceConfig lists maintenance windows known at startup; more can
+// be scheduled through the admin API.
+type MaintenanceConfig struct {
+	Windows []MaintenanceWindow `yaml:"windows"`
 }
 
 type ServerConfig struct {
@@ -81,6 +1449,105 @@ type ServerConfig struct {
 	IdleTimeout  time.Duration `yaml:"idle_timeout"`
 	HTTP2        HTTP2Config   `yaml:"http2"`
 	TLS          TLSConfig     `yaml:"tls"`
+
+	MaxPipelineCommands int `yaml:"max_pipeline_commands"`
+
+	// MaxRequestTimeout caps client-requested timeouts (timeout_ms and
+	// X-Request-Timeout)
+	MaxRequestTimeout time.Duration `yaml:"max_request_timeout"`
+
+	// ShutdownTimeout bounds how long in-flight requests may drain on
+	// shutdown before they are cancelled
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	Concurrency ConcurrencyConfig `yaml:"concurrency"`
+
+	// Listeners replace the one on host:port when set, e.g. to add a Unix
+	// socket for a sidecar or to keep admin routes on a private address
+	Listeners []ListenerConfig `yaml:"listeners"`
+
+	// Admin, when its Address is set, is a listener for the admin routes,
+	// which the others then no longer serve
+	Admin ListenerConfig `yaml:"admin"`
+
+	// Pprof serves Go profiles at /debug/pprof on admin listeners
+	Pprof bool `yaml:"pprof"`
+
+	// LargeValues streams big values of /v1/keys in chunks
+	LargeValues LargeValueConfig `yaml:"large_values"`
+
+	// RedisErrorStatus answers single commands failing with errors such as
+	// WRONGTYPE or OOM with a matching HTTP status instead of 200
+	RedisErrorStatus bool `yaml:"redis_error_status"`
+
+	// AllowUnknownCommands passes commands missing from the proxy's
+	// command table through to Redis, such as those of modules it doesn't
+	// list, instead of rejecting them with 400
+	AllowUnknownCommands bool `yaml:"allow_unknown_commands"`
+}
+
+// LargeValueConfig configures streaming of /v1/keys values longer than
+// Threshold bytes, which are sent to and read from Redis ChunkSize bytes
+// at a time instead of being buffered whole. MaxSize bounds a streamed
+// upload.
+type LargeValueConfig struct {
+	Threshold int   `yaml:"threshold"`
+	ChunkSize int   `yaml:"chunk_size"`
+	MaxSize   int64 `yaml:"max_size"`
+}
+
+// Listener networks and the routes a listener may be limited to
+const (
+	ListenerTCP  = "tcp"
+	ListenerUnix = "unix"
+
+	ListenerRoutesAPI   = "api"
+	ListenerRoutesAdmin = "admin"
+)
+
+// ListenerConfig is one address the server listens on. Network is tcp
+// (the default) with a host:port Address, or unix with a socket path,
+// created with SocketMode (octal, e.g. "0660"). Routes limits the listener
+// to api (the tenant-facing routes) or admin (/admin, /debug and metrics,
+// plus health and readiness); empty serves everything but /debug.
+type ListenerConfig struct {
+	Name       string    `yaml:"name"`
+	Network    string    `yaml:"network"`
+	Address    string    `yaml:"address"`
+	SocketMode string    `yaml:"socket_mode"`
+	Routes     string    `yaml:"routes"`
+	TLS        TLSConfig `yaml:"tls"`
+}
+
+// ConcurrencyConfig configures the adaptive limit on in-flight requests.
+type ConcurrencyConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	InitialLimit int  `yaml:"initial_limit"`
+	MinLimit     int  `yaml:"min_limit"`
+	MaxLimit     int  `yaml:"max_limit"`
+
+	// SampleWindow is how many Redis round trips are averaged before the
+	// limit is adjusted
+	SampleWindow int `yaml:"sample_window"`
+
+	// Tolerance is how many times the baseline latency the average may
+	// reach before the limit is cut by Backoff (e.g. 0.9 keeps 90%)
+	Tolerance float64 `yaml:"tolerance"`
+	Backoff   float64 `yaml:"backoff"`
+
+	// Tiers let requests over the limit wait for a slot, with freed slots
+	// shared between tiers by weight. Without tiers they are rejected.
+	Tiers        []TierConfig  `yaml:"tiers"`
+	DefaultTier  string        `yaml:"default_tier"`
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+}
+
+// TierConfig is a tenant priority tier. Higher weights get a larger share
+// of freed slots; once MaxQueue requests are waiting, more are shed.
+type TierConfig struct {
+	Name     string `yaml:"name"`
+	Weight   int    `yaml:"weight"`
+	MaxQueue int    `yaml:"max_queue"`
 }
 
 type HTTP2Config struct {
@@ -99,9 +1566,89 @@ type TLSConfig struct {
 	KeyFile  string `yaml:"key_file"`
 }
 
+// Redis modes
+const (
+	// RedisModeServer proxies to the configured Redis servers
+	RedisModeServer = "server"
+	// RedisModeEmbedded runs an in-memory Redis inside the proxy instead,
+	// for local development and CI only
+	RedisModeEmbedded = "embedded"
+)
+
 type RedisConfig struct {
+	// Mode is server (the default) or embedded, which ignores the
+	// primary's address
+	Mode string `yaml:"mode"`
+
 	Primary   RedisInstanceConfig `yaml:"primary"`
 	Dragonfly DragonflyConfig     `yaml:"dragonfly"`
+	Databases int                 `yaml:"databases"`
+
+	// Read-only commands are routed to replicas when ReadFromReplicas is
+	// set; session tokens keep reads consistent with a client's own writes.
+	Replicas              []RedisInstanceConfig `yaml:"replicas"`
+	ReadFromReplicas      bool                  `yaml:"read_from_replicas"`
+	ReplicaOffsetInterval time.Duration         `yaml:"replica_offset_interval"`
+
+	// Shards are further backends keys are spread over together with the
+	// primary, by consistent hashing on each command's first key.
+	Shards []ShardConfig `yaml:"shards"`
+
+	// NamedDatabases let requests pick a database by name, decoupling
+	// clients from which backend and db number hold it.
+	NamedDatabases map[string]NamedDatabaseConfig `yaml:"named_databases"`
+
+	// OOMCooldown is how long low-priority writes are paused after Redis
+	// rejects a command for exceeding maxmemory
+	OOMCooldown time.Duration `yaml:"oom_cooldown"`
+
+	Retry RetryConfig `yaml:"retry"`
+
+	ClientCache ClientCacheConfig `yaml:"client_cache"`
+
+	// Connections are named <client_name>/<instance>/<backend>, with the
+	// database, ACL user and protocol of other pools appended, so CLIENT
+	// LIST on Redis shows which proxy owns each one. Instance defaults to
+	// the hostname.
+	ClientName string `yaml:"client_name"`
+	Instance   string `yaml:"instance"`
+
+	Connect ConnectConfig `yaml:"connect"`
+}
+
+// ConnectConfig decides what happens when Redis is unreachable at startup.
+// By default the proxy exits. With Lazy it starts anyway, reports not
+// ready, and retries with exponential backoff from InitialBackoff up to
+// MaxBackoff until the primary, DragonflyDB and every shard answer.
+type ConnectConfig struct {
+	Lazy           bool          `yaml:"lazy"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// ClientCacheConfig enables caching GET replies in the proxy, kept correct
+// by Redis client-side caching: Redis tracks the keys read and reports
+// when they change, and the proxy drops them.
+type ClientCacheConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// MaxBytes bounds the cached keys and values
+	MaxBytes int `yaml:"max_bytes"`
+
+	// PoolSize is the number of tracked connections GETs are sent over
+	PoolSize int `yaml:"pool_size"`
+}
+
+// RetryConfig controls retries of read-only commands that fail with a
+// transient error such as a dropped connection or a timeout.
+type RetryConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+
+	// Jitter is the fraction (0-1) of each backoff that is randomised
+	Jitter float64 `yaml:"jitter"`
 }
 
 type RedisInstanceConfig struct {
@@ -114,11 +1661,56 @@ type RedisInstanceConfig struct {
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 }
 
+// ShardConfig is one backend of a sharded deployment. Names place the
+// shard on the hash ring, so renaming a shard moves its keys.
+type ShardConfig struct {
+	Name     string `yaml:"name"`
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// NamedDatabaseConfig maps a database name to a db on one backend:
+// primary, dragonfly or a shard name.
+type NamedDatabaseConfig struct {
+	Backend string `yaml:"backend"`
+	DB      int    `yaml:"db"`
+}
+
 type DragonflyConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	Addr     string `yaml:"addr"`
 	Password string `yaml:"password"`
 	DB       int    `yaml:"db"`
+
+	Routing RoutingConfig `yaml:"routing"`
+}
+
+// Routing modes for commands that DragonflyDB or Redis could serve
+const (
+	// RoutingLatency sends each command to the backend that has been
+	// faster for it
+	RoutingLatency = "latency"
+	// RoutingStatic sends a fixed list of multi-key and sorted set
+	// commands to DragonflyDB
+	RoutingStatic = "static"
+)
+
+// RoutingConfig controls latency-aware routing between Redis and
+// DragonflyDB. Each command keeps an average latency per backend, and its
+// traffic only moves to the other backend once that is faster by more than
+// Hysteresis. ExploreRatio of requests go to the slower backend to keep its
+// average current.
+type RoutingConfig struct {
+	Mode         string  `yaml:"mode"`
+	ExploreRatio float64 `yaml:"explore_ratio"`
+	Hysteresis   float64 `yaml:"hysteresis"`
+
+	// MinSamples each backend needs before the averages are compared
+	MinSamples int `yaml:"min_samples"`
+
+	// Smoothing is the weight (0-1] of each new sample in the averages
+	Smoothing float64 `yaml:"smoothing"`
 }
 
 type PoolConfig struct {
@@ -134,6 +1726,65 @@ type AuthConfig struct {
 	Enabled   bool      `yaml:"enabled"`
 	JWTSecret string    `yaml:"jwt_secret"`
 	APIKeys   []APIKey  `yaml:"api_keys"`
+
+	// TrustForwardedFor takes the client IP checked against allowed_cidrs
+	// from the last X-Forwarded-For entry, the one the load balancer
+	// appended; only enable it behind a single trusted load balancer.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for"`
+
+	// MaxClockSkew bounds X-SR-Date drift on signed requests (default 5m)
+	MaxClockSkew time.Duration `yaml:"max_clock_skew"`
+
+	// KeyPattern is the key naming policy of tenants whose API keys don't
+	// set one, e.g. "^{tenant}:"; empty allows any key
+	KeyPattern string `yaml:"key_pattern"`
+
+	// TokenCacheSize bounds how many verified JWTs are remembered until
+	// they expire, so they aren't parsed and verified on every request
+	// (default 10000, negative to disable)
+	TokenCacheSize int `yaml:"token_cache_size"`
+
+	// Lockout refuses clients, by IP, that fail to authenticate too often
+	Lockout LockoutConfig `yaml:"lockout"`
+
+	// Anonymous lets requests without credentials read public keys
+	Anonymous AnonymousConfig `yaml:"anonymous"`
+
+	Revocation RevocationConfig `yaml:"revocation"`
+}
+
+// LockoutConfig locks a client IP out for Duration once it fails to
+// authenticate MaxFailures times within Window (defaults 20, 1m and 5m;
+// a negative MaxFailures disables lockouts). Requests with credentials
+// that are valid but restricted from the client don't count.
+type LockoutConfig struct {
+	MaxFailures int           `yaml:"max_failures"`
+	Window      time.Duration `yaml:"window"`
+	Duration    time.Duration `yaml:"duration"`
+}
+
+// AnonymousConfig lets requests without an Authorization header run, as
+// the "anonymous" tenant, the read-only Commands on keys starting with
+// KeyPrefix in DB, e.g. for static sites reading public feature flags.
+// RateLimit is shared by every anonymous client (default 100), and
+// AllowedOrigins optionally restricts the sites that may read.
+type AnonymousConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Commands       []string `yaml:"commands"`
+	KeyPrefix      string   `yaml:"key_prefix"`
+	DB             int      `yaml:"db"`
+	RateLimit      int      `yaml:"rate_limit"`
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// RevocationConfig controls the JWT revocation list kept in Redis. DB
+// should be a database no tenant is allowed to access.
+type RevocationConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	DB        int           `yaml:"db"`
+	KeyPrefix string        `yaml:"key_prefix"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+	Retention time.Duration `yaml:"retention"`
 }
 
 type APIKey struct {
@@ -142,16 +1793,172 @@ type APIKey struct {
 	RateLimit   int      `yaml:"rate_limit"`
 	AllowedDBs  []int    `yaml:"allowed_dbs"`
 	Permissions []string `yaml:"permissions"`
+	Admin       bool     `yaml:"admin"`
+
+	// Optional restrictions on where the key may be used from. Origins may
+	// use a leading wildcard label, e.g. "https://*.example.com".
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	AllowedCIDRs   []string `yaml:"allowed_cidrs"`
+
+	// KeyID and SigningSecret enable SR-HMAC-SHA256 request signing; the
+	// secret itself is never sent. Key may be left empty for sign-only keys.
+	KeyID         string `yaml:"key_id"`
+	SigningSecret string `yaml:"signing_secret"`
+
+	// RedisUsername and RedisPassword make the proxy run this tenant's
+	// commands over a dedicated pool authenticated as that Redis 6 ACL
+	// user, so Redis enforces the tenant's permissions as well.
+	RedisUsername string `yaml:"redis_username"`
+	RedisPassword string `yaml:"redis_password"`
+
+	// Tier names one of server.concurrency.tiers; empty uses the default
+	// tier
+	Tier string `yaml:"tier"`
+
+	// MaxValueBytes rejects writes of larger values; 0 uses
+	// big_values.max_value_bytes
+	MaxValueBytes int `yaml:"max_value_bytes"`
+
+	// MaxKeyBytes rejects writes to longer keys; 0 uses
+	// big_values.max_key_bytes
+	MaxKeyBytes int `yaml:"max_key_bytes"`
+
+	// KeyPattern is a regular expression every key the tenant's commands
+	// name must match, with {tenant} standing for the tenant ID; empty uses
+	// auth.key_pattern
+	KeyPattern string `yaml:"key_pattern"`
+
+	// AllowedDatabases are the redis.named_databases the tenant may use;
+	// "*" allows all of them
+	AllowedDatabases []string `yaml:"allowed_databases"`
+
+	// DefaultTTL is the expiry given to keys the tenant's SET-family
+	// commands write without one; MaxTTL caps the expiries they ask for,
+	// and is the default if DefaultTTL is 0. 0 leaves keys as written.
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+	MaxTTL     time.Duration `yaml:"max_ttl"`
 }
 
 type MetricsConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Path    string `yaml:"path"`
+
+	// In-memory stats history served at /admin/v1/history
+	HistoryInterval  time.Duration `yaml:"history_interval"`
+	HistoryRetention time.Duration `yaml:"history_retention"`
+
+	Tenants TenantMetricsConfig `yaml:"tenants"`
+
+	Histograms HistogramConfig `yaml:"histograms"`
+
+	Access OperatorAccessConfig `yaml:"access"`
+}
+
+// OperatorAccessConfig protects the endpoints meant for operators rather
+// than tenants. With a BearerToken or a Username and Password, the metrics
+// path and /debug need one of them. With AllowedCIDRs, those and /admin
+// are only served to clients in the listed networks. /admin keeps using
+// admin API keys, so credentials here don't apply to it.
+type OperatorAccessConfig struct {
+	BearerToken string `yaml:"bearer_token"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+}
+
+// HistogramConfig sets the buckets of the latency histograms, in seconds;
+// empty keeps the built-in ones. With Native, they are also exposed as
+// Prometheus native histograms, whose buckets grow by NativeBucketFactor
+// up to NativeMaxBuckets, for scrapers that support them.
+type HistogramConfig struct {
+	HTTPBuckets  []float64 `yaml:"http_buckets"`
+	RedisBuckets []float64 `yaml:"redis_buckets"`
+
+	Native             bool    `yaml:"native"`
+	NativeBucketFactor float64 `yaml:"native_bucket_factor"`
+	NativeMaxBuckets   uint32  `yaml:"native_max_buckets"`
+}
+
+// Tenant label modes of TenantMetricsConfig.
+const (
+	TenantLabelID    = "id"
+	TenantLabelGroup = "group"
+	TenantLabelNone  = "none"
+)
+
+// TenantMetricsConfig bounds the cardinality of the tenant label on
+// Prometheus metrics. In "id" mode it is the tenant ID. In "group" mode it
+// is the tenant's group from Groups, else a hash bucket of Buckets, else
+// "other". In "none" mode it is left empty. Allowlisted tenants keep their
+// ID in every mode.
+type TenantMetricsConfig struct {
+	Label     string            `yaml:"label"`
+	Groups    map[string]string `yaml:"groups"`
+	Buckets   int               `yaml:"buckets"`
+	Allowlist []string          `yaml:"allowlist"`
+}
+
+// OpenAPIConfig controls the API documentation endpoints. The spec itself
+// is always served at /openapi.json.
+type OpenAPIConfig struct {
+	SwaggerUI bool   `yaml:"swagger_ui"`
+	UIPath    string `yaml:"ui_path"`
+}
+
+type ObservabilityConfig struct {
+	OTLP   OTLPConfig           `yaml:"otlp"`
+	Errors ErrorReportingConfig `yaml:"errors"`
+}
+
+// ErrorReportingConfig sends panics and 5xx responses, with the request
+// they happened on minus its credentials, to Sentry, a webhook, or both.
+type ErrorReportingConfig struct {
+	Enabled        bool              `yaml:"enabled"`
+	SentryDSN      string            `yaml:"sentry_dsn"`
+	WebhookURL     string            `yaml:"webhook_url"`
+	WebhookHeaders map[string]string `yaml:"webhook_headers"`
+	Environment    string            `yaml:"environment"`
+
+	// SampleRate is the fraction of 5xx responses reported; panics always
+	// are
+	SampleRate float64       `yaml:"sample_rate"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+// OTLPConfig wires traces, metrics and logs to one OTLP/HTTP collector,
+// sharing the same resource attributes across all three signals.
+type OTLPConfig struct {
+	Enabled            bool              `yaml:"enabled"`
+	Endpoint           string            `yaml:"endpoint"`
+	Headers            map[string]string `yaml:"headers"`
+	ServiceName        string            `yaml:"service_name"`
+	Region             string            `yaml:"region"`
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+	ExportInterval     time.Duration     `yaml:"export_interval"`
+	Timeout            time.Duration     `yaml:"timeout"`
 }
 
 type LoggingConfig struct {
 	Level  string `yaml:"level"`
 	Format string `yaml:"format"`
+
+	Access AccessLogConfig `yaml:"access"`
+}
+
+// AccessLogConfig configures the per-request access log.
+type AccessLogConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Fields picks and orders the logged fields; empty logs all of them
+	Fields []string `yaml:"fields"`
+
+	// SampleRate is the fraction of successful requests logged. Requests
+	// answered with 4xx or 5xx are always logged.
+	SampleRate float64 `yaml:"sample_rate"`
+
+	// Exclude skips requests whose path starts with one of these
+	Exclude []string `yaml:"exclude"`
 }
 
 // Internal Types
@@ -160,6 +1967,35 @@ type Tenant struct {
 	RateLimit   int
 	AllowedDBs  []int
 	Permissions []string
+	Admin       bool
+
+	// Empty means unrestricted
+	AllowedOrigins []string
+	AllowedNets    []*net.IPNet
+
+	// Redis ACL user for this tenant's commands; empty uses the proxy's own
+	RedisUsername string
+	RedisPassword string
+
+	// Priority tier under load; empty uses the default tier
+	Tier string
+
+	// Largest value the tenant may write; 0 uses the server-wide limit
+	MaxValueBytes int
+
+	// Longest key the tenant may write to; 0 uses the server-wide limit
+	MaxKeyBytes int
+
+	// Keys the tenant's commands may name must match this; nil allows any
+	KeyPattern *regexp.Regexp
+
+	// Named databases the tenant may use; "*" allows all
+	AllowedDatabases []string
+
+	// Expiry of keys written without one, and the longest allowed; 0 for
+	// none
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
 }
 
 type ResponseType string
@@ -172,4 +2008,43 @@ const (
 	ResponseTypeNil     ResponseType = "nil"
 	ResponseTypeBool    ResponseType = "boolean"
 	ResponseTypeHash    ResponseType = "hash"
-)
\ No newline at end of file
+	ResponseTypeJSON    ResponseType = "json"
+
+	// RESP3 reply types, returned for requests with resp3 set
+	ResponseTypeMap       ResponseType = "map"
+	ResponseTypeSet       ResponseType = "set"
+	ResponseTypeDouble    ResponseType = "double"
+	ResponseTypeBigNumber ResponseType = "big_number"
+	ResponseTypeVerbatim  ResponseType = "verbatim"
+)
+
+// RESP3 replies are returned as these types, so the response type can be
+// told from the result.
+type (
+	// MapReply is a RESP3 map; keys that aren't strings are formatted.
+	MapReply map[string]interface{}
+	// SetReply is a RESP3 set.
+	SetReply []interface{}
+	// DoubleReply is a RESP3 double.
+	DoubleReply float64
+	// BigNumberReply is a RESP3 big number, kept as digits so JSON
+	// clients don't round it.
+	BigNumberReply string
+	// VerbatimReply is a RESP3 verbatim string, without its format.
+	VerbatimReply string
+)
+
+// MarshalJSON writes infinities and NaN, which JSON numbers can't hold, as
+// the strings Redis uses for them.
+func (d DoubleReply) MarshalJSON() ([]byte, error) {
+	f := float64(d)
+	switch {
+	case math.IsInf(f, 1):
+		return []byte(`"inf"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-inf"`), nil
+	case math.IsNaN(f):
+		return []byte(`"nan"`), nil
+	}
+	return strconv.AppendFloat(nil, f, 'g', -1, 64), nil
+}
\ No newline at end of file
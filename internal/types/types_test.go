@@ -135,6 +135,7 @@ func TestResponseTypes(t *testing.T) {
 		{"Nil", ResponseTypeNil, "nil"},
 		{"Boolean", ResponseTypeBool, "boolean"},
 		{"Hash", ResponseTypeHash, "hash"},
+		{"JSON", ResponseTypeJSON, "json"},
 	}
 
 	for _, tt := range tests {
@@ -144,4 +145,75 @@ func TestResponseTypes(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestSetRequestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         SetRequest
+		expectError bool
+	}{
+		{"Plain", SetRequest{Key: "k", Value: "v"}, false},
+		{"NX with EX", SetRequest{Key: "k", Value: "v", NX: true, EX: 10}, false},
+		{"Number value", SetRequest{Key: "k", Value: float64(42)}, false},
+		{"Missing key", SetRequest{Value: "v"}, true},
+		{"Missing value", SetRequest{Key: "k"}, true},
+		{"Object value", SetRequest{Key: "k", Value: map[string]interface{}{"a": 1}}, true},
+		{"NX and XX", SetRequest{Key: "k", Value: "v", NX: true, XX: true}, true},
+		{"EX and PX", SetRequest{Key: "k", Value: "v", EX: 1, PX: 1000}, true},
+		{"EX and KEEPTTL", SetRequest{Key: "k", Value: "v", EX: 1, KeepTTL: true}, true},
+		{"Negative EX", SetRequest{Key: "k", Value: "v", EX: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.expectError && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSetRequestCommand(t *testing.T) {
+	req := SetRequest{Key: "k", Value: "v", DB: 2, XX: true, PX: 1500, Get: true}
+	cmd := req.Command()
+
+	if cmd.Command != "SET" {
+		t.Errorf("Expected command SET, got %s", cmd.Command)
+	}
+
+	if cmd.DB != 2 {
+		t.Errorf("Expected DB 2, got %d", cmd.DB)
+	}
+
+	expected := []interface{}{"k", "v", "XX", "PX", int64(1500), "GET"}
+	if len(cmd.Args) != len(expected) {
+		t.Fatalf("Expected %d args, got %d: %v", len(expected), len(cmd.Args), cmd.Args)
+	}
+	for i := range expected {
+		if cmd.Args[i] != expected[i] {
+			t.Errorf("Arg %d: expected %v, got %v", i, expected[i], cmd.Args[i])
+		}
+	}
+}
+
+func TestVectorFP32(t *testing.T) {
+	item := VectorItem{ID: "a", Vector: []float32{1, 0.5}}
+	encoded := VectorItem{ID: "a", VectorB64: "AACAPwAAAD8="}
+
+	blob, err := item.FP32()
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	decoded, err := encoded.FP32()
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if string(blob) != string(decoded) || len(blob) != 8 {
+		t.Errorf("Expected numbers and base64 to give the same float32s, got %x and %x", blob, decoded)
+	}
+}
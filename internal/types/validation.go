@@ -0,0 +1,607 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// Machine-readable error codes returned in ErrorResponse.Code, so that SDKs
+// can branch on the code instead of matching on messages.
+const (
+	ErrCodeInvalidJSON      = "ERR_INVALID_JSON"
+	ErrCodeEmptyCommand     = "ERR_EMPTY_COMMAND"
+	ErrCodeEmptyPipeline    = "ERR_EMPTY_PIPELINE"
+	ErrCodeTooManyCommands  = "ERR_TOO_MANY_COMMANDS"
+	ErrCodeInvalidDB        = "ERR_INVALID_DB"
+	ErrCodeInvalidArgument  = "ERR_INVALID_ARGUMENT"
+	ErrCodeMissingField     = "ERR_MISSING_FIELD"
+	ErrCodeConflictingField = "ERR_CONFLICTING_FIELDS"
+
+	// Command table
+	ErrCodeUnknownCommand = "ERR_UNKNOWN_COMMAND"
+	ErrCodeWrongArity     = "ERR_WRONG_ARITY"
+
+	// Backend memory pressure
+	ErrCodeOutOfMemory  = "ERR_OOM"
+	ErrCodeWritesPaused = "ERR_WRITES_PAUSED"
+	ErrCodeMaintenance  = "ERR_MAINTENANCE"
+
+	// Backpressure
+	ErrCodePoolExhausted = "ERR_POOL_EXHAUSTED"
+	ErrCodeOverloaded    = "ERR_OVERLOADED"
+
+	// Sharding
+	ErrCodeCrossShard = "ERR_CROSS_SHARD"
+
+	// Value sizes
+	ErrCodeValueTooLarge = "ERR_VALUE_TOO_LARGE"
+	ErrCodeKeyTooLarge   = "ERR_KEY_TOO_LARGE"
+
+	// Key naming policy
+	ErrCodeKeyPolicy = "ERR_KEY_POLICY"
+
+	// Per-tenant key expiry policy
+	ErrCodeTTLPolicy = "ERR_TTL_POLICY"
+
+	// Write-ahead op log
+	ErrCodeOpLogFull    = "ERR_OPLOG_FULL"
+	ErrCodeOpLogPending = "ERR_OPLOG_PENDING"
+
+	// Per-tenant command cost rate limit
+	ErrCodeRateLimited = "ERR_RATE_LIMITED"
+
+	// Deadlines
+	ErrCodeTimeout        = "ERR_TIMEOUT"
+	ErrCodeBudgetExceeded = "ERR_BUDGET_EXCEEDED"
+
+	// Redis modules
+	ErrCodeModuleMissing = "ERR_MODULE_MISSING"
+)
+
+// ValidationError describes why a request failed schema validation.
+type ValidationError struct {
+	Code    string
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// NewValidationError builds a ValidationError with a formatted message.
+func NewValidationError(code, field, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Code: code, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// MaxPipelineParallelism bounds the connections one pipeline may use.
+const MaxPipelineParallelism = 16
+
+// MaxTransactionRetries bounds the retries of a transaction whose watched
+// keys keep changing.
+const MaxTransactionRetries = 10
+
+// ValidationLimits are the server-configured bounds requests are checked
+// against.
+type ValidationLimits struct {
+	MaxCommands int
+	Databases   int
+
+	// NamedDatabases are the configured database names
+	NamedDatabases map[string]bool
+
+	// AllowUnknownCommands passes commands missing from the command table
+	// through to Redis instead of rejecting them
+	AllowUnknownCommands bool
+}
+
+// Validate checks a single command request and coerces its arguments.
+func (r *CommandRequest) Validate(limits ValidationLimits) error {
+	return validateCommand(r, "", limits)
+}
+
+// Validate checks a pipeline request and every command in it.
+func (r *PipelineRequest) Validate(limits ValidationLimits) error {
+	if r.Parallelism < 0 || r.Parallelism > MaxPipelineParallelism {
+		return NewValidationError(ErrCodeInvalidArgument, "parallelism",
+			"parallelism must be between 0 and %d, got %d", MaxPipelineParallelism, r.Parallelism)
+	}
+
+	return validateBatch(r.Commands, r.Database, r.DB, limits)
+}
+
+// Validate checks a transaction request and every command in it.
+func (r *TransactionRequest) Validate(limits ValidationLimits) error {
+	if err := validateBatch(r.Commands, r.Database, r.DB, limits); err != nil {
+		return err
+	}
+
+	for i, key := range r.Watch {
+		if key == "" {
+			return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("watch[%d]", i), "watched key must not be empty")
+		}
+	}
+
+	if r.Retries < 0 || r.Retries > MaxTransactionRetries {
+		return NewValidationError(ErrCodeInvalidArgument, "retries",
+			"retries must be between 0 and %d, got %d", MaxTransactionRetries, r.Retries)
+	}
+	if r.Retries > 0 && len(r.Watch) == 0 {
+		return NewValidationError(ErrCodeMissingField, "watch", "retries need watched keys; without them a transaction is never aborted")
+	}
+
+	if p := r.Precondition; p != nil {
+		if len(r.Watch) == 0 {
+			return NewValidationError(ErrCodeMissingField, "watch", "a precondition needs watched keys, or what it checked may change before EXEC")
+		}
+		if strings.TrimSpace(p.Script) == "" {
+			return NewValidationError(ErrCodeMissingField, "precondition.script", "script is required")
+		}
+		for i, key := range p.Keys {
+			if key == "" {
+				return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("precondition.keys[%d]", i), "key must not be empty")
+			}
+		}
+		args, err := coerceArgs("EVAL", p.Args, "precondition.args")
+		if err != nil {
+			return err
+		}
+		p.Args = args
+	}
+
+	return nil
+}
+
+// Validate checks the keys to watch and the database of a transaction
+// session.
+func (r *TxSessionRequest) Validate(limits ValidationLimits) error {
+	for i, key := range r.Watch {
+		if key == "" {
+			return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("watch[%d]", i), "watched key must not be empty")
+		}
+	}
+	return validateDatabase(r.Database, r.DB, "", limits)
+}
+
+// ValidateTxSessionCommand checks a command sent to a transaction session,
+// which runs on the session's database and connection. Its arguments are
+// coerced like those of any command.
+func ValidateTxSessionCommand(req *CommandRequest, limits ValidationLimits) error {
+	if err := validateCommand(req, "", limits); err != nil {
+		return err
+	}
+	switch {
+	case req.DB != 0 || req.Database != "":
+		return NewValidationError(ErrCodeInvalidArgument, "db", "commands run on the session's database, set when it is opened")
+	case req.RESP3:
+		return NewValidationError(ErrCodeInvalidArgument, "resp3", "resp3 is not supported in transaction sessions")
+	}
+	switch strings.ToUpper(req.Command) {
+	case "EXEC", "DISCARD":
+		return NewValidationError(ErrCodeInvalidArgument, "command", "send %s to the session's /%s endpoint", strings.ToUpper(req.Command), strings.ToLower(req.Command))
+	case "SELECT", "SWAPDB", "AUTH", "HELLO", "RESET", "QUIT", "CLIENT", "MONITOR", "SYNC", "PSYNC",
+		"SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE":
+		return NewValidationError(ErrCodeInvalidArgument, "command", "%s would change the session's connection", strings.ToUpper(req.Command))
+	}
+	return nil
+}
+
+func validateBatch(commands []CommandRequest, database string, db int, limits ValidationLimits) error {
+	if len(commands) == 0 {
+		return NewValidationError(ErrCodeEmptyPipeline, "commands", "at least one command is required")
+	}
+
+	if limits.MaxCommands > 0 && len(commands) > limits.MaxCommands {
+		return NewValidationError(ErrCodeTooManyCommands, "commands",
+			"%d commands exceeds the maximum of %d", len(commands), limits.MaxCommands)
+	}
+
+	if err := validateDatabase(database, db, "", limits); err != nil {
+		return err
+	}
+
+	for i := range commands {
+		if err := validateCommand(&commands[i], fmt.Sprintf("commands[%d].", i), limits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCommand checks cmd, coerces its arguments to the strings Redis
+// is sent and checks them against the command table.
+func validateCommand(cmd *CommandRequest, prefix string, limits ValidationLimits) error {
+	if strings.TrimSpace(cmd.Command) == "" {
+		return NewValidationError(ErrCodeEmptyCommand, prefix+"command", "command must not be empty")
+	}
+
+	if err := validateDatabase(cmd.Database, cmd.DB, prefix, limits); err != nil {
+		return err
+	}
+
+	args, err := coerceArgs(cmd.Command, cmd.Args, prefix+"args")
+	if err != nil {
+		return err
+	}
+	cmd.Args = args
+
+	if cmd.TimeoutMs < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, prefix+"timeout_ms", "timeout_ms must not be negative")
+	}
+
+	switch cmd.HashFormat {
+	case "", HashFormatObject, HashFormatPairs, HashFormatSorted:
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, prefix+"hash_format",
+			"hash_format must be object, pairs or sorted, got %q", cmd.HashFormat)
+	}
+
+	if cmd.MaxItems < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, prefix+"max_items", "max_items must not be negative")
+	}
+
+	return checkSignature(cmd, prefix, limits)
+}
+
+// Validate checks that a multi-get names at least one key and no empty
+// ones.
+func (r *MGetRequest) Validate(limits ValidationLimits) error {
+	if len(r.Keys) == 0 {
+		return NewValidationError(ErrCodeMissingField, "keys", "at least one key is required")
+	}
+	for i, key := range r.Keys {
+		if key == "" {
+			return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("keys[%d]", i), "key must not be empty")
+		}
+	}
+	return validateDatabase(r.Database, r.DB, "", limits)
+}
+
+// Validate checks that a multi-set writes at least one key and only
+// scalar values.
+func (r *MSetRequest) Validate(limits ValidationLimits) error {
+	if len(r.Values) == 0 {
+		return NewValidationError(ErrCodeMissingField, "values", "at least one value is required")
+	}
+	for key, value := range r.Values {
+		if key == "" {
+			return NewValidationError(ErrCodeInvalidArgument, "values", "key must not be empty")
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return NewValidationError(ErrCodeInvalidArgument, "values."+key,
+				"values must be strings, numbers or booleans, got %T", value)
+		}
+	}
+	return validateDatabase(r.Database, r.DB, "", limits)
+}
+
+// Validate checks that a delayed task names a target it can be delivered
+// to and exactly one of delay_ms and deliver_at.
+func (r *DelayRequest) Validate(limits ValidationLimits) error {
+	switch r.Target {
+	case DelayTargetList, DelayTargetStream:
+		if r.Key == "" {
+			return NewValidationError(ErrCodeMissingField, "key", "key is required for a %s target", r.Target)
+		}
+	case DelayTargetWebhook:
+		if r.URL == "" {
+			return NewValidationError(ErrCodeMissingField, "url", "url is required for a webhook target")
+		}
+		if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return NewValidationError(ErrCodeInvalidArgument, "url", "url must be an absolute http or https URL")
+		}
+	case "":
+		return NewValidationError(ErrCodeMissingField, "target", "target is required")
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, "target", "target must be list, stream or webhook, got %q", r.Target)
+	}
+
+	if len(r.Payload) == 0 {
+		return NewValidationError(ErrCodeMissingField, "payload", "payload is required")
+	}
+
+	if r.DelayMs < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, "delay_ms", "delay_ms must not be negative")
+	}
+	if r.DelayMs > 0 && r.DeliverAt != nil {
+		return NewValidationError(ErrCodeConflictingField, "deliver_at", "delay_ms and deliver_at are mutually exclusive")
+	}
+
+	return validateDB(r.DB, "db", limits)
+}
+
+// maxFlagNameLength bounds flag names, which appear in URLs.
+const maxFlagNameLength = 128
+
+// ValidateFlagName checks that name can name a flag: letters, digits, '.',
+// '_' and '-', other than the stream and evaluate endpoints under
+// /v1/flags.
+func ValidateFlagName(name, field string) error {
+	if name == "" {
+		return NewValidationError(ErrCodeMissingField, field, "flag name is required")
+	}
+	if len(name) > maxFlagNameLength {
+		return NewValidationError(ErrCodeInvalidArgument, field, "flag name is longer than %d bytes", maxFlagNameLength)
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return NewValidationError(ErrCodeInvalidArgument, field, "flag name may only contain letters, digits, '.', '_' and '-', got %q", name)
+		}
+	}
+	if name == "stream" || name == "evaluate" {
+		return NewValidationError(ErrCodeInvalidArgument, field, "%q is reserved", name)
+	}
+	return nil
+}
+
+// Validate checks that a flag's rollout is a percentage and its subjects
+// aren't empty.
+func (r *FlagRequest) Validate() error {
+	if r.Rollout != nil && (*r.Rollout < 0 || *r.Rollout > 100) {
+		return NewValidationError(ErrCodeInvalidArgument, "rollout", "rollout must be between 0 and 100, got %d", *r.Rollout)
+	}
+	for i, subject := range r.Subjects {
+		if subject == "" {
+			return NewValidationError(ErrCodeInvalidArgument, fmt.Sprintf("subjects[%d]", i), "subjects must not be empty")
+		}
+	}
+	return nil
+}
+
+// Validate checks the names of the flags to evaluate.
+func (r *FlagEvaluationRequest) Validate() error {
+	for i, name := range r.Flags {
+		if err := ValidateFlagName(name, fmt.Sprintf("flags[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that a HyperLogLog add has elements.
+func (r *HLLAddRequest) Validate(limits ValidationLimits) error {
+	if len(r.Elements) == 0 {
+		return NewValidationError(ErrCodeMissingField, "elements", "at least one element is required")
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// Validate checks that a Bloom filter request has items.
+func (r *BloomRequest) Validate(limits ValidationLimits) error {
+	if len(r.Items) == 0 {
+		return NewValidationError(ErrCodeMissingField, "items", "at least one item is required")
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// maxGeoLat is the furthest latitude from the equator Redis can index.
+const maxGeoLat = 85.05112878
+
+func validateGeoPoint(lat, lon float64, field string) error {
+	if lat < -maxGeoLat || lat > maxGeoLat {
+		return NewValidationError(ErrCodeInvalidArgument, field+".lat", "lat must be between -%v and %v", maxGeoLat, maxGeoLat)
+	}
+	if lon < -180 || lon > 180 {
+		return NewValidationError(ErrCodeInvalidArgument, field+".lon", "lon must be between -180 and 180")
+	}
+	return nil
+}
+
+// Validate checks that a geo add has named members at valid positions.
+func (r *GeoAddRequest) Validate(limits ValidationLimits) error {
+	if len(r.Members) == 0 {
+		return NewValidationError(ErrCodeMissingField, "members", "at least one member is required")
+	}
+	for i, member := range r.Members {
+		field := fmt.Sprintf("members[%d]", i)
+		if member.Name == "" {
+			return NewValidationError(ErrCodeMissingField, field+".name", "name is required")
+		}
+		if err := validateGeoPoint(member.Lat, member.Lon, field); err != nil {
+			return err
+		}
+	}
+	if r.NX && r.XX {
+		return NewValidationError(ErrCodeConflictingField, "nx", "nx and xx are mutually exclusive")
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// Validate checks that a geo search has one center and one shape.
+func (r *GeoSearchRequest) Validate(limits ValidationLimits) error {
+	switch {
+	case r.Member == "" && r.Center == nil:
+		return NewValidationError(ErrCodeMissingField, "center", "one of member and center is required")
+	case r.Member != "" && r.Center != nil:
+		return NewValidationError(ErrCodeConflictingField, "center", "member and center are mutually exclusive")
+	case r.Center != nil:
+		if err := validateGeoPoint(r.Center.Lat, r.Center.Lon, "center"); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case r.Radius == 0 && r.Box == nil:
+		return NewValidationError(ErrCodeMissingField, "radius", "one of radius and box is required")
+	case r.Radius != 0 && r.Box != nil:
+		return NewValidationError(ErrCodeConflictingField, "box", "radius and box are mutually exclusive")
+	case r.Radius < 0:
+		return NewValidationError(ErrCodeInvalidArgument, "radius", "radius must be positive")
+	case r.Box != nil && (r.Box.Width <= 0 || r.Box.Height <= 0):
+		return NewValidationError(ErrCodeInvalidArgument, "box", "box width and height must be positive")
+	}
+
+	switch r.Unit {
+	case "", "m", "km", "mi", "ft":
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, "unit", "unit must be m, km, mi or ft, got %q", r.Unit)
+	}
+	switch r.Order {
+	case "", "asc", "desc":
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, "order", "order must be asc or desc, got %q", r.Order)
+	}
+	if r.Count < 0 {
+		return NewValidationError(ErrCodeInvalidArgument, "count", "count must not be negative")
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// MaxVectorK bounds how many matches a vector search returns.
+const MaxVectorK = 1000
+
+// vectorFP32 returns a vector given as numbers or as base64 as the
+// little-endian float32s VADD and VSIM take with FP32.
+func vectorFP32(values []float32, encoded, prefix string) ([]byte, error) {
+	switch {
+	case len(values) > 0 && encoded != "":
+		return nil, NewValidationError(ErrCodeConflictingField, prefix+"vector_b64", "vector and vector_b64 are mutually exclusive")
+	case encoded != "":
+		blob, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(blob)%4 != 0 {
+			return nil, NewValidationError(ErrCodeInvalidArgument, prefix+"vector_b64", "vector_b64 must be base64 of little-endian float32s")
+		}
+		return blob, nil
+	}
+
+	blob := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(value))
+	}
+	return blob, nil
+}
+
+// FP32 returns the item's vector as little-endian float32s.
+func (i *VectorItem) FP32() ([]byte, error) {
+	return vectorFP32(i.Vector, i.VectorB64, "")
+}
+
+// FP32 returns the query vector as little-endian float32s.
+func (r *VectorSearchRequest) FP32() ([]byte, error) {
+	return vectorFP32(r.Vector, r.VectorB64, "")
+}
+
+// Validate checks that every item has an ID and a vector, all of one
+// dimension.
+func (r *VectorUpsertRequest) Validate(limits ValidationLimits) error {
+	if len(r.Items) == 0 {
+		return NewValidationError(ErrCodeMissingField, "items", "at least one item is required")
+	}
+	if limits.MaxCommands > 0 && len(r.Items) > limits.MaxCommands {
+		return NewValidationError(ErrCodeTooManyCommands, "items", "%d items exceeds the maximum of %d", len(r.Items), limits.MaxCommands)
+	}
+
+	dimension := 0
+	for i := range r.Items {
+		item := &r.Items[i]
+		field := fmt.Sprintf("items[%d]", i)
+		if item.ID == "" {
+			return NewValidationError(ErrCodeMissingField, field+".id", "id is required")
+		}
+
+		blob, err := vectorFP32(item.Vector, item.VectorB64, field+".")
+		if err != nil {
+			return err
+		}
+		if len(blob) == 0 {
+			return NewValidationError(ErrCodeMissingField, field+".vector", "vector or vector_b64 is required")
+		}
+		if dimension == 0 {
+			dimension = len(blob) / 4
+		} else if len(blob)/4 != dimension {
+			return NewValidationError(ErrCodeInvalidArgument, field+".vector", "vector has %d dimensions, expected %d", len(blob)/4, dimension)
+		}
+
+		var attributes map[string]interface{}
+		if len(item.Attributes) > 0 && json.Unmarshal(item.Attributes, &attributes) != nil {
+			return NewValidationError(ErrCodeInvalidArgument, field+".attributes", "attributes must be a JSON object")
+		}
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// Validate checks that a vector search has one query and a K in range.
+func (r *VectorSearchRequest) Validate(limits ValidationLimits) error {
+	blob, err := r.FP32()
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(blob) == 0 && r.ID == "":
+		return NewValidationError(ErrCodeMissingField, "vector", "one of vector, vector_b64 and id is required")
+	case len(blob) > 0 && r.ID != "":
+		return NewValidationError(ErrCodeConflictingField, "id", "id and vector are mutually exclusive")
+	}
+
+	if r.K < 0 || r.K > MaxVectorK {
+		return NewValidationError(ErrCodeInvalidArgument, "k", "k must be between 1 and %d", MaxVectorK)
+	}
+	return validateDB(r.DB, "db", limits)
+}
+
+// Validate checks a maintenance mode switch.
+func (m *MaintenanceMode) Validate() error {
+	switch m.Mode {
+	case MaintenanceOff, MaintenanceReadOnly, MaintenanceFull:
+	case "":
+		return NewValidationError(ErrCodeMissingField, "mode", "mode is required")
+	default:
+		return NewValidationError(ErrCodeInvalidArgument, "mode", "mode must be off, read_only or full, got %q", m.Mode)
+	}
+	return nil
+}
+
+// Validate checks that a recording switch says which way.
+func (r *RecordingRequest) Validate() error {
+	if r.Enabled == nil {
+		return NewValidationError(ErrCodeMissingField, "enabled", "enabled is required")
+	}
+	return nil
+}
+
+// Validate checks that a cache purge names exactly one target.
+func (r *CachePurgeRequest) Validate() error {
+	targets := 0
+	for _, set := range []bool{r.Key != "", r.TenantID != "", r.All} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return NewValidationError(ErrCodeConflictingField, "", "exactly one of key, tenant_id or all is required")
+	}
+	return nil
+}
+
+// validateDatabase checks a database given by name, or by number if name
+// is empty.
+func validateDatabase(name string, db int, prefix string, limits ValidationLimits) error {
+	if name == "" {
+		return validateDB(db, prefix+"db", limits)
+	}
+	if db != 0 {
+		return NewValidationError(ErrCodeConflictingField, prefix+"database", "database and db are mutually exclusive")
+	}
+	if !limits.NamedDatabases[name] {
+		return NewValidationError(ErrCodeInvalidDB, prefix+"database", "database %q is not configured", name)
+	}
+	return nil
+}
+
+func validateDB(db int, field string, limits ValidationLimits) error {
+	if db < 0 || (limits.Databases > 0 && db >= limits.Databases) {
+		return NewValidationError(ErrCodeInvalidDB, field, "database %d is out of range", db)
+	}
+	return nil
+}
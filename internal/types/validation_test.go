@@ -0,0 +1,333 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequestValidation(t *testing.T) {
+	limits := ValidationLimits{MaxCommands: 2, Databases: 16, NamedDatabases: map[string]bool{"sessions": true}}
+
+	tests := []struct {
+		name     string
+		validate func() error
+		code     string
+		field    string
+	}{
+		{"Valid command", func() error {
+			req := CommandRequest{Command: "SET", Args: []interface{}{"k", float64(1), true}}
+			return req.Validate(limits)
+		}, "", ""},
+		{"Empty command", func() error {
+			req := CommandRequest{Command: "  "}
+			return req.Validate(limits)
+		}, ErrCodeEmptyCommand, "command"},
+		{"Negative DB", func() error {
+			req := CommandRequest{Command: "GET", DB: -1}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "db"},
+		{"DB out of range", func() error {
+			req := CommandRequest{Command: "GET", DB: 16}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "db"},
+		{"Named database", func() error {
+			req := CommandRequest{Command: "GET", Args: []interface{}{"k"}, Database: "sessions"}
+			return req.Validate(limits)
+		}, "", ""},
+		{"Unknown named database", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET"}}, Database: "cache"}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "database"},
+		{"Named database with db", func() error {
+			req := CommandRequest{Command: "GET", Database: "sessions", DB: 2}
+			return req.Validate(limits)
+		}, ErrCodeConflictingField, "database"},
+		{"Nested argument", func() error {
+			req := CommandRequest{Command: "SET", Args: []interface{}{"k", map[string]interface{}{}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[1]"},
+		{"Null argument", func() error {
+			req := CommandRequest{Command: "SET", Args: []interface{}{"k", nil}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[1]"},
+		{"Doubly nested argument", func() error {
+			req := CommandRequest{Command: "DEL", Args: []interface{}{[]interface{}{"a", []interface{}{"b"}}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[0][1]"},
+		{"Fraction for an integer argument", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET", Args: []interface{}{"k"}}, {Command: "expire", Args: []interface{}{"k", 1.5}}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "commands[1].args[1]"},
+		{"Integer past 2^53", func() error {
+			req := CommandRequest{Command: "SET", Args: []interface{}{"k", float64(1 << 60)}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[1]"},
+		{"Boolean for a float argument", func() error {
+			req := CommandRequest{Command: "INCRBYFLOAT", Args: []interface{}{"k", true}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[1]"},
+		{"Null value in an object", func() error {
+			req := CommandRequest{Command: "HSET", Args: []interface{}{"h", map[string]interface{}{"name": nil}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[1].name"},
+		{"Unknown command", func() error {
+			req := CommandRequest{Command: "GETT", Args: []interface{}{"k"}}
+			return req.Validate(limits)
+		}, ErrCodeUnknownCommand, "command"},
+		{"Unknown command allowed", func() error {
+			req := CommandRequest{Command: "CF.ADD", Args: []interface{}{"filter", "item"}}
+			return req.Validate(ValidationLimits{AllowUnknownCommands: true})
+		}, "", ""},
+		{"Too few arguments", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET", Args: []interface{}{"k"}}, {Command: "hset", Args: []interface{}{"h", "f"}}}}
+			return req.Validate(limits)
+		}, ErrCodeWrongArity, "commands[1].args"},
+		{"Too many arguments", func() error {
+			req := CommandRequest{Command: "GET", Args: []interface{}{"a", "b"}}
+			return req.Validate(limits)
+		}, ErrCodeWrongArity, "args"},
+		{"Key without a value", func() error {
+			req := CommandRequest{Command: "MSET", Args: []interface{}{"a", "1", "b"}}
+			return req.Validate(limits)
+		}, ErrCodeWrongArity, "args"},
+		{"Arguments counted after flattening", func() error {
+			req := CommandRequest{Command: "MSET", Args: []interface{}{[]interface{}{"a", "1", "b", "2"}}}
+			return req.Validate(limits)
+		}, "", ""},
+		{"More keys than arguments", func() error {
+			req := CommandRequest{Command: "EVAL", Args: []interface{}{"return 1", float64(2), "k"}}
+			return req.Validate(limits)
+		}, ErrCodeWrongArity, "args"},
+		{"Number of keys not a number", func() error {
+			req := CommandRequest{Command: "ZUNION", Args: []interface{}{"a", "b"}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "args[0]"},
+		{"Unknown hash format", func() error {
+			req := CommandRequest{Command: "HGETALL", Args: []interface{}{"h"}, HashFormat: "list"}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "hash_format"},
+		{"Negative timeout", func() error {
+			req := CommandRequest{Command: "GET", Args: []interface{}{"k"}, TimeoutMs: -1}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "timeout_ms"},
+		{"Multi-get without keys", func() error {
+			req := MGetRequest{}
+			return req.Validate(limits)
+		}, ErrCodeMissingField, "keys"},
+		{"Multi-get with an empty key", func() error {
+			req := MGetRequest{Keys: []string{"a", ""}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "keys[1]"},
+		{"Multi-set with a nested value", func() error {
+			req := MSetRequest{Values: map[string]interface{}{"a": "1", "b": []interface{}{}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "values.b"},
+		{"Multi-set with a null value", func() error {
+			req := MSetRequest{Values: map[string]interface{}{"a": nil}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "values.a"},
+		{"Multi-set out of range DB", func() error {
+			req := MSetRequest{Values: map[string]interface{}{"a": "1"}, DB: 16}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "db"},
+		{"Delayed task to a list", func() error {
+			req := DelayRequest{Target: DelayTargetList, Key: "jobs", Payload: []byte(`{"job": 1}`), DelayMs: 5000}
+			return req.Validate(limits)
+		}, "", ""},
+		{"Delayed task without a key", func() error {
+			req := DelayRequest{Target: DelayTargetStream, Payload: []byte(`"x"`)}
+			return req.Validate(limits)
+		}, ErrCodeMissingField, "key"},
+		{"Delayed task to a relative URL", func() error {
+			req := DelayRequest{Target: DelayTargetWebhook, URL: "/hook", Payload: []byte(`"x"`)}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "url"},
+		{"Delayed task with delay_ms and deliver_at", func() error {
+			at := time.Now()
+			req := DelayRequest{Target: DelayTargetList, Key: "jobs", Payload: []byte(`"x"`), DelayMs: 1, DeliverAt: &at}
+			return req.Validate(limits)
+		}, ErrCodeConflictingField, "deliver_at"},
+		{"Flag with an out of range rollout", func() error {
+			rollout := 101
+			req := FlagRequest{Enabled: true, Rollout: &rollout}
+			return req.Validate()
+		}, ErrCodeInvalidArgument, "rollout"},
+		{"Flag evaluation of a reserved name", func() error {
+			req := FlagEvaluationRequest{Subject: "user-1", Flags: []string{"new-checkout", "stream"}}
+			return req.Validate()
+		}, ErrCodeInvalidArgument, "flags[1]"},
+		{"Flag name with a slash", func() error {
+			return ValidateFlagName("a/b", "name")
+		}, ErrCodeInvalidArgument, "name"},
+		{"HyperLogLog add without elements", func() error {
+			req := HLLAddRequest{}
+			return req.Validate(limits)
+		}, ErrCodeMissingField, "elements"},
+		{"Bloom filter check out of range DB", func() error {
+			req := BloomRequest{Items: []string{"a"}, DB: 16}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "db"},
+		{"Geo add with an out of range latitude", func() error {
+			req := GeoAddRequest{Members: []GeoMember{{Name: "a", Lat: 10, Lon: 20}, {Name: "b", Lat: 89, Lon: 0}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "members[1].lat"},
+		{"Geo search by radius around a point", func() error {
+			req := GeoSearchRequest{Center: &GeoPoint{Lat: 52.5, Lon: 13.4}, Radius: 5, Unit: "km", Count: 10}
+			return req.Validate(limits)
+		}, "", ""},
+		{"Geo search with a radius and a box", func() error {
+			req := GeoSearchRequest{Member: "a", Radius: 5, Box: &GeoBox{Width: 1, Height: 1}}
+			return req.Validate(limits)
+		}, ErrCodeConflictingField, "box"},
+		{"Geo search in an unknown unit", func() error {
+			req := GeoSearchRequest{Member: "a", Radius: 5, Unit: "yd"}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "unit"},
+		{"Vector upsert of mixed dimensions", func() error {
+			req := VectorUpsertRequest{Items: []VectorItem{{ID: "a", Vector: []float32{1, 0}}, {ID: "b", VectorB64: "AACAPwAAAAAAAAAA"}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "items[1].vector"},
+		{"Vector upsert with truncated base64", func() error {
+			req := VectorUpsertRequest{Items: []VectorItem{{ID: "a", VectorB64: "AACAPwA="}}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "items[0].vector_b64"},
+		{"Vector search by vector and id", func() error {
+			req := VectorSearchRequest{Vector: []float32{1, 0}, ID: "a"}
+			return req.Validate(limits)
+		}, ErrCodeConflictingField, "id"},
+		{"Unknown maintenance mode", func() error {
+			req := MaintenanceMode{Mode: "partial"}
+			return req.Validate()
+		}, ErrCodeInvalidArgument, "mode"},
+		{"Recording switch without enabled", func() error {
+			req := RecordingRequest{}
+			return req.Validate()
+		}, ErrCodeMissingField, "enabled"},
+		{"Cache purge with two targets", func() error {
+			req := CachePurgeRequest{TenantID: "acme", All: true}
+			return req.Validate()
+		}, ErrCodeConflictingField, ""},
+		{"Negative max payload", func() error {
+			req := NegotiateRequest{MaxPayloadBytes: -1}
+			return req.Validate()
+		}, ErrCodeInvalidArgument, "max_payload_bytes"},
+		{"Empty pipeline", func() error {
+			req := PipelineRequest{}
+			return req.Validate(limits)
+		}, ErrCodeEmptyPipeline, "commands"},
+		{"Too many commands", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET"}, {Command: "GET"}, {Command: "GET"}}}
+			return req.Validate(limits)
+		}, ErrCodeTooManyCommands, "commands"},
+		{"Empty command in pipeline", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET", Args: []interface{}{"k"}}, {Command: ""}}}
+			return req.Validate(limits)
+		}, ErrCodeEmptyCommand, "commands[1].command"},
+		{"Negative max items", func() error {
+			req := CommandRequest{Command: "LRANGE", MaxItems: -1}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "max_items"},
+		{"Pipeline parallelism too high", func() error {
+			req := PipelineRequest{Commands: []CommandRequest{{Command: "GET"}}, Parallelism: MaxPipelineParallelism + 1}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "parallelism"},
+		{"Empty watch key", func() error {
+			req := TransactionRequest{Commands: []CommandRequest{{Command: "INCR", Args: []interface{}{"k"}}}, Watch: []string{""}}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "watch[0]"},
+		{"Transaction retries without watched keys", func() error {
+			req := TransactionRequest{Commands: []CommandRequest{{Command: "INCR", Args: []interface{}{"k"}}}, Retries: 3}
+			return req.Validate(limits)
+		}, ErrCodeMissingField, "watch"},
+		{"Transaction retries too many", func() error {
+			req := TransactionRequest{Commands: []CommandRequest{{Command: "INCR", Args: []interface{}{"k"}}}, Watch: []string{"k"}, Retries: MaxTransactionRetries + 1}
+			return req.Validate(limits)
+		}, ErrCodeInvalidArgument, "retries"},
+		{"Transaction precondition without a script", func() error {
+			req := TransactionRequest{Commands: []CommandRequest{{Command: "INCR", Args: []interface{}{"k"}}}, Watch: []string{"k"}, Precondition: &TransactionPrecondition{Keys: []string{"k"}}}
+			return req.Validate(limits)
+		}, ErrCodeMissingField, "precondition.script"},
+		{"Session with an unknown database", func() error {
+			req := TxSessionRequest{Watch: []string{"balance"}, Database: "cache"}
+			return req.Validate(limits)
+		}, ErrCodeInvalidDB, "database"},
+		{"Session command changing the database", func() error {
+			return ValidateTxSessionCommand(&CommandRequest{Command: "select", Args: []interface{}{float64(1)}}, limits)
+		}, ErrCodeInvalidArgument, "command"},
+		{"Session command sending EXEC", func() error {
+			return ValidateTxSessionCommand(&CommandRequest{Command: "EXEC"}, limits)
+		}, ErrCodeInvalidArgument, "command"},
+		{"Session command on another database", func() error {
+			return ValidateTxSessionCommand(&CommandRequest{Command: "GET", Args: []interface{}{"k"}, DB: 2}, limits)
+		}, ErrCodeInvalidArgument, "db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate()
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+
+			if validationErr.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, validationErr.Code)
+			}
+
+			if validationErr.Field != tt.field {
+				t.Errorf("Expected field %s, got %s", tt.field, validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestArgCoercion(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CommandRequest
+		want []interface{}
+	}{
+		{"Strings are kept", CommandRequest{Command: "SET", Args: []interface{}{"k", "1e6"}},
+			[]interface{}{"k", "1e6"}},
+		{"Whole numbers without an exponent", CommandRequest{Command: "SET", Args: []interface{}{"k", float64(1e6), float64(1 << 53), int64(-7)}},
+			[]interface{}{"k", "1000000", "9007199254740992", "-7"}},
+		{"Fractions and tiny numbers", CommandRequest{Command: "SET", Args: []interface{}{"k", 1.5, 1e-7}},
+			[]interface{}{"k", "1.5", "0.0000001"}},
+		{"Booleans as 1 and 0", CommandRequest{Command: "SETBIT", Args: []interface{}{"bits", float64(7), true}},
+			[]interface{}{"bits", "7", "1"}},
+		{"Floats past 2^53 where numbers are taken", CommandRequest{Command: "ZCOUNT", Args: []interface{}{"z", float64(-1e20), "+inf"}},
+			[]interface{}{"z", "-100000000000000000000", "+inf"}},
+		{"Arrays are flattened", CommandRequest{Command: "DEL", Args: []interface{}{"a", []interface{}{"b", float64(3)}}},
+			[]interface{}{"a", "b", "3"}},
+		{"Positions count flattened arguments", CommandRequest{Command: "LRANGE", Args: []interface{}{[]interface{}{"list", float64(0)}, float64(-1)}},
+			[]interface{}{"list", "0", "-1"}},
+		{"Objects as sorted pairs", CommandRequest{Command: "hset", Args: []interface{}{"h", map[string]interface{}{"plan": "pro", "age": float64(36), "admin": false}}},
+			[]interface{}{"h", "admin", "0", "age", "36", "plan", "pro"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(ValidationLimits{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(tt.req.Args, tt.want) {
+				t.Errorf("Expected args %v, got %v", tt.want, tt.req.Args)
+			}
+		})
+	}
+
+	// Objects are only taken by commands taking field/value pairs
+	req := CommandRequest{Command: "SADD", Args: []interface{}{"s", map[string]interface{}{"a": "b"}}}
+	if err := req.Validate(ValidationLimits{}); err == nil {
+		t.Error("Expected an object to be rejected for SADD")
+	}
+}
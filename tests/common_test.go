@@ -218,7 +218,7 @@ func NewTestServer() *TestServer {
 	authManager := auth.NewManager(authConfig)
 	// Use a mock metrics collector to avoid Prometheus registration conflicts in tests
 	metricsCollector := &MockMetricsCollector{}
-	cache := server.NewInMemoryCache(100)
+	cache := server.NewInMemoryCache(1<<20, 0)
 
 	ts := &TestServer{
 		redisClient: redisClient,
@@ -237,7 +237,7 @@ func (ts *TestServer) setupRoutes() {
 	// Add middleware stack
 	router.Use(server.KeepAliveMiddleware)
 	router.Use(server.ContentEncodingMiddleware)
-	router.Use(server.CachingMiddleware(ts.cache))
+	router.Use(server.CachingMiddleware(ts.cache, false))
 
 	// API routes
 	api := router.PathPrefix("/v1").Subrouter()